@@ -8,6 +8,9 @@ TIKA_PORT=9998
 # Ensure the HOME directory exists for snap_daemon
 mkdir -p "${HOME}"
 
+# Tika is not ready until it answers again; the CLI and ragd record it then
+snapctl unset readiness.tika || true
+
 # Start Tika server as snap_daemon
 exec "${SNAP}"/usr/bin/setpriv \
     --clear-groups \
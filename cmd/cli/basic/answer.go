@@ -8,9 +8,10 @@ import (
 
 	"github.com/charmbracelet/huh"
 	"github.com/jpnorenam/rag-snap/cmd/cli/basic/chat"
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
 	"github.com/jpnorenam/rag-snap/cmd/cli/basic/rfp"
 	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/processing"
 	"github.com/spf13/cobra"
 )
 
@@ -86,10 +87,18 @@ func (cmd *answerCommand) batchCommand() *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("getting server API URLs: %w", err)
 			}
-			knowledgeClient, _ := knowledge.NewClient(apiUrls[opensearch])
+			settings, err := chatSettings(cmd.Context)
+			if err != nil {
+				return err
+			}
+			kSettings, err := knowledgeSettings(cmd.Context)
+			if err != nil {
+				return err
+			}
+			knowledgeClient, _ := knowledge.NewClient(apiUrls[opensearch], kSettings)
 			embeddingModelID, _ := getConfigString(cmd.Context, knowledge.ConfEmbeddingModelID)
 			kapaClient := buildKapaClient(cmd.Context)
-			return chat.ProcessBatchChat(apiUrls[openAi], knowledgeClient, kapaClient, embeddingModelID, manifest, chat.LoadPrompts(), temperature, cmd.Verbose)
+			return chat.ProcessBatchChat(apiUrls[openAi], knowledgeClient, kapaClient, embeddingModelID, manifest, chat.LoadPrompts(), temperature, settings, cmd.Verbose)
 		},
 	}
 
@@ -129,15 +138,15 @@ func (cmd *answerCommand) runBuild(docPath, outputPath string, previewOnly, noRe
 		questions, extractErr = rfpExtractCSV(docPath)
 
 	case "xlsx":
-		var tikaURL string
-		if tikaURL, extractErr = rfpTikaURL(cmd.Context); extractErr == nil {
-			questions, extractErr = rfpExtractXLSX(docPath, tikaURL)
+		var tikaClient *processing.TikaClient
+		if tikaClient, extractErr = rfpTikaClient(cmd.Context); extractErr == nil {
+			questions, extractErr = rfpExtractXLSX(docPath, tikaClient)
 		}
 
 	case "pdf", "docx":
-		var tikaURL string
-		if tikaURL, extractErr = rfpTikaURL(cmd.Context); extractErr == nil {
-			questions, extractErr = rfpExtractText(docPath, tikaURL)
+		var tikaClient *processing.TikaClient
+		if tikaClient, extractErr = rfpTikaClient(cmd.Context); extractErr == nil {
+			questions, extractErr = rfpExtractText(docPath, tikaClient)
 		}
 	}
 
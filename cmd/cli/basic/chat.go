@@ -1,11 +1,16 @@
 package basic
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/jpnorenam/rag-snap/cmd/cli/basic/chat"
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
 	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/cmd/cli/config"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
 	"github.com/spf13/cobra"
 )
 
@@ -13,6 +18,7 @@ type chatCommand struct {
 	*common.Context
 	temperature float64
 	prompt      string
+	listen      string
 }
 
 func ChatCommand(ctx *common.Context) *cobra.Command {
@@ -20,10 +26,13 @@ func ChatCommand(ctx *common.Context) *cobra.Command {
 	cmd.Context = ctx
 
 	cobraCmd := &cobra.Command{
-		Use:               "chat",
-		Aliases:           []string{"c"},
-		Short:             "Start the chat CLI",
-		Long:              "Chat with the server via its OpenAI API.\nThis CLI supports text-based prompting only.",
+		Use:     "chat",
+		Aliases: []string{"c"},
+		Short:   "Start the chat CLI",
+		Long: "Chat with the server via its OpenAI API.\nThis CLI supports text-based prompting only.\n\n" +
+			"With --listen <addr>, no REPL is started: instead, chat sessions are served over a\n" +
+			"local websocket at ws://<addr>" + chat.ListenPath + ", streaming each answer's tokens and the\n" +
+			"sources it cites, so a local web frontend can reuse the RAG pipeline.",
 		GroupID:           groupID,
 		Args:              cobra.MaximumNArgs(1),
 		ValidArgsFunction: cobra.NoFileCompletions,
@@ -32,11 +41,37 @@ func ChatCommand(ctx *common.Context) *cobra.Command {
 
 	cobraCmd.Flags().Float64Var(&cmd.temperature, "temperature", 0.3, "Sampling temperature (0.0–1.0); lower = more deterministic")
 	cobraCmd.Flags().StringVar(&cmd.prompt, "prompt", "", "Name of a chat_system_prompt variant to use for this session (requires the ragd daemon)")
+	cobraCmd.Flags().StringVar(&cmd.listen, "listen", "", "Serve chat sessions over a websocket on this loopback address (e.g. localhost:8765) instead of starting the REPL")
 	addDebugFlags(cobraCmd, ctx)
 
+	cobraCmd.AddCommand(cmd.exportCommand())
+
 	return cobraCmd
 }
 
+func (cmd *chatCommand) exportCommand() *cobra.Command {
+	var id string
+
+	c := &cobra.Command{
+		Use:   "export <file.md|file.html>",
+		Short: "Export a saved chat to Markdown or HTML",
+		Long: "Render a saved conversation (prompts, answers, cited sources, and timestamps) to a file " +
+			"for archiving or sharing. The format follows the file extension: .md for Markdown, .html for HTML.\n\n" +
+			"Without --id, pick the chat from the saved chats list.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if dc := daemonClient(cmd.Context); dc != nil {
+				return chat.ExportRemoteChat(context.Background(), dc, id, args[0])
+			}
+			return chat.ExportSavedChat(id, args[0])
+		},
+	}
+
+	c.Flags().StringVar(&id, "id", "", "ID of the saved chat to export (default: choose interactively)")
+
+	return c
+}
+
 func (cmd *chatCommand) run(_ *cobra.Command, args []string) error {
 	var llmModelName string
 	if len(args) > 0 {
@@ -46,9 +81,27 @@ func (cmd *chatCommand) run(_ *cobra.Command, args []string) error {
 		llmModelName, _ = getConfigString(cmd.Context, confChatModel)
 	}
 
+	// The prompt history is the REPL's own, over the daemon or not.
+	history, err := chat.ParseHistorySettings(config.Lookup(cmd.Config))
+	if err != nil {
+		return err
+	}
+
+	if cmd.listen != "" {
+		if err := chat.CheckListenAddr(cmd.listen); err != nil {
+			return err
+		}
+		if daemonClient(cmd.Context) != nil {
+			return fmt.Errorf("--listen is not supported over the ragd daemon; the daemon serves chat sessions over its own API (POST /1.0/chat)")
+		}
+		if cmd.prompt != "" {
+			return fmt.Errorf("--prompt selects a stored prompt variant, which requires the ragd daemon; start it and retry")
+		}
+	}
+
 	// Prefer a running daemon: it owns the session, backends, and secrets.
 	if dc := daemonClient(cmd.Context); dc != nil {
-		return chat.RemoteClient(dc, llmModelName, nil, cmd.temperature, cmd.prompt)
+		return chat.RemoteClient(dc, llmModelName, nil, cmd.temperature, cmd.prompt, history)
 	}
 
 	// Named prompt variants live in the daemon; a daemonless run cannot resolve
@@ -62,8 +115,15 @@ func (cmd *chatCommand) run(_ *cobra.Command, args []string) error {
 		return fmt.Errorf("error getting server api urls: %w", err)
 	}
 
-	knowledgeClient, err := knowledge.NewClient(apiUrls[opensearch])
-
+	settings, err := chatSettings(cmd.Context)
+	if err != nil {
+		return err
+	}
+	kSettings, err := knowledgeSettings(cmd.Context)
+	if err != nil {
+		return err
+	}
+	knowledgeClient, err := knowledge.NewClient(apiUrls[opensearch], kSettings)
 	if err != nil {
 		if cmd.Verbose {
 			fmt.Printf("Knowledge base not available: %v\n", err)
@@ -72,7 +132,22 @@ func (cmd *chatCommand) run(_ *cobra.Command, args []string) error {
 
 	embeddingModelID, _ := getConfigString(cmd.Context, knowledge.ConfEmbeddingModelID)
 
+	if cmd.listen != "" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return chat.Listen(ctx, cmd.listen, chat.ListenOptions{
+			BaseURL:          apiUrls[openAi],
+			Model:            llmModelName,
+			KnowledgeClient:  knowledgeClient,
+			EmbeddingModelID: embeddingModelID,
+			SystemPrompt:     chat.LoadPrompts().ChatSystemPrompt,
+			Temperature:      cmd.temperature,
+			Settings:         settings,
+			Verbose:          cmd.Verbose,
+		})
+	}
+
 	kapaClient := buildKapaClient(cmd.Context)
 
-	return chat.Client(apiUrls[openAi], knowledgeClient, kapaClient, embeddingModelID, llmModelName, chat.LoadPrompts(), cmd.temperature, cmd.Verbose)
+	return chat.Client(apiUrls[openAi], knowledgeClient, apiUrls[opensearch], kSettings, kapaClient, embeddingModelID, llmModelName, chat.LoadPrompts(), cmd.temperature, settings, history, cmd.Verbose)
 }
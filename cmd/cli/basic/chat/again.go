@@ -0,0 +1,75 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// cmdAgain runs the previous prompt again, or an edited one in its place.
+const cmdAgain = "/again"
+
+// lastTurn is what /again needs of the most recently answered prompt.
+type lastTurn struct {
+	prompt string
+	// lexicalQuery is the rewritten query retrieval ran with; empty when the
+	// prompt was answered without retrieval.
+	lexicalQuery string
+	// before and after are the history lengths before and after the turn, so
+	// /again can tell whether the turn is still the last one in the history.
+	before, after int
+}
+
+// handleAgain runs the retrieval and answer loop again for the previous
+// prompt, or for query in its place, replacing the previous turn in the
+// history when it is still the last one there. The answer is generated afresh,
+// bypassing the response cache, and the rewritten lexical query is compared
+// with the one the previous run used.
+func handleAgain(client openai.Client, params openai.ChatCompletionNewParams, query string, session *Session, verbose bool) (openai.ChatCompletionNewParams, error) {
+	last := session.lastTurn
+	if last == nil {
+		fmt.Printf("Nothing to run again yet. Usage: %s [new query]\n", cmdAgain)
+		return params, nil
+	}
+
+	prompt := last.prompt
+	if query = strings.TrimSpace(query); query != "" {
+		expanded, ok := expandAttachments(query)
+		if !ok {
+			return params, nil
+		}
+		prompt = expanded
+	}
+
+	params.Messages = session.rewindLastTurn(params.Messages)
+	return answerPrompt(client, params, prompt, true, last, session, verbose)
+}
+
+// rewindLastTurn drops the last turn from messages and from the session's
+// exchanges, if it is still the last one in the history. A history that moved
+// on since (e.g. a /recall added to it) is returned as it is, and the turn run
+// again is added after it.
+func (s *Session) rewindLastTurn(messages []openai.ChatCompletionMessageParamUnion) []openai.ChatCompletionMessageParamUnion {
+	last := s.lastTurn
+	if last == nil || len(messages) != last.after {
+		return messages
+	}
+	if len(s.exchanges) > 0 {
+		s.exchanges = s.exchanges[:len(s.exchanges)-1]
+	}
+	return messages[:last.before]
+}
+
+// lexicalQueryChange describes how the rewritten lexical query of a prompt run
+// again compares with the previous run's.
+func lexicalQueryChange(previous, current string) string {
+	switch {
+	case previous == "":
+		return fmt.Sprintf("Lexical query: %q", current)
+	case previous == current:
+		return fmt.Sprintf("Lexical query unchanged: %q", current)
+	default:
+		return fmt.Sprintf("Lexical query: %q → %q", previous, current)
+	}
+}
@@ -0,0 +1,59 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/openai/openai-go/v3"
+)
+
+func TestRewindLastTurn(t *testing.T) {
+	history := []openai.ChatCompletionMessageParamUnion{
+		openai.SystemMessage("system"),
+		openai.UserMessage("first"),
+		openai.AssistantMessage("answer"),
+		openai.UserMessage("second"),
+		openai.AssistantMessage("answer"),
+	}
+
+	s := &Session{
+		exchanges: make([]exchange, 2),
+		lastTurn:  &lastTurn{prompt: "second", before: 3, after: 5},
+	}
+	if got := s.rewindLastTurn(history); len(got) != 3 {
+		t.Errorf("rewound history has %d messages, want 3", len(got))
+	}
+	if len(s.exchanges) != 1 {
+		t.Errorf("rewinding left %d exchanges, want 1", len(s.exchanges))
+	}
+
+	// A history that moved on since the turn keeps it.
+	s = &Session{
+		exchanges: make([]exchange, 2),
+		lastTurn:  &lastTurn{prompt: "first", before: 1, after: 3},
+	}
+	if got := s.rewindLastTurn(history); len(got) != len(history) {
+		t.Errorf("history that moved on was rewound to %d messages", len(got))
+	}
+	if len(s.exchanges) != 2 {
+		t.Errorf("history that moved on lost an exchange")
+	}
+
+	if got := (&Session{}).rewindLastTurn(history); len(got) != len(history) {
+		t.Errorf("rewinding with no last turn changed the history")
+	}
+}
+
+func TestLexicalQueryChange(t *testing.T) {
+	tests := []struct {
+		previous, current, want string
+	}{
+		{"", "ceph osd", `Lexical query: "ceph osd"`},
+		{"ceph osd", "ceph osd", `Lexical query unchanged: "ceph osd"`},
+		{"ceph osd", "ceph osd recovery", `Lexical query: "ceph osd" → "ceph osd recovery"`},
+	}
+	for _, tt := range tests {
+		if got := lexicalQueryChange(tt.previous, tt.current); got != tt.want {
+			t.Errorf("lexicalQueryChange(%q, %q) = %q, want %q", tt.previous, tt.current, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/rag"
+	"github.com/openai/openai-go/v3"
+)
+
+// AskOptions configures a one-shot question answered outside the REPL.
+type AskOptions struct {
+	// BaseURL is the OpenAI-compatible inference server.
+	BaseURL string
+	// Model is the chat model; when empty the server's first model is used.
+	Model            string
+	KnowledgeClient  *knowledge.OpenSearchClient
+	KapaClient       *knowledge.KapaClient
+	EmbeddingModelID string
+	// Bases are the knowledge base names to retrieve from.
+	Bases []string
+	// KapaGroups are kapa.ai source groups to retrieve from as well.
+	KapaGroups   []string
+	SystemPrompt string
+	Temperature  float64
+	// Settings are the chat.* settings the question is answered with.
+	Settings Settings
+	// ContextOnly stops after retrieval and writes the formatted context
+	// instead of generating an answer.
+	ContextOnly bool
+	Verbose     bool
+}
+
+// Ask answers a single question with the chat REPL's pipeline — query rewrite,
+// retrieval, context budget, grounded generation — and writes the answer
+// followed by its sources to out. With ContextOnly it writes just the context
+// block the model would have been given, for piping into other tools.
+func Ask(ctx context.Context, question string, opts AskOptions, out io.Writer) error {
+	model := opts.Model
+	if model == "" {
+		var err error
+		if model, err = findModelName(opts.BaseURL, opts.Verbose); err != nil {
+			return err
+		}
+	}
+	client := NewInferenceClient(opts.BaseURL)
+
+	session := &Session{
+		KnowledgeClient:  opts.KnowledgeClient,
+		KapaClient:       opts.KapaClient,
+		EmbeddingModelID: opts.EmbeddingModelID,
+		ActiveKapaGroups: opts.KapaGroups,
+		Settings:         opts.Settings,
+		MultiQuery:       opts.Settings.multiQuery,
+		TopK:             opts.Settings.topK,
+		MinScore:         opts.Settings.minScore,
+		ContextWindow:    DetectContextWindow(ctx, opts.BaseURL, model),
+	}
+	if opts.KnowledgeClient != nil {
+		for _, b := range opts.Bases {
+			session.ActiveIndexes = append(session.ActiveIndexes, knowledge.FullIndexName(b))
+		}
+	}
+
+	lexicalQuery := rewriteSearchQuery(client, model, nil, question, opts.Verbose)
+	hits := retrieve(client, model, nil, session, question, lexicalQuery, opts.Verbose)
+	ragContext, hits := session.Settings.fitContext(client, model, session.ContextWindow, hits, opts.Verbose)
+
+	if opts.ContextOnly {
+		if ragContext == "" {
+			return fmt.Errorf("no relevant context was retrieved for this question")
+		}
+		_, err := fmt.Fprintln(out, ragContext)
+		return err
+	}
+
+	params := openai.ChatCompletionNewParams{
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(opts.SystemPrompt),
+			openai.UserMessage(session.Settings.assemblePrompt(ragContext, question, hits, true, opts.Verbose)),
+		},
+		Model:       model,
+		Temperature: openai.Float(opts.Temperature),
+	}
+
+	stopProgress := common.StartProgressSpinner("Generating an answer")
+	stream := client.Chat.Completions.NewStreaming(ctx, params)
+	var answered bool
+	_, err := streamTurn(stream, func(kind TokenKind, content string) error {
+		if kind == TokenThink {
+			return nil
+		}
+		if !answered {
+			stopProgress()
+			content = strings.TrimLeft(content, "\n")
+			answered = content != ""
+		}
+		_, err := io.WriteString(out, content)
+		return err
+	})
+	stopProgress()
+	if err != nil {
+		return fmt.Errorf("generating answer: %w", err)
+	}
+	fmt.Fprintln(out)
+
+	if sources := rag.Sources(hits); len(sources) > 0 {
+		fmt.Fprintf(out, "\nSources: %s\n", strings.Join(sources, ", "))
+	}
+	return nil
+}
@@ -8,7 +8,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/rag"
 	"github.com/openai/openai-go/v3"
 	"gopkg.in/yaml.v3"
 )
@@ -200,6 +201,7 @@ func RunBatch(
 	manifest *BatchManifest,
 	prompts PromptConfig,
 	temperature float64,
+	settings Settings,
 	hooks BatchHooks,
 	verbose bool,
 ) (*BatchOutput, error) {
@@ -228,6 +230,8 @@ func RunBatch(
 		EmbeddingModelID: embeddingModelID,
 		ActiveIndexes:    activeIndexes,
 		ActiveKapaGroups: manifest.KapaSourceGroups,
+		Settings:         settings,
+		ContextWindow:    DetectContextWindow(ctx, baseURL, modelName),
 	}
 
 	defaultSystemPrompt := prompts.AnswerSystemPrompt
@@ -258,7 +262,8 @@ func RunBatch(
 		if lexicalQuery != q.Question {
 			semanticQuery = q.Question + " " + lexicalQuery
 		}
-		ragContext := retrieveContext(session, semanticQuery, lexicalQuery, verbose)
+		hits := retrieveHits(session, semanticQuery, lexicalQuery, verbose)
+		ragContext, hits := session.Settings.fitContext(client, modelName, session.ContextWindow, hits, verbose)
 
 		// When no context was retrieved there is nothing to ground the answer on.
 		// Skip the LLM call entirely and emit the fixed no-answer string to avoid
@@ -273,7 +278,7 @@ func RunBatch(
 		resp, err := client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
 			Messages: []openai.ChatCompletionMessageParamUnion{
 				openai.SystemMessage(defaultSystemPrompt),
-				openai.UserMessage(buildRAGPrompt(ragContext, q.Question)),
+				openai.UserMessage(session.Settings.assemblePrompt(ragContext, q.Question, hits, true, verbose)),
 			},
 			Model:       modelName,
 			Temperature: openai.Float(temperature),
@@ -293,7 +298,7 @@ func RunBatch(
 
 		var answer string
 		if len(resp.Choices) > 0 {
-			answer = StripThinkTags(resp.Choices[0].Message.Content)
+			answer = rag.StripThinkTags(resp.Choices[0].Message.Content)
 		}
 
 		result := BatchResult{ID: q.ID, Question: q.Question, Answer: answer}
@@ -318,6 +323,7 @@ func ProcessBatchChat(
 	manifest *BatchManifest,
 	prompts PromptConfig,
 	temperature float64,
+	settings Settings,
 	verbose bool,
 ) error {
 	fmt.Printf("Found %d questions in batch manifest version %s\n", len(manifest.Questions), manifest.Version)
@@ -334,7 +340,7 @@ func ProcessBatchChat(
 		},
 	}
 
-	out, err := RunBatch(context.Background(), baseURL, knowledgeClient, kapaClient, embeddingModelID, manifest, prompts, temperature, hooks, verbose)
+	out, err := RunBatch(context.Background(), baseURL, knowledgeClient, kapaClient, embeddingModelID, manifest, prompts, temperature, settings, hooks, verbose)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,169 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jpnorenam/rag-snap/internal/chatstore"
+	"github.com/openai/openai-go/v3"
+)
+
+const (
+	// cmdRewind drops the last turns from the conversation.
+	cmdRewind = "/rewind"
+	// cmdFork saves the conversation as a named branch to resume later.
+	cmdFork = "/fork"
+)
+
+// handleRewind processes /rewind [N], dropping the last N turns (one by
+// default) from messages so the conversation continues from before them.
+func handleRewind(args string, session *Session, messages []openai.ChatCompletionMessageParamUnion) []openai.ChatCompletionMessageParamUnion {
+	n := 1
+	if args = strings.TrimSpace(args); args != "" {
+		var err error
+		if n, err = strconv.Atoi(args); err != nil || n <= 0 {
+			fmt.Printf("Usage: %s [N]  (N: the number of turns to drop, 1 by default)\n", cmdRewind)
+			return messages
+		}
+	}
+
+	turns := countTurns(messages)
+	switch {
+	case turns == 0:
+		fmt.Println("Nothing to rewind yet — ask a question first.")
+		return messages
+	case n > turns:
+		fmt.Printf("The conversation has only %d turn(s); nothing was rewound.\n", turns)
+		return messages
+	}
+
+	messages = session.rewindTurns(messages, n)
+	if left := turns - n; left == 0 {
+		fmt.Printf("Rewound %d turn(s); the conversation starts over.\n", n)
+	} else {
+		fmt.Printf("Rewound %d turn(s); the conversation continues after turn %d.\n", n, left)
+	}
+	return messages
+}
+
+// countTurns returns the number of user prompts in messages.
+func countTurns(messages []openai.ChatCompletionMessageParamUnion) int {
+	n := 0
+	for _, m := range messages {
+		if m.OfUser != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// rewindTurns cuts messages just before their n-th last user prompt, dropping
+// those turns with anything added after them (such as a /recall), and the
+// turns' exchanges. /again has no previous prompt to run afterwards.
+func (s *Session) rewindTurns(messages []openai.ChatCompletionMessageParamUnion, n int) []openai.ChatCompletionMessageParamUnion {
+	cut := len(messages)
+	for seen := 0; cut > 0 && seen < n; {
+		cut--
+		if messages[cut].OfUser != nil {
+			seen++
+		}
+	}
+	s.exchanges = s.exchanges[:max(0, len(s.exchanges)-n)]
+	s.lastTurn = nil
+	return messages[:cut]
+}
+
+// forkDirectChat processes /fork <name>, saving the direct-REPL conversation as
+// branch name of the chat chatID to the client-local store, to resume later
+// from /history; the session itself carries on where it is. A conversation not
+// yet saved is saved first, so its branches have a parent to belong to, and
+// the returned id pins it as /save does. Forking a branch forks its parent,
+// and forking under an existing branch name replaces that branch. With no
+// name, it lists the conversation's branches.
+func forkDirectChat(store *chatstore.Store, chatID, name string, session *Session, messages []openai.ChatCompletionMessageParamUnion) string {
+	if store == nil {
+		fmt.Println("Saved chats are unavailable: could not resolve the config directory.")
+		return chatID
+	}
+	name = strings.TrimSpace(name)
+	parentID := chatID
+	if chatID != "" {
+		if current, err := store.Get(chatID); err == nil && current.ParentID != "" {
+			parentID = current.ParentID
+		}
+	}
+
+	if name == "" {
+		listBranches(store, parentID)
+		return chatID
+	}
+	if !chatstore.ValidBranch(name) {
+		fmt.Printf("Could not fork: %v\n", chatstore.ErrInvalidBranch)
+		return chatID
+	}
+
+	turns := session.annotateTurns(historyToTurns(messages))
+	if parentID == "" {
+		parent, err := store.Save(chatstore.Chat{Model: session.ModelName, Bases: activeBaseNames(session), Turns: turns})
+		if err != nil {
+			if errors.Is(err, chatstore.ErrEmpty) {
+				fmt.Println("Nothing to fork yet — ask a question first.")
+			} else {
+				fmt.Printf("Could not save chat: %v\n", err)
+			}
+			return chatID
+		}
+		fmt.Printf("Saved chat as %q.\n", parent.Title)
+		chatID, parentID = parent.ID, parent.ID
+	}
+
+	branch := chatstore.Chat{
+		Model:    session.ModelName,
+		Bases:    activeBaseNames(session),
+		Turns:    turns,
+		Branch:   name,
+		ParentID: parentID,
+	}
+	if existing, err := store.Branches(parentID); err == nil {
+		for _, b := range existing {
+			if b.Branch == name {
+				branch.ID = b.ID
+			}
+		}
+	}
+	if parent, err := store.Get(parentID); err == nil {
+		branch.Title = parent.Title
+	}
+	if _, err := store.Save(branch); err != nil {
+		if errors.Is(err, chatstore.ErrEmpty) {
+			fmt.Println("Nothing to fork yet — ask a question first.")
+		} else {
+			fmt.Printf("Could not fork chat: %v\n", err)
+		}
+		return chatID
+	}
+	fmt.Printf("Forked the conversation into branch %q. Resume it later with %s.\n", name, cmdHistory)
+	return chatID
+}
+
+// listBranches prints the branches forked from parentID.
+func listBranches(store *chatstore.Store, parentID string) {
+	var branches []chatstore.Summary
+	if parentID != "" {
+		var err error
+		if branches, err = store.Branches(parentID); err != nil {
+			fmt.Printf("Could not list branches: %v\n", err)
+			return
+		}
+	}
+	if len(branches) == 0 {
+		fmt.Printf("No branches yet. Usage: %s <name>\n", cmdFork)
+		return
+	}
+	for _, b := range branches {
+		fmt.Printf("%s  %s\n", b.Branch, dim(fmt.Sprintf("%d turns, %s", b.TurnCount, relativeTime(b.UpdatedAt))))
+	}
+	fmt.Printf("Resume a branch with %s.\n", cmdHistory)
+}
@@ -0,0 +1,82 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/jpnorenam/rag-snap/internal/chatstore"
+	"github.com/openai/openai-go/v3"
+)
+
+func branchHistory() []openai.ChatCompletionMessageParamUnion {
+	return []openai.ChatCompletionMessageParamUnion{
+		openai.SystemMessage("system"),
+		openai.UserMessage("first"),
+		openai.AssistantMessage("answer"),
+		openai.UserMessage("second"),
+		openai.AssistantMessage("answer"),
+		openai.SystemMessage("recalled"),
+		openai.UserMessage("third"),
+		openai.AssistantMessage("answer"),
+	}
+}
+
+func TestRewindTurns(t *testing.T) {
+	s := &Session{exchanges: make([]exchange, 3), lastTurn: &lastTurn{prompt: "third"}}
+	got := s.rewindTurns(branchHistory(), 2)
+	if len(got) != 3 || countTurns(got) != 1 {
+		t.Errorf("rewinding 2 turns left %d messages and %d turns, want 3 and 1", len(got), countTurns(got))
+	}
+	if len(s.exchanges) != 1 || s.lastTurn != nil {
+		t.Errorf("rewinding left %d exchanges and last turn %v, want 1 and nil", len(s.exchanges), s.lastTurn)
+	}
+
+	s = &Session{exchanges: make([]exchange, 3)}
+	if got := s.rewindTurns(branchHistory(), 3); len(got) != 1 {
+		t.Errorf("rewinding every turn left %d messages, want only the system prompt", len(got))
+	}
+}
+
+func TestHandleRewindRejectsTooMany(t *testing.T) {
+	s := &Session{exchanges: make([]exchange, 3)}
+	if got := handleRewind("4", s, branchHistory()); len(got) != len(branchHistory()) {
+		t.Errorf("rewinding past the start changed the history to %d messages", len(got))
+	}
+	if got := handleRewind("x", s, branchHistory()); len(got) != len(branchHistory()) {
+		t.Errorf("an invalid count changed the history to %d messages", len(got))
+	}
+	if got := handleRewind("", s, branchHistory()); countTurns(got) != 2 {
+		t.Errorf("/rewind with no count left %d turns, want 2", countTurns(got))
+	}
+}
+
+func TestForkDirectChat(t *testing.T) {
+	store := chatstore.New(t.TempDir())
+	s := &Session{}
+
+	chatID := forkDirectChat(store, "", "alt", s, branchHistory())
+	if chatID == "" {
+		t.Fatal("forking an unsaved conversation did not save it")
+	}
+	// Forking again under the same name replaces the branch, and forking from
+	// a branch forks its parent.
+	branches, _ := store.Branches(chatID)
+	if len(branches) != 1 || branches[0].Branch != "alt" {
+		t.Fatalf("branches = %+v, want one named alt", branches)
+	}
+	if got := forkDirectChat(store, branches[0].ID, "alt", s, branchHistory()[:3]); got != branches[0].ID {
+		t.Errorf("forking from a branch repinned the session to %q", got)
+	}
+	forkDirectChat(store, branches[0].ID, "other", s, branchHistory())
+
+	branches, _ = store.Branches(chatID)
+	if len(branches) != 2 || branches[0].Branch != "alt" || branches[0].TurnCount != 2 || branches[1].Branch != "other" {
+		t.Errorf("branches = %+v, want alt with 2 turns and other", branches)
+	}
+
+	if got := forkDirectChat(store, chatID, "bad name", s, branchHistory()); got != chatID {
+		t.Errorf("an invalid branch name repinned the session to %q", got)
+	}
+	if branches, _ = store.Branches(chatID); len(branches) != 2 {
+		t.Errorf("an invalid branch name was saved: %+v", branches)
+	}
+}
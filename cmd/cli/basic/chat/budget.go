@@ -0,0 +1,216 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/rag"
+	"github.com/openai/openai-go/v3"
+)
+
+// Truncation strategies applied when retrieved context exceeds the budget.
+const (
+	// TruncateDrop drops the lowest-scoring hits until the rest fit.
+	TruncateDrop = "drop"
+	// TruncatePerHit keeps every hit but cuts each to an equal share.
+	TruncatePerHit = "truncate"
+	// TruncateSummarize keeps the hits that fit and has the LLM summarize the
+	// dropped ones into the remaining room.
+	TruncateSummarize = "summarize"
+)
+
+// minSummaryRoom is the smallest space worth summarizing the overflow into;
+// below it the dropped hits are simply left out.
+const minSummaryRoom = 200
+
+// contextBudget caps the RAG context injected into a prompt. A zero maxChars
+// leaves context unbounded.
+type contextBudget struct {
+	maxChars int
+	strategy string
+}
+
+// parseContextBudget reads the RAG context budget from the chat.context.max
+// (characters; empty or 0 for no limit) and chat.context.truncation (drop,
+// truncate, or summarize; empty for drop) config values.
+func parseContextBudget(maxChars, strategy string) (contextBudget, error) {
+	b := contextBudget{strategy: TruncateDrop}
+	if maxChars = strings.TrimSpace(maxChars); maxChars != "" {
+		n, err := strconv.Atoi(maxChars)
+		if err != nil || n < 0 {
+			return contextBudget{}, fmt.Errorf("invalid chat.context.max %q: expected a number of characters, or 0 for no limit", maxChars)
+		}
+		b.maxChars = n
+	}
+	switch strategy = strings.TrimSpace(strategy); strategy {
+	case "":
+	case TruncateDrop, TruncatePerHit, TruncateSummarize:
+		b.strategy = strategy
+	default:
+		return contextBudget{}, fmt.Errorf("invalid chat.context.truncation %q: expected %s, %s, or %s", strategy, TruncateDrop, TruncatePerHit, TruncateSummarize)
+	}
+	return b, nil
+}
+
+// budgetFor returns the context budget for a model with a context window of
+// window tokens (0 when unknown). An explicit chat.context.max wins; without
+// one, retrieved context gets ragContextShare of a known window.
+func (s Settings) budgetFor(window int) contextBudget {
+	b := s.budget
+	if b.strategy == "" {
+		b.strategy = TruncateDrop
+	}
+	if b.maxChars == 0 && window > 0 {
+		b.maxChars = int(float64(window) * ragContextShare * charsPerToken)
+	}
+	return b
+}
+
+// fitContext renders hits as a RAG context block within the budget for a
+// model with a context window of window tokens, and returns it with the hits
+// it draws on. Hits are sanitized first when chat.rag.sanitize is on. client
+// and model are used only by the summarize strategy.
+func (s Settings) fitContext(client openai.Client, model string, window int, hits []knowledge.SearchHit, verbose bool) (string, []knowledge.SearchHit) {
+	if len(hits) == 0 {
+		return "", nil
+	}
+	hits = s.sanitizeHits(hits, verbose)
+	full := s.formatContext(hits)
+	b := s.budgetFor(window)
+	if b.maxChars <= 0 || runeLen(full) <= b.maxChars {
+		return full, hits
+	}
+
+	var (
+		text string
+		used []knowledge.SearchHit
+	)
+	switch b.strategy {
+	case TruncatePerHit:
+		text, used = s.truncateHits(hits, b.maxChars)
+	case TruncateSummarize:
+		var dropped []knowledge.SearchHit
+		used, dropped = s.dropLowest(hits, b.maxChars)
+		text = s.formatContext(used)
+		if summary := s.summarizeOverflow(client, model, dropped, b.maxChars-runeLen(text), verbose); summary != "" {
+			text += summary
+			used = append(used, dropped...)
+		}
+	default:
+		used, _ = s.dropLowest(hits, b.maxChars)
+		text = s.formatContext(used)
+	}
+
+	if verbose {
+		fmt.Printf("Context budget (%s): %d of %d characters over %d hits, cut to %d characters\n",
+			b.strategy, runeLen(full), b.maxChars, len(hits), runeLen(text))
+	}
+	return text, used
+}
+
+// dropLowest keeps the highest-scoring hits that fit in maxChars, in their
+// original order, and returns the rest as dropped.
+func (s Settings) dropLowest(hits []knowledge.SearchHit, maxChars int) (kept, dropped []knowledge.SearchHit) {
+	order := make([]int, len(hits))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return hits[order[a]].Score > hits[order[b]].Score })
+
+	keep := make([]bool, len(hits))
+	var candidate []knowledge.SearchHit
+	for _, i := range order {
+		keep[i] = true
+		candidate = candidate[:0]
+		for j, k := range keep {
+			if k {
+				candidate = append(candidate, hits[j])
+			}
+		}
+		if runeLen(s.formatContext(candidate)) > maxChars {
+			keep[i] = false
+		}
+	}
+	for i, hit := range hits {
+		if keep[i] {
+			kept = append(kept, hit)
+		} else {
+			dropped = append(dropped, hit)
+		}
+	}
+	return kept, dropped
+}
+
+// truncateHits cuts every hit's content to an equal share of what maxChars
+// leaves after the per-hit labels and source lines. When not even the labels
+// fit, it falls back to dropping hits.
+func (s Settings) truncateHits(hits []knowledge.SearchHit, maxChars int) (string, []knowledge.SearchHit) {
+	bare := make([]knowledge.SearchHit, len(hits))
+	for i, hit := range hits {
+		hit.Content = ""
+		bare[i] = hit
+	}
+	share := (maxChars - runeLen(s.formatContext(bare))) / len(hits)
+	if share <= 1 {
+		kept, _ := s.dropLowest(hits, maxChars)
+		return s.formatContext(kept), kept
+	}
+	cut := make([]knowledge.SearchHit, len(hits))
+	for i, hit := range hits {
+		if r := []rune(hit.Content); len(r) > share {
+			hit.Content = string(r[:share-1]) + "…"
+		}
+		cut[i] = hit
+	}
+	return s.formatContext(cut), hits
+}
+
+// summarizeOverflow asks the LLM to condense the dropped hits into at most room
+// characters, returned as a context block ready to append. It returns "" when
+// there is nothing to summarize, too little room, or the request fails; the
+// answer then simply goes without the dropped hits.
+func (s Settings) summarizeOverflow(client openai.Client, model string, dropped []knowledge.SearchHit, room int, verbose bool) string {
+	const header = "\n---\n[SUMMARY OF ADDITIONAL CONTEXT]\n"
+	room -= runeLen(header)
+	if len(dropped) == 0 || room < minSummaryRoom {
+		return ""
+	}
+
+	stopProgress := common.StartProgressSpinner("Summarizing additional context")
+	resp, err := client.Chat.Completions.New(context.Background(), openai.ChatCompletionNewParams{
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(fmt.Sprintf(
+				"Summarize the following retrieved passages in at most %d characters. "+
+					"Keep concrete facts, commands, versions, and names; name the source of each fact in parentheses. "+
+					"Output only the summary.", room)),
+			openai.UserMessage(s.formatContext(dropped)),
+		},
+		Model:       model,
+		Temperature: openai.Float(0),
+	})
+	stopProgress()
+	if err != nil || len(resp.Choices) == 0 {
+		if verbose {
+			fmt.Printf("Context summary failed: %v\n", err)
+		}
+		return ""
+	}
+
+	summary := strings.TrimSpace(rag.StripThinkTags(resp.Choices[0].Message.Content))
+	if r := []rune(summary); len(r) > room {
+		summary = string(r[:room-1]) + "…"
+	}
+	if summary == "" {
+		return ""
+	}
+	return header + summary
+}
+
+func runeLen(s string) int {
+	return len([]rune(s))
+}
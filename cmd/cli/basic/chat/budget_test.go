@@ -0,0 +1,77 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+)
+
+func budgetHits() []knowledge.SearchHit {
+	return []knowledge.SearchHit{
+		{SourceID: "a", Label: "canonical", Score: 0.5, Content: strings.Repeat("a", 100)},
+		{SourceID: "b", Label: "canonical", Score: 0.9, Content: strings.Repeat("b", 100)},
+		{SourceID: "c", Label: "upstream", Score: 0.1, Content: strings.Repeat("c", 100)},
+	}
+}
+
+func TestParseContextBudget(t *testing.T) {
+	b, err := parseContextBudget("12000", TruncateSummarize)
+	if err != nil {
+		t.Fatalf("parseContextBudget returned error: %v", err)
+	}
+	if b.maxChars != 12000 || b.strategy != TruncateSummarize {
+		t.Errorf("budget = %+v, want 12000/summarize", b)
+	}
+	if b, err := parseContextBudget("", ""); err != nil || b.maxChars != 0 || b.strategy != TruncateDrop {
+		t.Errorf("parseContextBudget(empty) = %+v, %v, want unbounded drop", b, err)
+	}
+	for _, in := range [][2]string{{"-1", ""}, {"12k", ""}, {"", "shorten"}} {
+		if _, err := parseContextBudget(in[0], in[1]); err == nil {
+			t.Errorf("parseContextBudget(%q, %q) = nil error, want error", in[0], in[1])
+		}
+	}
+}
+
+func TestDropLowestKeepsBestInOrder(t *testing.T) {
+	var s Settings
+	hits := budgetHits()
+	// Room for two hits but not three.
+	limit := runeLen(s.formatContext(hits[:2])) + 10
+
+	kept, dropped := s.dropLowest(hits, limit)
+	if len(kept) != 2 || kept[0].SourceID != "a" || kept[1].SourceID != "b" {
+		t.Errorf("kept = %v, want a then b in original order", sourceIDs(kept))
+	}
+	if len(dropped) != 1 || dropped[0].SourceID != "c" {
+		t.Errorf("dropped = %v, want the lowest-scoring c", sourceIDs(dropped))
+	}
+	if got := runeLen(s.formatContext(kept)); got > limit {
+		t.Errorf("kept context is %d characters, over the %d budget", got, limit)
+	}
+}
+
+func TestTruncateHitsFitsBudget(t *testing.T) {
+	var s Settings
+	hits := budgetHits()
+	limit := runeLen(s.formatContext(hits)) - 150
+
+	text, used := s.truncateHits(hits, limit)
+	if len(used) != len(hits) {
+		t.Errorf("truncate used %d hits, want all %d", len(used), len(hits))
+	}
+	if got := runeLen(text); got > limit {
+		t.Errorf("truncated context is %d characters, over the %d budget", got, limit)
+	}
+	if !strings.Contains(text, "…") {
+		t.Errorf("truncated context has no ellipsis:\n%s", text)
+	}
+}
+
+func sourceIDs(hits []knowledge.SearchHit) []string {
+	ids := make([]string, len(hits))
+	for i, h := range hits {
+		ids[i] = h.SourceID
+	}
+	return ids
+}
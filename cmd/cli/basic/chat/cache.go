@@ -0,0 +1,102 @@
+package chat
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jpnorenam/rag-snap/pkg/storage"
+	"github.com/openai/openai-go/v3"
+)
+
+const (
+	// cmdNoCache prefixes a prompt to answer it afresh, bypassing the response
+	// cache. Unlike the other slash commands it is sent to the model.
+	cmdNoCache = "/nocache"
+	// responseCacheDirName is the response cache directory under the user's
+	// data dir.
+	responseCacheDirName = "response-cache"
+)
+
+// parseResponseCacheTTL reads how long chat answers are cached from the
+// chat.cache.ttl config value (a duration such as 1h; empty or 0 for no
+// cache). A prompt repeated within the TTL, against the same model, bases,
+// and retrieved context, is answered from the cache without the model.
+func parseResponseCacheTTL(ttl string) (time.Duration, error) {
+	if ttl = strings.TrimSpace(ttl); ttl == "" || ttl == "0" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(ttl)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid chat.cache.ttl %q: expected a duration such as 1h", ttl)
+	}
+	return d, nil
+}
+
+// newResponseCache returns the response cache for new sessions, or nil when
+// caching is off or there is nowhere to keep it.
+func (s Settings) newResponseCache(verbose bool) *storage.ResponseCache {
+	if s.cacheTTL <= 0 {
+		return nil
+	}
+	dir, err := userDataDir()
+	if err != nil {
+		if verbose {
+			fmt.Printf("Answers will not be cached: %v\n", err)
+		}
+		return nil
+	}
+	return storage.NewResponseCache(filepath.Join(dir, responseCacheDirName), s.cacheTTL)
+}
+
+// cutNoCache strips a leading /nocache from prompt, reporting whether it was
+// there.
+func cutNoCache(prompt string) (string, bool) {
+	rest, ok := strings.CutPrefix(prompt, cmdNoCache)
+	if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '\n') {
+		return prompt, false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// responseCacheKey derives the cache key of an answer to prompt from the
+// model, system prompt, and temperature of params, the session's active
+// knowledge bases and Kapa groups, and a hash of the context retrieved for it,
+// so an answer is reused only while it would be generated the same way and
+// grounded on the same material.
+func responseCacheKey(params openai.ChatCompletionNewParams, prompt string, session *Session, ragContext string) string {
+	bases := slices.Sorted(slices.Values(session.ActiveIndexes))
+	groups := slices.Sorted(slices.Values(session.ActiveKapaGroups))
+	retrieval := sha256.Sum256([]byte(ragContext))
+	var temperature string
+	if params.Temperature.Valid() {
+		temperature = strconv.FormatFloat(params.Temperature.Value, 'g', -1, 64)
+	}
+	return storage.ResponseCacheKey(params.Model, prompt, systemPrompt(params.Messages), temperature,
+		strings.Join(bases, ","), strings.Join(groups, ","), hex.EncodeToString(retrieval[:]))
+}
+
+// systemPrompt returns the text of the conversation's system message, or ""
+// when it has none.
+func systemPrompt(messages []openai.ChatCompletionMessageParamUnion) string {
+	for _, m := range messages {
+		if m.OfSystem != nil {
+			return m.OfSystem.Content.OfString.Or("")
+		}
+	}
+	return ""
+}
+
+// cachedResponse builds the cache entry of the assistant message answer,
+// grounded on sources.
+func cachedResponse(answer *openai.ChatCompletionMessageParamUnion, sources []string) storage.CachedResponse {
+	return storage.CachedResponse{
+		Answer:  answer.OfAssistant.Content.OfString.Or(""),
+		Sources: sources,
+	}
+}
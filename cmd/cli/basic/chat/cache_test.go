@@ -0,0 +1,73 @@
+package chat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+)
+
+func TestCutNoCache(t *testing.T) {
+	tests := []struct {
+		in, want string
+		noCache  bool
+	}{
+		{"what is snap?", "what is snap?", false},
+		{"/nocache what is snap?", "what is snap?", true},
+		{"/nocache", "", true},
+		{"/nocacheless", "/nocacheless", false},
+	}
+	for _, tt := range tests {
+		got, noCache := cutNoCache(tt.in)
+		if got != tt.want || noCache != tt.noCache {
+			t.Errorf("cutNoCache(%q) = %q, %v; want %q, %v", tt.in, got, noCache, tt.want, tt.noCache)
+		}
+	}
+}
+
+func TestParseResponseCacheTTL(t *testing.T) {
+	if ttl, err := parseResponseCacheTTL("90m"); err != nil || ttl != 90*time.Minute {
+		t.Errorf("parseResponseCacheTTL(90m) = %v, %v", ttl, err)
+	}
+	if ttl, err := parseResponseCacheTTL(""); err != nil || ttl != 0 {
+		t.Errorf("parseResponseCacheTTL(\"\") = %v, %v; want no cache", ttl, err)
+	}
+	if _, err := parseResponseCacheTTL("soon"); err == nil {
+		t.Error("parseResponseCacheTTL(soon) succeeded")
+	}
+}
+
+func TestResponseCacheKey(t *testing.T) {
+	a := &Session{ActiveIndexes: []string{"rag-b", "rag-a"}}
+	b := &Session{ActiveIndexes: []string{"rag-a", "rag-b"}}
+	params := openai.ChatCompletionNewParams{
+		Model:       "m",
+		Messages:    []openai.ChatCompletionMessageParamUnion{openai.SystemMessage("sys")},
+		Temperature: openai.Float(0.2),
+	}
+	key := responseCacheKey(params, "q", a, "ctx")
+	if key != responseCacheKey(params, "q", b, "ctx") {
+		t.Error("key depends on the order of the active bases")
+	}
+	if key == responseCacheKey(params, "q", a, "other ctx") {
+		t.Error("key ignores the retrieved context")
+	}
+
+	otherPrompt := params
+	otherPrompt.Messages = []openai.ChatCompletionMessageParamUnion{openai.SystemMessage("other sys")}
+	if key == responseCacheKey(otherPrompt, "q", a, "ctx") {
+		t.Error("key ignores the system prompt")
+	}
+	otherTemperature := params
+	otherTemperature.Temperature = openai.Float(0.9)
+	if key == responseCacheKey(otherTemperature, "q", a, "ctx") {
+		t.Error("key ignores the temperature")
+	}
+
+	// Later turns leave the system prompt, and so the key, as it was.
+	later := params
+	later.Messages = append(later.Messages, openai.UserMessage("earlier"), openai.AssistantMessage("answer"))
+	if key != responseCacheKey(later, "q", a, "ctx") {
+		t.Error("key depends on the turns after the system prompt")
+	}
+}
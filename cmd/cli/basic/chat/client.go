@@ -17,15 +17,21 @@ import (
 
 	"github.com/chzyer/readline"
 	"github.com/fatih/color"
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
 	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/pkg/httpclient"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/rag"
+	"github.com/jpnorenam/rag-snap/pkg/storage"
 	"github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/option"
 	"github.com/openai/openai-go/v3/packages/ssestream"
 )
 
 func clientOptions(baseURL string) []option.RequestOption {
-	opts := []option.RequestOption{option.WithBaseURL(baseURL)}
+	opts := []option.RequestOption{
+		option.WithBaseURL(baseURL),
+		option.WithHTTPClient(httpclient.New(0)),
+	}
 	if key := os.Getenv("CHAT_API_KEY"); key != "" {
 		opts = append(opts, option.WithAPIKey(key))
 	}
@@ -36,8 +42,14 @@ func clientOptions(baseURL string) []option.RequestOption {
 // and returns the first model name. Returns an error if the server is
 // unreachable or returns no models.
 func FindModelName(baseURL string) (string, error) {
+	return FindModelNameContext(context.Background(), baseURL)
+}
+
+// FindModelNameContext is FindModelName bounded by ctx, for probes that must
+// answer within a timeout.
+func FindModelNameContext(ctx context.Context, baseURL string) (string, error) {
 	modelService := openai.NewModelService(clientOptions(baseURL)...)
-	modelPage, err := modelService.List(context.Background())
+	modelPage, err := modelService.List(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -47,7 +59,7 @@ func FindModelName(baseURL string) (string, error) {
 	return modelPage.Data[0].ID, nil
 }
 
-func Client(baseURL string, knowledgeClient *knowledge.OpenSearchClient, kapaClient *knowledge.KapaClient, embeddingModelID string, llmModelName string, prompts PromptConfig, temperature float64, verbose bool) error {
+func Client(baseURL string, knowledgeClient *knowledge.OpenSearchClient, knowledgeURL string, knowledgeSettings knowledge.Settings, kapaClient *knowledge.KapaClient, embeddingModelID string, llmModelName string, prompts PromptConfig, temperature float64, settings Settings, history HistorySettings, verbose bool) error {
 	fmt.Printf("Using inference server at %v\n", baseURL)
 
 	// Check if server is reachable
@@ -64,6 +76,8 @@ func Client(baseURL string, knowledgeClient *knowledge.OpenSearchClient, kapaCli
 			knowledgeClient.URL(),
 			cmdUseKnowledge,
 		)
+	} else if knowledgeURL != "" {
+		printOfflineBanner()
 	}
 	if kapaClient != nil {
 		fmt.Printf("\t> Use `%s` to select Kapa.ai source groups\n", cmdUseKapa)
@@ -71,11 +85,16 @@ func Client(baseURL string, knowledgeClient *knowledge.OpenSearchClient, kapaCli
 	fmt.Println()
 
 	if llmModelName == "" {
-		var err error
-		llmModelName, err = findModelName(baseURL, verbose)
+		names, err := findModelNames(baseURL, verbose)
 		if err != nil {
 			return err
 		}
+		llmModelName = names[0]
+		if len(names) > 1 {
+			if llmModelName, err = pickModel(names, ""); err != nil {
+				return err
+			}
+		}
 	}
 	if verbose {
 		fmt.Printf("Using model %v\n", llmModelName)
@@ -107,6 +126,7 @@ func Client(baseURL string, knowledgeClient *knowledge.OpenSearchClient, kapaCli
 		HistorySearchFold:   true,
 		FuncFilterInputRune: filterInput,
 	}
+	history.configureHistoryFile(rlConfig, verbose)
 
 	rl, err := readline.NewEx(rlConfig)
 	if err != nil {
@@ -130,10 +150,26 @@ func Client(baseURL string, knowledgeClient *knowledge.OpenSearchClient, kapaCli
 	}
 
 	session := &Session{
-		KnowledgeClient:  knowledgeClient,
-		KapaClient:       kapaClient,
-		EmbeddingModelID: embeddingModelID,
-		ActiveIndexes:    []string{knowledge.DefaultIndexName()},
+		KnowledgeClient:   knowledgeClient,
+		KapaClient:        kapaClient,
+		EmbeddingModelID:  embeddingModelID,
+		ActiveIndexes:     []string{knowledge.DefaultIndexName()},
+		InferenceURL:      baseURL,
+		ModelName:         llmModelName,
+		Settings:          settings,
+		MultiQuery:        settings.multiQuery,
+		TopK:              settings.topK,
+		MinScore:          settings.minScore,
+		ContextWindow:     DetectContextWindow(context.Background(), baseURL, llmModelName),
+		KnowledgeURL:      knowledgeURL,
+		KnowledgeSettings: knowledgeSettings,
+		SavedSearches:     storage.NewSavedSearches(),
+		ResponseCache:     settings.newResponseCache(verbose),
+		knowledgeOffline:  knowledgeClient == nil && knowledgeURL != "",
+		lastReconnect:     time.Now(),
+	}
+	if verbose && session.ContextWindow > 0 {
+		fmt.Printf("Model context window: %d tokens\n", session.ContextWindow)
 	}
 
 	// Saved-chat history is stored client-locally in daemonless mode. chatID pins
@@ -142,7 +178,7 @@ func Client(baseURL string, knowledgeClient *knowledge.OpenSearchClient, kapaCli
 	var chatID string
 
 	for {
-		prompt, err := rl.Readline()
+		prompt, multiline, err := readPrompt(rl)
 		clearSlashHints()
 		if errors.Is(err, readline.ErrInterrupt) {
 			if len(prompt) == 0 {
@@ -153,25 +189,41 @@ func Client(baseURL string, knowledgeClient *knowledge.OpenSearchClient, kapaCli
 		} else if err == io.EOF {
 			break
 		}
-		if prompt == "exit" {
+		if prompt == "exit" && !multiline {
 			break
 		}
 
 		// Handle slash commands without sending to the LLM. Readline is torn down
 		// and recreated around them because /use-knowledge and /history drive the
 		// terminal via huh, which conflicts with an active readline.
-		if strings.HasPrefix(prompt, "/") {
+		if _, noCache := cutNoCache(prompt); strings.HasPrefix(prompt, "/") && !noCache && !multiline {
 			rl.Close()
 			verb, args, _ := strings.Cut(strings.TrimSpace(prompt), " ")
 			switch verb {
 			case cmdSave:
-				if id, ok := saveDirectChat(chatStore, chatID, args, llmModelName, session, params.Messages); ok {
+				if id, ok := saveDirectChat(chatStore, chatID, args, session.ModelName, session, params.Messages); ok {
 					chatID = id
 				}
 			case cmdHistory:
-				if msgs, id, ok := resumeDirectChat(chatStore, initialSystemPrompt, session); ok {
+				if strings.TrimSpace(args) == historyClearArg {
+					clearPromptHistory(rlConfig)
+				} else if msgs, id, ok := resumeDirectChat(chatStore, initialSystemPrompt, session); ok {
 					params.Messages = msgs
 					chatID = id
+					session.lastTurn = nil
+				}
+			case cmdExport:
+				exportDirectChat(chatStore, chatID, args, session, params.Messages)
+			case cmdRecall:
+				params.Messages = handleRecall(args, session, params.Messages)
+			case cmdRewind:
+				params.Messages = handleRewind(args, session, params.Messages)
+			case cmdFork:
+				chatID = forkDirectChat(chatStore, chatID, args, session, params.Messages)
+			case cmdAgain:
+				params, err = handleAgain(client, params, args, session, verbose)
+				if err != nil {
+					return err
 				}
 			default:
 				handleSlashCommand(prompt, session)
@@ -185,13 +237,18 @@ func Client(baseURL string, knowledgeClient *knowledge.OpenSearchClient, kapaCli
 		}
 
 		if len(prompt) > 0 {
-			rl.SaveHistory(prompt)
-			params, err = handlePrompt(client, params, prompt, session, verbose)
+			saveHistory(rl, prompt)
+			expanded, ok := expandAttachments(prompt)
+			if !ok {
+				continue
+			}
+			params, err = handlePrompt(client, params, expanded, session, verbose)
 			if err != nil {
 				return err
 			}
 		}
 	}
+	rememberSession(client, session, params.Messages, verbose)
 	fmt.Println("Closing chat")
 
 	return nil
@@ -275,7 +332,10 @@ func checkServer(client openai.Client, modelName string) error {
 	}
 }
 
-func findModelName(baseURL string, verbose bool) (string, error) {
+// findModelNames lists the models the inference server exposes, waiting briefly
+// for a server that is still loading and reports none. Several models are not an
+// error: the REPL offers them for selection and /model switches between them.
+func findModelNames(baseURL string, verbose bool) ([]string, error) {
 	stopProgress := common.StartProgressSpinner("Looking up model name")
 	defer stopProgress()
 
@@ -289,32 +349,68 @@ func findModelName(baseURL string, verbose bool) (string, error) {
 	for {
 		modelPage, err := modelService.List(context.Background())
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 
 		if len(modelPage.Data) == 0 {
 			// This can happen when OpenVINO Model Server is starting up
 			if time.Since(start) > waitTimeout {
 				// Stop waiting
-				return "", fmt.Errorf("server returned no models\n\n%s\n%s",
+				return nil, fmt.Errorf("server returned no models\n\n%s\n%s",
 					common.SuggestServerStartup(),
 					common.SuggestServerLogs())
 			}
 			time.Sleep(retryInterval)
 			continue
-		} else if len(modelPage.Data) > 1 {
-			var names []string
-			for _, model := range modelPage.Data {
-				names = append(names, model.ID)
-			}
-			return "", fmt.Errorf("expected one but server returned multiple models: %s", strings.Join(names, ", "))
 		}
 
-		return modelPage.Data[0].ID, nil
+		names := make([]string, 0, len(modelPage.Data))
+		for _, model := range modelPage.Data {
+			names = append(names, model.ID)
+		}
+		return names, nil
 	} // end for
 }
 
+// findModelName resolves the single model to use when nobody is there to pick
+// one (batch answering). A server exposing several models is an error listing
+// them, so the caller can name one explicitly.
+func findModelName(baseURL string, verbose bool) (string, error) {
+	names, err := findModelNames(baseURL, verbose)
+	if err != nil {
+		return "", err
+	}
+	if len(names) > 1 {
+		return "", fmt.Errorf("expected one but server returned multiple models: %s", strings.Join(names, ", "))
+	}
+	return names[0], nil
+}
+
 func handlePrompt(client openai.Client, params openai.ChatCompletionNewParams, prompt string, session *Session, verbose bool) (openai.ChatCompletionNewParams, error) {
+	// A leading /nocache answers the prompt afresh; the fresh answer still
+	// replaces the cached one.
+	prompt, noCache := cutNoCache(prompt)
+	if prompt == "" {
+		fmt.Printf("Usage: %s <prompt>\n", cmdNoCache)
+		return params, nil
+	}
+	return answerPrompt(client, params, prompt, noCache, nil, session, verbose)
+}
+
+// answerPrompt runs the rewrite → retrieve → augment → stream loop for prompt
+// and adds the turn to the history. again is the turn /again runs again, whose
+// lexical query the new one is compared with; nil for a new prompt.
+func answerPrompt(client openai.Client, params openai.ChatCompletionNewParams, prompt string, noCache bool, again *lastTurn, session *Session, verbose bool) (openai.ChatCompletionNewParams, error) {
+	// /model may have switched models since the last turn; the choice applies
+	// from this message on, history included.
+	if session.ModelName != "" {
+		params.Model = session.ModelName
+	}
+
+	// A knowledge base that went away is retried between prompts, so a
+	// restarted cluster is picked up without /reconnect.
+	maybeReconnect(session, verbose)
+
 	// RAG augmentation applies only when a knowledge client is present AND at
 	// least one base is active. With no active base the prompt is answered
 	// without retrieval (mirroring the daemon's LiveSession.Prompt), so a plain
@@ -325,23 +421,45 @@ func handlePrompt(client openai.Client, params openai.ChatCompletionNewParams, p
 
 	// Rewrite the query for richer BM25 matching using conversation context.
 	// On the first turn (no history) this returns the original prompt.
+	asked := time.Now()
 	lexicalQuery := prompt
 	ragContext := ""
+	var hits []knowledge.SearchHit
 	if hasContext {
 		lexicalQuery = rewriteSearchQuery(client, params.Model, params.Messages, prompt, verbose)
 		// Retrieve RAG context from knowledge base (no-op when unavailable).
-		ragContext = retrieveContext(session, prompt, lexicalQuery, verbose)
+		if again != nil {
+			fmt.Println(dim(lexicalQueryChange(again.lexicalQuery, lexicalQuery)))
+		}
+		hits = retrieve(client, params.Model, params.Messages, session, prompt, lexicalQuery, verbose)
+		ragContext, hits = session.Settings.fitContext(client, params.Model, session.ContextWindow, hits, verbose)
+	}
+	turn := &lastTurn{prompt: prompt, before: len(params.Messages)}
+	if hasContext {
+		turn.lexicalQuery = lexicalQuery
 	}
 
 	// Build the message sent to the LLM: augmented when context is found.
 	// When a base is active but retrieval returned nothing, inject an explicit
 	// empty-context note so the grounding rules in the system prompt apply and
 	// the model does not answer from parametric knowledge.
-	llmPrompt := prompt
-	if ragContext != "" {
-		llmPrompt = buildRAGPrompt(ragContext, prompt)
-	} else if hasContext {
-		llmPrompt = buildRAGPrompt("No relevant context was retrieved for this query.", prompt)
+	llmPrompt := session.Settings.assemblePrompt(ragContext, prompt, hits, hasContext, verbose)
+
+	// Only answers grounded on retrieval are cached: without it, the key says
+	// nothing about the conversation a follow-up question depends on.
+	var cacheKey string
+	if session.ResponseCache != nil && hasContext {
+		cacheKey = responseCacheKey(params, prompt, session, ragContext)
+		if cached, ok := session.ResponseCache.Get(cacheKey); ok && !noCache {
+			fmt.Println(cached.Answer)
+			fmt.Println(dim(fmt.Sprintf("(cached answer from %s; prefix the prompt with %s to ask again)",
+				cached.Created.Format(time.DateTime), cmdNoCache)))
+			params.Messages = append(params.Messages, openai.UserMessage(prompt), openai.AssistantMessage(cached.Answer))
+			session.recordExchange(asked, cached.Sources)
+			turn.after = len(params.Messages)
+			session.lastTurn = turn
+			return params, nil
+		}
 	}
 
 	// Build a temporary copy of the message history so the augmented prompt
@@ -358,14 +476,34 @@ func handlePrompt(client openai.Client, params openai.ChatCompletionNewParams, p
 		fmt.Printf("Sending request: %s\n", paramDebugString)
 	}
 
+	// Ask for a final usage chunk so the token statistics are exact; servers
+	// that ignore this fall back to counting streamed chunks.
+	apiParams.StreamOptions = openai.ChatCompletionStreamOptionsParam{IncludeUsage: openai.Bool(true)}
+
+	// With /set max-time, generation is cut off after that long and the
+	// partial answer kept.
+	genCtx, cancel := context.Background(), context.CancelFunc(func() {})
+	if session.MaxTime > 0 {
+		genCtx, cancel = context.WithTimeout(genCtx, session.MaxTime)
+	}
+	defer cancel()
+
 	stopProgress := common.StartProgressSpinner("Generating an answer")
-	stream := client.Chat.Completions.NewStreaming(context.Background(), apiParams)
+	meter := newStreamMeter()
+	stream := client.Chat.Completions.NewStreaming(genCtx, apiParams)
 	stopProgress()
 
-	appendParam, err := processStream(stream)
-	if err != nil {
+	appendParam, usage, err := processStream(genCtx, stream, meter)
+	cutOff := errors.Is(err, context.DeadlineExceeded)
+	if err != nil && !cutOff {
 		return params, err
 	}
+	stats := meter.finish(usage)
+	session.stats.add(stats)
+	if cutOff {
+		fmt.Println()
+		fmt.Println(dim(fmt.Sprintf("Answer cut off after %s (%s max-time); the partial answer is kept.", session.MaxTime, cmdSet)))
+	}
 
 	// Store the original prompt (not the augmented one) plus the assistant
 	// response in the conversation history.
@@ -373,12 +511,29 @@ func handlePrompt(client openai.Client, params openai.ChatCompletionNewParams, p
 	if appendParam != nil {
 		params.Messages = append(params.Messages, *appendParam)
 	}
+	session.recordExchange(asked, rag.Sources(hits))
+	turn.after = len(params.Messages)
+	session.lastTurn = turn
+	if cacheKey != "" && appendParam != nil && !cutOff {
+		answer := cachedResponse(appendParam, rag.Sources(hits))
+		if err := session.ResponseCache.Put(cacheKey, answer); err != nil && verbose {
+			fmt.Printf("Answer not cached: %v\n", err)
+		}
+	}
 	fmt.Println()
+	if verbose {
+		fmt.Println(dim(stats.String()))
+	}
 
 	return params, nil
 }
 
-func processStream(stream *ssestream.Stream[openai.ChatCompletionChunk]) (*openai.ChatCompletionMessageParamUnion, error) {
+// processStream prints the streamed answer, feeding each content delta to meter,
+// and returns the assistant message to append to history along with the usage
+// the server reported (zero when it reported none). When ctx's deadline cuts
+// the stream off, the answer streamed so far is returned, ending in
+// cutOffNotice, with ctx's error.
+func processStream(ctx context.Context, stream *ssestream.Stream[openai.ChatCompletionChunk], meter *streamMeter) (*openai.ChatCompletionMessageParamUnion, openai.CompletionUsage, error) {
 	// optionally, an accumulator helper can be used
 	acc := openai.ChatCompletionAccumulator{}
 
@@ -405,6 +560,7 @@ func processStream(stream *ssestream.Stream[openai.ChatCompletionChunk]) (*opena
 		// Print chunks as they are received
 		if len(chunk.Choices) > 0 {
 			lastChunk := chunk.Choices[0].Delta.Content
+			meter.observe(lastChunk)
 
 			if strings.Contains(lastChunk, "<think>") {
 				thinking = true
@@ -423,24 +579,32 @@ func processStream(stream *ssestream.Stream[openai.ChatCompletionChunk]) (*opena
 	}
 
 	if err := stream.Err(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			var partial *openai.ChatCompletionMessageParamUnion
+			if len(acc.Choices) > 0 && acc.Choices[0].Message.Content != "" {
+				msg := openai.AssistantMessage(acc.Choices[0].Message.Content + "\n\n" + cutOffNotice)
+				partial = &msg
+			}
+			return partial, acc.Usage, ctx.Err()
+		}
 		if errors.Is(err, syscall.ECONNREFUSED) { // connection refused before streaming
-			return nil, fmt.Errorf("connection refused\n\n%s",
+			return nil, openai.CompletionUsage{}, fmt.Errorf("connection refused\n\n%s",
 				common.SuggestServerLogs())
 		} else if errors.Is(err, io.ErrUnexpectedEOF) {
 			fmt.Println() // break the line after incomplete stream
-			return nil, fmt.Errorf("connection closed by server\n\n%s",
+			return nil, openai.CompletionUsage{}, fmt.Errorf("connection closed by server\n\n%s",
 				common.SuggestServerLogs())
 		}
-		return nil, fmt.Errorf("%s\n\n%s", err,
+		return nil, openai.CompletionUsage{}, fmt.Errorf("%s\n\n%s", err,
 			common.SuggestServerLogs())
 	}
 
 	// After the stream is finished, acc can be used like a ChatCompletion
-	appendParam := acc.Choices[0].Message.ToParam()
-	if acc.Choices[0].Message.Content == "" {
-		return nil, nil
+	if len(acc.Choices) == 0 || acc.Choices[0].Message.Content == "" {
+		return nil, acc.Usage, nil
 	}
-	return &appendParam, nil
+	appendParam := acc.Choices[0].Message.ToParam()
+	return &appendParam, acc.Usage, nil
 }
 
 func filterInput(r rune) (rune, bool) {
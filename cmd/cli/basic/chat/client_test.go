@@ -0,0 +1,54 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// TestProcessStreamCutOff verifies an answer cut off by its deadline keeps
+// what was streamed, marked as cut off.
+func TestProcessStreamCutOff(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, token := range []string{"Add the node ", "with microcloud add"} {
+			fmt.Fprintf(w, "data: {\"id\":\"1\",\"object\":\"chat.completion.chunk\",\"created\":1,\"model\":\"m\","+
+				"\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", token)
+		}
+		w.(http.Flusher).Flush()
+		select { // a slow model that never finishes
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	client := NewInferenceClient(srv.URL)
+	stream := client.Chat.Completions.NewStreaming(ctx, openai.ChatCompletionNewParams{
+		Model:    "m",
+		Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("How do I add a node?")},
+	})
+
+	answer, _, err := processStream(ctx, stream, newStreamMeter())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("processStream error = %v, want deadline exceeded", err)
+	}
+	if answer == nil {
+		t.Fatal("processStream dropped the partial answer")
+	}
+	content := answer.OfAssistant.Content.OfString.Value
+	if !strings.HasPrefix(content, "Add the node with microcloud add") || !strings.HasSuffix(content, cutOffNotice) {
+		t.Errorf("partial answer = %q", content)
+	}
+}
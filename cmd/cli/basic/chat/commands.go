@@ -5,12 +5,17 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/huh"
 	"github.com/chzyer/readline"
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
+	"github.com/fatih/color"
 	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/storage"
 )
 
 const (
@@ -19,8 +24,19 @@ const (
 	cmdSearch       = "/search"
 	cmdSave         = "/save"
 	cmdHistory      = "/history"
+	cmdExport       = "/export"
+	cmdModel        = "/model"
+	cmdStats        = "/stats"
+	cmdSet          = "/set"
+	cmdFilter       = "/filter"
+	cmdRecall       = "/recall"
+	cmdReconnect    = "/reconnect"
 )
 
+// historyClearArg is the /history argument that clears the prompt history
+// instead of opening the saved chats.
+const historyClearArg = "clear"
+
 // slashCommand describes a registered slash command and its argument syntax.
 type slashCommand struct {
 	name   string // e.g. "/search"
@@ -33,7 +49,18 @@ var slashCommands = []slashCommand{
 	{name: cmdUseKapa},
 	{name: cmdSearch, syntax: "[-k N] <query>"},
 	{name: cmdSave, syntax: "[title]"},
-	{name: cmdHistory},
+	{name: cmdHistory, syntax: "[clear]"},
+	{name: cmdExport, syntax: "<file.md|file.html>"},
+	{name: cmdModel, syntax: "[name]"},
+	{name: cmdStats},
+	{name: cmdSet, syntax: "<option> <value>"},
+	{name: cmdFilter, syntax: "[since|until <time> | versions on|off | clear]"},
+	{name: cmdRecall, syntax: "[topic]"},
+	{name: cmdReconnect},
+	{name: cmdNoCache, syntax: "<prompt>"},
+	{name: cmdAgain, syntax: "[new query]"},
+	{name: cmdRewind, syntax: "[N]"},
+	{name: cmdFork, syntax: "[name]"},
 }
 
 // syntaxHint returns the argument syntax to show as dimmed ghost text when
@@ -59,9 +86,10 @@ type syntaxPainter struct{}
 // walks back over the real buffer length (ignoring runes Paint appends), so with
 // the cursor at end-of-line the trailing CSI-D is what restores the cursor.
 func (syntaxPainter) Paint(line []rune, pos int) []rune {
-	if pos != len(line) {
+	if pos != len(line) || color.NoColor {
 		// Only ghost when the cursor is at end-of-line; gating here avoids
-		// tangling with readline's mid-line backspace sequence.
+		// tangling with readline's mid-line backspace sequence. Without color
+		// the ghost would be indistinguishable from typed text.
 		return line
 	}
 	suffix, ok := syntaxHint(string(line))
@@ -97,7 +125,7 @@ func slashHinter() readline.Listener {
 			if len(matches) > 0 {
 				fmt.Fprint(os.Stderr, "\033[s")
 				for _, m := range matches {
-					fmt.Fprintf(os.Stderr, "\n  \033[90m%s\033[0m", m)
+					fmt.Fprintf(os.Stderr, "\n  %s", dim(m))
 				}
 				fmt.Fprint(os.Stderr, "\033[u")
 			}
@@ -112,6 +140,14 @@ func slashHinter() readline.Listener {
 	})
 }
 
+// dim renders s in the dark gray used for hints, or plain under --no-color.
+func dim(s string) string {
+	if color.NoColor {
+		return s
+	}
+	return "\033[90m" + s + "\033[0m"
+}
+
 // clearSlashHints removes any slash command hints displayed below the
 // current input line. Safe to call even when no hints are showing.
 func clearSlashHints() {
@@ -127,6 +163,58 @@ type Session struct {
 	EmbeddingModelID string
 	ActiveIndexes    []string
 	ActiveKapaGroups []string
+	// Settings are the chat.* settings the session retrieves, prompts, and
+	// caches with.
+	Settings Settings
+	// InferenceURL is the OpenAI-compatible server the session talks to; /model
+	// lists the models it exposes.
+	InferenceURL string
+	// ModelName is the model used for the next message. /model changes it
+	// mid-session when the server exposes several.
+	ModelName string
+	// MultiQuery widens retrieval with LLM paraphrases of each question,
+	// fused with reciprocal rank fusion. /set multiquery toggles it.
+	MultiQuery bool
+	// TopK is how many hits each retrieval search fetches, and MinScore the
+	// score below which knowledge base hits are dropped. They start from
+	// chat.rag.top-k and chat.rag.min-score; /set changes them.
+	TopK     int
+	MinScore float64
+	// MaxTime cuts an answer off after that long generating, keeping what
+	// was generated; 0 lets it run to the end. /set max-time changes it.
+	MaxTime time.Duration
+	// Filter restricts knowledge base retrieval, for both RAG context and
+	// /search. /filter sets its date range and whether replaced versions
+	// of sources are retrieved.
+	Filter knowledge.SearchOptions
+	// ContextWindow is the model's context length in tokens as reported by
+	// the inference server, or 0 when unknown. Without chat.context.max, it
+	// sizes the retrieved context.
+	ContextWindow int
+	// SavedSearches resolves /search <name> to a saved search; nil leaves
+	// every /search a plain query.
+	SavedSearches savedSearchStore
+	// ResponseCache answers repeated prompts without the model; nil when
+	// chat.cache.ttl leaves caching off.
+	ResponseCache *storage.ResponseCache
+	// KnowledgeURL is where the REPL reconnects to the knowledge base after
+	// losing it; empty for sessions that do not track its availability.
+	KnowledgeURL string
+	// KnowledgeSettings are the settings the REPL reconnects with.
+	KnowledgeSettings knowledge.Settings
+	// knowledgeOffline is set while the knowledge base is unreachable, and
+	// lastReconnect is when the session last tried to reach it again.
+	knowledgeOffline bool
+	lastReconnect    time.Time
+	// exchanges records, per user prompt in the history, when it was asked and
+	// answered and which sources grounded the answer. The message history has
+	// no room for either, so exports and saves annotate turns from here.
+	exchanges []exchange
+	// stats accumulates per-response token and timing statistics for /stats.
+	stats sessionStats
+	// lastTurn is the most recently answered prompt, which /again runs again;
+	// nil before the first answer.
+	lastTurn *lastTurn
 }
 
 // handleSlashCommand processes slash commands entered in the chat REPL.
@@ -152,6 +240,23 @@ func handleSlashCommand(input string, session *Session) bool {
 	case cmdSearch:
 		handleSearch(args, session)
 		return true
+	case cmdModel:
+		if err := selectModel(session, strings.TrimSpace(args)); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+		return true
+	case cmdStats:
+		printSessionStats(session)
+		return true
+	case cmdSet:
+		handleSet(args, session)
+		return true
+	case cmdFilter:
+		handleFilter(args, session)
+		return true
+	case cmdReconnect:
+		handleReconnect(session)
+		return true
 	default:
 		names := make([]string, len(slashCommands))
 		for i, c := range slashCommands {
@@ -266,3 +371,175 @@ func selectKapaGroups(session *Session) error {
 
 	return nil
 }
+
+// selectModel implements /model: it lists the models the inference server
+// exposes and switches the session to the one named in args, or to the one
+// picked from an interactive menu when args is empty. The switch applies from
+// the next message on.
+func selectModel(session *Session, args string) error {
+	if session.InferenceURL == "" {
+		return fmt.Errorf("model selection is not available for this session")
+	}
+
+	names, err := findModelNames(session.InferenceURL, false)
+	if err != nil {
+		return fmt.Errorf("listing models: %w", err)
+	}
+
+	chosen := args
+	if chosen == "" {
+		chosen, err = pickModel(names, session.ModelName)
+		if errors.Is(err, huh.ErrUserAborted) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	} else if !slices.Contains(names, chosen) {
+		return fmt.Errorf("model %q is not served; available models: %s", chosen, strings.Join(names, ", "))
+	}
+
+	if chosen == session.ModelName {
+		fmt.Printf("Already using model %s.\n", chosen)
+		return nil
+	}
+	session.ModelName = chosen
+	session.ContextWindow = DetectContextWindow(context.Background(), session.InferenceURL, chosen)
+	fmt.Printf("Using model %s from the next message on.\n", chosen)
+	return nil
+}
+
+// pickModel presents the served models in a select menu, pre-selecting current
+// when it is among them, and returns the chosen name. A cancelled menu returns
+// huh.ErrUserAborted.
+func pickModel(names []string, current string) (string, error) {
+	options := make([]huh.Option[string], len(names))
+	for i, name := range names {
+		options[i] = huh.NewOption(name, name)
+	}
+
+	chosen := current
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Select the chat model").
+				Options(options...).
+				Value(&chosen),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return "", err
+	}
+	return chosen, nil
+}
+
+// handleSet processes /set <option> <value>, changing a retrieval or generation
+// setting for the rest of the session. With no arguments it shows the current
+// settings.
+func handleSet(args string, session *Session) {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		fmt.Printf("multiquery: %s\n", onOff(session.MultiQuery))
+		fmt.Printf("top-k:      %d\n", sessionTopK(session))
+		fmt.Printf("min-score:  %g\n", session.MinScore)
+		fmt.Printf("max-time:   %s\n", maxTimeString(session.MaxTime))
+		if b := session.Settings.budgetFor(session.ContextWindow); b.maxChars > 0 {
+			fmt.Printf("context:    %d characters max", b.maxChars)
+			if session.ContextWindow > 0 {
+				fmt.Printf(" (model window: %d tokens)", session.ContextWindow)
+			}
+			fmt.Println()
+		}
+		return
+	}
+	if len(fields) != 2 {
+		fmt.Printf("Usage: %s <option> <value>  (options: multiquery on|off, top-k <n>, min-score <score>, max-time <duration>|off)\n", cmdSet)
+		return
+	}
+
+	switch option, value := fields[0], fields[1]; option {
+	case "multiquery":
+		on, ok := parseOnOff(value)
+		if !ok {
+			fmt.Printf("Invalid value %q for multiquery: expected on or off\n", value)
+			return
+		}
+		session.MultiQuery = on
+		fmt.Printf("Multi-query retrieval %s.\n", onOff(on))
+	case "top-k":
+		k, err := strconv.Atoi(value)
+		if err != nil || k <= 0 {
+			fmt.Printf("Invalid value %q for top-k: expected a positive number of hits\n", value)
+			return
+		}
+		session.TopK = k
+		fmt.Printf("Retrieving up to %d hits per search.\n", k)
+	case "min-score":
+		score, err := strconv.ParseFloat(value, 64)
+		if err != nil || score < 0 {
+			fmt.Printf("Invalid value %q for min-score: expected a non-negative score\n", value)
+			return
+		}
+		session.MinScore = score
+		if score == 0 {
+			fmt.Println("Keeping hits of any score.")
+		} else {
+			fmt.Printf("Dropping hits scoring below %g.\n", score)
+		}
+	case "max-time":
+		d, ok := parseMaxTime(value)
+		if !ok {
+			fmt.Printf("Invalid value %q for max-time: expected a duration such as 30s, or off\n", value)
+			return
+		}
+		session.MaxTime = d
+		if d == 0 {
+			fmt.Println("Answers run to the end.")
+		} else {
+			fmt.Printf("Cutting answers off after %s.\n", d)
+		}
+	default:
+		fmt.Printf("Unknown option %q (options: multiquery, top-k, min-score, max-time)\n", option)
+	}
+}
+
+// cutOffNotice ends an answer cut off by max-time in the history.
+const cutOffNotice = "[answer cut off: max-time reached]"
+
+// parseMaxTime parses a /set max-time value: a positive duration, or off or 0
+// for no limit.
+func parseMaxTime(s string) (time.Duration, bool) {
+	if on, ok := parseOnOff(s); ok && !on {
+		return 0, true
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, false
+	}
+	return d, true
+}
+
+// maxTimeString shows a max-time setting.
+func maxTimeString(d time.Duration) string {
+	if d == 0 {
+		return "off"
+	}
+	return d.String()
+}
+
+func parseOnOff(s string) (bool, bool) {
+	switch strings.ToLower(s) {
+	case "on", "true", "yes", "1":
+		return true, true
+	case "off", "false", "no", "0":
+		return false, true
+	}
+	return false, false
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
@@ -1,6 +1,9 @@
 package chat
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestSyntaxHint(t *testing.T) {
 	tests := []struct {
@@ -17,7 +20,18 @@ func TestSyntaxHint(t *testing.T) {
 		{"command without args", "/use-knowledge", "", false},
 		{"save command", "/save", "[title]", true},
 		{"save with title started", "/save notes", "", false},
-		{"history command has no args", "/history", "", false},
+		{"history command", "/history", "[clear]", true},
+		{"history clear started", "/history clear", "", false},
+		{"model command", "/model", "[name]", true},
+		{"model name started", "/model llama", "", false},
+		{"stats command has no args", "/stats", "", false},
+		{"set command", "/set", "<option> <value>", true},
+		{"filter command", "/filter", "[since|until <time> | versions on|off | clear]", true},
+		{"filter bound started", "/filter since 7d", "", false},
+		{"recall command", "/recall", "[topic]", true},
+		{"recall topic started", "/recall ceph", "", false},
+		{"export command", "/export", "<file.md|file.html>", true},
+		{"export path started", "/export chat.md", "", false},
 		{"bare slash", "/", "", false},
 		{"plain text", "hello", "", false},
 		{"empty", "", "", false},
@@ -40,6 +54,9 @@ func TestSlashCommandsRegistered(t *testing.T) {
 		cmdSearch:       false,
 		cmdSave:         false,
 		cmdHistory:      false,
+		cmdExport:       false,
+		cmdModel:        false,
+		cmdReconnect:    false,
 	}
 	for _, c := range slashCommands {
 		if _, ok := want[c.name]; ok {
@@ -52,3 +69,23 @@ func TestSlashCommandsRegistered(t *testing.T) {
 		}
 	}
 }
+
+func TestParseMaxTime(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"30s", 30 * time.Second, true},
+		{"2m", 2 * time.Minute, true},
+		{"off", 0, true},
+		{"0", 0, true},
+		{"-5s", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		if got, ok := parseMaxTime(tt.value); got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseMaxTime(%q) = (%v, %v), want (%v, %v)", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package chat
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+)
+
+// parseConfidence reads the top retrieval score below which a
+// knowledge-seeking question is answered with "I cannot find it" rather than
+// a guess, from the chat.rag.confidence config value (a non-negative score;
+// empty or 0 for off).
+func parseConfidence(value string) (float64, error) {
+	if value = strings.TrimSpace(value); value == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid chat.rag.confidence %q: expected a non-negative score", value)
+	}
+	return f, nil
+}
+
+// lowConfidenceNote follows the context of a prompt whose retrieval scored
+// below chat.rag.confidence.
+const lowConfidenceNote = "The context above scored low for relevance to this question and likely does not answer it. " +
+	"Unless it clearly does, reply that you cannot find the answer in the knowledge base, " +
+	"and do not answer from general knowledge."
+
+// knowledgeSeeking matches prompts that ask for information: questions, and
+// requests to explain, describe, or list something.
+var knowledgeSeeking = regexp.MustCompile(`(?i)\?\s*$|^\s*(?:what|how|why|when|where|which|who|whose|whom|is|are|was|were|can|could|does|do|did|should|will|would|has|have|explain|describe|list|summari[sz]e|compare|define|tell me|show me|give me)\b`)
+
+// lowConfidence reports whether hits are too weak to ground an answer to
+// prompt: the prompt seeks knowledge, and no knowledge base hit scores
+// chat.rag.confidence. Kapa hits carry rank positions rather than relevance
+// scores, so they do not count. It says what it decided in verbose mode.
+func (s Settings) lowConfidence(prompt string, hits []knowledge.SearchHit, verbose bool) bool {
+	if s.confidence <= 0 || !knowledgeSeeking.MatchString(prompt) {
+		return false
+	}
+	top, scored := 0.0, false
+	for _, hit := range hits {
+		if hit.Index == knowledge.KapaIndexName {
+			continue
+		}
+		if !scored || hit.Score > top {
+			top, scored = hit.Score, true
+		}
+	}
+	if !scored && len(hits) > 0 {
+		// Only Kapa hits: nothing to score them by.
+		return false
+	}
+	low := top < s.confidence
+	if verbose {
+		if low {
+			fmt.Printf("Top retrieval score %.3f is below chat.rag.confidence %g: asking the model to say it cannot find the answer\n", top, s.confidence)
+		} else {
+			fmt.Printf("Top retrieval score %.3f meets chat.rag.confidence %g\n", top, s.confidence)
+		}
+	}
+	return low
+}
+
+// assemblePrompt builds the message sent to the model for prompt: augmented
+// with ragContext when retrieval found some, and with an explicit empty
+// context when hasContext says a knowledge source was searched but nothing was
+// found, so the grounding rules apply. Weak context (see lowConfidence) is
+// followed by an instruction to say the answer cannot be found.
+func (s Settings) assemblePrompt(ragContext, prompt string, hits []knowledge.SearchHit, hasContext, verbose bool) string {
+	switch {
+	case ragContext != "" && s.lowConfidence(prompt, hits, verbose):
+		return s.buildPrompt(ragContext+"\n\n"+lowConfidenceNote, prompt)
+	case ragContext != "":
+		return s.buildPrompt(ragContext, prompt)
+	case hasContext:
+		return s.buildPrompt("No relevant context was retrieved for this query.", prompt)
+	}
+	return prompt
+}
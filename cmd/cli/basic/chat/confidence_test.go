@@ -0,0 +1,68 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+)
+
+func TestParseConfidence(t *testing.T) {
+	for value, want := range map[string]float64{"": 0, "0": 0, " 0.45 ": 0.45} {
+		if got, err := parseConfidence(value); err != nil || got != want {
+			t.Errorf("parseConfidence(%q) = %g, %v; want %g", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"-1", "high"} {
+		if _, err := parseConfidence(value); err == nil {
+			t.Errorf("parseConfidence(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestLowConfidence(t *testing.T) {
+	s := Settings{confidence: 0.5}
+
+	weak := []knowledge.SearchHit{{Index: "docs", Score: 0.2}, {Index: knowledge.KapaIndexName, Score: 1}}
+	strong := []knowledge.SearchHit{{Index: "docs", Score: 0.2}, {Index: "docs", Score: 0.7}}
+	tests := []struct {
+		name   string
+		prompt string
+		hits   []knowledge.SearchHit
+		want   bool
+	}{
+		{"weak hits for a question", "How do I refresh a snap?", weak, true},
+		{"weak hits for a request", "explain snap confinement", weak, true},
+		{"strong hits", "How do I refresh a snap?", strong, false},
+		{"not knowledge-seeking", "thanks, that helped", weak, false},
+		{"kapa hits only", "What is a snap?", []knowledge.SearchHit{{Index: knowledge.KapaIndexName, Score: 1}}, false},
+	}
+	for _, tt := range tests {
+		if got := s.lowConfidence(tt.prompt, tt.hits, false); got != tt.want {
+			t.Errorf("%s: lowConfidence = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if (Settings{}).lowConfidence("How do I refresh a snap?", weak, false) {
+		t.Error("lowConfidence is on with chat.rag.confidence unset")
+	}
+}
+
+func TestAssemblePrompt(t *testing.T) {
+	s := Settings{confidence: 0.5}
+
+	weak := []knowledge.SearchHit{{Index: "docs", Score: 0.1}}
+	if got := s.assemblePrompt("ctx", "What is a snap?", weak, true, false); !strings.Contains(got, lowConfidenceNote) {
+		t.Errorf("weak context prompt lacks the note:\n%s", got)
+	}
+	strong := []knowledge.SearchHit{{Index: "docs", Score: 0.9}}
+	if got := s.assemblePrompt("ctx", "What is a snap?", strong, true, false); strings.Contains(got, lowConfidenceNote) {
+		t.Errorf("strong context prompt has the note:\n%s", got)
+	}
+	if got := s.assemblePrompt("", "Hi", nil, false, false); got != "Hi" {
+		t.Errorf("prompt without retrieval = %q, want it unchanged", got)
+	}
+	if got := s.assemblePrompt("", "Hi", nil, true, false); !strings.Contains(got, "No relevant context") {
+		t.Errorf("prompt with empty retrieval = %q, want the empty-context note", got)
+	}
+}
@@ -0,0 +1,129 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jpnorenam/rag-snap/pkg/httpclient"
+)
+
+const (
+	// contextWindowTimeout bounds each context window probe, so a server
+	// that does not answer them cannot delay the session.
+	contextWindowTimeout = 5 * time.Second
+	// ragContextShare is the share of the model's context window given to
+	// retrieved context when chat.context.max is unset; the rest is left to
+	// the system prompt, the history, and the answer.
+	ragContextShare = 0.5
+	// charsPerToken approximates how many characters a token covers, to size
+	// the character budget from a window in tokens.
+	charsPerToken = 4
+)
+
+// DetectContextWindow asks the inference server for model's context length in
+// tokens, returning 0 when it does not say. It reads the model list first
+// (vLLM's max_model_len and the context_length other servers report), then
+// llama-server's /props, which has the context the server was started with,
+// and only then the model's training context from llama-server's model list,
+// which the server may have been started below.
+func DetectContextWindow(ctx context.Context, baseURL, model string) int {
+	models, _ := getJSON(ctx, strings.TrimSuffix(baseURL, "/")+"/models")
+	if n := modelsContextWindow(models, model, false); n > 0 {
+		return n
+	}
+	if props, err := getJSON(ctx, serverRoot(baseURL)+"/props"); err == nil {
+		if n := propsContextWindow(props); n > 0 {
+			return n
+		}
+	}
+	return modelsContextWindow(models, model, true)
+}
+
+// serverRoot strips the OpenAI API version path from baseURL, for the
+// endpoints llama-server serves beside it.
+func serverRoot(baseURL string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/v1")
+}
+
+// getJSON fetches url with the inference server's credentials and returns the
+// response body.
+func getJSON(ctx context.Context, url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextWindowTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if key := os.Getenv("CHAT_API_KEY"); key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	resp, err := httpclient.New(0).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// modelsContextWindow reads model's context length from a /models response:
+// the serving length servers report, or with training set, llama-server's
+// training length.
+func modelsContextWindow(body []byte, model string, training bool) int {
+	var list struct {
+		Data []struct {
+			ID               string `json:"id"`
+			MaxModelLen      int    `json:"max_model_len"`
+			ContextLength    int    `json:"context_length"`
+			MaxContextLength int    `json:"max_context_length"`
+			Meta             struct {
+				NCtxTrain int `json:"n_ctx_train"`
+			} `json:"meta"`
+		} `json:"data"`
+	}
+	if len(body) == 0 || json.Unmarshal(body, &list) != nil {
+		return 0
+	}
+	for _, m := range list.Data {
+		if m.ID != model {
+			continue
+		}
+		if training {
+			return m.Meta.NCtxTrain
+		}
+		for _, n := range []int{m.MaxModelLen, m.ContextLength, m.MaxContextLength} {
+			if n > 0 {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// propsContextWindow reads the context size from a llama-server /props
+// response, which reports it per slot under default_generation_settings and,
+// in newer releases, at the top level.
+func propsContextWindow(body []byte) int {
+	var props struct {
+		NCtx     int `json:"n_ctx"`
+		Defaults struct {
+			NCtx int `json:"n_ctx"`
+		} `json:"default_generation_settings"`
+	}
+	if json.Unmarshal(body, &props) != nil {
+		return 0
+	}
+	if props.Defaults.NCtx > 0 {
+		return props.Defaults.NCtx
+	}
+	return props.NCtx
+}
@@ -0,0 +1,71 @@
+package chat
+
+import "testing"
+
+func TestModelsContextWindow(t *testing.T) {
+	vllm := []byte(`{"data": [{"id": "other", "max_model_len": 2048}, {"id": "qwen", "max_model_len": 32768}]}`)
+	if got := modelsContextWindow(vllm, "qwen", false); got != 32768 {
+		t.Errorf("vLLM max_model_len = %d, want 32768", got)
+	}
+	if got := modelsContextWindow(vllm, "missing", false); got != 0 {
+		t.Errorf("unlisted model = %d, want 0", got)
+	}
+
+	llama := []byte(`{"data": [{"id": "gemma", "meta": {"n_ctx_train": 131072}}]}`)
+	if got := modelsContextWindow(llama, "gemma", false); got != 0 {
+		t.Errorf("serving length from llama-server's list = %d, want 0", got)
+	}
+	if got := modelsContextWindow(llama, "gemma", true); got != 131072 {
+		t.Errorf("training length = %d, want 131072", got)
+	}
+	if got := modelsContextWindow(nil, "gemma", false); got != 0 {
+		t.Errorf("empty body = %d, want 0", got)
+	}
+}
+
+func TestPropsContextWindow(t *testing.T) {
+	tests := []struct {
+		body string
+		want int
+	}{
+		{`{"default_generation_settings": {"n_ctx": 8192}}`, 8192},
+		{`{"n_ctx": 4096}`, 4096},
+		{`{}`, 0},
+		{`not json`, 0},
+	}
+	for _, tt := range tests {
+		if got := propsContextWindow([]byte(tt.body)); got != tt.want {
+			t.Errorf("propsContextWindow(%s) = %d, want %d", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestServerRoot(t *testing.T) {
+	for _, base := range []string{"http://localhost:8328/v1", "http://localhost:8328/v1/"} {
+		if got := serverRoot(base); got != "http://localhost:8328" {
+			t.Errorf("serverRoot(%q) = %q, want http://localhost:8328", base, got)
+		}
+	}
+}
+
+func TestBudgetFor(t *testing.T) {
+	var s Settings
+	if got := s.budgetFor(0).maxChars; got != 0 {
+		t.Errorf("unknown window, no chat.context.max: maxChars = %d, want 0", got)
+	}
+	if got := s.budgetFor(8192).maxChars; got != 16384 {
+		t.Errorf("8192-token window: maxChars = %d, want 16384", got)
+	}
+	s, err := ParseSettings(func(key string) string {
+		if key == ConfContextMax {
+			return "12000"
+		}
+		return ""
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.budgetFor(8192).maxChars; got != 12000 {
+		t.Errorf("chat.context.max set: maxChars = %d, want 12000", got)
+	}
+}
@@ -0,0 +1,83 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+)
+
+const filterUsage = "Usage: /filter since <time> | until <time> | versions on|off | clear  (time: 7d, 12h, 2024-05-01, or 2024-05-01 14:00:00)"
+
+// handleFilter implements /filter. With no arguments it shows the session's
+// date range; "since" and "until" set one end, taking an age relative to now,
+// a date, or a UTC timestamp as knowledge search --since and --until do;
+// "versions on" also retrieves the chunks kept for replaced versions of
+// sources, as knowledge search --versions does; and "clear" removes the range
+// and turns versions off. The filter applies to knowledge base retrieval only:
+// Kapa has no ingest dates or versions to filter on.
+func handleFilter(args string, session *Session) {
+	verb, value, _ := strings.Cut(strings.TrimSpace(args), " ")
+	value = strings.TrimSpace(value)
+
+	switch verb {
+	case "":
+		fmt.Println(describeFilter(session.Filter))
+	case "clear":
+		session.Filter.Since, session.Filter.Until = time.Time{}, time.Time{}
+		session.Filter.Versions = false
+		fmt.Println(describeFilter(session.Filter))
+	case "versions":
+		switch value {
+		case "on", "off":
+			session.Filter.Versions = value == "on"
+			fmt.Println(describeFilter(session.Filter))
+		default:
+			fmt.Println(filterUsage)
+		}
+	case "since", "until":
+		if value == "" {
+			fmt.Println(filterUsage)
+			return
+		}
+		t, err := knowledge.ParseTimeBound(value, time.Now(), verb == "until")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if verb == "since" {
+			session.Filter.Since = t
+		} else {
+			session.Filter.Until = t
+		}
+		fmt.Println(describeFilter(session.Filter))
+	default:
+		fmt.Println(filterUsage)
+	}
+}
+
+// describeFilter renders the session's date range for /filter, and whether
+// replaced versions are retrieved. Relative ages were resolved when set, so
+// the bounds are shown as absolute UTC times.
+func describeFilter(f knowledge.SearchOptions) string {
+	if f.Versions {
+		return describeDates(f) + " Replaced versions of sources are included."
+	}
+	return describeDates(f)
+}
+
+// describeDates renders the session's date range for describeFilter.
+func describeDates(f knowledge.SearchOptions) string {
+	const layout = "2006-01-02 15:04 UTC"
+	switch {
+	case f.Since.IsZero() && f.Until.IsZero():
+		return "No date filter: retrieval searches chunks ingested at any time."
+	case f.Until.IsZero():
+		return "Retrieving chunks ingested since " + f.Since.UTC().Format(layout) + "."
+	case f.Since.IsZero():
+		return "Retrieving chunks ingested before " + f.Until.UTC().Format(layout) + "."
+	default:
+		return fmt.Sprintf("Retrieving chunks ingested from %s to before %s.", f.Since.UTC().Format(layout), f.Until.UTC().Format(layout))
+	}
+}
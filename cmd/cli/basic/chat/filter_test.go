@@ -0,0 +1,50 @@
+package chat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHandleFilter(t *testing.T) {
+	session := &Session{}
+
+	handleFilter("since 2024-05-01", session)
+	if want := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC); !session.Filter.Since.Equal(want) {
+		t.Errorf("since = %v, want %v", session.Filter.Since, want)
+	}
+
+	handleFilter("until 2024-05-31 12:00:00", session)
+	if want := time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC); !session.Filter.Until.Equal(want) {
+		t.Errorf("until = %v, want %v", session.Filter.Until, want)
+	}
+
+	handleFilter("since yesterday", session)
+	if want := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC); !session.Filter.Since.Equal(want) {
+		t.Errorf("invalid value changed since to %v", session.Filter.Since)
+	}
+
+	handleFilter("versions on", session)
+	if !session.Filter.Versions {
+		t.Error("versions on left replaced versions out")
+	}
+
+	handleFilter("clear", session)
+	if !session.Filter.Since.IsZero() || !session.Filter.Until.IsZero() || session.Filter.Versions {
+		t.Errorf("clear left %+v", session.Filter)
+	}
+}
+
+func TestDescribeFilter(t *testing.T) {
+	session := &Session{}
+	if got, want := describeFilter(session.Filter), "No date filter: retrieval searches chunks ingested at any time."; got != want {
+		t.Errorf("describeFilter = %q, want %q", got, want)
+	}
+	session.Filter.Since = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	if got, want := describeFilter(session.Filter), "Retrieving chunks ingested since 2024-05-01 00:00 UTC."; got != want {
+		t.Errorf("describeFilter = %q, want %q", got, want)
+	}
+	session.Filter.Versions = true
+	if got, want := describeFilter(session.Filter), "Retrieving chunks ingested since 2024-05-01 00:00 UTC. Replaced versions of sources are included."; got != want {
+		t.Errorf("describeFilter = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,59 @@
+package chat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/rag"
+)
+
+// parseSanitize reads prompt injection mitigation from the chat.rag.sanitize
+// config value (true or false; empty for true). When on, directive-looking
+// lines are stripped from retrieved chunks, each chunk is wrapped in
+// <document> delimiters, and the prompt tells the model not to follow
+// instructions found inside them.
+func parseSanitize(value string) (bool, error) {
+	if value = strings.TrimSpace(value); value == "" {
+		return true, nil
+	}
+	on, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid chat.rag.sanitize %q: expected true or false", value)
+	}
+	return on, nil
+}
+
+// sanitizeHits strips directive-looking lines from hits when sanitization is
+// on, listing what it removed in verbose mode.
+func (s Settings) sanitizeHits(hits []knowledge.SearchHit, verbose bool) []knowledge.SearchHit {
+	if s.unsanitized {
+		return hits
+	}
+	clean, removed := rag.SanitizeHits(hits)
+	if verbose && len(removed) > 0 {
+		fmt.Printf("Sanitized context: removed %d directive-looking lines\n", len(removed))
+		for _, r := range removed {
+			fmt.Printf("  %s: %q\n", r.SourceID, r.Line)
+		}
+	}
+	return clean
+}
+
+// formatContext renders hits as the context block of a prompt, delimited
+// when sanitization is on.
+func (s Settings) formatContext(hits []knowledge.SearchHit) string {
+	if !s.unsanitized {
+		return rag.FormatDelimitedContext(hits)
+	}
+	return rag.FormatContext(hits)
+}
+
+// buildPrompt wraps prompt with ragContext, guarded when sanitization is on.
+func (s Settings) buildPrompt(ragContext, prompt string) string {
+	if !s.unsanitized {
+		return rag.BuildGuardedPrompt(ragContext, prompt)
+	}
+	return rag.BuildPrompt(ragContext, prompt)
+}
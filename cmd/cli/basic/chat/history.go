@@ -11,8 +11,8 @@ import (
 
 	"github.com/charmbracelet/huh"
 	"github.com/fatih/color"
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
 	"github.com/jpnorenam/rag-snap/internal/chatstore"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
 	"github.com/openai/openai-go/v3"
 )
 
@@ -51,6 +51,9 @@ func pickSavedChat(summaries []chatstore.Summary) (chatstore.Summary, bool) {
 	index := make(map[string]chatstore.Summary, len(summaries))
 	for i, s := range summaries {
 		label := fmt.Sprintf("%s  ·  %s  ·  %d turns", s.Title, relativeTime(s.UpdatedAt), s.TurnCount)
+		if s.Branch != "" {
+			label += "  ·  branch " + s.Branch
+		}
 		if s.Model != "" {
 			label += "  ·  " + s.Model
 		}
@@ -89,7 +92,7 @@ func saveDirectChat(store *chatstore.Store, chatID, title, model string, session
 		Title: strings.TrimSpace(title),
 		Model: model,
 		Bases: activeBaseNames(session),
-		Turns: historyToTurns(messages),
+		Turns: session.annotateTurns(historyToTurns(messages)),
 	})
 	if err != nil {
 		if errors.Is(err, chatstore.ErrEmpty) {
@@ -133,11 +136,133 @@ func resumeDirectChat(store *chatstore.Store, systemPrompt string, session *Sess
 		fmt.Printf("Note: skipping knowledge base(s) that no longer exist: %s\n", strings.Join(dropped, ", "))
 	}
 
+	session.exchanges = exchangesFromTurns(saved.Turns)
 	renderTranscript(saved.Turns)
 	fmt.Printf("Resumed %q. Continue the conversation below.\n", saved.Title)
 	return turnsToHistory(systemPrompt, saved.Turns), saved.ID, true
 }
 
+// exportDirectChat renders the direct-REPL conversation to path as Markdown or
+// HTML, chosen by the file extension. A conversation already saved keeps its
+// stored title; otherwise the title is derived from the first prompt.
+func exportDirectChat(store *chatstore.Store, chatID, path string, session *Session, messages []openai.ChatCompletionMessageParamUnion) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		fmt.Printf("Usage: %s <file.md|file.html>\n", cmdExport)
+		return
+	}
+	turns := session.annotateTurns(historyToTurns(messages))
+	if len(turns) == 0 {
+		fmt.Println("Nothing to export yet — ask a question first.")
+		return
+	}
+	c := chatstore.Chat{
+		Model: session.ModelName,
+		Bases: activeBaseNames(session),
+		Turns: turns,
+	}
+	if store != nil && chatID != "" {
+		if saved, err := store.Get(chatID); err == nil {
+			c.Title, c.CreatedAt = saved.Title, saved.CreatedAt
+		}
+	}
+	if c.CreatedAt.IsZero() && len(session.exchanges) > 0 {
+		c.CreatedAt = session.exchanges[0].asked
+	}
+	c.UpdatedAt = time.Now()
+	if err := chatstore.Export(c, path); err != nil {
+		fmt.Printf("Could not export chat: %v\n", err)
+		return
+	}
+	fmt.Printf("Exported chat to %s.\n", path)
+}
+
+// ExportSavedChat writes a saved chat from the daemonless CLI's local store to
+// path. With an empty id the user picks the chat interactively.
+func ExportSavedChat(id, path string) error {
+	if _, err := chatstore.FormatForPath(path); err != nil {
+		return err
+	}
+	store, err := localChatStore()
+	if err != nil {
+		return fmt.Errorf("saved chats are unavailable: %w", err)
+	}
+	if id == "" {
+		summaries, err := store.List("")
+		if err != nil {
+			return fmt.Errorf("listing saved chats: %w", err)
+		}
+		picked, ok := pickSavedChat(summaries)
+		if !ok {
+			return nil
+		}
+		id = picked.ID
+	}
+	saved, err := store.Get(id)
+	if err != nil {
+		return fmt.Errorf("opening saved chat: %w", err)
+	}
+	if err := chatstore.Export(saved, path); err != nil {
+		return err
+	}
+	fmt.Printf("Exported %q to %s.\n", saved.Title, path)
+	return nil
+}
+
+// exchange is the bookkeeping for one prompt in a session's history.
+type exchange struct {
+	asked    time.Time
+	answered time.Time
+	sources  []string
+}
+
+// recordExchange notes a completed prompt, in history order.
+func (s *Session) recordExchange(asked time.Time, sources []string) {
+	s.exchanges = append(s.exchanges, exchange{asked: asked, answered: time.Now(), sources: sources})
+}
+
+// annotateTurns stamps turns with the timestamps and sources recorded for the
+// session: the i-th user turn takes the i-th exchange's asked time, and the
+// reply following it the answered time and sources. Turns without a recorded
+// exchange (e.g. when history was rebuilt elsewhere) are left as they are.
+func (s *Session) annotateTurns(turns []chatstore.Turn) []chatstore.Turn {
+	n := -1
+	for i := range turns {
+		switch turns[i].Role {
+		case "user":
+			n++
+			if n < len(s.exchanges) {
+				turns[i].At = s.exchanges[n].asked
+			}
+		case "assistant":
+			if n >= 0 && n < len(s.exchanges) {
+				turns[i].At = s.exchanges[n].answered
+				turns[i].Sources = s.exchanges[n].sources
+			}
+		}
+	}
+	return turns
+}
+
+// exchangesFromTurns recovers the exchanges of a saved conversation, so a
+// resumed session keeps its earlier timestamps and sources.
+func exchangesFromTurns(turns []chatstore.Turn) []exchange {
+	var exchanges []exchange
+	for _, t := range turns {
+		switch t.Role {
+		case "user":
+			exchanges = append(exchanges, exchange{asked: t.At})
+		case "assistant":
+			if len(exchanges) > 0 {
+				last := &exchanges[len(exchanges)-1]
+				last.answered = t.At
+				last.sources = t.Sources
+			}
+		}
+	}
+	return exchanges
+}
+
 // activeBaseNames returns the session's active knowledge bases as base names.
 func activeBaseNames(s *Session) []string {
 	names := make([]string, 0, len(s.ActiveIndexes))
@@ -64,3 +64,29 @@ func TestRelativeTime(t *testing.T) {
 		}
 	}
 }
+
+func TestAnnotateTurnsRoundTrip(t *testing.T) {
+	asked := time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC)
+	s := &Session{exchanges: []exchange{
+		{asked: asked, answered: asked.Add(5 * time.Second), sources: []string{"guide.pdf"}},
+	}}
+	turns := s.annotateTurns([]chatstore.Turn{
+		{Role: "user", Content: "hello"},
+		{Role: "assistant", Content: "hi there"},
+		{Role: "user", Content: "unrecorded"},
+	})
+	if !turns[0].At.Equal(asked) {
+		t.Errorf("user turn At = %v, want %v", turns[0].At, asked)
+	}
+	if !turns[1].At.Equal(asked.Add(5*time.Second)) || len(turns[1].Sources) != 1 || turns[1].Sources[0] != "guide.pdf" {
+		t.Errorf("assistant turn = %+v, want answered time and guide.pdf", turns[1])
+	}
+	if !turns[2].At.IsZero() {
+		t.Errorf("turn without an exchange got At = %v", turns[2].At)
+	}
+
+	restored := exchangesFromTurns(turns)
+	if len(restored) != 2 || !restored[0].answered.Equal(asked.Add(5*time.Second)) || restored[0].sources[0] != "guide.pdf" {
+		t.Errorf("exchangesFromTurns = %+v", restored)
+	}
+}
@@ -0,0 +1,144 @@
+package chat
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/chzyer/readline"
+)
+
+const (
+	// multilineFence opens and closes a multi-line prompt in the REPL.
+	multilineFence = `"""`
+	// maxAttachmentBytes caps a file inlined with @file:, so a stray path to a
+	// large log cannot flood the context window.
+	maxAttachmentBytes = 64 << 10
+)
+
+// attachmentPattern matches @file:<path> references in a prompt.
+var attachmentPattern = regexp.MustCompile(`@file:(\S+)`)
+
+// readPrompt reads the next prompt from the REPL. A line starting with """
+// opens a multi-line block that runs until a line ending with """; multiline
+// reports whether the prompt came from such a block, which is always sent as a
+// prompt even if it starts with a slash.
+func readPrompt(rl *readline.Instance) (prompt string, multiline bool, err error) {
+	line, err := rl.Readline()
+	if err != nil || !strings.HasPrefix(strings.TrimSpace(line), multilineFence) {
+		return line, false, err
+	}
+
+	prev := rl.Config.Prompt
+	rl.SetPrompt(dim("… "))
+	defer rl.SetPrompt(prev)
+
+	prompt, err = collectMultiline(line, rl.Readline)
+	if err != nil {
+		// Ctrl-C abandons the block rather than quitting the chat.
+		return "", true, nil
+	}
+	return prompt, true, nil
+}
+
+// collectMultiline assembles a """-fenced block whose opening line is first,
+// reading further lines from next until one ends with the closing fence. Text
+// on the fence lines is kept; blank lines around the block are dropped.
+func collectMultiline(first string, next func() (string, error)) (string, error) {
+	line := strings.TrimPrefix(strings.TrimSpace(first), multilineFence)
+	var lines []string
+	for {
+		if trimmed := strings.TrimRight(line, " \t"); strings.HasSuffix(trimmed, multilineFence) {
+			lines = append(lines, strings.TrimSuffix(trimmed, multilineFence))
+			break
+		}
+		lines = append(lines, line)
+
+		var err error
+		if line, err = next(); err != nil {
+			return "", err
+		}
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n"), nil
+}
+
+// saveHistory records a single-line prompt in the readline history. Multi-line
+// blocks are left out: readline recalls history one line at a time.
+func saveHistory(rl *readline.Instance, prompt string) {
+	if !strings.Contains(prompt, "\n") {
+		rl.SaveHistory(prompt)
+	}
+}
+
+// attachFiles replaces each @file:<path> in prompt with the file's content in a
+// fenced block, and returns the expanded prompt with the paths it inlined. A
+// missing, oversized, or binary file is an error, so nothing is sent with a
+// reference the model cannot read.
+func attachFiles(prompt string) (string, []string, error) {
+	var (
+		attached []string
+		firstErr error
+	)
+	expanded := attachmentPattern.ReplaceAllStringFunc(prompt, func(ref string) string {
+		if firstErr != nil {
+			return ref
+		}
+		path := strings.TrimPrefix(ref, "@file:")
+		content, err := readAttachment(path)
+		if err != nil {
+			firstErr = err
+			return ref
+		}
+		attached = append(attached, path)
+		return fmt.Sprintf("\n```%s\n%s\n```\n", filepath.Base(path), strings.TrimRight(content, "\n"))
+	})
+	if firstErr != nil {
+		return "", nil, firstErr
+	}
+	return expanded, attached, nil
+}
+
+func readAttachment(path string) (string, error) {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("attaching %s: %w", path, err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("attaching %s: is a directory", path)
+	}
+	if info.Size() > maxAttachmentBytes {
+		return "", fmt.Errorf("attaching %s: file is %d bytes, over the %d byte limit for @file", path, info.Size(), maxAttachmentBytes)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("attaching %s: %w", path, err)
+	}
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return "", fmt.Errorf("attaching %s: not a text file", path)
+	}
+	return string(data), nil
+}
+
+// expandAttachments inlines the prompt's @file: references for sending, noting
+// each attached file. It prints the error and returns false when a reference
+// cannot be inlined; the prompt is then not sent.
+func expandAttachments(prompt string) (string, bool) {
+	expanded, attached, err := attachFiles(prompt)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return "", false
+	}
+	for _, path := range attached {
+		fmt.Println(dim("Attached " + path))
+	}
+	return expanded, true
+}
@@ -0,0 +1,85 @@
+package chat
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCollectMultiline(t *testing.T) {
+	tests := []struct {
+		name  string
+		first string
+		rest  []string
+		want  string
+	}{
+		{"fence lines alone", `"""`, []string{"line one", "", "line two", `"""`}, "line one\n\nline two"},
+		{"text on fence lines", `"""first`, []string{"second", `last"""`}, "first\nsecond\nlast"},
+		{"single line", `"""just this"""`, nil, "just this"},
+		{"slash text is kept", `"""`, []string{"/search is not a command here", `"""`}, "/search is not a command here"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rest := tt.rest
+			next := func() (string, error) {
+				if len(rest) == 0 {
+					t.Fatal("read past the closing fence")
+				}
+				line := rest[0]
+				rest = rest[1:]
+				return line, nil
+			}
+			got, err := collectMultiline(tt.first, next)
+			if err != nil {
+				t.Fatalf("collectMultiline: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("collectMultiline = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	_, err := collectMultiline(`"""`, func() (string, error) { return "", io.EOF })
+	if err != io.EOF {
+		t.Errorf("unterminated block err = %v, want io.EOF", err)
+	}
+}
+
+func TestAttachFiles(t *testing.T) {
+	dir := t.TempDir()
+	conf := filepath.Join(dir, "app.conf")
+	if err := os.WriteFile(conf, []byte("port = 8080\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, attached, err := attachFiles("why does @file:" + conf + " fail?")
+	if err != nil {
+		t.Fatalf("attachFiles: %v", err)
+	}
+	if want := "why does \n```app.conf\nport = 8080\n```\n fail?"; got != want {
+		t.Errorf("attachFiles = %q, want %q", got, want)
+	}
+	if len(attached) != 1 || attached[0] != conf {
+		t.Errorf("attached = %v, want [%s]", attached, conf)
+	}
+
+	if got, attached, err := attachFiles("no references"); err != nil || got != "no references" || len(attached) != 0 {
+		t.Errorf("attachFiles without references = (%q, %v, %v)", got, attached, err)
+	}
+
+	binary := filepath.Join(dir, "blob.bin")
+	if err := os.WriteFile(binary, []byte{0x7f, 'E', 'L', 'F', 0}, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	large := filepath.Join(dir, "large.log")
+	if err := os.WriteFile(large, []byte(strings.Repeat("x", maxAttachmentBytes+1)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{binary, large, filepath.Join(dir, "missing"), dir} {
+		if _, _, err := attachFiles("@file:" + path); err == nil {
+			t.Errorf("attachFiles(%s) succeeded, want an error", path)
+		}
+	}
+}
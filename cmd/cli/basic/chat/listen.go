@@ -0,0 +1,196 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+)
+
+// ListenPath is the websocket endpoint chat --listen serves.
+const ListenPath = "/chat"
+
+// listenIdleTimeout ends a listen connection after this period without a
+// client message.
+const listenIdleTimeout = 30 * time.Minute
+
+// ListenOptions configures the sessions chat --listen runs: one LiveSession
+// per websocket connection, as NewLiveSession takes them.
+type ListenOptions struct {
+	BaseURL          string
+	Model            string
+	KnowledgeClient  *knowledge.OpenSearchClient
+	EmbeddingModelID string
+	SystemPrompt     string
+	Temperature      float64
+	// Settings are the chat.* settings each connection's session runs with.
+	Settings Settings
+	Verbose  bool
+}
+
+// listenControlMessage is a client→server frame: "prompt" submits a question
+// and "set-active-kbs" changes the active knowledge bases, as on the daemon's
+// chat websocket.
+type listenControlMessage struct {
+	Type    string   `json:"type"`
+	Content string   `json:"content,omitempty"`
+	Bases   []string `json:"bases,omitempty"`
+}
+
+// listenServerMessage is a server→client frame: streamed "token"/"think"
+// content, the "sources" the answer was grounded on, a terminal "done" per
+// answer, an "active-kbs" acknowledgement, or an "error".
+type listenServerMessage struct {
+	Type    string   `json:"type"`
+	Content string   `json:"content,omitempty"`
+	Sources []string `json:"sources,omitempty"`
+	Bases   []string `json:"bases,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// CheckListenAddr rejects an address chat --listen should not serve on: the
+// endpoint has no authentication, so it must be bound to a loopback address.
+func CheckListenAddr(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid --listen address %q: %w", addr, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("invalid --listen address %q: the endpoint is unauthenticated, so it must listen on localhost, 127.0.0.1, or ::1", addr)
+}
+
+// Listen serves chat sessions over a websocket at ListenPath on addr, instead
+// of running the REPL, for local web frontends to reuse the RAG pipeline. Each
+// connection gets its own session, starting with the default knowledge base
+// active. It returns when ctx is done.
+func Listen(ctx context.Context, addr string, opts ListenOptions) error {
+	if err := CheckListenAddr(addr); err != nil {
+		return err
+	}
+	if err := handshake(opts.BaseURL); err != nil {
+		return err
+	}
+	if opts.Model == "" {
+		model, err := FindModelNameContext(ctx, opts.BaseURL)
+		if err != nil {
+			return err
+		}
+		opts.Model = model
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", addr, err)
+	}
+	srv := &http.Server{Handler: listenHandler(opts), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("Serving chat with model %s at ws://%s%s. CTRL-C to stop.\n", opts.Model, listener.Addr(), ListenPath)
+	if err := srv.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// listenHandler serves the chat websocket. Browsers may connect from pages
+// served on any local port.
+func listenHandler(opts ListenOptions) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+ListenPath, func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+			OriginPatterns: []string{"localhost:*", "127.0.0.1:*", "[::1]:*"},
+		})
+		if err != nil {
+			return // Accept already wrote the error to the client.
+		}
+		conn.SetReadLimit(1 << 20)
+		defer conn.Close(websocket.StatusNormalClosure, "session closed")
+
+		defaultBase, _ := knowledge.KnowledgeBaseNameFromIndex(knowledge.DefaultIndexName())
+		var bases []string
+		if opts.KnowledgeClient != nil {
+			bases = []string{defaultBase}
+		}
+		live, err := NewLiveSession(opts.BaseURL, opts.Model, opts.KnowledgeClient, opts.EmbeddingModelID, bases, opts.SystemPrompt, opts.Temperature, opts.Settings, opts.Verbose)
+		if err != nil {
+			_ = writeListen(r.Context(), conn, listenServerMessage{Type: "error", Error: err.Error()})
+			return
+		}
+		serveListen(r.Context(), conn, live)
+	})
+	return mux
+}
+
+// serveListen runs one connection's session: a RAG turn per prompt frame,
+// streaming tokens, then the cited sources, then done. It returns when the
+// client disconnects, stays idle for listenIdleTimeout, or ctx is done.
+func serveListen(ctx context.Context, conn *websocket.Conn, live *LiveSession) {
+	for {
+		readCtx, cancel := context.WithTimeout(ctx, listenIdleTimeout)
+		var msg listenControlMessage
+		err := wsjson.Read(readCtx, conn, &msg)
+		cancel()
+		if err != nil {
+			return
+		}
+
+		var reply listenServerMessage
+		switch strings.TrimSpace(msg.Type) {
+		case "prompt":
+			text := strings.TrimSpace(msg.Content)
+			if text == "" {
+				reply = listenServerMessage{Type: "error", Error: "empty prompt"}
+				break
+			}
+			emit := func(kind TokenKind, content string) error {
+				return writeListen(ctx, conn, listenServerMessage{Type: string(kind), Content: content})
+			}
+			if err := live.Prompt(ctx, text, emit); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				reply = listenServerMessage{Type: "error", Error: err.Error()}
+				break
+			}
+			if sources := live.LastSources(); len(sources) > 0 {
+				if err := writeListen(ctx, conn, listenServerMessage{Type: "sources", Sources: sources}); err != nil {
+					return
+				}
+			}
+			reply = listenServerMessage{Type: "done"}
+		case "set-active-kbs":
+			live.SetActiveBases(msg.Bases)
+			reply = listenServerMessage{Type: "active-kbs", Bases: live.ActiveBases()}
+		default:
+			reply = listenServerMessage{Type: "error", Error: fmt.Sprintf("unknown control message type %q", msg.Type)}
+		}
+		if err := writeListen(ctx, conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// writeListen sends a server frame with a bounded write deadline, so a stuck
+// client cannot block its session.
+func writeListen(ctx context.Context, conn *websocket.Conn, msg listenServerMessage) error {
+	writeCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	return wsjson.Write(writeCtx, conn, msg)
+}
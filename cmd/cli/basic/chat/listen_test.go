@@ -0,0 +1,89 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+func TestCheckListenAddr(t *testing.T) {
+	for _, addr := range []string{"localhost:8765", "127.0.0.1:0", "[::1]:8765"} {
+		if err := CheckListenAddr(addr); err != nil {
+			t.Errorf("CheckListenAddr(%q) = %v, want nil", addr, err)
+		}
+	}
+	for _, addr := range []string{"0.0.0.0:8765", ":8765", "192.168.1.10:8765", "localhost"} {
+		if err := CheckListenAddr(addr); err == nil {
+			t.Errorf("CheckListenAddr(%q) succeeded, want an error", addr)
+		}
+	}
+}
+
+// TestListenStreamsTurn holds one turn over the --listen websocket against a
+// stub inference server and checks the answer streams in, ended by done.
+func TestListenStreamsTurn(t *testing.T) {
+	inference := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/chat/completions") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, token := range []string{"<think>", "hmm", "</think>", "Hello", " world"} {
+			fmt.Fprintf(w, "data: {\"id\":\"1\",\"object\":\"chat.completion.chunk\",\"created\":1,\"model\":\"m\","+
+				"\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", token)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer inference.Close()
+
+	srv := httptest.NewServer(listenHandler(ListenOptions{BaseURL: inference.URL, Model: "m"}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http")+ListenPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+
+	if err := wsjson.Write(ctx, conn, listenControlMessage{Type: "prompt", Content: "Hi"}); err != nil {
+		t.Fatal(err)
+	}
+	var answer, think strings.Builder
+	for {
+		var msg listenServerMessage
+		if err := wsjson.Read(ctx, conn, &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Type == "done" {
+			break
+		}
+		switch msg.Type {
+		case string(TokenAnswer):
+			answer.WriteString(msg.Content)
+		case string(TokenThink):
+			think.WriteString(msg.Content)
+		default:
+			t.Fatalf("unexpected frame %+v", msg)
+		}
+	}
+	if answer.String() != "Hello world" || !strings.Contains(think.String(), "hmm") {
+		t.Errorf("answer %q, think %q", answer.String(), think.String())
+	}
+
+	if err := wsjson.Write(ctx, conn, listenControlMessage{Type: "bogus"}); err != nil {
+		t.Fatal(err)
+	}
+	var msg listenServerMessage
+	if err := wsjson.Read(ctx, conn, &msg); err != nil || msg.Type != "error" {
+		t.Errorf("unknown frame answered with %+v, %v; want an error frame", msg, err)
+	}
+}
@@ -0,0 +1,175 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/rag"
+	"github.com/openai/openai-go/v3"
+)
+
+const (
+	// recallCount is how many past session summaries /recall brings back.
+	recallCount = 3
+	// maxMemoryChars caps a stored session summary.
+	maxMemoryChars = 1200
+	// maxMemoryTranscript caps the transcript sent for summarizing; a longer
+	// session is summarized from its most recent turns.
+	maxMemoryTranscript = 16000
+)
+
+// parseMemory reads whether chat sessions are summarized into the memory
+// index when they end, from the chat.memory config value (true or false; empty
+// for false). /recall works either way, on the summaries saved so far.
+func parseMemory(value string) (bool, error) {
+	if value = strings.TrimSpace(value); value == "" {
+		return false, nil
+	}
+	on, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid chat.memory %q: expected true or false", value)
+	}
+	return on, nil
+}
+
+// rememberSession summarizes a finished REPL session and stores the summary in
+// the memory index, when chat.memory is on. A session in which nothing was
+// answered is not stored. Failures are reported but never keep the chat from
+// closing.
+func rememberSession(client openai.Client, session *Session, messages []openai.ChatCompletionMessageParamUnion, verbose bool) {
+	if !session.Settings.memory || session.KnowledgeClient == nil {
+		return
+	}
+	transcript := memoryTranscript(messages)
+	if transcript == "" {
+		return
+	}
+
+	summary, err := summarizeSession(client, session.ModelName, transcript)
+	if err != nil {
+		fmt.Printf("Could not save this chat to memory: %v\n", err)
+		return
+	}
+	err = session.KnowledgeClient.SaveMemory(context.Background(), knowledge.Memory{
+		Summary:   summary,
+		Model:     session.ModelName,
+		Owner:     knowledge.CurrentUser(),
+		CreatedAt: time.Now().UTC().Format(knowledge.DateFormat),
+	})
+	if err != nil {
+		fmt.Printf("Could not save this chat to memory: %v\n", err)
+		return
+	}
+	if verbose {
+		fmt.Printf("Memory: %s\n", summary)
+	}
+	fmt.Println(dim("Saved a summary of this chat to memory."))
+}
+
+// memoryTranscript renders the conversation's turns for summarizing, keeping
+// the most recent maxMemoryTranscript characters. It is empty when no prompt
+// was answered.
+func memoryTranscript(messages []openai.ChatCompletionMessageParamUnion) string {
+	var (
+		b        strings.Builder
+		answered bool
+	)
+	for _, t := range historyToTurns(messages) {
+		content := strings.TrimSpace(rag.StripThinkTags(t.Content))
+		if content == "" {
+			continue
+		}
+		answered = answered || t.Role == "assistant"
+		fmt.Fprintf(&b, "%s: %s\n\n", t.Role, content)
+	}
+	if !answered {
+		return ""
+	}
+	transcript := strings.TrimSpace(b.String())
+	if r := []rune(transcript); len(r) > maxMemoryTranscript {
+		transcript = "…" + string(r[len(r)-maxMemoryTranscript:])
+	}
+	return transcript
+}
+
+// summarizeSession asks the inference server for a summary of transcript a
+// later session can pick up from.
+func summarizeSession(client openai.Client, model, transcript string) (string, error) {
+	stopProgress := common.StartProgressSpinner("Saving chat to memory")
+	resp, err := client.Chat.Completions.New(context.Background(), openai.ChatCompletionNewParams{
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(fmt.Sprintf(
+				"Summarize the following conversation for a later conversation with the same user, in at most %d characters. "+
+					"Keep the topics discussed, the conclusions reached, and any open follow-ups; keep concrete names, versions, and commands. "+
+					"Output only the summary.", maxMemoryChars)),
+			openai.UserMessage(transcript),
+		},
+		Model:       model,
+		Temperature: openai.Float(0),
+	})
+	stopProgress()
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("the inference server returned no summary")
+	}
+
+	summary := strings.TrimSpace(rag.StripThinkTags(resp.Choices[0].Message.Content))
+	if summary == "" {
+		return "", fmt.Errorf("the inference server returned an empty summary")
+	}
+	if r := []rune(summary); len(r) > maxMemoryChars {
+		summary = string(r[:maxMemoryChars-1]) + "…"
+	}
+	return summary, nil
+}
+
+// handleRecall implements /recall: it retrieves the user's past session
+// summaries matching query, or the most recent ones without a query, shows
+// them, and adds them to the conversation so the model can pick up where
+// those sessions left off.
+func handleRecall(query string, session *Session, messages []openai.ChatCompletionMessageParamUnion) []openai.ChatCompletionMessageParamUnion {
+	if session.KnowledgeClient == nil {
+		fmt.Println("Memory is not available: the knowledge base is not reachable.")
+		return messages
+	}
+
+	stopProgress := common.StartProgressSpinner("Recalling earlier chats")
+	memories, err := session.KnowledgeClient.RecallMemories(context.Background(), knowledge.CurrentUser(), strings.TrimSpace(query), recallCount)
+	stopProgress()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return messages
+	}
+	if len(memories) == 0 {
+		if session.Settings.memory {
+			fmt.Println("No matching memories.")
+		} else {
+			fmt.Println("No matching memories. Set chat.memory=true to save a summary of each chat when it ends.")
+		}
+		return messages
+	}
+
+	for _, m := range memories {
+		fmt.Printf("%s %s\n", dim(m.CreatedAt), m.Summary)
+	}
+	fmt.Println(dim(fmt.Sprintf("Recalled %d earlier chat(s) into this conversation.", len(memories))))
+	return append(messages, openai.SystemMessage(formatMemories(memories)))
+}
+
+// formatMemories renders recalled summaries as the system message /recall adds.
+func formatMemories(memories []knowledge.Memory) string {
+	var b strings.Builder
+	b.WriteString("Summaries of earlier conversations with this user, for continuity. " +
+		"They may be outdated: prefer the retrieved context where they disagree.\n")
+	for _, m := range memories {
+		fmt.Fprintf(&b, "- [%s] %s\n", m.CreatedAt, m.Summary)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,60 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/openai/openai-go/v3"
+)
+
+func TestParseMemory(t *testing.T) {
+	if on, err := parseMemory("true"); err != nil || !on {
+		t.Errorf("parseMemory(true) = %v, %v, want true, nil", on, err)
+	}
+	if on, err := parseMemory(""); err != nil || on {
+		t.Errorf("parseMemory(empty) = %v, %v, want false, nil", on, err)
+	}
+	if _, err := parseMemory("sometimes"); err == nil {
+		t.Error("parseMemory(sometimes) = nil error, want error")
+	}
+}
+
+func TestMemoryTranscript(t *testing.T) {
+	unanswered := []openai.ChatCompletionMessageParamUnion{
+		openai.SystemMessage("You are helpful."),
+		openai.UserMessage("hello"),
+	}
+	if got := memoryTranscript(unanswered); got != "" {
+		t.Errorf("memoryTranscript without an answer = %q, want empty", got)
+	}
+
+	answered := append(unanswered, openai.AssistantMessage("<think>greet</think>Hi there"))
+	got := memoryTranscript(answered)
+	if !strings.Contains(got, "user: hello") || !strings.Contains(got, "assistant: Hi there") {
+		t.Errorf("memoryTranscript = %q, want the user and assistant turns", got)
+	}
+	if strings.Contains(got, "greet") || strings.Contains(got, "You are helpful") {
+		t.Errorf("memoryTranscript = %q, want think tags and the system prompt left out", got)
+	}
+
+	long := append(answered, openai.UserMessage(strings.Repeat("x", maxMemoryTranscript)))
+	if got := memoryTranscript(long); len([]rune(got)) != maxMemoryTranscript+1 || !strings.HasPrefix(got, "…") {
+		t.Errorf("long transcript kept %d runes, want the last %d after an ellipsis", len([]rune(got)), maxMemoryTranscript)
+	}
+}
+
+func TestFormatMemories(t *testing.T) {
+	got := formatMemories([]knowledge.Memory{
+		{Summary: "Discussed the Ceph upgrade.", CreatedAt: "2024-05-02 14:10:44"},
+		{Summary: "Tuned OpenSearch heap.", CreatedAt: "2024-04-28 09:00:00"},
+	})
+	for _, want := range []string{"- [2024-05-02 14:10:44] Discussed the Ceph upgrade.", "- [2024-04-28 09:00:00] Tuned OpenSearch heap."} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatMemories missing %q:\n%s", want, got)
+		}
+	}
+	if strings.HasSuffix(got, "\n") {
+		t.Error("formatMemories ends with a newline")
+	}
+}
@@ -0,0 +1,172 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/rag"
+	"github.com/openai/openai-go/v3"
+)
+
+const (
+	// maxQueryVariants is how many paraphrases multi-query retrieval asks for
+	// on top of the original question.
+	maxQueryVariants = 3
+	// rrfK damps the weight of top ranks in reciprocal rank fusion; 60 is the
+	// value from the original RRF paper and what OpenSearch uses.
+	rrfK = 60
+)
+
+// parseMultiQuery reads whether chat sessions start with multi-query
+// retrieval from the chat.multiquery config value (true or false; empty for
+// false). /set multiquery overrides it for one REPL session.
+func parseMultiQuery(value string) (bool, error) {
+	if value = strings.TrimSpace(value); value == "" {
+		return false, nil
+	}
+	on, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid chat.multiquery %q: expected true or false", value)
+	}
+	return on, nil
+}
+
+// retrieve runs retrieval for query, widened by multi-query when the session
+// has it on: the original question and each LLM paraphrase are searched
+// separately, and the result lists are fused with reciprocal rank fusion.
+func retrieve(client openai.Client, model string, messages []openai.ChatCompletionMessageParamUnion, session *Session, query, lexicalQuery string, verbose bool) []knowledge.SearchHit {
+	hits := retrieveHits(session, query, lexicalQuery, verbose)
+	if !session.MultiQuery {
+		return hits
+	}
+
+	variants := generateQueryVariants(client, model, messages, query, verbose)
+	if len(variants) == 0 {
+		return hits
+	}
+	lists := [][]knowledge.SearchHit{hits}
+	for _, v := range variants {
+		lists = append(lists, retrieveHits(session, v, v, verbose))
+	}
+	fused := fuseRankings(lists)
+	if verbose {
+		fmt.Printf("Multi-query: fused %d queries into %d results\n", len(lists), len(fused))
+	}
+	return fused
+}
+
+// generateQueryVariants asks the inference server for paraphrases of query
+// that may match documents worded differently. It returns nil on any failure,
+// leaving retrieval to the original question alone.
+func generateQueryVariants(client openai.Client, model string, messages []openai.ChatCompletionMessageParamUnion, query string, verbose bool) []string {
+	conversationCtx := formatConversationForRewrite(messages, maxRewriteTurns)
+
+	stopProgress := common.StartProgressSpinner("Generating query variants")
+	resp, err := client.Chat.Completions.New(context.Background(), openai.ChatCompletionNewParams{
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(
+				"You are a RAG query optimizer. Given a conversation and a follow-up question, write " +
+					strconv.Itoa(maxQueryVariants) + " alternative phrasings of the question that could match documents using different wording.\n" +
+					"Each phrasing must be self-contained (resolve references to the conversation) and keep the technical terms of the question.\n" +
+					"Output only a JSON array of strings, no explanation.",
+			),
+			openai.UserMessage(conversationCtx + "Question: " + query),
+		},
+		Model:               model,
+		MaxCompletionTokens: openai.Int(int64(maxRewriteTokens)),
+		MaxTokens:           openai.Int(int64(maxRewriteTokens)),
+	})
+	stopProgress()
+	if err != nil {
+		if verbose {
+			fmt.Printf("Query variant generation failed: %v\n", err)
+		}
+		return nil
+	}
+	if len(resp.Choices) == 0 {
+		return nil
+	}
+
+	variants, err := parseQueryVariants(resp.Choices[0].Message.Content, query)
+	if err != nil {
+		if verbose {
+			fmt.Printf("Query variant parse failed (%v), using the original query only\n", err)
+		}
+		return nil
+	}
+	if verbose {
+		fmt.Printf("Query variants: %q\n", variants)
+	}
+	return variants
+}
+
+// parseQueryVariants extracts the JSON array of paraphrases from a model reply,
+// dropping blanks, repeats, and copies of the original query, and keeping at
+// most maxQueryVariants.
+func parseQueryVariants(raw, query string) ([]string, error) {
+	raw = strings.TrimSpace(rag.StripThinkTags(raw))
+	raw = strings.TrimPrefix(raw, "```json")
+	raw = strings.TrimPrefix(raw, "```")
+	raw = strings.TrimSuffix(raw, "```")
+	raw = strings.TrimSpace(raw)
+
+	var all []string
+	if err := json.Unmarshal([]byte(raw), &all); err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{strings.ToLower(strings.TrimSpace(query)): true}
+	var variants []string
+	for _, v := range all {
+		v = strings.TrimSpace(v)
+		key := strings.ToLower(v)
+		if v == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		variants = append(variants, v)
+		if len(variants) == maxQueryVariants {
+			break
+		}
+	}
+	return variants, nil
+}
+
+// fuseRankings merges ranked hit lists with reciprocal rank fusion: a hit
+// scores the sum of 1/(rrfK+rank) over the lists it appears in, so chunks that
+// several phrasings retrieve rise above chunks only one finds. Each fused hit
+// carries its fusion score, keeping score-based context trimming meaningful.
+func fuseRankings(lists [][]knowledge.SearchHit) []knowledge.SearchHit {
+	type fused struct {
+		hit   knowledge.SearchHit
+		score float64
+	}
+	byKey := map[string]*fused{}
+	var order []*fused
+	for _, list := range lists {
+		for rank, hit := range list {
+			key := hit.Index + "\x00" + hit.SourceID + "\x00" + hit.Content
+			f, ok := byKey[key]
+			if !ok {
+				f = &fused{hit: hit}
+				byKey[key] = f
+				order = append(order, f)
+			}
+			f.score += 1 / float64(rrfK+rank+1)
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool { return order[i].score > order[j].score })
+	hits := make([]knowledge.SearchHit, len(order))
+	for i, f := range order {
+		hits[i] = f.hit
+		hits[i].Score = f.score
+	}
+	return hits
+}
@@ -0,0 +1,56 @@
+package chat
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+)
+
+func TestParseQueryVariants(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want []string
+	}{
+		{"plain array", `["a b", "c d"]`, []string{"a b", "c d"}},
+		{"fenced", "```json\n[\"a b\"]\n```", []string{"a b"}},
+		{"think block", "<think>hmm</think>[\"a b\"]", []string{"a b"}},
+		{"drops blanks, repeats, and the query", `["", "A B", "a b", "How do I x"]`, []string{"A B"}},
+		{"capped", `["1", "2", "3", "4"]`, []string{"1", "2", "3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseQueryVariants(tt.raw, "how do i x")
+			if err != nil {
+				t.Fatalf("parseQueryVariants: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseQueryVariants = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseQueryVariants("not json", "q"); err == nil {
+		t.Error("parseQueryVariants accepted a non-JSON reply")
+	}
+}
+
+func TestFuseRankings(t *testing.T) {
+	a := knowledge.SearchHit{Index: "i", SourceID: "a", Content: "alpha", Score: 9}
+	b := knowledge.SearchHit{Index: "i", SourceID: "b", Content: "beta", Score: 8}
+	c := knowledge.SearchHit{Index: "i", SourceID: "c", Content: "gamma", Score: 7}
+
+	// b is second in both lists, a and c first in one each: b wins on fusion.
+	got := fuseRankings([][]knowledge.SearchHit{{a, b}, {c, b}})
+	var ids []string
+	for _, hit := range got {
+		ids = append(ids, hit.SourceID)
+	}
+	if want := []string{"b", "a", "c"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("fused order = %v, want %v", ids, want)
+	}
+	if want := 2.0 / 62; got[0].Score != want {
+		t.Errorf("fused score = %v, want %v", got[0].Score, want)
+	}
+}
@@ -0,0 +1,102 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+)
+
+const (
+	// offlineBanner is shown whenever the session loses its knowledge base.
+	offlineBanner = "knowledge base unavailable — answering without context"
+	// reconnectInterval is how long an offline session waits between
+	// reconnection attempts, which are made as prompts are submitted.
+	reconnectInterval = 30 * time.Second
+	// reconnectTimeout bounds one reconnection attempt, so an unreachable
+	// cluster does not hold up the prompt it runs before.
+	reconnectTimeout = 5 * time.Second
+)
+
+// printOfflineBanner tells the user that answers are no longer grounded.
+func printOfflineBanner() {
+	fmt.Println(color.YellowString("⚠ %s", offlineBanner))
+	fmt.Printf("\t> Use `%s` once it is back; the chat also retries every %s\n", cmdReconnect, reconnectInterval)
+}
+
+// markKnowledgeOffline drops the session's knowledge client after it stopped
+// answering, so retrieval, /search, and /use-knowledge report the base as
+// unavailable until a reconnection succeeds.
+func markKnowledgeOffline(session *Session) {
+	if session.knowledgeOffline {
+		return
+	}
+	session.KnowledgeClient = nil
+	session.knowledgeOffline = true
+	session.lastReconnect = time.Now()
+	printOfflineBanner()
+}
+
+// checkKnowledgeOnline is called after a knowledge base search failed: a
+// failed ping tells a cluster that went away from a query OpenSearch rejected,
+// and takes the session offline.
+func checkKnowledgeOnline(session *Session) {
+	if session.KnowledgeClient == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), reconnectTimeout)
+	defer cancel()
+	if err := session.KnowledgeClient.Ping(ctx); err != nil {
+		markKnowledgeOffline(session)
+	}
+}
+
+// maybeReconnect retries an offline session's knowledge base once
+// reconnectInterval has passed since the last attempt, announcing it only when
+// the base is back.
+func maybeReconnect(session *Session, verbose bool) {
+	if !session.knowledgeOffline || time.Since(session.lastReconnect) < reconnectInterval {
+		return
+	}
+	if err := reconnectKnowledge(session); err != nil {
+		if verbose {
+			fmt.Printf("Knowledge base still unavailable: %v\n", err)
+		}
+		return
+	}
+	fmt.Println(color.GreenString("Knowledge base back online at %s", session.KnowledgeURL))
+}
+
+// reconnectKnowledge connects to the session's knowledge base afresh and, on
+// success, brings the session back online.
+func reconnectKnowledge(session *Session) error {
+	if session.KnowledgeURL == "" {
+		return fmt.Errorf("no knowledge base is configured")
+	}
+	session.lastReconnect = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), reconnectTimeout)
+	defer cancel()
+	client, err := knowledge.NewClientNoWait(ctx, session.KnowledgeURL, session.KnowledgeSettings)
+	if err != nil {
+		return err
+	}
+	session.KnowledgeClient = client
+	session.knowledgeOffline = false
+	return nil
+}
+
+// handleReconnect implements /reconnect: it reconnects to the knowledge base
+// right away, whether the session is offline or the user only suspects it.
+func handleReconnect(session *Session) {
+	if err := reconnectKnowledge(session); err != nil {
+		if session.KnowledgeURL != "" {
+			markKnowledgeOffline(session)
+		}
+		fmt.Printf("Knowledge base still unavailable: %v\n", err)
+		return
+	}
+	fmt.Printf("Connected to the knowledge base at %s\n", session.KnowledgeURL)
+}
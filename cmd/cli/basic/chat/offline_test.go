@@ -0,0 +1,34 @@
+package chat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaybeReconnectWaitsForInterval(t *testing.T) {
+	// A documentation address: trying it would take the whole reconnect
+	// timeout, so the interval must keep the attempt from running at all.
+	last := time.Now()
+	session := &Session{
+		KnowledgeURL:     "http://192.0.2.1:9200",
+		knowledgeOffline: true,
+		lastReconnect:    last,
+	}
+	maybeReconnect(session, false)
+	if !session.knowledgeOffline || session.KnowledgeClient != nil {
+		t.Fatal("session came back online before the reconnect interval passed")
+	}
+	if !session.lastReconnect.Equal(last) {
+		t.Error("maybeReconnect attempted a reconnection before the interval passed")
+	}
+}
+
+func TestReconnectWithoutKnowledgeURL(t *testing.T) {
+	session := &Session{}
+	if err := reconnectKnowledge(session); err == nil {
+		t.Error("reconnectKnowledge with no configured knowledge base = nil error, want error")
+	}
+	if session.knowledgeOffline {
+		t.Error("a session without a knowledge base was marked offline")
+	}
+}
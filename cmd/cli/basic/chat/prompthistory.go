@@ -0,0 +1,121 @@
+package chat
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/chzyer/readline"
+)
+
+const (
+	// defaultHistoryMax is how many prompts the history file keeps when
+	// chat.history.max is not set, matching readline's in-memory default.
+	defaultHistoryMax = 500
+	// historyFileName is the prompt history file under the user's data dir.
+	historyFileName = "chat-history"
+)
+
+// HistorySettings are how the REPL keeps its prompt history.
+type HistorySettings struct {
+	// Persist is whether prompts are kept in the history file across
+	// sessions, and Max how many of them it keeps.
+	Persist bool
+	Max     int
+}
+
+// DefaultHistorySettings returns the settings used when the chat.history.*
+// keys are unset.
+func DefaultHistorySettings() HistorySettings {
+	return HistorySettings{Persist: true, Max: defaultHistoryMax}
+}
+
+// ParseHistorySettings reads how the REPL's prompt history is kept, from the
+// chat.history.persist value get returns (true or false; empty for true) and
+// chat.history.max (a positive number of prompts; empty for 500). With
+// persistence off, prompts are recalled only within a session — for hosts
+// where questions must not be left on disk.
+func ParseHistorySettings(get func(key string) string) (HistorySettings, error) {
+	h := DefaultHistorySettings()
+	if persist := strings.TrimSpace(get(ConfHistoryPersist)); persist != "" {
+		on, err := strconv.ParseBool(persist)
+		if err != nil {
+			return HistorySettings{}, fmt.Errorf("invalid chat.history.persist %q: expected true or false", persist)
+		}
+		h.Persist = on
+	}
+	if max := strings.TrimSpace(get(ConfHistoryMax)); max != "" {
+		n, err := strconv.Atoi(max)
+		if err != nil || n <= 0 {
+			return HistorySettings{}, fmt.Errorf("invalid chat.history.max %q: expected a positive number of prompts", max)
+		}
+		h.Max = n
+	}
+	return h, nil
+}
+
+// userDataDir returns where the chat keeps per-user files: $SNAP_USER_DATA
+// when running as a snap, otherwise ~/.config/rag-cli/.
+func userDataDir() (string, error) {
+	if snapData := os.Getenv("SNAP_USER_DATA"); snapData != "" {
+		return snapData, nil
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locating config directory: %w", err)
+	}
+	return filepath.Join(configDir, "rag-cli"), nil
+}
+
+// historyFilePath returns where prompt history is kept, in userDataDir.
+func historyFilePath() (string, error) {
+	dir, err := userDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, historyFileName), nil
+}
+
+// configureHistoryFile points a REPL's readline at the history file, so
+// prompts are recalled across sessions and across the readline restarts
+// around slash commands. The file is created owner-only before readline
+// opens it, since prompts may hold anything the user asked. Without
+// persistence, or when the file cannot be created, history stays in memory.
+func (h HistorySettings) configureHistoryFile(cfg *readline.Config, verbose bool) {
+	cfg.HistoryLimit = h.Max
+	if !h.Persist {
+		return
+	}
+	path, err := historyFilePath()
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0700)
+	}
+	if err == nil {
+		var f *os.File
+		if f, err = os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0600); err == nil {
+			f.Close()
+		}
+	}
+	if err != nil {
+		if verbose {
+			fmt.Printf("Prompt history will not be saved: %v\n", err)
+		}
+		return
+	}
+	cfg.HistoryFile = path
+}
+
+// clearPromptHistory implements /history clear: it empties the history file.
+// The REPL restarts readline after every slash command, so the new instance
+// starts with no history either way.
+func clearPromptHistory(cfg *readline.Config) {
+	if cfg.HistoryFile != "" {
+		if err := os.Truncate(cfg.HistoryFile, 0); err != nil {
+			fmt.Printf("Error clearing prompt history: %v\n", err)
+			return
+		}
+	}
+	fmt.Println("Prompt history cleared.")
+}
@@ -0,0 +1,63 @@
+package chat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chzyer/readline"
+)
+
+// historyValues returns a ParseHistorySettings lookup of persist and max.
+func historyValues(persist, max string) func(string) string {
+	return func(key string) string {
+		return map[string]string{ConfHistoryPersist: persist, ConfHistoryMax: max}[key]
+	}
+}
+
+func TestParseHistorySettings(t *testing.T) {
+	if h, err := ParseHistorySettings(historyValues("", "")); err != nil || h != DefaultHistorySettings() || !h.Persist || h.Max != defaultHistoryMax {
+		t.Errorf("defaults: %+v, err %v", h, err)
+	}
+	if h, err := ParseHistorySettings(historyValues("false", "2000")); err != nil || h.Persist || h.Max != 2000 {
+		t.Errorf("false, 2000: %+v, err %v", h, err)
+	}
+	for _, bad := range [][2]string{{"sometimes", ""}, {"", "0"}, {"", "lots"}} {
+		if _, err := ParseHistorySettings(historyValues(bad[0], bad[1])); err == nil {
+			t.Errorf("ParseHistorySettings(%q, %q) accepted", bad[0], bad[1])
+		}
+	}
+}
+
+func TestConfigureHistoryFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SNAP_USER_DATA", dir)
+
+	cfg := &readline.Config{}
+	HistorySettings{Persist: true, Max: 50}.configureHistoryFile(cfg, false)
+	want := filepath.Join(dir, historyFileName)
+	if cfg.HistoryFile != want || cfg.HistoryLimit != 50 {
+		t.Fatalf("HistoryFile %q, HistoryLimit %d; want %q, 50", cfg.HistoryFile, cfg.HistoryLimit, want)
+	}
+	info, err := os.Stat(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("history file mode = %v, want 0600", perm)
+	}
+
+	if err := os.WriteFile(want, []byte("what is ceph\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	clearPromptHistory(cfg)
+	if data, _ := os.ReadFile(want); len(data) != 0 {
+		t.Errorf("history after clear = %q, want empty", data)
+	}
+
+	cfg = &readline.Config{}
+	HistorySettings{Persist: false, Max: defaultHistoryMax}.configureHistoryFile(cfg, false)
+	if cfg.HistoryFile != "" {
+		t.Errorf("HistoryFile = %q with persistence off, want none", cfg.HistoryFile)
+	}
+}
@@ -3,6 +3,7 @@ package chat
 import (
 	"encoding/json"
 	"errors"
+	"github.com/jpnorenam/rag-snap/pkg/rag"
 	"os"
 	"path/filepath"
 )
@@ -19,9 +20,9 @@ type PromptConfig struct {
 // DefaultPrompts returns the built-in default prompt configuration.
 func DefaultPrompts() PromptConfig {
 	return PromptConfig{
-		SourceRules:        ragSourceRules,
-		AnswerSystemPrompt: ragAnswerSystemPrompt,
-		ChatSystemPrompt:   ragChatSystemPrompt,
+		SourceRules:        rag.SourceRules,
+		AnswerSystemPrompt: rag.AnswerSystemPrompt,
+		ChatSystemPrompt:   rag.ChatSystemPrompt,
 	}
 }
 
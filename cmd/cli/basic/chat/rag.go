@@ -7,13 +7,14 @@ import (
 	"strings"
 	"sync"
 
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
 	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/rag"
 	"github.com/openai/openai-go/v3"
 )
 
 const (
-	defaultRAGTopK     = 15
+	defaultRAGTopK     = rag.DefaultTopK
 	maxRewriteTurns    = 3
 	maxRewriteTokens   = 256
 	maxAssistantLength = 400
@@ -25,33 +26,16 @@ type extractedKeywords struct {
 	Expansion []string `json:"expansion"`
 }
 
-// formatContext renders a slice of search hits into a single text block
-// suitable for injection into a RAG prompt. Each chunk is prefixed with its
-// resolved knowledge label so the LLM can apply the priority rules the active
-// system prompt defines for those labels.
-func formatContext(hits []knowledge.SearchHit) string {
-	var b strings.Builder
-	for i, hit := range hits {
-		if i > 0 {
-			b.WriteString("\n---\n")
-		}
-		fmt.Fprintf(&b, "%s\n", knowledge.LabelTag(hit.Label))
-		b.WriteString(hit.Content)
-		fmt.Fprintf(&b, "\n(source: %s, score: %.4f)", hit.SourceID, hit.Score)
-	}
-	return b.String()
-}
-
-// retrieveContext searches all active knowledge sources for content relevant to
+// retrieveHits searches all active knowledge sources for content relevant to
 // query. Local OpenSearch indexes and kapa.ai are queried in parallel when both
 // are available. Local hits appear first (more specific); kapa hits follow.
-// Returns an empty string when no sources are configured or retrieval yields nothing.
-func retrieveContext(session *Session, query, lexicalQuery string, verbose bool) string {
+// Returns nil when no sources are configured or retrieval yields nothing.
+func retrieveHits(session *Session, query, lexicalQuery string, verbose bool) []knowledge.SearchHit {
 	hasLocal := session.KnowledgeClient != nil && len(session.ActiveIndexes) > 0 && session.EmbeddingModelID != ""
 	hasKapa := session.KapaClient != nil && len(session.ActiveKapaGroups) > 0
 
 	if !hasLocal && !hasKapa {
-		return ""
+		return nil
 	}
 
 	var (
@@ -66,13 +50,14 @@ func retrieveContext(session *Session, query, lexicalQuery string, verbose bool)
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			localHits, localErr = session.KnowledgeClient.Search(
+			localHits, localErr = session.KnowledgeClient.SearchWithOptions(
 				context.Background(),
 				session.ActiveIndexes,
 				query,
 				lexicalQuery,
 				session.EmbeddingModelID,
-				defaultRAGTopK,
+				sessionTopK(session),
+				session.Filter,
 			)
 		}()
 	}
@@ -84,7 +69,7 @@ func retrieveContext(session *Session, query, lexicalQuery string, verbose bool)
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			kapaHits, kapaErr = session.KapaClient.Search(context.Background(), query, defaultRAGTopK, session.ActiveKapaGroups)
+			kapaHits, kapaErr = session.KapaClient.Search(context.Background(), query, sessionTopK(session), session.ActiveKapaGroups)
 		}()
 	}
 
@@ -93,23 +78,34 @@ func retrieveContext(session *Session, query, lexicalQuery string, verbose bool)
 	if localErr != nil && verbose {
 		fmt.Printf("Knowledge search failed: %v\n", localErr)
 	}
+	if localErr != nil && session.KnowledgeURL != "" {
+		checkKnowledgeOnline(session)
+	}
 	if kapaErr != nil && verbose {
 		fmt.Printf("Kapa search failed: %v\n", kapaErr)
 	}
+	// Kapa scores are rank positions, not relevance, so only local hits are
+	// held to the score floor.
+	if kept := rag.DropWeakHits(localHits, session.MinScore); len(kept) < len(localHits) {
+		if verbose {
+			fmt.Printf("Dropped %d hits scoring below %g\n", len(localHits)-len(kept), session.MinScore)
+		}
+		localHits = kept
+	}
 
 	allHits := make([]knowledge.SearchHit, 0, len(localHits)+len(kapaHits))
 	allHits = append(allHits, localHits...)
 	allHits = append(allHits, kapaHits...)
 
 	if len(allHits) == 0 {
-		return ""
+		return nil
 	}
 
 	if verbose {
 		fmt.Printf("Retrieved %d local + %d kapa results\n", len(localHits), len(kapaHits))
 	}
 
-	return formatContext(allHits)
+	return allHits
 }
 
 // rewriteSearchQuery uses the inference server to extract search keywords
@@ -160,7 +156,7 @@ func rewriteSearchQuery(
 		return query
 	}
 
-	raw := strings.TrimSpace(StripThinkTags(resp.Choices[0].Message.Content))
+	raw := strings.TrimSpace(rag.StripThinkTags(resp.Choices[0].Message.Content))
 	if raw == "" {
 		return query
 	}
@@ -197,23 +193,6 @@ func rewriteSearchQuery(
 	return result
 }
 
-// StripThinkTags removes <think>...</think> reasoning blocks that
-// reasoning models (e.g. DeepSeek R1) emit before their actual response.
-func StripThinkTags(s string) string {
-	for {
-		start := strings.Index(s, "<think>")
-		if start == -1 {
-			return s
-		}
-		end := strings.Index(s, "</think>")
-		if end == -1 {
-			// Unclosed <think> — drop everything from the tag onward.
-			return s[:start]
-		}
-		s = s[:start] + s[end+len("</think>"):]
-	}
-}
-
 // conversationMessage is used to extract role and content from the
 // ChatCompletionMessageParamUnion discriminated union via JSON round-tripping.
 type conversationMessage struct {
@@ -242,7 +221,7 @@ func formatConversationForRewrite(messages []openai.ChatCompletionMessageParamUn
 		}
 		// Strip reasoning blocks from assistant responses.
 		if cm.Role == "assistant" {
-			cm.Content = StripThinkTags(cm.Content)
+			cm.Content = rag.StripThinkTags(cm.Content)
 			cm.Content = strings.TrimSpace(cm.Content)
 		}
 		if cm.Content == "" {
@@ -274,57 +253,3 @@ func formatConversationForRewrite(messages []openai.ChatCompletionMessageParamUn
 	}
 	return b.String()
 }
-
-// ragSourceRules is the non-negotiable source-grounding block appended to any
-// custom manifest prompt to ensure [CANONICAL]/[KAPA-CANONICAL]/[UPSTREAM] rules
-// are always active, regardless of which of those tags actually shows up in a
-// given context (Kapa.ai is an optional integration; [KAPA-CANONICAL] chunks are
-// only present when it is enabled and configured).
-const ragSourceRules = "Source rules (mandatory, override any prior instruction):\n" +
-	"- Context chunks are tagged with knowledge labels assigned at ingestion; the default labels are [CANONICAL], [KAPA-CANONICAL], and [UPSTREAM]. Not every tag necessarily appears in a given context — Kapa.ai integration is optional, so [KAPA-CANONICAL] chunks are only present when it is enabled and returns results. Apply the rules below only to tags actually present; never treat a missing tag as a gap to fill with outside knowledge.\n" +
-	"- Priority among tags actually present: [CANONICAL] > [KAPA-CANONICAL] > [UPSTREAM]. A higher-priority tag overrides a lower one on the same point; a lower-priority tag remains usable on points no higher-priority tag covers.\n" +
-	"- Only name a product or component if a [CANONICAL] or [KAPA-CANONICAL] chunk explicitly documents it. Do NOT name anything found only in [UPSTREAM] chunks.\n" +
-	"- If the question names a product as an example, do not repeat or endorse it unless a [CANONICAL] or [KAPA-CANONICAL] chunk confirms it.\n" +
-	"- Never speculate or use knowledge outside the provided context."
-
-// ragAnswerSystemPrompt is the system-level instruction for batch answer (rag answer batch).
-// Produces professional, document-ready responses suitable for submission in RFI/RFP documents.
-const ragAnswerSystemPrompt = "You are a Canonical support engineer responding to a procurement executive on behalf of Canonical. Apply these rules strictly:\n" +
-	"1. GROUNDING: Use ONLY information explicitly stated in the provided context. Never infer, extrapolate, or use outside knowledge.\n" +
-	"2. SOURCE PRIORITY: Context chunks are tagged with knowledge labels assigned at ingestion; the default labels are [CANONICAL], [KAPA-CANONICAL], and [UPSTREAM]. Not all three necessarily appear — Kapa.ai integration is optional, so [KAPA-CANONICAL] chunks are only present when it is enabled and returns results. Apply priority only among tags actually present in the context; never treat a missing tier as a gap to fill with outside knowledge.\n" +
-	"   - [CANONICAL]: private internal documents (RFPs, implementation notes) — most specific, takes precedence over [KAPA-CANONICAL] and [UPSTREAM] on the same point.\n" +
-	"   - [KAPA-CANONICAL]: official Canonical public documentation — authoritative for general product facts and capabilities on points no [CANONICAL] chunk covers.\n" +
-	"   - [UPSTREAM]: third-party upstream docs — supplemental only, lowest priority; usable on points no [CANONICAL] or [KAPA-CANONICAL] chunk covers, subject to rule 3.\n" +
-	"   When sources conflict on the same point, follow the higher-priority source exclusively.\n" +
-	"3. PRODUCTS: Only name a product or component if a [CANONICAL] or [KAPA-CANONICAL] chunk explicitly documents or endorses it. " +
-	"Do NOT name any product found only in [UPSTREAM] chunks — not even as background context or an example. " +
-	"If the question itself names a product as an example, do NOT repeat or endorse it unless a [CANONICAL] or [KAPA-CANONICAL] chunk explicitly confirms it. " +
-	"Never mention proprietary third-party products.\n" +
-	"4. FORMAT: Write for a procurement executive, not a technical audience. " +
-	"Be direct and concise — state the capability or answer plainly, then stop. " +
-	"Use declarative third-person statements (e.g. 'The solution provides…', 'Canonical offers…'). " +
-	"Do NOT use bullet points, numbered lists, or headers. Write in flowing prose only. " +
-	"Do not include preamble, meta-commentary, or phrases like 'Based on the context…'.\n" +
-	"5. NO ANSWER: If the context does not contain enough information, reply exactly: " +
-	"\"The provided context does not contain enough information to answer this question.\""
-
-// ragChatSystemPrompt is the system-level instruction for the interactive chat REPL (rag chat).
-// Grounded and conversational — follows the same strict accuracy rules with natural phrasing.
-const ragChatSystemPrompt = "You are a Canonical technical assistant. Apply these rules strictly:\n" +
-	"1. GROUNDING: Use ONLY information explicitly stated in the provided context. Never infer, extrapolate, or use outside knowledge.\n" +
-	"2. SOURCE PRIORITY: Context chunks are tagged with knowledge labels assigned at ingestion; the default labels are [CANONICAL], [KAPA-CANONICAL], and [UPSTREAM]. Not all three necessarily appear — Kapa.ai integration is optional, so [KAPA-CANONICAL] chunks are only present when it is enabled and returns results. Apply priority only among tags actually present in the context; never treat a missing tier as a gap to fill with outside knowledge.\n" +
-	"   - [CANONICAL]: private internal documents (RFPs, implementations) — takes precedence over [KAPA-CANONICAL] and [UPSTREAM] on the same point.\n" +
-	"   - [KAPA-CANONICAL]: official Canonical public documentation — authoritative for general product facts on points no [CANONICAL] chunk covers.\n" +
-	"   - [UPSTREAM]: third-party upstream docs — supplemental only, usable on points no [CANONICAL] or [KAPA-CANONICAL] chunk covers, subject to rule 3.\n" +
-	"   When sources conflict on the same point, follow the higher-priority source.\n" +
-	"3. PRODUCTS: Only name a product or component if a [CANONICAL] or [KAPA-CANONICAL] chunk explicitly documents or endorses it. " +
-	"Do NOT name any product found only in [UPSTREAM] chunks — not even as background context or an example. " +
-	"Never mention proprietary third-party products.\n" +
-	"4. FORMAT: Be concise and direct. Use bullet points when listing multiple items. You may ask a clarifying question if the query is ambiguous.\n" +
-	"5. NO ANSWER: If the context does not contain enough information, say so plainly and do not speculate."
-
-// buildRAGPrompt wraps the user's original prompt with the retrieved
-// context so the LLM can ground its answer.
-func buildRAGPrompt(ragContext, prompt string) string {
-	return fmt.Sprintf("Context:\n%s\n\nQuestion: %s", ragContext, prompt)
-}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/jpnorenam/rag-snap/cmd/cli/basic/rfp"
+	"github.com/jpnorenam/rag-snap/pkg/rag"
 	"github.com/openai/openai-go/v3"
 )
 
@@ -80,7 +81,7 @@ func RefineQuestions(baseURL, model string, questions []rfp.Question) ([]rfp.Que
 		return nil, nil, fmt.Errorf("empty response from LLM")
 	}
 
-	raw := strings.TrimSpace(StripThinkTags(resp.Choices[0].Message.Content))
+	raw := strings.TrimSpace(rag.StripThinkTags(resp.Choices[0].Message.Content))
 	raw = strings.TrimPrefix(raw, "```json")
 	raw = strings.TrimPrefix(raw, "```")
 	raw = strings.TrimSuffix(raw, "```")
@@ -11,9 +11,10 @@ import (
 	"github.com/charmbracelet/huh"
 	"github.com/chzyer/readline"
 	"github.com/fatih/color"
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
 	"github.com/jpnorenam/rag-snap/cmd/cli/common"
 	"github.com/jpnorenam/rag-snap/internal/apiclient"
+	"github.com/jpnorenam/rag-snap/internal/chatstore"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
 )
 
 // RemoteClient runs the interactive chat REPL against a ragd daemon over its
@@ -22,7 +23,7 @@ import (
 // REPL only sends prompts and renders streamed token/think frames. /use-knowledge
 // becomes a set-active-kbs control message; other slash commands behave as in the
 // direct REPL where they make sense.
-func RemoteClient(dc *apiclient.Client, llmModelName string, bases []string, temperature float64, promptVariant string) error {
+func RemoteClient(dc *apiclient.Client, llmModelName string, bases []string, temperature float64, promptVariant string, history HistorySettings) error {
 	ctx := context.Background()
 
 	stop := common.StartProgressSpinner("Connecting to ragd")
@@ -60,6 +61,7 @@ func RemoteClient(dc *apiclient.Client, llmModelName string, bases []string, tem
 		HistorySearchFold:      true,
 		FuncFilterInputRune:    filterInput,
 	}
+	history.configureHistoryFile(rlConfig, false)
 	rl, err := readline.NewEx(rlConfig)
 	if err != nil {
 		return fmt.Errorf("error initializing readline: %w", err)
@@ -68,7 +70,7 @@ func RemoteClient(dc *apiclient.Client, llmModelName string, bases []string, tem
 	log.SetOutput(rl.Stderr())
 
 	for {
-		prompt, err := rl.Readline()
+		prompt, multiline, err := readPrompt(rl)
 		clearSlashHints()
 		if errors.Is(err, readline.ErrInterrupt) {
 			if len(prompt) == 0 {
@@ -78,9 +80,16 @@ func RemoteClient(dc *apiclient.Client, llmModelName string, bases []string, tem
 		} else if err == io.EOF {
 			break
 		}
-		if prompt == "exit" {
+		if prompt == "exit" && !multiline {
 			break
 		}
+		// A """ block is always a prompt, even when it starts with a slash.
+		if multiline {
+			if err := remoteSendPrompt(ctx, rl, session, prompt); err != nil {
+				return err
+			}
+			continue
+		}
 
 		// /use-knowledge maps to a set-active-kbs control frame; the daemon
 		// holds the active set for the session. With no inline args it opens
@@ -117,10 +126,11 @@ func RemoteClient(dc *apiclient.Client, llmModelName string, bases []string, tem
 		// /history lists the shared store and, on selection, closes this session
 		// and starts a fresh one resumed from the chosen chat. Readline is torn
 		// down around the huh picker, as for /use-knowledge.
-		if verb, _, _ := strings.Cut(strings.TrimSpace(prompt), " "); verb == cmdHistory {
+		if verb, args, _ := strings.Cut(strings.TrimSpace(prompt), " "); verb == cmdHistory {
 			rl.Close()
-			newSession, newBases, ok := remoteHistory(ctx, dc)
-			if ok {
+			if strings.TrimSpace(args) == historyClearArg {
+				clearPromptHistory(rlConfig)
+			} else if newSession, newBases, ok := remoteHistory(ctx, dc); ok {
 				session.Close()
 				session = newSession
 				activeBases = newBases
@@ -132,16 +142,18 @@ func RemoteClient(dc *apiclient.Client, llmModelName string, bases []string, tem
 			log.SetOutput(rl.Stderr())
 			continue
 		}
+		// The daemon keeps the live transcript, so /export goes through the
+		// saved copy: /save it, then export with `chat export`.
+		if verb, _, _ := strings.Cut(strings.TrimSpace(prompt), " "); verb == cmdExport {
+			fmt.Printf("%s is not available over the daemon yet; use %s, then `chat export <file>` to export the saved chat.\n", cmdExport, cmdSave)
+			continue
+		}
 		if strings.HasPrefix(prompt, "/") {
 			fmt.Printf("Command %q is not available over the daemon; use it in direct mode.\n", prompt)
 			continue
 		}
 
-		if len(prompt) == 0 {
-			continue
-		}
-		rl.SaveHistory(prompt)
-		if err := remotePromptTurn(ctx, session, prompt); err != nil {
+		if err := remoteSendPrompt(ctx, rl, session, prompt); err != nil {
 			return err
 		}
 	}
@@ -149,6 +161,21 @@ func RemoteClient(dc *apiclient.Client, llmModelName string, bases []string, tem
 	return nil
 }
 
+// remoteSendPrompt records prompt in the history, inlines its @file:
+// attachments, and runs it as a turn. An empty prompt, or one whose attachments
+// cannot be read, is not sent.
+func remoteSendPrompt(ctx context.Context, rl *readline.Instance, session *apiclient.ChatSession, prompt string) error {
+	if len(prompt) == 0 {
+		return nil
+	}
+	saveHistory(rl, prompt)
+	expanded, ok := expandAttachments(prompt)
+	if !ok {
+		return nil
+	}
+	return remotePromptTurn(ctx, session, expanded)
+}
+
 // remoteSetActiveBases resolves the desired active knowledge bases and sends
 // them to the daemon as a set-active-kbs frame, returning the acknowledged set.
 // "/use-knowledge base1 base2 ..." uses the inline names; bare "/use-knowledge"
@@ -332,7 +359,7 @@ func formatRemoteSearchResults(hits []apiclient.SearchHit) string {
 
 		header := fmt.Sprintf("[%d] score %.4f  ·  %s  %s", i+1, hit.Score, hit.Base, knowledge.LabelTag(hit.Label))
 		fmt.Fprintln(&b, color.New(color.Bold).Sprint(header))
-		fmt.Fprintf(&b, "    source: %s   created: %s\n", hit.SourceID, hit.CreatedAt)
+		fmt.Fprintf(&b, "    source: %s   created: %s\n", knowledge.Citation(hit.SourceID, hit.Page), hit.CreatedAt)
 		fmt.Fprintln(&b, color.HiBlackString("    "+strings.Repeat("─", 56)))
 		b.WriteString(hit.Content)
 		b.WriteString("\n")
@@ -383,3 +410,33 @@ func remotePromptTurn(ctx context.Context, session *apiclient.ChatSession, promp
 		}
 	}
 }
+
+// ExportRemoteChat writes a chat saved in the daemon's shared store to path.
+// With an empty id the user picks the chat interactively.
+func ExportRemoteChat(ctx context.Context, dc *apiclient.Client, id, path string) error {
+	if _, err := chatstore.FormatForPath(path); err != nil {
+		return err
+	}
+	if id == "" {
+		stop := common.StartProgressSpinner("Fetching saved chats")
+		summaries, err := dc.ListChats(ctx, "")
+		stop()
+		if err != nil {
+			return fmt.Errorf("listing saved chats: %w", err)
+		}
+		picked, ok := pickSavedChat(summaries)
+		if !ok {
+			return nil
+		}
+		id = picked.ID
+	}
+	saved, err := dc.GetChat(ctx, id)
+	if err != nil {
+		return fmt.Errorf("opening saved chat: %w", err)
+	}
+	if err := chatstore.Export(*saved, path); err != nil {
+		return err
+	}
+	fmt.Printf("Exported %q to %s.\n", saved.Title, path)
+	return nil
+}
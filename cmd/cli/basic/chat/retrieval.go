@@ -0,0 +1,39 @@
+package chat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseRetrieval reads the retrieval defaults of new chat sessions from the
+// chat.rag.top-k (hits fetched per search; empty for 15) and chat.rag.min-score
+// (hits scoring below it are dropped; empty or 0 keeps every hit) config
+// values. /set top-k and /set min-score override them for one REPL session.
+func parseRetrieval(topK, minScore string) (int, float64, error) {
+	k := defaultRAGTopK
+	if topK = strings.TrimSpace(topK); topK != "" {
+		n, err := strconv.Atoi(topK)
+		if err != nil || n <= 0 {
+			return 0, 0, fmt.Errorf("invalid chat.rag.top-k %q: expected a positive number of hits", topK)
+		}
+		k = n
+	}
+	var floor float64
+	if minScore = strings.TrimSpace(minScore); minScore != "" {
+		f, err := strconv.ParseFloat(minScore, 64)
+		if err != nil || f < 0 {
+			return 0, 0, fmt.Errorf("invalid chat.rag.min-score %q: expected a non-negative score", minScore)
+		}
+		floor = f
+	}
+	return k, floor, nil
+}
+
+// sessionTopK returns the number of hits a search fetches for session.
+func sessionTopK(session *Session) int {
+	if session.TopK > 0 {
+		return session.TopK
+	}
+	return defaultRAGTopK
+}
@@ -0,0 +1,21 @@
+package chat
+
+import "testing"
+
+func TestParseRetrieval(t *testing.T) {
+	k, floor, err := parseRetrieval("8", "0.3")
+	if err != nil {
+		t.Fatalf("parseRetrieval returned error: %v", err)
+	}
+	if k != 8 || floor != 0.3 {
+		t.Errorf("defaults = %d/%g, want 8/0.3", k, floor)
+	}
+	if k, floor, err := parseRetrieval("", ""); err != nil || k != defaultRAGTopK || floor != 0 {
+		t.Errorf("parseRetrieval(empty) = %d/%g, %v, want %d/0", k, floor, err, defaultRAGTopK)
+	}
+	for _, in := range [][2]string{{"0", ""}, {"ten", ""}, {"", "-0.1"}, {"", "high"}} {
+		if _, _, err := parseRetrieval(in[0], in[1]); err == nil {
+			t.Errorf("parseRetrieval(%q, %q) = nil error, want error", in[0], in[1])
+		}
+	}
+}
@@ -3,52 +3,128 @@ package chat
 import (
 	"context"
 	"fmt"
+	"maps"
 	"strconv"
 	"strings"
 
 	"github.com/fatih/color"
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/storage"
 )
 
 // searchUsage is printed when /search is invoked with missing or invalid args.
-var searchUsage = fmt.Sprintf("Usage: /search [-k N] <query>\n"+
+var searchUsage = fmt.Sprintf("Usage: /search [-k N] <query> | /search <saved-search>\n"+
 	"  Retrieve matching chunks from the active knowledge bases (no answer is generated).\n"+
-	"  -k N   maximum number of results (default: %d)", defaultRAGTopK)
+	"  -k N   maximum number of results (default: %d)\n"+
+	"  A saved search's name runs it, over its own bases and filters.", defaultRAGTopK)
+
+// savedSearchStore looks up the searches saved with knowledge saved-search.
+type savedSearchStore interface {
+	Get(name string) (storage.SavedSearch, error)
+}
 
 // handleSearch implements the /search slash command: a retrieval-only query
 // against the active knowledge bases. It runs the same hybrid pipeline as the
 // RAG loop but performs no query rewriting, no augmentation, and no LLM
 // generation — it simply prints the matching chunks with their metadata.
 func handleSearch(args string, session *Session) {
+	if saved, ok := lookupSavedSearch(args, session); ok {
+		runSavedSearch(saved, session)
+		return
+	}
+
 	k, terms, ok := parseSearchArgs(args)
 	if !ok {
 		fmt.Println(searchUsage)
 		return
 	}
+	runSearch(session, session.ActiveIndexes, terms, k, session.Filter)
+}
+
+// lookupSavedSearch returns the saved search args names, when args is a
+// single word naming one. A one-word query that is not a saved search's name
+// is searched for as usual.
+func lookupSavedSearch(args string, session *Session) (storage.SavedSearch, bool) {
+	name := strings.TrimSpace(args)
+	if session.SavedSearches == nil || name == "" || strings.ContainsAny(name, " \t") {
+		return storage.SavedSearch{}, false
+	}
+	if storage.ValidateSavedSearchName(name) != nil {
+		return storage.SavedSearch{}, false
+	}
+	saved, err := session.SavedSearches.Get(name)
+	if err != nil {
+		return storage.SavedSearch{}, false
+	}
+	return saved, true
+}
 
-	// Preconditions mirror retrieveContext: without a client, active indexes,
+// runSavedSearch runs a saved search over its own bases, or the active ones
+// when it names none, adding its metadata filters to the session's filter.
+func runSavedSearch(saved storage.SavedSearch, session *Session) {
+	indexes := session.ActiveIndexes
+	if len(saved.Bases) > 0 {
+		indexes = make([]string, len(saved.Bases))
+		for i, base := range saved.Bases {
+			indexes[i] = knowledge.FullIndexName(base)
+		}
+	}
+
+	tags, err := knowledge.ParseTags(saved.Filters)
+	if err != nil {
+		fmt.Printf("Saved search is invalid: %v\n", err)
+		return
+	}
+	opts := session.Filter
+	if len(tags) > 0 {
+		opts.Tags = maps.Clone(opts.Tags)
+		if opts.Tags == nil {
+			opts.Tags = map[string]string{}
+		}
+		maps.Copy(opts.Tags, tags)
+	}
+
+	k := saved.TopK
+	if k <= 0 {
+		k = defaultRAGTopK
+	}
+	fmt.Println(dim(fmt.Sprintf("Searching for %q", saved.Query)))
+	runSearch(session, indexes, saved.Query, k, opts)
+}
+
+// runSearch retrieves the top k hits for terms from indexes and prints them.
+func runSearch(session *Session, indexes []string, terms string, k int, opts knowledge.SearchOptions) {
+	// Preconditions mirror retrieveHits: without a client, active indexes,
 	// and an embedding model, the hybrid pipeline cannot run.
+	if session.knowledgeOffline {
+		fmt.Printf("The knowledge base is unavailable; use %s once it is back.\n", cmdReconnect)
+		return
+	}
 	if session.KnowledgeClient == nil || session.EmbeddingModelID == "" {
 		fmt.Println("Knowledge retrieval is unavailable for this session.")
 		return
 	}
-	if len(session.ActiveIndexes) == 0 {
+	if len(indexes) == 0 {
 		fmt.Printf("No active knowledge bases. Select one with %s first.\n", cmdUseKnowledge)
 		return
 	}
 
 	// Verbatim terms for both the lexical (BM25) and neural/rerank query —
 	// no rewriteSearchQuery, so no inference-server round-trip.
-	hits, err := session.KnowledgeClient.Search(
+	hits, err := session.KnowledgeClient.SearchWithOptions(
 		context.Background(),
-		session.ActiveIndexes,
+		indexes,
 		terms,
 		terms,
 		session.EmbeddingModelID,
 		k,
+		opts,
 	)
 	if err != nil {
 		fmt.Printf("Search failed: %v\n", err)
+		if session.KnowledgeURL != "" {
+			checkKnowledgeOnline(session)
+		}
 		return
 	}
 
@@ -101,7 +177,7 @@ func parseSearchArgs(args string) (k int, terms string, ok bool) {
 }
 
 // formatSearchResults renders search hits for human reading. Unlike
-// formatContext (which is tuned for LLM injection), this leads with provenance
+// FormatContext (which is tuned for LLM injection), this leads with provenance
 // metadata and prints the full, untruncated chunk content. Hits are already
 // sorted by score descending by Search.
 func formatSearchResults(hits []knowledge.SearchHit) string {
@@ -118,7 +194,7 @@ func formatSearchResults(hits []knowledge.SearchHit) string {
 
 		header := fmt.Sprintf("[%d] score %.4f  ·  %s  %s", i+1, hit.Score, name, knowledge.LabelTag(hit.Label))
 		fmt.Fprintln(&b, color.New(color.Bold).Sprint(header))
-		fmt.Fprintf(&b, "    source: %s   created: %s\n", hit.SourceID, hit.CreatedAt)
+		fmt.Fprintf(&b, "    source: %s   created: %s\n", knowledge.Citation(hit.SourceID, hit.Page), hit.CreatedAt)
 		fmt.Fprintln(&b, color.HiBlackString("    "+strings.Repeat("─", 56)))
 		b.WriteString(hit.Content)
 		b.WriteString("\n")
@@ -0,0 +1,48 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/jpnorenam/rag-snap/pkg/storage"
+)
+
+// fakeSavedSearches is a savedSearchStore backed by a map.
+type fakeSavedSearches map[string]storage.SavedSearch
+
+func (f fakeSavedSearches) Get(name string) (storage.SavedSearch, error) {
+	s, ok := f[name]
+	if !ok {
+		return storage.SavedSearch{}, storage.ErrorNotFound
+	}
+	return s, nil
+}
+
+func TestLookupSavedSearch(t *testing.T) {
+	session := &Session{SavedSearches: fakeSavedSearches{
+		"open-cves": {Query: "open CVEs"},
+	}}
+
+	tests := []struct {
+		args string
+		want bool
+	}{
+		{"open-cves", true},
+		{"  open-cves ", true},
+		{"open-cves in 2024", false}, // a query that starts with the name
+		{"kubernetes", false},        // a one-word query
+		{"", false},
+	}
+	for _, tt := range tests {
+		saved, ok := lookupSavedSearch(tt.args, session)
+		if ok != tt.want {
+			t.Errorf("lookupSavedSearch(%q) ok = %v, want %v", tt.args, ok, tt.want)
+		}
+		if ok && saved.Query != "open CVEs" {
+			t.Errorf("lookupSavedSearch(%q) = %+v", tt.args, saved)
+		}
+	}
+
+	if _, ok := lookupSavedSearch("open-cves", &Session{}); ok {
+		t.Error("lookupSavedSearch without a store found a saved search")
+	}
+}
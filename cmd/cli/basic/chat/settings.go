@@ -0,0 +1,78 @@
+package chat
+
+import "time"
+
+// Config keys of the settings a chat session retrieves, prompts, and caches
+// with.
+const (
+	ConfContextMax        = "chat.context.max"
+	ConfContextTruncation = "chat.context.truncation"
+	ConfMultiQuery        = "chat.multiquery"
+	ConfRAGTopK           = "chat.rag.top-k"
+	ConfRAGMinScore       = "chat.rag.min-score"
+	ConfRAGSanitize       = "chat.rag.sanitize"
+	ConfRAGConfidence     = "chat.rag.confidence"
+	ConfCacheTTL          = "chat.cache.ttl"
+	ConfMemory            = "chat.memory"
+	ConfHistoryPersist    = "chat.history.persist"
+	ConfHistoryMax        = "chat.history.max"
+)
+
+// Settings are the chat.* settings of a chat session, as ParseSettings reads
+// them. The zero Settings are the defaults.
+type Settings struct {
+	// budget caps the RAG context injected into a prompt.
+	budget contextBudget
+	// multiQuery is whether sessions start with multi-query retrieval.
+	multiQuery bool
+	// topK and minScore are the retrieval breadth and score floor sessions
+	// start with; a zero topK is defaultRAGTopK.
+	topK     int
+	minScore float64
+	// unsanitized is whether retrieved context reaches the model as is,
+	// rather than sanitized, delimited, and guarded.
+	unsanitized bool
+	// confidence is the top retrieval score below which a knowledge-seeking
+	// question is answered with "I cannot find it" rather than a guess; 0
+	// turns the check off.
+	confidence float64
+	// cacheTTL is how long answers are cached; zero turns the cache off.
+	cacheTTL time.Duration
+	// memory is whether REPL sessions are summarized into long-term memory
+	// when they end.
+	memory bool
+}
+
+// ParseSettings reads the chat.* values get returns, but for the REPL's
+// prompt history (see ParseHistorySettings). An empty value keeps the key's
+// default.
+func ParseSettings(get func(key string) string) (Settings, error) {
+	var (
+		s   Settings
+		err error
+	)
+	if s.budget, err = parseContextBudget(get(ConfContextMax), get(ConfContextTruncation)); err != nil {
+		return Settings{}, err
+	}
+	if s.multiQuery, err = parseMultiQuery(get(ConfMultiQuery)); err != nil {
+		return Settings{}, err
+	}
+	if s.topK, s.minScore, err = parseRetrieval(get(ConfRAGTopK), get(ConfRAGMinScore)); err != nil {
+		return Settings{}, err
+	}
+	sanitize, err := parseSanitize(get(ConfRAGSanitize))
+	if err != nil {
+		return Settings{}, err
+	}
+	s.unsanitized = !sanitize
+	if s.confidence, err = parseConfidence(get(ConfRAGConfidence)); err != nil {
+		return Settings{}, err
+	}
+	if s.cacheTTL, err = parseResponseCacheTTL(get(ConfCacheTTL)); err != nil {
+		return Settings{}, err
+	}
+	if s.memory, err = parseMemory(get(ConfMemory)); err != nil {
+		return Settings{}, err
+	}
+	return s, nil
+}
@@ -0,0 +1,48 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSettings(t *testing.T) {
+	values := map[string]string{
+		ConfContextMax:     "9000",
+		ConfRAGTopK:        "8",
+		ConfRAGSanitize:    "false",
+		ConfCacheTTL:       "1h",
+		ConfHistoryMax:     "not read",
+		"knowledge.guard":  "not read",
+		ConfRAGConfidence:  "0.4",
+		ConfMultiQuery:     "true",
+		ConfMemory:         "",
+		ConfRAGMinScore:    "",
+		ConfHistoryPersist: "",
+	}
+	s, err := ParseSettings(func(key string) string { return values[key] })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := s.budgetFor(0); b.maxChars != 9000 || b.strategy != TruncateDrop {
+		t.Errorf("budget = %+v, want 9000/drop", b)
+	}
+	if s.topK != 8 || !s.unsanitized || s.cacheTTL != time.Hour || s.confidence != 0.4 || !s.multiQuery || s.memory {
+		t.Errorf("settings = %+v", s)
+	}
+
+	values = map[string]string{ConfContextTruncation: "shorten"}
+	if _, err := ParseSettings(func(key string) string { return values[key] }); err == nil || !strings.Contains(err.Error(), ConfContextTruncation) {
+		t.Errorf("ParseSettings with a bad truncation = %v, want an error naming the key", err)
+	}
+}
+
+func TestZeroSettings(t *testing.T) {
+	var s Settings
+	if s.unsanitized || s.budgetFor(0).strategy != TruncateDrop || s.newResponseCache(false) != nil {
+		t.Errorf("zero settings = %+v, want sanitized, dropping, uncached", s)
+	}
+	if !strings.Contains(s.buildPrompt("ctx", "q"), "ctx") {
+		t.Error("zero settings dropped the context from the prompt")
+	}
+}
@@ -0,0 +1,179 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// responseStats measures one streamed answer.
+type responseStats struct {
+	// tokens is the number of completion tokens generated.
+	tokens int64
+	// estimated is set when the server reported no usage, so tokens counts
+	// content chunks instead; most servers stream one token per chunk.
+	estimated bool
+	// firstToken is the time from sending the request to the first content.
+	firstToken time.Duration
+	// elapsed is the time from sending the request to the end of the stream.
+	elapsed time.Duration
+}
+
+// tokensPerSecond is the generation rate after the first token, so prompt
+// processing and retrieval latency do not drag it down. It is 0 when nothing
+// was generated.
+func (s responseStats) tokensPerSecond() float64 {
+	generating := s.elapsed - s.firstToken
+	if generating <= 0 {
+		generating = s.elapsed
+	}
+	if s.tokens == 0 || generating <= 0 {
+		return 0
+	}
+	return float64(s.tokens) / generating.Seconds()
+}
+
+// String renders the compact footer shown after an answer in verbose mode.
+func (s responseStats) String() string {
+	approx := ""
+	if s.estimated {
+		approx = "~"
+	}
+	return fmt.Sprintf("%s%d tokens · %.2fs to first token · %.1f tok/s · %.2fs total",
+		approx, s.tokens, s.firstToken.Seconds(), s.tokensPerSecond(), s.elapsed.Seconds())
+}
+
+// streamMeter times a streamed completion as its chunks arrive.
+type streamMeter struct {
+	start  time.Time
+	first  time.Time
+	chunks int64
+}
+
+func newStreamMeter() *streamMeter {
+	return &streamMeter{start: time.Now()}
+}
+
+// observe records a streamed content delta.
+func (m *streamMeter) observe(delta string) {
+	if delta == "" {
+		return
+	}
+	if m.first.IsZero() {
+		m.first = time.Now()
+	}
+	m.chunks++
+}
+
+// finish closes the measurement, preferring the server's usage report over the
+// chunk count.
+func (m *streamMeter) finish(usage openai.CompletionUsage) responseStats {
+	end := time.Now()
+	s := responseStats{tokens: usage.CompletionTokens, elapsed: end.Sub(m.start)}
+	if s.tokens == 0 {
+		s.tokens, s.estimated = m.chunks, m.chunks > 0
+	}
+	if !m.first.IsZero() {
+		s.firstToken = m.first.Sub(m.start)
+	}
+	return s
+}
+
+// sessionStats accumulates responseStats over a chat session for /stats.
+type sessionStats struct {
+	responses  int
+	tokens     int64
+	estimated  bool
+	firstToken time.Duration
+	generating time.Duration
+	elapsed    time.Duration
+}
+
+func (s *sessionStats) add(r responseStats) {
+	s.responses++
+	s.tokens += r.tokens
+	s.estimated = s.estimated || r.estimated
+	s.firstToken += r.firstToken
+	s.elapsed += r.elapsed
+	if g := r.elapsed - r.firstToken; g > 0 {
+		s.generating += g
+	}
+}
+
+// lines renders the session totals, one statistic per line.
+func (s sessionStats) lines() []string {
+	if s.responses == 0 {
+		return []string{"No responses yet."}
+	}
+	approx := ""
+	if s.estimated {
+		approx = "~"
+	}
+	rate := 0.0
+	if s.generating > 0 {
+		rate = float64(s.tokens) / s.generating.Seconds()
+	}
+	return []string{
+		fmt.Sprintf("Responses:             %d", s.responses),
+		fmt.Sprintf("Tokens generated:      %s%d", approx, s.tokens),
+		fmt.Sprintf("Avg. time to first:    %.2fs", (s.firstToken / time.Duration(s.responses)).Seconds()),
+		fmt.Sprintf("Avg. generation rate:  %.1f tok/s", rate),
+		fmt.Sprintf("Total response time:   %.2fs", s.elapsed.Seconds()),
+	}
+}
+
+// printSessionStats handles /stats.
+func printSessionStats(session *Session) {
+	for _, l := range session.stats.lines() {
+		fmt.Println(l)
+	}
+	if session.stats.estimated {
+		fmt.Println(dim("~ the server reported no token usage; counts are estimated from streamed chunks"))
+	}
+}
+
+const (
+	// throughputProbePrompt asks for an answer long enough to time generation
+	// and short enough to cost the server little.
+	throughputProbePrompt = "Count from 1 to 50, separated by spaces."
+	// throughputProbeTokens caps the probe's answer.
+	throughputProbeTokens = 64
+)
+
+// MeasureThroughput times one short streamed completion by model on the
+// inference server at baseURL and returns its generation rate in tokens per
+// second, as the chat footer reports it.
+func MeasureThroughput(ctx context.Context, baseURL, model string) (float64, error) {
+	client := NewInferenceClient(baseURL)
+	meter := newStreamMeter()
+	stream := client.Chat.Completions.NewStreaming(ctx, openai.ChatCompletionNewParams{
+		Messages:            []openai.ChatCompletionMessageParamUnion{openai.UserMessage(throughputProbePrompt)},
+		Model:               model,
+		MaxCompletionTokens: openai.Int(throughputProbeTokens),
+		MaxTokens:           openai.Int(throughputProbeTokens),
+		StreamOptions:       openai.ChatCompletionStreamOptionsParam{IncludeUsage: openai.Bool(true)},
+	})
+	defer stream.Close()
+
+	var usage openai.CompletionUsage
+	for stream.Next() {
+		chunk := stream.Current()
+		if len(chunk.Choices) > 0 {
+			meter.observe(chunk.Choices[0].Delta.Content)
+		}
+		if chunk.Usage.CompletionTokens > 0 {
+			usage = chunk.Usage
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return 0, fmt.Errorf("streaming probe completion: %w", err)
+	}
+	rate := meter.finish(usage).tokensPerSecond()
+	if rate == 0 {
+		return 0, errors.New("the inference server generated no tokens")
+	}
+	return rate, nil
+}
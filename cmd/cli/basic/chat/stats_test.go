@@ -0,0 +1,58 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResponseStatsRate(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats responseStats
+		want  float64
+	}{
+		{"excludes time to first token", responseStats{tokens: 100, firstToken: time.Second, elapsed: 3 * time.Second}, 50},
+		{"first token at end", responseStats{tokens: 10, firstToken: 2 * time.Second, elapsed: 2 * time.Second}, 5},
+		{"nothing generated", responseStats{elapsed: time.Second}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stats.tokensPerSecond(); got != tt.want {
+				t.Errorf("tokensPerSecond() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResponseStatsString(t *testing.T) {
+	s := responseStats{tokens: 100, firstToken: 500 * time.Millisecond, elapsed: 2500 * time.Millisecond}
+	want := "100 tokens · 0.50s to first token · 50.0 tok/s · 2.50s total"
+	if got := s.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	s.estimated = true
+	if got := s.String(); !strings.HasPrefix(got, "~100 tokens") {
+		t.Errorf("estimated String() = %q, want a ~ prefix", got)
+	}
+}
+
+func TestSessionStats(t *testing.T) {
+	var s sessionStats
+	if got := s.lines(); len(got) != 1 {
+		t.Fatalf("empty session lines = %q, want a single note", got)
+	}
+
+	s.add(responseStats{tokens: 60, firstToken: time.Second, elapsed: 2 * time.Second})
+	s.add(responseStats{tokens: 40, firstToken: 3 * time.Second, elapsed: 4 * time.Second, estimated: true})
+
+	if s.responses != 2 || s.tokens != 100 || !s.estimated {
+		t.Fatalf("totals = %+v", s)
+	}
+	got := strings.Join(s.lines(), "\n")
+	for _, want := range []string{"~100", "2.00s", "50.0 tok/s", "6.00s"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("lines missing %q:\n%s", want, got)
+		}
+	}
+}
@@ -3,9 +3,11 @@ package chat
 import (
 	"context"
 	"strings"
+	"time"
 
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
 	"github.com/jpnorenam/rag-snap/internal/chatstore"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/rag"
 	"github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/packages/ssestream"
 )
@@ -58,8 +60,9 @@ type LiveSession struct {
 // model is the resolved chat model; when empty it is looked up from the server.
 // knowledgeClient and embeddingModelID enable RAG retrieval; pass a nil client
 // to disable it. activeBases are initial active knowledge-base names (resolved
-// to indexes). systemPrompt and temperature seed the conversation.
-func NewLiveSession(baseURL, model string, knowledgeClient *knowledge.OpenSearchClient, embeddingModelID string, activeBases []string, systemPrompt string, temperature float64, verbose bool) (*LiveSession, error) {
+// to indexes). systemPrompt and temperature seed the conversation, and
+// settings its retrieval and prompts.
+func NewLiveSession(baseURL, model string, knowledgeClient *knowledge.OpenSearchClient, embeddingModelID string, activeBases []string, systemPrompt string, temperature float64, settings Settings, verbose bool) (*LiveSession, error) {
 	if model == "" {
 		var err error
 		model, err = FindModelName(baseURL)
@@ -86,6 +89,12 @@ func NewLiveSession(baseURL, model string, knowledgeClient *knowledge.OpenSearch
 			KnowledgeClient:  knowledgeClient,
 			EmbeddingModelID: embeddingModelID,
 			ActiveIndexes:    indexes,
+			Settings:         settings,
+			MultiQuery:       settings.multiQuery,
+			TopK:             settings.topK,
+			MinScore:         settings.minScore,
+			ContextWindow:    DetectContextWindow(context.Background(), baseURL, model),
+			ResponseCache:    settings.newResponseCache(verbose),
 		},
 		verbose:      verbose,
 		systemPrompt: systemPrompt,
@@ -101,13 +110,14 @@ func (ls *LiveSession) Model() string { return ls.params.Model }
 // record. It is called before the session's websocket is driven.
 func (ls *LiveSession) Restore(turns []chatstore.Turn, chatID string) {
 	ls.params.Messages = turnsToHistory(ls.systemPrompt, turns)
+	ls.session.exchanges = exchangesFromTurns(turns)
 	ls.chatID = chatID
 }
 
 // Turns returns the conversation so far as store turns (system prompt excluded),
 // ready to persist.
 func (ls *LiveSession) Turns() []chatstore.Turn {
-	return historyToTurns(ls.params.Messages)
+	return ls.session.annotateTurns(historyToTurns(ls.params.Messages))
 }
 
 // SetPromptRef records the provenance reference of the session's resolved system
@@ -148,31 +158,54 @@ func (ls *LiveSession) ActiveBases() []string {
 	return names
 }
 
+// LastSources returns the sources that grounded the last answer, in retrieval
+// order; nil before the first answer or when nothing was retrieved.
+func (ls *LiveSession) LastSources() []string {
+	if n := len(ls.session.exchanges); n > 0 {
+		return ls.session.exchanges[n-1].sources
+	}
+	return nil
+}
+
 // Prompt runs one RAG turn for text, streaming output through emit, and appends
 // the user prompt and assistant reply to the session history so the next turn
 // continues the conversation. It is the presentation-free counterpart of the
 // REPL's handlePrompt: the same rewrite → retrieve → augment → stream pipeline.
 // Retrieval augmentation is applied only when a knowledge client and at least
 // one active base are present; with no active bases the prompt is answered
-// without retrieval.
+// without retrieval. A text starting with /nocache bypasses the response cache.
 func (ls *LiveSession) Prompt(ctx context.Context, text string, emit StreamFunc) error {
+	text, noCache := cutNoCache(text)
 	hasRAG := ls.session.KnowledgeClient != nil && len(ls.session.ActiveIndexes) > 0
 
+	asked := time.Now()
 	lexicalQuery := text
 	ragContext := ""
+	var hits []knowledge.SearchHit
 	if hasRAG {
 		lexicalQuery = rewriteSearchQuery(ls.client, ls.params.Model, ls.params.Messages, text, ls.verbose)
-		ragContext = retrieveContext(ls.session, text, lexicalQuery, ls.verbose)
+		hits = retrieve(ls.client, ls.params.Model, ls.params.Messages, ls.session, text, lexicalQuery, ls.verbose)
+		ragContext, hits = ls.session.Settings.fitContext(ls.client, ls.params.Model, ls.session.ContextWindow, hits, ls.verbose)
 	}
 
-	llmPrompt := text
-	if ragContext != "" {
-		llmPrompt = buildRAGPrompt(ragContext, text)
-	} else if hasRAG {
-		// A base is active but retrieval returned nothing: inject an explicit
-		// empty-context note so the grounding rules apply and the model does
-		// not answer from parametric knowledge (matching the REPL).
-		llmPrompt = buildRAGPrompt("No relevant context was retrieved for this query.", text)
+	// As in the REPL, a base that is active but returned nothing gets an
+	// explicit empty-context note, so the model does not answer from
+	// parametric knowledge.
+	llmPrompt := ls.session.Settings.assemblePrompt(ragContext, text, hits, hasRAG, ls.verbose)
+
+	// Cached answers are emitted whole, as a single token, and only answers
+	// grounded on retrieval are cached, as in the REPL.
+	var cacheKey string
+	if ls.session.ResponseCache != nil && hasRAG {
+		cacheKey = responseCacheKey(ls.params, text, ls.session, ragContext)
+		if cached, ok := ls.session.ResponseCache.Get(cacheKey); ok && !noCache {
+			if err := emit(TokenAnswer, cached.Answer); err != nil {
+				return err
+			}
+			ls.params.Messages = append(ls.params.Messages, openai.UserMessage(text), openai.AssistantMessage(cached.Answer))
+			ls.session.recordExchange(asked, cached.Sources)
+			return nil
+		}
 	}
 
 	// Send the augmented prompt to the API but keep only the original prompt in
@@ -194,6 +227,11 @@ func (ls *LiveSession) Prompt(ctx context.Context, text string, emit StreamFunc)
 	if appendParam != nil {
 		ls.params.Messages = append(ls.params.Messages, *appendParam)
 	}
+	ls.session.recordExchange(asked, rag.Sources(hits))
+	if cacheKey != "" && appendParam != nil {
+		// A failed write only costs the next identical prompt a model call.
+		_ = ls.session.ResponseCache.Put(cacheKey, cachedResponse(appendParam, rag.Sources(hits)))
+	}
 	return nil
 }
 
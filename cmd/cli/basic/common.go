@@ -2,12 +2,15 @@ package basic
 
 import (
 	"fmt"
+	"log"
 	"net/url"
 	"os"
 
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
+	"github.com/jpnorenam/rag-snap/cmd/cli/basic/chat"
 	"github.com/jpnorenam/rag-snap/cmd/cli/common"
 	"github.com/jpnorenam/rag-snap/cmd/cli/config"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/processing"
 	"github.com/jpnorenam/rag-snap/pkg/storage"
 	"github.com/spf13/cobra"
 )
@@ -35,6 +38,10 @@ const (
 	confTikaHttpPort = "tika.http.port"
 	confTikaHttpPath = "tika.http.path"
 	confTikaHttpTLS  = "tika.http.tls"
+
+	confKnowledgeReadOnly = "knowledge.read-only"
+
+	confKnowledgeStorageWarnAt = "knowledge.storage.warn-at"
 )
 
 func Group(title string) *cobra.Group {
@@ -155,48 +162,127 @@ func buildKapaClient(ctx *common.Context) *knowledge.KapaClient {
 	return nil
 }
 
-func serverApiUrls(ctx *common.Context) (map[string]string, error) {
-	openAiHost, err := getConfigString(ctx, confOpenAiHttpHost)
+// openSearchClusterURL builds the OpenSearch URL from knowledge.http.hosts when
+// it lists the cluster's nodes, otherwise from knowledge.http.host and port.
+func openSearchClusterURL(ctx *common.Context) (string, error) {
+	secure := getConfigBool(ctx, confOpenSearchHttpTLS, true)
+	if hosts, _ := config.GetString(ctx.Config, knowledge.ConfHTTPHosts); hosts != "" {
+		port, _ := config.GetString(ctx.Config, confOpenSearchHttpPort)
+		return knowledge.ClusterURL(hosts, port, secure)
+	}
+	host, err := getConfigString(ctx, confOpenSearchHttpHost)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	openAiPort, err := getConfigString(ctx, confOpenAiHttpPort)
+	port, err := getConfigString(ctx, confOpenSearchHttpPort)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	openAiBasePath, err := getConfigString(ctx, confOpenAiHttpPath)
+	return buildServiceURL(host, port, "", secure), nil
+}
+
+// CleanStaleTempFiles removes temp files older than temp.max-age left behind by
+// runs that crashed before cleaning up. It is run at CLI startup; failures are
+// only reported in verbose mode, since they must not block the command.
+func CleanStaleTempFiles(ctx *common.Context) {
+	temp, err := processing.ParseTempSettings(config.Lookup(ctx.Config))
 	if err != nil {
-		return nil, err
+		if ctx.Verbose {
+			log.Printf("Skipping temp cleanup: %v", err)
+		}
+		return
 	}
-	openAiTLS := getConfigBool(ctx, confOpenAiHttpTLS, false)
+	result, err := temp.CleanStale()
+	if ctx.Verbose {
+		if err != nil {
+			log.Printf("Temp cleanup failed: %v", err)
+		} else if result.Removed > 0 {
+			log.Printf("Removed %d stale temp entries from %s", result.Removed, processing.TempDir())
+		}
+	}
+}
 
-	openSearchHost, err := getConfigString(ctx, confOpenSearchHttpHost)
+// configuredOpenAiURL builds the inference server URL from the chat.http.* keys.
+func configuredOpenAiURL(ctx *common.Context) (string, error) {
+	openAiHost, err := getConfigString(ctx, confOpenAiHttpHost)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	openSearchPort, err := getConfigString(ctx, confOpenSearchHttpPort)
+	openAiPort, err := getConfigString(ctx, confOpenAiHttpPort)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	openAiBasePath, err := getConfigString(ctx, confOpenAiHttpPath)
+	if err != nil {
+		return "", err
 	}
-	openSearchTLS := getConfigBool(ctx, confOpenSearchHttpTLS, true)
+	openAiTLS := getConfigBool(ctx, confOpenAiHttpTLS, false)
+	return buildServiceURL(openAiHost, openAiPort, openAiBasePath, openAiTLS), nil
+}
 
+// configuredTikaURL builds the Tika server URL from the tika.http.* keys.
+func configuredTikaURL(ctx *common.Context) (string, error) {
 	tikaHost, err := getConfigString(ctx, confTikaHttpHost)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	tikaPort, err := getConfigString(ctx, confTikaHttpPort)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	tikaBasePath, err := getConfigString(ctx, confTikaHttpPath)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	tikaTLS := getConfigBool(ctx, confTikaHttpTLS, false)
+	return buildServiceURL(tikaHost, tikaPort, tikaBasePath, tikaTLS), nil
+}
 
-	return map[string]string{
-		openAi:     buildServiceURL(openAiHost, openAiPort, openAiBasePath, openAiTLS),
-		opensearch: buildServiceURL(openSearchHost, openSearchPort, "", openSearchTLS),
-		tika:       buildServiceURL(tikaHost, tikaPort, tikaBasePath, tikaTLS),
-	}, nil
+// serverApiUrls resolves the service URLs, by service. An endpoint given with
+// --opensearch-url and the like is used as is, without reading its keys.
+func serverApiUrls(ctx *common.Context) (map[string]string, error) {
+	urls := make(map[string]string, 3)
+	for _, e := range endpointOverrides(ctx) {
+		if e.url != "" {
+			urls[e.service] = e.url
+			continue
+		}
+		u, err := e.configured(ctx)
+		if err != nil {
+			return nil, err
+		}
+		urls[e.service] = u
+	}
+	return urls, nil
+}
+
+// knowledgeSettings reads the knowledge.* and temp.* settings a command's
+// knowledge client runs with.
+func knowledgeSettings(ctx *common.Context) (knowledge.Settings, error) {
+	return knowledge.ParseSettings(config.Lookup(ctx.Config))
+}
+
+// newKnowledgeClient connects to the knowledge base at baseURL with the
+// configured knowledge settings.
+func newKnowledgeClient(ctx *common.Context, baseURL string) (*knowledge.OpenSearchClient, error) {
+	settings, err := knowledgeSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return knowledge.NewClient(baseURL, settings)
+}
+
+// chatSettings reads the chat.* settings a command's chat sessions run with.
+func chatSettings(ctx *common.Context) (chat.Settings, error) {
+	return chat.ParseSettings(config.Lookup(ctx.Config))
+}
+
+// newTikaClient returns the Tika client at tikaURL, with the configured tika.*
+// settings.
+func newTikaClient(ctx *common.Context, tikaURL string) (*processing.TikaClient, error) {
+	settings, err := processing.ParseTikaSettings(config.Lookup(ctx.Config))
+	if err != nil {
+		return nil, err
+	}
+	return processing.NewTikaClient(tikaURL, settings)
 }
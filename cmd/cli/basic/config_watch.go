@@ -0,0 +1,62 @@
+package basic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/cmd/cli/config"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/storage"
+)
+
+// ConfigWatchActions are what config watch runs when the keys they depend on
+// change.
+func ConfigWatchActions(ctx *common.Context) []config.WatchAction {
+	return []config.WatchAction{
+		{
+			// Without this, changing a model ID by hand leaves the pipelines
+			// embedding and reranking with the old model until init runs again.
+			Name: "pipeline update",
+			Keys: []string{knowledge.ConfEmbeddingModelID, knowledge.ConfRerankModelID},
+			Run: func(c context.Context, _ []string) error {
+				apiUrls, err := serverApiUrls(ctx)
+				if err != nil {
+					return fmt.Errorf("getting server API URLs: %w", err)
+				}
+				// Never wait for a starting server: a hook running this holds
+				// up the snap set that triggered it.
+				settings, err := knowledgeSettings(ctx)
+				if err != nil {
+					return err
+				}
+				checkCtx, cancel := context.WithTimeout(c, readyCheckTimeout)
+				client, err := knowledge.NewClientNoWait(checkCtx, apiUrls[opensearch], settings)
+				cancel()
+				if err != nil {
+					return err
+				}
+				embedding, _ := getConfigString(ctx, knowledge.ConfEmbeddingModelID)
+				rerank, _ := getConfigString(ctx, knowledge.ConfRerankModelID)
+				return client.RewirePipelines(c, embedding, rerank)
+			},
+		},
+		readinessResetAction(ctx, storage.ServiceOpenSearch, "knowledge.http.*"),
+		readinessResetAction(ctx, storage.ServiceTika, "tika.http.*"),
+		readinessResetAction(ctx, storage.ServiceOpenAI, "chat.http.*"),
+	}
+}
+
+// readinessResetAction forgets that service was ready when the keys locating
+// it change, so the next command checks the new endpoint instead of trusting a
+// record made for the old one.
+func readinessResetAction(ctx *common.Context, service, keys string) config.WatchAction {
+	return config.WatchAction{
+		Name: service + " readiness reset",
+		Keys: []string{keys},
+		Run: func(context.Context, []string) error {
+			markServiceNotReady(ctx, service)
+			return nil
+		},
+	}
+}
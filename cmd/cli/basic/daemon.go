@@ -14,7 +14,9 @@ import (
 // clients directly when it returns nil. Detection is skipped in --debug mode,
 // where the file-based config implies offline/inspection use.
 func daemonClient(ctx *common.Context) *apiclient.Client {
-	if ctx.Debug {
+	// Overridden endpoints are the CLI's to reach: the daemon talks to the
+	// configured ones.
+	if ctx.Debug || ctx.OverridesEndpoints() {
 		return nil
 	}
 	return apiclient.Detect()
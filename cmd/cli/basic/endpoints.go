@@ -0,0 +1,66 @@
+package basic
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+
+	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/spf13/cobra"
+)
+
+// Environment variables that stand in for the endpoint override flags.
+const (
+	envOpenSearchURL = "RAG_OPENSEARCH_URL"
+	envTikaURL       = "RAG_TIKA_URL"
+	envOpenAIURL     = "RAG_OPENAI_URL"
+)
+
+// endpointOverride is a service endpoint a flag can override for one
+// invocation, and how it is resolved from the config otherwise.
+type endpointOverride struct {
+	service    string // key in the serverApiUrls map
+	flag       string
+	url        string
+	configured func(*common.Context) (string, error)
+}
+
+// endpointOverrides lists the services whose endpoint can be overridden,
+// with the overrides ctx holds.
+func endpointOverrides(ctx *common.Context) []endpointOverride {
+	return []endpointOverride{
+		{service: openAi, flag: "openai-url", url: ctx.OpenAIURL, configured: configuredOpenAiURL},
+		{service: opensearch, flag: "opensearch-url", url: ctx.OpenSearchURL, configured: openSearchClusterURL},
+		{service: tika, flag: "tika-url", url: ctx.TikaURL, configured: configuredTikaURL},
+	}
+}
+
+// AddEndpointFlags adds the --opensearch-url, --tika-url, and --openai-url
+// persistent flags, which point one invocation at services other than the
+// configured ones, e.g. to debug against services outside the snap. Each
+// defaults to its environment variable.
+func AddEndpointFlags(cobraCmd *cobra.Command, ctx *common.Context) {
+	flags := cobraCmd.PersistentFlags()
+	flags.StringVar(&ctx.OpenSearchURL, "opensearch-url", os.Getenv(envOpenSearchURL), "Use this OpenSearch URL instead of the configured one (or set "+envOpenSearchURL+")")
+	flags.StringVar(&ctx.TikaURL, "tika-url", os.Getenv(envTikaURL), "Use this Tika URL instead of the configured one (or set "+envTikaURL+")")
+	flags.StringVar(&ctx.OpenAIURL, "openai-url", os.Getenv(envOpenAIURL), "Use this OpenAI-compatible inference URL instead of the configured one (or set "+envOpenAIURL+")")
+}
+
+// ValidateEndpointOverrides checks that the endpoint overrides are http or
+// https URLs, and reports the ones in use in verbose mode.
+func ValidateEndpointOverrides(ctx *common.Context) error {
+	for _, e := range endpointOverrides(ctx) {
+		if e.url == "" {
+			continue
+		}
+		u, err := url.Parse(e.url)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid --%s %q: expected an http:// or https:// URL", e.flag, e.url)
+		}
+		if ctx.Verbose {
+			log.Printf("Using %s from --%s instead of the configured endpoint", e.url, e.flag)
+		}
+	}
+	return nil
+}
@@ -2,16 +2,23 @@ package basic
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"path/filepath"
+	"os"
+	"os/signal"
+	"regexp"
+	"slices"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/charmbracelet/huh"
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/processing"
 	"github.com/jpnorenam/rag-snap/cmd/cli/common"
 	"github.com/jpnorenam/rag-snap/internal/apiclient"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/processing"
+	"github.com/jpnorenam/rag-snap/pkg/rag"
+	"github.com/jpnorenam/rag-snap/pkg/storage"
 	"github.com/spf13/cobra"
 )
 
@@ -36,14 +43,53 @@ func (cmd *knowledgeCommand) embeddingModelID() (string, error) {
 	return modelID, nil
 }
 
+// readyCheckTimeout bounds the single connection attempt made to a service
+// the readiness registry says is up.
+const readyCheckTimeout = 5 * time.Second
+
 // opensearchClient creates a new OpenSearch client for the configured cluster.
+// When the readiness registry says the cluster is up, it connects once rather
+// than waiting for it to start; a cluster that does not answer after all is
+// waited for as usual.
 func (cmd *knowledgeCommand) opensearchClient() (*knowledge.OpenSearchClient, error) {
 	url, err := cmd.opensearchURL()
 	if err != nil {
 		return nil, err
 	}
-	fmt.Printf("Using opensearch cluster at %v\n", url)
-	return knowledge.NewClient(url)
+	common.Infof("Using opensearch cluster at %v\n", url)
+	settings, err := knowledgeSettings(cmd.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	if serviceReady(cmd.Context, storage.ServiceOpenSearch) {
+		ctx, cancel := context.WithTimeout(context.Background(), readyCheckTimeout)
+		client, err := knowledge.NewClientNoWait(ctx, url, settings)
+		cancel()
+		if err == nil {
+			return client, nil
+		}
+		if cmd.Verbose {
+			fmt.Printf("Opensearch is recorded as ready but did not answer (%v); waiting for it\n", err)
+		}
+	}
+
+	client, err := knowledge.NewClient(url, settings)
+	if err != nil {
+		markServiceNotReady(cmd.Context, storage.ServiceOpenSearch)
+		return nil, err
+	}
+	markServiceReady(cmd.Context, storage.ServiceOpenSearch)
+	return client, nil
+}
+
+// knowledgeBaseError reports a failed lookup of a knowledge base by name,
+// keeping the typed error it wraps for the exit code and hint.
+func knowledgeBaseError(name string, err error) error {
+	if errors.Is(err, knowledge.ErrIndexNotFound) {
+		return fmt.Errorf("%w: '%s'", knowledge.ErrIndexNotFound, name)
+	}
+	return fmt.Errorf("knowledge base '%s': %w", name, err)
 }
 
 func KnowledgeCommand(ctx *common.Context) *cobra.Command {
@@ -66,14 +112,29 @@ func KnowledgeCommand(ctx *common.Context) *cobra.Command {
 		cmd.listCommand(),
 		cmd.createCommand(),
 		cmd.labelCommand(),
+		cmd.policyCommand(),
+		cmd.pipelineCommand(),
+		cmd.reindexCommand(),
+		cmd.tuneCommand(),
 		cmd.ingestCommand(),
+		cmd.refreshCommand(),
+		cmd.retryCommand(),
+		cmd.queueCommand(),
+		cmd.workerCommand(),
 		cmd.searchCommand(),
+		cmd.compareCommand(),
+		cmd.savedSearchCommand(),
+		cmd.findCommand(),
+		cmd.askCommand(),
 		cmd.forgetCommand(),
 		cmd.metadataCommand(),
+		cmd.historyCommand(),
 		cmd.deleteCommand(),
+		cmd.resetCommand(),
 		cmd.exportCommand(),
 		cmd.importCommand(),
 	)
+	guardReadOnly(ctx, cobraCmd, "")
 
 	return cobraCmd
 }
@@ -84,15 +145,41 @@ func (cmd *knowledgeCommand) initCommand() *cobra.Command {
 	// --sentence-transformer/--cross-encoder flags that were printed and then
 	// ignored; selecting a model is only safe once switching one prunes the
 	// previous deployment, so the flags are gone rather than misleading.
+	// --model-file and --rerank-model-file do not select a model: they supply
+	// the same fixed models from local artifacts, for air-gapped clusters.
+	var (
+		modelFile         string
+		modelSHA256       string
+		rerankModelFile   string
+		rerankModelSHA256 string
+	)
+
 	cobraCmd := &cobra.Command{
 		Use:   "init",
 		Short: "Initialize the knowledge base pipelines and index template",
 		Long: "Create and initialize an OpenSearch pipelines and index template for storing knowledge base documents.\n" +
 			"Re-running is safe: existing models are reused and the pipelines are rewired to them.\n" +
-			"Use 'knowledge models' to see what is registered and deployed.",
+			"Use 'knowledge models' to see what is registered and deployed.\n" +
+			"On clusters without internet access, pass the models' TorchScript zips with --model-file and\n" +
+			"--rerank-model-file: they are uploaded from this machine instead of downloaded from Hugging Face.",
 		Args: cobra.NoArgs,
 		RunE: func(_ *cobra.Command, _ []string) error {
+			var local knowledge.LocalModels
+			if modelFile != "" {
+				local.Embedding = &knowledge.LocalModel{Path: modelFile, SHA256: modelSHA256}
+			} else if modelSHA256 != "" {
+				return fmt.Errorf("--model-sha256 requires --model-file")
+			}
+			if rerankModelFile != "" {
+				local.Rerank = &knowledge.LocalModel{Path: rerankModelFile, SHA256: rerankModelSHA256}
+			} else if rerankModelSHA256 != "" {
+				return fmt.Errorf("--rerank-model-sha256 requires --rerank-model-file")
+			}
+
 			if dc := daemonClient(cmd.Context); dc != nil {
+				if local.Embedding != nil || local.Rerank != nil {
+					return fmt.Errorf("--model-file and --rerank-model-file are not supported over the ragd daemon yet; stop the daemon to initialize from local models")
+				}
 				opURL, err := dc.EngineInit(context.Background())
 				if err != nil {
 					return err
@@ -120,10 +207,16 @@ func (cmd *knowledgeCommand) initCommand() *cobra.Command {
 				},
 			}
 
+			client.UseLocalModels(local)
 			return client.InitPipelines(context.Background(), hooks)
 		},
 	}
 
+	cobraCmd.Flags().StringVar(&modelFile, "model-file", "", "TorchScript zip of the embedding model to upload instead of downloading it")
+	cobraCmd.Flags().StringVar(&modelSHA256, "model-sha256", "", "Expected SHA-256 of --model-file")
+	cobraCmd.Flags().StringVar(&rerankModelFile, "rerank-model-file", "", "TorchScript zip of the rerank model to upload instead of downloading it")
+	cobraCmd.Flags().StringVar(&rerankModelSHA256, "rerank-model-sha256", "", "Expected SHA-256 of --rerank-model-file")
+
 	return cobraCmd
 }
 
@@ -188,18 +281,60 @@ func (cmd *knowledgeCommand) printEngineInitResult(op *apiclient.Operation) {
 
 func (cmd *knowledgeCommand) listCommand() *cobra.Command {
 	var showSources bool
+	var watch bool
+	var interval time.Duration
+	var (
+		statuses []string
+		since    string
+		sortBy   string
+		limit    int
+		wide     bool
+	)
 
 	cobraCmd := &cobra.Command{
 		Use:   "list [index_name]",
 		Short: "List knowledge base indexes or sources",
-		Long:  "List all OpenSearch indexes matching the knowledge base pattern.\nUse --sources to list ingested source documents instead.",
-		Args:  cobra.MaximumNArgs(1),
-		RunE: func(_ *cobra.Command, args []string) error {
+		Long: "List all OpenSearch indexes matching the knowledge base pattern.\nUse --sources to list ingested source documents instead.\n" +
+			"Narrow the sources down with --status and --since, order them with --sort, and cap\n" +
+			"them with --limit. Long source IDs, bases, and labels are cut to fit the table\n" +
+			"unless --wide is set.\n" +
+			"Add --watch to refresh the source table until Ctrl-C, e.g. while a batch ingest runs.\n" +
+			"With knowledge.storage.warn-at set, bases past their storage budget are flagged\n" +
+			"with suggestions to free space.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
 			ctx := context.Background()
 
+			if watch && !showSources {
+				return fmt.Errorf("--watch requires --sources")
+			}
+			for _, name := range []string{"status", "since", "sort", "limit", "wide"} {
+				if c.Flags().Changed(name) && !showSources {
+					return fmt.Errorf("--%s requires --sources", name)
+				}
+			}
+			query := knowledge.SourceQuery{Statuses: statuses, Sort: sortBy, Limit: limit}
+			if since != "" {
+				t, err := knowledge.ParseSince(since, time.Now().UTC())
+				if err != nil {
+					return err
+				}
+				query.Since = t
+			}
+			if err := query.Validate(); err != nil {
+				return err
+			}
+			base := ""
+			if len(args) > 0 {
+				base = args[0]
+			}
+
 			if dc := daemonClient(cmd.Context); dc != nil {
+				if watch {
+					return watchSources(fetchSourceRowsAPI(dc, base, query), interval, wide)
+				}
 				if showSources {
-					return cmd.listSourcesAPI(ctx, dc, args)
+					return listSourceRows(ctx, fetchSourceRowsAPI(dc, base, query), wide)
 				}
 				return cmd.listIndexesAPI(ctx, dc)
 			}
@@ -209,20 +344,31 @@ func (cmd *knowledgeCommand) listCommand() *cobra.Command {
 				return err
 			}
 
+			if watch {
+				return watchSources(fetchSourceRows(client, base, query), interval, wide)
+			}
 			if showSources {
-				return cmd.listSources(ctx, client, args)
+				return listSourceRows(ctx, fetchSourceRows(client, base, query), wide)
 			}
 			return cmd.listIndexes(ctx, client)
 		},
 	}
 
 	cobraCmd.Flags().BoolVarP(&showSources, "sources", "s", false, "List ingested source documents instead of indexes")
+	cobraCmd.Flags().BoolVarP(&watch, "watch", "w", false, "With --sources, refresh the table until interrupted, with status colors and throughput")
+	cobraCmd.Flags().DurationVar(&interval, "interval", defaultWatchInterval, "Refresh interval for --watch")
+	cobraCmd.Flags().StringSliceVar(&statuses, "status", nil, "With --sources, only list sources in these states: processing, completed, failed (comma-separated)")
+	cobraCmd.Flags().StringVar(&since, "since", "", "With --sources, only list sources ingested since a duration ago (36h, 7d) or a UTC date or time")
+	cobraCmd.Flags().StringVar(&sortBy, "sort", "", "With --sources, order by ingested_at (newest first), chunks (most first), or name")
+	cobraCmd.Flags().IntVarP(&limit, "limit", "n", 0, "With --sources, list at most this many sources (default 1000)")
+	cobraCmd.Flags().BoolVar(&wide, "wide", false, "With --sources, print long source IDs, bases, and labels in full")
 
 	return cobraCmd
 }
 
 func (cmd *knowledgeCommand) createCommand() *cobra.Command {
-	var labelFlag string
+	var labelFlag, presetFlag string
+	var retainFlag, maxSizeFlag string
 
 	cobraCmd := &cobra.Command{
 		Use:   "create <knowledge_base_name>",
@@ -231,18 +377,40 @@ func (cmd *knowledgeCommand) createCommand() *cobra.Command {
 			"Use --label to set the base's default knowledge label; sources ingested\n" +
 			"without an explicit label inherit it. Without --label, the default follows\n" +
 			"the naming convention ('upstream' for names containing \"upstream\", else\n" +
-			"'canonical'). Define what labels mean to the LLM in your prompt variants.",
+			"'canonical'). Define what labels mean to the LLM in your prompt variants.\n\n" +
+			"Use --preset to tune the base for its content: chunking, text analysis,\n" +
+			"and indexed metadata are chosen at creation and applied to every ingest.\n" +
+			presetHelp(),
 		Args: cobra.ExactArgs(1),
 		RunE: func(_ *cobra.Command, args []string) error {
 			knowledgeBaseName := args[0]
 
+			var preset *knowledge.Preset
+			if presetFlag != "" {
+				p, err := knowledge.LookupPreset(presetFlag)
+				if err != nil {
+					return err
+				}
+				preset = &p
+			}
+
 			if labelFlag != "" {
 				if err := knowledge.ValidateLabel(labelFlag); err != nil {
 					return err
 				}
 			}
+			policy, err := knowledge.ParseRetentionPolicy(retainFlag, maxSizeFlag)
+			if err != nil {
+				return err
+			}
 
 			if dc := daemonClient(cmd.Context); dc != nil {
+				if !policy.IsZero() {
+					return fmt.Errorf("--retain and --max-size are not supported over the ragd daemon yet; stop the daemon to create the base directly")
+				}
+				if preset != nil {
+					return fmt.Errorf("--preset is not supported over the ragd daemon yet; stop the daemon to create the base directly")
+				}
 				if _, err := dc.CreateKnowledge(context.Background(), knowledgeBaseName, labelFlag); err != nil {
 					return err
 				}
@@ -258,7 +426,12 @@ func (cmd *knowledgeCommand) createCommand() *cobra.Command {
 			}
 
 			ctx := context.Background()
-			if err := client.CreateIndex(ctx, indexName); err != nil {
+			if preset != nil {
+				err = client.CreateIndexWithPreset(ctx, indexName, *preset)
+			} else {
+				err = client.CreateIndex(ctx, indexName)
+			}
+			if err != nil {
 				return fmt.Errorf("creating index: %w", err)
 			}
 			if labelFlag != "" {
@@ -266,17 +439,41 @@ func (cmd *knowledgeCommand) createCommand() *cobra.Command {
 					return fmt.Errorf("setting default label: %w", err)
 				}
 			}
+			if !policy.IsZero() {
+				if err := client.SetRetentionPolicy(ctx, indexName, policy); err != nil {
+					return fmt.Errorf("setting retention policy: %w", err)
+				}
+			}
 
 			fmt.Printf("Knowledge base '%s' created successfully.\n", knowledgeBaseName)
+			if preset != nil {
+				fmt.Printf("Preset: %s (%s chunks of up to %d characters).\n", preset.Name, preset.Chunking.Strategy, preset.Chunking.Size)
+			}
+			if !policy.IsZero() {
+				fmt.Printf("Retention policy: %s. Sources past a limit are forgotten, oldest first.\n", policy)
+			}
 			return nil
 		},
 	}
 
 	cobraCmd.Flags().StringVarP(&labelFlag, "label", "l", "", "Default knowledge label for sources ingested into this base")
+	cobraCmd.Flags().StringVar(&presetFlag, "preset", "", "Content preset: docs, code, or logs")
+	cobraCmd.Flags().StringVar(&retainFlag, "retain", "", "Forget sources not ingested or updated for this long (e.g. 90d, 12h)")
+	cobraCmd.Flags().StringVar(&maxSizeFlag, "max-size", "", "Forget the oldest sources while the primary store exceeds this size (e.g. 500m, 5g)")
 
 	return cobraCmd
 }
 
+// presetHelp lists the knowledge base presets for the create command's help.
+func presetHelp() string {
+	var b strings.Builder
+	b.WriteString("\nPresets:\n")
+	for _, p := range knowledge.Presets {
+		fmt.Fprintf(&b, "  %-6s %s\n", p.Name, p.Description)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
 func (cmd *knowledgeCommand) labelCommand() *cobra.Command {
 	var applyToExisting bool
 
@@ -378,18 +575,79 @@ func (cmd *knowledgeCommand) ingestCommand() *cobra.Command {
 	var batchFlag string
 	var formatFlag string
 	var labelFlag string
+	var metadataFlags []string
 	var forceFlag bool
+	var waitFlag bool
+	var createMissingFlag bool
+	var dryRunFlag bool
+	var includeFlag string
+	var excludeFlag string
+	var concurrencyFlag int
+	var rateFlag float64
+	var keepVersionsFlag int
 
 	cobraCmd := &cobra.Command{
-		Use:   "ingest [<knowledge_base_name> <source_id>]",
+		Use:   "ingest [[<knowledge_base_name>] <source_id>]",
 		Short: "Ingest a document into the knowledge base",
 		Long: "Ingest a document into the knowledge base index with the given source ID.\n" +
 			"Provide the document via --file (local path) or --url (remote URL).\n" +
-			"Use --batch <config.yaml> to ingest multiple documents from a YAML file.\n" +
+			"When only the source ID is given, you are asked to pick the knowledge base\n" +
+			"(the default base is used when not running in a terminal).\n" +
+			"Use --batch <config.yaml> to ingest multiple documents from a YAML file;\n" +
+			"every job is validated before any is run (--dry-run to only validate).\n" +
 			"Use --format rfp to ingest a CSV of previous RFP question/answer pairs\n" +
-			"(columns: question, answer, source), one chunk per row.",
+			"(columns: question, answer, source), one chunk per row.\n" +
+			"CSV, JSON, and YAML files are chunked along their structure (row groups\n" +
+			"with column names, top-level keys, OpenAPI endpoints and schemas); use\n" +
+			"--format to override detection, or --format tika to extract them as text.\n" +
+			"Use --metadata key=value (repeatable) to tag the source for filtered search.\n" +
+			"Use --wait to return only once the ingested chunks are searchable.\n" +
+			"With --force, --keep-versions N keeps the chunks of the version being\n" +
+			"replaced under <source_id>@v<version>, up to N replaced versions; they\n" +
+			"are only searched with knowledge search --versions; see `knowledge history`.\n" +
+			"When --url points to a sitemap (an .xml file), each page it lists is\n" +
+			"ingested as a separate source named <source_id>/<page path>; narrow the\n" +
+			"pages with --include/--exclude regular expressions, and use --dry-run to\n" +
+			"only list them.",
 		Args: cobra.RangeArgs(0, 2),
 		RunE: func(_ *cobra.Command, args []string) error {
+			tags, err := knowledge.ParseTags(metadataFlags)
+			if err != nil {
+				return err
+			}
+			if len(tags) > 0 && batchFlag != "" {
+				return fmt.Errorf("--metadata is not allowed with --batch; set per-job metadata in the YAML file")
+			}
+			sitemap := urlFlag != "" && processing.IsSitemapURL(urlFlag)
+			if createMissingFlag && batchFlag == "" {
+				return fmt.Errorf("--create-missing requires --batch")
+			}
+			if dryRunFlag && batchFlag == "" && !sitemap {
+				return fmt.Errorf("--dry-run requires --batch or a sitemap --url")
+			}
+			if (includeFlag != "" || excludeFlag != "") && !sitemap {
+				return fmt.Errorf("--include and --exclude require a sitemap --url")
+			}
+			if keepVersionsFlag < 0 {
+				return fmt.Errorf("--keep-versions must not be negative")
+			}
+			if keepVersionsFlag > 0 {
+				switch {
+				case !forceFlag:
+					return fmt.Errorf("--keep-versions requires --force")
+				case batchFlag != "" || sitemap:
+					return fmt.Errorf("--keep-versions is not allowed with --batch or a sitemap --url")
+				case daemonClient(cmd.Context) != nil:
+					return fmt.Errorf("--keep-versions is not supported over the ragd daemon yet; run without the daemon to keep old versions")
+				}
+			}
+
+			// Ctrl-C cancels the in-flight request instead of killing the
+			// process, so deferred temp-file cleanup runs and a half-indexed
+			// source is removed rather than left in the processing state.
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
 			if labelFlag != "" {
 				if err := knowledge.ValidateLabel(labelFlag); err != nil {
 					return err
@@ -412,15 +670,26 @@ func (cmd *knowledgeCommand) ingestCommand() *cobra.Command {
 				if err != nil {
 					return err
 				}
-				return knowledge.ProcessBatch(context.Background(), client, apiUrls[tika], batchFlag, forceFlag)
+				if waitFlag {
+					_ = client.SetBulkRefresh(knowledge.RefreshWaitFor)
+				}
+				lowerIngestPriority(client)
+				tikaClient, err := newTikaClient(cmd.Context, apiUrls[tika])
+				if err != nil {
+					return err
+				}
+				return knowledge.ProcessBatch(ctx, client, tikaClient, batchFlag, knowledge.BatchOptions{
+					Force:         forceFlag,
+					CreateMissing: createMissingFlag,
+					DryRun:        dryRunFlag,
+				})
 			}
 
-			// Single-document mode: require exactly 2 positional args.
-			if len(args) != 2 {
-				return fmt.Errorf("requires <knowledge_base_name> and <source_id>, or use --batch <config.yaml>")
+			// Single-document mode: require the source ID, and the knowledge
+			// base unless it can be picked.
+			if len(args) == 0 {
+				return fmt.Errorf("requires [<knowledge_base_name>] <source_id>, or use --batch <config.yaml>")
 			}
-			knowledgeBaseName := args[0]
-			sourceID := args[1]
 
 			// Validate mutual exclusivity
 			if fileFlag == "" && urlFlag == "" {
@@ -430,10 +699,52 @@ func (cmd *knowledgeCommand) ingestCommand() *cobra.Command {
 				return fmt.Errorf("--file and --url are mutually exclusive")
 			}
 
+			sourceID := args[len(args)-1]
+			var knowledgeBaseName string
+			if len(args) == 2 {
+				knowledgeBaseName = args[0]
+			} else {
+				picked, err := cmd.pickKnowledgeBases("Select the knowledge base to ingest into", false)
+				if err != nil {
+					return err
+				}
+				knowledgeBaseName = picked[0]
+			}
+
+			if sitemap {
+				if daemonClient(cmd.Context) != nil {
+					return fmt.Errorf("sitemap ingestion is not supported over the ragd daemon yet")
+				}
+				if formatFlag != "" {
+					return fmt.Errorf("--format is not allowed with a sitemap --url")
+				}
+				return cmd.ingestSitemap(ctx, urlFlag, includeFlag, excludeFlag, dryRunFlag, waitFlag, knowledge.SitemapOptions{
+					SourcePrefix: sourceID,
+					TargetIndex:  knowledge.FullIndexName(knowledgeBaseName),
+					Label:        labelFlag,
+					Tags:         tags,
+					Force:        forceFlag,
+					Concurrency:  concurrencyFlag,
+					Rate:         rateFlag,
+				})
+			}
+
 			// Daemon mode: hand the source to ragd, which crawls/extracts and
 			// indexes server-side as an async operation. The file upload is
 			// streamed over the socket; URL crawling happens on the daemon.
 			if dc := daemonClient(cmd.Context); dc != nil {
+				// The daemon owns the ingest; restore default Ctrl-C handling
+				// so interrupting only stops waiting on the operation.
+				stop()
+				if len(tags) > 0 {
+					return fmt.Errorf("--metadata is not supported over the ragd daemon yet; run without the daemon to tag sources")
+				}
+				if formatFlag != "" {
+					return fmt.Errorf("--format is not supported over the ragd daemon yet; structured files are detected by extension")
+				}
+				if waitFlag {
+					return fmt.Errorf("--wait is not supported over the ragd daemon yet; set knowledge.bulk.refresh=wait_for instead")
+				}
 				var opURL string
 				var err error
 				if urlFlag != "" {
@@ -451,29 +762,15 @@ func (cmd *knowledgeCommand) ingestCommand() *cobra.Command {
 				return nil
 			}
 
-			if formatFlag != "" && formatFlag != "rfp" {
-				return fmt.Errorf("unsupported format %q (supported: rfp)", formatFlag)
-			}
-			if formatFlag == "rfp" && urlFlag != "" {
-				return fmt.Errorf("--format rfp requires --file, not --url")
-			}
-
-			// Resolve the file path
-			var filePath string
-			var metadataPath string // stored in SourceMetadata.FilePath
-			var webMeta *processing.WebMetadata
-			if urlFlag != "" {
-				crawled, wm, cleanup, err := processing.CrawlURL(urlFlag)
-				if err != nil {
-					return fmt.Errorf("Crawling URL: %w", err)
+			switch formatFlag {
+			case "", knowledge.FormatRFP, processing.FormatTika:
+			default:
+				if !processing.IsStructuredFormat(formatFlag) {
+					return fmt.Errorf("unsupported format %q (supported: rfp, csv, json, yaml, openapi, tika)", formatFlag)
 				}
-				defer cleanup()
-				filePath = crawled
-				metadataPath = urlFlag
-				webMeta = wm
-			} else {
-				filePath = fileFlag
-				metadataPath = fileFlag
+			}
+			if (formatFlag == knowledge.FormatRFP || processing.IsStructuredFormat(formatFlag)) && urlFlag != "" {
+				return fmt.Errorf("--format %s requires --file, not --url", formatFlag)
 			}
 
 			indexName := knowledge.FullIndexName(knowledgeBaseName)
@@ -482,107 +779,62 @@ func (cmd *knowledgeCommand) ingestCommand() *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("getting server API URLs: %w", err)
 			}
-
-			var result *processing.IngestResult
-			if formatFlag == "rfp" {
-				result, err = processing.IngestRFP(filePath, sourceID)
-			} else {
-				result, err = processing.Ingest(apiUrls[tika], filePath, sourceID)
-			}
+			client, err := newKnowledgeClient(cmd.Context, apiUrls[opensearch])
 			if err != nil {
-				return fmt.Errorf("ingesting document: %w", err)
+				return err
 			}
-
-			client, err := knowledge.NewClient(apiUrls[opensearch])
+			if waitFlag {
+				_ = client.SetBulkRefresh(knowledge.RefreshWaitFor)
+			}
+			lowerIngestPriority(client)
+			tikaClient, err := newTikaClient(cmd.Context, apiUrls[tika])
 			if err != nil {
 				return err
 			}
-
-			ctx := context.Background()
-
-			// Resolve the source's label: explicit > base default > convention.
-			label := labelFlag
-			if label == "" {
-				if label, _, err = client.GetDefaultLabel(ctx, indexName); err != nil {
-					return fmt.Errorf("resolving base default label: %w", err)
-				}
-			}
-			// Older indexes lack the label keyword mapping; ensure it before the
-			// first labeled write so dynamic mapping cannot type the field wrong.
-			if err := client.EnsureLabelMapping(ctx, indexName); err != nil {
-				return fmt.Errorf("ensuring label mapping: %w", err)
+			if !forceFlag && client.SourceCompleted(ctx, sourceID) {
+				return fmt.Errorf("source '%s' is already ingested; use --force to re-ingest it", sourceID)
 			}
 
-			// Build source metadata with status=processing
-			now := time.Now().UTC().Format(knowledge.DateFormat)
-			chunkOverlap := processing.DefaultChunkOverlap
-			if formatFlag == "rfp" {
-				chunkOverlap = 0
-			}
-			meta := knowledge.SourceMetadata{
-				SourceID:      sourceID,
-				FileName:      filepath.Base(filePath),
-				FilePath:      metadataPath,
-				Checksum:      result.Checksum,
-				IndexName:     indexName,
-				ChunkCount:    len(result.Chunks),
-				ChunkSize:     processing.DefaultChunkSize,
-				ChunkOverlap:  chunkOverlap,
-				ContentLength: result.ContentLength,
-				Label:         label,
-				Status:        knowledge.StatusProcessing,
-				IngestedAt:    now,
-				UpdatedAt:     now,
-			}
-			if formatFlag == "rfp" {
-				meta.ContentType = "text/csv"
-			}
-			if result.TikaMetadata != nil {
-				meta.ContentType = result.TikaMetadata.ContentType
-				meta.Title = result.TikaMetadata.Title
-				meta.Author = result.TikaMetadata.Author
-				meta.Language = result.TikaMetadata.Language
+			opts := knowledge.IngestOptions{
+				SourceID:     sourceID,
+				TargetIndex:  indexName,
+				Label:        labelFlag,
+				Tags:         tags,
+				Force:        forceFlag,
+				Format:       formatFlag,
+				KeepVersions: keepVersionsFlag,
 			}
-			if webMeta != nil {
-				if meta.Title == "" {
-					meta.Title = webMeta.Title
-				}
-				if meta.Author == "" {
-					meta.Author = webMeta.Author
+			// Resolve the file path
+			if urlFlag != "" {
+				crawled, webMeta, cleanup, err := processing.CrawlURL(client.Settings().Temp, urlFlag)
+				if err != nil {
+					return fmt.Errorf("Crawling URL: %w", err)
 				}
+				defer cleanup()
+				opts.FilePath = crawled
+				opts.MetadataPath = urlFlag
+				opts.Title, opts.Author = webMeta.Title, webMeta.Author
+				opts.ETag, opts.LastModified = webMeta.ETag, webMeta.LastModified
+			} else {
+				opts.FilePath = fileFlag
 			}
 
-			// Write metadata BEFORE bulk indexing
-			if err := client.IndexSourceMetadata(ctx, meta); err != nil {
-				return fmt.Errorf("writing source metadata: %w", err)
-			}
-
-			// Convert chunks to documents and bulk index
-			docs := make([]knowledge.Document, len(result.Chunks))
-			for i, c := range result.Chunks {
-				docs[i] = knowledge.Document{
-					Content:   c.Content,
-					SourceID:  c.SourceID,
-					Label:     label,
-					CreatedAt: c.CreatedAt,
+			bulkResult, err := knowledge.NewIngestor(client, tikaClient).Ingest(ctx, opts)
+			if bulkResult != nil {
+				fmt.Printf("Ingested %d/%d chunks into index '%s'\n",
+					bulkResult.Indexed, bulkResult.Total, indexName)
+				if bulkResult.Reused > 0 {
+					fmt.Printf("  Reused embeddings for %d unchanged chunks\n", bulkResult.Reused)
+				}
+				if bulkResult.Duplicates > 0 {
+					fmt.Printf("  Skipped %d chunks other sources already put in the base\n", bulkResult.Duplicates)
 				}
 			}
-
-			bulkResult, err := client.BulkIndex(ctx, indexName, docs)
 			if err != nil {
-				_ = client.UpdateSourceStatus(ctx, sourceID, knowledge.StatusFailed)
-				return fmt.Errorf("indexing chunks: %w", err)
-			}
-
-			// Update metadata status to completed
-			if err := client.UpdateSourceStatus(ctx, sourceID, knowledge.StatusCompleted); err != nil {
-				return fmt.Errorf("updating source status: %w", err)
-			}
-
-			fmt.Printf("Ingested %d/%d chunks into index '%s'\n",
-				bulkResult.Indexed, bulkResult.Total, indexName)
-			if bulkResult.Errors > 0 {
-				fmt.Printf("  Errors: %d (%s)\n", bulkResult.Errors, bulkResult.FirstError)
+				if ctx.Err() != nil {
+					return fmt.Errorf("ingest interrupted; removed partial chunks for source '%s'", sourceID)
+				}
+				return err
 			}
 
 			return nil
@@ -592,44 +844,239 @@ func (cmd *knowledgeCommand) ingestCommand() *cobra.Command {
 	cobraCmd.Flags().StringVarP(&fileFlag, "file", "f", "", "Local file path to ingest")
 	cobraCmd.Flags().StringVarP(&urlFlag, "url", "u", "", "URL to download and ingest")
 	cobraCmd.Flags().StringVarP(&batchFlag, "batch", "B", "", "YAML batch config file — ingest multiple documents at once")
-	cobraCmd.Flags().StringVar(&formatFlag, "format", "", "Input format: 'rfp' for a CSV of question,answer,source rows (default: auto-detect via Tika)")
+	cobraCmd.Flags().StringVar(&formatFlag, "format", "", "Input format: rfp, csv, json, yaml, openapi, or tika (default: csv/json/yaml by extension, otherwise Tika)")
 	cobraCmd.Flags().StringVarP(&labelFlag, "label", "l", "", "Knowledge label for this source (default: the base's default label)")
+	cobraCmd.Flags().StringArrayVarP(&metadataFlags, "metadata", "m", nil, "User-defined key=value tag for this source (repeatable)")
 	cobraCmd.Flags().BoolVar(&forceFlag, "force", false, "Re-ingest sources even if already present in the knowledge base")
+	cobraCmd.Flags().BoolVar(&createMissingFlag, "create-missing", false, "With --batch, create target knowledge bases that do not exist yet")
+	cobraCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "With --batch, validate every job without ingesting anything; with a sitemap, list its pages")
+	cobraCmd.Flags().StringVar(&includeFlag, "include", "", "With a sitemap --url, only ingest pages whose URL matches this regular expression")
+	cobraCmd.Flags().StringVar(&excludeFlag, "exclude", "", "With a sitemap --url, skip pages whose URL matches this regular expression")
+	cobraCmd.Flags().IntVar(&concurrencyFlag, "concurrency", 4, "With a sitemap --url, how many pages to fetch at once")
+	cobraCmd.Flags().Float64Var(&rateFlag, "rate", 2, "With a sitemap --url, the most pages to request per second (0 for no limit)")
+	cobraCmd.Flags().BoolVar(&waitFlag, "wait", false, "Wait until the ingested chunks are searchable before returning (overrides knowledge.bulk.refresh)")
+	cobraCmd.Flags().IntVar(&keepVersionsFlag, "keep-versions", 0, "With --force, keep the chunks of up to this many replaced versions under versioned source IDs")
 
 	return cobraCmd
 }
 
+// ingestSitemap ingests the pages listed by the sitemap at sitemapURL that
+// pass the include/exclude filters, or only lists them for a dry run. With
+// wait, the pages are searchable as soon as it returns.
+func (cmd *knowledgeCommand) ingestSitemap(ctx context.Context, sitemapURL, include, exclude string, dryRun, wait bool, opts knowledge.SitemapOptions) error {
+	var includeRe, excludeRe *regexp.Regexp
+	var err error
+	if include != "" {
+		if includeRe, err = regexp.Compile(include); err != nil {
+			return fmt.Errorf("invalid --include: %w", err)
+		}
+	}
+	if exclude != "" {
+		if excludeRe, err = regexp.Compile(exclude); err != nil {
+			return fmt.Errorf("invalid --exclude: %w", err)
+		}
+	}
+	if opts.Concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+	if opts.Rate < 0 {
+		return fmt.Errorf("--rate must not be negative")
+	}
+
+	stopProgress := common.StartProgressSpinner("Reading sitemap")
+	all, err := processing.FetchSitemap(ctx, sitemapURL)
+	stopProgress()
+	if err != nil {
+		return err
+	}
+	pages := processing.FilterURLs(all, includeRe, excludeRe)
+	fmt.Printf("Sitemap lists %d pages, %d selected for ingestion\n", len(all), len(pages))
+	const shown = 10
+	for i, page := range pages {
+		if i == shown && !dryRun {
+			fmt.Printf("  … and %d more\n", len(pages)-shown)
+			break
+		}
+		fmt.Printf("  %s\n", page)
+	}
+	if dryRun || len(pages) == 0 {
+		return nil
+	}
+
+	apiUrls, err := serverApiUrls(cmd.Context)
+	if err != nil {
+		return fmt.Errorf("getting server API URLs: %w", err)
+	}
+	client, err := newKnowledgeClient(cmd.Context, apiUrls[opensearch])
+	if err != nil {
+		return err
+	}
+	if wait {
+		_ = client.SetBulkRefresh(knowledge.RefreshWaitFor)
+	}
+	lowerIngestPriority(client)
+	tikaClient, err := newTikaClient(cmd.Context, apiUrls[tika])
+	if err != nil {
+		return err
+	}
+	if exists, err := client.IndexExists(ctx, opts.TargetIndex); err != nil {
+		return err
+	} else if !exists {
+		kb, _ := knowledge.KnowledgeBaseNameFromIndex(opts.TargetIndex)
+		return fmt.Errorf("%w: '%s' (create it with `knowledge create %s`)", knowledge.ErrIndexNotFound, kb, kb)
+	}
+
+	result, err := knowledge.IngestSitemap(ctx, client, tikaClient, pages, opts)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("ingest interrupted after %d pages; re-run to ingest the rest", result.Ingested)
+		}
+		return err
+	}
+	fmt.Printf("Ingested %d pages into index '%s' (%d skipped, %d failed)\n",
+		result.Ingested, opts.TargetIndex, result.Skipped, result.Failed)
+	if result.Failed > 0 {
+		return fmt.Errorf("%d of %d pages failed to ingest", result.Failed, len(pages))
+	}
+	return nil
+}
+
+// lowerIngestPriority lowers a direct-mode ingest's CPU and IO priority when
+// knowledge.guard is on. Failing to only loses the guard's benefit, so it is
+// reported as a warning.
+func lowerIngestPriority(client *knowledge.OpenSearchClient) {
+	if !client.ResourceGuardEnabled() {
+		return
+	}
+	if err := knowledge.LowerProcessPriority(); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
+
 func (cmd *knowledgeCommand) searchCommand() *cobra.Command {
 	var (
-		bases []string
-		k     int
+		bases    []string
+		k        int
+		filters  []string
+		from     int
+		size     int
+		all      bool
+		since    string
+		until    string
+		output   string
+		out      string
+		perBase  int
+		versions bool
 	)
 
 	cobraCmd := &cobra.Command{
 		Use:   "search <query>",
 		Short: "Search the knowledge base",
-		Long:  "Search for documents across knowledge bases.\nIf no bases are specified with --index, the default index is searched.\nResults from all bases are merged and sorted by relevance score.",
-		Args:  cobra.ExactArgs(1),
+		Long: "Search for documents across knowledge bases.\nIf no bases are specified with --bases, you are asked to pick them (the default base is searched when not running in a terminal).\nResults from all bases are merged and sorted by relevance score.\nUse --filter key=value (repeatable) to only match sources tagged with ingest --metadata.\n" +
+			"Use --since and --until to only match chunks ingested in a time range: an age (7d, 12h),\n" +
+			"a date (2024-05-01, inclusive), or a UTC timestamp (2024-05-01 14:00:00).\n" +
+			"Use --from and --size to page through the merged results.\n" +
+			"Use --per-base-k to start the merged results with each base's best hits, so no base is crowded out.\n" +
+			"Use --versions to also match the chunks kept for replaced versions of sources (see knowledge history).\n" +
+			"Use --all to export every chunk matching the query's terms as NDJSON (one JSON object per line),\n" +
+			"ordered by lexical (BM25) score; the neural and rerank stages only ever rank a top-k, so they are skipped.\n" +
+			"Use --output context to print only the context block chat injects into the prompt, labels and\n" +
+			"source annotations included, for use with another LLM frontend.\n" +
+			"Use --output csv, json, or ndjson to export the full hits (score, index, source, date, tags,\n" +
+			"content) for spreadsheets or notebooks, to stdout or to the file given with --out.",
+		Args: cobra.ExactArgs(1),
 		RunE: func(_ *cobra.Command, args []string) error {
 			query := args[0]
 
+			switch output {
+			case searchOutputText:
+			case searchOutputContext:
+				if all {
+					return fmt.Errorf("--output context cannot be combined with --all")
+				}
+			case searchOutputCSV, searchOutputNDJSON:
+			case searchOutputJSON:
+				if all {
+					return fmt.Errorf("--output json cannot be combined with --all; use ndjson or csv to stream every match")
+				}
+			default:
+				return fmt.Errorf("invalid --output %q: expected %s, %s, %s, %s, or %s", output,
+					searchOutputText, searchOutputContext, searchOutputCSV, searchOutputJSON, searchOutputNDJSON)
+			}
+			if out != "" && !all && !isSearchExport(output) {
+				return fmt.Errorf("--out requires --output %s, %s, or %s", searchOutputCSV, searchOutputJSON, searchOutputNDJSON)
+			}
+
+			if from < 0 || size < 0 || perBase < 0 {
+				return fmt.Errorf("--from, --size, and --per-base-k must not be negative")
+			}
+			paginate := from > 0 || size > 0
+			if paginate && size == 0 {
+				size = k
+			}
+			// Each base must contribute enough candidates to fill the page
+			// once the results are merged.
+			fetch := k
+			if paginate {
+				fetch = from + size
+			}
+
+			tags, err := knowledge.ParseTags(filters)
+			if err != nil {
+				return err
+			}
+			opts := knowledge.SearchOptions{Tags: tags, PerBaseK: perBase, Versions: versions}
+			now := time.Now()
+			if opts.Since, err = knowledge.ParseTimeBound(since, now, false); err != nil {
+				return fmt.Errorf("--since: %w", err)
+			}
+			if opts.Until, err = knowledge.ParseTimeBound(until, now, true); err != nil {
+				return fmt.Errorf("--until: %w", err)
+			}
+
+			if len(bases) == 0 {
+				if bases, err = cmd.pickKnowledgeBases("Select knowledge bases to search", true); err != nil {
+					return err
+				}
+			}
+
 			if dc := daemonClient(cmd.Context); dc != nil {
-				searchBases := bases
-				if len(searchBases) == 0 {
-					defaultBase, _ := knowledge.KnowledgeBaseNameFromIndex(knowledge.DefaultIndexName())
-					searchBases = []string{defaultBase}
+				if len(tags) > 0 {
+					return fmt.Errorf("--filter is not supported over the ragd daemon yet; run without the daemon to filter by metadata")
+				}
+				if since != "" || until != "" {
+					return fmt.Errorf("--since and --until are not supported over the ragd daemon yet; run without the daemon to filter by date")
+				}
+				if all {
+					return fmt.Errorf("--all is not supported over the ragd daemon yet; run without the daemon to export results")
 				}
-				hits, err := dc.Search(context.Background(), query, searchBases, k)
+				if perBase > 0 {
+					return fmt.Errorf("--per-base-k is not supported over the ragd daemon yet; run without the daemon to reserve results per base")
+				}
+				if versions {
+					return fmt.Errorf("--versions is not supported over the ragd daemon yet; run without the daemon to search replaced versions")
+				}
+				hits, err := dc.Search(context.Background(), query, bases, fetch)
 				if err != nil {
 					return err
 				}
+				if paginate {
+					hits = hits[min(from, len(hits)):min(from+size, len(hits))]
+				}
+				if output == searchOutputContext {
+					printSearchContext(knowledgeHits(hits))
+					return nil
+				}
+				if isSearchExport(output) {
+					return writeSearchExport(out, output, knowledgeHits(hits))
+				}
 				if len(hits) == 0 {
 					fmt.Println("No results found.")
 					return nil
 				}
 				for i, hit := range hits {
-					fmt.Printf("\n--- Result %d (score: %.4f, base: %s) %s ---\n", i+1, hit.Score, hit.Base, knowledge.LabelTag(hit.Label))
-					fmt.Printf("  Source: %s\n", hit.SourceID)
+					fmt.Printf("\n--- Result %d (score: %.4f, base: %s) %s ---\n", from+i+1, hit.Score, hit.Base, knowledge.LabelTag(hit.Label))
+					fmt.Printf("  Source: %s\n", knowledge.Citation(hit.SourceID, hit.Page))
 					fmt.Printf("  Date:   %s\n", hit.CreatedAt)
 					content := hit.Content
 					if len(content) > 200 {
@@ -637,12 +1084,55 @@ func (cmd *knowledgeCommand) searchCommand() *cobra.Command {
 					}
 					fmt.Printf("  %s\n", content)
 				}
-				fmt.Printf("\nTotal: %d results\n", len(hits))
+				printSearchTotal(len(hits), from, paginate)
 				return nil
 			}
 
-			client, err := cmd.opensearchClient()
-			if err != nil {
+			var fullIndexNames []string
+			for _, suffix := range bases {
+				fullIndexNames = append(fullIndexNames, knowledge.FullIndexName(suffix))
+			}
+
+			if all {
+				// Not cmd.opensearchClient: its notice would end up in the NDJSON.
+				url, err := cmd.opensearchURL()
+				if err != nil {
+					return err
+				}
+				client, err := newKnowledgeClient(cmd.Context, url)
+				if err != nil {
+					return err
+				}
+				format := output
+				if format == searchOutputText {
+					format = searchOutputNDJSON
+				}
+				w, closeOut, err := openSearchOutput(out)
+				if err != nil {
+					return err
+				}
+				exporter, err := newSearchExporter(w, format)
+				if err != nil {
+					return finishSearchOutput(out, 0, closeOut, err)
+				}
+				err = client.SearchAll(context.Background(), fullIndexNames, query, opts, exporter.write)
+				if err == nil {
+					err = exporter.close()
+				}
+				return finishSearchOutput(out, exporter.count, closeOut, err)
+			}
+
+			var client *knowledge.OpenSearchClient
+			if output != searchOutputText {
+				// As for --all, keep the notice out of the context block and exports.
+				url, err := cmd.opensearchURL()
+				if err != nil {
+					return err
+				}
+				if client, err = newKnowledgeClient(cmd.Context, url); err != nil {
+					return err
+				}
+			} else if client, err = cmd.opensearchClient(); err != nil {
 				return err
 			}
 
@@ -652,20 +1142,20 @@ func (cmd *knowledgeCommand) searchCommand() *cobra.Command {
 				return err
 			}
 
-			// Resolve index names: use provided suffixes or default index.
-			var fullIndexNames []string
-			if len(bases) > 0 {
-				for _, suffix := range bases {
-					fullIndexNames = append(fullIndexNames, knowledge.FullIndexName(suffix))
-				}
-			} else {
-				fullIndexNames = []string{knowledge.DefaultIndexName()}
-			}
-
-			results, err := client.Search(context.Background(), fullIndexNames, query, query, modelID, k)
+			results, err := client.SearchWithOptions(context.Background(), fullIndexNames, query, query, modelID, fetch, opts)
 			if err != nil {
 				return fmt.Errorf("searching: %w", err)
 			}
+			if paginate {
+				results = knowledge.PageHits(results, from, size)
+			}
+			if output == searchOutputContext {
+				printSearchContext(results)
+				return nil
+			}
+			if isSearchExport(output) {
+				return writeSearchExport(out, output, results)
+			}
 
 			if len(results) == 0 {
 				fmt.Println("No results found.")
@@ -673,9 +1163,12 @@ func (cmd *knowledgeCommand) searchCommand() *cobra.Command {
 			}
 
 			for i, hit := range results {
-				fmt.Printf("\n--- Result %d (score: %.4f, index: %s) %s ---\n", i+1, hit.Score, hit.Index, knowledge.LabelTag(hit.Label))
-				fmt.Printf("  Source: %s\n", hit.SourceID)
+				fmt.Printf("\n--- Result %d (score: %.4f, index: %s) %s ---\n", from+i+1, hit.Score, hit.Index, knowledge.LabelTag(hit.Label))
+				fmt.Printf("  Source: %s\n", knowledge.Citation(hit.SourceID, hit.Page))
 				fmt.Printf("  Date:   %s\n", hit.CreatedAt)
+				if len(hit.Tags) > 0 {
+					fmt.Printf("  Tags:   %s\n", knowledge.FormatTags(hit.Tags))
+				}
 				content := hit.Content
 				if len(content) > 200 {
 					content = content[:200] + "..."
@@ -683,13 +1176,126 @@ func (cmd *knowledgeCommand) searchCommand() *cobra.Command {
 				fmt.Printf("  %s\n", content)
 			}
 
-			fmt.Printf("\nTotal: %d results\n", len(results))
+			printSearchTotal(len(results), from, paginate)
 			return nil
 		},
 	}
 
-	cobraCmd.Flags().StringSliceVarP(&bases, "bases", "b", nil, "Knowledge base name(s) to search (comma-separated string list, defaults to 'default')")
+	cobraCmd.Flags().StringSliceVarP(&bases, "bases", "b", nil, "Knowledge base name(s) to search (comma-separated string list, prompts when omitted in a terminal, otherwise 'default')")
 	cobraCmd.Flags().IntVarP(&k, "top", "k", 10, "Number of results per index")
+	cobraCmd.Flags().StringArrayVarP(&filters, "filter", "f", nil, "Only match sources tagged key=value (repeatable; all must match)")
+	cobraCmd.Flags().StringVar(&since, "since", "", "Only match chunks ingested at or after this time (e.g. 7d, 2024-05-01)")
+	cobraCmd.Flags().StringVar(&until, "until", "", "Only match chunks ingested before this time (a date is inclusive)")
+	cobraCmd.Flags().IntVar(&from, "from", 0, "Skip this many merged results (for paging)")
+	cobraCmd.Flags().IntVar(&size, "size", 0, "Number of merged results per page (default: --top)")
+	cobraCmd.Flags().BoolVar(&all, "all", false, "Export every chunk matching the query's terms as NDJSON (or --output csv)")
+	cobraCmd.Flags().StringVarP(&output, "output", "o", searchOutputText, "Output format: text, context for the block chat injects into the prompt, or csv, json, or ndjson to export the full hits")
+	cobraCmd.Flags().StringVar(&out, "out", "", "Write the --output csv, json, or ndjson export to this file instead of stdout")
+	cobraCmd.Flags().IntVar(&perBase, "per-base-k", 0, "Start the merged results with this many of each base's best hits")
+	cobraCmd.Flags().BoolVar(&versions, "versions", false, "Also match the chunks kept for replaced versions of sources (ingest --keep-versions)")
+	cobraCmd.MarkFlagsMutuallyExclusive("all", "from")
+	cobraCmd.MarkFlagsMutuallyExclusive("all", "size")
+	cobraCmd.MarkFlagsMutuallyExclusive("all", "top")
+	cobraCmd.MarkFlagsMutuallyExclusive("all", "per-base-k")
+
+	return cobraCmd
+}
+
+// knowledge search --output values.
+const (
+	searchOutputText    = "text"
+	searchOutputContext = "context"
+)
+
+// printSearchContext prints hits as the context block chat injects into the
+// prompt, and nothing at all when there are none, so a script can tell an
+// empty result from a context.
+func printSearchContext(hits []knowledge.SearchHit) {
+	if len(hits) == 0 {
+		return
+	}
+	fmt.Println(rag.FormatContext(hits))
+}
+
+// knowledgeHits converts daemon search hits for rag.FormatContext and the
+// search exports. The daemon does not return tags, so they are left empty.
+func knowledgeHits(hits []apiclient.SearchHit) []knowledge.SearchHit {
+	out := make([]knowledge.SearchHit, len(hits))
+	for i, h := range hits {
+		out[i] = knowledge.SearchHit{
+			Index:     knowledge.FullIndexName(h.Base),
+			Score:     h.Score,
+			Content:   h.Content,
+			SourceID:  h.SourceID,
+			Label:     h.Label,
+			CreatedAt: h.CreatedAt,
+			Page:      h.Page,
+		}
+	}
+	return out
+}
+
+// printSearchTotal prints the footer after search results: the total, or the
+// range of results shown when paging.
+func printSearchTotal(n, from int, paginate bool) {
+	if paginate {
+		fmt.Printf("\nResults %d-%d (next page: --from %d)\n", from+1, from+n, from+n)
+		return
+	}
+	fmt.Printf("\nTotal: %d results\n", n)
+}
+
+func (cmd *knowledgeCommand) findCommand() *cobra.Command {
+	var (
+		bases []string
+		limit int
+	)
+
+	cobraCmd := &cobra.Command{
+		Use:   "find <text>",
+		Short: "Find ingested sources by their metadata",
+		Long: "Search source metadata — title, author, file name and path, source ID, and tag values — rather than\n" +
+			"chunk content, and list the matching sources with their knowledge bases. Use it to locate the\n" +
+			"source ID to forget, re-ingest, or edit with `metadata set`.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if daemonClient(cmd.Context) != nil {
+				return fmt.Errorf("knowledge find is not supported over the ragd daemon yet; use `knowledge list --sources`")
+			}
+			if limit < 1 {
+				return fmt.Errorf("--limit must be at least 1")
+			}
+
+			client, err := cmd.opensearchClient()
+			if err != nil {
+				return err
+			}
+
+			var indexNames []string
+			for _, b := range bases {
+				indexNames = append(indexNames, knowledge.FullIndexName(b))
+			}
+
+			sources, err := client.FindSources(context.Background(), args[0], indexNames, limit)
+			if err != nil {
+				return fmt.Errorf("finding sources: %w", err)
+			}
+			if len(sources) == 0 {
+				fmt.Println("No matching sources found.")
+				return nil
+			}
+
+			fmt.Printf("%-50s %-30s %-40s %s\n", "SOURCE ID", "KNOWLEDGE BASE", "TITLE", "FILE")
+			for _, s := range sources {
+				knowledgeBaseName, _ := knowledge.KnowledgeBaseNameFromIndex(s.IndexName)
+				fmt.Printf("%-50s %-30s %-40s %s\n", s.SourceID, knowledgeBaseName, s.Title, s.FileName)
+			}
+			return nil
+		},
+	}
+
+	cobraCmd.Flags().StringSliceVarP(&bases, "bases", "b", nil, "Only find sources in these knowledge bases (comma-separated string list, default: all)")
+	cobraCmd.Flags().IntVarP(&limit, "limit", "n", knowledge.DefaultFindLimit, "Maximum number of sources to list")
 
 	return cobraCmd
 }
@@ -722,15 +1328,22 @@ func (cmd *knowledgeCommand) forgetCommand() *cobra.Command {
 			ctx := context.Background()
 
 			// Verify source exists
-			if _, err := client.GetSourceMetadata(ctx, sourceID); err != nil {
-				return fmt.Errorf("source not found: %w", err)
+			meta, err := client.GetSourceMetadata(ctx, sourceID)
+			if err != nil {
+				return err
 			}
 
-			// Delete chunks from the KNN index
+			// Delete chunks from the KNN index, including those kept for
+			// earlier versions
 			deleted, err := client.DeleteChunksBySourceID(ctx, indexName, sourceID)
 			if err != nil {
 				return fmt.Errorf("deleting chunks: %w", err)
 			}
+			archived, err := client.DeleteArchivedChunks(ctx, meta)
+			if err != nil {
+				return fmt.Errorf("deleting archived chunks: %w", err)
+			}
+			deleted += archived
 
 			// Delete the metadata record
 			if err := client.DeleteSourceMetadata(ctx, sourceID); err != nil {
@@ -746,11 +1359,12 @@ func (cmd *knowledgeCommand) forgetCommand() *cobra.Command {
 }
 
 func (cmd *knowledgeCommand) metadataCommand() *cobra.Command {
-	return &cobra.Command{
+	cobraCmd := &cobra.Command{
 		Use:   "metadata <knowledge_base_name> <source_id>",
 		Short: "Show metadata for an ingested source",
-		Long:  "Display the stored metadata for a source document ingested into the knowledge base.",
-		Args:  cobra.ExactArgs(2),
+		Long: "Display the stored metadata for a source document ingested into the knowledge base.\n" +
+			"Use `metadata set` to correct or enrich it.",
+		Args: cobra.ExactArgs(2),
 		RunE: func(_ *cobra.Command, args []string) error {
 			knowledgeBaseName := args[0]
 			sourceID := args[1]
@@ -771,7 +1385,7 @@ func (cmd *knowledgeCommand) metadataCommand() *cobra.Command {
 
 			meta, err := client.GetSourceMetadata(context.Background(), sourceID)
 			if err != nil {
-				return fmt.Errorf("source not found: %w", err)
+				return err
 			}
 
 			knowledgeBaseName, _ = knowledge.KnowledgeBaseNameFromIndex(meta.IndexName)
@@ -784,8 +1398,14 @@ func (cmd *knowledgeCommand) metadataCommand() *cobra.Command {
 			fmt.Printf("Content type:   %s\n", meta.ContentType)
 			fmt.Printf("Content length: %d bytes\n", meta.ContentLength)
 			fmt.Printf("Label:          %s\n", knowledge.ResolveLabel(meta.IndexName, meta.Label))
+			if len(meta.Tags) > 0 {
+				fmt.Printf("Tags:           %s\n", knowledge.FormatTags(meta.Tags))
+			}
 			fmt.Printf("Checksum:       %s\n", meta.Checksum)
 			fmt.Printf("Chunks:         %d (size=%d, overlap=%d)\n", meta.ChunkCount, meta.ChunkSize, meta.ChunkOverlap)
+			if meta.DuplicateChunks > 0 {
+				fmt.Printf("Duplicates:     %d chunks skipped, already in the base from other sources\n", meta.DuplicateChunks)
+			}
 			fmt.Printf("Ingested at:    %s\n", meta.IngestedAt)
 			fmt.Printf("Updated at:     %s\n", meta.UpdatedAt)
 			if meta.Title != "" {
@@ -801,6 +1421,86 @@ func (cmd *knowledgeCommand) metadataCommand() *cobra.Command {
 			return nil
 		},
 	}
+	cobraCmd.AddCommand(cmd.metadataSetCommand())
+	return cobraCmd
+}
+
+func (cmd *knowledgeCommand) metadataSetCommand() *cobra.Command {
+	var titleFlag, authorFlag string
+	var tagFlags, untagFlags []string
+
+	cobraCmd := &cobra.Command{
+		Use:   "set <knowledge_base_name> <source_id>",
+		Short: "Edit the metadata of an ingested source",
+		Long: "Correct or enrich a source's metadata after ingest without re-ingesting it.\n" +
+			"--title and --author replace the stored values (pass \"\" to clear one).\n" +
+			"--tag key=value adds or replaces a tag and --untag key removes one; tag\n" +
+			"changes are applied to every chunk of the source, so they take effect in\n" +
+			"`knowledge search --filter` and its results right away.",
+		Args: cobra.ExactArgs(2),
+		RunE: func(c *cobra.Command, args []string) error {
+			knowledgeBaseName, sourceID := args[0], args[1]
+
+			var update knowledge.SourceMetadataUpdate
+			if c.Flags().Changed("title") {
+				update.Title = &titleFlag
+			}
+			if c.Flags().Changed("author") {
+				update.Author = &authorFlag
+			}
+			tags, err := knowledge.ParseTags(tagFlags)
+			if err != nil {
+				return err
+			}
+			update.SetTags = tags
+			update.RemoveTags = untagFlags
+			if update.IsZero() {
+				return fmt.Errorf("nothing to change: pass --title, --author, --tag, or --untag")
+			}
+
+			if daemonClient(cmd.Context) != nil {
+				return fmt.Errorf("metadata set is not supported over the ragd daemon yet; run without the daemon to edit source metadata")
+			}
+
+			client, err := cmd.opensearchClient()
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			existing, err := client.GetSourceMetadata(ctx, sourceID)
+			if err != nil {
+				return err
+			}
+			if existing.IndexName != knowledge.FullIndexName(knowledgeBaseName) {
+				return fmt.Errorf("source '%s' does not belong to knowledge base '%s'", sourceID, knowledgeBaseName)
+			}
+
+			meta, err := client.UpdateSourceMetadata(ctx, sourceID, update)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Updated metadata for source '%s' in knowledge base '%s'\n", sourceID, knowledgeBaseName)
+			if meta.Title != "" {
+				fmt.Printf("Title:  %s\n", meta.Title)
+			}
+			if meta.Author != "" {
+				fmt.Printf("Author: %s\n", meta.Author)
+			}
+			if len(meta.Tags) > 0 {
+				fmt.Printf("Tags:   %s\n", knowledge.FormatTags(meta.Tags))
+			}
+			return nil
+		},
+	}
+
+	cobraCmd.Flags().StringVar(&titleFlag, "title", "", "New title for the source")
+	cobraCmd.Flags().StringVar(&authorFlag, "author", "", "New author for the source")
+	cobraCmd.Flags().StringArrayVarP(&tagFlags, "tag", "t", nil, "Add or replace a key=value tag (repeatable)")
+	cobraCmd.Flags().StringArrayVar(&untagFlags, "untag", nil, "Remove a tag by key (repeatable)")
+
+	return cobraCmd
 }
 
 func (cmd *knowledgeCommand) deleteCommand() *cobra.Command {
@@ -893,43 +1593,62 @@ func (cmd *knowledgeCommand) listIndexes(ctx context.Context, client *knowledge.
 		return nil
 	}
 
-	fmt.Printf("%-30s %-10s %-10s %-12s %-10s\n", "KNOWLEDGE BASE", "HEALTH", "STATUS", "DOCS", "SIZE")
-	for _, idx := range indexes {
+	budget, err := cmd.storageBudget()
+	if err != nil {
+		return err
+	}
+	var storage map[string]knowledge.IndexStorage
+	if !budget.IsZero() {
+		// Without index monitor permission, bases are listed unchecked.
+		storage, _ = client.IndexStorageStats(ctx)
+	}
 
+	rows := make([]baseRow, 0, len(indexes))
+	for _, idx := range indexes {
 		knowledgeBaseName, _ := knowledge.KnowledgeBaseNameFromIndex(idx.Name)
-		fmt.Printf("%-30s %-10s %-10s %-12s %-10s\n",
-			knowledgeBaseName, idx.Health, idx.Status, idx.DocsCount, idx.StoreSize)
+		rows = append(rows, baseRow{
+			Name:    knowledgeBaseName,
+			Index:   idx.Name,
+			Health:  idx.Health,
+			Status:  idx.Status,
+			Docs:    idx.DocsCount,
+			Size:    idx.StoreSize,
+			Storage: storage[idx.Name],
+		})
 	}
+	printBaseTable(rows, budget, func(indexName string) (knowledge.RetentionPolicy, error) {
+		return client.GetRetentionPolicy(ctx, indexName)
+	})
 
 	return nil
 }
 
-// listSources lists all ingested source documents, optionally filtered by index name.
-func (cmd *knowledgeCommand) listSources(ctx context.Context, client *knowledge.OpenSearchClient, args []string) error {
-	var indexFilter string
-	if len(args) > 0 {
-		indexFilter = args[0]
-	}
-
-	if indexFilter != "" {
-		indexFilter = knowledge.FullIndexName(indexFilter)
-	}
-
-	sources, err := client.ListSourceMetadata(ctx, indexFilter)
+// listSourceRows prints the sources fetch lists, with a TAGS column when any
+// of them is tagged.
+func listSourceRows(ctx context.Context, fetch func(context.Context) ([]sourceRow, error), wide bool) error {
+	rows, err := fetch(ctx)
 	if err != nil {
 		return fmt.Errorf("listing sources: %w", err)
 	}
-
-	if len(sources) == 0 {
+	if len(rows) == 0 {
 		fmt.Println("No ingested sources found.")
 		return nil
 	}
 
-	fmt.Printf("%-50s %-30s %-16s %-12s %-8s %-20s\n", "SOURCE ID", "KNOWLEDGE BASE", "LABEL", "STATUS", "CHUNKS", "INGESTED AT")
-	for _, s := range sources {
-		knowledgeBaseName, _ := knowledge.KnowledgeBaseNameFromIndex(s.IndexName)
-		fmt.Printf("%-50s %-30s %-16s %-12s %-8d %-20s\n",
-			s.SourceID, knowledgeBaseName, knowledge.ResolveLabel(s.IndexName, s.Label), s.Status, s.ChunkCount, s.IngestedAt)
+	tagged := slices.ContainsFunc(rows, func(r sourceRow) bool { return r.Tags != "" })
+	cols := sourceTableColumns(rows, wide)
+	if tagged {
+		fmt.Println(cols.header("TAGS"))
+	} else {
+		fmt.Println(cols.header())
+	}
+	for _, r := range rows {
+		status := fmt.Sprintf("%-12s", r.Status)
+		if tagged {
+			fmt.Println(cols.row(r, status, r.Tags))
+		} else {
+			fmt.Println(cols.row(r, status))
+		}
 	}
 
 	return nil
@@ -1078,7 +1797,7 @@ func (cmd *knowledgeCommand) importCommand() *cobra.Command {
 
 			for i, archive := range archives {
 				fmt.Printf("[%d/%d] Downloading %s...\n", i+1, len(archives), archive.Name)
-				tmpPath, cleanup, dlErr := knowledge.DownloadDriveArchive(ctx, archive, accessToken)
+				tmpPath, cleanup, dlErr := knowledge.DownloadDriveArchive(ctx, client.Settings().Temp, archive, accessToken)
 				if dlErr != nil {
 					fmt.Printf("  skip: %v\n", dlErr)
 					continue
@@ -1,173 +0,0 @@
-package knowledge
-
-import (
-	"bytes"
-	"context"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-	"sort"
-
-	"github.com/jpnorenam/rag-snap/cmd/cli/common"
-)
-
-// SearchHit represents a single search result with its relevance score.
-// Label is always resolved (stored chunk label, or the legacy index-name
-// inference for unlabeled chunks) — consumers use it directly and never
-// re-derive provenance.
-type SearchHit struct {
-	Index     string  `json:"index"`
-	Score     float64 `json:"score"`
-	Content   string  `json:"content"`
-	SourceID  string  `json:"source_id"`
-	Label     string  `json:"label"`
-	CreatedAt string  `json:"created_at"`
-}
-
-// Search performs a hybrid search (BM25 + neural) with reranking across the
-// given indexes, merges the results, and returns them sorted by score descending.
-// Indexes should be full index names (e.g. "rag-snap-context-default").
-// The query parameter is used for neural embedding and reranking.
-// The lexicalQuery parameter is used for BM25 matching and may include
-// additional context (e.g. recent conversation queries) for richer lexical recall.
-func (c *OpenSearchClient) Search(ctx context.Context, indexes []string, query, lexicalQuery, embeddingModelID string, k int) ([]SearchHit, error) {
-	stopProgress := common.StartProgressSpinner("Searching knowledge base")
-	defer stopProgress()
-
-	return c.search(ctx, indexes, query, lexicalQuery, embeddingModelID, k)
-}
-
-func (c *OpenSearchClient) search(ctx context.Context, indexes []string, query, lexicalQuery, embeddingModelID string, k int) ([]SearchHit, error) {
-	// Search each index individually and collect all hits.
-	var allHits []SearchHit
-	for _, index := range indexes {
-		hits, err := c.hybridSearch(ctx, index, query, lexicalQuery, embeddingModelID, k)
-		if err != nil {
-			return nil, fmt.Errorf("searching index %q: %w", index, err)
-		}
-		allHits = append(allHits, hits...)
-	}
-
-	// Merge results from all indexes sorted by score descending.
-	sort.Slice(allHits, func(i, j int) bool {
-		return allHits[i].Score > allHits[j].Score
-	})
-
-	return allHits, nil
-}
-
-// hybridSearch executes a hybrid (BM25 + neural) search with reranking on a single index.
-func (c *OpenSearchClient) hybridSearch(
-	ctx context.Context,
-	indexName, query, lexicalQuery, embeddingModelID string,
-	k int,
-) ([]SearchHit, error) {
-	body := buildSearchBody(query, lexicalQuery, embeddingModelID, k)
-
-	bodyBytes, err := json.Marshal(body)
-	if err != nil {
-		return nil, fmt.Errorf("marshaling search body: %w", err)
-	}
-
-	path := fmt.Sprintf("/%s/_search?search_pipeline=%s", indexName, searchPipelineName)
-	req, err := c.newAuthenticatedRequest(http.MethodGet, path, bytes.NewReader(bodyBytes))
-	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
-	}
-
-	resp, err := c.client.Client.Perform(req.WithContext(ctx))
-	if err != nil {
-		return nil, fmt.Errorf("executing search request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("search request failed with status %d: %s", resp.StatusCode, string(respBody))
-	}
-
-	var searchResp neuralSearchResponse
-	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
-		return nil, fmt.Errorf("decoding search response: %w", err)
-	}
-
-	hits := make([]SearchHit, 0, len(searchResp.Hits.Hits))
-	for _, hit := range searchResp.Hits.Hits {
-		hits = append(hits, SearchHit{
-			Index:     hit.Index,
-			Score:     hit.Score,
-			Content:   hit.Source.Content,
-			SourceID:  hit.Source.SourceID,
-			Label:     ResolveLabel(hit.Index, hit.Source.Label),
-			CreatedAt: hit.Source.CreatedAt,
-		})
-	}
-
-	return hits, nil
-}
-
-// buildSearchBody constructs a hybrid search request body combining BM25
-// lexical matching with neural KNN, plus reranking context.
-// The lexicalQuery is used for BM25 matching and may be enriched with
-// conversation history. The query is used for neural embedding and reranking.
-func buildSearchBody(query, lexicalQuery, embeddingModelID string, k int) map[string]any {
-	// Over-fetch candidates so the reranker has a larger pool to work with.
-	// The final result count is capped back to k via "size".
-	neuralK := k * 3
-	return map[string]any{
-		"size": k,
-		"_source": map[string]any{
-			"excludes": []string{"embedding"},
-		},
-		"query": map[string]any{
-			"hybrid": map[string]any{
-				"queries": []map[string]any{
-					{
-						"match": map[string]any{
-							"content": map[string]any{
-								"query": lexicalQuery,
-							},
-						},
-					},
-					{
-						"neural": map[string]any{
-							"embedding": map[string]any{
-								"query_text": query,
-								"model_id":   embeddingModelID,
-								"k":          neuralK,
-							},
-						},
-					},
-				},
-			},
-		},
-		"ext": map[string]any{
-			"rerank": map[string]any{
-				"query_context": map[string]any{
-					"query_text": query,
-				},
-			},
-		},
-	}
-}
-
-// neuralSearchResponse represents the OpenSearch response for a neural search query.
-type neuralSearchResponse struct {
-	Hits struct {
-		Total struct {
-			Value int `json:"value"`
-		} `json:"total"`
-		Hits []struct {
-			Index  string  `json:"_index"`
-			ID     string  `json:"_id"`
-			Score  float64 `json:"_score"`
-			Source struct {
-				Content   string `json:"content"`
-				SourceID  string `json:"source_id"`
-				Label     string `json:"label"`
-				CreatedAt string `json:"created_at"`
-			} `json:"_source"`
-		} `json:"hits"`
-	} `json:"hits"`
-}
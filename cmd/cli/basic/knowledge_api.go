@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/jpnorenam/rag-snap/internal/apiclient"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
 )
 
 // listIndexesAPI lists knowledge bases via the daemon, matching the direct-mode
@@ -21,42 +22,24 @@ func (cmd *knowledgeCommand) listIndexesAPI(ctx context.Context, dc *apiclient.C
 		fmt.Println("No knowledge base indexes found.")
 		return nil
 	}
-	fmt.Printf("%-30s %-10s %-10s %-12s %-10s\n", "KNOWLEDGE BASE", "HEALTH", "STATUS", "DOCS", "SIZE")
-	for _, b := range bases {
-		fmt.Printf("%-30s %-10s %-10s %-12s %-10s\n", b.Name, b.Health, b.Status, b.DocsCount, b.StoreSize)
-	}
-	return nil
-}
-
-// listSourcesAPI lists sources via the daemon, matching the direct-mode
-// listSources output. An optional index filter selects a single base.
-func (cmd *knowledgeCommand) listSourcesAPI(ctx context.Context, dc *apiclient.Client, args []string) error {
-	var bases []apiclient.KnowledgeBase
-	if len(args) > 0 {
-		bases = []apiclient.KnowledgeBase{{Name: args[0]}}
-	} else {
-		var err error
-		bases, err = dc.ListKnowledge(ctx)
-		if err != nil {
-			return err
-		}
+	budget, err := cmd.storageBudget()
+	if err != nil {
+		return err
 	}
-
-	fmt.Printf("%-50s %-30s %-16s %-12s %-8s %-20s\n", "SOURCE ID", "KNOWLEDGE BASE", "LABEL", "STATUS", "CHUNKS", "INGESTED AT")
-	found := false
+	rows := make([]baseRow, 0, len(bases))
 	for _, b := range bases {
-		sources, err := dc.ListSources(ctx, b.Name)
-		if err != nil {
-			return err
-		}
-		for _, s := range sources {
-			found = true
-			fmt.Printf("%-50s %-30s %-16s %-12s %-8d %-20s\n", s.SourceID, b.Name, s.Label, s.Status, s.ChunkCount, s.IngestedAt)
-		}
-	}
-	if !found {
-		fmt.Println("No ingested sources found.")
+		rows = append(rows, baseRow{
+			Name:    b.Name,
+			Index:   b.Index,
+			Health:  b.Health,
+			Status:  b.Status,
+			Docs:    b.DocsCount,
+			Size:    b.StoreSize,
+			Storage: knowledge.IndexStorage{StoreBytes: b.StoreBytes, DeletedDocs: b.DeletedDocs},
+		})
 	}
+	// Retention policies are not served by the daemon yet.
+	printBaseTable(rows, budget, nil)
 	return nil
 }
 
@@ -73,6 +56,9 @@ func printSourceMetadata(knowledgeBaseName string, meta *apiclient.Source) {
 	fmt.Printf("Label:          %s\n", meta.Label)
 	fmt.Printf("Checksum:       %s\n", meta.Checksum)
 	fmt.Printf("Chunks:         %d (size=%d, overlap=%d)\n", meta.ChunkCount, meta.ChunkSize, meta.ChunkOverlap)
+	if meta.DuplicateChunks > 0 {
+		fmt.Printf("Duplicates:     %d chunks skipped, already in the base from other sources\n", meta.DuplicateChunks)
+	}
 	fmt.Printf("Ingested at:    %s\n", meta.IngestedAt)
 	fmt.Printf("Updated at:     %s\n", meta.UpdatedAt)
 	if meta.Title != "" {
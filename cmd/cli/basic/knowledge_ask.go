@@ -0,0 +1,88 @@
+package basic
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jpnorenam/rag-snap/cmd/cli/basic/chat"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/spf13/cobra"
+)
+
+func (cmd *knowledgeCommand) askCommand() *cobra.Command {
+	var (
+		bases       []string
+		model       string
+		temperature float64
+		contextOnly bool
+	)
+
+	cobraCmd := &cobra.Command{
+		Use:   "ask <question>",
+		Short: "Answer a question from the knowledge base in one shot",
+		Long: "Answer a single question without entering the chat REPL: the question is\n" +
+			"rewritten into search keywords, relevant chunks are retrieved from the given\n" +
+			"bases (default: 'default'), and the inference server answers from them. The\n" +
+			"answer is followed by the sources it drew on.\n" +
+			"Use --context-only to print just the retrieved, formatted context instead of\n" +
+			"an answer, e.g. to pipe it into another tool.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if daemonClient(cmd.Context) != nil {
+				return fmt.Errorf("knowledge ask is not supported over the ragd daemon yet; use `chat` or `knowledge search`")
+			}
+
+			apiUrls, err := serverApiUrls(cmd.Context)
+			if err != nil {
+				return fmt.Errorf("getting server API URLs: %w", err)
+			}
+			modelID, err := cmd.embeddingModelID()
+			if err != nil {
+				return err
+			}
+			settings, err := chatSettings(cmd.Context)
+			if err != nil {
+				return err
+			}
+			// Not cmd.opensearchClient: its notice would end up in piped output.
+			client, err := newKnowledgeClient(cmd.Context, apiUrls[opensearch])
+			if err != nil {
+				return err
+			}
+
+			if len(bases) == 0 {
+				defaultBase, _ := knowledge.KnowledgeBaseNameFromIndex(knowledge.DefaultIndexName())
+				bases = []string{defaultBase}
+			}
+			if model == "" {
+				model, _ = getConfigString(cmd.Context, confChatModel)
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			return chat.Ask(ctx, args[0], chat.AskOptions{
+				BaseURL:          apiUrls[openAi],
+				Model:            model,
+				KnowledgeClient:  client,
+				EmbeddingModelID: modelID,
+				Bases:            bases,
+				SystemPrompt:     chat.LoadPrompts().ChatSystemPrompt,
+				Temperature:      temperature,
+				Settings:         settings,
+				ContextOnly:      contextOnly,
+				Verbose:          cmd.Verbose,
+			}, os.Stdout)
+		},
+	}
+
+	cobraCmd.Flags().StringSliceVarP(&bases, "bases", "b", nil, "Knowledge base name(s) to retrieve from (comma-separated string list, defaults to 'default')")
+	cobraCmd.Flags().StringVar(&model, "model", "", "Chat model to answer with (default: chat.model, or the server's only model)")
+	cobraCmd.Flags().Float64Var(&temperature, "temperature", 0.3, "Sampling temperature (0.0–1.0); lower = more deterministic")
+	cobraCmd.Flags().BoolVar(&contextOnly, "context-only", false, "Print the retrieved context instead of generating an answer")
+
+	return cobraCmd
+}
@@ -0,0 +1,140 @@
+package basic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/spf13/cobra"
+)
+
+// compareColumnWidth is the width of each side's column in the compare table.
+const compareColumnWidth = 44
+
+func (cmd *knowledgeCommand) compareCommand() *cobra.Command {
+	var (
+		bases []string
+		k     int
+		left  string
+		right string
+	)
+
+	cobraCmd := &cobra.Command{
+		Use:   "compare <query>",
+		Short: "Compare two search configurations side by side",
+		Long: "Run a query with two retrieval configurations and show their results side by side,\n" +
+			"for tuning retrieval. Each of --left and --right is a mode (hybrid, neural, or lexical),\n" +
+			"optionally followed by ,pipeline=<search pipeline> and ,model=<embedding model id>,\n" +
+			"e.g. --left neural --right hybrid,pipeline=my-rerank-pipeline.\n" +
+			"The DELTA column shows how far each right-hand result moved from its rank on the left\n" +
+			"(↑ up, ↓ down, = same), or 'new' when the left configuration did not return it.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			query := args[0]
+
+			leftVariant, err := knowledge.ParseSearchVariant(left)
+			if err != nil {
+				return fmt.Errorf("--left: %w", err)
+			}
+			rightVariant, err := knowledge.ParseSearchVariant(right)
+			if err != nil {
+				return fmt.Errorf("--right: %w", err)
+			}
+			if k <= 0 {
+				return fmt.Errorf("--top must be positive")
+			}
+
+			if daemonClient(cmd.Context) != nil {
+				return fmt.Errorf("compare is not supported over the ragd daemon yet; run without the daemon to compare search configurations")
+			}
+
+			if len(bases) == 0 {
+				if bases, err = cmd.pickKnowledgeBases("Select knowledge bases to search", true); err != nil {
+					return err
+				}
+			}
+			var fullIndexNames []string
+			for _, suffix := range bases {
+				fullIndexNames = append(fullIndexNames, knowledge.FullIndexName(suffix))
+			}
+
+			client, err := cmd.opensearchClient()
+			if err != nil {
+				return err
+			}
+			modelID, err := cmd.embeddingModelID()
+			if err != nil {
+				return err
+			}
+
+			leftHits, err := client.SearchVariant(context.Background(), fullIndexNames, query, modelID, k, leftVariant)
+			if err != nil {
+				return fmt.Errorf("searching with --left %s: %w", leftVariant, err)
+			}
+			rightHits, err := client.SearchVariant(context.Background(), fullIndexNames, query, modelID, k, rightVariant)
+			if err != nil {
+				return fmt.Errorf("searching with --right %s: %w", rightVariant, err)
+			}
+			leftHits, rightHits = leftHits[:min(k, len(leftHits))], rightHits[:min(k, len(rightHits))]
+
+			printComparison(leftVariant, rightVariant, leftHits, rightHits)
+			return nil
+		},
+	}
+
+	cobraCmd.Flags().StringSliceVarP(&bases, "bases", "b", nil, "Knowledge base name(s) to search (comma-separated string list, prompts when omitted in a terminal, otherwise 'default')")
+	cobraCmd.Flags().IntVarP(&k, "top", "k", 10, "Number of results to compare")
+	cobraCmd.Flags().StringVar(&left, "left", knowledge.SearchNeural, "Left configuration: <mode>[,pipeline=<name>][,model=<id>]")
+	cobraCmd.Flags().StringVar(&right, "right", knowledge.SearchHybrid, "Right configuration: <mode>[,pipeline=<name>][,model=<id>]")
+
+	return cobraCmd
+}
+
+// printComparison renders the two result lists as a table, one rank per row,
+// with the rank delta of each right-hand hit, then how many hits both share.
+func printComparison(leftVariant, rightVariant knowledge.SearchVariant, left, right []knowledge.SearchHit) {
+	if len(left) == 0 && len(right) == 0 {
+		fmt.Println("No results found.")
+		return
+	}
+
+	deltas := knowledge.RankDeltas(left, right)
+	fmt.Printf("%-5s %-*s %-*s %s\n", "RANK",
+		compareColumnWidth, "LEFT: "+leftVariant.String(), compareColumnWidth, "RIGHT: "+rightVariant.String(), "DELTA")
+	shared := 0
+	for i := range max(len(left), len(right)) {
+		var leftCell, rightCell, delta string
+		if i < len(left) {
+			leftCell = compareCell(left[i])
+		}
+		if i < len(right) {
+			rightCell = compareCell(right[i])
+			switch from := deltas[i]; {
+			case from < 0:
+				delta = "new"
+			case from > i:
+				delta = fmt.Sprintf("↑%d", from-i)
+			case from < i:
+				delta = fmt.Sprintf("↓%d", i-from)
+			default:
+				delta = "="
+			}
+			if deltas[i] >= 0 {
+				shared++
+			}
+		}
+		fmt.Printf("%-5d %-*s %-*s %s\n", i+1, compareColumnWidth, leftCell, compareColumnWidth, rightCell, delta)
+	}
+	fmt.Printf("\n%d of %d right-hand results also appear on the left.\n", shared, len(right))
+}
+
+// compareCell renders a hit as its citation and score, fitted to
+// compareColumnWidth.
+func compareCell(hit knowledge.SearchHit) string {
+	score := fmt.Sprintf(" (%.4f)", hit.Score)
+	source := []rune(knowledge.Citation(hit.SourceID, hit.Page))
+	if room := compareColumnWidth - len(score); len(source) > room {
+		source = append(source[:room-1], '…')
+	}
+	return string(source) + score
+}
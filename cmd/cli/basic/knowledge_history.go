@@ -0,0 +1,65 @@
+package basic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/spf13/cobra"
+)
+
+// checksumWidth is how much of a version's checksum the history shows.
+const checksumWidth = 12
+
+func (cmd *knowledgeCommand) historyCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "history <knowledge_base_name> <source_id>",
+		Short: "Show the version history of an ingested source",
+		Long: "List every ingest of a source, oldest first: its checksum and chunk count,\n" +
+			"when it ran, and what ran it (ingest, batch, sitemap, refresh, retry, or the\n" +
+			"daemon) as which user. Versions replaced with `ingest --force --keep-versions`\n" +
+			"show the source ID their chunks are kept under.",
+		Args: cobra.ExactArgs(2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			knowledgeBaseName, sourceID := args[0], args[1]
+
+			if daemonClient(cmd.Context) != nil {
+				return fmt.Errorf("history is not supported over the ragd daemon yet; run without the daemon to show source history")
+			}
+
+			client, err := cmd.opensearchClient()
+			if err != nil {
+				return err
+			}
+			meta, err := client.GetSourceMetadata(context.Background(), sourceID)
+			if err != nil {
+				return err
+			}
+			if meta.IndexName != knowledge.FullIndexName(knowledgeBaseName) {
+				return fmt.Errorf("source '%s' does not belong to knowledge base '%s'", sourceID, knowledgeBaseName)
+			}
+
+			fmt.Printf("%-8s %-20s %-8s %-*s %-10s %-12s %s\n", "VERSION", "INGESTED AT", "CHUNKS", checksumWidth, "CHECKSUM", "TRIGGER", "USER", "KEPT AS")
+			for _, v := range meta.History() {
+				trigger, user := v.Trigger, v.User
+				if trigger == "" {
+					trigger = "-"
+				}
+				if user == "" {
+					user = "-"
+				}
+				fmt.Printf("%-8d %-20s %-8d %-*s %-10s %-12s %s\n",
+					v.Version, v.IngestedAt, v.ChunkCount, checksumWidth, truncateChecksum(v.Checksum), trigger, user, v.ArchivedAs)
+			}
+			return nil
+		},
+	}
+}
+
+// truncateChecksum shortens a checksum to checksumWidth characters.
+func truncateChecksum(sum string) string {
+	if len(sum) > checksumWidth {
+		return sum[:checksumWidth]
+	}
+	return sum
+}
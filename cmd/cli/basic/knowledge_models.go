@@ -5,9 +5,9 @@ import (
 	"fmt"
 	"sort"
 
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
 	"github.com/jpnorenam/rag-snap/cmd/cli/common"
 	"github.com/jpnorenam/rag-snap/internal/apiclient"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
 	"github.com/spf13/cobra"
 )
 
@@ -27,16 +27,13 @@ func (cmd *knowledgeCommand) modelsCommand() *cobra.Command {
 			"and 'models prune' removes them.",
 		Args: cobra.NoArgs,
 		RunE: func(_ *cobra.Command, _ []string) error {
-			models, err := cmd.engineModels(context.Background())
-			if err != nil {
-				return err
-			}
-			printModelInventory(models)
-			return nil
+			return cmd.listModels()
 		},
 	}
 
 	cobraCmd.AddCommand(
+		cmd.modelsListCommand(),
+		cmd.modelsUndeployCommand(),
 		cmd.modelsPruneCommand(),
 		cmd.modelsRemoveCommand(),
 	)
@@ -44,6 +41,54 @@ func (cmd *knowledgeCommand) modelsCommand() *cobra.Command {
 	return cobraCmd
 }
 
+func (cmd *knowledgeCommand) modelsListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the engine's models",
+		Long: "List the models registered in the knowledge engine's model group, with their\n" +
+			"deployment state, size, and the engine role they serve. Same as 'knowledge models'.",
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return cmd.listModels()
+		},
+	}
+}
+
+func (cmd *knowledgeCommand) listModels() error {
+	models, err := cmd.engineModels(context.Background())
+	if err != nil {
+		return err
+	}
+	printModelInventory(models)
+	return nil
+}
+
+func (cmd *knowledgeCommand) modelsUndeployCommand() *cobra.Command {
+	var force bool
+
+	cobraCmd := &cobra.Command{
+		Use:   "undeploy <model_id>",
+		Short: "Free a model's memory without deleting it",
+		Long: "Release a model from the ML nodes' memory, keeping it registered so it can be\n" +
+			"deployed again without re-downloading it.\n" +
+			"A model the engine currently uses is refused unless --force is given:\n" +
+			"ingest and search fail until 'knowledge init' deploys it again.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			id := args[0]
+			if err := cmd.undeployEngineModel(context.Background(), id, force); err != nil {
+				return err
+			}
+			fmt.Printf("Undeployed %s.\n", id)
+			return nil
+		},
+	}
+
+	cobraCmd.Flags().BoolVarP(&force, "force", "f", false, "Undeploy the model even if the engine uses it")
+
+	return cobraCmd
+}
+
 func (cmd *knowledgeCommand) modelsPruneCommand() *cobra.Command {
 	var yes bool
 
@@ -97,8 +142,9 @@ func (cmd *knowledgeCommand) modelsRemoveCommand() *cobra.Command {
 	var force bool
 
 	cobraCmd := &cobra.Command{
-		Use:   "remove <model_id>",
-		Short: "Undeploy and delete a single model",
+		Use:     "remove <model_id>",
+		Aliases: []string{"delete"},
+		Short:   "Undeploy and delete a single model",
 		Long: "Free a model's memory on the ML nodes and delete it.\n" +
 			"A model the engine currently uses is refused unless --force is given:\n" +
 			"removing it breaks ingest and search until 'knowledge init' runs again.",
@@ -169,6 +215,28 @@ func (cmd *knowledgeCommand) removeEngineModel(ctx context.Context, id string, f
 	return client.DeleteModel(ctx, id)
 }
 
+// undeployEngineModel undeploys a model through the daemon when one is running,
+// or straight from OpenSearch otherwise, with the same in-use guard as
+// removeEngineModel.
+func (cmd *knowledgeCommand) undeployEngineModel(ctx context.Context, id string, force bool) error {
+	if dc := daemonClient(cmd.Context); dc != nil {
+		return dc.UndeployEngineModel(ctx, id, force)
+	}
+
+	client, err := cmd.opensearchClient()
+	if err != nil {
+		return err
+	}
+	embedding, _ := getConfigString(cmd.Context, knowledge.ConfEmbeddingModelID)
+	rerank, _ := getConfigString(cmd.Context, knowledge.ConfRerankModelID)
+
+	if role := knowledge.ModelRole(id, embedding, rerank); role != "" && !force {
+		return fmt.Errorf("model %s is the engine's %s model; pass --force to undeploy it anyway", id, role)
+	}
+
+	return client.UndeployModel(ctx, id)
+}
+
 // sortedModels orders the inventory so the models in use come first, then strays
 // by name — the reading order of "what am I using, and what is left over".
 func sortedModels(models []knowledge.ModelInfo) []knowledge.ModelInfo {
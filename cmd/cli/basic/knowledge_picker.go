@@ -0,0 +1,120 @@
+package basic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/charmbracelet/huh"
+	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+)
+
+// knowledgeBaseChoice is one knowledge base offered by the picker.
+type knowledgeBaseChoice struct {
+	name      string
+	docsCount string
+	storeSize string
+}
+
+// defaultKnowledgeBase is the base used when none is given and none is picked.
+func defaultKnowledgeBase() string {
+	name, _ := knowledge.KnowledgeBaseNameFromIndex(knowledge.DefaultIndexName())
+	return name
+}
+
+// knowledgeBaseChoices lists the knowledge bases with their document counts,
+// from the daemon when one is running and from OpenSearch otherwise.
+func (cmd *knowledgeCommand) knowledgeBaseChoices(ctx context.Context) ([]knowledgeBaseChoice, error) {
+	var choices []knowledgeBaseChoice
+	if dc := daemonClient(cmd.Context); dc != nil {
+		bases, err := dc.ListKnowledge(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range bases {
+			choices = append(choices, knowledgeBaseChoice{name: b.Name, docsCount: b.DocsCount, storeSize: b.StoreSize})
+		}
+		return choices, nil
+	}
+
+	// Not cmd.opensearchClient: the command connects again for its real work
+	// and prints the cluster notice then.
+	url, err := cmd.opensearchURL()
+	if err != nil {
+		return nil, err
+	}
+	client, err := newKnowledgeClient(cmd.Context, url)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := client.ListIndexes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, idx := range indexes {
+		name, err := knowledge.KnowledgeBaseNameFromIndex(idx.Name)
+		if err != nil {
+			continue
+		}
+		choices = append(choices, knowledgeBaseChoice{name: name, docsCount: idx.DocsCount, storeSize: idx.StoreSize})
+	}
+	return choices, nil
+}
+
+// pickKnowledgeBases asks the user which knowledge bases to use when none were
+// given on the command line: a multi-select when multi is set, otherwise a
+// single choice. Outside a terminal, or when there is nothing to choose
+// between, it returns the default base without prompting.
+func (cmd *knowledgeCommand) pickKnowledgeBases(title string, multi bool) ([]string, error) {
+	if !common.CanPrompt() {
+		return []string{defaultKnowledgeBase()}, nil
+	}
+
+	stop := common.StartProgressSpinner("Fetching knowledge bases")
+	choices, err := cmd.knowledgeBaseChoices(context.Background())
+	stop()
+	if err != nil {
+		return nil, fmt.Errorf("listing knowledge bases: %w", err)
+	}
+	switch len(choices) {
+	case 0:
+		return []string{defaultKnowledgeBase()}, nil
+	case 1:
+		return []string{choices[0].name}, nil
+	}
+
+	options := make([]huh.Option[string], len(choices))
+	for i, c := range choices {
+		label := fmt.Sprintf("%s (%s docs, %s)", c.name, c.docsCount, c.storeSize)
+		options[i] = huh.NewOption(label, c.name)
+	}
+
+	if multi {
+		selected := []string{defaultKnowledgeBase()}
+		form := huh.NewForm(huh.NewGroup(
+			huh.NewMultiSelect[string]().
+				Title(title).
+				Options(options...).
+				Value(&selected),
+		))
+		if err := form.Run(); err != nil {
+			return nil, fmt.Errorf("selection cancelled: %w", err)
+		}
+		if len(selected) == 0 {
+			return nil, fmt.Errorf("no knowledge base selected")
+		}
+		return selected, nil
+	}
+
+	selected := defaultKnowledgeBase()
+	form := huh.NewForm(huh.NewGroup(
+		huh.NewSelect[string]().
+			Title(title).
+			Options(options...).
+			Value(&selected),
+	))
+	if err := form.Run(); err != nil {
+		return nil, fmt.Errorf("selection cancelled: %w", err)
+	}
+	return []string{selected}, nil
+}
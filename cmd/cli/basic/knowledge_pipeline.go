@@ -0,0 +1,186 @@
+package basic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/spf13/cobra"
+)
+
+// errPipelineOverDaemon is returned by the pipeline subcommands when a daemon
+// is running: the daemon's API does not expose index pipelines yet.
+var errPipelineOverDaemon = errors.New("knowledge base pipelines are not supported over the ragd daemon yet; stop the daemon to manage them directly")
+
+func (cmd *knowledgeCommand) pipelineCommand() *cobra.Command {
+	cobraCmd := &cobra.Command{
+		Use:   "pipeline",
+		Short: "Show or switch the pipelines a knowledge base runs",
+		Long: "Manage the ingest and search pipelines attached to each knowledge base.\n" +
+			"A base runs rag-snap's pipelines unless its index.default_pipeline or\n" +
+			"index.search.default_pipeline setting names another one. The listings flag\n" +
+			"pipelines that are missing or whose models no longer match the engine's;\n" +
+			"'knowledge init' repairs rag-snap's own.",
+	}
+
+	cobraCmd.AddCommand(cmd.pipelineListCommand(), cmd.pipelineShowCommand(), cmd.pipelineSetCommand())
+
+	return cobraCmd
+}
+
+func (cmd *knowledgeCommand) pipelineListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the pipelines of every knowledge base",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if daemonClient(cmd.Context) != nil {
+				return errPipelineOverDaemon
+			}
+			client, err := cmd.opensearchClient()
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+			list, err := client.ListIndexPipelines(ctx)
+			if err != nil {
+				return err
+			}
+			if len(list) == 0 {
+				fmt.Println("No knowledge base indexes found.")
+				return nil
+			}
+
+			embedding, rerank := cmd.engineModelIDs()
+			fmt.Printf("%-30s %-30s %-30s %s\n", "KNOWLEDGE BASE", "INGEST PIPELINE", "SEARCH PIPELINE", "STATUS")
+			for _, p := range list {
+				ingest, search, err := client.CheckIndexPipelines(ctx, p, embedding, rerank)
+				if err != nil {
+					return err
+				}
+				name, _ := knowledge.KnowledgeBaseNameFromIndex(p.Index)
+				fmt.Printf("%-30s %-30s %-30s %s\n", name, ingest.Name, search.Name, pipelinesStatus(ingest, search))
+			}
+			return nil
+		},
+	}
+}
+
+func (cmd *knowledgeCommand) pipelineShowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <knowledge_base_name>",
+		Short: "Show the pipelines a knowledge base runs",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if daemonClient(cmd.Context) != nil {
+				return errPipelineOverDaemon
+			}
+			client, err := cmd.opensearchClient()
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+			indexName := knowledge.FullIndexName(args[0])
+			if _, _, err := client.GetDefaultLabel(ctx, indexName); err != nil {
+				return knowledgeBaseError(args[0], err)
+			}
+			p, err := client.GetIndexPipelines(ctx, indexName)
+			if err != nil {
+				return err
+			}
+			embedding, rerank := cmd.engineModelIDs()
+			ingest, search, err := client.CheckIndexPipelines(ctx, p, embedding, rerank)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Ingest pipeline: %s (%s)\n", ingest.Name, ingest.Status())
+			fmt.Printf("Search pipeline: %s (%s)\n", search.Name, search.Status())
+			if (ingest.Problem != "" && !ingest.Custom) || (search.Problem != "" && !search.Custom) {
+				fmt.Println("\nRun 'knowledge init' to restore rag-snap's pipelines.")
+			}
+			return nil
+		},
+	}
+}
+
+func (cmd *knowledgeCommand) pipelineSetCommand() *cobra.Command {
+	var ingest, search string
+	var reset bool
+
+	cobraCmd := &cobra.Command{
+		Use:   "set <knowledge_base_name>",
+		Short: "Point a knowledge base at other pipelines",
+		Long: "Attach an existing ingest and/or search pipeline to a knowledge base, in place\n" +
+			"of rag-snap's. Ingest and search on the base then run through them.\n" +
+			"Pass \"default\" to --ingest or --search, or use --reset for both, to go back\n" +
+			"to rag-snap's pipelines. A custom ingest pipeline must still fill the\n" +
+			"embedding field, or the base's chunks cannot be found by meaning.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if reset && (ingest != "" || search != "") {
+				return fmt.Errorf("--reset cannot be combined with --ingest or --search")
+			}
+			if reset {
+				ingest, search = knowledge.PipelineDefault, knowledge.PipelineDefault
+			}
+			if ingest == "" && search == "" {
+				return fmt.Errorf("give --ingest and/or --search, or --reset to use rag-snap's pipelines")
+			}
+			if daemonClient(cmd.Context) != nil {
+				return errPipelineOverDaemon
+			}
+
+			client, err := cmd.opensearchClient()
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+			indexName := knowledge.FullIndexName(args[0])
+			if _, _, err := client.GetDefaultLabel(ctx, indexName); err != nil {
+				return knowledgeBaseError(args[0], err)
+			}
+			if err := client.SetIndexPipelines(ctx, indexName, ingest, search); err != nil {
+				return err
+			}
+
+			p, err := client.GetIndexPipelines(ctx, indexName)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Pipelines of '%s' set: ingest %s, search %s.\n", args[0], p.EffectiveIngest(), p.EffectiveSearch())
+			return nil
+		},
+	}
+
+	cobraCmd.Flags().StringVar(&ingest, "ingest", "", "Ingest pipeline to attach, or \"default\" for rag-snap's")
+	cobraCmd.Flags().StringVar(&search, "search", "", "Search pipeline to attach, or \"default\" for rag-snap's")
+	cobraCmd.Flags().BoolVar(&reset, "reset", false, "Go back to rag-snap's ingest and search pipelines")
+
+	return cobraCmd
+}
+
+// engineModelIDs returns the configured embedding and rerank model ids the
+// rag-snap pipelines are expected to use; empty when not configured.
+func (cmd *knowledgeCommand) engineModelIDs() (embedding, rerank string) {
+	embedding, _ = getConfigString(cmd.Context, knowledge.ConfEmbeddingModelID)
+	rerank, _ = getConfigString(cmd.Context, knowledge.ConfRerankModelID)
+	return embedding, rerank
+}
+
+// pipelinesStatus summarizes a base's two pipeline checks for the listing.
+func pipelinesStatus(ingest, search knowledge.PipelineCheck) string {
+	switch {
+	case ingest.Problem != "" && search.Problem != "":
+		return fmt.Sprintf("ingest: %s; search: %s", ingest.Problem, search.Problem)
+	case ingest.Problem != "":
+		return "ingest: " + ingest.Problem
+	case search.Problem != "":
+		return "search: " + search.Problem
+	case ingest.Custom || search.Custom:
+		return "custom"
+	default:
+		return "ok"
+	}
+}
@@ -0,0 +1,158 @@
+package basic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/spf13/cobra"
+)
+
+// errPolicyOverDaemon is returned by the policy subcommands when a daemon is
+// running: the daemon's API does not expose retention policies yet.
+var errPolicyOverDaemon = errors.New("retention policies are not supported over the ragd daemon yet; stop the daemon to manage them directly")
+
+func (cmd *knowledgeCommand) policyCommand() *cobra.Command {
+	cobraCmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Show or set a knowledge base's retention policy",
+		Long: "Manage the retention policy of a knowledge base.\n" +
+			"A policy bounds how long sources are kept (--retain) and/or how large the base\n" +
+			"may grow (--max-size). Sources past a limit are forgotten, chunks, archived\n" +
+			"versions and metadata alike, oldest first; the rest of the base is kept.\n" +
+			"The ragd daemon applies policies hourly; without it, run 'policy apply'.",
+	}
+
+	cobraCmd.AddCommand(cmd.policyShowCommand(), cmd.policySetCommand(), cmd.policyApplyCommand())
+
+	return cobraCmd
+}
+
+func (cmd *knowledgeCommand) policyShowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <knowledge_base_name>",
+		Short: "Show a knowledge base's retention policy",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if daemonClient(cmd.Context) != nil {
+				return errPolicyOverDaemon
+			}
+			client, err := cmd.opensearchClient()
+			if err != nil {
+				return err
+			}
+			policy, err := client.GetRetentionPolicy(context.Background(), knowledge.FullIndexName(args[0]))
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Retention policy: %s\n", policy)
+			return nil
+		},
+	}
+}
+
+func (cmd *knowledgeCommand) policySetCommand() *cobra.Command {
+	var retain, maxSize string
+	var clearPolicy bool
+
+	cobraCmd := &cobra.Command{
+		Use:   "set <knowledge_base_name>",
+		Short: "Set or clear a knowledge base's retention policy",
+		Long: "Replace the retention policy of a knowledge base with the given limits.\n" +
+			"Limits not given are unbounded; use --clear to remove the policy entirely.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			policy, err := knowledge.ParseRetentionPolicy(retain, maxSize)
+			if err != nil {
+				return err
+			}
+			if clearPolicy && !policy.IsZero() {
+				return fmt.Errorf("--clear cannot be combined with --retain or --max-size")
+			}
+			if !clearPolicy && policy.IsZero() {
+				return fmt.Errorf("give --retain and/or --max-size, or --clear to remove the policy")
+			}
+			if daemonClient(cmd.Context) != nil {
+				return errPolicyOverDaemon
+			}
+
+			client, err := cmd.opensearchClient()
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+			indexName := knowledge.FullIndexName(args[0])
+			if _, _, err := client.GetDefaultLabel(ctx, indexName); err != nil {
+				return knowledgeBaseError(args[0], err)
+			}
+			if err := client.SetRetentionPolicy(ctx, indexName, policy); err != nil {
+				return err
+			}
+
+			if clearPolicy {
+				fmt.Printf("Removed the retention policy of '%s'.\n", args[0])
+			} else {
+				fmt.Printf("Retention policy of '%s' set: %s.\n", args[0], policy)
+			}
+			return nil
+		},
+	}
+
+	cobraCmd.Flags().StringVar(&retain, "retain", "", "Forget sources not ingested or updated for this long (e.g. 90d, 12h)")
+	cobraCmd.Flags().StringVar(&maxSize, "max-size", "", "Forget the oldest sources while the primary store exceeds this size (e.g. 500m, 5g)")
+	cobraCmd.Flags().BoolVar(&clearPolicy, "clear", false, "Remove the retention policy")
+
+	return cobraCmd
+}
+
+func (cmd *knowledgeCommand) policyApplyCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "apply [knowledge_base_name]",
+		Short: "Forget the sources past a retention policy's limits now",
+		Long: "Apply the retention policy of a knowledge base, or of every base when none is\n" +
+			"named: forget the sources not ingested or updated within --retain, then the\n" +
+			"oldest sources while the base exceeds --max-size. The ragd daemon does this\n" +
+			"hourly on its own.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if daemonClient(cmd.Context) != nil {
+				return errors.New("the ragd daemon applies retention policies hourly; stop it to apply them directly")
+			}
+			client, err := cmd.opensearchClient()
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+
+			var results map[string]knowledge.RetentionResult
+			if len(args) == 1 {
+				indexName := knowledge.FullIndexName(args[0])
+				if _, _, err := client.GetDefaultLabel(ctx, indexName); err != nil {
+					return knowledgeBaseError(args[0], err)
+				}
+				result, err := client.EnforceRetention(ctx, indexName, time.Now())
+				if err != nil {
+					return err
+				}
+				results = map[string]knowledge.RetentionResult{indexName: result}
+			} else if results, err = client.EnforceRetentionAll(ctx, time.Now()); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+
+			forgotten := 0
+			for indexName, result := range results {
+				base, _ := knowledge.KnowledgeBaseNameFromIndex(indexName)
+				for _, sourceID := range result.Sources {
+					fmt.Printf("Forgot %s from '%s'.\n", sourceID, base)
+				}
+				forgotten += len(result.Sources)
+			}
+			if forgotten == 0 {
+				fmt.Println("No source is past a retention limit.")
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,256 @@
+package basic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/spf13/cobra"
+)
+
+// errQueueOverDaemon is returned by knowledge queue and knowledge worker when
+// a daemon is running: the daemon runs ingests as operations of its own and
+// does not serve the queue.
+var errQueueOverDaemon = errors.New("the ingest queue is not supported over the ragd daemon yet; stop the daemon to queue ingests")
+
+func (cmd *knowledgeCommand) queueCommand() *cobra.Command {
+	cobraCmd := &cobra.Command{
+		Use:   "queue",
+		Short: "Queue ingests for a background worker",
+		Long: "Queue ingest jobs for 'knowledge worker' to run in the background, so a\n" +
+			"large batch does not tie up an interactive shell. The queue is kept in\n" +
+			"OpenSearch, so it survives restarts and several workers can share it.\n" +
+			"Run the worker as a service with 'sudo snap start rag-cli.ingest-worker'.",
+	}
+
+	cobraCmd.AddCommand(
+		cmd.queueAddCommand(),
+		cmd.queueListCommand(),
+		cmd.queueCancelCommand(),
+	)
+
+	return cobraCmd
+}
+
+func (cmd *knowledgeCommand) queueAddCommand() *cobra.Command {
+	var fileFlag, urlFlag, batchFlag, labelFlag string
+	var metadataFlags []string
+	var forceFlag, createMissingFlag bool
+
+	cobraCmd := &cobra.Command{
+		Use:   "add [<knowledge_base_name> <source_id>]",
+		Short: "Queue a document or a batch file for ingestion",
+		Long: "Queue a document given by --file or --url, or every job of a --batch YAML\n" +
+			"file, for the worker to ingest. Jobs are validated as 'knowledge ingest'\n" +
+			"validates them, so a bad job is refused now rather than failing later.\n" +
+			"Files are queued by absolute path and must stay readable by the worker.",
+		Args: cobra.RangeArgs(0, 2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if daemonClient(cmd.Context) != nil {
+				return errQueueOverDaemon
+			}
+			tags, err := knowledge.ParseTags(metadataFlags)
+			if err != nil {
+				return err
+			}
+			if labelFlag != "" {
+				if err := knowledge.ValidateLabel(labelFlag); err != nil {
+					return err
+				}
+			}
+
+			var jobs []knowledge.BatchJob
+			if batchFlag != "" {
+				if len(args) != 0 || fileFlag != "" || urlFlag != "" {
+					return fmt.Errorf("--batch cannot be combined with a source")
+				}
+				if labelFlag != "" || len(tags) > 0 {
+					return fmt.Errorf("--label and --metadata are not allowed with --batch; set them per job in the YAML file")
+				}
+				batchCfg, err := knowledge.ReadBatchFile(batchFlag)
+				if err != nil {
+					return err
+				}
+				jobs = batchCfg.Jobs
+			} else {
+				if len(args) != 2 {
+					return fmt.Errorf("requires <knowledge_base_name> <source_id>, or use --batch <config.yaml>")
+				}
+				if (fileFlag == "") == (urlFlag == "") {
+					return fmt.Errorf("exactly one of --file or --url must be specified")
+				}
+				job := knowledge.BatchJob{
+					Name:     args[1],
+					Type:     "file",
+					Source:   fileFlag,
+					TargetKB: args[0],
+					Label:    labelFlag,
+					Metadata: tags,
+				}
+				if urlFlag != "" {
+					job.Type, job.Source = "url", urlFlag
+				}
+				jobs = []knowledge.BatchJob{job}
+			}
+
+			client, err := cmd.opensearchClient()
+			if err != nil {
+				return err
+			}
+			queued, err := client.QueueJobs(context.Background(), jobs, knowledge.BatchOptions{
+				Force:         forceFlag,
+				CreateMissing: createMissingFlag,
+			})
+			for _, job := range queued {
+				fmt.Printf("Queued job %s: %s\n", job.ID, job.Job.Source)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Println("Run 'knowledge queue list' to follow them.")
+			return nil
+		},
+	}
+
+	cobraCmd.Flags().StringVarP(&fileFlag, "file", "f", "", "Local file path to ingest")
+	cobraCmd.Flags().StringVarP(&urlFlag, "url", "u", "", "URL to download and ingest")
+	cobraCmd.Flags().StringVarP(&batchFlag, "batch", "B", "", "YAML batch config file — queue every job in it")
+	cobraCmd.Flags().StringVarP(&labelFlag, "label", "l", "", "Knowledge label for this source (default: the base's default label)")
+	cobraCmd.Flags().StringArrayVarP(&metadataFlags, "metadata", "m", nil, "User-defined key=value tag for this source (repeatable)")
+	cobraCmd.Flags().BoolVar(&forceFlag, "force", false, "Re-ingest sources even if already present in the knowledge base")
+	cobraCmd.Flags().BoolVar(&createMissingFlag, "create-missing", false, "With --batch, create target knowledge bases that do not exist yet")
+
+	return cobraCmd
+}
+
+func (cmd *knowledgeCommand) queueListCommand() *cobra.Command {
+	var statuses []string
+
+	cobraCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List queued ingest jobs",
+		Long:  "List the jobs in the ingest queue, oldest first, with the error of each failed job.",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if daemonClient(cmd.Context) != nil {
+				return errQueueOverDaemon
+			}
+			client, err := cmd.opensearchClient()
+			if err != nil {
+				return err
+			}
+			jobs, err := client.ListQueuedJobs(context.Background(), statuses...)
+			if err != nil {
+				return err
+			}
+			if len(jobs) == 0 {
+				fmt.Println("No queued jobs.")
+				return nil
+			}
+
+			fmt.Printf("%-10s %-10s %-20s %-20s %s\n", "ID", "STATUS", "QUEUED AT", "KNOWLEDGE BASE", "SOURCE")
+			for _, job := range jobs {
+				kb := job.Job.TargetKB
+				if kb == "" {
+					kb, _ = knowledge.KnowledgeBaseNameFromIndex(knowledge.DefaultIndexName())
+				}
+				fmt.Printf("%-10s %-10s %-20s %-20s %s\n", job.ID, job.Status, job.QueuedAt, kb, job.Job.Source)
+				if job.Error != "" {
+					fmt.Printf("%-10s %s\n", "", job.Error)
+				}
+			}
+			return nil
+		},
+	}
+
+	cobraCmd.Flags().StringSliceVarP(&statuses, "status", "s", nil, "Only list jobs in these states: queued, running, completed, failed, cancelled (comma-separated)")
+
+	return cobraCmd
+}
+
+func (cmd *knowledgeCommand) queueCancelCommand() *cobra.Command {
+	cobraCmd := &cobra.Command{
+		Use:   "cancel <job_id>...",
+		Short: "Cancel queued or running ingest jobs",
+		Long: "Cancel jobs that have not finished. A queued job is never started; a running\n" +
+			"job is stopped by its worker within a few seconds, and the chunks it had\n" +
+			"indexed of the source in progress are removed.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if daemonClient(cmd.Context) != nil {
+				return errQueueOverDaemon
+			}
+			client, err := cmd.opensearchClient()
+			if err != nil {
+				return err
+			}
+			var failed int
+			for _, id := range args {
+				job, err := client.CancelQueuedJob(context.Background(), id)
+				if err != nil {
+					fmt.Printf("❌ %v\n", err)
+					failed++
+					continue
+				}
+				fmt.Printf("Cancelled job %s: %s\n", job.ID, job.Job.Source)
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d jobs could not be cancelled", failed, len(args))
+			}
+			return nil
+		},
+	}
+
+	return cobraCmd
+}
+
+func (cmd *knowledgeCommand) workerCommand() *cobra.Command {
+	var interval time.Duration
+	var once bool
+
+	cobraCmd := &cobra.Command{
+		Use:   "worker",
+		Short: "Ingest queued jobs in the background",
+		Long: "Take jobs from the ingest queue one at a time, oldest first, and ingest each\n" +
+			"as 'knowledge ingest --batch' would, waiting for more when the queue is\n" +
+			"empty. Stopping the worker puts the job in progress back in the queue.\n" +
+			"The snap runs it as the ingest-worker service; use --once to drain the\n" +
+			"queue from a shell instead.",
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if daemonClient(cmd.Context) != nil {
+				return errQueueOverDaemon
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			apiUrls, err := serverApiUrls(cmd.Context)
+			if err != nil {
+				return fmt.Errorf("getting server API URLs: %w", err)
+			}
+			client, err := cmd.opensearchClient()
+			if err != nil {
+				return err
+			}
+			lowerIngestPriority(client)
+			tikaClient, err := newTikaClient(cmd.Context, apiUrls[tika])
+			if err != nil {
+				return err
+			}
+			return knowledge.RunWorker(ctx, client, tikaClient, knowledge.WorkerOptions{
+				Interval: interval,
+				Once:     once,
+			})
+		},
+	}
+
+	cobraCmd.Flags().DurationVar(&interval, "interval", knowledge.DefaultWorkerInterval, "How long to wait before looking at an empty queue again")
+	cobraCmd.Flags().BoolVar(&once, "once", false, "Exit once the queue is empty instead of waiting for more jobs")
+
+	return cobraCmd
+}
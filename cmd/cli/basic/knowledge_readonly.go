@@ -0,0 +1,60 @@
+package basic
+
+import (
+	"fmt"
+
+	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/spf13/cobra"
+)
+
+// mutatingKnowledgeCommands lists the knowledge subcommands that change the
+// cluster, by path below knowledge, with whether a given invocation does. They
+// are refused while knowledge.read-only is set.
+var mutatingKnowledgeCommands = map[string]func(args []string) bool{
+	"init":            alwaysMutates,
+	"models undeploy": alwaysMutates,
+	"models prune":    alwaysMutates,
+	"models remove":   alwaysMutates,
+	"create":          alwaysMutates,
+	"label":           func(args []string) bool { return len(args) == 2 },
+	"policy set":      alwaysMutates,
+	"policy apply":    alwaysMutates,
+	"pipeline set":    alwaysMutates,
+	"reindex":         alwaysMutates,
+	"tune":            alwaysMutates, // re-chunks into temporary indexes
+	"ingest":          alwaysMutates,
+	"refresh":         alwaysMutates,
+	"retry":           alwaysMutates,
+	"queue add":       alwaysMutates,
+	"queue cancel":    alwaysMutates,
+	"worker":          alwaysMutates,
+	"forget":          alwaysMutates,
+	"metadata set":    alwaysMutates,
+	"delete":          alwaysMutates,
+	"reset":           alwaysMutates,
+	"import":          alwaysMutates,
+}
+
+func alwaysMutates([]string) bool { return true }
+
+// guardReadOnly makes the mutating subcommands below parent refuse to run
+// while knowledge.read-only is set, so a deployment can offer list, search,
+// and metadata without risking its knowledge bases.
+func guardReadOnly(ctx *common.Context, parent *cobra.Command, prefix string) {
+	for _, sub := range parent.Commands() {
+		path := prefix + sub.Name()
+		guardReadOnly(ctx, sub, path+" ")
+
+		mutates, ok := mutatingKnowledgeCommands[path]
+		if !ok || sub.RunE == nil {
+			continue
+		}
+		run := sub.RunE
+		sub.RunE = func(c *cobra.Command, args []string) error {
+			if mutates(args) && getConfigBool(ctx, confKnowledgeReadOnly, false) {
+				return fmt.Errorf("knowledge %s is disabled: knowledge bases are read-only (%s is set)", path, confKnowledgeReadOnly)
+			}
+			return run(c, args)
+		}
+	}
+}
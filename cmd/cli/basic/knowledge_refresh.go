@@ -0,0 +1,96 @@
+package basic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/spf13/cobra"
+)
+
+// errRefreshOverDaemon is returned by knowledge refresh when a daemon is
+// running: the daemon's API has no refresh endpoint yet.
+var errRefreshOverDaemon = errors.New("refreshing sources is not supported over the ragd daemon yet; stop the daemon to refresh directly")
+
+func (cmd *knowledgeCommand) refreshCommand() *cobra.Command {
+	cobraCmd := &cobra.Command{
+		Use:   "refresh <knowledge_base_name> [source_id]",
+		Short: "Re-crawl URL sources and re-ingest the ones that changed",
+		Long: "Re-fetch the pages of a knowledge base's URL sources, or of one source, and\n" +
+			"re-ingest only the ones whose content changed. Pages are requested with the\n" +
+			"ETag and Last-Modified they were served with, so servers that honour them\n" +
+			"answer without resending unchanged pages; other pages are compared with the\n" +
+			"stored checksum. A changed source's new chunks replace its old ones in one\n" +
+			"swap, and it keeps its label, tags, and ingest time.",
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if daemonClient(cmd.Context) != nil {
+				return errRefreshOverDaemon
+			}
+			var sourceID string
+			if len(args) == 2 {
+				sourceID = args[1]
+			}
+
+			apiUrls, err := serverApiUrls(cmd.Context)
+			if err != nil {
+				return fmt.Errorf("getting server API URLs: %w", err)
+			}
+			client, err := cmd.opensearchClient()
+			if err != nil {
+				return err
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			indexName := knowledge.FullIndexName(args[0])
+			if _, _, err := client.GetDefaultLabel(ctx, indexName); err != nil {
+				return knowledgeBaseError(args[0], err)
+			}
+			sources, err := client.RefreshTargets(ctx, indexName, sourceID)
+			if err != nil {
+				return err
+			}
+			if len(sources) == 0 {
+				fmt.Printf("Knowledge base '%s' has no sources ingested from a URL\n", args[0])
+				return nil
+			}
+
+			tikaClient, err := newTikaClient(cmd.Context, apiUrls[tika])
+			if err != nil {
+				return err
+			}
+			var updated, unchanged, failed int
+			for i, meta := range sources {
+				fmt.Printf("[%d/%d] %s (%s)\n", i+1, len(sources), meta.SourceID, meta.FilePath)
+				result := client.RefreshSource(ctx, tikaClient, meta)
+				if ctx.Err() != nil {
+					return fmt.Errorf("refresh interrupted; source '%s' keeps its previous chunks", meta.SourceID)
+				}
+				switch result.Outcome {
+				case knowledge.RefreshUpdated:
+					updated++
+					fmt.Printf("  Updated: re-ingested %d chunks\n", result.Chunks)
+				case knowledge.RefreshUnchanged:
+					unchanged++
+					fmt.Printf("  Unchanged: %s\n", result.Reason)
+				default:
+					failed++
+					fmt.Printf("  ❌ Error: %v\n", result.Err)
+				}
+			}
+
+			fmt.Printf("\nRefreshed %d sources: %d updated, %d unchanged, %d failed\n", len(sources), updated, unchanged, failed)
+			if failed > 0 {
+				return fmt.Errorf("%d sources could not be refreshed", failed)
+			}
+			return nil
+		},
+	}
+
+	return cobraCmd
+}
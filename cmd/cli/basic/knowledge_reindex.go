@@ -0,0 +1,59 @@
+package basic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/spf13/cobra"
+)
+
+func (cmd *knowledgeCommand) reindexCommand() *cobra.Command {
+	var yes bool
+
+	cobraCmd := &cobra.Command{
+		Use:   "reindex <knowledge_base_name>",
+		Short: "Re-embed a knowledge base for the model its ingest pipeline runs",
+		Long: "Rebuild a knowledge base's index for the embedding dimension of the model in\n" +
+			"its ingest pipeline, re-embedding every chunk with that model. Run it after\n" +
+			"pointing a base at a pipeline whose model produces embeddings of another\n" +
+			"size, which 'knowledge ingest' otherwise refuses. The chunks are re-embedded\n" +
+			"into a temporary index before the base's index is replaced; searches of the\n" +
+			"base find nothing while it is being refilled.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if daemonClient(cmd.Context) != nil {
+				return fmt.Errorf("knowledge reindex is not supported over the ragd daemon yet; stop the daemon to reindex directly")
+			}
+			if !yes && !common.CanPrompt() {
+				return fmt.Errorf("knowledge reindex needs a terminal to confirm; pass --yes to reindex without asking")
+			}
+
+			client, err := cmd.opensearchClient()
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+			indexName := knowledge.FullIndexName(args[0])
+			if _, _, err := client.GetDefaultLabel(ctx, indexName); err != nil {
+				return knowledgeBaseError(args[0], err)
+			}
+			if !yes && !common.ConfirmationPrompt(fmt.Sprintf("Re-embed every chunk of '%s'?", args[0])) {
+				return fmt.Errorf("reindex aborted")
+			}
+
+			result, err := client.Reindex(ctx, indexName)
+			if err != nil {
+				return knowledgeBaseError(args[0], err)
+			}
+			fmt.Printf("Re-embedded %d chunk(s) of '%s' with model %s: %d → %d dimensions.\n",
+				result.Chunks, args[0], result.ModelID, result.FromDimension, result.ToDimension)
+			return nil
+		},
+	}
+
+	cobraCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Reindex without asking")
+
+	return cobraCmd
+}
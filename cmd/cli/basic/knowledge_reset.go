@@ -0,0 +1,137 @@
+package basic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/charmbracelet/huh"
+	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/spf13/cobra"
+)
+
+func (cmd *knowledgeCommand) resetCommand() *cobra.Command {
+	var (
+		models  bool
+		indexes bool
+		yes     bool
+	)
+
+	cobraCmd := &cobra.Command{
+		Use:   "reset --models",
+		Short: "Remove everything the snap created in OpenSearch",
+		Long: "Tear down what 'knowledge init' and the knowledge commands created in OpenSearch:\n" +
+			"the models (undeployed, then deleted), their model group, the ingest and search\n" +
+			"pipelines, the index template, and the ingest queue and chat memory indexes.\n" +
+			"Knowledge base indexes, and the source metadata index describing them, are only\n" +
+			"removed with --indexes.\n" +
+			"A checklist of what was found lets you leave items out before anything is removed.\n" +
+			"Run 'knowledge init' afterwards to set the engine up again.",
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if !models {
+				return fmt.Errorf("nothing to reset: pass --models to tear down the engine")
+			}
+			if dc := daemonClient(cmd.Context); dc != nil {
+				return fmt.Errorf("knowledge reset is not supported over the ragd daemon yet; stop the daemon to reset the engine directly")
+			}
+			if !yes && !common.CanPrompt() {
+				return fmt.Errorf("knowledge reset needs a terminal to confirm; pass --yes to reset without asking")
+			}
+
+			client, err := cmd.opensearchClient()
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+
+			stop := common.StartProgressSpinner("Looking for the snap's artifacts")
+			artifacts, err := client.Artifacts(ctx)
+			stop()
+			if err != nil {
+				return fmt.Errorf("listing artifacts: %w", err)
+			}
+			if len(artifacts) == 0 {
+				fmt.Println("Nothing to remove: the snap has no artifacts in OpenSearch.")
+				return nil
+			}
+
+			selected := knowledge.DefaultResetSelection(artifacts, indexes)
+			if !yes {
+				if selected, err = pickArtifacts(artifacts, selected); err != nil {
+					return err
+				}
+			}
+			if len(selected) == 0 {
+				fmt.Println("Nothing selected. Nothing removed.")
+				return nil
+			}
+
+			fmt.Printf("The following %d artifact(s) will be permanently removed:\n\n", len(selected))
+			for _, a := range selected {
+				fmt.Printf("  %s\n", a)
+			}
+			if !yes && !common.ConfirmationPrompt("\nRemove them?") {
+				return fmt.Errorf("reset aborted")
+			}
+			fmt.Println()
+
+			removedModels := false
+			for _, a := range selected {
+				if err := client.RemoveArtifact(ctx, a); err != nil {
+					return fmt.Errorf("removing %s: %w", a, err)
+				}
+				fmt.Printf("Removed %s.\n", a)
+				removedModels = removedModels || a.Kind == knowledge.ArtifactModel
+			}
+
+			if removedModels {
+				fmt.Printf("\n%s and %s now name deleted models.\n", knowledge.ConfEmbeddingModelID, knowledge.ConfRerankModelID)
+			}
+			fmt.Println("Run 'knowledge init' to set the engine up again.")
+			return nil
+		},
+	}
+
+	cobraCmd.Flags().BoolVar(&models, "models", false, "Remove the models, model group, pipelines, index template, ingest queue, and chat memory")
+	cobraCmd.Flags().BoolVar(&indexes, "indexes", false, "Also remove every knowledge base index and the source metadata")
+	cobraCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Remove the default selection without asking")
+
+	return cobraCmd
+}
+
+// pickArtifacts shows a checklist of artifacts with selected checked, and
+// returns what the user left checked, in the removal order of artifacts.
+func pickArtifacts(artifacts, selected []knowledge.Artifact) ([]knowledge.Artifact, error) {
+	options := make([]huh.Option[int], len(artifacts))
+	checked := map[knowledge.Artifact]bool{}
+	for _, a := range selected {
+		checked[a] = true
+	}
+	for i, a := range artifacts {
+		options[i] = huh.NewOption(a.String(), i).Selected(checked[a])
+	}
+
+	var picked []int
+	form := huh.NewForm(huh.NewGroup(
+		huh.NewMultiSelect[int]().
+			Title("Select what to remove").
+			Options(options...).
+			Value(&picked),
+	))
+	if err := form.Run(); err != nil {
+		return nil, fmt.Errorf("selection cancelled: %w", err)
+	}
+
+	keep := map[int]bool{}
+	for _, i := range picked {
+		keep[i] = true
+	}
+	var out []knowledge.Artifact
+	for i, a := range artifacts {
+		if keep[i] {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,98 @@
+package basic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/spf13/cobra"
+)
+
+// errRetryOverDaemon is returned by knowledge retry when a daemon is running:
+// the daemon's API has no retry endpoint yet.
+var errRetryOverDaemon = errors.New("retrying sources is not supported over the ragd daemon yet; stop the daemon to retry directly")
+
+func (cmd *knowledgeCommand) retryCommand() *cobra.Command {
+	var allFailed bool
+
+	cobraCmd := &cobra.Command{
+		Use:   "retry <knowledge_base_name> [source_id | --all-failed]",
+		Short: "Ingest failed sources again",
+		Long: "Ingest a knowledge base's failed source, or with --all-failed every failed\n" +
+			"source, again from the URL or file path its metadata recorded. Chunks a failed\n" +
+			"attempt left in the base are deleted first, and the source keeps its label and\n" +
+			"tags. Sources ingested from a repository or uploaded to the daemon record no\n" +
+			"path to ingest them from; ingest them again with --force instead.",
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			var sourceID string
+			if len(args) == 2 {
+				sourceID = args[1]
+			}
+			if (sourceID == "") == !allFailed {
+				return fmt.Errorf("pass either a source ID or --all-failed")
+			}
+			if daemonClient(cmd.Context) != nil {
+				return errRetryOverDaemon
+			}
+
+			apiUrls, err := serverApiUrls(cmd.Context)
+			if err != nil {
+				return fmt.Errorf("getting server API URLs: %w", err)
+			}
+			client, err := cmd.opensearchClient()
+			if err != nil {
+				return err
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			indexName := knowledge.FullIndexName(args[0])
+			if _, _, err := client.GetDefaultLabel(ctx, indexName); err != nil {
+				return knowledgeBaseError(args[0], err)
+			}
+			sources, err := client.RetryTargets(ctx, indexName, sourceID)
+			if err != nil {
+				return err
+			}
+			if len(sources) == 0 {
+				fmt.Printf("Knowledge base '%s' has no failed sources\n", args[0])
+				return nil
+			}
+
+			tikaClient, err := newTikaClient(cmd.Context, apiUrls[tika])
+			if err != nil {
+				return err
+			}
+			var failed int
+			for i, meta := range sources {
+				fmt.Printf("[%d/%d] %s (%s)\n", i+1, len(sources), meta.SourceID, meta.FilePath)
+				result := client.RetrySource(ctx, tikaClient, meta)
+				if ctx.Err() != nil {
+					client.AbandonSource(meta.IndexName, meta.SourceID)
+					return fmt.Errorf("retry interrupted; source '%s' is still marked failed", meta.SourceID)
+				}
+				if result.Err != nil {
+					failed++
+					fmt.Printf("  ❌ Error: %v\n", result.Err)
+					continue
+				}
+				fmt.Printf("  Ingested %d chunks\n", result.Chunks)
+			}
+
+			fmt.Printf("\nRetried %d sources: %d completed, %d failed\n", len(sources), len(sources)-failed, failed)
+			if failed > 0 {
+				return fmt.Errorf("%d sources could not be ingested", failed)
+			}
+			return nil
+		},
+	}
+
+	cobraCmd.Flags().BoolVar(&allFailed, "all-failed", false, "Retry every failed source of the knowledge base")
+
+	return cobraCmd
+}
@@ -0,0 +1,181 @@
+package basic
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+func (cmd *knowledgeCommand) savedSearchCommand() *cobra.Command {
+	cobraCmd := &cobra.Command{
+		Use:   "saved-search",
+		Short: "Store and replay named searches",
+		Long: "Store a knowledge base query, the bases it searches, and its metadata\n" +
+			"filters under a name, then replay it with 'saved-search run' or with\n" +
+			"/search <name> in chat. Saved searches live in the snap's config store, so\n" +
+			"creating and deleting them needs sudo; any user may list and run them.",
+	}
+
+	cobraCmd.AddCommand(
+		cmd.savedSearchCreateCommand(),
+		cmd.savedSearchRunCommand(),
+		cmd.savedSearchListCommand(),
+		cmd.savedSearchDeleteCommand(),
+	)
+
+	return cobraCmd
+}
+
+func (cmd *knowledgeCommand) savedSearchCreateCommand() *cobra.Command {
+	var query string
+	var bases, filters []string
+	var k int
+
+	cobraCmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Save a search under a name",
+		Long: "Save a search under a name, replacing any saved search of that name.\n" +
+			"Without --bases, running it asks for the bases to search like 'knowledge search'.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if query == "" {
+				return fmt.Errorf("--query is required")
+			}
+			if k < 0 {
+				return fmt.Errorf("--top cannot be negative")
+			}
+			if _, err := knowledge.ParseTags(filters); err != nil {
+				return err
+			}
+			search := storage.SavedSearch{Query: query, Bases: bases, Filters: filters, TopK: k}
+			if err := storage.NewSavedSearches().Save(args[0], search); err != nil {
+				return fmt.Errorf("saving search: %w", err)
+			}
+			fmt.Printf("Saved search '%s'. Run it with 'knowledge saved-search run %s'.\n", args[0], args[0])
+			return nil
+		},
+	}
+
+	cobraCmd.Flags().StringVar(&query, "query", "", "Query to run")
+	cobraCmd.Flags().StringSliceVarP(&bases, "bases", "b", nil, "Knowledge base name(s) to search (comma-separated string list)")
+	cobraCmd.Flags().StringArrayVarP(&filters, "filter", "f", nil, "Only match sources tagged key=value (repeatable; all must match)")
+	cobraCmd.Flags().IntVarP(&k, "top", "k", 0, "Number of results per index (default: as for 'knowledge search')")
+
+	return cobraCmd
+}
+
+func (cmd *knowledgeCommand) savedSearchRunCommand() *cobra.Command {
+	var output, out string
+
+	cobraCmd := &cobra.Command{
+		Use:   "run <name>",
+		Short: "Run a saved search",
+		Long:  "Run a saved search exactly as 'knowledge search' would run it with the saved flags.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			search, err := storage.NewSavedSearches().Get(args[0])
+			if errors.Is(err, storage.ErrorNotFound) {
+				return fmt.Errorf("no saved search named '%s'; see 'knowledge saved-search list'", args[0])
+			}
+			if err != nil {
+				return err
+			}
+
+			// Replay through the search command itself, so a saved search
+			// behaves as the same search typed out would.
+			searchCmd := cmd.searchCommand()
+			flags := searchCmd.Flags()
+			if len(search.Bases) > 0 {
+				if err := flags.Set("bases", strings.Join(search.Bases, ",")); err != nil {
+					return err
+				}
+			}
+			for _, f := range search.Filters {
+				if err := flags.Set("filter", f); err != nil {
+					return err
+				}
+			}
+			if search.TopK > 0 {
+				if err := flags.Set("top", strconv.Itoa(search.TopK)); err != nil {
+					return err
+				}
+			}
+			if err := flags.Set("output", output); err != nil {
+				return err
+			}
+			if err := flags.Set("out", out); err != nil {
+				return err
+			}
+			return searchCmd.RunE(searchCmd, []string{search.Query})
+		},
+	}
+
+	cobraCmd.Flags().StringVarP(&output, "output", "o", searchOutputText, "Output format: text, context for the block chat injects into the prompt, or csv, json, or ndjson to export the full hits")
+	cobraCmd.Flags().StringVar(&out, "out", "", "Write the --output csv, json, or ndjson export to this file instead of stdout")
+
+	return cobraCmd
+}
+
+func (cmd *knowledgeCommand) savedSearchListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List saved searches",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			searches, err := storage.NewSavedSearches().List()
+			if err != nil {
+				return err
+			}
+			if len(searches) == 0 {
+				fmt.Println("No saved searches. Create one with 'knowledge saved-search create <name> --query ...'.")
+				return nil
+			}
+
+			names := make([]string, 0, len(searches))
+			for name := range searches {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+
+			fmt.Printf("%-24s %-40s %-24s %s\n", "NAME", "QUERY", "BASES", "FILTERS")
+			for _, name := range names {
+				s := searches[name]
+				bases := strings.Join(s.Bases, ",")
+				if bases == "" {
+					bases = "-"
+				}
+				filters := strings.Join(s.Filters, " ")
+				if filters == "" {
+					filters = "-"
+				}
+				fmt.Printf("%-24s %-40s %-24s %s\n", name, strconv.Quote(s.Query), bases, filters)
+			}
+			return nil
+		},
+	}
+}
+
+func (cmd *knowledgeCommand) savedSearchDeleteCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <name>",
+		Short: "Delete a saved search",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			err := storage.NewSavedSearches().Delete(args[0])
+			if errors.Is(err, storage.ErrorNotFound) {
+				return fmt.Errorf("no saved search named '%s'", args[0])
+			}
+			if err != nil {
+				return fmt.Errorf("deleting search: %w", err)
+			}
+			fmt.Printf("Deleted saved search '%s'.\n", args[0])
+			return nil
+		},
+	}
+}
@@ -0,0 +1,149 @@
+package basic
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+)
+
+// knowledge search --output values that export full hits for post-processing.
+const (
+	searchOutputCSV    = "csv"
+	searchOutputJSON   = "json"
+	searchOutputNDJSON = "ndjson"
+)
+
+// searchCSVHeader names the columns of --output csv, in order.
+var searchCSVHeader = []string{"score", "index", "base", "source_id", "page", "label", "created_at", "tags", "content"}
+
+// isSearchExport reports whether output is a format searchExporter writes.
+func isSearchExport(output string) bool {
+	switch output {
+	case searchOutputCSV, searchOutputJSON, searchOutputNDJSON:
+		return true
+	}
+	return false
+}
+
+// searchExporter writes search hits in an export format: CSV with a header
+// row, a JSON array, or NDJSON. CSV and NDJSON stream one hit at a time; JSON
+// holds the hits until close.
+type searchExporter struct {
+	format string
+	csv    *csv.Writer
+	enc    *json.Encoder
+	hits   []knowledge.SearchHit
+	count  int
+}
+
+// newSearchExporter starts an export in format to w.
+func newSearchExporter(w io.Writer, format string) (*searchExporter, error) {
+	e := &searchExporter{format: format}
+	switch format {
+	case searchOutputCSV:
+		e.csv = csv.NewWriter(w)
+		if err := e.csv.Write(searchCSVHeader); err != nil {
+			return nil, err
+		}
+	case searchOutputJSON:
+		e.enc = json.NewEncoder(w)
+		e.enc.SetIndent("", "  ")
+		e.hits = []knowledge.SearchHit{}
+	case searchOutputNDJSON:
+		e.enc = json.NewEncoder(w)
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+	return e, nil
+}
+
+// write adds one hit to the export.
+func (e *searchExporter) write(hit knowledge.SearchHit) error {
+	e.count++
+	switch e.format {
+	case searchOutputCSV:
+		base, _ := knowledge.KnowledgeBaseNameFromIndex(hit.Index)
+		page := ""
+		if hit.Page > 0 {
+			page = strconv.Itoa(hit.Page)
+		}
+		return e.csv.Write([]string{
+			strconv.FormatFloat(hit.Score, 'f', -1, 64), hit.Index, base, hit.SourceID, page,
+			hit.Label, hit.CreatedAt, knowledge.FormatTags(hit.Tags), hit.Content,
+		})
+	case searchOutputJSON:
+		e.hits = append(e.hits, hit)
+		return nil
+	default:
+		return e.enc.Encode(hit)
+	}
+}
+
+// close finishes the export: it flushes CSV and writes the JSON array.
+func (e *searchExporter) close() error {
+	switch e.format {
+	case searchOutputCSV:
+		e.csv.Flush()
+		return e.csv.Error()
+	case searchOutputJSON:
+		return e.enc.Encode(e.hits)
+	}
+	return nil
+}
+
+// exportSearchHits writes hits to w in format.
+func exportSearchHits(w io.Writer, format string, hits []knowledge.SearchHit) error {
+	e, err := newSearchExporter(w, format)
+	if err != nil {
+		return err
+	}
+	for _, hit := range hits {
+		if err := e.write(hit); err != nil {
+			return err
+		}
+	}
+	return e.close()
+}
+
+// writeSearchExport writes hits in format to the file out, or to stdout when
+// out is empty.
+func writeSearchExport(out, format string, hits []knowledge.SearchHit) error {
+	w, closeOut, err := openSearchOutput(out)
+	if err != nil {
+		return err
+	}
+	return finishSearchOutput(out, len(hits), closeOut, exportSearchHits(w, format, hits))
+}
+
+// openSearchOutput opens the file an export goes to, or stdout when out is
+// empty. The returned func closes it.
+func openSearchOutput(out string) (io.Writer, func() error, error) {
+	if out == "" {
+		return os.Stdout, func() error { return nil }, nil
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating %s: %w", out, err)
+	}
+	return f, f.Close, nil
+}
+
+// finishSearchOutput closes an export opened with openSearchOutput, reporting
+// how many hits went to the file. An export that failed keeps its error.
+func finishSearchOutput(out string, count int, closeOut func() error, err error) error {
+	if closeErr := closeOut(); err == nil && closeErr != nil {
+		err = fmt.Errorf("writing %s: %w", out, closeErr)
+	}
+	if err != nil {
+		return err
+	}
+	if out != "" {
+		fmt.Printf("Wrote %d results to %s.\n", count, out)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package basic
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+)
+
+// Column widths of the sources table. Longer values are cut to fit unless the
+// table is printed --wide.
+const (
+	sourceIDWidth    = 50
+	sourceBaseWidth  = 30
+	sourceLabelWidth = 16
+)
+
+// sourceColumns are the widths of the sources table's text columns.
+type sourceColumns struct {
+	id, base, label int
+}
+
+// sourceTableColumns returns the column widths for rows: the fixed widths, or
+// with wide, as wide as the longest value of each column.
+func sourceTableColumns(rows []sourceRow, wide bool) sourceColumns {
+	cols := sourceColumns{id: sourceIDWidth, base: sourceBaseWidth, label: sourceLabelWidth}
+	if !wide {
+		return cols
+	}
+	for _, r := range rows {
+		cols.id = max(cols.id, utf8.RuneCountInString(r.SourceID))
+		cols.base = max(cols.base, utf8.RuneCountInString(r.Base))
+		cols.label = max(cols.label, utf8.RuneCountInString(r.Label))
+	}
+	return cols
+}
+
+// header renders the table's header line, followed by extra headings.
+func (c sourceColumns) header(extra ...string) string {
+	line := fmt.Sprintf("%-*s %-*s %-*s %-12s %-8s %-20s", c.id, "SOURCE ID", c.base, "KNOWLEDGE BASE", c.label, "LABEL", "STATUS", "CHUNKS", "INGESTED AT")
+	if len(extra) > 0 {
+		line += " " + strings.Join(extra, " ")
+	}
+	return line
+}
+
+// row renders r's line, with status already padded (and colored) by the
+// caller, followed by extra cells.
+func (c sourceColumns) row(r sourceRow, status string, extra ...string) string {
+	line := fmt.Sprintf("%-*s %-*s %-*s %s %-8d %-20s",
+		c.id, truncateCell(r.SourceID, c.id), c.base, truncateCell(r.Base, c.base), c.label, truncateCell(r.Label, c.label),
+		status, r.Chunks, r.IngestedAt)
+	if len(extra) > 0 {
+		line += " " + strings.Join(extra, " ")
+	}
+	return line
+}
+
+// truncateCell cuts s to width runes, ending it with "…" when it was longer.
+func truncateCell(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:width-1]) + "…"
+}
+
+// applySourceQuery filters, sorts, and limits rows listed without q, as the
+// daemon lists them, the way QuerySourceMetadata does in OpenSearch.
+func applySourceQuery(rows []sourceRow, q knowledge.SourceQuery) []sourceRow {
+	var out []sourceRow
+	for _, r := range rows {
+		if q.Matches(r.Status, r.IngestedAt) {
+			out = append(out, r)
+		}
+	}
+
+	switch q.Sort {
+	case knowledge.SourceSortIngestedAt:
+		// The ingest time format sorts as text.
+		slices.SortStableFunc(out, func(a, b sourceRow) int {
+			return strings.Compare(b.IngestedAt, a.IngestedAt)
+		})
+	case knowledge.SourceSortChunks:
+		slices.SortStableFunc(out, func(a, b sourceRow) int { return b.Chunks - a.Chunks })
+	case knowledge.SourceSortName:
+		slices.SortStableFunc(out, func(a, b sourceRow) int { return strings.Compare(a.SourceID, b.SourceID) })
+	}
+
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[:q.Limit]
+	}
+	return out
+}
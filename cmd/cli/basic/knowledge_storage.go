@@ -0,0 +1,99 @@
+package basic
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/jpnorenam/rag-snap/cmd/cli/config"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+)
+
+// baseRow is one knowledge base of the knowledge list table.
+type baseRow struct {
+	Name, Index, Health, Status, Docs, Size string
+	// Storage is zero when the index statistics could not be read, which
+	// leaves the base unchecked against its budget.
+	Storage knowledge.IndexStorage
+}
+
+// storageBudget returns the knowledge.storage.warn-at budget knowledge list
+// checks bases against.
+func (cmd *knowledgeCommand) storageBudget() (knowledge.StorageBudget, error) {
+	warnAt, _ := config.GetString(cmd.Config, confKnowledgeStorageWarnAt)
+	return knowledge.ParseStorageBudget(warnAt)
+}
+
+// retentionLookup returns an index's retention policy; nil when policies
+// cannot be read, as over the daemon.
+type retentionLookup func(indexName string) (knowledge.RetentionPolicy, error)
+
+// printBaseTable prints the knowledge list table. With a storage budget set it
+// adds a BUDGET column, flags the bases past theirs, and suggests how to bring
+// each back under it.
+func printBaseTable(rows []baseRow, budget knowledge.StorageBudget, policyOf retentionLookup) {
+	if budget.IsZero() {
+		fmt.Printf("%-30s %-10s %-10s %-12s %-10s\n", "KNOWLEDGE BASE", "HEALTH", "STATUS", "DOCS", "SIZE")
+		for _, r := range rows {
+			fmt.Printf("%-30s %-10s %-10s %-12s %-10s\n", r.Name, r.Health, r.Status, r.Docs, r.Size)
+		}
+		return
+	}
+
+	var over []baseRow
+	fmt.Printf("%-30s %-10s %-10s %-12s %-10s %s\n", "KNOWLEDGE BASE", "HEALTH", "STATUS", "DOCS", "SIZE", "BUDGET")
+	for _, r := range rows {
+		fmt.Printf("%-30s %-10s %-10s %-12s %-10s %s\n", r.Name, r.Health, r.Status, r.Docs, r.Size, budgetCell(r, budget))
+		if budget.Over(r.Name, r.Storage.StoreBytes) {
+			over = append(over, r)
+		}
+	}
+	for _, r := range over {
+		printBudgetAdvice(r, budget.For(r.Name), policyOf)
+	}
+}
+
+// budgetCell renders a base's store size as a share of its budget, in red
+// and marked when past it.
+func budgetCell(r baseRow, budget knowledge.StorageBudget) string {
+	limit := budget.For(r.Name)
+	if limit == 0 || r.Storage.StoreBytes == 0 {
+		return "-"
+	}
+	cell := fmt.Sprintf("%d%% of %s", r.Storage.StoreBytes*100/limit, humanBytes(int64(limit)))
+	if budget.Over(r.Name, r.Storage.StoreBytes) {
+		return color.RedString(cell + " !")
+	}
+	return cell
+}
+
+// printBudgetAdvice explains that r is past its budget of limit bytes and
+// lists the commands that free its space: finding and forgetting large
+// sources, or, unless the base has one, a retention policy that forgets its
+// oldest sources past an age or size.
+func printBudgetAdvice(r baseRow, limit uint64, policyOf retentionLookup) {
+	cli := cliCommand()
+	fmt.Printf("\n%s stores %s, over its %s budget (%s).\n",
+		color.RedString("'%s'", r.Name), humanBytes(int64(r.Storage.StoreBytes)), humanBytes(int64(limit)), confKnowledgeStorageWarnAt)
+	fmt.Printf("  Find its largest sources:   %s knowledge list %s --sources --sort chunks\n", cli, r.Name)
+	fmt.Printf("  Forget those not needed:    %s knowledge forget %s <source_id>\n", cli, r.Name)
+	if r.Storage.DeletedDocs > 0 {
+		fmt.Printf("  (%d forgotten or replaced chunks still take space until OpenSearch merges them away.)\n", r.Storage.DeletedDocs)
+	}
+
+	if policyOf != nil {
+		if policy, err := policyOf(r.Index); err == nil && !policy.IsZero() {
+			fmt.Printf("  Its retention policy (%s) forgets the oldest sources past a limit.\n", policy)
+			return
+		}
+	}
+	fmt.Printf("  Or cap it automatically:    %s knowledge policy set %s --max-size %s\n", cli, r.Name, ismSize(limit))
+}
+
+// ismSize renders a byte count as a retention --max-size value, rounded down
+// to whole gigabytes or megabytes.
+func ismSize(n uint64) string {
+	if n >= 1<<30 && n%(1<<30) == 0 {
+		return fmt.Sprintf("%dg", n>>30)
+	}
+	return fmt.Sprintf("%dm", max(n>>20, 1))
+}
@@ -0,0 +1,128 @@
+package basic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/spf13/cobra"
+)
+
+// errTuneOverDaemon is returned by knowledge tune when a daemon is running:
+// the daemon's API cannot create the temporary indexes tuning needs yet.
+var errTuneOverDaemon = errors.New("chunk tuning is not supported over the ragd daemon yet; stop the daemon to tune directly")
+
+func (cmd *knowledgeCommand) tuneCommand() *cobra.Command {
+	var queriesPath string
+	var sizes, overlaps []int
+	var sample, k int
+	var apply bool
+
+	cobraCmd := &cobra.Command{
+		Use:   "tune <knowledge_base_name>",
+		Short: "Find the chunk size and overlap that retrieve best",
+		Long: "Re-chunk a sample of a knowledge base's sources at several chunk sizes and\n" +
+			"overlaps, each into a temporary index, and measure recall@k of a set of\n" +
+			"queries with known answers on each. The sample is every source the queries\n" +
+			"expect plus --sample others; sources are re-read from their file or URL.\n" +
+			"The base itself is not changed unless --apply is given, and then only for\n" +
+			"later ingests: re-ingest with --force to re-chunk existing sources.\n\n" +
+			"The queries file is YAML:\n\n" +
+			"  queries:\n" +
+			"    - query: How do I rotate the TLS certificates?\n" +
+			"      sources: [ops/tls.md]",
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if queriesPath == "" {
+				return fmt.Errorf("--queries is required")
+			}
+			if k < 1 {
+				return fmt.Errorf("--top must be at least 1")
+			}
+			if sample < 0 {
+				return fmt.Errorf("--sample cannot be negative")
+			}
+			if daemonClient(cmd.Context) != nil {
+				return errTuneOverDaemon
+			}
+
+			f, err := os.Open(queriesPath)
+			if err != nil {
+				return fmt.Errorf("opening queries: %w", err)
+			}
+			queries, err := knowledge.ParseTuneQueries(f)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("%s: %w", queriesPath, err)
+			}
+
+			modelID, err := cmd.embeddingModelID()
+			if err != nil {
+				return err
+			}
+			apiUrls, err := serverApiUrls(cmd.Context)
+			if err != nil {
+				return fmt.Errorf("getting server API URLs: %w", err)
+			}
+			client, err := cmd.opensearchClient()
+			if err != nil {
+				return err
+			}
+			tikaClient, err := newTikaClient(cmd.Context, apiUrls[tika])
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+			indexName := knowledge.FullIndexName(args[0])
+			if _, _, err := client.GetDefaultLabel(ctx, indexName); err != nil {
+				return knowledgeBaseError(args[0], err)
+			}
+
+			results, err := client.Tune(ctx, tikaClient, indexName, queries, knowledge.TuneOptions{
+				Sizes:            sizes,
+				Overlaps:         overlaps,
+				Sample:           sample,
+				K:                k,
+				EmbeddingModelID: modelID,
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("\n%-10s %-10s %-12s %s\n", "SIZE", "OVERLAP", "RECALL@"+fmt.Sprint(k), "CHUNKS")
+			for _, r := range results {
+				current := ""
+				if r.Current {
+					current = "  (current)"
+				}
+				fmt.Printf("%-10d %-10d %-12.3f %d%s\n", r.Chunking.Size, r.Chunking.Overlap, r.Recall, r.Chunks, current)
+			}
+
+			best := results[0]
+			if best.Current {
+				fmt.Printf("\nThe current setting (size %d, overlap %d) retrieves best; nothing to change.\n", best.Chunking.Size, best.Chunking.Overlap)
+				return nil
+			}
+			if !apply {
+				fmt.Printf("\nBest: chunk size %d, overlap %d. Run again with --apply to use it for '%s'.\n", best.Chunking.Size, best.Chunking.Overlap, args[0])
+				return nil
+			}
+			if err := client.SetBaseChunking(ctx, indexName, best.Chunking); err != nil {
+				return err
+			}
+			fmt.Printf("\nKnowledge base '%s' now chunks at size %d, overlap %d. Re-ingest with --force to re-chunk existing sources.\n", args[0], best.Chunking.Size, best.Chunking.Overlap)
+			return nil
+		},
+	}
+
+	cobraCmd.Flags().StringVar(&queriesPath, "queries", "", "YAML file of queries and the sources each should retrieve")
+	cobraCmd.Flags().IntSliceVar(&sizes, "sizes", knowledge.DefaultTuneSizes, "Chunk sizes to try (comma-separated)")
+	cobraCmd.Flags().IntSliceVar(&overlaps, "overlaps", knowledge.DefaultTuneOverlaps, "Chunk overlaps to try (comma-separated)")
+	cobraCmd.Flags().IntVar(&sample, "sample", knowledge.DefaultTuneSample, "Sources to re-chunk besides the ones the queries expect")
+	cobraCmd.Flags().IntVarP(&k, "top", "k", knowledge.DefaultTuneK, "Number of hits recall is measured at")
+	cobraCmd.Flags().BoolVar(&apply, "apply", false, "Use the best setting for the base's later ingests")
+
+	return cobraCmd
+}
@@ -0,0 +1,195 @@
+package basic
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/internal/apiclient"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+)
+
+// defaultWatchInterval is how often `knowledge list --sources --watch` refreshes.
+const defaultWatchInterval = 3 * time.Second
+
+// sourceRow is one line of the watched sources table. Direct and daemon
+// listings both project into it so a single renderer serves either mode.
+type sourceRow struct {
+	SourceID   string
+	Base       string
+	Label      string
+	Status     string
+	Chunks     int
+	IngestedAt string
+	// Tags are the source's tags as FormatTags renders them; the daemon does
+	// not report them.
+	Tags string
+}
+
+// sourceTotals summarises a snapshot of sources by status.
+type sourceTotals struct {
+	Processing      int
+	Completed       int
+	Failed          int
+	CompletedChunks int
+}
+
+// totalSources counts rows by status and sums the chunks of completed sources.
+func totalSources(rows []sourceRow) sourceTotals {
+	var t sourceTotals
+	for _, r := range rows {
+		switch r.Status {
+		case knowledge.StatusProcessing:
+			t.Processing++
+		case knowledge.StatusCompleted:
+			t.Completed++
+			t.CompletedChunks += r.Chunks
+		case knowledge.StatusFailed:
+			t.Failed++
+		}
+	}
+	return t
+}
+
+// colorStatus renders a source status in its dashboard color.
+func colorStatus(status string) string {
+	padded := fmt.Sprintf("%-12s", status)
+	switch status {
+	case knowledge.StatusProcessing:
+		return color.YellowString(padded)
+	case knowledge.StatusCompleted:
+		return color.GreenString(padded)
+	case knowledge.StatusFailed:
+		return color.RedString(padded)
+	default:
+		return padded
+	}
+}
+
+// watchSources redraws the sources table every interval until Ctrl-C, with a
+// status summary and the ingestion throughput since the previous refresh.
+func watchSources(fetch func(context.Context) ([]sourceRow, error), interval time.Duration, wide bool) error {
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var prev sourceTotals
+	var prevAt time.Time
+	for {
+		rows, err := fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("listing sources: %w", err)
+		}
+		now := time.Now()
+		totals := totalSources(rows)
+
+		// Clear the screen and home the cursor before each redraw. Redirected
+		// output gets each refresh appended instead of escape codes.
+		if common.Interactive() {
+			fmt.Print("\033[H\033[2J")
+		} else if !prevAt.IsZero() {
+			fmt.Println()
+		}
+		fmt.Printf("Every %s: knowledge sources   %s\n\n", interval, now.Format(time.TimeOnly))
+		cols := sourceTableColumns(rows, wide)
+		fmt.Println(cols.header())
+		for _, r := range rows {
+			fmt.Println(cols.row(r, colorStatus(r.Status)))
+		}
+		fmt.Printf("\n%d sources: %s, %s, %s\n", len(rows),
+			color.YellowString("%d processing", totals.Processing),
+			color.GreenString("%d completed", totals.Completed),
+			color.RedString("%d failed", totals.Failed))
+		if !prevAt.IsZero() {
+			elapsed := now.Sub(prevAt).Seconds()
+			sources := totals.Completed - prev.Completed
+			chunks := totals.CompletedChunks - prev.CompletedChunks
+			fmt.Printf("Throughput: %.2f sources/s, %.1f chunks/s\n", float64(sources)/elapsed, float64(chunks)/elapsed)
+		}
+		fmt.Println("Press Ctrl-C to stop.")
+		prev, prevAt = totals, now
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetchSourceRows lists the sources q selects in direct mode, optionally
+// limited to one base.
+func fetchSourceRows(client *knowledge.OpenSearchClient, base string, q knowledge.SourceQuery) func(context.Context) ([]sourceRow, error) {
+	if base != "" {
+		q.IndexName = knowledge.FullIndexName(base)
+	}
+	return func(ctx context.Context) ([]sourceRow, error) {
+		sources, err := client.QuerySourceMetadata(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		rows := make([]sourceRow, len(sources))
+		for i, s := range sources {
+			name, _ := knowledge.KnowledgeBaseNameFromIndex(s.IndexName)
+			rows[i] = sourceRow{
+				SourceID:   s.SourceID,
+				Base:       name,
+				Label:      knowledge.ResolveLabel(s.IndexName, s.Label),
+				Status:     s.Status,
+				Chunks:     s.ChunkCount,
+				IngestedAt: s.IngestedAt,
+				Tags:       knowledge.FormatTags(s.Tags),
+			}
+		}
+		return rows, nil
+	}
+}
+
+// fetchSourceRowsAPI lists the sources q selects via the daemon, optionally
+// limited to one base. The daemon lists every source, so q is applied here.
+func fetchSourceRowsAPI(dc *apiclient.Client, base string, q knowledge.SourceQuery) func(context.Context) ([]sourceRow, error) {
+	return func(ctx context.Context) ([]sourceRow, error) {
+		bases := []string{base}
+		if base == "" {
+			kbs, err := dc.ListKnowledge(ctx)
+			if err != nil {
+				return nil, err
+			}
+			bases = bases[:0]
+			for _, kb := range kbs {
+				bases = append(bases, kb.Name)
+			}
+		}
+		var rows []sourceRow
+		for _, b := range bases {
+			sources, err := dc.ListSources(ctx, b)
+			if err != nil {
+				return nil, err
+			}
+			for _, s := range sources {
+				rows = append(rows, sourceRow{
+					SourceID:   s.SourceID,
+					Base:       b,
+					Label:      s.Label,
+					Status:     s.Status,
+					Chunks:     s.ChunkCount,
+					IngestedAt: s.IngestedAt,
+				})
+			}
+		}
+		return applySourceQuery(rows, q), nil
+	}
+}
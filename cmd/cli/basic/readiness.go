@@ -0,0 +1,34 @@
+package basic
+
+import (
+	"time"
+
+	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+)
+
+// serviceReady reports whether the readiness registry records service as up.
+// An unreadable registry counts as not ready, so callers fall back to waiting.
+func serviceReady(ctx *common.Context, service string) bool {
+	if ctx.Readiness == nil {
+		return false
+	}
+	missing, err := ctx.Readiness.NotReady([]string{service})
+	return err == nil && len(missing) == 0
+}
+
+// markServiceReady records that service has just answered. Recording needs
+// root, so a failure is ignored: the registry is a shortcut, never the
+// source of truth.
+func markServiceReady(ctx *common.Context, service string) {
+	if ctx.Readiness != nil {
+		_ = ctx.Readiness.MarkReady(service, time.Now())
+	}
+}
+
+// markServiceNotReady removes service's record after it failed to answer,
+// best-effort like markServiceReady.
+func markServiceNotReady(ctx *common.Context, service string) {
+	if ctx.Readiness != nil {
+		_ = ctx.Readiness.MarkNotReady(service)
+	}
+}
@@ -7,15 +7,18 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/huh"
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/processing"
 	"github.com/jpnorenam/rag-snap/cmd/cli/basic/rfp"
 	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/processing"
 )
 
-// rfpTikaURL returns the Tika server URL by reading only the tika.http.* config keys.
+// rfpTikaURL returns the Tika server URL given with --tika-url, or else by reading only the tika.http.* config keys.
 // Unlike serverApiUrls it does not require chat or opensearch config to be present.
 func rfpTikaURL(ctx *common.Context) (string, error) {
+	if ctx.TikaURL != "" {
+		return ctx.TikaURL, nil
+	}
 	host, err := getConfigString(ctx, confTikaHttpHost)
 	if err != nil {
 		return "", fmt.Errorf("Tika host not configured — run: rag set tika.http.host <host>")
@@ -29,8 +32,25 @@ func rfpTikaURL(ctx *common.Context) (string, error) {
 	return buildServiceURL(host, port, tikaPath, tikaTLS), nil
 }
 
-// rfpOpenSearchURL returns the OpenSearch URL by reading only the knowledge.http.* config keys.
+// rfpTikaClient returns the Tika client at rfpTikaURL, with the configured
+// tika.* settings.
+func rfpTikaClient(ctx *common.Context) (*processing.TikaClient, error) {
+	tikaURL, err := rfpTikaURL(ctx)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newTikaClient(ctx, tikaURL)
+	if err != nil {
+		return nil, fmt.Errorf("creating Tika client: %w", err)
+	}
+	return client, nil
+}
+
+// rfpOpenSearchURL returns the OpenSearch URL given with --opensearch-url, or else by reading only the knowledge.http.* config keys.
 func rfpOpenSearchURL(ctx *common.Context) (string, error) {
+	if ctx.OpenSearchURL != "" {
+		return ctx.OpenSearchURL, nil
+	}
 	host, err := getConfigString(ctx, confOpenSearchHttpHost)
 	if err != nil {
 		return "", fmt.Errorf("OpenSearch host not configured")
@@ -89,7 +109,7 @@ func rfpListKnowledgeBases(ctx *common.Context) ([]rfpKBChoice, bool) {
 		return nil, false
 	}
 	stop := common.StartProgressSpinner("Fetching knowledge bases")
-	client, clientErr := knowledge.NewClient(osURL)
+	client, clientErr := newKnowledgeClient(ctx, osURL)
 	var indexes []knowledge.IndexInfo
 	if clientErr == nil {
 		indexes, clientErr = client.ListIndexes(context.Background())
@@ -215,13 +235,8 @@ func rfpExtractCSV(filePath string) ([]rfp.Question, error) {
 // rfpExtractXLSX guides the user through sheet and column selection, supporting
 // multiple sheets and multiple tables per sheet. Each question is tagged with
 // the sheet name as its source.
-func rfpExtractXLSX(filePath, tikaURL string) ([]rfp.Question, error) {
+func rfpExtractXLSX(filePath string, tikaClient *processing.TikaClient) ([]rfp.Question, error) {
 	stop := common.StartProgressSpinner("Parsing XLSX via Tika")
-	tikaClient, err := processing.NewTikaClient(tikaURL)
-	if err != nil {
-		stop()
-		return nil, fmt.Errorf("creating Tika client: %w", err)
-	}
 	htmlContent, err := tikaClient.ExtractHTML(filePath)
 	stop()
 	if err != nil {
@@ -413,12 +428,7 @@ func rfpExtractFromTables(sheets []rfp.SheetTable) ([]rfp.Question, error) {
 // questions from a PDF or DOCX file via Tika. Supports list/paragraph mode
 // (plain-text extraction with optional TOC filter) and table mode (HTML extraction
 // with column picker, reusing the XLSX table flow).
-func rfpExtractText(filePath, tikaURL string) ([]rfp.Question, error) {
-	tikaClient, err := processing.NewTikaClient(tikaURL)
-	if err != nil {
-		return nil, fmt.Errorf("creating Tika client: %w", err)
-	}
-
+func rfpExtractText(filePath string, tikaClient *processing.TikaClient) ([]rfp.Question, error) {
 	// ── Structure mode ────────────────────────────────────────────────────────
 	var structureMode string
 	if err := huh.NewForm(huh.NewGroup(
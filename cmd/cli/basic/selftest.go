@@ -0,0 +1,215 @@
+package basic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jpnorenam/rag-snap/cmd/cli/basic/chat"
+	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/processing"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// selfTestQuestion asks about a fact only the sample document states, so a
+	// grounded answer has to come from the ingested chunk.
+	selfTestQuestion = "When does the Zephyr-7 maintenance window open, and which team coordinates it?"
+	selfTestSample   = `# Zephyr-7 operations guide
+
+## Maintenance window
+
+The Zephyr-7 maintenance window opens every Tuesday at 03:00 UTC and lasts
+two hours. The Orchid team coordinates every maintenance window and posts
+the change list one day in advance.
+
+## Escalation
+
+Outside the maintenance window, incidents are escalated to the on-call
+engineer through the Zephyr-7 paging rotation.
+`
+	// selfTestCleanupTimeout bounds the cleanup after an interrupted run.
+	selfTestCleanupTimeout = 30 * time.Second
+)
+
+// SelfTestCommand runs the whole RAG pipeline once against a throwaway
+// knowledge base: ingest a built-in sample, search it, and answer a question
+// from it, reporting each step as it passes or fails.
+func SelfTestCommand(ctx *common.Context) *cobra.Command {
+	var model string
+
+	cobraCmd := &cobra.Command{
+		Use:   "self-test",
+		Short: "Check the installation by running the pipeline end to end",
+		Long: "Ingest a small built-in sample document into a temporary knowledge base, search\n" +
+			"it, and answer a question from it through the inference server, checking that\n" +
+			"the answer cites the sample. Each step is reported as it passes or fails, and the\n" +
+			"temporary base is deleted afterwards. Run it after installing or reconfiguring\n" +
+			"the snap to confirm OpenSearch, Tika, and the inference server work together.",
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if daemonClient(ctx) != nil {
+				return fmt.Errorf("debug self-test is not supported over the ragd daemon yet")
+			}
+			if model == "" {
+				model, _ = getConfigString(ctx, confChatModel)
+			}
+
+			runCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if err := runSelfTest(runCtx, ctx, model); err != nil {
+				return fmt.Errorf("self-test failed: %w", err)
+			}
+			fmt.Println("Self-test passed")
+			return nil
+		},
+	}
+
+	cobraCmd.Flags().StringVar(&model, "model", "", "Chat model to answer with (default: chat.model, or the server's only model)")
+
+	return cobraCmd
+}
+
+// runSelfTest runs the self-test steps in order, stopping at the first
+// failure. The temporary base and its source record are removed either way.
+func runSelfTest(ctx context.Context, cmdCtx *common.Context, model string) error {
+	var (
+		apiUrls    map[string]string
+		modelID    string
+		client     *knowledge.OpenSearchClient
+		tikaClient *processing.TikaClient
+		settings   chat.Settings
+	)
+	baseName := fmt.Sprintf("selftest-%d", time.Now().Unix())
+	indexName := knowledge.FullIndexName(baseName)
+	// Source IDs are global, so the sample's is unique to this run too.
+	sourceID := baseName + "-sample"
+
+	step := func(name string, fn func() error) error {
+		start := time.Now()
+		if err := fn(); err != nil {
+			fmt.Printf("  FAIL  %s: %v\n", name, err)
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		fmt.Printf("  PASS  %s (%s)\n", name, time.Since(start).Round(time.Millisecond))
+		return nil
+	}
+
+	if err := step("Resolve configuration", func() error {
+		var err error
+		if apiUrls, err = serverApiUrls(cmdCtx); err != nil {
+			return fmt.Errorf("getting server API URLs: %w", err)
+		}
+		if modelID, err = getConfigString(cmdCtx, knowledge.ConfEmbeddingModelID); err != nil {
+			return fmt.Errorf("embedding model ID not configured; run 'knowledge init' first")
+		}
+		settings, err = chatSettings(cmdCtx)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	if err := step("Connect to OpenSearch", func() error {
+		var err error
+		if client, err = newKnowledgeClient(cmdCtx, apiUrls[opensearch]); err != nil {
+			return err
+		}
+		tikaClient, err = newTikaClient(cmdCtx, apiUrls[tika])
+		return err
+	}); err != nil {
+		return err
+	}
+
+	if err := step("Create temporary knowledge base "+baseName, func() error {
+		return client.CreateIndex(ctx, indexName)
+	}); err != nil {
+		return err
+	}
+	defer cleanupSelfTest(ctx, client, indexName, sourceID)
+
+	if err := step("Ingest sample document", func() error {
+		f, err := client.Settings().Temp.CreateTemp("selftest-*.md")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(f.Name())
+		if _, err := f.WriteString(selfTestSample); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		// The search below must see the chunks as soon as ingest returns.
+		if err := client.SetBulkRefresh(knowledge.RefreshWaitFor); err != nil {
+			return err
+		}
+		return client.IngestSource(ctx, tikaClient, knowledge.IngestOptions{
+			FilePath:    f.Name(),
+			SourceID:    sourceID,
+			TargetIndex: indexName,
+		})
+	}); err != nil {
+		return err
+	}
+
+	if err := step("Search the sample", func() error {
+		hits, err := client.Search(ctx, []string{indexName}, selfTestQuestion, selfTestQuestion, modelID, 5)
+		if err != nil {
+			return err
+		}
+		if !slices.ContainsFunc(hits, func(h knowledge.SearchHit) bool { return h.SourceID == sourceID }) {
+			return fmt.Errorf("no hit from the sample document among %d results", len(hits))
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return step("Answer from the sample", func() error {
+		var out bytes.Buffer
+		err := chat.Ask(ctx, selfTestQuestion, chat.AskOptions{
+			BaseURL:          apiUrls[openAi],
+			Model:            model,
+			KnowledgeClient:  client,
+			EmbeddingModelID: modelID,
+			Bases:            []string{baseName},
+			SystemPrompt:     chat.LoadPrompts().ChatSystemPrompt,
+			Settings:         settings,
+		}, &out)
+		if err != nil {
+			return err
+		}
+		answer, sources, _ := strings.Cut(out.String(), "\nSources: ")
+		if strings.TrimSpace(answer) == "" {
+			return fmt.Errorf("the inference server returned an empty answer")
+		}
+		if !strings.Contains(sources, sourceID) {
+			return fmt.Errorf("the answer does not cite the sample document")
+		}
+		return nil
+	})
+}
+
+// cleanupSelfTest deletes the temporary base and the sample's source record.
+// It runs on its own context so an interrupted run still cleans up.
+func cleanupSelfTest(ctx context.Context, client *knowledge.OpenSearchClient, indexName, sourceID string) {
+	cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), selfTestCleanupTimeout)
+	defer cancel()
+
+	if err := client.DeleteSourceMetadata(cleanupCtx, sourceID); err != nil {
+		fmt.Printf("Warning: removing the sample's source record: %v\n", err)
+	}
+	if err := client.DeleteIndex(cleanupCtx, indexName); err != nil {
+		fmt.Printf("Warning: deleting temporary index %s: %v\n", indexName, err)
+	}
+}
@@ -1,14 +1,21 @@
 package basic
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/canonical/go-snapctl"
 	"github.com/jpnorenam/rag-snap/cmd/cli/basic/chat"
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
 	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/processing"
+	"github.com/jpnorenam/rag-snap/pkg/storage"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
@@ -17,7 +24,9 @@ type statusCommand struct {
 	*common.Context
 
 	// flags
-	format string
+	format  string
+	wait    bool
+	timeout time.Duration
 }
 
 func StatusCommand(ctx *common.Context) *cobra.Command {
@@ -36,6 +45,8 @@ func StatusCommand(ctx *common.Context) *cobra.Command {
 
 	// flags
 	cobraCmd.Flags().StringVar(&cmd.format, "format", "yaml", "output format")
+	cobraCmd.Flags().BoolVar(&cmd.wait, "wait", false, "wait until every configured service is ready before showing the status")
+	cobraCmd.Flags().DurationVar(&cmd.timeout, "timeout", defaultWaitTimeout, "how long --wait waits before giving up")
 
 	return cobraCmd
 }
@@ -44,6 +55,12 @@ func (cmd *statusCommand) run(_ *cobra.Command, _ []string) error {
 	var statusText string
 	var err error
 
+	if cmd.wait {
+		if err := cmd.waitReady(); err != nil {
+			return err
+		}
+	}
+
 	stopProgress := common.StartProgressSpinner("Getting status")
 	defer stopProgress()
 
@@ -98,26 +115,46 @@ type Status struct {
 	Models    map[string]string `json:"models" yaml:"models"`
 	Services  map[string]string `json:"services" yaml:"services"`
 	Endpoints map[string]string `json:"endpoints" yaml:"endpoints"`
+	Health    Health            `json:"health" yaml:"health"`
 }
 
+// Health is the result of probing each backend the snap depends on. Overall is
+// healthy only when every configured check is ok; any other check state makes
+// it degraded.
+type Health struct {
+	Overall string                 `json:"overall" yaml:"overall"`
+	Checks  map[string]HealthCheck `json:"checks" yaml:"checks"`
+}
+
+// HealthCheck is one probe's outcome. Detail explains any state other than ok.
+type HealthCheck struct {
+	State  string `json:"state" yaml:"state"`
+	Detail string `json:"detail,omitempty" yaml:"detail,omitempty"`
+}
+
+// Health check states and the overall verdicts derived from them.
+const (
+	healthOK            = "ok"
+	healthDegraded      = "degraded"
+	healthDown          = "down"
+	healthNotConfigured = "not configured"
+
+	overallHealthy  = "healthy"
+	overallDegraded = "degraded"
+)
+
+// healthProbeTimeout bounds each health probe. Probes run concurrently, so a
+// fully-down stack costs one timeout rather than the sum of them.
+const healthProbeTimeout = 3 * time.Second
+
 func (cmd *statusCommand) statusStruct() (*Status, error) {
 	var statusStr Status
 
-	services, err := snapctl.Services().Run()
+	services, err := serviceStates()
 	if err != nil {
-		return nil, fmt.Errorf("error getting services: %w", err)
-	}
-	statusStr.Services = make(map[string]string)
-	for name, service := range services {
-		// The service name is in the format <snap-name>.<service-app>, we only want the service-app part.
-		_, serviceApp, found := strings.Cut(name, ".")
-		if !found {
-			return nil, fmt.Errorf("error unexpected service name format: %q", name)
-		}
-		// Append the service status exactly as snapd reports it. Often this is in the host system language, see bug:
-		// https://bugs.launchpad.net/snapd/+bug/2137543
-		statusStr.Services[serviceApp] = service.Current
+		return nil, err
 	}
+	statusStr.Services = services
 
 	endpoints, err := serverApiUrls(cmd.Context)
 	if err != nil {
@@ -145,5 +182,205 @@ func (cmd *statusCommand) statusStruct() (*Status, error) {
 		statusStr.Models["reranker"] = fmt.Sprintf("%s (%s)", knowledge.DefaultCrossEncoderName, rerankModelID)
 	}
 
+	statusStr.Health = probeHealth(endpoints, embeddingModelID, rerankModelID)
+
 	return &statusStr, nil
 }
+
+// serviceStates maps each of the snap's services to its current state.
+func serviceStates() (map[string]string, error) {
+	services, err := snapctl.Services().Run()
+	if err != nil {
+		return nil, fmt.Errorf("error getting services: %w", err)
+	}
+	states := make(map[string]string)
+	for name, service := range services {
+		// The service name is in the format <snap-name>.<service-app>, we only want the service-app part.
+		_, serviceApp, found := strings.Cut(name, ".")
+		if !found {
+			return nil, fmt.Errorf("error unexpected service name format: %q", name)
+		}
+		// Append the service status exactly as snapd reports it. Often this is in the host system language, see bug:
+		// https://bugs.launchpad.net/snapd/+bug/2137543
+		states[serviceApp] = service.Current
+	}
+	return states, nil
+}
+
+// probeHealth runs the backend health probes concurrently, each bounded by
+// healthProbeTimeout, and derives the overall verdict.
+func probeHealth(endpoints map[string]string, embeddingModelID, rerankModelID string) Health {
+	checks := map[string]HealthCheck{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	probe := func(fn func(ctx context.Context) map[string]HealthCheck) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), healthProbeTimeout)
+			defer cancel()
+			results := fn(ctx)
+			mu.Lock()
+			defer mu.Unlock()
+			maps.Copy(checks, results)
+		}()
+	}
+
+	probe(func(ctx context.Context) map[string]HealthCheck {
+		return probeOpenSearchHealth(ctx, endpoints[opensearch], embeddingModelID, rerankModelID)
+	})
+	probe(func(ctx context.Context) map[string]HealthCheck {
+		return map[string]HealthCheck{"inference": probeInferenceHealth(ctx, endpoints[openAi])}
+	})
+	probe(func(ctx context.Context) map[string]HealthCheck {
+		return map[string]HealthCheck{"tika": probeTikaHealth(ctx, endpoints[tika])}
+	})
+	wg.Wait()
+
+	health := Health{Overall: overallHealthy, Checks: checks}
+	for _, check := range checks {
+		if check.State != healthOK && check.State != healthNotConfigured {
+			health.Overall = overallDegraded
+		}
+	}
+	return health
+}
+
+// probeOpenSearchHealth reports the cluster health color and the deployment
+// state of the configured embedding and rerank models. The model checks are
+// reported as down along with the cluster when it cannot be reached, since
+// retrieval cannot use them either way.
+func probeOpenSearchHealth(ctx context.Context, url, embeddingModelID, rerankModelID string) map[string]HealthCheck {
+	models := map[string]string{"embedding": embeddingModelID, "reranker": rerankModelID}
+	checks := map[string]HealthCheck{}
+	if url == "" {
+		checks["opensearch"] = HealthCheck{State: healthNotConfigured}
+		return checks
+	}
+
+	// The probe runs no ingest or search, so the knowledge.* settings are
+	// left at their defaults rather than failing it over a bad one.
+	var color string
+	client, err := knowledge.NewClientNoWait(ctx, url, knowledge.Settings{})
+	if err == nil {
+		color, err = client.ClusterHealth(ctx)
+	}
+	switch {
+	case err != nil:
+		checks["opensearch"] = HealthCheck{State: healthDown, Detail: err.Error()}
+	case color == "green":
+		checks["opensearch"] = HealthCheck{State: healthOK}
+	case color == "yellow":
+		checks["opensearch"] = HealthCheck{State: healthDegraded, Detail: "cluster health is yellow: some replica shards are unassigned"}
+	default:
+		checks["opensearch"] = HealthCheck{State: healthDown, Detail: fmt.Sprintf("cluster health is %s: some primary shards are unassigned", color)}
+	}
+
+	for role, id := range models {
+		name := role + " model"
+		switch {
+		case id == "":
+			checks[name] = HealthCheck{State: healthNotConfigured}
+		case client == nil:
+			checks[name] = HealthCheck{State: healthDown, Detail: "opensearch is unreachable"}
+		default:
+			checks[name] = modelHealth(ctx, client, id)
+		}
+	}
+	return checks
+}
+
+// modelHealth maps an ML model's deployment state to a health check.
+func modelHealth(ctx context.Context, client *knowledge.OpenSearchClient, modelID string) HealthCheck {
+	state, err := client.ModelState(ctx, modelID)
+	if err != nil {
+		return HealthCheck{State: healthDown, Detail: err.Error()}
+	}
+	switch state {
+	case "DEPLOYED":
+		return HealthCheck{State: healthOK}
+	case "PARTIALLY_DEPLOYED":
+		return HealthCheck{State: healthDegraded, Detail: "model " + modelID + " is only deployed on some nodes"}
+	default:
+		return HealthCheck{State: healthDown, Detail: fmt.Sprintf("model %s is %s; run `knowledge init` to deploy it", modelID, state)}
+	}
+}
+
+// probeInferenceHealth reports whether the inference server answers with a
+// model to serve.
+func probeInferenceHealth(ctx context.Context, url string) HealthCheck {
+	if url == "" {
+		return HealthCheck{State: healthNotConfigured}
+	}
+	if _, err := chat.FindModelNameContext(ctx, url); err != nil {
+		return HealthCheck{State: healthDown, Detail: err.Error()}
+	}
+	return HealthCheck{State: healthOK}
+}
+
+// probeTikaHealth reports whether Tika is accepting extraction requests.
+func probeTikaHealth(ctx context.Context, url string) HealthCheck {
+	if url == "" {
+		return HealthCheck{State: healthNotConfigured}
+	}
+	client, err := processing.NewTikaClient(url, processing.TikaSettings{})
+	if err == nil {
+		err = client.Ready(ctx)
+	}
+	if err != nil {
+		return HealthCheck{State: healthDown, Detail: err.Error()}
+	}
+	return HealthCheck{State: healthOK}
+}
+
+// defaultWaitTimeout is how long status --wait waits for the services, matching
+// the time other commands give a starting OpenSearch.
+const defaultWaitTimeout = 60 * time.Second
+
+// waitPollInterval is the pause between rounds of probes while waiting.
+const waitPollInterval = 2 * time.Second
+
+// readinessChecks maps each service in the readiness registry to the health
+// check that decides it.
+var readinessChecks = map[string]string{
+	storage.ServiceOpenSearch: "opensearch",
+	storage.ServiceOpenAI:     "inference",
+	storage.ServiceTika:       "tika",
+}
+
+// waitReady probes the services until every configured one answers, recording
+// each outcome in the readiness registry, or until --timeout passes. A
+// degraded service counts as ready: it answers, only not at full strength.
+func (cmd *statusCommand) waitReady() error {
+	stopProgress := common.StartProgressSpinner("Waiting for services")
+	defer stopProgress()
+
+	deadline := time.Now().Add(cmd.timeout)
+	for {
+		endpoints, err := serverApiUrls(cmd.Context)
+		if err != nil {
+			return fmt.Errorf("error getting server api endpoints: %w", err)
+		}
+		health := probeHealth(endpoints, "", "")
+
+		var waiting []string
+		for service, name := range readinessChecks {
+			switch health.Checks[name].State {
+			case healthNotConfigured:
+			case healthDown:
+				markServiceNotReady(cmd.Context, service)
+				waiting = append(waiting, service)
+			default:
+				markServiceReady(cmd.Context, service)
+			}
+		}
+		if len(waiting) == 0 {
+			return nil
+		}
+		if time.Now().Add(waitPollInterval).After(deadline) {
+			sort.Strings(waiting)
+			return fmt.Errorf("timed out after %s waiting for: %s", cmd.timeout, strings.Join(waiting, ", "))
+		}
+		time.Sleep(waitPollInterval)
+	}
+}
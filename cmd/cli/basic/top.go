@@ -0,0 +1,395 @@
+package basic
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jpnorenam/rag-snap/cmd/cli/basic/chat"
+	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// defaultTopInterval is how often top refreshes.
+	defaultTopInterval = 5 * time.Second
+	// topProbeTimeout bounds the OpenSearch queries of one refresh.
+	topProbeTimeout = 5 * time.Second
+	// topThroughputTimeout bounds an inference throughput measurement.
+	topThroughputTimeout = 60 * time.Second
+	// topRecentSources is how many of the latest ingests top lists.
+	topRecentSources = 8
+)
+
+var (
+	topTitleStyle   = lipgloss.NewStyle().Bold(true)
+	topHeadingStyle = lipgloss.NewStyle().Bold(true).Underline(true)
+	topDimStyle     = lipgloss.NewStyle().Faint(true)
+	topOKStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	topWarnStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+	topErrorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+)
+
+type topCommand struct {
+	*common.Context
+
+	// flags
+	interval time.Duration
+}
+
+// TopCommand shows a live dashboard of the snap's services, backend health,
+// models, knowledge bases, and recent ingests, for operators who want one
+// screen to keep an eye on a deployment.
+func TopCommand(ctx *common.Context) *cobra.Command {
+	var cmd topCommand
+	cmd.Context = ctx
+
+	cobraCmd := &cobra.Command{
+		Use:   "top",
+		Short: "Show a live dashboard of services, models, and knowledge bases",
+		Long: "Show a live dashboard of the snap's services, the health of its backends, the\n" +
+			"engine's models, the knowledge bases and their sizes, and the latest ingests,\n" +
+			"refreshed every --interval along with the ingest rate since the last refresh.\n" +
+			"Inference throughput is measured on demand with a short completion: press t.\n" +
+			"Press r to refresh now and q to quit.",
+		GroupID:           groupID,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE:              cmd.run,
+	}
+
+	cobraCmd.Flags().DurationVar(&cmd.interval, "interval", defaultTopInterval, "how often to refresh the dashboard")
+
+	return cobraCmd
+}
+
+func (cmd *topCommand) run(_ *cobra.Command, _ []string) error {
+	if cmd.interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	if !common.Interactive() {
+		return fmt.Errorf("top needs an interactive terminal; use status for a one-off report")
+	}
+	m := topModel{collect: cmd.collect, measure: cmd.measure, interval: cmd.interval, loading: true}
+	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	return err
+}
+
+// topSnapshot is one round of the dashboard's probes. Each section keeps its
+// own error, so an unreachable backend blanks only its part of the screen.
+type topSnapshot struct {
+	at          time.Time
+	err         error
+	services    map[string]string
+	servicesErr error
+	health      Health
+	models      []knowledge.ModelInfo
+	indexes     []knowledge.IndexInfo
+	recent      []knowledge.SourceMetadata
+	// opensearchErr blanks the models, knowledge bases, and recent ingests.
+	opensearchErr error
+	// completedChunks sums the chunks of completed sources, for the ingest
+	// rate between two snapshots.
+	completedChunks int
+}
+
+// collect runs one round of probes.
+func (cmd *topCommand) collect() topSnapshot {
+	snap := topSnapshot{at: time.Now()}
+	snap.services, snap.servicesErr = serviceStates()
+
+	endpoints, err := serverApiUrls(cmd.Context)
+	if err != nil {
+		snap.err = fmt.Errorf("getting server api endpoints: %w", err)
+		return snap
+	}
+	embeddingModelID, _ := getConfigString(cmd.Context, knowledge.ConfEmbeddingModelID)
+	rerankModelID, _ := getConfigString(cmd.Context, knowledge.ConfRerankModelID)
+	snap.health = probeHealth(endpoints, embeddingModelID, rerankModelID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), topProbeTimeout)
+	defer cancel()
+	client, err := knowledge.NewClientNoWait(ctx, endpoints[opensearch], knowledge.Settings{})
+	if err != nil {
+		snap.opensearchErr = err
+		return snap
+	}
+	if snap.models, err = client.ListModels(ctx, embeddingModelID, rerankModelID); err != nil {
+		snap.opensearchErr = err
+		return snap
+	}
+	if snap.indexes, err = client.ListIndexes(ctx); err != nil {
+		snap.opensearchErr = err
+		return snap
+	}
+	sources, err := client.ListSourceMetadata(ctx, "")
+	if err != nil {
+		snap.opensearchErr = err
+		return snap
+	}
+	for _, s := range sources {
+		if s.Status == knowledge.StatusCompleted {
+			snap.completedChunks += s.ChunkCount
+		}
+	}
+	// The date format sorts lexically in time order.
+	slices.SortFunc(sources, func(a, b knowledge.SourceMetadata) int { return strings.Compare(b.UpdatedAt, a.UpdatedAt) })
+	snap.recent = sources[:min(len(sources), topRecentSources)]
+	return snap
+}
+
+// measure times a short completion by the served chat model.
+func (cmd *topCommand) measure() (float64, error) {
+	endpoints, err := serverApiUrls(cmd.Context)
+	if err != nil {
+		return 0, fmt.Errorf("getting server api endpoints: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), topThroughputTimeout)
+	defer cancel()
+	model, err := chat.FindModelNameContext(ctx, endpoints[openAi])
+	if err != nil {
+		return 0, err
+	}
+	return chat.MeasureThroughput(ctx, endpoints[openAi], model)
+}
+
+type (
+	topSnapshotMsg   topSnapshot
+	topTickMsg       struct{ seq int }
+	topThroughputMsg struct {
+		rate float64
+		at   time.Time
+		err  error
+	}
+)
+
+// topModel is the dashboard's bubbletea model.
+type topModel struct {
+	collect  func() topSnapshot
+	measure  func() (float64, error)
+	interval time.Duration
+
+	snap, prev *topSnapshot
+	// loading is set while a snapshot is being collected.
+	loading bool
+	// seq numbers the snapshots, so the tick scheduled after an older one is
+	// dropped when a manual refresh overtook it.
+	seq        int
+	measuring  bool
+	throughput *topThroughputMsg
+}
+
+func (m topModel) Init() tea.Cmd {
+	return m.refresh()
+}
+
+func (m topModel) refresh() tea.Cmd {
+	collect := m.collect
+	return func() tea.Msg { return topSnapshotMsg(collect()) }
+}
+
+func (m topModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		case "r":
+			if !m.loading {
+				m.loading = true
+				return m, m.refresh()
+			}
+		case "t":
+			if !m.measuring {
+				m.measuring = true
+				measure := m.measure
+				return m, func() tea.Msg {
+					rate, err := measure()
+					return topThroughputMsg{rate: rate, at: time.Now(), err: err}
+				}
+			}
+		}
+	case topSnapshotMsg:
+		snap := topSnapshot(msg)
+		m.prev, m.snap = m.snap, &snap
+		m.loading = false
+		m.seq++
+		seq := m.seq
+		return m, tea.Tick(m.interval, func(time.Time) tea.Msg { return topTickMsg{seq: seq} })
+	case topTickMsg:
+		if msg.seq == m.seq && !m.loading {
+			m.loading = true
+			return m, m.refresh()
+		}
+	case topThroughputMsg:
+		m.measuring = false
+		m.throughput = &msg
+	}
+	return m, nil
+}
+
+func (m topModel) View() string {
+	var b strings.Builder
+	status := "starting"
+	if m.snap != nil {
+		status = fmt.Sprintf("refreshed %s, every %s", m.snap.at.Format(time.TimeOnly), m.interval)
+	}
+	if m.loading {
+		status += " · refreshing…"
+	}
+	fmt.Fprintf(&b, "%s   %s\n", topTitleStyle.Render(snapName()+" top"), topDimStyle.Render(status))
+
+	if m.snap != nil {
+		m.viewSnapshot(&b)
+	}
+
+	b.WriteString("\n" + topHeadingStyle.Render("Throughput") + "\n")
+	if rate, ok := m.ingestRate(); ok {
+		fmt.Fprintf(&b, "  %-12s %.1f chunks/s since the last refresh\n", "Ingest", rate)
+	} else {
+		fmt.Fprintf(&b, "  %-12s %s\n", "Ingest", topDimStyle.Render("measured from the second refresh"))
+	}
+	switch {
+	case m.measuring:
+		fmt.Fprintf(&b, "  %-12s %s\n", "Inference", topDimStyle.Render("measuring…"))
+	case m.throughput == nil:
+		fmt.Fprintf(&b, "  %-12s %s\n", "Inference", topDimStyle.Render("press t to measure"))
+	case m.throughput.err != nil:
+		fmt.Fprintf(&b, "  %-12s %s\n", "Inference", topErrorStyle.Render(m.throughput.err.Error()))
+	default:
+		fmt.Fprintf(&b, "  %-12s %.1f tok/s %s\n", "Inference", m.throughput.rate,
+			topDimStyle.Render("(measured at "+m.throughput.at.Format(time.TimeOnly)+")"))
+	}
+
+	b.WriteString("\n" + topDimStyle.Render("q quit · r refresh · t measure inference throughput") + "\n")
+	return b.String()
+}
+
+// viewSnapshot renders the probed sections.
+func (m topModel) viewSnapshot(b *strings.Builder) {
+	snap := m.snap
+	b.WriteString("\n" + topHeadingStyle.Render("Services") + "\n")
+	if snap.servicesErr != nil {
+		b.WriteString("  " + topErrorStyle.Render(snap.servicesErr.Error()) + "\n")
+	}
+	for _, name := range slices.Sorted(maps.Keys(snap.services)) {
+		state := snap.services[name]
+		style := topWarnStyle
+		if state == "active" {
+			style = topOKStyle
+		}
+		fmt.Fprintf(b, "  %-24s %s\n", name, style.Render(state))
+	}
+	if snap.err != nil {
+		b.WriteString("\n  " + topErrorStyle.Render(snap.err.Error()) + "\n")
+		return
+	}
+
+	overall := topOKStyle
+	if snap.health.Overall != overallHealthy {
+		overall = topErrorStyle
+	}
+	fmt.Fprintf(b, "\n%s  %s\n", topHeadingStyle.Render("Health"), overall.Render(snap.health.Overall))
+	for _, name := range slices.Sorted(maps.Keys(snap.health.Checks)) {
+		check := snap.health.Checks[name]
+		fmt.Fprintf(b, "  %-24s %s %s\n", name, healthStyle(check.State).Render(fmt.Sprintf("%-14s", check.State)), topDimStyle.Render(check.Detail))
+	}
+
+	if snap.opensearchErr != nil {
+		b.WriteString("\n  " + topErrorStyle.Render("opensearch: "+snap.opensearchErr.Error()) + "\n")
+		return
+	}
+
+	b.WriteString("\n" + topHeadingStyle.Render("Models") + "\n")
+	if len(snap.models) == 0 {
+		b.WriteString("  " + topDimStyle.Render("no models registered") + "\n")
+	}
+	for _, model := range snap.models {
+		role := model.Role
+		if role == "" {
+			role = "-"
+		}
+		fmt.Fprintf(b, "  %-10s %-48s %s %s\n", role, model.Name, modelStateStyle(model.State).Render(fmt.Sprintf("%-20s", model.State)), humanBytes(model.SizeBytes))
+	}
+
+	b.WriteString("\n" + topHeadingStyle.Render("Knowledge bases") + "\n")
+	if len(snap.indexes) == 0 {
+		b.WriteString("  " + topDimStyle.Render("no knowledge bases") + "\n")
+	}
+	for _, idx := range snap.indexes {
+		name, err := knowledge.KnowledgeBaseNameFromIndex(idx.Name)
+		if err != nil {
+			name = idx.Name
+		}
+		fmt.Fprintf(b, "  %-30s %10s docs %10s  %s\n", name, idx.DocsCount, idx.StoreSize, healthStyle(clusterHealthState(idx.Health)).Render(idx.Health))
+	}
+
+	b.WriteString("\n" + topHeadingStyle.Render("Recent ingests") + "\n")
+	if len(snap.recent) == 0 {
+		b.WriteString("  " + topDimStyle.Render("nothing ingested yet") + "\n")
+	}
+	for _, s := range snap.recent {
+		base, err := knowledge.KnowledgeBaseNameFromIndex(s.IndexName)
+		if err != nil {
+			base = s.IndexName
+		}
+		fmt.Fprintf(b, "  %-20s %-40s %-20s %s %6d chunks\n", s.UpdatedAt, s.SourceID, base, colorStatus(s.Status), s.ChunkCount)
+	}
+}
+
+// ingestRate is the rate completed chunks grew at between the last two
+// snapshots. Forgotten sources can shrink the count; that reads as zero.
+func (m topModel) ingestRate() (float64, bool) {
+	if m.prev == nil || m.snap == nil || m.prev.opensearchErr != nil || m.snap.opensearchErr != nil {
+		return 0, false
+	}
+	elapsed := m.snap.at.Sub(m.prev.at).Seconds()
+	if elapsed <= 0 {
+		return 0, false
+	}
+	return float64(max(m.snap.completedChunks-m.prev.completedChunks, 0)) / elapsed, true
+}
+
+// healthStyle colors a health check state.
+func healthStyle(state string) lipgloss.Style {
+	switch state {
+	case healthOK:
+		return topOKStyle
+	case healthDegraded:
+		return topWarnStyle
+	case healthNotConfigured:
+		return topDimStyle
+	default:
+		return topErrorStyle
+	}
+}
+
+// clusterHealthState maps an index's health color to a health check state.
+func clusterHealthState(color string) string {
+	switch color {
+	case "green":
+		return healthOK
+	case "yellow":
+		return healthDegraded
+	default:
+		return healthDown
+	}
+}
+
+// modelStateStyle colors an ML model's deployment state.
+func modelStateStyle(state string) lipgloss.Style {
+	switch state {
+	case "DEPLOYED":
+		return topOKStyle
+	case "PARTIALLY_DEPLOYED", "DEPLOYING":
+		return topWarnStyle
+	default:
+		return topDimStyle
+	}
+}
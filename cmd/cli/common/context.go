@@ -5,5 +5,23 @@ import "github.com/jpnorenam/rag-snap/pkg/storage"
 type Context struct {
 	Verbose bool
 	Debug   bool
+	Quiet   bool
+	NoColor bool
 	Config  storage.Config
+	// Readiness is the registry of which services are up. Commands record
+	// services they have just seen answer, best-effort.
+	Readiness *storage.Readiness
+	// OpenSearchURL, TikaURL, and OpenAIURL, when set, replace the configured
+	// service endpoints for this invocation (--opensearch-url and the like).
+	OpenSearchURL string
+	TikaURL       string
+	OpenAIURL     string
+	// Trace, when set, is the file every outbound HTTP exchange is appended
+	// to (--trace).
+	Trace string
+}
+
+// OverridesEndpoints reports whether any service endpoint is overridden.
+func (c *Context) OverridesEndpoints() bool {
+	return c.OpenSearchURL != "" || c.TikaURL != "" || c.OpenAIURL != ""
 }
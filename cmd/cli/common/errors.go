@@ -1,7 +1,60 @@
 package common
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/canonical/go-snapctl/env"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+)
 
 var (
 	ErrPermissionDenied = errors.New("permission denied, try again with sudo")
 )
+
+// Exit codes the CLI returns, so scripts can tell failures apart without
+// parsing messages. Any error not listed here exits with ExitFailure.
+const (
+	ExitFailure              = 1
+	ExitClusterUnavailable   = 3
+	ExitKnowledgeBaseMissing = 4
+	ExitSourceMissing        = 5
+	ExitModelNotDeployed     = 6
+)
+
+// ExitCode returns the exit code for an error a command returned.
+func ExitCode(err error) int {
+	switch {
+	case errors.Is(err, knowledge.ErrClusterUnavailable):
+		return ExitClusterUnavailable
+	case errors.Is(err, knowledge.ErrIndexNotFound):
+		return ExitKnowledgeBaseMissing
+	case errors.Is(err, knowledge.ErrSourceNotFound):
+		return ExitSourceMissing
+	case errors.Is(err, knowledge.ErrModelNotDeployed):
+		return ExitModelNotDeployed
+	}
+	return ExitFailure
+}
+
+// Hint returns a remediation hint for an error a command returned, or "" if
+// it has none.
+func Hint(err error) string {
+	instanceName := env.SnapInstanceName()
+	if instanceName == "" { // not a snap
+		instanceName = "<snap-instance-name>"
+	}
+	rag := instanceName + ".rag"
+
+	switch {
+	case errors.Is(err, knowledge.ErrClusterUnavailable):
+		return fmt.Sprintf("Run \"%s status\" to check the services.", rag)
+	case errors.Is(err, knowledge.ErrIndexNotFound):
+		return fmt.Sprintf("Run \"%s knowledge list\" to see the knowledge bases, or \"%s knowledge create\" to create one.", rag, rag)
+	case errors.Is(err, knowledge.ErrSourceNotFound):
+		return fmt.Sprintf("Run \"%s knowledge list --sources\" to see the ingested sources.", rag)
+	case errors.Is(err, knowledge.ErrModelNotDeployed):
+		return fmt.Sprintf("Run \"%s knowledge init\" to deploy the models.", rag)
+	}
+	return ""
+}
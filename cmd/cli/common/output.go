@@ -0,0 +1,58 @@
+package common
+
+import (
+	"os"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// quiet suppresses spinners, progress, and informational notices, leaving only
+// results and errors on the output.
+var quiet bool
+
+// ConfigureOutput applies the global --quiet and --no-color flags. Colors are
+// also off whenever stdout is not a terminal or NO_COLOR is set; the color
+// package detects both on its own, so noColor only ever turns colors off.
+func ConfigureOutput(quietFlag, noColor bool) {
+	quiet = quietFlag
+	if noColor {
+		color.NoColor = true
+	}
+}
+
+// Quiet reports whether --quiet is in effect.
+func Quiet() bool {
+	return quiet
+}
+
+// Infof prints an informational notice — which server is in use, a default
+// that was picked — unless --quiet is in effect.
+func Infof(format string, args ...any) {
+	if quiet {
+		return
+	}
+	Printf(format, args...)
+}
+
+// Report prints the outcome of one item of a batch, marked ✅ or ❌ when
+// colors are on. Successes are left out under --quiet; failures never are.
+func Report(ok bool, format string, args ...any) {
+	if ok && quiet {
+		return
+	}
+	if !color.NoColor {
+		mark := "✅ "
+		if !ok {
+			mark = "❌ "
+		}
+		format = mark + format
+	}
+	Printf(format, args...)
+}
+
+// CanPrompt reports whether both stdin and stdout are terminals, i.e. whether an
+// interactive form can be shown in place of a missing argument.
+func CanPrompt() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
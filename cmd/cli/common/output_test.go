@@ -0,0 +1,39 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/jpnorenam/rag-snap/pkg/progress"
+)
+
+// TestQuietOutput prints what a batch ingestion prints through pkg/progress:
+// --quiet leaves only its results and failures, and without colors the
+// outcome lines lose their marks.
+func TestQuietOutput(t *testing.T) {
+	saved, savedQuiet, savedNoColor := block, quiet, color.NoColor
+	defer func() { block, quiet, color.NoColor = saved, savedQuiet, savedNoColor }()
+
+	batch := func() string {
+		var out bytes.Buffer
+		block = NewProgress(&out, func() int { return 0 })
+		progress.Infof("[1/2] Processing: a.pdf\n")
+		progress.Succeeded("Success: a.pdf\n")
+		progress.Infof("[2/2] Processing: b.pdf\n")
+		progress.Failed("Error processing b.pdf: unreadable\n")
+		progress.Printf("Batch done\n")
+		return out.String()
+	}
+
+	ConfigureOutput(false, false)
+	color.NoColor = false
+	if got, want := batch(), "[1/2] Processing: a.pdf\n✅ Success: a.pdf\n[2/2] Processing: b.pdf\n❌ Error processing b.pdf: unreadable\nBatch done\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	ConfigureOutput(true, true)
+	if got, want := batch(), "Error processing b.pdf: unreadable\nBatch done\n"; got != want {
+		t.Errorf("--quiet --no-color output = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,237 @@
+package common
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const (
+	// progressInterval is how often the tasks in progress are redrawn.
+	progressInterval = 200 * time.Millisecond
+
+	hideCursor = "\033[?25l"
+	showCursor = "\033[?25h"
+	// eraseLine returns to the start of the line and clears it; cursorUp
+	// moves to the line above.
+	eraseLine = "\r\033[K"
+	cursorUp  = "\033[1A"
+)
+
+// progressFrames are the spinner frames drawn after each task's label.
+var progressFrames = []string{"|", "/", "-", "\\"}
+
+// Progress draws the tasks in progress as one block, a line per task with its
+// spinner, from a single render loop. Tasks started concurrently, say by
+// parallel extractions, each get their own line instead of overwriting one
+// another's frames, and a nested task is indented under its parent. The loop
+// runs while any task does; finished tasks are erased from the block.
+type Progress struct {
+	mu    sync.Mutex
+	out   io.Writer
+	width func() int
+	tasks []*Task
+	frame int
+	// drawn is the number of lines the last frame took.
+	drawn int
+	// stop ends the running render loop; nil when none runs.
+	stop chan struct{}
+}
+
+// Task is one task of a Progress block. A nil Task, returned when progress is
+// not shown, ignores every call.
+type Task struct {
+	p      *Progress
+	parent *Task
+	depth  int
+	label  string
+}
+
+// NewProgress returns a Progress drawing to out, cutting lines to the width
+// reports; a width of 0 or less leaves them whole.
+func NewProgress(out io.Writer, width func() int) *Progress {
+	return &Progress{out: out, width: width}
+}
+
+// block is the progress block every spinner of the CLI draws in.
+var block = NewProgress(os.Stdout, func() int {
+	w, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return 0
+	}
+	return w
+})
+
+// StartTask shows label as a task in progress on the CLI's shared block until
+// the task is done. It returns nil when progress is not shown (see
+// Interactive).
+func StartTask(label string) *Task {
+	if !Interactive() {
+		return nil
+	}
+	return block.Start(label)
+}
+
+// Printf prints whole lines above the tasks in progress, so the block stays
+// below the output instead of tearing through it.
+func Printf(format string, args ...any) {
+	block.Printf(format, args...)
+}
+
+// Start shows label as a new top-level task.
+func (p *Progress) Start(label string) *Task {
+	t := &Task{p: p, label: label}
+	p.add(t)
+	return t
+}
+
+// Start shows label as a task nested under t, drawn below it and indented.
+// Finishing t finishes its nested tasks too.
+func (t *Task) Start(label string) *Task {
+	if t == nil {
+		return nil
+	}
+	child := &Task{p: t.p, parent: t, depth: t.depth + 1, label: label}
+	t.p.add(child)
+	return child
+}
+
+// Update changes the label shown for t.
+func (t *Task) Update(label string) {
+	if t == nil {
+		return
+	}
+	t.p.mu.Lock()
+	defer t.p.mu.Unlock()
+	t.label = label
+}
+
+// Done erases t, and the tasks nested under it, from the block.
+func (t *Task) Done() {
+	if t == nil {
+		return
+	}
+	t.p.remove(t)
+}
+
+// within reports whether t is nested, at any depth, under ancestor.
+func (t *Task) within(ancestor *Task) bool {
+	for p := t.parent; p != nil; p = p.parent {
+		if p == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// add inserts t after its parent's last nested task, or at the end of the
+// block, and starts the render loop if none runs.
+func (p *Progress) add(t *Task) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	at := len(p.tasks)
+	if t.parent != nil {
+		i := slices.Index(p.tasks, t.parent)
+		if i < 0 {
+			return // The parent is done; so is t.
+		}
+		for at = i + 1; at < len(p.tasks) && p.tasks[at].within(t.parent); at++ {
+		}
+	}
+	p.tasks = slices.Insert(p.tasks, at, t)
+
+	if p.stop == nil {
+		p.stop = make(chan struct{})
+		fmt.Fprint(p.out, hideCursor)
+		go p.loop(p.stop)
+	}
+	p.render()
+}
+
+// remove drops t and its nested tasks, stopping the render loop once no
+// task is left.
+func (p *Progress) remove(t *Task) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := len(p.tasks)
+	p.tasks = slices.DeleteFunc(p.tasks, func(x *Task) bool { return x == t || x.within(t) })
+	if len(p.tasks) == n {
+		return
+	}
+	if len(p.tasks) > 0 {
+		p.render()
+		return
+	}
+	p.erase()
+	fmt.Fprint(p.out, showCursor)
+	close(p.stop)
+	p.stop = nil
+}
+
+// Printf prints above the block: it erases the block, prints, and draws the
+// block again below the output, which should end in a newline.
+func (p *Progress) Printf(format string, args ...any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.erase()
+	fmt.Fprintf(p.out, format, args...)
+	if len(p.tasks) > 0 {
+		p.render()
+	}
+}
+
+// loop redraws the block every progressInterval until stop is closed.
+func (p *Progress) loop(stop chan struct{}) {
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			if p.stop == stop {
+				p.frame++
+				p.render()
+			}
+			p.mu.Unlock()
+		}
+	}
+}
+
+// render replaces the last frame with the current tasks. p.mu must be held.
+func (p *Progress) render() {
+	p.erase()
+	width := p.width()
+	frame := progressFrames[p.frame%len(progressFrames)]
+	lines := make([]string, len(p.tasks))
+	for i, t := range p.tasks {
+		line := strings.Repeat("  ", t.depth) + t.label + " " + frame
+		if runes := []rune(line); width > 0 && len(runes) >= width {
+			// A line reaching the last column wraps, and the next erase
+			// would miss the wrapped part.
+			line = string(runes[:width-1])
+		}
+		lines[i] = line
+	}
+	fmt.Fprint(p.out, strings.Join(lines, "\n"))
+	p.drawn = len(lines)
+}
+
+// erase clears the last frame, leaving the cursor where it began. p.mu must
+// be held.
+func (p *Progress) erase() {
+	if p.drawn == 0 {
+		return
+	}
+	fmt.Fprint(p.out, eraseLine+strings.Repeat(cursorUp+eraseLine, p.drawn-1))
+	p.drawn = 0
+}
@@ -0,0 +1,79 @@
+package common
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// labels returns the block's lines as drawn, indentation included.
+func labels(p *Progress) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var got []string
+	for _, t := range p.tasks {
+		got = append(got, strings.Repeat("  ", t.depth)+t.label)
+	}
+	return got
+}
+
+func TestProgressNestedTasks(t *testing.T) {
+	var out bytes.Buffer
+	p := NewProgress(&out, func() int { return 0 })
+
+	a := p.Start("a")
+	b := p.Start("b")
+	c := a.Start("c")
+	a.Start("d")
+	c.Start("e")
+	if got, want := strings.Join(labels(p), ","), "a,  c,    e,  d,b"; got != want {
+		t.Errorf("block = %q, want %q", got, want)
+	}
+
+	c.Update("c2")
+	c.Done()
+	if got, want := strings.Join(labels(p), ","), "a,  d,b"; got != want {
+		t.Errorf("block after c is done = %q, want %q", got, want)
+	}
+	a.Done()
+	a.Done()
+	a.Start("late")
+	if got, want := strings.Join(labels(p), ","), "b"; got != want {
+		t.Errorf("block after a is done = %q, want %q", got, want)
+	}
+
+	b.Done()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil || p.drawn != 0 {
+		t.Errorf("render loop still running (%v) or %d lines drawn after the last task", p.stop != nil, p.drawn)
+	}
+	if !strings.HasPrefix(out.String(), hideCursor) || !strings.HasSuffix(out.String(), showCursor) {
+		t.Errorf("output %q does not hide then restore the cursor", out.String())
+	}
+}
+
+func TestProgressPrintf(t *testing.T) {
+	var out bytes.Buffer
+	p := NewProgress(&out, func() int { return 8 })
+	task := p.Start("extracting file")
+	defer task.Done()
+
+	p.Printf("done %d\n", 1)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// The line is cut to the width less one, so it never wraps.
+	if got, want := out.String(), eraseLine+"done 1\n"+"extract"; !strings.HasSuffix(got, want) {
+		t.Errorf("Printf wrote %q, want it to end with %q", got, want)
+	}
+}
+
+func TestNilTask(t *testing.T) {
+	var task *Task
+	task.Update("x")
+	task.Done()
+	if task.Start("child") != nil {
+		t.Error("a nil task started a nested task")
+	}
+}
@@ -2,42 +2,39 @@ package common
 
 import (
 	"os"
-	"time"
 
-	"github.com/briandowns/spinner"
+	"github.com/jpnorenam/rag-snap/pkg/progress"
 	"golang.org/x/term"
 )
 
-// interactive reports whether stdout is a terminal. The operations these spinners
-// wrap also run inside ragd, where every animation frame would land in the
-// daemon's journal; there the spinner is skipped entirely.
-func interactive() bool {
-	return term.IsTerminal(int(os.Stdout.Fd()))
+// The knowledge and processing packages report their long-running steps,
+// and print while steps may be in progress, through pkg/progress; show both
+// on the same progress block.
+func init() {
+	progress.SetSpinner(StartProgressSpinner)
+	progress.SetPrinter(Printf)
+	progress.SetNotifier(Infof)
+	progress.SetReporter(Report)
 }
 
-func StartProgressSpinner(prefix string) (stop func()) {
-	if !interactive() {
-		return func() {}
-	}
-
-	s := spinner.New(spinner.CharSets[9], time.Millisecond*200)
-	s.Prefix = prefix + " "
-	s.Start()
+// Interactive reports whether stdout is a terminal and --quiet is not in effect,
+// i.e. whether animations and screen redraws belong on it. The operations the
+// spinners wrap also run inside ragd, where every animation frame would land in
+// the daemon's journal; there the spinner is skipped entirely.
+func Interactive() bool {
+	return !quiet && term.IsTerminal(int(os.Stdout.Fd()))
+}
 
-	return s.Stop
+// StartProgressSpinner shows prefix with a spinner on the CLI's progress block
+// (see StartTask) and returns the function that stops it.
+func StartProgressSpinner(prefix string) (stop func()) {
+	return StartTask(prefix).Done
 }
 
 // StartUpdatableSpinner starts a spinner whose prefix can be changed while it
 // runs (e.g. to show live operation progress). It returns an update function to
 // set a new prefix and a stop function to halt the spinner.
 func StartUpdatableSpinner(prefix string) (update func(string), stop func()) {
-	if !interactive() {
-		return func(string) {}, func() {}
-	}
-
-	s := spinner.New(spinner.CharSets[9], time.Millisecond*200)
-	s.Prefix = prefix + " "
-	s.Start()
-
-	return func(p string) { s.Prefix = p + " " }, s.Stop
+	t := StartTask(prefix)
+	return t.Update, t.Done
 }
@@ -4,28 +4,19 @@ import (
 	"fmt"
 
 	"github.com/canonical/go-snapctl/env"
+	"github.com/jpnorenam/rag-snap/pkg/suggest"
 )
 
 func SuggestServerStartup() string {
-	return "Try again when the server is ready."
-}
-
-// daemonServiceName returns the snap service name of the ragd daemon
-// (e.g. "rag-cli.ragd"), falling back to a placeholder outside a snap.
-func daemonServiceName() string {
-	instanceName := env.SnapInstanceName()
-	if instanceName == "" { // not a snap
-		instanceName = "<snap-instance-name>"
-	}
-	return instanceName + ".ragd"
+	return suggest.ServerStartup()
 }
 
 func SuggestServerLogs() string {
-	return fmt.Sprintf("Run \"snap logs %s\" to see the server logs.", daemonServiceName())
+	return suggest.ServerLogs()
 }
 
 func SuggestStartServer() string {
-	return fmt.Sprintf("Run \"sudo snap start %s\" to start the server.", daemonServiceName())
+	return fmt.Sprintf("Run \"sudo snap start %s\" to start the server.", suggest.DaemonServiceName())
 }
 
 // SuggestSetModelID returns the command that writes a resolved model ID to the
@@ -3,7 +3,9 @@ package config
 import (
 	"fmt"
 	"slices"
+	"strings"
 
+	"github.com/jpnorenam/rag-snap/pkg/httpclient"
 	"github.com/jpnorenam/rag-snap/pkg/storage"
 	"github.com/spf13/cobra"
 )
@@ -29,6 +31,25 @@ func IsDeprecated(key string) bool {
 	return slices.Contains(deprecatedConfig, key)
 }
 
+// RedactedValue stands in for the value of a secret key wherever config is read
+// back: the daemon's config API and config export.
+const RedactedValue = "<redacted>"
+
+// secretKeySuffixes marks config keys whose value must never be read back. Matching
+// is on the key's final segment, not on the value, so it is deterministic: a key is
+// secret because of what it is, not because of what happens to be stored in it today.
+// The service credentials (OPENSEARCH_USERNAME/PASSWORD, CHAT_API_KEY) are
+// environment variables and cannot appear here at all; this guards the config keys
+// that *are* secrets, today gdrive.client.secret and kapa.api.key.
+var secretKeySuffixes = []string{"secret", "password", "token", "key"}
+
+// IsSecret reports whether a config key holds a secret. The daemon's config API
+// redacts these on read and config export masks them, from this one list.
+func IsSecret(key string) bool {
+	segments := strings.Split(key, ".")
+	return slices.Contains(secretKeySuffixes, segments[len(segments)-1])
+}
+
 func Group(title string) *cobra.Group {
 	return &cobra.Group{
 		ID:    groupID,
@@ -60,3 +81,25 @@ func GetString(cfg storage.Config, key string) (string, error) {
 	}
 	return fmt.Sprintf("%v", val), nil
 }
+
+// Lookup returns a key lookup over cfg for the packages' ParseSettings
+// functions. A key that cannot be read looks unset, so its default applies.
+func Lookup(cfg storage.Config) func(key string) string {
+	return func(key string) string {
+		val, _ := GetString(cfg, key)
+		return val
+	}
+}
+
+// ConfigureHTTPClient applies the http.* keys (proxy, timeouts, response-size
+// cap) to the shared outbound HTTP client factory. The CLI calls it before every
+// command and the daemon on every (re)start, so both honour the same settings.
+func ConfigureHTTPClient(cfg storage.Config) error {
+	opts, err := httpclient.OptionsFromLookup(func(key string) (string, error) {
+		return GetString(cfg, key)
+	})
+	if err != nil {
+		return err
+	}
+	return httpclient.Configure(opts)
+}
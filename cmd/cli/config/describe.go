@@ -0,0 +1,221 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+// keyDoc documents a configuration key for `config describe`. Default is the
+// built-in value a key takes while its package value is empty.
+type keyDoc struct {
+	Description string
+	Default     string
+}
+
+// keyDocs documents the keys the snap registers. The first sentence of each
+// description is the summary `config describe` lists; keep it short.
+var keyDocs = map[string]keyDoc{
+	"chat.http.host":          {Description: "Host of the OpenAI-compatible inference server."},
+	"chat.http.port":          {Description: "Port of the inference server."},
+	"chat.http.path":          {Description: "Base path of the inference server's API, e.g. v1."},
+	"chat.http.tls":           {Description: "Whether to reach the inference server over HTTPS.", Default: "false"},
+	"chat.model":              {Description: "Model chat and answer use. Empty picks the server's model, asking when it serves several."},
+	"chat.context.max":        {Description: "Most characters of retrieved context injected into a prompt. 0 is no limit.", Default: "0"},
+	"chat.context.truncation": {Description: "How context past chat.context.max is cut: drop, truncate, or summarize.", Default: "drop"},
+	"chat.multiquery":         {Description: "Widen retrieval with LLM paraphrases of each question. /set multiquery toggles it per session.", Default: "false"},
+	"chat.rag.top-k":          {Description: "How many hits each retrieval search fetches. /set top-k overrides it per session.", Default: "15"},
+	"chat.rag.min-score":      {Description: "Score below which knowledge base hits are dropped instead of injected. 0 keeps every hit.", Default: "0"},
+	"chat.rag.sanitize":       {Description: "Strip directive-looking lines from retrieved chunks and delimit them, guarding against prompt injection.", Default: "true"},
+	"chat.rag.confidence":     {Description: "Top retrieval score below which a question is answered with \"I cannot find it in the knowledge base\" instead of a guess. 0 turns it off.", Default: "0"},
+	"chat.cache.ttl":          {Description: "How long answers are cached for repeated prompts. 0 is no cache; /nocache bypasses it.", Default: "0"},
+	"chat.memory":             {Description: "Summarize each chat into the memory index when it ends, for /recall.", Default: "false"},
+	"chat.history.persist":    {Description: "Save chat prompt history across chats. false keeps it in memory only.", Default: "true"},
+	"chat.history.max":        {Description: "Most prompts the chat history file keeps.", Default: "500"},
+
+	"knowledge.http.host":            {Description: "Host of the OpenSearch cluster."},
+	"knowledge.http.hosts":           {Description: "Comma-separated OpenSearch node addresses. Replaces knowledge.http.host when set."},
+	"knowledge.http.port":            {Description: "Port of the OpenSearch cluster."},
+	"knowledge.http.tls":             {Description: "Whether to reach OpenSearch over HTTPS.", Default: "true"},
+	"knowledge.model.embedding":      {Description: "ID of the embedding model in OpenSearch. Set by knowledge init."},
+	"knowledge.model.rerank":         {Description: "ID of the rerank model in OpenSearch. Set by knowledge init."},
+	"knowledge.model.timeout":        {Description: "How long knowledge init waits for each model registration or deployment.", Default: "5m"},
+	"knowledge.bulk.bytes":           {Description: "Payload cap of each bulk indexing request.", Default: "5M"},
+	"knowledge.bulk.docs":            {Description: "Documents per bulk indexing request.", Default: "200"},
+	"knowledge.bulk.refresh":         {Description: "Refresh policy applied when an ingest finishes: false, wait_for, or true.", Default: "false"},
+	"knowledge.ingest.dedup":         {Description: "Leave out chunks whose exact content another source already put in the target base.", Default: "false"},
+	"knowledge.ingest.pre-hook":      {Description: "Shell command run before each ingest, e.g. to convert a proprietary format; a failure fails the ingest."},
+	"knowledge.ingest.post-hook":     {Description: "Shell command run after each ingest with its result, e.g. to notify a webhook."},
+	"knowledge.search.boosts":        {Description: "Weights of content, title, and heading matches in the lexical search, as field=weight pairs.", Default: "content=1,title=2,heading=1.5"},
+	"knowledge.search.weights":       {Description: "Weights of knowledge bases when merging a search across several, as base=weight pairs; bases left out weigh 1."},
+	"knowledge.search.normalization": {Description: "Score normalization applied to each base before merging a search across several: none, min-max, or z-score.", Default: "none"},
+	"knowledge.guard":                {Description: "Ingest at lower CPU and IO priority, in smaller requests, pausing under memory pressure.", Default: "false"},
+	"knowledge.guard.memory":         {Description: "System memory use, in percent, past which a guarded ingest pauses.", Default: "85"},
+	"knowledge.read-only":            {Description: "Refuse knowledge commands that change the cluster, such as ingest, forget, create, and delete.", Default: "false"},
+	"knowledge.storage.warn-at":      {Description: "Store size past which knowledge list flags a knowledge base, e.g. 5G, with <base>=<size> overrides."},
+
+	"tika.http.host":     {Description: "Host of the Tika server."},
+	"tika.http.port":     {Description: "Port of the Tika server."},
+	"tika.http.path":     {Description: "Base path of the Tika server's API."},
+	"tika.http.tls":      {Description: "Whether to reach Tika over HTTPS.", Default: "false"},
+	"tika.ready.timeout": {Description: "How long ingestion waits for a starting Tika server before failing.", Default: "60s"},
+	"tika.concurrency":   {Description: "Most Tika extractions run at once, and how many repository files a batch job extracts ahead of indexing.", Default: "4"},
+	"tika.rmeta":         {Description: "Extract content and metadata with one Tika /rmeta request instead of two.", Default: "false"},
+
+	"http.proxy":           {Description: "Proxy for outbound HTTP. Empty uses HTTP_PROXY and HTTPS_PROXY."},
+	"http.timeout.connect": {Description: "Connect timeout of outbound HTTP requests.", Default: "30s"},
+	"http.timeout.read":    {Description: "Read timeout of outbound HTTP requests. 0 is none.", Default: "0"},
+	"http.response.max":    {Description: "Largest outbound HTTP response body accepted. 0 is no cap.", Default: "0"},
+
+	"api.socket.group":     {Description: "Group whose members, besides root, may use the ragd unix socket."},
+	"api.socket.mode":      {Description: "Octal file mode of the ragd unix socket."},
+	"api.loopback.enabled": {Description: "Also serve the ragd API on a loopback TCP listener, with a bearer token."},
+	"api.loopback.address": {Description: "Loopback address the ragd TCP listener binds. Port 0 lets the OS pick."},
+	"gdrive.client.id":     {Description: "OAuth2 client ID for importing from Google Drive."},
+	"gdrive.client.secret": {Description: "OAuth2 client secret for importing from Google Drive."},
+	"kapa.enabled":         {Description: "Use Kapa.ai as an extra retrieval source when its keys are set."},
+	"kapa.api.key":         {Description: "Kapa.ai API key. The KAPA_API_KEY environment variable takes precedence."},
+	"kapa.project.id":      {Description: "Kapa.ai project ID. The KAPA_PROJECT_ID environment variable takes precedence."},
+	"temp.quota":           {Description: "Size cap on crawled pages, downloads, and archives staged in the temp directory.", Default: "2G"},
+	"temp.max-age":         {Description: "Age after which leftover temp files are removed at startup.", Default: "24h"},
+}
+
+// Sources of a key's effective value, from `config describe`.
+const (
+	sourceUser       = "user"
+	sourceDiscovered = "discovered"
+	sourcePackage    = "package"
+	sourceUnset      = "unset"
+)
+
+type describeCommand struct {
+	*common.Context
+}
+
+func DescribeCommand(ctx *common.Context) *cobra.Command {
+	var cmd describeCommand
+	cmd.Context = ctx
+
+	cobraCmd := &cobra.Command{
+		Use:   "describe [<key>]",
+		Short: "Describe configurations",
+		Long: "Describe a configuration: what it does, which layer its value comes from\n" +
+			"(user, discovered, or package), its default, and its current value.\n" +
+			"Without a key, list every configuration with a summary.",
+		GroupID:           groupID,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE:              cmd.run,
+	}
+
+	return cobraCmd
+}
+
+func (cmd *describeCommand) run(_ *cobra.Command, args []string) error {
+	values, err := cmd.Config.GetAll()
+	if err != nil {
+		return fmt.Errorf("error getting values: %v", err)
+	}
+	sources, err := cmd.sources()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		return cmd.describeKey(args[0], values, sources)
+	}
+
+	keys := slices.Collect(maps.Keys(keyDocs))
+	for k := range values {
+		if _, documented := keyDocs[k]; !documented {
+			keys = append(keys, k)
+		}
+	}
+	keys = slices.DeleteFunc(keys, IsDeprecated)
+	slices.Sort(keys)
+
+	fmt.Printf("%-28s %-11s %s\n", "KEY", "SOURCE", "DESCRIPTION")
+	for _, k := range keys {
+		fmt.Printf("%-28s %-11s %s\n", k, keySource(k, sources), summary(keyDocs[k].Description))
+	}
+	return nil
+}
+
+func (cmd *describeCommand) describeKey(key string, values map[string]any, sources map[string]map[string]any) error {
+	doc, documented := keyDocs[key]
+	_, set := values[key]
+	if IsDeprecated(key) || (!documented && !set) {
+		return fmt.Errorf("unknown key %q", key)
+	}
+
+	description := doc.Description
+	if description == "" {
+		description = "(undocumented)"
+	}
+	def := doc.Default
+	if v := fmt.Sprint(sources[sourcePackage][key]); sources[sourcePackage][key] != nil && v != "" {
+		def = v
+	}
+	value, _ := GetString(cmd.Config, key)
+
+	fmt.Printf("key:         %s\n", key)
+	fmt.Printf("description: %s\n", description)
+	fmt.Printf("source:      %s\n", keySource(key, sources))
+	fmt.Printf("default:     %s\n", orNone(def))
+	fmt.Printf("value:       %s\n", orNone(value))
+	return nil
+}
+
+// sources returns the keys each layer sets, by source name.
+func (cmd *describeCommand) sources() (map[string]map[string]any, error) {
+	user, err := cmd.Config.GetAllFromLayer(storage.UserConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user values: %v", err)
+	}
+	pkg, err := cmd.Config.GetAllFromLayer(storage.PackageConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error getting package values: %v", err)
+	}
+	discovered := map[string]any{}
+	if dc, ok := cmd.Config.(*discoveryConfig); ok {
+		if discovered, err = dc.discovered(); err != nil {
+			return nil, err
+		}
+	}
+	return map[string]map[string]any{
+		sourceUser:       user,
+		sourceDiscovered: discovered,
+		sourcePackage:    pkg,
+	}, nil
+}
+
+// keySource names the layer key's effective value comes from, following the
+// precedence WithDiscovery applies.
+func keySource(key string, sources map[string]map[string]any) string {
+	for _, source := range []string{sourceUser, sourceDiscovered, sourcePackage} {
+		if _, ok := sources[source][key]; ok {
+			return source
+		}
+	}
+	return sourceUnset
+}
+
+// summary returns the first sentence of a description.
+func summary(description string) string {
+	if i := strings.Index(description, ". "); i >= 0 {
+		return description[:i+1]
+	}
+	return description
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
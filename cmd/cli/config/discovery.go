@@ -0,0 +1,151 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/canonical/go-snapctl/env"
+	"github.com/jpnorenam/rag-snap/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// envEndpointsDir overrides where endpoint registrations are read from, for
+// runs outside a snap and for pointing a snap at a hand-written registration.
+const envEndpointsDir = "RAG_ENDPOINTS_DIR"
+
+// discoveredServices are the backends a companion snap can register, named by
+// their config key prefix: a registration for "knowledge" fills in the
+// knowledge.http.* keys.
+var discoveredServices = []string{"chat", "knowledge", "tika"}
+
+// endpointRegistration is a companion snap's description of the endpoint it
+// serves. Empty fields are left to config.
+type endpointRegistration struct {
+	Host string `yaml:"host"`
+	Port string `yaml:"port"`
+	Path string `yaml:"path"`
+	TLS  *bool  `yaml:"tls"`
+}
+
+// WithDiscovery returns cfg with endpoints registered by companion snaps
+// layered between the package defaults and the user's overrides: a discovered
+// value replaces a package value, while anything set with `set` still wins.
+//
+// Registrations are read from $SNAP_COMMON/endpoints (or $RAG_ENDPOINTS_DIR),
+// where the chat-endpoint, knowledge-endpoint, and tika-endpoint content plugs
+// mount each provider's directory. A service is registered by
+// <dir>/<service>/endpoint.yaml — the content plug layout — or by a plain
+// <dir>/<service>.yaml file. Reads happen on every lookup, so the CLI sees a
+// connected or disconnected plug right away; ragd on its next reload.
+func WithDiscovery(cfg storage.Config) storage.Config {
+	dir := os.Getenv(envEndpointsDir)
+	if dir == "" {
+		if common := env.SnapCommon(); common != "" {
+			dir = filepath.Join(common, "endpoints")
+		}
+	}
+	if dir == "" {
+		return cfg
+	}
+	return &discoveryConfig{Config: cfg, dir: dir}
+}
+
+type discoveryConfig struct {
+	storage.Config
+	dir string
+}
+
+// Get returns the effective value of key with discovered endpoints applied.
+func (c *discoveryConfig) Get(key string) (map[string]any, error) {
+	values, err := c.Config.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	discovered, err := c.discovered()
+	if err != nil {
+		return nil, err
+	}
+	maps.DeleteFunc(discovered, func(k string, _ any) bool {
+		return k != key && !strings.HasPrefix(k, key+".")
+	})
+	return c.overlay(values, discovered)
+}
+
+// GetAll returns every effective value with discovered endpoints applied.
+func (c *discoveryConfig) GetAll() (map[string]any, error) {
+	values, err := c.Config.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	discovered, err := c.discovered()
+	if err != nil {
+		return nil, err
+	}
+	return c.overlay(values, discovered)
+}
+
+// overlay sets each discovered value on values unless the user overrides it.
+func (c *discoveryConfig) overlay(values, discovered map[string]any) (map[string]any, error) {
+	if len(discovered) == 0 {
+		return values, nil
+	}
+	overrides, err := c.Config.GetAllFromLayer(storage.UserConfig)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range discovered {
+		if _, overridden := overrides[k]; !overridden {
+			values[k] = v
+		}
+	}
+	return values, nil
+}
+
+// discovered reads the registrations present in the endpoints directory as
+// config keys. A missing registration is not an error; a malformed one is, so
+// a broken provider is reported rather than silently ignored.
+func (c *discoveryConfig) discovered() (map[string]any, error) {
+	values := map[string]any{}
+	for _, service := range discoveredServices {
+		reg, found, err := readRegistration(c.dir, service)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			continue
+		}
+		for suffix, value := range map[string]string{"host": reg.Host, "port": reg.Port, "path": reg.Path} {
+			if value != "" {
+				values[service+".http."+suffix] = value
+			}
+		}
+		if reg.TLS != nil {
+			values[service+".http.tls"] = fmt.Sprint(*reg.TLS)
+		}
+	}
+	return values, nil
+}
+
+func readRegistration(dir, service string) (endpointRegistration, bool, error) {
+	var reg endpointRegistration
+	for _, path := range []string{
+		filepath.Join(dir, service, "endpoint.yaml"),
+		filepath.Join(dir, service+".yaml"),
+	} {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return reg, false, fmt.Errorf("reading %s endpoint registration: %w", service, err)
+		}
+		if err := yaml.Unmarshal(data, &reg); err != nil {
+			return reg, false, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		return reg, true, nil
+	}
+	return reg, false, nil
+}
@@ -0,0 +1,229 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/pkg/storage"
+	"github.com/jpnorenam/rag-snap/pkg/utils"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileVersion is the version of the config export format.
+const configFileVersion = 1
+
+// configFile is the file config export writes and config import reads: the
+// package and user layers, each as flat key/value pairs. Discovered endpoints
+// are left out, since they are worked out anew on every host.
+type configFile struct {
+	Version int            `yaml:"version"`
+	Package map[string]any `yaml:"package,omitempty"`
+	User    map[string]any `yaml:"user,omitempty"`
+}
+
+type exportCommand struct {
+	*common.Context
+
+	// flags
+	includeSecrets bool
+}
+
+func ExportCommand(ctx *common.Context) *cobra.Command {
+	var cmd exportCommand
+	cmd.Context = ctx
+
+	cobraCmd := &cobra.Command{
+		Use:   "export <file.yaml>",
+		Short: "Export the configuration to a file",
+		Long: "Write the package and user configuration layers to a YAML file, to back up a\n" +
+			"tuned deployment or provision others from it with config import. Secret keys\n" +
+			"are written as " + RedactedValue + " unless --include-secrets is given. Use - to\n" +
+			"write to standard output.",
+		GroupID: groupID,
+		Args:    cobra.ExactArgs(1),
+		RunE:    cmd.run,
+	}
+
+	cobraCmd.Flags().BoolVar(&cmd.includeSecrets, "include-secrets", false, "write secret values in clear instead of masking them")
+
+	return cobraCmd
+}
+
+func (cmd *exportCommand) run(_ *cobra.Command, args []string) error {
+	file := configFile{Version: configFileVersion}
+	var err error
+	if file.Package, err = cmd.layer(storage.PackageConfig); err != nil {
+		return err
+	}
+	if file.User, err = cmd.layer(storage.UserConfig); err != nil {
+		return err
+	}
+
+	out, err := yaml.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("error serializing configuration: %v", err)
+	}
+	if args[0] == "-" {
+		fmt.Printf("%s", out)
+		return nil
+	}
+	// Owner-only, as the file can hold secrets.
+	if err := os.WriteFile(args[0], out, 0600); err != nil {
+		return fmt.Errorf("error writing %s: %v", args[0], err)
+	}
+	fmt.Printf("Exported %d package and %d user values to %s\n", len(file.Package), len(file.User), args[0])
+	return nil
+}
+
+// layer reads one config layer for export, without deprecated keys and with
+// secrets masked unless --include-secrets is given.
+func (cmd *exportCommand) layer(confType storage.ConfigType) (map[string]any, error) {
+	values, err := cmd.Config.GetAllFromLayer(confType)
+	if err != nil {
+		return nil, fmt.Errorf("error getting %s values: %v", confType, err)
+	}
+	for k, v := range values {
+		switch {
+		case IsDeprecated(k):
+			delete(values, k)
+		case !cmd.includeSecrets && IsSecret(k) && fmt.Sprint(v) != "":
+			values[k] = RedactedValue
+		}
+	}
+	return values, nil
+}
+
+type importCommand struct {
+	*common.Context
+
+	// flags
+	replace bool
+}
+
+func ImportCommand(ctx *common.Context) *cobra.Command {
+	var cmd importCommand
+	cmd.Context = ctx
+
+	cobraCmd := &cobra.Command{
+		Use:   "import <file.yaml>",
+		Short: "Import the configuration from a file",
+		Long: "Restore the package and user configuration layers from a file written by\n" +
+			"config export. Every key is checked before any is written. Masked secrets\n" +
+			"are skipped and keep their current value; set them with config set. With\n" +
+			"--replace, user overrides the file does not list are removed, so the user\n" +
+			"layer ends up exactly as exported.",
+		GroupID: groupID,
+		Args:    cobra.ExactArgs(1),
+		RunE:    cmd.run,
+	}
+
+	cobraCmd.Flags().BoolVar(&cmd.replace, "replace", false, "remove user overrides the file does not list")
+
+	return cobraCmd
+}
+
+func (cmd *importCommand) run(_ *cobra.Command, args []string) error {
+	if !utils.IsRootUser() {
+		return common.ErrPermissionDenied
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", args[0], err)
+	}
+	var file configFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("error parsing %s: %v", args[0], err)
+	}
+	if file.Version != configFileVersion {
+		return fmt.Errorf("%s: unsupported version %d, expected %d", args[0], file.Version, configFileVersion)
+	}
+
+	pkg, skipped, err := importValues(file.Package)
+	if err != nil {
+		return err
+	}
+	user, skippedUser, err := importValues(file.User)
+	if err != nil {
+		return err
+	}
+	skipped = append(skipped, skippedUser...)
+
+	// User values override package keys, which must exist here or come with
+	// the file; check them all before writing any.
+	known, err := cmd.Config.GetAll()
+	if err != nil {
+		return fmt.Errorf("error getting values: %v", err)
+	}
+	for _, k := range slices.Sorted(maps.Keys(user)) {
+		_, exists := known[k]
+		if _, packaged := pkg[k]; !exists && !packaged {
+			return fmt.Errorf("%s: unknown key %q", args[0], k)
+		}
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(pkg)) {
+		if err := cmd.Config.Set(k, pkg[k], storage.PackageConfig); err != nil {
+			return fmt.Errorf("error setting package value for %q: %v", k, err)
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(user)) {
+		if err := cmd.Config.Set(k, user[k], storage.UserConfig); err != nil {
+			return fmt.Errorf("error setting value for %q: %v", k, err)
+		}
+	}
+
+	var removed int
+	if cmd.replace {
+		current, err := cmd.Config.GetAllFromLayer(storage.UserConfig)
+		if err != nil {
+			return fmt.Errorf("error getting user values: %v", err)
+		}
+		for _, k := range slices.Sorted(maps.Keys(current)) {
+			if _, listed := file.User[k]; listed || IsDeprecated(k) {
+				continue
+			}
+			if err := cmd.Config.Unset(k, storage.UserConfig); err != nil {
+				return fmt.Errorf("error removing %q: %v", k, err)
+			}
+			removed++
+		}
+	}
+
+	fmt.Printf("Imported %d package and %d user values from %s\n", len(pkg), len(user), args[0])
+	if removed > 0 {
+		fmt.Printf("Removed %d user overrides the file does not list\n", removed)
+	}
+	for _, k := range skipped {
+		fmt.Printf("Skipped %s: its value was masked on export; set it with config set\n", k)
+	}
+	return nil
+}
+
+// importValues renders a layer's values as the strings config set takes,
+// leaving out masked secrets, which it returns by key. Deprecated keys are
+// rejected, as config set rejects them.
+func importValues(values map[string]any) (map[string]string, []string, error) {
+	out := make(map[string]string, len(values))
+	var masked []string
+	for k, v := range values {
+		if IsDeprecated(k) {
+			return nil, nil, fmt.Errorf("%q is read-only", k)
+		}
+		s := ""
+		if v != nil {
+			s = fmt.Sprint(v)
+		}
+		if s == RedactedValue {
+			masked = append(masked, k)
+			continue
+		}
+		out[k] = s
+	}
+	slices.Sort(masked)
+	return out, masked, nil
+}
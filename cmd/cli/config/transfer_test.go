@@ -0,0 +1,92 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// layeredConfig is an in-memory storage.Config with a package and a user
+// layer, enough for config export to read.
+type layeredConfig struct {
+	storage.Config
+	layers map[storage.ConfigType]map[string]any
+}
+
+func (c layeredConfig) GetAllFromLayer(confType storage.ConfigType) (map[string]any, error) {
+	out := map[string]any{}
+	for k, v := range c.layers[confType] {
+		out[k] = v
+	}
+	return out, nil
+}
+
+func TestExportMasksSecrets(t *testing.T) {
+	cfg := layeredConfig{layers: map[storage.ConfigType]map[string]any{
+		storage.PackageConfig: {
+			"chat.http.host":   "localhost",
+			"kapa.api.key":     "kapa-clear-key",
+			"gdrive.api.token": "",
+		},
+		storage.UserConfig: {
+			"knowledge.search.size":  "8",
+			"opensearch.password":    "hunter2-clear",
+			"webhook.signing.secret": "whsec-clear",
+		},
+	}}
+	export := func(includeSecrets bool) (configFile, string) {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		cmd := exportCommand{Context: &common.Context{Config: cfg}, includeSecrets: includeSecrets}
+		if err := cmd.run(nil, []string{path}); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var file configFile
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			t.Fatal(err)
+		}
+		return file, string(data)
+	}
+
+	file, data := export(false)
+	for _, clear := range []string{"kapa-clear-key", "hunter2-clear", "whsec-clear"} {
+		if strings.Contains(data, clear) {
+			t.Errorf("export wrote the secret %q in clear:\n%s", clear, data)
+		}
+	}
+	wantPackage := map[string]any{"chat.http.host": "localhost", "kapa.api.key": RedactedValue, "gdrive.api.token": ""}
+	if !reflect.DeepEqual(file.Package, wantPackage) {
+		t.Errorf("package layer = %v, want %v", file.Package, wantPackage)
+	}
+	wantUser := map[string]any{"knowledge.search.size": "8", "opensearch.password": RedactedValue, "webhook.signing.secret": RedactedValue}
+	if !reflect.DeepEqual(file.User, wantUser) {
+		t.Errorf("user layer = %v, want %v", file.User, wantUser)
+	}
+
+	// Importing the file keeps the masked secrets out.
+	user, skipped, err := importValues(file.User)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"opensearch.password", "webhook.signing.secret"}; !reflect.DeepEqual(skipped, want) {
+		t.Errorf("import skipped %v, want %v", skipped, want)
+	}
+	if want := map[string]string{"knowledge.search.size": "8"}; !reflect.DeepEqual(user, want) {
+		t.Errorf("import set %v, want %v", user, want)
+	}
+
+	file, _ = export(true)
+	if file.User["opensearch.password"] != "hunter2-clear" || file.Package["kapa.api.key"] != "kapa-clear-key" {
+		t.Errorf("export --include-secrets = %v / %v, want the secrets in clear", file.Package, file.User)
+	}
+}
@@ -0,0 +1,248 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/canonical/go-snapctl/env"
+	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/spf13/cobra"
+)
+
+// watchSnapshotRelPath is where config watch keeps the config it last saw,
+// under $SNAP_COMMON.
+const watchSnapshotRelPath = "config/watch-snapshot.json"
+
+// WatchAction is something config watch runs when a key it depends on has
+// changed since the last run.
+type WatchAction struct {
+	Name string
+	// Keys are the keys the action depends on. A key ending in ".*" stands for
+	// every key below it, e.g. "knowledge.http.*".
+	Keys []string
+	// Run applies the change; changed are the keys that triggered it.
+	Run func(ctx context.Context, changed []string) error
+}
+
+// matches returns the changed keys the action depends on.
+func (a WatchAction) matches(changed []string) []string {
+	var out []string
+	for _, key := range changed {
+		for _, pattern := range a.Keys {
+			prefix, wildcard := strings.CutSuffix(pattern, "*")
+			if key == pattern || (wildcard && strings.HasPrefix(key, prefix)) {
+				out = append(out, key)
+				break
+			}
+		}
+	}
+	return out
+}
+
+type watchCommand struct {
+	*common.Context
+	actions  []WatchAction
+	snapshot string
+	interval time.Duration
+}
+
+// ConfigCommand groups the configuration commands meant for the snap's hooks
+// and services rather than for users; it is hidden from help.
+func ConfigCommand(ctx *common.Context, actions ...WatchAction) *cobra.Command {
+	cobraCmd := &cobra.Command{
+		Use:    "config",
+		Short:  "Configuration commands for the snap's hooks and services",
+		Hidden: true,
+	}
+	cobraCmd.AddCommand(WatchCommand(ctx, actions...))
+	return cobraCmd
+}
+
+// WatchCommand returns the config watch command, which runs actions when the
+// keys they depend on change.
+func WatchCommand(ctx *common.Context, actions ...WatchAction) *cobra.Command {
+	var cmd watchCommand
+	cmd.Context = ctx
+	cmd.actions = actions
+
+	cobraCmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Apply configuration changes made since the last run",
+		Long: "Compare the configuration with the snapshot recorded by the last run, print the\n" +
+			"keys that changed, and run the actions that depend on them, e.g. pointing the\n" +
+			"pipelines at new model IDs when knowledge.model.embedding changes.\n" +
+			"The first run only records the snapshot. When an action fails, the snapshot is\n" +
+			"kept, so the next run tries the change again.\n" +
+			"With --interval, keep checking at that interval until interrupted.",
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE:              cmd.run,
+	}
+
+	cobraCmd.Flags().StringVar(&cmd.snapshot, "snapshot", "", "File to keep the snapshot in (default: $SNAP_COMMON/"+watchSnapshotRelPath+")")
+	cobraCmd.Flags().DurationVar(&cmd.interval, "interval", 0, "Keep checking at this interval (e.g. 30s) instead of once")
+
+	return cobraCmd
+}
+
+func (cmd *watchCommand) run(_ *cobra.Command, _ []string) error {
+	if cmd.snapshot == "" {
+		base := env.SnapCommon()
+		if base == "" {
+			// Outside a snap (e.g. local dev), fall back to a temp dir.
+			base = os.TempDir()
+		}
+		cmd.snapshot = filepath.Join(base, watchSnapshotRelPath)
+	}
+	if cmd.interval < 0 {
+		return fmt.Errorf("--interval must not be negative")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if cmd.interval == 0 {
+		return cmd.check(ctx)
+	}
+
+	ticker := time.NewTicker(cmd.interval)
+	defer ticker.Stop()
+	for {
+		// A failed check is retried on the next tick rather than ending the
+		// watch: a service running it must not stop over a passing outage.
+		if err := cmd.check(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// check compares the configuration with the snapshot, runs the actions the
+// changes trigger, and records the new snapshot once they all succeeded.
+func (cmd *watchCommand) check(ctx context.Context) error {
+	current, err := cmd.Config.GetAll()
+	if err != nil {
+		return fmt.Errorf("error getting values: %v", err)
+	}
+	for k, v := range current {
+		current[k] = snapshotValue(k, v)
+	}
+
+	previous, err := readWatchSnapshot(cmd.snapshot)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("Recorded %d configuration key(s) to watch.\n", len(current))
+		return writeWatchSnapshot(cmd.snapshot, current)
+	}
+	if err != nil {
+		return err
+	}
+
+	changed := changedKeys(previous, current)
+	if len(changed) == 0 {
+		return nil
+	}
+	for _, key := range changed {
+		fmt.Printf("Changed %s: %s -> %s\n", key, watchValue(key, previous), watchValue(key, current))
+	}
+
+	var errs []error
+	for _, action := range cmd.actions {
+		keys := action.matches(changed)
+		if len(keys) == 0 {
+			continue
+		}
+		fmt.Printf("Running %s (%s).\n", action.Name, strings.Join(keys, ", "))
+		if err := action.Run(ctx, keys); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", action.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return writeWatchSnapshot(cmd.snapshot, current)
+}
+
+// changedKeys returns the keys set, unset, or given another value between two
+// snapshots, sorted.
+func changedKeys(previous, current map[string]any) []string {
+	var changed []string
+	for k, v := range current {
+		if old, ok := previous[k]; !ok || old != v {
+			changed = append(changed, k)
+		}
+	}
+	for k := range previous {
+		if _, ok := current[k]; !ok {
+			changed = append(changed, k)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+// watchValue renders a key's value in a snapshot for the change listing,
+// keeping secrets out of it.
+func watchValue(key string, snapshot map[string]any) string {
+	v, ok := snapshot[key]
+	switch {
+	case !ok:
+		return "(unset)"
+	case IsSecret(key):
+		return RedactedValue
+	}
+	return fmt.Sprintf("%q", v)
+}
+
+// snapshotValue is how a key's value is kept in the snapshot: as text, or as
+// a digest for a secret, so the file never holds one.
+func snapshotValue(key string, value any) string {
+	s := fmt.Sprint(value)
+	if IsSecret(key) {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	return s
+}
+
+// readWatchSnapshot reads the snapshot recorded at path.
+func readWatchSnapshot(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot map[string]any
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("error reading config snapshot %s: %v", path, err)
+	}
+	return snapshot, nil
+}
+
+func writeWatchSnapshot(path string, snapshot map[string]any) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing config snapshot: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating config snapshot directory: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("error writing config snapshot %s: %v", path, err)
+	}
+	return nil
+}
@@ -12,15 +12,36 @@ import (
 	"github.com/jpnorenam/rag-snap/cmd/cli/config"
 	"github.com/jpnorenam/rag-snap/cmd/cli/others"
 	"github.com/jpnorenam/rag-snap/cmd/cli/others/debug"
+	"github.com/jpnorenam/rag-snap/pkg/httpclient"
 	"github.com/jpnorenam/rag-snap/pkg/storage"
 	"github.com/spf13/cobra"
 )
 
 func main() {
 	ctx := &common.Context{
-		Config: storage.NewConfig(),
+		Config:    config.WithDiscovery(storage.NewConfig()),
+		Readiness: storage.NewReadiness(),
 	}
+	rootCmd := newRootCommand(ctx)
+	if rootCmd == nil {
+		return
+	}
+
+	// disable logging timestamps
+	log.SetFlags(0)
+
+	err := rootCmd.Execute()
+	if err != nil {
+		if hint := common.Hint(err); hint != "" {
+			fmt.Fprintln(os.Stderr, hint)
+		}
+		os.Exit(common.ExitCode(err))
+	}
+}
 
+// newRootCommand builds the CLI's command tree, or returns nil when the snap's
+// services cannot be listed.
+func newRootCommand(ctx *common.Context) *cobra.Command {
 	// Get snap name for dynamic commands
 	instanceName := env.SnapInstanceName()
 	if instanceName == "" {
@@ -34,8 +55,21 @@ func main() {
 		Long: instanceName + " runs an engine that is optimized for your host machine,\n" +
 			"providing a local service endpoint.\n\n" +
 			"Use this command to configure the active engine, or switch to an alternative engine.",
-		PersistentPreRunE: persistentPreRunE,
-		Use:               instanceName,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := config.ConfigureHTTPClient(ctx.Config); err != nil {
+				return err
+			}
+			if err := httpclient.EnableTrace(ctx.Trace); err != nil {
+				return err
+			}
+			common.ConfigureOutput(ctx.Quiet, ctx.NoColor)
+			if err := basic.ValidateEndpointOverrides(ctx); err != nil {
+				return err
+			}
+			basic.CleanStaleTempFiles(ctx)
+			return persistentPreRunE(cmd, args)
+		},
+		Use: instanceName,
 	}
 
 	// Add custom text after the help message - only show service management if snap has services
@@ -43,7 +77,7 @@ func main() {
 		services, err := snapctl.Services().Run()
 		if err != nil {
 			fmt.Printf("Error: could not retrieve snap services: %v\n", err)
-			return
+			return nil
 		}
 		if len(services) > 0 {
 			rootCmd.SetUsageTemplate(rootCmd.UsageTemplate() + common.SuggestServiceManagement())
@@ -52,6 +86,10 @@ func main() {
 
 	// Global flags
 	rootCmd.PersistentFlags().BoolVarP(&ctx.Verbose, "verbose", "v", false, "Enable verbose logging")
+	rootCmd.PersistentFlags().BoolVarP(&ctx.Quiet, "quiet", "q", false, "Suppress spinners, progress, and informational messages")
+	rootCmd.PersistentFlags().BoolVar(&ctx.NoColor, "no-color", false, "Disable colored output (also off when stdout is not a terminal or NO_COLOR is set)")
+	rootCmd.PersistentFlags().StringVar(&ctx.Trace, "trace", "", "Append every outbound HTTP request and response to this file, secrets redacted")
+	basic.AddEndpointFlags(rootCmd, ctx)
 
 	// Disable command sorting to keep commands sorted as added below
 	cobra.EnableCommandSorting = false
@@ -59,6 +97,7 @@ func main() {
 	rootCmd.AddGroup(basic.Group("Basic Commands:"))
 	rootCmd.AddCommand(
 		basic.StatusCommand(ctx),
+		basic.TopCommand(ctx),
 		basic.ChatCommand(ctx),
 		basic.UICommand(ctx),
 		basic.AnswerCommand(ctx),
@@ -70,6 +109,10 @@ func main() {
 	rootCmd.AddCommand(
 		config.GetCommand(ctx),
 		config.SetCommand(ctx),
+		config.DescribeCommand(ctx),
+		config.ExportCommand(ctx),
+		config.ImportCommand(ctx),
+		config.ConfigCommand(ctx, basic.ConfigWatchActions(ctx)...),
 	)
 
 	// other commands (help is added by default)
@@ -78,16 +121,10 @@ func main() {
 		debug.DebugCommand(ctx),
 	)
 
-	// disable logging timestamps
-	log.SetFlags(0)
-
 	// Hide the 'completion' command from help text
 	rootCmd.CompletionOptions.HiddenDefaultCmd = true
 
-	err := rootCmd.Execute()
-	if err != nil {
-		os.Exit(1)
-	}
+	return rootCmd
 }
 
 func persistentPreRunE(cmd *cobra.Command, args []string) error {
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"io"
+	"testing"
+
+	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+// TestCommandFlags runs every command of the tree with --help, which merges
+// its flags with the persistent ones of its parents: cobra panics there when
+// a command's shorthand is already taken, such as -q by the global --quiet.
+func TestCommandFlags(t *testing.T) {
+	ctx := &common.Context{Config: storage.NewConfig(), Readiness: storage.NewReadiness()}
+	root := newRootCommand(ctx)
+	root.SetOut(io.Discard)
+	root.SetErr(io.Discard)
+
+	var walk func(c *cobra.Command, path []string)
+	walk = func(c *cobra.Command, path []string) {
+		for _, sub := range c.Commands() {
+			subPath := append(append([]string{}, path...), sub.Name())
+			t.Run(sub.CommandPath(), func(t *testing.T) {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Fatalf("%v --help panicked: %v", subPath, r)
+					}
+				}()
+				root.SetArgs(append(subPath, "--help"))
+				if err := root.Execute(); err != nil {
+					t.Errorf("%v --help: %v", subPath, err)
+				}
+			})
+			walk(sub, subPath)
+		}
+	}
+	walk(root, nil)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/jpnorenam/rag-snap/cmd/cli/basic/chat"
 	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
 	"github.com/spf13/cobra"
 )
 
@@ -41,5 +42,5 @@ func (cmd *chatCommand) run(_ *cobra.Command, args []string) error {
 		return fmt.Errorf("the --base-url parameter is required")
 	}
 
-	return chat.Client(cmd.baseUrl, nil, nil, "", cmd.modelName, chat.DefaultPrompts(), 0.3, cmd.Verbose)
+	return chat.Client(cmd.baseUrl, nil, "", knowledge.Settings{}, nil, "", cmd.modelName, chat.DefaultPrompts(), 0.3, chat.Settings{}, chat.DefaultHistorySettings(), cmd.Verbose)
 }
@@ -0,0 +1,37 @@
+package debug
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/pkg/processing"
+	"github.com/jpnorenam/rag-snap/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+func CleanTempCommand(ctx *common.Context) *cobra.Command {
+	var olderThan time.Duration
+
+	cobraCmd := &cobra.Command{
+		Use:   "clean-temp",
+		Short: "Remove staged temp files",
+		Long: "Remove the crawled pages, downloaded files, and extracted archives staged in the managed\n" +
+			"temp directory. Files still in use by a running ingest are removed too unless --older-than\n" +
+			"spares them. Entries older than temp.max-age are also removed automatically at startup.",
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			result, err := processing.CleanTemp(olderThan)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Removed %d entries (%s) from %s\n", result.Removed, utils.FmtBytes(uint64(result.Freed)), processing.TempDir())
+			return nil
+		},
+	}
+
+	cobraCmd.Flags().DurationVar(&olderThan, "older-than", 0, "Only remove entries in which nothing was modified for this long, e.g. 1h")
+
+	return cobraCmd
+}
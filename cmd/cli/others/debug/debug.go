@@ -1,6 +1,7 @@
 package debug
 
 import (
+	"github.com/jpnorenam/rag-snap/cmd/cli/basic"
 	"github.com/jpnorenam/rag-snap/cmd/cli/common"
 	"github.com/spf13/cobra"
 )
@@ -14,6 +15,8 @@ func DebugCommand(ctx *common.Context) *cobra.Command {
 
 	debugCmd.AddCommand(
 		ChatCommand(ctx),
+		CleanTempCommand(ctx),
+		basic.SelfTestCommand(ctx),
 	)
 
 	return debugCmd
@@ -4,16 +4,24 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
+	"time"
 
 	"github.com/jpnorenam/rag-snap/cmd/cli/common"
 	"github.com/spf13/cobra"
 )
 
+// readinessPollInterval is the pause between reads of the readiness registry
+// while --wait-for is waiting.
+const readinessPollInterval = time.Second
+
 type runCommand struct {
 	*common.Context
 
 	// flags
 	waitForComponentsFlag bool
+	waitFor               []string
+	waitTimeout           time.Duration
 }
 
 func RunCommand(ctx *common.Context) *cobra.Command {
@@ -31,6 +39,8 @@ func RunCommand(ctx *common.Context) *cobra.Command {
 
 	// flags
 	cobraCmd.Flags().BoolVar(&cmd.waitForComponentsFlag, "wait-for-components", false, "wait for engine components to be installed before running")
+	cobraCmd.Flags().StringSliceVar(&cmd.waitFor, "wait-for", nil, "services the readiness registry must record as ready before running (comma-separated)")
+	cobraCmd.Flags().DurationVar(&cmd.waitTimeout, "wait-timeout", 60*time.Second, "how long --wait-for waits before giving up")
 
 	return cobraCmd
 }
@@ -40,6 +50,10 @@ func (cmd *runCommand) run(_ *cobra.Command, args []string) error {
 		return fmt.Errorf("unexpected number of arguments, expected 1 got %d", len(args))
 	}
 
+	if err := cmd.waitForServices(); err != nil {
+		return err
+	}
+
 	path := args[0]
 
 	execCmd := exec.Command(path)
@@ -47,3 +61,26 @@ func (cmd *runCommand) run(_ *cobra.Command, args []string) error {
 	execCmd.Stderr = os.Stderr
 	return execCmd.Run()
 }
+
+// waitForServices blocks until the readiness registry records every --wait-for
+// service as ready, or --wait-timeout passes.
+func (cmd *runCommand) waitForServices() error {
+	if len(cmd.waitFor) == 0 || cmd.Readiness == nil {
+		return nil
+	}
+
+	deadline := time.Now().Add(cmd.waitTimeout)
+	for {
+		missing, err := cmd.Readiness.NotReady(cmd.waitFor)
+		if err != nil {
+			return fmt.Errorf("reading readiness registry: %w", err)
+		}
+		if len(missing) == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for: %s", cmd.waitTimeout, strings.Join(missing, ", "))
+		}
+		time.Sleep(readinessPollInterval)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"syscall"
 
 	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/cmd/cli/config"
 	"github.com/jpnorenam/rag-snap/internal/api"
 	"github.com/jpnorenam/rag-snap/pkg/storage"
 )
@@ -35,7 +36,7 @@ func run() error {
 	hup := make(chan os.Signal, 1)
 	signal.Notify(hup, syscall.SIGHUP)
 
-	appCtx := &common.Context{Config: storage.NewConfig()}
+	appCtx := &common.Context{Config: config.WithDiscovery(storage.NewConfig())}
 
 	for {
 		if err := serveOnce(ctx, hup, appCtx); err != nil {
@@ -57,6 +58,13 @@ func serveOnce(ctx context.Context, hup <-chan os.Signal, appCtx *common.Context
 	if err != nil {
 		return err
 	}
+	settings, err := api.ResolveSettings(appCtx)
+	if err != nil {
+		return err
+	}
+	if err := config.ConfigureHTTPClient(appCtx.Config); err != nil {
+		return err
+	}
 	socket := api.ResolveSocketConfig(appCtx)
 	loopback := api.ResolveLoopbackConfig(appCtx)
 
@@ -65,6 +73,8 @@ func serveOnce(ctx context.Context, hup <-chan os.Signal, appCtx *common.Context
 		Socket:      socket,
 		Loopback:    loopback,
 		BackendURLs: backendURLs,
+		Settings:    settings,
+		Readiness:   storage.NewReadiness(),
 	})
 
 	// runCtx is cancelled either by shutdown (parent ctx) or by a reload (SIGHUP).
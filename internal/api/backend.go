@@ -21,6 +21,16 @@ type backendState struct {
 	mu    sync.RWMutex
 	ready map[string]bool
 	urls  map[string]string
+	// registry, when set, is told of every readiness change, so the CLI and
+	// the hooks learn of it without polling the backends themselves.
+	registry readinessRecorder
+}
+
+// readinessRecorder records service readiness in the snap's readiness
+// registry (storage.Readiness).
+type readinessRecorder interface {
+	MarkReady(service string, at time.Time) error
+	MarkNotReady(service string) error
 }
 
 func newBackendState(urls map[string]string) *backendState {
@@ -51,8 +61,11 @@ func (b *backendState) snapshot() map[string]bool {
 }
 
 // poll runs a readiness loop until ctx is cancelled, dialling each backend's
-// host:port and recording reachability. It never blocks the listener.
+// host:port and recording reachability. It never blocks the listener. The
+// first probe is always recorded in the registry, replacing whatever an
+// earlier daemon left there.
 func (b *backendState) poll(ctx context.Context, interval time.Duration) {
+	first := true
 	check := func() {
 		for name, raw := range b.urls {
 			reachable := dialable(ctx, raw)
@@ -63,7 +76,11 @@ func (b *backendState) poll(ctx context.Context, interval time.Duration) {
 			if changed {
 				log.Printf("backend %q readiness: %v", name, reachable)
 			}
+			if changed || first {
+				b.record(name, reachable)
+			}
 		}
+		first = false
 	}
 
 	check() // probe once immediately
@@ -79,6 +96,22 @@ func (b *backendState) poll(ctx context.Context, interval time.Duration) {
 	}
 }
 
+// record writes a backend's readiness to the registry, if there is one.
+func (b *backendState) record(name string, ready bool) {
+	if b.registry == nil {
+		return
+	}
+	var err error
+	if ready {
+		err = b.registry.MarkReady(name, time.Now())
+	} else {
+		err = b.registry.MarkNotReady(name)
+	}
+	if err != nil {
+		log.Printf("recording backend %q readiness: %v", name, err)
+	}
+}
+
 // dialable reports whether the host:port of a service URL accepts a TCP connection.
 func dialable(ctx context.Context, raw string) bool {
 	u, err := url.Parse(raw)
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRegistry records the readiness the backend poller reports, and cancels
+// the poll once want services have been recorded.
+type fakeRegistry struct {
+	mu     sync.Mutex
+	ready  map[string]bool
+	want   int
+	cancel context.CancelFunc
+}
+
+func (r *fakeRegistry) set(service string, ready bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ready[service] = ready
+	if len(r.ready) == r.want {
+		r.cancel()
+	}
+}
+
+func (r *fakeRegistry) MarkReady(service string, _ time.Time) error {
+	r.set(service, true)
+	return nil
+}
+
+func (r *fakeRegistry) MarkNotReady(service string) error {
+	r.set(service, false)
+	return nil
+}
+
+func TestBackendPollRecordsReadiness(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	b := newBackendState(map[string]string{
+		backendOpenSearch: "http://" + ln.Addr().String(),
+		backendTika:       "http://127.0.0.1:1",
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	registry := &fakeRegistry{ready: map[string]bool{}, want: 2, cancel: cancel}
+	b.registry = registry
+
+	// The first probe is recorded whether or not it changed anything, and
+	// the hour-long interval means there is no second one.
+	b.poll(ctx, time.Hour)
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	ready, recorded := registry.ready[backendTika]
+	if !registry.ready[backendOpenSearch] || !recorded || ready {
+		t.Errorf("recorded readiness = %v, want opensearch ready and tika not ready", registry.ready)
+	}
+}
@@ -6,8 +6,8 @@ import (
 	"path/filepath"
 
 	"github.com/canonical/go-snapctl/env"
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
 	"github.com/jpnorenam/rag-snap/internal/chatstore"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
 )
 
 // chatsRelDir is the saved-chat store location under $SNAP_COMMON, alongside the
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"sync"
 
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
+	"github.com/jpnorenam/rag-snap/cmd/cli/basic/chat"
 	"github.com/jpnorenam/rag-snap/cmd/cli/common"
 	"github.com/jpnorenam/rag-snap/cmd/cli/config"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/processing"
 )
 
 // clientCache lazily builds and caches the long-lived backend clients the
@@ -15,15 +17,16 @@ import (
 // backend is down) and reused thereafter. A reload (SIGHUP) constructs a new
 // Server with a fresh cache, so no explicit invalidation is needed here.
 type clientCache struct {
-	urls map[string]string
-	ctx  *common.Context
+	urls     map[string]string
+	ctx      *common.Context
+	settings Settings
 
 	mu         sync.Mutex
 	openSearch *knowledge.OpenSearchClient
 }
 
-func newClientCache(ctx *common.Context, urls map[string]string) *clientCache {
-	return &clientCache{ctx: ctx, urls: urls}
+func newClientCache(ctx *common.Context, urls map[string]string, settings Settings) *clientCache {
+	return &clientCache{ctx: ctx, urls: urls, settings: settings}
 }
 
 // openSearchClient returns the cached OpenSearchClient, building it on first
@@ -41,7 +44,7 @@ func (c *clientCache) openSearchClient() (*knowledge.OpenSearchClient, error) {
 	if url == "" {
 		return nil, fmt.Errorf("OpenSearch backend URL is not configured")
 	}
-	client, err := knowledge.NewClient(url)
+	client, err := knowledge.NewClient(url, c.settings.Knowledge)
 	if err != nil {
 		return nil, fmt.Errorf("knowledge backend unavailable: %w", err)
 	}
@@ -64,7 +67,7 @@ func (c *clientCache) openSearchClientNoWait(ctx context.Context) (*knowledge.Op
 	if url == "" {
 		return nil, fmt.Errorf("OpenSearch backend URL is not configured")
 	}
-	client, err := knowledge.NewClientNoWait(ctx, url)
+	client, err := knowledge.NewClientNoWait(ctx, url, c.settings.Knowledge)
 	if err != nil {
 		return nil, fmt.Errorf("knowledge backend unavailable: %w", err)
 	}
@@ -94,8 +97,22 @@ func (c *clientCache) chatModelID() string {
 	return id
 }
 
-// tikaURL returns the configured Tika base URL.
-func (c *clientCache) tikaURL() string { return c.urls[backendTika] }
+// tikaClient returns the client of the configured Tika server, with the
+// daemon's tika.* settings; nil when no Tika URL is configured.
+func (c *clientCache) tikaClient() *processing.TikaClient {
+	if c.urls[backendTika] == "" {
+		return nil
+	}
+	client, err := processing.NewTikaClient(c.urls[backendTika], c.settings.Tika)
+	if err != nil {
+		return nil
+	}
+	return client
+}
+
+// chatSettings returns the chat.* settings of the daemon's chat sessions and
+// batch answers.
+func (c *clientCache) chatSettings() chat.Settings { return c.settings.Chat }
 
 // openAIURL returns the configured inference server base URL.
 func (c *clientCache) openAIURL() string { return c.urls[backendOpenAI] }
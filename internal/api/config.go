@@ -8,8 +8,11 @@ import (
 	"strconv"
 
 	"github.com/canonical/go-snapctl/env"
+	"github.com/jpnorenam/rag-snap/cmd/cli/basic/chat"
 	"github.com/jpnorenam/rag-snap/cmd/cli/common"
 	"github.com/jpnorenam/rag-snap/cmd/cli/config"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/processing"
 )
 
 // Config keys the daemon reads from the snapctl-backed store. These mirror the
@@ -25,6 +28,8 @@ const (
 	confOpenSearchHTTPPort = "knowledge.http.port"
 	confOpenSearchHTTPTLS  = "knowledge.http.tls"
 
+	confKnowledgeReadOnly = "knowledge.read-only"
+
 	confTikaHTTPHost = "tika.http.host"
 	confTikaHTTPPort = "tika.http.port"
 	confTikaHTTPPath = "tika.http.path"
@@ -97,11 +102,7 @@ func ResolveBackendURLs(ctx *common.Context) (map[string]string, error) {
 	}
 	openAiPath, _ := config.GetString(ctx.Config, confOpenAiHTTPPath)
 
-	osHost, err := requireString(ctx, confOpenSearchHTTPHost)
-	if err != nil {
-		return nil, err
-	}
-	osPort, err := requireString(ctx, confOpenSearchHTTPPort)
+	osURL, err := resolveOpenSearchURL(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -118,11 +119,59 @@ func ResolveBackendURLs(ctx *common.Context) (map[string]string, error) {
 
 	return map[string]string{
 		backendOpenAI:     buildURL(openAiHost, openAiPort, openAiPath, getBool(ctx, confOpenAiHTTPTLS, false)),
-		backendOpenSearch: buildURL(osHost, osPort, "", getBool(ctx, confOpenSearchHTTPTLS, true)),
+		backendOpenSearch: osURL,
 		backendTika:       buildURL(tikaHost, tikaPort, tikaPath, getBool(ctx, confTikaHTTPTLS, false)),
 	}, nil
 }
 
+// Settings are the package settings the daemon's clients and sessions run
+// with, read once at startup. The zero Settings are the defaults.
+type Settings struct {
+	Knowledge knowledge.Settings
+	Tika      processing.TikaSettings
+	Chat      chat.Settings
+}
+
+// ResolveSettings reads the knowledge.*, temp.*, tika.*, and chat.* settings
+// from config. A malformed value yields an error, as a missing backend key
+// does in ResolveBackendURLs.
+func ResolveSettings(ctx *common.Context) (Settings, error) {
+	var (
+		s   Settings
+		err error
+	)
+	get := config.Lookup(ctx.Config)
+	if s.Knowledge, err = knowledge.ParseSettings(get); err != nil {
+		return Settings{}, err
+	}
+	if s.Tika, err = processing.ParseTikaSettings(get); err != nil {
+		return Settings{}, err
+	}
+	if s.Chat, err = chat.ParseSettings(get); err != nil {
+		return Settings{}, err
+	}
+	return s, nil
+}
+
+// resolveOpenSearchURL builds the OpenSearch URL from knowledge.http.hosts when
+// it lists the cluster's nodes, otherwise from knowledge.http.host and port.
+func resolveOpenSearchURL(ctx *common.Context) (string, error) {
+	secure := getBool(ctx, confOpenSearchHTTPTLS, true)
+	if hosts, _ := config.GetString(ctx.Config, knowledge.ConfHTTPHosts); hosts != "" {
+		port, _ := config.GetString(ctx.Config, confOpenSearchHTTPPort)
+		return knowledge.ClusterURL(hosts, port, secure)
+	}
+	host, err := requireString(ctx, confOpenSearchHTTPHost)
+	if err != nil {
+		return "", err
+	}
+	port, err := requireString(ctx, confOpenSearchHTTPPort)
+	if err != nil {
+		return "", err
+	}
+	return buildURL(host, port, "", secure), nil
+}
+
 // ResolveSocketConfig builds the socket config from $SNAP_COMMON and the
 // api.socket.* keys, applying defaults when unset.
 func ResolveSocketConfig(ctx *common.Context) SocketConfig {
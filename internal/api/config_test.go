@@ -0,0 +1,28 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+)
+
+func TestResolveSettings(t *testing.T) {
+	ctx := &common.Context{Config: newMemConfig(map[string]any{
+		"tika.concurrency": 3,
+		"tika.rmeta":       "true",
+		"temp.quota":       "1G",
+	}, nil)}
+	s, err := ResolveSettings(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Tika.Concurrency != 3 || !s.Tika.Rmeta || s.Knowledge.Temp.Quota != 1<<30 {
+		t.Errorf("settings = %+v", s)
+	}
+
+	ctx = &common.Context{Config: newMemConfig(map[string]any{"knowledge.bulk.refresh": "sometimes"}, nil)}
+	if _, err := ResolveSettings(ctx); err == nil || !strings.Contains(err.Error(), "knowledge.bulk.refresh") {
+		t.Errorf("ResolveSettings with a bad refresh = %v, want an error naming the key", err)
+	}
+}
@@ -12,8 +12,8 @@ import (
 	"strings"
 
 	"github.com/jpnorenam/rag-snap/cmd/cli/basic/chat"
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/processing"
 	"github.com/jpnorenam/rag-snap/cmd/cli/basic/rfp"
+	"github.com/jpnorenam/rag-snap/pkg/processing"
 )
 
 // buildQuestionJSON is a single extracted candidate question published on the
@@ -118,7 +118,7 @@ func (s *Server) handleAnswerBuild(w http.ResponseWriter, r *http.Request) {
 	tmp.Close()
 	stagedPath := tmp.Name()
 
-	tikaURL := s.clients.tikaURL()
+	tika := s.clients.tikaClient()
 	inferenceURL := s.clients.openAIURL()
 	model := s.clients.chatModelID()
 	tabular := format == "xlsx" || format == "csv"
@@ -132,7 +132,7 @@ func (s *Server) handleAnswerBuild(w http.ResponseWriter, r *http.Request) {
 			if tabular {
 				// Inspect pass: parse into tables and publish them for the
 				// client to choose a column; extract nothing here.
-				tables, err := parseTables(format, stagedPath, tikaURL)
+				tables, err := parseTables(format, stagedPath, tika)
 				if err != nil {
 					return err
 				}
@@ -155,7 +155,7 @@ func (s *Server) handleAnswerBuild(w http.ResponseWriter, r *http.Request) {
 			}
 
 			// Free-text pass: extract in one shot (unchanged behavior).
-			questions, err := extractTextQuestions(stagedPath, tikaURL)
+			questions, err := extractTextQuestions(stagedPath, tika)
 			if err != nil {
 				return err
 			}
@@ -312,17 +312,13 @@ func toBuildQuestionsJSON(questions []rfp.Question) []buildQuestionJSON {
 // parseTables parses a tabular document into rfp.SheetTable(s): Tika HTML for
 // XLSX (one table per sheet, names recovered from the workbook), a single
 // synthesized table for CSV.
-func parseTables(format, path, tikaURL string) ([]rfp.SheetTable, error) {
+func parseTables(format, path string, tika *processing.TikaClient) ([]rfp.SheetTable, error) {
 	switch format {
 	case "xlsx":
-		if tikaURL == "" {
+		if tika == nil {
 			return nil, fmt.Errorf("tika service is not configured — cannot read this document type")
 		}
-		tikaClient, err := processing.NewTikaClient(tikaURL)
-		if err != nil {
-			return nil, fmt.Errorf("creating Tika client: %w", err)
-		}
-		htmlContent, err := tikaClient.ExtractHTML(path)
+		htmlContent, err := tika.ExtractHTML(path)
 		if err != nil {
 			return nil, fmt.Errorf("tika extraction failed: %w", err)
 		}
@@ -501,15 +497,11 @@ func columnAvgLen(t rfp.SheetTable, col int) int {
 // extractTextQuestions extracts questions from a PDF/DOCX via Tika HTML in
 // list/paragraph mode across all pages, then applies the TOC filter. Unchanged
 // free-text path.
-func extractTextQuestions(path, tikaURL string) ([]rfp.Question, error) {
-	if tikaURL == "" {
+func extractTextQuestions(path string, tika *processing.TikaClient) ([]rfp.Question, error) {
+	if tika == nil {
 		return nil, fmt.Errorf("tika service is not configured — cannot extract from this document type")
 	}
-	tikaClient, err := processing.NewTikaClient(tikaURL)
-	if err != nil {
-		return nil, fmt.Errorf("creating Tika client: %w", err)
-	}
-	htmlRaw, err := tikaClient.ExtractHTML(path)
+	htmlRaw, err := tika.ExtractHTML(path)
 	if err != nil {
 		return nil, fmt.Errorf("tika extraction failed: %w", err)
 	}
@@ -108,7 +108,7 @@ func (s *Server) handleChatStart(w http.ResponseWriter, r *http.Request) {
 			embeddingModelID = id
 		} else {
 			// No embedding model: retrieval is unavailable, so do not wire the
-			// knowledge client (mirrors retrieveContext's guard).
+			// knowledge client (mirrors retrieveHits' guard).
 			knowledgeClient = nil
 		}
 	}
@@ -144,7 +144,7 @@ func (s *Server) handleChatStart(w http.ResponseWriter, r *http.Request) {
 		initialBases, droppedBases = filterExistingBases(r.Context(), knowledgeClient, resumed.Bases)
 	}
 
-	live, err := chat.NewLiveSession(baseURL, model, knowledgeClient, embeddingModelID, initialBases, systemPrompt, temperature, s.ctx.Verbose)
+	live, err := chat.NewLiveSession(baseURL, model, knowledgeClient, embeddingModelID, initialBases, systemPrompt, temperature, s.clients.chatSettings(), s.ctx.Verbose)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "starting chat session: "+err.Error())
 		return
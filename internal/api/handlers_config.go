@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"slices"
 	"sort"
 	"strings"
 
@@ -14,15 +13,7 @@ import (
 
 // redactedValue replaces a secret-shaped config value in every read. The key stays
 // listed and writable — it is write-only through the API, not hidden.
-const redactedValue = "<redacted>"
-
-// secretKeySuffixes marks config keys whose value must never be read back. Matching
-// is on the key's final segment, not on the value, so it is deterministic: a key is
-// secret because of what it is, not because of what happens to be stored in it today.
-// The service credentials (OPENSEARCH_USERNAME/PASSWORD, CHAT_API_KEY) are
-// environment variables and cannot appear here at all; this guards the config keys
-// that *are* secrets, today gdrive.client.secret.
-var secretKeySuffixes = []string{"secret", "password", "token"}
+const redactedValue = config.RedactedValue
 
 // configEntry is one key in the config listing: its effective value and the layer
 // that value comes from. Layer provenance drives the client's "revert to package
@@ -230,9 +221,8 @@ func displayValue(key string, value any) string {
 	return rendered
 }
 
-// isSecretKey reports whether a key's value must be redacted on read.
+// isSecretKey reports whether a key's value must be redacted on read; see
+// config.IsSecret.
 func isSecretKey(key string) bool {
-	segments := strings.Split(key, ".")
-	last := segments[len(segments)-1]
-	return slices.Contains(secretKeySuffixes, last)
+	return config.IsSecret(key)
 }
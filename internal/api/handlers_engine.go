@@ -11,9 +11,9 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/processing"
 	"github.com/jpnorenam/rag-snap/cmd/cli/config"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/processing"
 	"github.com/jpnorenam/rag-snap/pkg/storage"
 )
 
@@ -181,6 +181,47 @@ func (s *Server) handleEngineModelDelete(w http.ResponseWriter, r *http.Request)
 	respondSync(w, map[string]string{"id": id})
 }
 
+// swagger:route POST /1.0/knowledge-engine/models/{id}/undeploy knowledge engineModelUndeploy
+//
+// Undeploy a model.
+//
+// Frees the model's memory on the ML nodes but keeps it registered, so it can
+// be deployed again without re-downloading it. A model the engine currently
+// uses is refused unless "force=true" is given, since ingest and search need it
+// deployed.
+//
+//	Responses:
+//	  200: syncResponse
+//	  400: errorResponse
+//	  403: errorResponse
+//	  500: errorResponse
+func (s *Server) handleEngineModelUndeploy(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	client, err := s.clients.openSearchClient()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	embedding, _ := config.GetString(s.ctx.Config, knowledge.ConfEmbeddingModelID)
+	rerank, _ := config.GetString(s.ctx.Config, knowledge.ConfRerankModelID)
+
+	if role := knowledge.ModelRole(id, embedding, rerank); role != "" && r.URL.Query().Get("force") != "true" {
+		respondError(w, http.StatusBadRequest,
+			fmt.Sprintf("model %s is the engine's %s model; pass force=true to undeploy it anyway", id, role))
+		return
+	}
+
+	if err := client.UndeployModel(r.Context(), id); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	log.Printf("knowledge engine: undeployed model %s", id)
+	respondSync(w, map[string]string{"id": id})
+}
+
 // exportRequest is the body of POST /1.0/knowledge/{name}/export.
 type exportRequest struct {
 	OutputDir string `json:"output_dir"`
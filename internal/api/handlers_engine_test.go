@@ -5,8 +5,8 @@ import (
 	"errors"
 	"testing"
 
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
 	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
 	"github.com/jpnorenam/rag-snap/pkg/storage"
 )
 
@@ -8,7 +8,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
 )
 
 // gdriveFlowTimeout bounds how long the daemon waits for the user to complete
@@ -337,7 +337,7 @@ func (s *Server) handleGdriveImport(w http.ResponseWriter, r *http.Request) {
 		fmt.Sprintf("Importing %q from Google Drive", req.Name),
 		map[string][]string{"knowledge": {"/1.0/knowledge"}}, false,
 		func(ctx context.Context, _ *Operation) error {
-			tmpPath, cleanup, err := knowledge.DownloadDriveArchive(ctx, archive, token)
+			tmpPath, cleanup, err := knowledge.DownloadDriveArchive(ctx, client.Settings().Temp, archive, token)
 			if err != nil {
 				return fmt.Errorf("downloading %q: %w", req.Name, err)
 			}
@@ -10,8 +10,8 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/processing"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/processing"
 )
 
 // ingestItem describes a single source to ingest. For URL items URL is set; for
@@ -106,7 +106,7 @@ func (s *Server) handleSourcesIngest(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	tikaURL := s.clients.tikaURL()
+	tika := s.clients.tikaClient()
 	resources := map[string][]string{"knowledge": {"/1.0/knowledge/" + name}}
 
 	op, err := s.ops.runTask(
@@ -114,7 +114,7 @@ func (s *Server) handleSourcesIngest(w http.ResponseWriter, r *http.Request) {
 		resources, true,
 		func(ctx context.Context, op *Operation) error {
 			defer cleanupItems(items)
-			return runIngest(ctx, op, client, tikaURL, index, items, force)
+			return runIngest(ctx, op, client, tika, index, items, force)
 		},
 	)
 	if err != nil {
@@ -221,7 +221,7 @@ func (s *Server) collectUploadedItems(r *http.Request) ([]ingestItem, bool, erro
 
 // runIngest processes each item, updating operation progress and honouring
 // cancellation between items. Repo items expand into many files server-side.
-func runIngest(ctx context.Context, op *Operation, client *knowledge.OpenSearchClient, tikaURL, index string, items []ingestItem, force bool) error {
+func runIngest(ctx context.Context, op *Operation, client *knowledge.OpenSearchClient, tika *processing.TikaClient, index string, items []ingestItem, force bool) error {
 	total := len(items)
 	op.UpdateMetadata(map[string]any{"sources_total": total, "sources_done": 0})
 
@@ -229,7 +229,7 @@ func runIngest(ctx context.Context, op *Operation, client *knowledge.OpenSearchC
 		if err := ctx.Err(); err != nil {
 			return err
 		}
-		if err := ingestOneItem(ctx, client, tikaURL, index, items[i], force); err != nil {
+		if err := ingestOneItem(ctx, client, tika, index, items[i], force); err != nil {
 			return fmt.Errorf("ingesting %q: %w", effectiveSourceID(items[i]), err)
 		}
 		op.UpdateMetadata(map[string]any{"sources_total": total, "sources_done": i + 1})
@@ -240,14 +240,14 @@ func runIngest(ctx context.Context, op *Operation, client *knowledge.OpenSearchC
 // ingestOneItem dispatches one item by type. github/gitea items expand into
 // multiple files; url/file items ingest a single source. In batch context an
 // already-completed source is skipped unless force is set.
-func ingestOneItem(ctx context.Context, client *knowledge.OpenSearchClient, tikaURL, index string, item ingestItem, force bool) error {
+func ingestOneItem(ctx context.Context, client *knowledge.OpenSearchClient, tika *processing.TikaClient, index string, item ingestItem, force bool) error {
 	switch item.Type {
 	case "github":
-		return ingestGitHubRepo(ctx, client, tikaURL, index, item, force)
+		return ingestGitHubRepo(ctx, client, tika, index, item, force)
 	case "gitea":
-		return ingestGiteaRepo(ctx, client, tikaURL, index, item, force)
+		return ingestGiteaRepo(ctx, client, tika, index, item, force)
 	case "url":
-		path, _, cleanup, err := processing.CrawlURL(item.URL)
+		path, _, cleanup, err := processing.CrawlURL(client.Settings().Temp, item.URL)
 		if err != nil {
 			return fmt.Errorf("crawling URL: %w", err)
 		}
@@ -256,7 +256,7 @@ func ingestOneItem(ctx context.Context, client *knowledge.OpenSearchClient, tika
 		if sourceID == "" {
 			sourceID = item.URL
 		}
-		return ingestResolvedFile(ctx, client, tikaURL, index, path, sourceID, item.URL, item.Label, force)
+		return ingestResolvedFile(ctx, client, tika, index, path, sourceID, item.URL, item.Label, force)
 	default: // staged file upload
 		if item.filePath == "" {
 			if item.Type == "file" {
@@ -268,29 +268,30 @@ func ingestOneItem(ctx context.Context, client *knowledge.OpenSearchClient, tika
 		if sourceID == "" {
 			sourceID = filepath.Base(item.filePath)
 		}
-		return ingestResolvedFile(ctx, client, tikaURL, index, item.filePath, sourceID, item.filePath, item.Label, force)
+		return ingestResolvedFile(ctx, client, tika, index, item.filePath, sourceID, item.filePath, item.Label, force)
 	}
 }
 
 // ingestResolvedFile skips an already-completed source unless force is set, then
 // runs the shared ingest core.
-func ingestResolvedFile(ctx context.Context, client *knowledge.OpenSearchClient, tikaURL, index, filePath, sourceID, metadataPath, label string, force bool) error {
+func ingestResolvedFile(ctx context.Context, client *knowledge.OpenSearchClient, tika *processing.TikaClient, index, filePath, sourceID, metadataPath, label string, force bool) error {
 	if !force && client.SourceCompleted(ctx, sourceID) {
 		return nil
 	}
-	return client.IngestSource(ctx, tikaURL, knowledge.IngestOptions{
+	return client.IngestSource(ctx, tika, knowledge.IngestOptions{
 		FilePath:     filePath,
 		SourceID:     sourceID,
 		MetadataPath: metadataPath,
 		TargetIndex:  index,
 		Label:        label,
 		Force:        force,
+		Trigger:      knowledge.TriggerDaemon,
 	})
 }
 
 // ingestGitHubRepo lists a GitHub repo's matching files and ingests each. A
 // missing token fails the whole repo entry with the exact env-var hint.
-func ingestGitHubRepo(ctx context.Context, client *knowledge.OpenSearchClient, tikaURL, index string, item ingestItem, force bool) error {
+func ingestGitHubRepo(ctx context.Context, client *knowledge.OpenSearchClient, tika *processing.TikaClient, index string, item ingestItem, force bool) error {
 	owner, repo, err := processing.ParseGitHubSource(item.Source)
 	if err != nil {
 		return fmt.Errorf("parsing GitHub source: %w", err)
@@ -303,11 +304,11 @@ func ingestGitHubRepo(ctx context.Context, client *knowledge.OpenSearchClient, t
 	if err != nil {
 		return fmt.Errorf("listing repository files: %w", err)
 	}
-	return ingestRepoEntries(ctx, client, tikaURL, index, entries, token, item.Label, force)
+	return ingestRepoEntries(ctx, client, tika, index, entries, token, item.Label, force)
 }
 
 // ingestGiteaRepo mirrors ingestGitHubRepo for Gitea.
-func ingestGiteaRepo(ctx context.Context, client *knowledge.OpenSearchClient, tikaURL, index string, item ingestItem, force bool) error {
+func ingestGiteaRepo(ctx context.Context, client *knowledge.OpenSearchClient, tika *processing.TikaClient, index string, item ingestItem, force bool) error {
 	baseURL, owner, repo, err := processing.ParseGiteaSource(item.Source)
 	if err != nil {
 		return fmt.Errorf("parsing Gitea source: %w", err)
@@ -320,20 +321,20 @@ func ingestGiteaRepo(ctx context.Context, client *knowledge.OpenSearchClient, ti
 	if err != nil {
 		return fmt.Errorf("listing repository files: %w", err)
 	}
-	return ingestRepoEntries(ctx, client, tikaURL, index, entries, token, item.Label, force)
+	return ingestRepoEntries(ctx, client, tika, index, entries, token, item.Label, force)
 }
 
 // ingestRepoEntries fetches and ingests each repo file, honouring cancellation.
-func ingestRepoEntries(ctx context.Context, client *knowledge.OpenSearchClient, tikaURL, index string, entries []processing.RepoEntry, token, label string, force bool) error {
+func ingestRepoEntries(ctx context.Context, client *knowledge.OpenSearchClient, tika *processing.TikaClient, index string, entries []processing.RepoEntry, token, label string, force bool) error {
 	for _, entry := range entries {
 		if err := ctx.Err(); err != nil {
 			return err
 		}
-		tempPath, cleanup, err := processing.FetchRepoFile(entry.RawURL, entry.Path, token)
+		tempPath, cleanup, err := processing.FetchRepoFile(client.Settings().Temp, entry.RawURL, entry.Path, token)
 		if err != nil {
 			return fmt.Errorf("fetching %q: %w", entry.Path, err)
 		}
-		err = ingestResolvedFile(ctx, client, tikaURL, index, tempPath, entry.Path, entry.Path, label, force)
+		err = ingestResolvedFile(ctx, client, tika, index, tempPath, entry.Path, entry.Path, label, force)
 		cleanup()
 		if err != nil {
 			return fmt.Errorf("ingesting %q: %w", entry.Path, err)
@@ -3,21 +3,26 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
 )
 
 // knowledgeBaseSummary is the API view of a knowledge base, derived from its
 // backing index.
 type knowledgeBaseSummary struct {
-	Name         string `json:"name"`
-	Index        string `json:"index"`
-	Health       string `json:"health"`
-	Status       string `json:"status"`
-	DocsCount    string `json:"docs_count"`
-	StoreSize    string `json:"store_size"`
+	Name      string `json:"name"`
+	Index     string `json:"index"`
+	Health    string `json:"health"`
+	Status    string `json:"status"`
+	DocsCount string `json:"docs_count"`
+	StoreSize string `json:"store_size"`
+	// StoreBytes is StoreSize in bytes, for storage budget checks; zero when
+	// the index statistics could not be read.
+	StoreBytes   uint64 `json:"store_bytes,omitempty"`
+	DeletedDocs  int64  `json:"deleted_docs,omitempty"`
 	SourceCount  int    `json:"source_count"`
 	DefaultLabel string `json:"default_label,omitempty"`
 }
@@ -61,6 +66,12 @@ func (s *Server) handleKnowledgeList(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		sourceCounts = map[string]int{}
 	}
+	// Byte counts are best-effort too: a user without index monitor
+	// permission still gets the list.
+	storage, err := client.IndexStorageStats(r.Context())
+	if err != nil {
+		storage = map[string]knowledge.IndexStorage{}
+	}
 
 	bases := make([]knowledgeBaseSummary, 0, len(indexes))
 	for _, idx := range indexes {
@@ -72,6 +83,8 @@ func (s *Server) handleKnowledgeList(w http.ResponseWriter, r *http.Request) {
 			Status:      idx.Status,
 			DocsCount:   idx.DocsCount,
 			StoreSize:   idx.StoreSize,
+			StoreBytes:  storage[idx.Name].StoreBytes,
+			DeletedDocs: storage[idx.Name].DeletedDocs,
 			SourceCount: sourceCounts[idx.Name],
 		})
 	}
@@ -353,6 +366,7 @@ func (s *Server) handleSourcesList(w http.ResponseWriter, r *http.Request) {
 //	  403: errorResponse
 //	  404: errorResponse
 //	  500: errorResponse
+//	  503: errorResponse
 func (s *Server) handleSourceGet(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	client, err := s.clients.openSearchClient()
@@ -362,7 +376,7 @@ func (s *Server) handleSourceGet(w http.ResponseWriter, r *http.Request) {
 	}
 	meta, err := client.GetSourceMetadata(r.Context(), id)
 	if err != nil {
-		respondError(w, http.StatusNotFound, err.Error())
+		respondError(w, knowledgeErrorStatus(err), err.Error())
 		return
 	}
 	meta.Label = knowledge.ResolveLabel(meta.IndexName, meta.Label)
@@ -380,6 +394,7 @@ func (s *Server) handleSourceGet(w http.ResponseWriter, r *http.Request) {
 //	  403: errorResponse
 //	  404: errorResponse
 //	  500: errorResponse
+//	  503: errorResponse
 func (s *Server) handleSourceDelete(w http.ResponseWriter, r *http.Request) {
 	name := r.PathValue("name")
 	id := r.PathValue("id")
@@ -388,8 +403,9 @@ func (s *Server) handleSourceDelete(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	if _, err := client.GetSourceMetadata(r.Context(), id); err != nil {
-		respondError(w, http.StatusNotFound, err.Error())
+	meta, err := client.GetSourceMetadata(r.Context(), id)
+	if err != nil {
+		respondError(w, knowledgeErrorStatus(err), err.Error())
 		return
 	}
 	index := knowledge.FullIndexName(name)
@@ -398,6 +414,12 @@ func (s *Server) handleSourceDelete(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	archived, err := client.DeleteArchivedChunks(r.Context(), meta)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	deleted += archived
 	if err := client.DeleteSourceMetadata(r.Context(), id); err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -407,3 +429,16 @@ func (s *Server) handleSourceDelete(w http.ResponseWriter, r *http.Request) {
 		"chunks_removed": deleted,
 	})
 }
+
+// knowledgeErrorStatus maps an error from the knowledge client to the status
+// to answer with: 404 for a missing knowledge base or source, 503 when
+// OpenSearch is unavailable, and 500 otherwise.
+func knowledgeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, knowledge.ErrIndexNotFound), errors.Is(err, knowledge.ErrSourceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, knowledge.ErrClusterUnavailable):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
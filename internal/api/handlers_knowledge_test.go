@@ -3,11 +3,13 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"testing"
 
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
 )
 
 // TestSearchValidation verifies request validation for POST /1.0/search occurs
@@ -114,3 +116,22 @@ func TestFallbackLabel(t *testing.T) {
 		t.Errorf("ResolveLabel(upstream, internal) = %q, want internal", got)
 	}
 }
+
+// TestKnowledgeErrorStatus verifies typed knowledge client errors map to the
+// status the source endpoints answer with, even when wrapped.
+func TestKnowledgeErrorStatus(t *testing.T) {
+	cases := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("%w: 'abc'", knowledge.ErrSourceNotFound), http.StatusNotFound},
+		{fmt.Errorf("get mapping: %w", knowledge.ErrIndexNotFound), http.StatusNotFound},
+		{fmt.Errorf("%w: connection refused", knowledge.ErrClusterUnavailable), http.StatusServiceUnavailable},
+		{errors.New("boom"), http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		if got := knowledgeErrorStatus(tc.err); got != tc.want {
+			t.Errorf("knowledgeErrorStatus(%q) = %d, want %d", tc.err, got, tc.want)
+		}
+	}
+}
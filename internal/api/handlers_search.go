@@ -5,7 +5,7 @@ import (
 	"net/http"
 	"strings"
 
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
 )
 
 // defaultSearchK is the default result count when the request omits one,
@@ -29,6 +29,7 @@ type searchResult struct {
 	CreatedAt string  `json:"created_at"`
 	Label     string  `json:"label"`
 	Content   string  `json:"content"`
+	Page      int     `json:"page,omitempty"`
 }
 
 // swagger:route POST /1.0/search search search
@@ -97,6 +98,7 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 			CreatedAt: h.CreatedAt,
 			Label:     h.Label,
 			Content:   h.Content,
+			Page:      h.Page,
 		})
 	}
 	respondSync(w, results)
@@ -11,8 +11,8 @@ import (
 	"time"
 
 	"github.com/jpnorenam/rag-snap/cmd/cli/basic/chat"
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
 	"github.com/jpnorenam/rag-snap/cmd/cli/config"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
 )
 
 // Service states reported by GET /1.0/status. A service is "not configured" when no
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// retentionInterval is how often ragd applies the knowledge bases' retention
+// policies.
+const retentionInterval = time.Hour
+
+// enforceRetention applies every knowledge base's retention policy each
+// interval until ctx is cancelled, forgetting the sources past a limit. A pass
+// is skipped while OpenSearch is unreachable, the next one catches up, and
+// while knowledge.read-only is set, since forgetting changes the cluster.
+func (s *Server) enforceRetention(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if getBool(s.ctx, confKnowledgeReadOnly, false) {
+				continue
+			}
+			client, err := s.clients.openSearchClient()
+			if err != nil {
+				continue
+			}
+			results, err := client.EnforceRetentionAll(ctx, time.Now())
+			for index, r := range results {
+				log.Printf("retention: forgot %d source(s), %d chunk(s) of %s", len(r.Sources), r.Chunks, index)
+			}
+			if err != nil {
+				log.Printf("retention: %v", err)
+			}
+		}
+	}
+}
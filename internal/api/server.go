@@ -10,11 +10,12 @@ import (
 	"sync"
 	"time"
 
-	"github.com/jpnorenam/rag-snap/cmd/cli/basic/knowledge"
 	"github.com/jpnorenam/rag-snap/cmd/cli/common"
 	"github.com/jpnorenam/rag-snap/cmd/cli/config"
 	"github.com/jpnorenam/rag-snap/internal/chatstore"
 	"github.com/jpnorenam/rag-snap/internal/webui"
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+	"github.com/jpnorenam/rag-snap/pkg/storage"
 )
 
 // apiVersion is the single supported major API version. New backward-compatible
@@ -92,6 +93,11 @@ type Options struct {
 	Loopback LoopbackConfig
 	// BackendURLs maps service name ("opensearch"/"openai"/"tika") to base URL.
 	BackendURLs map[string]string
+	// Settings are the package settings clients and sessions run with.
+	Settings Settings
+	// Readiness, when set, is the registry the daemon records its own and
+	// its backends' readiness in. Tests leave it nil.
+	Readiness *storage.Readiness
 }
 
 // New constructs a Server from already-resolved options. It does not bind the
@@ -102,12 +108,15 @@ func New(opts Options) *Server {
 		socket:   opts.Socket,
 		loopback: opts.Loopback,
 		backends: newBackendState(opts.BackendURLs),
-		clients:  newClientCache(opts.Context, opts.BackendURLs),
+		clients:  newClientCache(opts.Context, opts.BackendURLs, opts.Settings),
 		events:   newEventsHub(),
 		prompts:  newPromptStore(),
 		chats:    newChatStore(),
 		builds:   newBuildStore(),
 	}
+	if opts.Readiness != nil {
+		s.backends.registry = opts.Readiness
+	}
 	s.httpSrv = &http.Server{
 		Handler:           s.routes(),
 		ReadHeaderTimeout: 10 * time.Second,
@@ -118,8 +127,8 @@ func New(opts Options) *Server {
 	return s
 }
 
-// Serve binds the unix socket, starts background backend readiness polling, and
-// serves the API until ctx is cancelled. Backend reachability never blocks the
+// Serve binds the unix socket, starts background backend readiness polling and
+// retention enforcement, and serves the API until ctx is cancelled. Backend reachability never blocks the
 // listener: the socket is served as soon as it is bound.
 func (s *Server) Serve(ctx context.Context) error {
 	// The operations registry is bound to the serve context so in-flight work
@@ -145,6 +154,16 @@ func (s *Server) Serve(ctx context.Context) error {
 	}
 
 	go s.backends.poll(ctx, 10*time.Second)
+	go s.enforceRetention(ctx, retentionInterval)
+
+	// The daemon is ready once its socket is bound, and stops being so when
+	// it stops serving.
+	if s.backends.registry != nil {
+		if err := s.backends.registry.MarkReady(storage.ServiceRagd, time.Now()); err != nil {
+			log.Printf("recording daemon readiness: %v", err)
+		}
+		defer func() { _ = s.backends.registry.MarkNotReady(storage.ServiceRagd) }()
+	}
 
 	// Shut both HTTP servers down when the context is cancelled.
 	go func() {
@@ -325,6 +344,7 @@ func (s *Server) registerAPI(mux *http.ServeMux) {
 	mux.HandleFunc("POST /1.0/knowledge-engine", s.requireAuth(s.handleEngineInit))
 	mux.HandleFunc("GET /1.0/knowledge-engine/models", s.requireAuth(s.handleEngineModelsList))
 	mux.HandleFunc("DELETE /1.0/knowledge-engine/models/{id}", s.requireAuth(s.handleEngineModelDelete))
+	mux.HandleFunc("POST /1.0/knowledge-engine/models/{id}/undeploy", s.requireAuth(s.handleEngineModelUndeploy))
 
 	// Chat (interactive websocket session).
 	mux.HandleFunc("POST /1.0/chat", s.requireAuth(s.handleChatStart))
@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"github.com/canonical/go-snapctl/env"
+	"github.com/jpnorenam/rag-snap/pkg/httpclient"
 )
 
 // socketRelPath is the daemon socket location under $SNAP_COMMON, matching
@@ -64,11 +65,11 @@ func New(path string) *Client {
 	return &Client{
 		socketPath: path,
 		httpc: &http.Client{
-			Transport: &http.Transport{
+			Transport: httpclient.Trace(&http.Transport{
 				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
 					return dialer.DialContext(ctx, "unix", path)
 				},
-			},
+			}),
 		},
 	}
 }
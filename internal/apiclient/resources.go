@@ -19,6 +19,8 @@ type KnowledgeBase struct {
 	Status       string `json:"status"`
 	DocsCount    string `json:"docs_count"`
 	StoreSize    string `json:"store_size"`
+	StoreBytes   uint64 `json:"store_bytes,omitempty"`
+	DeletedDocs  int64  `json:"deleted_docs,omitempty"`
 	DefaultLabel string `json:"default_label,omitempty"`
 }
 
@@ -41,6 +43,8 @@ type Source struct {
 	Title         string `json:"title,omitempty"`
 	Author        string `json:"author,omitempty"`
 	Language      string `json:"language,omitempty"`
+	// DuplicateChunks counts chunks left out as already in the base.
+	DuplicateChunks int `json:"duplicate_chunks,omitempty"`
 }
 
 // LoopbackInfo is the client view of the loopback listener's state from the
@@ -80,6 +84,7 @@ type SearchHit struct {
 	CreatedAt string  `json:"created_at"`
 	Label     string  `json:"label"`
 	Content   string  `json:"content"`
+	Page      int     `json:"page,omitempty"`
 }
 
 // ListKnowledge returns all knowledge bases.
@@ -259,6 +264,16 @@ func (c *Client) DeleteEngineModel(ctx context.Context, id string, force bool) e
 	return c.Sync(ctx, "DELETE", path, nil, nil)
 }
 
+// UndeployEngineModel frees a model's memory on the ML nodes, keeping it
+// registered. force undeploys a model the engine currently uses.
+func (c *Client) UndeployEngineModel(ctx context.Context, id string, force bool) error {
+	path := "/1.0/knowledge-engine/models/" + id + "/undeploy"
+	if force {
+		path += "?force=true"
+	}
+	return c.Sync(ctx, "POST", path, nil, nil)
+}
+
 // Export starts an export operation for a knowledge base and returns the
 // operation URL.
 func (c *Client) Export(ctx context.Context, name, outputDir string, compress bool) (string, error) {
@@ -0,0 +1,186 @@
+package chatstore
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ExportFormat is a rendering of a chat for archiving or sharing.
+type ExportFormat string
+
+const (
+	// FormatMarkdown renders the chat as a Markdown document.
+	FormatMarkdown ExportFormat = "markdown"
+	// FormatHTML renders the chat as a standalone HTML page.
+	FormatHTML ExportFormat = "html"
+)
+
+// exportTimeLayout renders turn and chat timestamps, always in UTC so an export
+// reads the same wherever it is opened.
+const exportTimeLayout = "2006-01-02 15:04:05 MST"
+
+// FormatForPath picks the export format from path's extension: .md and
+// .markdown render Markdown, .html and .htm render HTML. Anything else is an
+// error rather than a guess.
+func FormatForPath(path string) (ExportFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return FormatMarkdown, nil
+	case ".html", ".htm":
+		return FormatHTML, nil
+	default:
+		return "", fmt.Errorf("cannot export to %q: use a .md or .html file name", path)
+	}
+}
+
+// Export renders c in the format implied by path's extension and writes it to
+// path, replacing any existing file.
+func Export(c Chat, path string) error {
+	format, err := FormatForPath(path)
+	if err != nil {
+		return err
+	}
+	data, err := Render(c, format)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Render returns c as a document in the given format. Reasoning/<think> spans
+// are stripped from assistant turns, and a chat without a title is titled from
+// its first prompt, as Save would.
+func Render(c Chat, format ExportFormat) ([]byte, error) {
+	c.Turns = strippedTurns(c.Turns)
+	if strings.TrimSpace(c.Title) == "" {
+		c.Title = deriveTitle(c.Turns)
+	}
+	switch format {
+	case FormatMarkdown:
+		return renderMarkdown(c), nil
+	case FormatHTML:
+		return renderHTML(c)
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+func renderMarkdown(c Chat) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# %s\n\n", c.Title)
+	for _, f := range exportFields(c) {
+		fmt.Fprintf(&b, "- **%s:** %s\n", f.Name, f.Value)
+	}
+	for _, t := range c.Turns {
+		fmt.Fprintf(&b, "\n## %s", turnLabel(t.Role))
+		if !t.At.IsZero() {
+			fmt.Fprintf(&b, " · %s", formatExportTime(t.At))
+		}
+		fmt.Fprintf(&b, "\n\n%s\n", strings.TrimSpace(t.Content))
+		if len(t.Sources) > 0 {
+			quoted := make([]string, len(t.Sources))
+			for i, s := range t.Sources {
+				quoted[i] = "`" + s + "`"
+			}
+			fmt.Fprintf(&b, "\n**Sources:** %s\n", strings.Join(quoted, ", "))
+		}
+	}
+	return b.Bytes()
+}
+
+// htmlExport is a standalone page: turn content is kept as preformatted text
+// rather than rendered as Markdown, so the export needs no extra dependencies
+// and shows exactly what the model wrote.
+var htmlExport = template.Must(template.New("chat").Funcs(template.FuncMap{
+	"label": turnLabel,
+	"when":  formatExportTime,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Chat.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 50rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+.meta { color: #666; }
+.turn { border-top: 1px solid #ddd; padding: 0.5rem 0; }
+.turn h2 { font-size: 1rem; margin: 0.5rem 0; }
+.turn time { color: #888; font-weight: normal; }
+.user h2 { color: #c7162b; }
+pre { white-space: pre-wrap; font-family: inherit; margin: 0; }
+.sources { color: #666; font-size: 0.9rem; }
+</style>
+</head>
+<body>
+<h1>{{.Chat.Title}}</h1>
+<ul class="meta">
+{{- range .Fields}}
+<li><strong>{{.Name}}:</strong> {{.Value}}</li>
+{{- end}}
+</ul>
+{{- range .Chat.Turns}}
+<section class="turn {{.Role}}">
+<h2>{{label .Role}}{{if not .At.IsZero}} <time datetime="{{.At.UTC.Format "2006-01-02T15:04:05Z07:00"}}">{{when .At}}</time>{{end}}</h2>
+<pre>{{.Content}}</pre>
+{{- if .Sources}}
+<p class="sources"><strong>Sources:</strong>{{range $i, $s := .Sources}}{{if $i}},{{end}} <code>{{$s}}</code>{{end}}</p>
+{{- end}}
+</section>
+{{- end}}
+</body>
+</html>
+`))
+
+func renderHTML(c Chat) ([]byte, error) {
+	var b bytes.Buffer
+	err := htmlExport.Execute(&b, struct {
+		Chat   Chat
+		Fields []exportField
+	}{c, exportFields(c)})
+	if err != nil {
+		return nil, fmt.Errorf("rendering chat: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// exportField is one line of the header listing a chat's provenance.
+type exportField struct {
+	Name  string
+	Value string
+}
+
+// exportFields returns the header lines for c, skipping anything unset.
+func exportFields(c Chat) []exportField {
+	var fields []exportField
+	if c.Model != "" {
+		fields = append(fields, exportField{"Model", c.Model})
+	}
+	if len(c.Bases) > 0 {
+		fields = append(fields, exportField{"Knowledge bases", strings.Join(c.Bases, ", ")})
+	}
+	if c.Prompt != "" {
+		fields = append(fields, exportField{"Prompt", c.Prompt})
+	}
+	if !c.CreatedAt.IsZero() {
+		fields = append(fields, exportField{"Started", formatExportTime(c.CreatedAt)})
+	}
+	if !c.UpdatedAt.IsZero() {
+		fields = append(fields, exportField{"Last updated", formatExportTime(c.UpdatedAt)})
+	}
+	return fields
+}
+
+func turnLabel(role string) string {
+	if role == "assistant" {
+		return "Assistant"
+	}
+	return "You"
+}
+
+func formatExportTime(t time.Time) string {
+	return t.UTC().Format(exportTimeLayout)
+}
@@ -0,0 +1,100 @@
+package chatstore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func exportSample() Chat {
+	at := time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC)
+	return Chat{
+		Model: "m1",
+		Bases: []string{"default", "docs"},
+		Turns: []Turn{
+			{Role: "user", Content: "How do I rotate the <admin> password?", At: at},
+			{Role: "assistant", Content: "<think>recall docs</think>Use the securityadmin tool.", At: at.Add(5 * time.Second), Sources: []string{"guide.pdf", "faq.md"}},
+		},
+	}
+}
+
+func TestFormatForPath(t *testing.T) {
+	cases := map[string]ExportFormat{
+		"chat.md":        FormatMarkdown,
+		"notes.markdown": FormatMarkdown,
+		"out/chat.HTML":  FormatHTML,
+		"chat.htm":       FormatHTML,
+		"transcript.txt": "",
+		"no-extension":   "",
+	}
+	for path, want := range cases {
+		got, err := FormatForPath(path)
+		if want == "" {
+			if err == nil {
+				t.Errorf("FormatForPath(%q) = %q, want error", path, got)
+			}
+			continue
+		}
+		if err != nil || got != want {
+			t.Errorf("FormatForPath(%q) = (%q, %v), want %q", path, got, err, want)
+		}
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	out, err := Render(exportSample(), FormatMarkdown)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	md := string(out)
+	for _, want := range []string{
+		"# How do I rotate the <admin> password?\n",
+		"- **Knowledge bases:** default, docs\n",
+		"## You · 2026-03-04 10:30:00 UTC\n",
+		"## Assistant · 2026-03-04 10:30:05 UTC\n\nUse the securityadmin tool.\n",
+		"**Sources:** `guide.pdf`, `faq.md`\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown export missing %q:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "recall docs") {
+		t.Errorf("markdown export kept a <think> span:\n%s", md)
+	}
+}
+
+func TestRenderHTMLEscapesContent(t *testing.T) {
+	out, err := Render(exportSample(), FormatHTML)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	page := string(out)
+	if strings.Contains(page, "<admin>") {
+		t.Errorf("html export did not escape turn content:\n%s", page)
+	}
+	for _, want := range []string{"&lt;admin&gt;", "<code>guide.pdf</code>", `datetime="2026-03-04T10:30:05Z"`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("html export missing %q:\n%s", want, page)
+		}
+	}
+}
+
+func TestExportWritesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chat.html")
+	if err := Export(exportSample(), path); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading export: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "<!DOCTYPE html>") {
+		t.Errorf("export is not an HTML page: %.40q", data)
+	}
+
+	if err := Export(exportSample(), filepath.Join(t.TempDir(), "chat.txt")); err == nil {
+		t.Error("Export to .txt = nil error, want error")
+	}
+}
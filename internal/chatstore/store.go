@@ -36,6 +36,8 @@ var (
 	// ErrUnavailable is returned when the store directory could not be resolved,
 	// so persistence is impossible while reads degrade to an empty store.
 	ErrUnavailable = errors.New("chat store is unavailable: cannot persist chats")
+	// ErrInvalidBranch is returned by Save for a branch name ValidBranch rejects.
+	ErrInvalidBranch = errors.New("invalid branch name: use letters, digits, dots, dashes, and underscores, at most 40 characters")
 )
 
 // titleMaxRunes bounds a derived title so a long first prompt does not become an
@@ -46,12 +48,21 @@ const titleMaxRunes = 60
 // from a request path can never escape the store directory.
 var idPattern = regexp.MustCompile(`^[a-f0-9]{8,}$`)
 
+// branchPattern is the shape of a branch name.
+var branchPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,39}$`)
+
 // Turn is one exchange in a saved conversation: a user prompt or an assistant
 // reply, as plain text. Reasoning/<think> spans are stripped from assistant
 // content before it reaches the store.
 type Turn struct {
 	Role    string `json:"role"` // "user" or "assistant"
 	Content string `json:"content"`
+	// At is when the prompt was sent or the reply finished. Records saved before
+	// the field existed decode it as zero.
+	At time.Time `json:"at,omitzero"`
+	// Sources lists the source ids whose chunks grounded an assistant reply, in
+	// retrieval order. Empty for user turns and ungrounded replies.
+	Sources []string `json:"sources,omitempty"`
 }
 
 // Chat is a saved conversation with everything needed to resume it.
@@ -69,6 +80,11 @@ type Chat struct {
 	// pinning this value, and records saved before the field existed decode it as
 	// empty (omitempty keeps their shape unchanged).
 	Prompt string `json:"prompt,omitempty"`
+	// Branch names a conversation forked from another, ParentID, to explore an
+	// alternative line of questioning; both are empty for a conversation that
+	// was not forked. Branches of a branch share its parent.
+	Branch   string `json:"branch,omitempty"`
+	ParentID string `json:"parent_id,omitempty"`
 }
 
 // Summary is the transcript-free view returned by List: enough to render a
@@ -83,6 +99,9 @@ type Summary struct {
 	TurnCount int       `json:"turn_count"`
 	// Prompt is the chat's prompt provenance reference (see Chat.Prompt).
 	Prompt string `json:"prompt,omitempty"`
+	// Branch and ParentID place a forked chat (see Chat.Branch).
+	Branch   string `json:"branch,omitempty"`
+	ParentID string `json:"parent_id,omitempty"`
 }
 
 // Store persists chats as one JSON file per id under dir. When dir is empty the
@@ -103,11 +122,15 @@ func New(dir string) *Store {
 // id and created_at are assigned; otherwise the record is updated in place,
 // preserving the original created_at (recreating it if the file is gone). The
 // title is used verbatim when non-empty, else kept from the existing record, else
-// derived from the first user turn. An empty transcript is rejected with ErrEmpty.
+// derived from the first user turn. A record updated without a branch keeps the
+// one it was saved with. An empty transcript is rejected with ErrEmpty.
 func (s *Store) Save(in Chat) (Chat, error) {
 	if !hasTurns(in.Turns) {
 		return Chat{}, ErrEmpty
 	}
+	if in.Branch != "" && !ValidBranch(in.Branch) {
+		return Chat{}, ErrInvalidBranch
+	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -143,6 +166,10 @@ func (s *Store) Save(in Chat) (Chat, error) {
 	}
 	out.UpdatedAt = now
 
+	if out.Branch == "" && existing != nil {
+		out.Branch, out.ParentID = existing.Branch, existing.ParentID
+	}
+
 	if strings.TrimSpace(out.Title) == "" {
 		if existing != nil && existing.Title != "" {
 			out.Title = existing.Title
@@ -184,6 +211,35 @@ func (s *Store) List(search string) ([]Summary, error) {
 	return summaries, nil
 }
 
+// Branches returns the summaries of the chats forked from parentID, sorted by
+// branch name.
+func (s *Store) Branches(parentID string) ([]Summary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chats, err := s.loadAllLocked()
+	if err != nil {
+		return nil, err
+	}
+	var branches []Summary
+	for _, c := range chats {
+		if c.Branch != "" && c.ParentID == parentID {
+			branches = append(branches, summaryOf(c))
+		}
+	}
+	sort.Slice(branches, func(i, j int) bool {
+		return branches[i].Branch < branches[j].Branch
+	})
+	return branches, nil
+}
+
+// ValidBranch reports whether name can name a branch: letters, digits, dots,
+// dashes, and underscores, starting with a letter or digit, at most 40
+// characters.
+func ValidBranch(name string) bool {
+	return branchPattern.MatchString(name)
+}
+
 // Get returns the full chat for id, or ErrNotFound.
 func (s *Store) Get(id string) (Chat, error) {
 	s.mu.Lock()
@@ -330,6 +386,8 @@ func summaryOf(c Chat) Summary {
 		Bases:     c.Bases,
 		TurnCount: len(c.Turns),
 		Prompt:    c.Prompt,
+		Branch:    c.Branch,
+		ParentID:  c.ParentID,
 	}
 }
 
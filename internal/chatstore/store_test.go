@@ -231,3 +231,36 @@ func TestStripThink(t *testing.T) {
 		}
 	}
 }
+
+func TestBranches(t *testing.T) {
+	s := New(t.TempDir())
+	parent, err := s.Save(Chat{Turns: sampleTurns()})
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	for _, name := range []string{"zeta", "alpha"} {
+		if _, err := s.Save(Chat{Branch: name, ParentID: parent.ID, Turns: sampleTurns()}); err != nil {
+			t.Fatalf("Save branch %s: %v", name, err)
+		}
+	}
+	if _, err := s.Save(Chat{Branch: "no spaces", ParentID: parent.ID, Turns: sampleTurns()}); err != ErrInvalidBranch {
+		t.Fatalf("Save with an invalid branch = %v, want ErrInvalidBranch", err)
+	}
+
+	branches, err := s.Branches(parent.ID)
+	if err != nil {
+		t.Fatalf("Branches: %v", err)
+	}
+	if len(branches) != 2 || branches[0].Branch != "alpha" || branches[1].Branch != "zeta" {
+		t.Fatalf("branches = %+v, want alpha then zeta", branches)
+	}
+
+	// Saving a branch again without its branch fields, as /save does, keeps them.
+	updated, err := s.Save(Chat{ID: branches[0].ID, Turns: sampleTurns()})
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if updated.Branch != "alpha" || updated.ParentID != parent.ID {
+		t.Fatalf("re-saved branch lost its place: %+v", updated)
+	}
+}
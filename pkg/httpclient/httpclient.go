@@ -0,0 +1,187 @@
+// Package httpclient builds the http.Clients used for outbound requests (web
+// crawling, Tika, Git forges, the snap store, the inference server), so proxy,
+// timeout, and response-size settings are configured once and honoured
+// everywhere.
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jpnorenam/rag-snap/pkg/utils"
+)
+
+// Config keys read by OptionsFromLookup.
+const (
+	ConfProxy          = "http.proxy"
+	ConfConnectTimeout = "http.timeout.connect"
+	ConfReadTimeout    = "http.timeout.read"
+	ConfMaxResponse    = "http.response.max"
+)
+
+// DefaultConnectTimeout matches net/http's default dialer timeout.
+const DefaultConnectTimeout = 30 * time.Second
+
+// maxIdleConnsPerHost is how many idle connections the transport keeps open
+// to each host.
+const maxIdleConnsPerHost = 16
+
+// ErrResponseTooLarge is returned by ReadAll when a body exceeds the configured
+// maximum response size.
+var ErrResponseTooLarge = errors.New("response exceeds the configured maximum size")
+
+// Options configures outbound HTTP behaviour. The zero value uses the
+// environment's proxy settings, the default connect timeout, no read timeout,
+// and no response-size cap.
+type Options struct {
+	// ProxyURL routes every request through this proxy. When empty,
+	// HTTP_PROXY/HTTPS_PROXY/NO_PROXY from the environment apply.
+	ProxyURL string
+	// ConnectTimeout bounds establishing the TCP connection.
+	ConnectTimeout time.Duration
+	// ReadTimeout bounds the wait for response headers after the request is
+	// sent. Zero disables it, since extraction and generation can be slow.
+	ReadTimeout time.Duration
+	// MaxResponseBytes caps bodies read through ReadAll. Zero means no cap.
+	MaxResponseBytes int64
+}
+
+var (
+	mu        sync.RWMutex
+	current   Options
+	transport http.RoundTripper = buildTransport(Options{})
+)
+
+// Configure validates opts and makes them the settings for every client
+// returned by New from now on.
+func Configure(opts Options) error {
+	t, err := newTransport(opts)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	current = opts
+	transport = t
+	return nil
+}
+
+// New returns a client using the configured transport. timeout bounds the
+// whole request including reading the body; zero means no overall timeout.
+// Clients share one transport, so connections are pooled across callers, and
+// are traced while EnableTrace is in effect.
+func New(timeout time.Duration) *http.Client {
+	mu.RLock()
+	defer mu.RUnlock()
+	return &http.Client{Transport: Trace(transport), Timeout: timeout}
+}
+
+// MaxResponseBytes returns the configured response-size cap, or zero when
+// responses are unbounded.
+func MaxResponseBytes() int64 {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current.MaxResponseBytes
+}
+
+// ReadAll reads r to the end, failing with ErrResponseTooLarge once more than
+// the configured maximum response size has been read.
+func ReadAll(r io.Reader) ([]byte, error) {
+	limit := MaxResponseBytes()
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w (%s)", ErrResponseTooLarge, utils.FmtBytes(uint64(limit)))
+	}
+	return data, nil
+}
+
+// OptionsFromLookup builds Options from the http.* config keys. get returns a
+// key's value; a lookup error or empty value leaves that setting at its
+// default, so an install without the keys behaves as before.
+func OptionsFromLookup(get func(key string) (string, error)) (Options, error) {
+	value := func(key string) string {
+		v, err := get(key)
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(v)
+	}
+
+	opts := Options{ProxyURL: value(ConfProxy)}
+	var err error
+	if opts.ConnectTimeout, err = parseDuration(ConfConnectTimeout, value(ConfConnectTimeout)); err != nil {
+		return Options{}, err
+	}
+	if opts.ReadTimeout, err = parseDuration(ConfReadTimeout, value(ConfReadTimeout)); err != nil {
+		return Options{}, err
+	}
+	if v := value(ConfMaxResponse); v != "" {
+		size, err := utils.StringToBytes(v)
+		if err != nil {
+			return Options{}, fmt.Errorf("invalid %s %q: expected a byte count with optional M or G suffix", ConfMaxResponse, v)
+		}
+		opts.MaxResponseBytes = int64(size)
+	}
+	if _, err := newTransport(opts); err != nil {
+		return Options{}, err
+	}
+	return opts, nil
+}
+
+func parseDuration(key, v string) (time.Duration, error) {
+	if v == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q: expected a duration such as 10s or 2m", key, v)
+	}
+	return d, nil
+}
+
+// newTransport validates opts and builds the transport for them.
+func newTransport(opts Options) (http.RoundTripper, error) {
+	if opts.ProxyURL != "" {
+		u, err := url.Parse(opts.ProxyURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid %s %q: expected a URL such as http://proxy:3128", ConfProxy, opts.ProxyURL)
+		}
+	}
+	return buildTransport(opts), nil
+}
+
+// buildTransport clones the default transport and applies opts. ProxyURL must
+// already be validated.
+func buildTransport(opts Options) http.RoundTripper {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	t.Proxy = http.ProxyFromEnvironment
+	if opts.ProxyURL != "" {
+		u, _ := url.Parse(opts.ProxyURL)
+		t.Proxy = http.ProxyURL(u)
+	}
+
+	connect := opts.ConnectTimeout
+	if connect <= 0 {
+		connect = DefaultConnectTimeout
+	}
+	t.DialContext = (&net.Dialer{Timeout: connect, KeepAlive: 30 * time.Second}).DialContext
+	t.ResponseHeaderTimeout = opts.ReadTimeout
+	// Keep enough idle connections per host for parallel Tika extractions
+	// (tika.concurrency) to reuse theirs, rather than net/http's default 2.
+	t.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	return t
+}
@@ -0,0 +1,82 @@
+package httpclient
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func lookup(values map[string]string) func(string) (string, error) {
+	return func(key string) (string, error) {
+		v, ok := values[key]
+		if !ok {
+			return "", errors.New("unknown key")
+		}
+		return v, nil
+	}
+}
+
+func TestOptionsFromLookup(t *testing.T) {
+	opts, err := OptionsFromLookup(lookup(map[string]string{
+		ConfProxy:          "http://proxy.internal:3128",
+		ConfConnectTimeout: "5s",
+		ConfReadTimeout:    "2m",
+		ConfMaxResponse:    "10M",
+	}))
+	if err != nil {
+		t.Fatalf("OptionsFromLookup returned error: %v", err)
+	}
+	want := Options{
+		ProxyURL:         "http://proxy.internal:3128",
+		ConnectTimeout:   5 * time.Second,
+		ReadTimeout:      2 * time.Minute,
+		MaxResponseBytes: 10 * 1024 * 1024,
+	}
+	if opts != want {
+		t.Errorf("OptionsFromLookup = %+v, want %+v", opts, want)
+	}
+
+	// Unset or unknown keys keep the defaults.
+	opts, err = OptionsFromLookup(lookup(map[string]string{ConfProxy: ""}))
+	if err != nil || opts != (Options{}) {
+		t.Errorf("OptionsFromLookup(empty) = (%+v, %v), want zero options", opts, err)
+	}
+}
+
+func TestOptionsFromLookupInvalid(t *testing.T) {
+	cases := map[string]map[string]string{
+		"proxy without scheme": {ConfProxy: "proxy.internal:3128"},
+		"bad connect timeout":  {ConfConnectTimeout: "soon"},
+		"negative read":        {ConfReadTimeout: "-1s"},
+		"bad size":             {ConfMaxResponse: "10K"},
+	}
+	for name, values := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := OptionsFromLookup(lookup(values)); err == nil {
+				t.Errorf("OptionsFromLookup(%v) = nil error, want error", values)
+			}
+		})
+	}
+}
+
+func TestReadAll(t *testing.T) {
+	t.Cleanup(func() { _ = Configure(Options{}) })
+
+	if err := Configure(Options{MaxResponseBytes: 4}); err != nil {
+		t.Fatalf("Configure returned error: %v", err)
+	}
+	if data, err := ReadAll(strings.NewReader("abcd")); err != nil || string(data) != "abcd" {
+		t.Errorf("ReadAll(at limit) = (%q, %v), want (\"abcd\", nil)", data, err)
+	}
+	if _, err := ReadAll(strings.NewReader("abcde")); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("ReadAll(over limit) error = %v, want ErrResponseTooLarge", err)
+	}
+
+	if err := Configure(Options{}); err != nil {
+		t.Fatalf("Configure returned error: %v", err)
+	}
+	if data, err := ReadAll(strings.NewReader("abcdefgh")); err != nil || len(data) != 8 {
+		t.Errorf("ReadAll(unbounded) = (%q, %v), want all 8 bytes", data, err)
+	}
+}
@@ -0,0 +1,225 @@
+package httpclient
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// traceBodyLimit is how much of each request and response body a trace
+// entry keeps.
+const traceBodyLimit = 4096
+
+// traceRedacted replaces secrets in trace entries. It needs no escaping in a
+// URL.
+const traceRedacted = "REDACTED"
+
+var (
+	traceMu  sync.Mutex
+	traceOut io.Writer
+)
+
+// EnableTrace appends an entry for every HTTP exchange made through New's
+// clients, or through a transport wrapped by Trace, to the file at path: the
+// method, URL, status, and duration, with the start of each body. Secrets in
+// URLs and bodies are redacted, and no headers are recorded, so credentials
+// never reach the file. An empty path disables tracing.
+func EnableTrace(path string) error {
+	traceMu.Lock()
+	defer traceMu.Unlock()
+	if c, ok := traceOut.(io.Closer); ok {
+		_ = c.Close()
+	}
+	traceOut = nil
+	if path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening trace file: %w", err)
+	}
+	traceOut = f
+	return nil
+}
+
+// Trace wraps rt so its exchanges are traced while EnableTrace is in effect.
+// Clients that cannot come from New, such as the OpenSearch client with its
+// own TLS settings, wrap their transport with it.
+func Trace(rt http.RoundTripper) http.RoundTripper {
+	return &traceTransport{next: rt}
+}
+
+type traceTransport struct {
+	next http.RoundTripper
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	traceMu.Lock()
+	enabled := traceOut != nil
+	traceMu.Unlock()
+	if !enabled {
+		return t.next.RoundTrip(req)
+	}
+
+	entry := &traceEntry{
+		start:       time.Now(),
+		method:      req.Method,
+		url:         redactURL(req.URL),
+		requestBody: requestBodyPrefix(req),
+	}
+	resp, err := t.next.RoundTrip(req)
+	entry.duration = time.Since(entry.start)
+	if err != nil {
+		entry.err = err
+		entry.write()
+		return nil, err
+	}
+	entry.status = resp.Status
+	if resp.Body == nil || resp.Body == http.NoBody {
+		entry.write()
+		return resp, nil
+	}
+	// The entry is written once the caller is done with the body, so
+	// streamed responses (chat completions) reach it as they arrive.
+	resp.Body = &traceBody{ReadCloser: resp.Body, entry: entry}
+	return resp, nil
+}
+
+// requestBodyPrefix returns the start of a request's body without consuming
+// it, or a note when the body can only be read once (a streamed upload).
+func requestBodyPrefix(req *http.Request) string {
+	if req.Body == nil || req.Body == http.NoBody {
+		return ""
+	}
+	if req.GetBody == nil {
+		return "(streamed body not recorded)"
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return "(body not recorded: " + err.Error() + ")"
+	}
+	defer body.Close()
+	data, _ := io.ReadAll(io.LimitReader(body, traceBodyLimit+1))
+	return traceBodyText(data)
+}
+
+// traceEntry is one exchange, written to the trace file as a block of lines.
+type traceEntry struct {
+	start        time.Time
+	duration     time.Duration
+	method, url  string
+	status       string
+	err          error
+	requestBody  string
+	responseBody bytes.Buffer
+	written      bool
+}
+
+func (e *traceEntry) write() {
+	if e.written {
+		return
+	}
+	e.written = true
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s", e.start.UTC().Format(time.RFC3339Nano), e.method, e.url)
+	if e.err != nil {
+		fmt.Fprintf(&b, " -> error after %s: %v\n", e.duration.Round(time.Millisecond), e.err)
+	} else {
+		fmt.Fprintf(&b, " -> %s in %s\n", e.status, e.duration.Round(time.Millisecond))
+	}
+	if e.requestBody != "" {
+		fmt.Fprintf(&b, "> %s\n", indentTraceBody(e.requestBody, "> "))
+	}
+	if e.responseBody.Len() > 0 {
+		fmt.Fprintf(&b, "< %s\n", indentTraceBody(traceBodyText(e.responseBody.Bytes()), "< "))
+	}
+	b.WriteString("\n")
+
+	traceMu.Lock()
+	defer traceMu.Unlock()
+	if traceOut != nil {
+		_, _ = io.WriteString(traceOut, b.String())
+	}
+}
+
+// traceBody records the start of a response body as the caller reads it, and
+// writes the entry when the body is closed.
+type traceBody struct {
+	io.ReadCloser
+	entry *traceEntry
+}
+
+func (b *traceBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := traceBodyLimit + 1 - b.entry.responseBody.Len(); room > 0 {
+		b.entry.responseBody.Write(p[:min(n, room)])
+	}
+	return n, err
+}
+
+func (b *traceBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.entry.write()
+	return err
+}
+
+// traceBodyText renders up to traceBodyLimit bytes of a body, with secrets
+// redacted and a note when it was cut short.
+func traceBodyText(data []byte) string {
+	truncated := len(data) > traceBodyLimit
+	if truncated {
+		data = data[:traceBodyLimit]
+	}
+	text := redactBody(string(data))
+	if truncated {
+		text += " ... (truncated)"
+	}
+	return text
+}
+
+func indentTraceBody(text, prefix string) string {
+	return strings.ReplaceAll(strings.TrimRight(text, "\n"), "\n", "\n"+prefix)
+}
+
+// secretName matches the names of fields and parameters that hold
+// credentials.
+const secretName = `(?i)[\w-]*(?:password|passwd|secret|token|api[_-]?key|apikey|authorization|credential)[\w-]*`
+
+var (
+	// secretJSONField matches a JSON string field with a secret name.
+	secretJSONField = regexp.MustCompile(`("` + secretName + `"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	// secretFormField matches a form-encoded field with a secret name.
+	secretFormField = regexp.MustCompile(`(^|&)(` + secretName + `)=[^&\s]*`)
+	secretParam     = regexp.MustCompile(`^` + secretName + `$`)
+)
+
+// redactBody replaces the values of secret-named JSON and form fields.
+func redactBody(body string) string {
+	body = secretJSONField.ReplaceAllString(body, `$1"`+traceRedacted+`"`)
+	return secretFormField.ReplaceAllString(body, `$1$2=`+traceRedacted)
+}
+
+// redactURL renders u without its user info and with the values of
+// secret-named query parameters replaced.
+func redactURL(u *url.URL) string {
+	c := *u
+	c.User = nil
+	if c.RawQuery != "" {
+		q := c.Query()
+		for name := range q {
+			if secretParam.MatchString(name) {
+				q.Set(name, traceRedacted)
+			}
+		}
+		c.RawQuery = q.Encode()
+	}
+	return c.String()
+}
@@ -0,0 +1,71 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{`{"user":"admin","password":"s3cr\"et"}`, `{"user":"admin","password":"REDACTED"}`},
+		{`{"api_key": "sk-123", "model": "x"}`, `{"api_key": "REDACTED", "model": "x"}`},
+		{`grant_type=refresh_token&refresh_token=abc&client_secret=def`, `grant_type=refresh_token&refresh_token=REDACTED&client_secret=REDACTED`},
+		{`{"query":{"match":{"content":"token limits"}}}`, `{"query":{"match":{"content":"token limits"}}}`},
+	}
+	for _, tt := range tests {
+		if got := redactBody(tt.in); got != tt.want {
+			t.Errorf("redactBody(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	u, _ := url.Parse("https://admin:pw@host:9200/_search?access_token=abc&size=5")
+	got := redactURL(u)
+	if strings.Contains(got, "pw") || strings.Contains(got, "abc") {
+		t.Errorf("redactURL = %s, still holds a secret", got)
+	}
+	if !strings.Contains(got, "size=5") {
+		t.Errorf("redactURL = %s, lost a plain parameter", got)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"access_token":"tok","ok":true}`)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "trace.log")
+	if err := EnableTrace(path); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = EnableTrace("") }()
+
+	resp, err := New(0).Post(srv.URL+"/login", "application/json", strings.NewReader(`{"password":"pw"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trace := string(data)
+	for _, want := range []string{"POST " + srv.URL + "/login -> 200 OK", `> {"password":"REDACTED"}`, `< {"access_token":"REDACTED","ok":true}`} {
+		if !strings.Contains(trace, want) {
+			t.Errorf("trace lacks %q:\n%s", want, trace)
+		}
+	}
+	if strings.Contains(trace, "tok\"") || strings.Contains(trace, "pw\"") {
+		t.Errorf("trace holds a secret:\n%s", trace)
+	}
+}
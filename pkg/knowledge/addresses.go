@@ -0,0 +1,60 @@
+package knowledge
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// ConfHTTPHosts lists the OpenSearch nodes to use, comma-separated. When set it
+// replaces knowledge.http.host; entries without a port take knowledge.http.port.
+const ConfHTTPHosts = "knowledge.http.hosts"
+
+// ClusterURL builds the OpenSearch URL for a comma-separated list of node
+// addresses. Each entry is a host, a host:port, or a full URL; bare hosts get
+// defaultPort and a scheme chosen by secure. The result is the node URLs
+// joined by commas, the form NewClient accepts for a multi-node cluster.
+func ClusterURL(hosts, defaultPort string, secure bool) (string, error) {
+	scheme := "http"
+	if secure {
+		scheme = "https"
+	}
+	var nodes []string
+	for _, entry := range strings.Split(hosts, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "://") {
+			if _, _, err := net.SplitHostPort(entry); err != nil {
+				if defaultPort == "" {
+					return "", fmt.Errorf("invalid %s entry %q: no port given and knowledge.http.port is not set", ConfHTTPHosts, entry)
+				}
+				entry = net.JoinHostPort(entry, defaultPort)
+			}
+			entry = scheme + "://" + entry
+		}
+		u, err := url.Parse(entry)
+		if err != nil || u.Host == "" {
+			return "", fmt.Errorf("invalid %s entry %q", ConfHTTPHosts, entry)
+		}
+		nodes = append(nodes, strings.TrimSuffix(u.String(), "/"))
+	}
+	if len(nodes) == 0 {
+		return "", fmt.Errorf("%s lists no addresses", ConfHTTPHosts)
+	}
+	return strings.Join(nodes, ","), nil
+}
+
+// nodeURLs splits a cluster URL built by ClusterURL (or a single node URL)
+// into its node URLs.
+func nodeURLs(clusterURL string) []string {
+	var nodes []string
+	for _, node := range strings.Split(clusterURL, ",") {
+		if node = strings.TrimSpace(node); node != "" {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
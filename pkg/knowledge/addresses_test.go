@@ -0,0 +1,36 @@
+package knowledge
+
+import "testing"
+
+func TestClusterURL(t *testing.T) {
+	tests := []struct {
+		hosts  string
+		port   string
+		secure bool
+		want   string
+	}{
+		{"10.0.0.1", "9200", true, "https://10.0.0.1:9200"},
+		{"node-a, node-b:9201", "9200", false, "http://node-a:9200,http://node-b:9201"},
+		{"https://search.example.com, http://10.0.0.2:9200/", "", true, "https://search.example.com,http://10.0.0.2:9200"},
+		{"node-a,,node-b", "9200", true, "https://node-a:9200,https://node-b:9200"},
+	}
+	for _, tt := range tests {
+		got, err := ClusterURL(tt.hosts, tt.port, tt.secure)
+		if err != nil {
+			t.Errorf("ClusterURL(%q) error: %v", tt.hosts, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ClusterURL(%q) = %q, want %q", tt.hosts, got, tt.want)
+		}
+		if nodes := nodeURLs(got); len(nodes) == 0 {
+			t.Errorf("nodeURLs(%q) is empty", got)
+		}
+	}
+
+	for _, hosts := range []string{"", " , ", "node-a"} {
+		if _, err := ClusterURL(hosts, "", true); err == nil {
+			t.Errorf("ClusterURL(%q) with no default port = nil error, want error", hosts)
+		}
+	}
+}
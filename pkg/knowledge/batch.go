@@ -0,0 +1,420 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jpnorenam/rag-snap/pkg/processing"
+	"github.com/jpnorenam/rag-snap/pkg/progress"
+	"gopkg.in/yaml.v3"
+)
+
+// BatchJob describes a single document ingestion task within a batch config.
+// The JSON form is how the ingest queue stores it.
+type BatchJob struct {
+	Name       string   `yaml:"name,omitempty" json:"name,omitempty"`
+	Type       string   `yaml:"type" json:"type"`
+	Source     string   `yaml:"source" json:"source"`
+	TargetKB   string   `yaml:"target_kb,omitempty" json:"target_kb,omitempty"`
+	Branch     string   `yaml:"branch,omitempty" json:"branch,omitempty"`
+	Extensions []string `yaml:"extensions,omitempty" json:"extensions,omitempty"`
+	Path       string   `yaml:"path,omitempty" json:"path,omitempty"`
+	Label      string   `yaml:"label,omitempty" json:"label,omitempty"`
+	// Metadata holds user-defined key/value tags applied to every source the
+	// job ingests, the batch counterpart of ingest --metadata.
+	Metadata map[string]string `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+}
+
+// BatchConfig is the top-level structure of a batch YAML file.
+type BatchConfig struct {
+	Version string     `yaml:"version"`
+	Jobs    []BatchJob `yaml:"jobs"`
+}
+
+// BatchOptions controls how ProcessBatch treats the jobs in a batch file.
+type BatchOptions struct {
+	// Force re-ingests sources that are already completed instead of skipping them.
+	Force bool
+	// CreateMissing creates target knowledge bases that do not exist yet,
+	// rather than failing validation.
+	CreateMissing bool
+	// DryRun validates every job and reports the outcome without ingesting
+	// anything or creating knowledge bases.
+	DryRun bool
+}
+
+// ProcessBatch reads a YAML batch file and ingests each job into OpenSearch.
+// Every job is validated first (see validateBatch), so a typo in the last job
+// fails the batch before any source is ingested. When opts.Force is false,
+// sources that are already ingested (status=completed) are skipped.
+func ProcessBatch(ctx context.Context, client *OpenSearchClient, tika *processing.TikaClient, yamlPath string, opts BatchOptions) error {
+	batchCfg, err := ReadBatchFile(yamlPath)
+	if err != nil {
+		return err
+	}
+
+	progress.Infof("Found %d jobs in batch file version %s\n", len(batchCfg.Jobs), batchCfg.Version)
+
+	missing, err := validateBatch(ctx, client, batchCfg.Jobs, opts.CreateMissing)
+	if err != nil {
+		return err
+	}
+	if opts.DryRun {
+		for _, kb := range missing {
+			progress.Printf("Would create knowledge base '%s'\n", kb)
+		}
+		progress.Printf("Batch file is valid: %d jobs ready to ingest\n", len(batchCfg.Jobs))
+		return nil
+	}
+	for _, kb := range missing {
+		if err := client.CreateIndex(ctx, FullIndexName(kb)); err != nil {
+			return fmt.Errorf("creating knowledge base '%s': %w", kb, err)
+		}
+		progress.Infof("Created knowledge base '%s'\n", kb)
+	}
+
+	for i, job := range batchCfg.Jobs {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("batch interrupted after %d/%d jobs: %w", i, len(batchCfg.Jobs), err)
+		}
+		progress.Infof("[%d/%d] Processing: %s\n", i+1, len(batchCfg.Jobs), job.Source)
+
+		if err := processSingleJob(ctx, client, tika, job, opts.Force); err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("batch interrupted during %s: %w", job.Source, ctx.Err())
+			}
+			progress.Failed("Error processing %s: %v\n", job.Source, err)
+			continue
+		}
+		progress.Succeeded("Success: %s\n", job.Source)
+	}
+
+	return nil
+}
+
+// ReadBatchFile reads and parses a YAML batch file, which must hold at least
+// one job.
+func ReadBatchFile(yamlPath string) (BatchConfig, error) {
+	data, err := os.ReadFile(yamlPath)
+	if err != nil {
+		return BatchConfig{}, fmt.Errorf("reading batch file: %w", err)
+	}
+
+	var batchCfg BatchConfig
+	if err := yaml.Unmarshal(data, &batchCfg); err != nil {
+		return BatchConfig{}, fmt.Errorf("parsing batch yaml: %w", err)
+	}
+	if len(batchCfg.Jobs) == 0 {
+		return BatchConfig{}, fmt.Errorf("batch file contains no jobs")
+	}
+	return batchCfg, nil
+}
+
+// validateBatch checks every job before any is processed: its label and tags,
+// its type and source (the file exists, the URL answers, the repository
+// reference parses), and that its target knowledge base exists. Every problem
+// is printed and the batch fails if there are any. Missing knowledge bases are
+// not a problem when createMissing is set; their names are returned for the
+// caller to create.
+func validateBatch(ctx context.Context, client *OpenSearchClient, jobs []BatchJob, createMissing bool) ([]string, error) {
+	var (
+		problems int
+		missing  []string
+	)
+	checkedKBs := map[string]bool{}
+	report := func(i int, job BatchJob, err error) {
+		problems++
+		progress.Failed("job %d (%s): %v\n", i+1, job.Source, err)
+	}
+
+	for i, job := range jobs {
+		if job.Label != "" {
+			if err := ValidateLabel(job.Label); err != nil {
+				report(i, job, err)
+			}
+		}
+		if err := ValidateTags(job.Metadata); err != nil {
+			report(i, job, err)
+		}
+		if err := validateJobSource(ctx, job); err != nil {
+			report(i, job, err)
+		}
+
+		kb := job.TargetKB
+		if kb == "" {
+			kb, _ = KnowledgeBaseNameFromIndex(DefaultIndexName())
+		}
+		if _, checked := checkedKBs[kb]; checked {
+			if !checkedKBs[kb] && !createMissing {
+				report(i, job, fmt.Errorf("knowledge base '%s' does not exist", kb))
+			}
+			continue
+		}
+		exists, err := client.IndexExists(ctx, FullIndexName(kb))
+		if err != nil {
+			return nil, err
+		}
+		checkedKBs[kb] = exists
+		switch {
+		case exists:
+		case createMissing:
+			missing = append(missing, kb)
+		default:
+			report(i, job, fmt.Errorf("knowledge base '%s' does not exist (create it with `knowledge create %s`, or pass --create-missing)", kb, kb))
+		}
+	}
+
+	if problems > 0 {
+		return nil, fmt.Errorf("batch validation failed with %d problem(s); no jobs were run", problems)
+	}
+	return missing, nil
+}
+
+// validateJobSource checks that a job's source can be fetched, without
+// fetching it.
+func validateJobSource(ctx context.Context, job BatchJob) error {
+	switch job.Type {
+	case "file":
+		path, err := filepath.Abs(job.Source)
+		if err != nil {
+			return fmt.Errorf("resolving path: %w", err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return fmt.Errorf("file not found: %s", path)
+			}
+			return err
+		}
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory, not a file", path)
+		}
+		return nil
+	case "url":
+		return processing.CheckURL(ctx, job.Source)
+	case "github-repo":
+		_, _, err := processing.ParseGitHubSource(job.Source)
+		return err
+	case "gitea-repo":
+		_, _, _, err := processing.ParseGiteaSource(job.Source)
+		return err
+	default:
+		return fmt.Errorf("unsupported job type %q (supported: file, url, github-repo, gitea-repo)", job.Type)
+	}
+}
+
+// processSingleJob ingests one job from a batch config into OpenSearch.
+func processSingleJob(ctx context.Context, client *OpenSearchClient, tika *processing.TikaClient, job BatchJob, force bool) error {
+	targetIndex := FullIndexName(job.TargetKB)
+	if job.TargetKB == "" {
+		targetIndex = DefaultIndexName()
+	}
+
+	switch job.Type {
+	case "file":
+		path, err := filepath.Abs(job.Source)
+		if err != nil {
+			return fmt.Errorf("resolving path: %w", err)
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return fmt.Errorf("file not found: %s", path)
+		}
+		sourceID := job.Name
+		if sourceID == "" {
+			sourceID = filepath.Base(path)
+		}
+		return ingestAndIndex(ctx, client, tika, IngestOptions{
+			FilePath:    path,
+			SourceID:    sourceID,
+			TargetIndex: targetIndex,
+			Label:       job.Label,
+			Tags:        job.Metadata,
+			Force:       force,
+		})
+
+	case "url":
+		crawled, webMeta, cleanup, err := processing.CrawlURL(client.settings.Temp, job.Source)
+		if err != nil {
+			return fmt.Errorf("crawling URL: %w", err)
+		}
+		defer cleanup()
+		sourceID := job.Name
+		if sourceID == "" {
+			sourceID = job.Source
+		}
+		return ingestAndIndex(ctx, client, tika, IngestOptions{
+			FilePath:     crawled,
+			SourceID:     sourceID,
+			MetadataPath: job.Source,
+			TargetIndex:  targetIndex,
+			Label:        job.Label,
+			Tags:         job.Metadata,
+			Force:        force,
+			Title:        webMeta.Title,
+			Author:       webMeta.Author,
+			ETag:         webMeta.ETag,
+			LastModified: webMeta.LastModified,
+		})
+
+	case "github-repo":
+		return processGitHubRepoJob(ctx, client, tika, job, targetIndex, force)
+
+	case "gitea-repo":
+		return processGiteaRepoJob(ctx, client, tika, job, targetIndex, force)
+
+	default:
+		return fmt.Errorf("unsupported job type %q (supported: file, url, github-repo, gitea-repo)", job.Type)
+	}
+}
+
+// processGitHubRepoJob fetches all matching files from a GitHub repository and indexes them.
+func processGitHubRepoJob(ctx context.Context, client *OpenSearchClient, tika *processing.TikaClient, job BatchJob, targetIndex string, force bool) error {
+	owner, repo, err := processing.ParseGitHubSource(job.Source)
+	if err != nil {
+		return fmt.Errorf("parsing GitHub source: %w", err)
+	}
+
+	token := os.Getenv("GITHUB_TOKEN")
+	entries, err := processing.ListGitHubRepoFiles(owner, repo, job.Branch, job.Path, job.Extensions, token)
+	if err != nil {
+		return fmt.Errorf("listing repository files: %w", err)
+	}
+
+	progress.Infof("Found %d files in %s/%s\n", len(entries), owner, repo)
+	return ingestRepoFiles(ctx, client, tika, job, targetIndex, force, entries, token)
+}
+
+// processGiteaRepoJob fetches all matching files from a Gitea repository and indexes them.
+func processGiteaRepoJob(ctx context.Context, client *OpenSearchClient, tika *processing.TikaClient, job BatchJob, targetIndex string, force bool) error {
+	baseURL, owner, repo, err := processing.ParseGiteaSource(job.Source)
+	if err != nil {
+		return fmt.Errorf("parsing Gitea source: %w", err)
+	}
+
+	token := os.Getenv("GITEA_TOKEN")
+	entries, err := processing.ListGiteaRepoFiles(baseURL, owner, repo, job.Branch, job.Path, job.Extensions, token)
+	if err != nil {
+		return fmt.Errorf("listing repository files: %w", err)
+	}
+
+	progress.Infof("Found %d files in %s/%s\n", len(entries), owner, repo)
+	return ingestRepoFiles(ctx, client, tika, job, targetIndex, force, entries, token)
+}
+
+// repoFile is a repository file ingestRepoFiles fetched, and extracted when
+// it extracts ahead of indexing.
+type repoFile struct {
+	path      string
+	cleanup   func()
+	extracted *processing.IngestResult
+	err       error
+}
+
+// ingestRepoFiles fetches and ingests the files of a repository job in order.
+// Fetching and extraction run ahead of indexing, up to tika.concurrency files
+// at a time, so Tika works on the next files while the current one is
+// embedded and indexed. Files are extracted inline instead when an ingest
+// pre-hook may convert them first, or when tika.concurrency is 1. Files
+// already ingested are neither fetched nor extracted unless force is set.
+func ingestRepoFiles(ctx context.Context, client *OpenSearchClient, tika *processing.TikaClient, job BatchJob, targetIndex string, force bool, entries []processing.RepoEntry, token string) error {
+	ahead := tika.Concurrency()
+	var chunking *processing.ChunkOptions
+	if ahead > 1 && client.settings.preHook == "" {
+		if settings, err := client.GetBaseSettings(ctx, targetIndex); err == nil {
+			chunking = &settings.Chunking
+		}
+	}
+	if chunking == nil {
+		ahead = 1
+	}
+
+	files := make([]chan repoFile, len(entries))
+	started, done := 0, 0
+	startUpTo := func(limit int) {
+		for ; started < min(limit, len(entries)); started++ {
+			entry, ch := entries[started], make(chan repoFile, 1)
+			files[started] = ch
+			go func() {
+				var f repoFile
+				if !force && client.SourceCompleted(ctx, entry.Path) {
+					ch <- f
+					return
+				}
+				f.path, f.cleanup, f.err = processing.FetchRepoFile(client.settings.Temp, entry.RawURL, entry.Path, token)
+				if f.err == nil && chunking != nil {
+					f.extracted, f.err = processing.IngestChunked(ctx, tika, f.path, entry.Path, "", *chunking)
+				}
+				ch <- f
+			}()
+		}
+	}
+	// Remove the files fetched ahead of an interrupted job.
+	defer func() {
+		for ; done < started; done++ {
+			if f := <-files[done]; f.cleanup != nil {
+				f.cleanup()
+			}
+		}
+	}()
+
+	for i, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		startUpTo(i + ahead)
+		f := <-files[i]
+		done++
+		// The next files are extracted meanwhile, their spinners below.
+		progress.Printf("  [%d/%d] %s\n", i+1, len(entries), entry.Path)
+		if f.err != nil {
+			progress.Printf("  skip %s: %v\n", entry.Path, f.err)
+			if f.cleanup != nil {
+				f.cleanup()
+			}
+			continue
+		}
+		ingestErr := ingestAndIndex(ctx, client, tika, IngestOptions{
+			FilePath:     f.path,
+			SourceID:     entry.Path,
+			MetadataPath: entry.Path,
+			TargetIndex:  targetIndex,
+			Label:        job.Label,
+			Tags:         job.Metadata,
+			Force:        force,
+			Extracted:    f.extracted,
+		})
+		if ingestErr != nil {
+			progress.Printf("  skip %s: %v\n", entry.Path, ingestErr)
+		}
+		if f.cleanup != nil {
+			f.cleanup()
+		}
+	}
+	return nil
+}
+
+// ingestAndIndex ingests one batch source through the shared Ingestor. When
+// opts.Force is false, sources already marked as completed are skipped (batch
+// policy); when it is set, the Ingestor replaces the existing source's chunks.
+func ingestAndIndex(ctx context.Context, client *OpenSearchClient, tika *processing.TikaClient, opts IngestOptions) error {
+	if !opts.Force && client.SourceCompleted(ctx, opts.SourceID) {
+		progress.Printf("  already ingested, skipping: %s\n", opts.SourceID)
+		return nil
+	}
+	opts.Trigger = TriggerBatch
+	ingestor := NewIngestor(client, tika)
+	ingestor.Hooks.Indexed = func(_ string, result *BulkResult) {
+		line := fmt.Sprintf("  indexed %d/%d chunks", result.Indexed, result.Total)
+		if result.Reused > 0 {
+			line += fmt.Sprintf(", reused %d embeddings", result.Reused)
+		}
+		if result.Duplicates > 0 {
+			line += fmt.Sprintf(", skipped %d duplicates", result.Duplicates)
+		}
+		progress.Printf("%s\n", line)
+	}
+	_, err := ingestor.Ingest(ctx, opts)
+	return err
+}
@@ -0,0 +1,71 @@
+package knowledge
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Fields the lexical leg of a search matches, and their default boosts: a
+// match in a source's title or a chunk's heading trail says more about what
+// the chunk covers than the same words in its body.
+const (
+	fieldContent = "content"
+	fieldTitle   = "title"
+	fieldHeading = "heading"
+
+	defaultTitleBoost   = 2.0
+	defaultHeadingBoost = 1.5
+
+	// boostTieBreaker is how much the fields other than the best matching one
+	// add to a chunk's lexical score.
+	boostTieBreaker = 0.3
+)
+
+func defaultSearchBoosts() map[string]float64 {
+	return map[string]float64{fieldContent: 1, fieldTitle: defaultTitleBoost, fieldHeading: defaultHeadingBoost}
+}
+
+// parseSearchBoosts reads the field boosts of the lexical search from the
+// knowledge.search.boosts config value: comma-separated field=weight pairs
+// over content, title, and heading, e.g. "title=3,heading=2". Fields left out
+// keep their default weights (content 1, title 2, heading 1.5); a weight of 0
+// stops the field being searched. An empty value keeps the defaults.
+func parseSearchBoosts(value string) (map[string]float64, error) {
+	boosts := defaultSearchBoosts()
+	if value = strings.TrimSpace(value); value != "" {
+		for _, pair := range strings.Split(value, ",") {
+			field, weight, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			field = strings.TrimSpace(field)
+			if _, known := boosts[field]; !ok || !known {
+				return nil, fmt.Errorf("invalid knowledge.search.boosts %q: expected field=weight pairs over content, title, and heading", value)
+			}
+			w, err := strconv.ParseFloat(strings.TrimSpace(weight), 64)
+			if err != nil || w < 0 {
+				return nil, fmt.Errorf("invalid knowledge.search.boosts weight %q for %s: expected a non-negative number", weight, field)
+			}
+			boosts[field] = w
+		}
+	}
+	for field, w := range boosts {
+		if w == 0 {
+			delete(boosts, field)
+		}
+	}
+	if len(boosts) == 0 {
+		return nil, fmt.Errorf("invalid knowledge.search.boosts %q: at least one field must keep a weight", value)
+	}
+	return boosts, nil
+}
+
+// boostedFields returns the fields the lexical search matches, as
+// field^weight, content first.
+func boostedFields(boosts map[string]float64) []string {
+	fields := make([]string, 0, len(boosts))
+	for _, field := range []string{fieldContent, fieldTitle, fieldHeading} {
+		if w, ok := boosts[field]; ok {
+			fields = append(fields, field+"^"+strconv.FormatFloat(w, 'g', -1, 64))
+		}
+	}
+	return fields
+}
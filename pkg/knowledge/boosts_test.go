@@ -0,0 +1,32 @@
+package knowledge
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSearchBoosts(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"", []string{"content^1", "title^2", "heading^1.5"}},
+		{"title=3, heading=2", []string{"content^1", "title^3", "heading^2"}},
+		{"heading=0", []string{"content^1", "title^2"}},
+	}
+	for _, tt := range tests {
+		boosts, err := parseSearchBoosts(tt.value)
+		if err != nil {
+			t.Fatalf("parseSearchBoosts(%q): %v", tt.value, err)
+		}
+		if got := boostedFields(boosts); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseSearchBoosts(%q) fields = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"body=2", "title", "title=-1", "title=x", "content=0,title=0,heading=0"} {
+		if _, err := parseSearchBoosts(bad); err == nil {
+			t.Errorf("parseSearchBoosts(%q) succeeded", bad)
+		}
+	}
+}
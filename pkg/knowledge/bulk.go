@@ -0,0 +1,337 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jpnorenam/rag-snap/pkg/processing"
+	"github.com/jpnorenam/rag-snap/pkg/progress"
+	"github.com/jpnorenam/rag-snap/pkg/utils"
+)
+
+// Document represents a single document to be indexed into OpenSearch.
+// Fields match the KNN index mapping (embedding is generated by the ingest pipeline).
+type Document struct {
+	Content   string            `json:"content"`
+	SourceID  string            `json:"source_id"`
+	Label     string            `json:"label,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	CreatedAt string            `json:"created_at"`
+	// Chunk provenance; see processing.Chunk.
+	Ordinal     int    `json:"ordinal"`
+	StartOffset int    `json:"start_offset"`
+	EndOffset   int    `json:"end_offset"`
+	ContentHash string `json:"content_hash,omitempty"`
+	Page        int    `json:"page,omitempty"`
+	// Title is the source's title and Heading the chunk's heading trail, for
+	// the lexical search to boost matches in them (knowledge.search.boosts).
+	Title   string `json:"title,omitempty"`
+	Heading string `json:"heading,omitempty"`
+	// Language and Symbol are a source code chunk's language and the symbol
+	// it declares.
+	Language string `json:"language,omitempty"`
+	Symbol   string `json:"symbol,omitempty"`
+	// FilePath is the source's path, set in bases whose preset indexes it.
+	FilePath string `json:"file_path,omitempty"`
+	// Embedding, when set by ReuseEmbeddings, is written as is instead of
+	// being computed by the ingest pipeline.
+	Embedding []float32 `json:"embedding,omitempty"`
+	// EmbeddingModelID is the model that computed Embedding. rag-snap's
+	// ingest pipeline sets it on the chunks it embeds; ReuseEmbeddings sets
+	// it on those it fills.
+	EmbeddingModelID string `json:"embedding_model_id,omitempty"`
+	// ID, when set, is the document's _id; otherwise OpenSearch assigns one.
+	ID string `json:"-"`
+}
+
+// DocumentFromChunk builds the document indexed for chunk, carrying its
+// provenance along with the source's label and tags.
+func DocumentFromChunk(chunk processing.Chunk, label string, tags map[string]string) Document {
+	return Document{
+		Content:     chunk.Content,
+		SourceID:    chunk.SourceID,
+		Label:       label,
+		Tags:        tags,
+		CreatedAt:   chunk.CreatedAt,
+		Ordinal:     chunk.Ordinal,
+		StartOffset: chunk.StartOffset,
+		EndOffset:   chunk.EndOffset,
+		ContentHash: chunk.ContentHash,
+		Page:        chunk.Page,
+		Heading:     chunk.Heading,
+		Language:    chunk.Language,
+		Symbol:      chunk.Symbol,
+	}
+}
+
+// EnsureProvenanceMapping adds the chunk provenance fields to an existing
+// index's mapping, so content hashes are exact-match keywords rather than
+// dynamically mapped text, titles and headings are searchable text, and code
+// languages, symbols, and embedding model IDs are exact-match keywords. Indexes created before the
+// template gained the fields need this before provenance is written.
+func (c *OpenSearchClient) EnsureProvenanceMapping(ctx context.Context, indexName string) error {
+	body := map[string]any{
+		"properties": map[string]any{
+			"ordinal":      map[string]any{"type": "integer"},
+			"start_offset": map[string]any{"type": "integer"},
+			"end_offset":   map[string]any{"type": "integer"},
+			"content_hash": map[string]any{"type": "keyword"},
+			"page":         map[string]any{"type": "integer"},
+			"title":        map[string]any{"type": "text"},
+			"heading":      map[string]any{"type": "text"},
+			"language":     map[string]any{"type": "keyword"},
+			"symbol":       map[string]any{"type": "keyword"},
+
+			"embedding_model_id": map[string]any{"type": "keyword"},
+		},
+	}
+	return c.putMapping(ctx, indexName, body)
+}
+
+// Refresh policies for bulk requests, as accepted by OpenSearch's refresh
+// parameter.
+const (
+	// RefreshFalse returns without waiting; chunks become searchable on the
+	// index's next periodic refresh (about a second by default).
+	RefreshFalse = "false"
+	// RefreshWaitFor returns once the indexed chunks are searchable.
+	RefreshWaitFor = "wait_for"
+	// RefreshTrue forces an immediate refresh of the affected shards.
+	RefreshTrue = "true"
+)
+
+// Bulk request defaults. Each request is kept well below OpenSearch's
+// http.max_content_length and small enough that the embedding pipeline does
+// not hold a large batch in memory at once.
+const (
+	DefaultBulkMaxBytes = 5 * 1024 * 1024
+	DefaultBulkMaxDocs  = 200
+)
+
+// bulkSettings shapes how BulkIndex splits and finishes a batch of documents.
+type bulkSettings struct {
+	maxBytes int
+	maxDocs  int
+	refresh  string
+}
+
+// parseBulkSettings reads the knowledge.bulk.bytes (payload cap per request,
+// with optional M or G suffix), knowledge.bulk.docs (documents per request),
+// and knowledge.bulk.refresh (false, wait_for, or true) config values. Empty
+// values keep the defaults.
+func parseBulkSettings(maxBytes, maxDocs, refresh string) (bulkSettings, error) {
+	b := bulkSettings{maxBytes: DefaultBulkMaxBytes, maxDocs: DefaultBulkMaxDocs, refresh: RefreshFalse}
+	if maxBytes = strings.TrimSpace(maxBytes); maxBytes != "" {
+		n, err := utils.StringToBytes(maxBytes)
+		if err != nil || n == 0 {
+			return bulkSettings{}, fmt.Errorf("invalid knowledge.bulk.bytes %q: expected a byte count with optional M or G suffix", maxBytes)
+		}
+		b.maxBytes = int(n)
+	}
+	if maxDocs = strings.TrimSpace(maxDocs); maxDocs != "" {
+		n, err := strconv.Atoi(maxDocs)
+		if err != nil || n < 1 {
+			return bulkSettings{}, fmt.Errorf("invalid knowledge.bulk.docs %q: expected a positive number of documents", maxDocs)
+		}
+		b.maxDocs = n
+	}
+	if refresh = strings.TrimSpace(refresh); refresh != "" {
+		if err := validateRefresh(refresh); err != nil {
+			return bulkSettings{}, fmt.Errorf("invalid knowledge.bulk.refresh: %w", err)
+		}
+		b.refresh = refresh
+	}
+	return b, nil
+}
+
+// SetBulkRefresh overrides the configured refresh policy for the rest of the
+// client's use, e.g. for an ingest that must be searchable as soon as it
+// returns.
+func (c *OpenSearchClient) SetBulkRefresh(refresh string) error {
+	if err := validateRefresh(refresh); err != nil {
+		return err
+	}
+	c.settings.bulk = c.settings.bulkSettings()
+	c.settings.bulk.refresh = refresh
+	return nil
+}
+
+func validateRefresh(refresh string) error {
+	switch refresh {
+	case RefreshFalse, RefreshWaitFor, RefreshTrue:
+		return nil
+	}
+	return fmt.Errorf("refresh policy %q: expected %s, %s, or %s", refresh, RefreshFalse, RefreshWaitFor, RefreshTrue)
+}
+
+// BulkResult contains statistics about a completed bulk indexing operation.
+type BulkResult struct {
+	Total int
+	// Reused counts documents written with an embedding from ReuseEmbeddings
+	// rather than one computed by the pipeline.
+	Reused     int
+	Indexed    int
+	Errors     int
+	FirstError string // reason from the first failed item, empty on full success
+	// Duplicates counts chunks Ingestor.Ingest left out, not in Total, because
+	// another source already put them in the base (knowledge.ingest.dedup).
+	Duplicates int
+}
+
+// BulkIndex indexes documents into the specified OpenSearch index
+// using the bulk API with the index's ingest pipeline for embedding generation;
+// documents that already carry an embedding skip the pipeline.
+// Documents are sent in requests bounded by knowledge.bulk.bytes and
+// knowledge.bulk.docs; the refresh policy applies to the last request, since a
+// refresh makes every earlier write searchable too. Under the resource guard,
+// requests are capped at 1M and held back while memory use is high.
+func (c *OpenSearchClient) BulkIndex(ctx context.Context, indexName string, documents []Document) (*BulkResult, error) {
+	stopProgress := progress.Start(fmt.Sprintf("Indexing %d chunks", len(documents)))
+	defer stopProgress()
+
+	pipelines, err := c.GetIndexPipelines(ctx, indexName)
+	if err != nil {
+		return nil, err
+	}
+	pipeline := pipelines.EffectiveIngest()
+
+	settings := c.settings.bulkSettings()
+	if c.settings.guard.enabled {
+		settings.maxBytes = min(settings.maxBytes, guardBulkMaxBytes)
+	}
+	result := &BulkResult{Total: len(documents)}
+
+	var (
+		buf  bytes.Buffer
+		docs int
+	)
+	send := func(refresh string) error {
+		if docs == 0 {
+			return nil
+		}
+		if err := waitForMemory(ctx, c.settings.guardSettings()); err != nil {
+			return err
+		}
+		if err := c.bulkRequest(ctx, &buf, pipeline, refresh, result); err != nil {
+			return err
+		}
+		buf.Reset()
+		docs = 0
+		return nil
+	}
+
+	for i, doc := range documents {
+		lines, err := bulkLines(indexName, doc)
+		if err != nil {
+			return nil, err
+		}
+		if docs > 0 && (docs >= settings.maxDocs || buf.Len()+len(lines) > settings.maxBytes) {
+			if err := send(RefreshFalse); err != nil {
+				return nil, err
+			}
+		}
+		buf.Write(lines)
+		docs++
+		if doc.Embedding != nil {
+			result.Reused++
+		}
+		if i == len(documents)-1 {
+			if err := send(settings.refresh); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// bulkLines renders a document as its bulk API action and source lines.
+func bulkLines(indexName string, doc Document) ([]byte, error) {
+	meta := map[string]any{
+		"_index": indexName,
+	}
+	if doc.ID != "" {
+		meta["_id"] = doc.ID
+	}
+	if doc.Embedding != nil {
+		// Overrides the request's pipeline for this document only.
+		meta["pipeline"] = "_none"
+	}
+	action := map[string]any{
+		"index": meta,
+	}
+	actionJSON, err := json.Marshal(action)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling action: %w", err)
+	}
+	docJSON, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling document: %w", err)
+	}
+	lines := make([]byte, 0, len(actionJSON)+len(docJSON)+2)
+	lines = append(lines, actionJSON...)
+	lines = append(lines, '\n')
+	lines = append(lines, docJSON...)
+	return append(lines, '\n'), nil
+}
+
+// bulkRequest sends one bulk request through pipeline and adds its per-item
+// outcome to result.
+func (c *OpenSearchClient) bulkRequest(ctx context.Context, buf *bytes.Buffer, pipeline, refresh string, result *BulkResult) error {
+	path := fmt.Sprintf("/_bulk?pipeline=%s&refresh=%s", pipeline, refresh)
+	req, err := c.newAuthenticatedRequest(http.MethodPost, path, buf)
+	if err != nil {
+		return fmt.Errorf("creating bulk request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("bulk request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError("bulk request", resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading bulk response: %w", err)
+	}
+
+	var bulkResp struct {
+		Errors bool `json:"errors"`
+		Items  []struct {
+			Index struct {
+				Status int `json:"status"`
+				Error  struct {
+					Type   string `json:"type"`
+					Reason string `json:"reason"`
+				} `json:"error"`
+			} `json:"index"`
+		} `json:"items"`
+	}
+
+	if err := json.Unmarshal(body, &bulkResp); err != nil {
+		return fmt.Errorf("parsing bulk response: %w", err)
+	}
+
+	for _, item := range bulkResp.Items {
+		if item.Index.Status >= 200 && item.Index.Status < 300 {
+			result.Indexed++
+		} else {
+			result.Errors++
+			if result.FirstError == "" && item.Index.Error.Reason != "" {
+				result.FirstError = fmt.Sprintf("%s: %s", item.Index.Error.Type, item.Index.Error.Reason)
+			}
+		}
+	}
+
+	return nil
+}
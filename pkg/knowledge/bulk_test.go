@@ -0,0 +1,128 @@
+package knowledge
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseBulkSettings(t *testing.T) {
+	b, err := parseBulkSettings("2M", "50", RefreshWaitFor)
+	if want := (bulkSettings{maxBytes: 2 << 20, maxDocs: 50, refresh: RefreshWaitFor}); err != nil || b != want {
+		t.Errorf("parseBulkSettings = %+v, %v; want %+v", b, err, want)
+	}
+	if b, err := parseBulkSettings("", "", ""); err != nil || b.maxBytes != DefaultBulkMaxBytes || b.maxDocs != DefaultBulkMaxDocs || b.refresh != RefreshFalse {
+		t.Errorf("parseBulkSettings of unset values = %+v, %v; want the defaults", b, err)
+	}
+	for _, bad := range [][3]string{{"0", "", ""}, {"lots", "", ""}, {"", "0", ""}, {"", "x", ""}, {"", "", "sometimes"}} {
+		if _, err := parseBulkSettings(bad[0], bad[1], bad[2]); err == nil {
+			t.Errorf("parseBulkSettings(%q, %q, %q) succeeded", bad[0], bad[1], bad[2])
+		}
+	}
+}
+
+// bulkRequestLog is what a fake _bulk endpoint saw of one request.
+type bulkRequestLog struct {
+	docs     int
+	pipeline string
+	refresh  string
+}
+
+// fakeBulkServer answers the pipeline settings lookup with ingest pipeline
+// custom-ingest and the _bulk requests with an item per document, failing
+// those whose content contains "bad".
+func fakeBulkServer(t *testing.T, requests *[]bulkRequestLog) http.HandlerFunc {
+	var mu sync.Mutex
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" {
+			fmt.Fprintf(w, `{%q:{"settings":{%q:"custom-ingest"}}}`, FullIndexName("docs"), settingIngestPipeline)
+			return
+		}
+		var items []string
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			// Every other line is a document source, after its action line.
+			if !scanner.Scan() {
+				t.Error("bulk body has an action without a document")
+				break
+			}
+			var doc Document
+			if err := json.Unmarshal(scanner.Bytes(), &doc); err != nil {
+				t.Errorf("bulk document: %v", err)
+			}
+			if strings.Contains(doc.Content, "bad") {
+				items = append(items, `{"index":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"bad field"}}}`)
+			} else {
+				items = append(items, `{"index":{"status":201}}`)
+			}
+		}
+		mu.Lock()
+		*requests = append(*requests, bulkRequestLog{len(items), r.URL.Query().Get("pipeline"), r.URL.Query().Get("refresh")})
+		mu.Unlock()
+		fmt.Fprintf(w, `{"errors":true,"items":[%s]}`, strings.Join(items, ","))
+	}
+}
+
+func TestBulkIndexBatches(t *testing.T) {
+	docs := []Document{
+		{Content: "one"}, {Content: "bad two"}, {Content: "three"},
+		{Content: "four"}, {Content: "five"}, {Content: "bad six"},
+		{Content: "seven"},
+	}
+
+	tests := []struct {
+		name                     string
+		maxBytes, maxDocs, fresh string
+		want                     []bulkRequestLog
+	}{
+		{
+			name: "by document count", maxDocs: "3", fresh: RefreshWaitFor,
+			want: []bulkRequestLog{
+				{3, "custom-ingest", RefreshFalse},
+				{3, "custom-ingest", RefreshFalse},
+				{1, "custom-ingest", RefreshWaitFor},
+			},
+		},
+		{
+			// Every document is over the cap alone, so each goes on its own.
+			name: "by payload size", maxBytes: "1",
+			want: []bulkRequestLog{
+				{1, "custom-ingest", RefreshFalse}, {1, "custom-ingest", RefreshFalse},
+				{1, "custom-ingest", RefreshFalse}, {1, "custom-ingest", RefreshFalse},
+				{1, "custom-ingest", RefreshFalse}, {1, "custom-ingest", RefreshFalse},
+				{1, "custom-ingest", RefreshFalse},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []bulkRequestLog
+			c := newTestClient(t, fakeBulkServer(t, &requests))
+			bulk, err := parseBulkSettings(tt.maxBytes, tt.maxDocs, tt.fresh)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.settings.bulk = bulk
+
+			result, err := c.BulkIndex(context.Background(), FullIndexName("docs"), docs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(requests, tt.want) {
+				t.Errorf("bulk requests = %+v, want %+v", requests, tt.want)
+			}
+			if result.Total != 7 || result.Indexed != 5 || result.Errors != 2 {
+				t.Errorf("result = %+v, want 7 total, 5 indexed, 2 errors", result)
+			}
+			if result.FirstError != "mapper_parsing_exception: bad field" {
+				t.Errorf("first error = %q, want the first failed item's", result.FirstError)
+			}
+		})
+	}
+}
@@ -14,7 +14,9 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/jpnorenam/rag-snap/cmd/cli/common"
+	"github.com/jpnorenam/rag-snap/pkg/httpclient"
+	"github.com/jpnorenam/rag-snap/pkg/progress"
+	"github.com/jpnorenam/rag-snap/pkg/suggest"
 	opensearch "github.com/opensearch-project/opensearch-go/v4"
 	opensearchapi "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
 )
@@ -28,7 +30,9 @@ const (
 )
 
 type OpenSearchClient struct {
-	client           *opensearchapi.Client
+	client *opensearchapi.Client
+	// url is the node URL, or several joined by commas for a multi-node
+	// cluster (see ClusterURL).
 	url              string
 	username         string
 	password         string
@@ -36,13 +40,24 @@ type OpenSearchClient struct {
 	ingestPipeline   string
 	rerankModelID    string
 	searchPipeline   string
+	// localModels are bundled artifacts Init uploads instead of downloading.
+	localModels LocalModels
+	// settings are the knowledge.* and temp.* settings the client works
+	// with.
+	settings Settings
 }
 
-// URL returns the OpenSearch server URL.
+// URL returns the OpenSearch server URL; for a multi-node cluster, the node
+// URLs joined by commas.
 func (c *OpenSearchClient) URL() string {
 	return c.url
 }
 
+// Settings returns the settings the client was created with.
+func (c *OpenSearchClient) Settings() Settings {
+	return c.settings
+}
+
 // EmbeddingModelID returns the embedding model id resolved during Init, if any.
 func (c *OpenSearchClient) EmbeddingModelID() string {
 	return c.embeddingModelID
@@ -87,13 +102,15 @@ func (c *OpenSearchClient) CreateIndex(ctx context.Context, indexName string) er
 
 // NewClient creates and validates an OpenSearch client connection. It waits for the
 // server to become ready (see checkServer), so it suits callers that can afford to
-// block while a starting OpenSearch comes up — ingest, search, init.
-func NewClient(baseUrl string) (*OpenSearchClient, error) {
+// block while a starting OpenSearch comes up — ingest, search, init. baseUrl may
+// list several nodes separated by commas; requests then fail over between them.
+// The client indexes, searches, and stages files with settings.
+func NewClient(baseUrl string, settings Settings) (*OpenSearchClient, error) {
 	if err := handshake(baseUrl); err != nil {
 		return nil, err
 	}
 
-	client, err := newClient(baseUrl)
+	client, err := newClient(baseUrl, settings)
 	if err != nil {
 		return nil, err
 	}
@@ -109,8 +126,8 @@ func NewClient(baseUrl string) (*OpenSearchClient, error) {
 // bounded by ctx. Unlike NewClient it never waits for a starting server: a caller
 // that must answer promptly — a status probe, where "unreachable" is a valid answer
 // and a minute-long stall is not — cannot use NewClient's retry-until-ready loop.
-func NewClientNoWait(ctx context.Context, baseURL string) (*OpenSearchClient, error) {
-	client, err := newClient(baseURL)
+func NewClientNoWait(ctx context.Context, baseURL string, settings Settings) (*OpenSearchClient, error) {
+	client, err := newClient(baseURL, settings)
 	if err != nil {
 		return nil, err
 	}
@@ -125,7 +142,7 @@ func NewClientNoWait(ctx context.Context, baseURL string) (*OpenSearchClient, er
 // newClient builds the client from the environment credentials without contacting
 // the server. Reachability is the caller's decision: see NewClient (wait) and
 // NewClientNoWait (fail fast).
-func newClient(baseURL string) (*OpenSearchClient, error) {
+func newClient(baseURL string, settings Settings) (*OpenSearchClient, error) {
 	username, found := os.LookupEnv(envOpenSearchUsername)
 	if !found {
 		return nil, fmt.Errorf("%q env var is not set", envOpenSearchUsername)
@@ -145,12 +162,13 @@ func newClient(baseURL string) (*OpenSearchClient, error) {
 		username: username,
 		password: password,
 		url:      baseURL,
+		settings: settings,
 	}, nil
 }
 
 // withProgress runs fn while displaying a progress spinner with the given message.
 func withProgress(message string, fn func() error) error {
-	stop := common.StartProgressSpinner(message)
+	stop := progress.Start(message)
 	err := fn()
 	stop()
 	return err
@@ -246,15 +264,15 @@ func (c *OpenSearchClient) Init(ctx context.Context, hooks InitHooks) error {
 func newOpenSearchClient(baseUrl, username, password string) (*opensearchapi.Client, error) {
 	client, err := opensearchapi.NewClient(opensearchapi.Config{
 		Client: opensearch.Config{
-			Addresses: []string{baseUrl},
+			Addresses: nodeURLs(baseUrl),
 			Username:  username,
 			Password:  password,
 			Transport: &headerTransport{
-				transport: &http.Transport{
+				transport: httpclient.Trace(&http.Transport{
 					TLSClientConfig: &tls.Config{
 						InsecureSkipVerify: true,
 					},
-				},
+				}),
 			},
 		},
 	})
@@ -264,11 +282,32 @@ func newOpenSearchClient(baseUrl, username, password string) (*opensearchapi.Cli
 	return client, nil
 }
 
+// handshake dials each node in turn and succeeds on the first that accepts a
+// connection, so one node being down does not block a multi-node cluster.
 func handshake(baseURL string) error {
-	stopProgress := common.StartProgressSpinner("Connecting to OpenSearch")
+	stopProgress := progress.Start("Connecting to OpenSearch")
 	defer stopProgress()
 
-	parsedURL, err := url.Parse(baseURL)
+	var err error
+	for _, node := range nodeURLs(baseURL) {
+		if err = dialNode(node); err == nil {
+			return nil
+		}
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("%w: connection refused\n\n%s\n%s",
+			ErrClusterUnavailable,
+			suggest.ServerStartup(),
+			suggest.ServerLogs())
+	}
+	if err == nil {
+		return fmt.Errorf("no OpenSearch address configured")
+	}
+	return fmt.Errorf("%w: %w", ErrClusterUnavailable, err)
+}
+
+func dialNode(nodeURL string) error {
+	parsedURL, err := url.Parse(nodeURL)
 	if err != nil {
 		return fmt.Errorf("invalid base URL: %w", err)
 	}
@@ -282,20 +321,15 @@ func handshake(baseURL string) error {
 		}
 	}
 	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, port), 5*time.Second)
-	if errors.Is(err, syscall.ECONNREFUSED) {
-		return fmt.Errorf("connection refused\n\n%s\n%s",
-			common.SuggestServerStartup(),
-			common.SuggestServerLogs())
-	} else if err != nil {
+	if err != nil {
 		return err
 	}
 	conn.Close()
-
 	return nil
 }
 
 func checkServer(client *opensearchapi.Client) error {
-	stopProgress := common.StartProgressSpinner("Waiting for OpenSearch to be ready")
+	stopProgress := progress.Start("Waiting for OpenSearch to be ready")
 	defer stopProgress()
 
 	const (
@@ -307,9 +341,10 @@ func checkServer(client *opensearchapi.Client) error {
 		resp, err := client.Cluster.Health(context.Background(), nil)
 		if err != nil {
 			if time.Since(start) > waitTimeout {
-				return fmt.Errorf("opensearch not available\n\n%s\n%s",
-					common.SuggestServerStartup(),
-					common.SuggestServerLogs())
+				return fmt.Errorf("%w\n\n%s\n%s",
+					ErrClusterUnavailable,
+					suggest.ServerStartup(),
+					suggest.ServerLogs())
 			}
 			time.Sleep(retryInterval)
 			continue
@@ -320,9 +355,10 @@ func checkServer(client *opensearchapi.Client) error {
 		}
 
 		if time.Since(start) > waitTimeout {
-			return fmt.Errorf("opensearch cluster not healthy\n\n%s\n%s",
-				common.SuggestServerStartup(),
-				common.SuggestServerLogs())
+			return fmt.Errorf("%w: cluster not healthy\n\n%s\n%s",
+				ErrClusterUnavailable,
+				suggest.ServerStartup(),
+				suggest.ServerLogs())
 		}
 		time.Sleep(retryInterval)
 	}
@@ -336,11 +372,16 @@ func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 }
 
 // AuthenticatedURL returns the base URL with credentials embedded, and the given
-// index path appended. Used to pass credentials to external tools like elasticdump.
+// index path appended. Used to pass credentials to external tools like elasticdump,
+// which take a single address: for a multi-node cluster the first node is used.
 func (c *OpenSearchClient) AuthenticatedURL(indexPath string) string {
-	parsed, err := url.Parse(c.url)
+	node := c.url
+	if nodes := nodeURLs(c.url); len(nodes) > 0 {
+		node = nodes[0]
+	}
+	parsed, err := url.Parse(node)
 	if err != nil {
-		return c.url + indexPath
+		return node + indexPath
 	}
 	parsed.User = url.UserPassword(c.username, c.password)
 	parsed.Path = indexPath
@@ -407,20 +448,50 @@ func (c *OpenSearchClient) Ping(ctx context.Context) error {
 
 	resp, err := c.client.Client.Perform(req.WithContext(ctx))
 	if err != nil {
-		return fmt.Errorf("pinging OpenSearch: %w", err)
+		return fmt.Errorf("%w: pinging OpenSearch: %w", ErrClusterUnavailable, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("ping failed with status %d: %s", resp.StatusCode, string(body))
+		return statusError("ping", resp)
 	}
 	return nil
 }
 
-// newAuthenticatedRequest creates an HTTP request with basic authentication.
+// ClusterHealth returns the cluster health color OpenSearch reports: "green"
+// when all shards are allocated, "yellow" when replicas are missing, and "red"
+// when a primary shard is unassigned and some data cannot be searched.
+func (c *OpenSearchClient) ClusterHealth(ctx context.Context) (string, error) {
+	req, err := c.newAuthenticatedRequest(http.MethodGet, "/_cluster/health", nil)
+	if err != nil {
+		return "", fmt.Errorf("creating cluster health request: %w", err)
+	}
+
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("getting cluster health: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("cluster health failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var healthResp struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&healthResp); err != nil {
+		return "", fmt.Errorf("decoding cluster health response: %w", err)
+	}
+	return healthResp.Status, nil
+}
+
+// newAuthenticatedRequest creates an HTTP request with basic authentication. The
+// request carries only the path: the transport's Perform fills in the node it
+// picks from the pool, retrying on another node when one fails.
 func (c *OpenSearchClient) newAuthenticatedRequest(method, path string, body io.Reader) (*http.Request, error) {
-	req, err := http.NewRequest(method, c.url+path, body)
+	req, err := http.NewRequest(method, path, body)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,144 @@
+package knowledge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Retrieval modes a SearchVariant can run.
+const (
+	// SearchHybrid is the default: BM25 and neural, normalized and combined.
+	SearchHybrid = "hybrid"
+	// SearchNeural is the neural (embedding) arm alone.
+	SearchNeural = "neural"
+	// SearchLexical is the BM25 arm alone.
+	SearchLexical = "lexical"
+)
+
+// SearchVariant is one retrieval configuration knowledge compare runs: a
+// mode, and optionally a search pipeline or embedding model other than the
+// base's. Reranking, when the pipeline has it, applies in every mode.
+type SearchVariant struct {
+	Mode string
+	// Pipeline overrides the search pipeline attached to each index.
+	Pipeline string
+	// Model overrides the embedding model the neural arm embeds the query
+	// with; it must produce vectors of the index's dimension.
+	Model string
+}
+
+// ParseSearchVariant parses a variant spec: a mode ("hybrid", "neural",
+// "lexical"), optionally followed by comma-separated pipeline=<name> and
+// model=<id> settings, e.g. "neural,model=abc123". The mode defaults to
+// hybrid, so "pipeline=my-pipeline" alone is valid.
+func ParseSearchVariant(spec string) (SearchVariant, error) {
+	v := SearchVariant{Mode: SearchHybrid}
+	for i, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		key, value, hasValue := strings.Cut(part, "=")
+		switch {
+		case !hasValue && i == 0:
+			switch part {
+			case SearchHybrid, SearchNeural, SearchLexical:
+				v.Mode = part
+			default:
+				return v, fmt.Errorf("invalid search variant %q: unknown mode %q (expected hybrid, neural, or lexical)", spec, part)
+			}
+		case hasValue && key == "pipeline" && value != "":
+			v.Pipeline = value
+		case hasValue && key == "model" && value != "":
+			v.Model = value
+		default:
+			return v, fmt.Errorf("invalid search variant %q: expected <mode>[,pipeline=<name>][,model=<id>]", spec)
+		}
+	}
+	if v.Model != "" && v.Mode == SearchLexical {
+		return v, fmt.Errorf("invalid search variant %q: a lexical search embeds nothing, so model= does not apply", spec)
+	}
+	return v, nil
+}
+
+// String renders v as ParseSearchVariant reads it.
+func (v SearchVariant) String() string {
+	s := v.Mode
+	if v.Pipeline != "" {
+		s += ",pipeline=" + v.Pipeline
+	}
+	if v.Model != "" {
+		s += ",model=" + v.Model
+	}
+	return s
+}
+
+// SearchVariant runs query against indexes the way v says, and returns the
+// merged hits as Search does.
+func (c *OpenSearchClient) SearchVariant(ctx context.Context, indexes []string, query, embeddingModelID string, k int, v SearchVariant) ([]SearchHit, error) {
+	if v.Model != "" {
+		embeddingModelID = v.Model
+	}
+	body := buildVariantBody(query, embeddingModelID, k, v.Mode, c.settings.searchBoosts())
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling search body: %w", err)
+	}
+
+	perIndex := make([][]SearchHit, 0, len(indexes))
+	for _, index := range indexes {
+		pipeline := v.Pipeline
+		if pipeline == "" {
+			pipelines, err := c.GetIndexPipelines(ctx, index)
+			if err != nil {
+				return nil, err
+			}
+			pipeline = pipelines.EffectiveSearch()
+		}
+		hits, err := c.searchWithBody(ctx, index, pipeline, bodyBytes)
+		if err != nil {
+			return nil, fmt.Errorf("searching index %q: %w", index, err)
+		}
+		perIndex = append(perIndex, hits)
+	}
+	return mergeHits(perIndex, 0, c.settings.searchNormalization(), c.settings.weights), nil
+}
+
+// buildVariantBody is buildSearchBody for a retrieval mode: the hybrid body,
+// or one of its arms alone with the same rerank context.
+func buildVariantBody(query, embeddingModelID string, k int, mode string, boosts map[string]float64) map[string]any {
+	body := buildSearchBody(query, query, embeddingModelID, k, SearchOptions{}, boosts)
+	arms := body["query"].(map[string]any)["hybrid"].(map[string]any)["queries"].([]map[string]any)
+	switch mode {
+	case SearchLexical:
+		body["query"] = arms[0]
+	case SearchNeural:
+		body["query"] = arms[1]
+	}
+	return body
+}
+
+// RankDeltas returns, for each hit of right, its 0-based rank among left's
+// hits, or -1 when left did not return it. Hits are the same chunk when they
+// come from the same index and source with the same content.
+func RankDeltas(left, right []SearchHit) []int {
+	rank := make(map[string]int, len(left))
+	for i, hit := range left {
+		if _, seen := rank[hitKey(hit)]; !seen {
+			rank[hitKey(hit)] = i
+		}
+	}
+	deltas := make([]int, len(right))
+	for i, hit := range right {
+		if r, ok := rank[hitKey(hit)]; ok {
+			deltas[i] = r
+		} else {
+			deltas[i] = -1
+		}
+	}
+	return deltas
+}
+
+// hitKey identifies the chunk a hit is.
+func hitKey(hit SearchHit) string {
+	return hit.Index + "\x00" + hit.SourceID + "\x00" + hit.Content
+}
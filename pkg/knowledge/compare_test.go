@@ -0,0 +1,62 @@
+package knowledge
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSearchVariant(t *testing.T) {
+	tests := map[string]SearchVariant{
+		"neural":                        {Mode: SearchNeural},
+		"lexical":                       {Mode: SearchLexical},
+		"hybrid,pipeline=p1":            {Mode: SearchHybrid, Pipeline: "p1"},
+		"pipeline=p1":                   {Mode: SearchHybrid, Pipeline: "p1"},
+		"neural, model=m1, pipeline=p1": {Mode: SearchNeural, Pipeline: "p1", Model: "m1"},
+	}
+	for spec, want := range tests {
+		got, err := ParseSearchVariant(spec)
+		if err != nil || got != want {
+			t.Errorf("ParseSearchVariant(%q) = %+v, %v; want %+v", spec, got, err, want)
+		}
+		if again, _ := ParseSearchVariant(got.String()); again != got {
+			t.Errorf("ParseSearchVariant(%q.String()) = %+v, want %+v", spec, again, got)
+		}
+	}
+
+	for _, bad := range []string{"", "semantic", "neural,pipeline=", "neural,rerank=x", "lexical,model=m1", "neural,hybrid"} {
+		if _, err := ParseSearchVariant(bad); err == nil {
+			t.Errorf("ParseSearchVariant(%q) = nil error, want error", bad)
+		}
+	}
+}
+
+func TestBuildVariantBody(t *testing.T) {
+	hybrid := buildSearchBody("q", "q", "model", 5, SearchOptions{}, defaultSearchBoosts())
+	arms := hybrid["query"].(map[string]any)["hybrid"].(map[string]any)["queries"].([]map[string]any)
+
+	if got := buildVariantBody("q", "model", 5, SearchHybrid, defaultSearchBoosts()); !reflect.DeepEqual(got, hybrid) {
+		t.Errorf("hybrid variant body = %v, want %v", got, hybrid)
+	}
+	if got := buildVariantBody("q", "model", 5, SearchLexical, defaultSearchBoosts())["query"]; !reflect.DeepEqual(got, arms[0]) {
+		t.Errorf("lexical variant query = %v, want %v", got, arms[0])
+	}
+	neural := buildVariantBody("q", "model", 5, SearchNeural, defaultSearchBoosts())
+	if got := neural["query"]; !reflect.DeepEqual(got, arms[1]) {
+		t.Errorf("neural variant query = %v, want %v", got, arms[1])
+	}
+	if !reflect.DeepEqual(neural["ext"], hybrid["ext"]) {
+		t.Errorf("neural variant ext = %v, want the hybrid rerank context %v", neural["ext"], hybrid["ext"])
+	}
+}
+
+func TestRankDeltas(t *testing.T) {
+	a := SearchHit{Index: "i", SourceID: "a", Content: "x"}
+	b := SearchHit{Index: "i", SourceID: "b", Content: "x"}
+	c := SearchHit{Index: "i", SourceID: "c", Content: "x"}
+	d := SearchHit{Index: "j", SourceID: "a", Content: "x"}
+
+	got := RankDeltas([]SearchHit{a, b, c}, []SearchHit{c, a, d})
+	if want := []int{2, 0, -1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RankDeltas = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,133 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// parseDedup reads whether ingests skip chunks another source already put in
+// the target base from the knowledge.ingest.dedup config value (true or
+// false). An empty value keeps the default, off.
+func parseDedup(enabled string) (bool, error) {
+	if enabled = strings.TrimSpace(enabled); enabled == "" {
+		return false, nil
+	}
+	on, err := strconv.ParseBool(enabled)
+	if err != nil {
+		return false, fmt.Errorf("invalid knowledge.ingest.dedup %q: expected true or false", enabled)
+	}
+	return on, nil
+}
+
+// DropDuplicateChunks removes from docs each document whose content hash
+// matches a chunk another source already has in indexName, and returns the
+// remaining documents and how many it removed. Boilerplate repeated across a
+// documentation set is then indexed once, by the first source that carries
+// it. The source's own chunks never count, nor do those archived for its
+// earlier versions, so a forced re-ingest keeps them.
+//
+// Matching is by exact content, so a repeated passage that starts a chunk
+// with different overlap is not caught. A failed lookup is not an error: the
+// documents it covered are kept.
+func (c *OpenSearchClient) DropDuplicateChunks(ctx context.Context, indexName, sourceID string, docs []Document) ([]Document, int) {
+	var hashes []string
+	seen := map[string]bool{}
+	for _, doc := range docs {
+		if doc.ContentHash != "" && !seen[doc.ContentHash] {
+			seen[doc.ContentHash] = true
+			hashes = append(hashes, doc.ContentHash)
+		}
+	}
+
+	present := map[string]bool{}
+	for start := 0; start < len(hashes); start += embeddingLookupBatch {
+		batch := hashes[start:min(start+embeddingLookupBatch, len(hashes))]
+		found, err := c.lookupForeignHashes(ctx, indexName, sourceID, batch)
+		if err != nil {
+			continue
+		}
+		for _, hash := range found {
+			present[hash] = true
+		}
+	}
+	if len(present) == 0 {
+		return docs, 0
+	}
+
+	kept := make([]Document, 0, len(docs))
+	for _, doc := range docs {
+		if !present[doc.ContentHash] {
+			kept = append(kept, doc)
+		}
+	}
+	return kept, len(docs) - len(kept)
+}
+
+// lookupForeignHashes returns which of hashes a chunk of a source other than
+// sourceID carries in indexName.
+func (c *OpenSearchClient) lookupForeignHashes(ctx context.Context, indexName, sourceID string, hashes []string) ([]string, error) {
+	bodyBytes, err := json.Marshal(buildForeignHashLookupBody(sourceID, hashes))
+	if err != nil {
+		return nil, fmt.Errorf("marshaling duplicate lookup body: %w", err)
+	}
+
+	path := fmt.Sprintf("/%s/_search", indexName)
+	req, err := c.newAuthenticatedRequest(http.MethodPost, path, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("executing duplicate lookup: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("duplicate lookup failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var searchResp struct {
+		Hits struct {
+			Hits []struct {
+				Source struct {
+					ContentHash string `json:"content_hash"`
+				} `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
+		return nil, fmt.Errorf("decoding duplicate lookup response: %w", err)
+	}
+
+	found := make([]string, 0, len(searchResp.Hits.Hits))
+	for _, hit := range searchResp.Hits.Hits {
+		found = append(found, hit.Source.ContentHash)
+	}
+	return found, nil
+}
+
+// buildForeignHashLookupBody constructs a search for chunks carrying any of
+// hashes outside sourceID, collapsed to one chunk per hash.
+func buildForeignHashLookupBody(sourceID string, hashes []string) map[string]any {
+	return map[string]any{
+		"size":    len(hashes),
+		"_source": []string{"content_hash"},
+		"query": map[string]any{
+			"bool": map[string]any{
+				"filter": []any{map[string]any{"terms": map[string]any{"content_hash": hashes}}},
+				"must_not": []any{
+					map[string]any{"term": map[string]any{"source_id": sourceID}},
+					map[string]any{"prefix": map[string]any{"source_id": versionedSourcePrefix(sourceID)}},
+				},
+			},
+		},
+		"collapse": map[string]any{"field": "content_hash"},
+	}
+}
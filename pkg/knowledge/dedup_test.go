@@ -0,0 +1,40 @@
+package knowledge
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildForeignHashLookupBody(t *testing.T) {
+	body := buildForeignHashLookupBody("guide.md", []string{"aa", "bb"})
+	want := map[string]any{
+		"size":    2,
+		"_source": []string{"content_hash"},
+		"query": map[string]any{
+			"bool": map[string]any{
+				"filter": []any{map[string]any{"terms": map[string]any{"content_hash": []string{"aa", "bb"}}}},
+				"must_not": []any{
+					map[string]any{"term": map[string]any{"source_id": "guide.md"}},
+					map[string]any{"prefix": map[string]any{"source_id": "guide.md@v"}},
+				},
+			},
+		},
+		"collapse": map[string]any{"field": "content_hash"},
+	}
+	if !reflect.DeepEqual(body, want) {
+		t.Errorf("buildForeignHashLookupBody = %v, want %v", body, want)
+	}
+}
+
+func TestParseDedup(t *testing.T) {
+	for value, want := range map[string]bool{"": false, "true": true, " false ": false, "1": true} {
+		if on, err := parseDedup(value); err != nil {
+			t.Errorf("parseDedup(%q) error: %v", value, err)
+		} else if on != want {
+			t.Errorf("parseDedup(%q) = %v, want %v", value, on, want)
+		}
+	}
+	if _, err := parseDedup("sometimes"); err == nil {
+		t.Error("parseDedup(\"sometimes\") = nil error, want error")
+	}
+}
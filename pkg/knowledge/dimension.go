@@ -0,0 +1,139 @@
+package knowledge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// EmbeddingDimensions is what CheckEmbeddingDimension compares: the vector
+// size a knowledge base's embedding field is mapped for, and the size the
+// model its ingest pipeline embeds with produces. A size it could not find
+// is 0.
+type EmbeddingDimensions struct {
+	Index          string
+	IndexDimension int
+	Pipeline       string
+	ModelID        string
+	ModelDimension int
+}
+
+// mismatch returns the ErrEmbeddingDimensionMismatch error for d, or nil when
+// the sizes agree or either is unknown.
+func (d EmbeddingDimensions) mismatch() error {
+	if d.IndexDimension == 0 || d.ModelDimension == 0 || d.IndexDimension == d.ModelDimension {
+		return nil
+	}
+	kb, _ := KnowledgeBaseNameFromIndex(d.Index)
+	return fmt.Errorf("%w: knowledge base '%s' stores %d-dimension embeddings, but model %s in its ingest pipeline %s produces %d; "+
+		"indexing would fail on every chunk. Run `knowledge reindex %s` to re-embed the base with the model at %d dimensions",
+		ErrEmbeddingDimensionMismatch, kb, d.IndexDimension, d.ModelID, d.Pipeline, d.ModelDimension, kb, d.ModelDimension)
+}
+
+// CheckEmbeddingDimension compares the embedding dimension of a knowledge
+// base's index with that of the model its ingest pipeline runs, so an ingest
+// can fail with an explanation before bulk indexing fails on every chunk with
+// a mapper error. It returns an ErrEmbeddingDimensionMismatch error when they
+// differ. A check it cannot complete, e.g. for a custom pipeline without a
+// text_embedding processor, is not an error: indexing reports any problem.
+func (c *OpenSearchClient) CheckEmbeddingDimension(ctx context.Context, indexName string) (EmbeddingDimensions, error) {
+	d := EmbeddingDimensions{Index: indexName}
+	var err error
+	if d.IndexDimension, err = c.indexEmbeddingDimension(ctx, indexName); err != nil || d.IndexDimension == 0 {
+		return d, nil
+	}
+	pipelines, err := c.GetIndexPipelines(ctx, indexName)
+	if err != nil {
+		return d, nil
+	}
+	d.Pipeline = pipelines.EffectiveIngest()
+	pipeline, err := c.getIngestPipeline(ctx, d.Pipeline)
+	if err != nil || pipeline == nil {
+		return d, nil
+	}
+	if d.ModelID = pipelineEmbeddingModel(pipeline, d.Pipeline); d.ModelID == "" {
+		return d, nil
+	}
+	if d.ModelDimension, err = c.modelEmbeddingDimension(ctx, d.ModelID); err != nil {
+		return d, nil
+	}
+	return d, d.mismatch()
+}
+
+// pipelineEmbeddingModel returns the model id of the text_embedding processor
+// in the ingest pipeline name, or "" when it has none.
+func pipelineEmbeddingModel(pipeline *ingestPipelineResponse, name string) string {
+	var model string
+	for _, proc := range (*pipeline)[name].Processors {
+		if te, ok := proc["text_embedding"].(map[string]any); ok {
+			model, _ = te["model_id"].(string)
+		}
+	}
+	return model
+}
+
+// indexEmbeddingDimension reads the dimension of the embedding field from an
+// index's mapping; 0 when the field is not mapped.
+func (c *OpenSearchClient) indexEmbeddingDimension(ctx context.Context, indexName string) (int, error) {
+	path := fmt.Sprintf("/%s/_mapping/field/embedding", indexName)
+	req, err := c.newAuthenticatedRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error creating request: %w", err)
+	}
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("error getting embedding mapping: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, statusError("get embedding mapping", resp)
+	}
+	return parseEmbeddingDimension(resp.Body, indexName)
+}
+
+// parseEmbeddingDimension reads the embedding dimension of indexName out of a
+// GET /{index}/_mapping/field/embedding response.
+func parseEmbeddingDimension(r io.Reader, indexName string) (int, error) {
+	var mappingResp map[string]struct {
+		Mappings map[string]struct {
+			Mapping map[string]struct {
+				Dimension int `json:"dimension"`
+			} `json:"mapping"`
+		} `json:"mappings"`
+	}
+	if err := json.NewDecoder(r).Decode(&mappingResp); err != nil {
+		return 0, fmt.Errorf("error decoding embedding mapping: %w", err)
+	}
+	return mappingResp[indexName].Mappings["embedding"].Mapping["embedding"].Dimension, nil
+}
+
+// modelEmbeddingDimension reads the output dimension the ML plugin records in
+// a model's config; 0 when it records none.
+func (c *OpenSearchClient) modelEmbeddingDimension(ctx context.Context, modelID string) (int, error) {
+	req, err := c.newAuthenticatedRequest(http.MethodGet, "/_plugins/_ml/models/"+url.PathEscape(modelID), nil)
+	if err != nil {
+		return 0, fmt.Errorf("error creating request: %w", err)
+	}
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("error getting model: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, statusError("get model", resp)
+	}
+	var modelResp struct {
+		ModelConfig struct {
+			EmbeddingDimension int `json:"embedding_dimension"`
+		} `json:"model_config"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&modelResp); err != nil {
+		return 0, fmt.Errorf("error decoding model response: %w", err)
+	}
+	return modelResp.ModelConfig.EmbeddingDimension, nil
+}
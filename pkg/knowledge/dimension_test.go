@@ -0,0 +1,49 @@
+package knowledge
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseEmbeddingDimension(t *testing.T) {
+	body := `{"rag-snap-context-docs":{"mappings":{"embedding":{"full_name":"embedding","mapping":{"embedding":{"type":"knn_vector","dimension":768}}}}}}`
+	got, err := parseEmbeddingDimension(strings.NewReader(body), "rag-snap-context-docs")
+	if err != nil || got != 768 {
+		t.Errorf("parseEmbeddingDimension = %d, %v; want 768", got, err)
+	}
+
+	got, err = parseEmbeddingDimension(strings.NewReader(`{"rag-snap-context-docs":{"mappings":{}}}`), "rag-snap-context-docs")
+	if err != nil || got != 0 {
+		t.Errorf("parseEmbeddingDimension without the field = %d, %v; want 0", got, err)
+	}
+}
+
+func TestEmbeddingDimensionsMismatch(t *testing.T) {
+	d := EmbeddingDimensions{
+		Index:          "rag-snap-context-docs",
+		IndexDimension: 768,
+		Pipeline:       "rag-snap-ingest-pipeline",
+		ModelID:        "m1",
+		ModelDimension: 384,
+	}
+	err := d.mismatch()
+	if !errors.Is(err, ErrEmbeddingDimensionMismatch) {
+		t.Fatalf("mismatch() = %v, want ErrEmbeddingDimensionMismatch", err)
+	}
+	for _, want := range []string{"'docs'", "768", "384", "m1", "`knowledge reindex docs`"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("mismatch error %q lacks %q", err, want)
+		}
+	}
+
+	for _, ok := range []EmbeddingDimensions{
+		{IndexDimension: 768, ModelDimension: 768},
+		{IndexDimension: 768},
+		{ModelDimension: 384},
+	} {
+		if err := ok.mismatch(); err != nil {
+			t.Errorf("mismatch(%+v) = %v, want nil", ok, err)
+		}
+	}
+}
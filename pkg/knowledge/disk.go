@@ -0,0 +1,132 @@
+package knowledge
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/jpnorenam/rag-snap/pkg/utils"
+)
+
+const (
+	// indexOverheadFactor scales a chunk's text to the disk it takes once
+	// indexed: the stored _source, the inverted index, and the translog.
+	indexOverheadFactor = 3
+	// vectorOverheadFactor scales a chunk's raw embedding to its on-disk size
+	// with the k-NN graph built over it.
+	vectorOverheadFactor = 2
+	// diskReserveFraction is the share of each data node's disk an ingest must
+	// leave free. OpenSearch stops allocating shards to a node past its 90%
+	// high watermark and makes its indexes read-only past the 95% flood stage,
+	// so the check stops short of both.
+	diskReserveFraction = 0.10
+)
+
+// nodeDisk is the filesystem usage OpenSearch reports for one data node.
+type nodeDisk struct {
+	Name      string
+	Total     int64
+	Available int64
+}
+
+// EstimateIndexGrowth estimates the disk the documents take once indexed:
+// their text with the index structures over it, plus one embedding each.
+func EstimateIndexGrowth(docs []Document) int64 {
+	var n int64
+	for _, d := range docs {
+		n += int64(len(d.Content)+len(d.SourceID)) * indexOverheadFactor
+		dims := len(d.Embedding)
+		if dims == 0 {
+			dims = embeddingDimension
+		}
+		n += int64(dims) * 4 * vectorOverheadFactor
+	}
+	return n
+}
+
+// CheckDiskSpace fails when indexing docs would push an OpenSearch data node
+// past the disk watermarks, before anything is written. Every data node is
+// checked for the whole growth, since any one of them may hold the shard. When the node statistics cannot be read (a user without
+// cluster monitor permission), the check is skipped.
+func (c *OpenSearchClient) CheckDiskSpace(ctx context.Context, docs []Document) error {
+	nodes, err := c.dataNodeDisks(ctx)
+	if err != nil || len(nodes) == 0 {
+		return nil
+	}
+	return checkNodeDisks(nodes, EstimateIndexGrowth(docs))
+}
+
+// checkNodeDisks reports the first node, least space available first, that
+// cannot take growth bytes and keep its reserve.
+func checkNodeDisks(nodes []nodeDisk, growth int64) error {
+	slices.SortFunc(nodes, func(a, b nodeDisk) int { return cmp.Compare(a.Available, b.Available) })
+	for _, n := range nodes {
+		reserve := int64(float64(n.Total) * diskReserveFraction)
+		if n.Available-growth >= reserve {
+			continue
+		}
+		return fmt.Errorf("not enough disk space on OpenSearch node %s: this ingest needs about %s and %s is available, "+
+			"of which %s must stay free to keep the node below its disk watermarks; "+
+			"free space on the node, delete unused knowledge bases or sources (knowledge delete, knowledge forget), "+
+			"or ingest fewer documents at a time",
+			n.Name, utils.FmtBytes(uint64(growth)), utils.FmtBytes(uint64(max(n.Available, 0))), utils.FmtBytes(uint64(reserve)))
+	}
+	return nil
+}
+
+// dataNodeDisks returns the filesystem usage of the cluster's data nodes.
+func (c *OpenSearchClient) dataNodeDisks(ctx context.Context) ([]nodeDisk, error) {
+	req, err := c.newAuthenticatedRequest(http.MethodGet, "/_nodes/stats/fs", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error getting node stats: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("node stats request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	return parseNodeDisks(resp.Body)
+}
+
+// parseNodeDisks reads the data nodes' filesystem totals out of a
+// _nodes/stats/fs response.
+func parseNodeDisks(r io.Reader) ([]nodeDisk, error) {
+	var stats struct {
+		Nodes map[string]struct {
+			Name  string   `json:"name"`
+			Roles []string `json:"roles"`
+			FS    struct {
+				Total struct {
+					TotalInBytes     int64 `json:"total_in_bytes"`
+					AvailableInBytes int64 `json:"available_in_bytes"`
+				} `json:"total"`
+			} `json:"fs"`
+		} `json:"nodes"`
+	}
+	if err := json.NewDecoder(r).Decode(&stats); err != nil {
+		return nil, fmt.Errorf("error decoding node stats: %w", err)
+	}
+
+	var nodes []nodeDisk
+	for _, n := range stats.Nodes {
+		if !slices.Contains(n.Roles, "data") || n.FS.Total.TotalInBytes == 0 {
+			continue
+		}
+		nodes = append(nodes, nodeDisk{
+			Name:      n.Name,
+			Total:     n.FS.Total.TotalInBytes,
+			Available: n.FS.Total.AvailableInBytes,
+		})
+	}
+	return nodes, nil
+}
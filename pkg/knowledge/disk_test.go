@@ -0,0 +1,52 @@
+package knowledge
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimateIndexGrowth(t *testing.T) {
+	docs := []Document{
+		{Content: strings.Repeat("a", 1000), SourceID: "guide"},
+		{Content: strings.Repeat("b", 1000), SourceID: "guide", Embedding: make([]float32, 384)},
+	}
+	want := int64(1005*indexOverheadFactor+embeddingDimension*4*vectorOverheadFactor) +
+		int64(1005*indexOverheadFactor+384*4*vectorOverheadFactor)
+	if got := EstimateIndexGrowth(docs); got != want {
+		t.Errorf("EstimateIndexGrowth = %d, want %d", got, want)
+	}
+	if got := EstimateIndexGrowth(nil); got != 0 {
+		t.Errorf("EstimateIndexGrowth(nil) = %d, want 0", got)
+	}
+}
+
+func TestParseNodeDisks(t *testing.T) {
+	body := `{"nodes": {
+		"a": {"name": "os-data-1", "roles": ["data", "ingest"], "fs": {"total": {"total_in_bytes": 1000, "available_in_bytes": 400}}},
+		"b": {"name": "os-manager", "roles": ["cluster_manager"], "fs": {"total": {"total_in_bytes": 1000, "available_in_bytes": 900}}}
+	}}`
+	nodes, err := parseNodeDisks(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("parseNodeDisks returned error: %v", err)
+	}
+	if len(nodes) != 1 || nodes[0] != (nodeDisk{Name: "os-data-1", Total: 1000, Available: 400}) {
+		t.Errorf("parseNodeDisks = %+v, want only os-data-1", nodes)
+	}
+}
+
+func TestCheckNodeDisks(t *testing.T) {
+	nodes := func() []nodeDisk {
+		return []nodeDisk{
+			{Name: "roomy", Total: 1000, Available: 800},
+			{Name: "tight", Total: 1000, Available: 300},
+		}
+	}
+	if err := checkNodeDisks(nodes(), 200); err != nil {
+		t.Errorf("checkNodeDisks(200) = %v, want nil", err)
+	}
+	// 300 available less 250 leaves 50, under the 100-byte reserve.
+	err := checkNodeDisks(nodes(), 250)
+	if err == nil || !strings.Contains(err.Error(), "node tight") {
+		t.Errorf("checkNodeDisks(250) = %v, want an error naming node tight", err)
+	}
+}
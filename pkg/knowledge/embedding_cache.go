@@ -0,0 +1,128 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// embeddingLookupBatch is how many content hashes one embedding lookup
+// request asks for.
+const embeddingLookupBatch = 256
+
+// ReuseEmbeddings fills in the embedding of each document whose content hash
+// matches a chunk already in indexName that modelID embedded, copying that
+// chunk's vector. BulkIndex writes such documents without the ingest pipeline,
+// so re-ingesting a barely changed source only runs the embedding model on the
+// chunks that changed. modelID is the model the base's ingest pipeline runs
+// and dimension the vector size its embedding field is mapped for; vectors of
+// another model or size, or of chunks that do not record their model, are
+// never copied, and with either unknown nothing is. Run it before a source's
+// old chunks are deleted, or they cannot be reused.
+//
+// It returns how many documents it filled. A failed lookup is not an error:
+// the remaining documents are embedded by the pipeline as usual.
+func (c *OpenSearchClient) ReuseEmbeddings(ctx context.Context, indexName, modelID string, dimension int, docs []Document) int {
+	if modelID == "" || dimension <= 0 {
+		return 0
+	}
+	byHash := map[string][]int{}
+	var hashes []string
+	for i, doc := range docs {
+		if doc.ContentHash == "" || doc.Embedding != nil {
+			continue
+		}
+		if _, seen := byHash[doc.ContentHash]; !seen {
+			hashes = append(hashes, doc.ContentHash)
+		}
+		byHash[doc.ContentHash] = append(byHash[doc.ContentHash], i)
+	}
+
+	reused := 0
+	for start := 0; start < len(hashes); start += embeddingLookupBatch {
+		batch := hashes[start:min(start+embeddingLookupBatch, len(hashes))]
+		found, err := c.lookupEmbeddings(ctx, indexName, modelID, dimension, batch)
+		if err != nil {
+			return reused
+		}
+		for hash, embedding := range found {
+			for _, i := range byHash[hash] {
+				docs[i].Embedding = embedding
+				docs[i].EmbeddingModelID = modelID
+				reused++
+			}
+		}
+	}
+	return reused
+}
+
+// lookupEmbeddings returns the stored embedding for each of hashes that a
+// chunk in indexName embedded by modelID carries, one chunk per hash, leaving
+// out vectors that are not dimension long.
+func (c *OpenSearchClient) lookupEmbeddings(ctx context.Context, indexName, modelID string, dimension int, hashes []string) (map[string][]float32, error) {
+	bodyBytes, err := json.Marshal(buildEmbeddingLookupBody(modelID, hashes))
+	if err != nil {
+		return nil, fmt.Errorf("marshaling embedding lookup body: %w", err)
+	}
+
+	path := fmt.Sprintf("/%s/_search", indexName)
+	req, err := c.newAuthenticatedRequest(http.MethodPost, path, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("executing embedding lookup: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("embedding lookup failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var searchResp struct {
+		Hits struct {
+			Hits []struct {
+				Source struct {
+					ContentHash string    `json:"content_hash"`
+					Embedding   []float32 `json:"embedding"`
+				} `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
+		return nil, fmt.Errorf("decoding embedding lookup response: %w", err)
+	}
+
+	found := make(map[string][]float32, len(searchResp.Hits.Hits))
+	for _, hit := range searchResp.Hits.Hits {
+		// A vector of the wrong size would be rejected by the knn_vector
+		// mapping; leave that chunk to the pipeline instead.
+		if len(hit.Source.Embedding) == dimension {
+			found[hit.Source.ContentHash] = hit.Source.Embedding
+		}
+	}
+	return found, nil
+}
+
+// buildEmbeddingLookupBody constructs a search for chunks embedded by modelID
+// carrying any of hashes, collapsed to one chunk per hash.
+func buildEmbeddingLookupBody(modelID string, hashes []string) map[string]any {
+	return map[string]any{
+		"size":    len(hashes),
+		"_source": []string{"content_hash", "embedding"},
+		"query": map[string]any{
+			"bool": map[string]any{
+				"filter": []any{
+					map[string]any{"terms": map[string]any{"content_hash": hashes}},
+					map[string]any{"term": map[string]any{"embedding_model_id": modelID}},
+				},
+			},
+		},
+		"collapse": map[string]any{"field": "content_hash"},
+	}
+}
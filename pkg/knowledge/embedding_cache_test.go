@@ -0,0 +1,104 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBulkLinesSkipsPipelineForReusedEmbedding(t *testing.T) {
+	action := func(doc Document) map[string]any {
+		lines, err := bulkLines("rag-snap-context-docs", doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		first, _, _ := bytes.Cut(lines, []byte("\n"))
+		var a map[string]map[string]any
+		if err := json.Unmarshal(first, &a); err != nil {
+			t.Fatal(err)
+		}
+		return a["index"]
+	}
+
+	if got := action(Document{Content: "new chunk"}); got["pipeline"] != nil {
+		t.Errorf("document without embedding overrides the pipeline: %v", got)
+	}
+	if got := action(Document{Content: "old chunk", Embedding: []float32{0.1, 0.2}}); got["pipeline"] != "_none" {
+		t.Errorf("document with embedding has pipeline %v, want _none", got["pipeline"])
+	}
+}
+
+func TestBuildEmbeddingLookupBody(t *testing.T) {
+	body := buildEmbeddingLookupBody("embed-1", []string{"aa", "bb"})
+	want := map[string]any{
+		"size":    2,
+		"_source": []string{"content_hash", "embedding"},
+		"query": map[string]any{
+			"bool": map[string]any{
+				"filter": []any{
+					map[string]any{"terms": map[string]any{"content_hash": []string{"aa", "bb"}}},
+					map[string]any{"term": map[string]any{"embedding_model_id": "embed-1"}},
+				},
+			},
+		},
+		"collapse": map[string]any{"field": "content_hash"},
+	}
+	if !reflect.DeepEqual(body, want) {
+		t.Errorf("buildEmbeddingLookupBody = %v, want %v", body, want)
+	}
+}
+
+func TestReuseEmbeddingsWithoutModel(t *testing.T) {
+	// With no model to match, nothing is looked up: the nil client would
+	// panic on a request.
+	var c *OpenSearchClient
+	docs := []Document{{Content: "chunk", ContentHash: "aa"}}
+	if n := c.ReuseEmbeddings(context.Background(), "rag-snap-context-docs", "", 768, docs); n != 0 || docs[0].Embedding != nil {
+		t.Errorf("ReuseEmbeddings without a model filled %d document(s)", n)
+	}
+	if n := c.ReuseEmbeddings(context.Background(), "rag-snap-context-docs", "embed-1", 0, docs); n != 0 || docs[0].Embedding != nil {
+		t.Errorf("ReuseEmbeddings without an index dimension filled %d document(s)", n)
+	}
+}
+
+// newTestClient returns an OpenSearchClient that sends its requests to
+// handler.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *OpenSearchClient {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	t.Setenv(envOpenSearchUsername, "admin")
+	t.Setenv(envOpenSearchPassword, "admin")
+	c, err := newClient(srv.URL, Settings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestReuseEmbeddingsMatchesIndexDimension(t *testing.T) {
+	// The base is mapped for 384 dimensions: the 384-long vector is copied
+	// and the 768-long one, which the mapping would reject, is not.
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"hits":{"hits":[
+			{"_source":{"content_hash":"aa","embedding":[`+strings.Repeat("0.1,", 383)+`0.1]}},
+			{"_source":{"content_hash":"bb","embedding":[`+strings.Repeat("0.1,", 767)+`0.1]}}
+		]}}`)
+	})
+	docs := []Document{{Content: "kept", ContentHash: "aa"}, {Content: "resized", ContentHash: "bb"}}
+	if n := c.ReuseEmbeddings(context.Background(), "rag-snap-context-docs", "embed-1", 384, docs); n != 1 {
+		t.Errorf("ReuseEmbeddings filled %d document(s), want 1", n)
+	}
+	if len(docs[0].Embedding) != 384 || docs[0].EmbeddingModelID != "embed-1" {
+		t.Errorf("document aa has a %d-dimension embedding of %q, want the stored 384 of embed-1", len(docs[0].Embedding), docs[0].EmbeddingModelID)
+	}
+	if docs[1].Embedding != nil {
+		t.Errorf("document bb reused a %d-dimension embedding into a 384-dimension base", len(docs[1].Embedding))
+	}
+}
@@ -0,0 +1,65 @@
+package knowledge
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Errors OpenSearchClient methods wrap, so callers can tell failures apart
+// with errors.Is rather than by matching messages.
+var (
+	// ErrClusterUnavailable is returned when OpenSearch cannot be reached or
+	// is not ready to serve requests.
+	ErrClusterUnavailable = errors.New("opensearch unavailable")
+	// ErrIndexNotFound is returned when a knowledge base's index does not
+	// exist.
+	ErrIndexNotFound = errors.New("knowledge base not found")
+	// ErrSourceNotFound is returned when no metadata record exists for a
+	// source id.
+	ErrSourceNotFound = errors.New("source not found")
+	// ErrModelNotDeployed is returned when a request needs an ML model that is
+	// not deployed, e.g. a search embedding its query.
+	ErrModelNotDeployed = errors.New("model not deployed")
+	// ErrEmbeddingDimensionMismatch is returned when the model a knowledge
+	// base's ingest pipeline embeds with produces vectors of another size than
+	// the base's index stores.
+	ErrEmbeddingDimensionMismatch = errors.New("embedding dimension mismatch")
+)
+
+// modelNotDeployedMarkers are the ML plugin's reasons for refusing to run a
+// model that is registered but not deployed.
+var modelNotDeployedMarkers = [][]byte{
+	[]byte("Model not ready yet"),
+	[]byte("is not deployed"),
+}
+
+// statusError reads a response OpenSearch answered with an unexpected status
+// into the "<op> failed with status N: <body>" error the client returns,
+// wrapping the typed error the response stands for, if any.
+func statusError(op string, resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	err := fmt.Errorf("%s failed with status %d: %s", op, resp.StatusCode, string(body))
+	if typed := classifyResponse(resp.StatusCode, body); typed != nil {
+		return fmt.Errorf("%w: %w", typed, err)
+	}
+	return err
+}
+
+// classifyResponse maps an error response to a typed error, or nil.
+func classifyResponse(status int, body []byte) error {
+	switch {
+	case status == http.StatusNotFound && bytes.Contains(body, []byte("index_not_found_exception")):
+		return ErrIndexNotFound
+	case status == http.StatusServiceUnavailable:
+		return ErrClusterUnavailable
+	}
+	for _, marker := range modelNotDeployedMarkers {
+		if bytes.Contains(body, marker) {
+			return ErrModelNotDeployed
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package knowledge
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestStatusError(t *testing.T) {
+	cases := []struct {
+		name   string
+		status int
+		body   string
+		want   error
+	}{
+		{"missing index", http.StatusNotFound, `{"error":{"type":"index_not_found_exception"}}`, ErrIndexNotFound},
+		{"unavailable", http.StatusServiceUnavailable, `{"error":"cluster_block_exception"}`, ErrClusterUnavailable},
+		{"model not deployed", http.StatusBadRequest, `{"error":{"reason":"Model not ready yet. Please deploy the model first."}}`, ErrModelNotDeployed},
+		{"other", http.StatusBadRequest, `{"error":{"type":"parsing_exception"}}`, nil},
+	}
+	typed := []error{ErrIndexNotFound, ErrClusterUnavailable, ErrModelNotDeployed, ErrSourceNotFound}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := statusError("search request", &http.Response{
+				StatusCode: tc.status,
+				Body:       io.NopCloser(strings.NewReader(tc.body)),
+			})
+			if !strings.Contains(err.Error(), "search request failed with status") || !strings.Contains(err.Error(), tc.body) {
+				t.Errorf("error %q lacks the status line or body", err)
+			}
+			for _, e := range typed {
+				if got := errors.Is(err, e); got != (e == tc.want) {
+					t.Errorf("errors.Is(err, %v) = %v", e, got)
+				}
+			}
+		})
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"time"
+
+	"github.com/jpnorenam/rag-snap/pkg/progress"
 )
 
 // ExportManifest describes the contents of an exported knowledge base.
@@ -81,7 +83,7 @@ func ExportKnowledgeBase(ctx context.Context, client *OpenSearchClient, kbName s
 		return fmt.Errorf("checking index: %w", err)
 	}
 	if !exists {
-		return fmt.Errorf("index %q not found — run 'knowledge create %s' first", indexName, kbName)
+		return fmt.Errorf("%w: index %q — run 'knowledge create %s' first", ErrIndexNotFound, indexName, kbName)
 	}
 
 	// Gather source metadata to compute totals for the manifest.
@@ -107,7 +109,7 @@ func ExportKnowledgeBase(ctx context.Context, client *OpenSearchClient, kbName s
 
 	// Export document data.
 	dataPath := filepath.Join(outputDir, "data.json")
-	fmt.Printf("Exporting document data to %s...\n", dataPath)
+	progress.Infof("Exporting document data to %s...\n", dataPath)
 	if err := runElasticdump(ctx, bin, nodeDir, []string{
 		"--input=" + inputURL,
 		"--output=" + dataPath,
@@ -120,7 +122,7 @@ func ExportKnowledgeBase(ctx context.Context, client *OpenSearchClient, kbName s
 
 	// Export index mapping.
 	mappingPath := filepath.Join(outputDir, "mapping.json")
-	fmt.Printf("Exporting mapping to %s...\n", mappingPath)
+	progress.Infof("Exporting mapping to %s...\n", mappingPath)
 	if err := runElasticdump(ctx, bin, nodeDir, []string{
 		"--input=" + inputURL,
 		"--output=" + mappingPath,
@@ -137,7 +139,7 @@ func ExportKnowledgeBase(ctx context.Context, client *OpenSearchClient, kbName s
 
 	// Export source metadata filtered to this index.
 	sourcesPath := filepath.Join(outputDir, "sources.json")
-	fmt.Printf("Exporting source metadata to %s...\n", sourcesPath)
+	progress.Infof("Exporting source metadata to %s...\n", sourcesPath)
 	metaInputURL := client.AuthenticatedURL("/" + sourcesIndexName)
 	searchBody := fmt.Sprintf(`{"query":{"term":{"index_name":"%s"}}}`, indexName)
 	if err := runElasticdump(ctx, bin, nodeDir, []string{
@@ -171,24 +173,24 @@ func ExportKnowledgeBase(ctx context.Context, client *OpenSearchClient, kbName s
 
 	if opts.Compress {
 		tarPath := outputDir + ".tar.gz"
-		fmt.Printf("Compressing to %s...\n", tarPath)
+		progress.Infof("Compressing to %s...\n", tarPath)
 		if err := compressDir(outputDir, tarPath); err != nil {
 			return fmt.Errorf("compressing export: %w", err)
 		}
 		if err := os.RemoveAll(outputDir); err != nil {
 			return fmt.Errorf("removing temporary export directory: %w", err)
 		}
-		fmt.Printf("\nExport complete.\n")
-		fmt.Printf("  Sources:  %d\n", len(sources))
-		fmt.Printf("  Chunks:   %d\n", chunkCount)
-		fmt.Printf("  Location: %s\n", tarPath)
+		progress.Printf("\nExport complete.\n")
+		progress.Printf("  Sources:  %d\n", len(sources))
+		progress.Printf("  Chunks:   %d\n", chunkCount)
+		progress.Printf("  Location: %s\n", tarPath)
 		return nil
 	}
 
-	fmt.Printf("\nExport complete.\n")
-	fmt.Printf("  Sources:  %d\n", len(sources))
-	fmt.Printf("  Chunks:   %d\n", chunkCount)
-	fmt.Printf("  Location: %s\n", outputDir)
+	progress.Printf("\nExport complete.\n")
+	progress.Printf("  Sources:  %d\n", len(sources))
+	progress.Printf("  Chunks:   %d\n", chunkCount)
+	progress.Printf("  Location: %s\n", outputDir)
 	return nil
 }
 
@@ -11,6 +11,9 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/jpnorenam/rag-snap/pkg/httpclient"
+	"github.com/jpnorenam/rag-snap/pkg/processing"
 )
 
 const driveAPIBase = "https://www.googleapis.com/drive/v3/files"
@@ -110,7 +113,7 @@ func driveGET(ctx context.Context, client *http.Client, apiURL, accessToken stri
 // ListDriveArchives returns all .tar.gz files whose immediate parent is folderID.
 // accessToken must be a valid OAuth2 access token with drive.readonly scope.
 func ListDriveArchives(ctx context.Context, folderID, accessToken string) ([]DriveArchive, error) {
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := httpclient.New(30 * time.Second)
 
 	var all []DriveArchive
 	pageToken := ""
@@ -159,15 +162,15 @@ func ListDriveArchives(ctx context.Context, folderID, accessToken string) ([]Dri
 	return all, nil
 }
 
-// DownloadDriveArchive streams a Drive file to a temporary .tar.gz file on disk
-// using io.Copy, so the archive is never fully buffered in RAM. The caller must
-// invoke the returned cleanup function when done.
-func DownloadDriveArchive(ctx context.Context, archive DriveArchive, accessToken string) (path string, cleanup func(), err error) {
+// DownloadDriveArchive streams a Drive file to a temporary .tar.gz file staged
+// under temp using io.Copy, so the archive is never fully buffered in RAM. The
+// caller must invoke the returned cleanup function when done.
+func DownloadDriveArchive(ctx context.Context, temp processing.TempSettings, archive DriveArchive, accessToken string) (path string, cleanup func(), err error) {
 	q := url.Values{}
 	q.Set("alt", "media")
 	apiURL := fmt.Sprintf("%s/%s?%s", driveAPIBase, archive.ID, q.Encode())
 
-	httpClient := &http.Client{Timeout: 30 * time.Minute}
+	httpClient := httpclient.New(30 * time.Minute)
 	resp, err := driveGET(ctx, httpClient, apiURL, accessToken)
 	if err != nil {
 		return "", func() {}, fmt.Errorf("downloading %q: %w", archive.Name, err)
@@ -180,7 +183,7 @@ func DownloadDriveArchive(ctx context.Context, archive DriveArchive, accessToken
 			resp.StatusCode, archive.Name, strings.TrimSpace(string(body)))
 	}
 
-	return streamToTempFile(resp.Body, archive.Name)
+	return streamToTempFile(temp, resp.Body, archive.Name)
 }
 
 // GetDriveFileName fetches the filename of a single Drive file by ID.
@@ -200,7 +203,7 @@ func GetDriveFile(ctx context.Context, fileID, accessToken string) (DriveArchive
 	q.Set("fields", "id,name,size,modifiedTime")
 	apiURL := fmt.Sprintf("%s/%s?%s", driveAPIBase, fileID, q.Encode())
 
-	client := &http.Client{Timeout: 15 * time.Second}
+	client := httpclient.New(15 * time.Second)
 	resp, err := driveGET(ctx, client, apiURL, accessToken)
 	if err != nil {
 		return DriveArchive{}, fmt.Errorf("fetching file metadata: %w", err)
@@ -239,7 +242,7 @@ func GetDriveAccountEmail(ctx context.Context, accessToken string) (string, erro
 	q.Set("fields", "user(emailAddress,displayName)")
 	apiURL := "https://www.googleapis.com/drive/v3/about?" + q.Encode()
 
-	client := &http.Client{Timeout: 15 * time.Second}
+	client := httpclient.New(15 * time.Second)
 	resp, err := driveGET(ctx, client, apiURL, accessToken)
 	if err != nil {
 		return "", fmt.Errorf("fetching account info: %w", err)
@@ -259,10 +262,11 @@ func GetDriveAccountEmail(ctx context.Context, accessToken string) (string, erro
 	return about.User.EmailAddress, nil
 }
 
-// streamToTempFile copies r into a new temporary .tar.gz file and returns the
-// path and a cleanup function. Callers must invoke cleanup when done.
-func streamToTempFile(r io.Reader, name string) (path string, cleanup func(), err error) {
-	tmp, err := os.CreateTemp("", "rag-gdrive-*.tar.gz")
+// streamToTempFile copies r into a new temporary .tar.gz file under temp and
+// returns the path and a cleanup function. Callers must invoke cleanup when
+// done.
+func streamToTempFile(temp processing.TempSettings, r io.Reader, name string) (path string, cleanup func(), err error) {
+	tmp, err := temp.CreateTemp("rag-gdrive-*.tar.gz")
 	if err != nil {
 		return "", func() {}, fmt.Errorf("creating temporary file: %w", err)
 	}
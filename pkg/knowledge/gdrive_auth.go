@@ -18,6 +18,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jpnorenam/rag-snap/pkg/httpclient"
+	"github.com/jpnorenam/rag-snap/pkg/progress"
 	"github.com/jpnorenam/rag-snap/pkg/storage"
 )
 
@@ -322,42 +324,26 @@ func (f *DriveOAuthFlow) Await(ctx context.Context, timeout time.Duration) (*Dri
 }
 
 // runLoopbackFlow drives the loopback OAuth flow interactively for the CLI:
-// it prints the consent URL, opens the browser, animates a wait, then blocks
-// for the callback.
+// it prints the consent URL, opens the browser, and shows the wait for the
+// callback as a step in progress.
 func runLoopbackFlow(ctx context.Context, clientID, clientSecret string) (*DriveToken, error) {
 	flow, err := startDriveOAuthFlow(clientID, clientSecret)
 	if err != nil {
 		return nil, err
 	}
 
-	fmt.Printf("\nTo authenticate with Google Drive, open the following URL in your browser:\n\n")
-	fmt.Printf("  %s\n\n", flow.ConsentURL())
-	fmt.Printf("Attempting to open your browser automatically...\n")
+	progress.Printf("\nTo authenticate with Google Drive, open the following URL in your browser:\n\n")
+	progress.Printf("  %s\n\n", flow.ConsentURL())
+	progress.Infof("Attempting to open your browser automatically...\n")
 	openBrowser(flow.ConsentURL())
-	fmt.Printf("Waiting for authorization")
-
-	// Animate dots on a ticker until Await returns.
-	done := make(chan struct{})
-	go func() {
-		ticker := time.NewTicker(2 * time.Second)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-done:
-				return
-			case <-ticker.C:
-				fmt.Print(".")
-			}
-		}
-	}()
 
+	stopProgress := progress.Start("Waiting for authorization")
 	tok, err := flow.Await(ctx, driveFlowTimeout)
-	close(done)
+	stopProgress()
 	if err != nil {
-		fmt.Println()
 		return nil, err
 	}
-	fmt.Println(" authorized.")
+	progress.Infof("Google Drive authorized.\n")
 	return tok, nil
 }
 
@@ -378,7 +364,7 @@ func exchangeCode(ctx context.Context, code, redirectURI, verifier, clientID, cl
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := (&http.Client{Timeout: 15 * time.Second}).Do(req)
+	resp, err := httpclient.New(15 * time.Second).Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("exchanging authorization code: %w", err)
 	}
@@ -415,7 +401,7 @@ func refreshDriveToken(ctx context.Context, tok *DriveToken, clientID, clientSec
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := (&http.Client{Timeout: 15 * time.Second}).Do(req)
+	resp, err := httpclient.New(15 * time.Second).Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("refreshing token: %w", err)
 	}
@@ -0,0 +1,162 @@
+package knowledge
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jpnorenam/rag-snap/pkg/progress"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// DefaultGuardMemoryPercent is the share of system memory in use past
+	// which a guarded ingest pauses.
+	DefaultGuardMemoryPercent = 85
+	// guardBulkMaxBytes caps each bulk request of a guarded ingest, so the
+	// embedding pipeline holds a small batch at a time.
+	guardBulkMaxBytes = 1024 * 1024
+	// guardNice is the CPU niceness a guarded ingest runs at.
+	guardNice = 10
+	// guardPollInterval is how often a paused ingest rechecks memory.
+	guardPollInterval = 2 * time.Second
+	meminfoPath       = "/proc/meminfo"
+
+	// ioprio_set(2) arguments: lowest best-effort priority for one thread.
+	ioprioWhoProcess  = 1
+	ioprioClassBE     = 2
+	ioprioClassShift  = 13
+	ioprioLowestLevel = 7
+)
+
+// guardSettings is the host resource guard for ingests.
+type guardSettings struct {
+	enabled       bool
+	memoryPercent int
+}
+
+// parseResourceGuard reads the ingest resource guard from the knowledge.guard
+// (true or false; empty for false) and knowledge.guard.memory (percent of
+// system memory in use past which ingest pauses; empty for 85) config values.
+func parseResourceGuard(enabled, memoryPercent string) (guardSettings, error) {
+	g := guardSettings{memoryPercent: DefaultGuardMemoryPercent}
+	if enabled = strings.TrimSpace(enabled); enabled != "" {
+		on, err := strconv.ParseBool(enabled)
+		if err != nil {
+			return guardSettings{}, fmt.Errorf("invalid knowledge.guard %q: expected true or false", enabled)
+		}
+		g.enabled = on
+	}
+	if memoryPercent = strings.TrimSpace(memoryPercent); memoryPercent != "" {
+		n, err := strconv.Atoi(strings.TrimSuffix(memoryPercent, "%"))
+		if err != nil || n < 1 || n > 99 {
+			return guardSettings{}, fmt.Errorf("invalid knowledge.guard.memory %q: expected a percentage between 1 and 99", memoryPercent)
+		}
+		g.memoryPercent = n
+	}
+	return g, nil
+}
+
+// ResourceGuardEnabled reports whether the client's knowledge.guard is on.
+func (c *OpenSearchClient) ResourceGuardEnabled() bool {
+	return c.settings.guard.enabled
+}
+
+// LowerProcessPriority runs the rest of the process at a lower CPU priority
+// (nice 10) and the lowest best-effort IO priority, so extraction and chunking
+// yield to the inference server on a small host. Linux keeps both per thread,
+// so every thread of the process is lowered; threads started later inherit it.
+func LowerProcessPriority() error {
+	tasks, err := os.ReadDir("/proc/self/task")
+	if err != nil {
+		return fmt.Errorf("listing process threads: %w", err)
+	}
+	ioprio := ioprioClassBE<<ioprioClassShift | ioprioLowestLevel
+	for _, t := range tasks {
+		tid, err := strconv.Atoi(t.Name())
+		if err != nil {
+			continue
+		}
+		if err := unix.Setpriority(unix.PRIO_PROCESS, tid, guardNice); err != nil {
+			return fmt.Errorf("lowering CPU priority: %w", err)
+		}
+		if _, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid), uintptr(ioprio)); errno != 0 {
+			return fmt.Errorf("lowering IO priority: %w", errno)
+		}
+	}
+	return nil
+}
+
+// waitForMemory blocks while system memory use is past guard's threshold, so a
+// guarded ingest does not push the host into swapping or the OOM killer. It
+// returns at once when the guard is off or memory use cannot be read.
+func waitForMemory(ctx context.Context, guard guardSettings) error {
+	if !guard.enabled {
+		return nil
+	}
+	paused := false
+	for {
+		used, err := memoryUsedPercent()
+		if err != nil || used < guard.memoryPercent {
+			if paused {
+				progress.Infof("Memory use down to %d%%, resuming ingest\n", used)
+			}
+			return nil
+		}
+		if !paused {
+			progress.Infof("Memory use at %d%% (knowledge.guard.memory is %d%%), pausing ingest\n", used, guard.memoryPercent)
+			paused = true
+		}
+		select {
+		case <-time.After(guardPollInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// memoryUsedPercent returns the share of system memory in use.
+func memoryUsedPercent() (int, error) {
+	f, err := os.Open(meminfoPath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return parseMemoryUsedPercent(f)
+}
+
+// parseMemoryUsedPercent reads the memory in use, as a share of the total,
+// from /proc/meminfo content. Memory the kernel can reclaim (MemAvailable)
+// counts as free.
+func parseMemoryUsedPercent(r io.Reader) (int, error) {
+	var total, available int64 = -1, -1
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		n, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			total = n
+		case "MemAvailable:":
+			available = n
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	if total <= 0 || available < 0 {
+		return 0, fmt.Errorf("MemTotal or MemAvailable missing from %s", meminfoPath)
+	}
+	return int((total - available) * 100 / total), nil
+}
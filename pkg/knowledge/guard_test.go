@@ -0,0 +1,39 @@
+package knowledge
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseResourceGuard(t *testing.T) {
+	g, err := parseResourceGuard("true", "80%")
+	if err != nil {
+		t.Fatalf("parseResourceGuard returned error: %v", err)
+	}
+	if !g.enabled || g.memoryPercent != 80 {
+		t.Errorf("guard = %+v, want enabled at 80%%", g)
+	}
+	if g, err := parseResourceGuard("", ""); err != nil || g.enabled || g.memoryPercent != DefaultGuardMemoryPercent {
+		t.Errorf("parseResourceGuard(empty) = %+v, %v; want off at the default", g, err)
+	}
+	for _, in := range [][2]string{{"maybe", ""}, {"", "0"}, {"", "100"}, {"", "high"}} {
+		if _, err := parseResourceGuard(in[0], in[1]); err == nil {
+			t.Errorf("parseResourceGuard(%q, %q) = nil error, want error", in[0], in[1])
+		}
+	}
+}
+
+func TestParseMemoryUsedPercent(t *testing.T) {
+	meminfo := `MemTotal:        8000000 kB
+MemFree:          500000 kB
+MemAvailable:    2000000 kB
+Buffers:          100000 kB
+`
+	got, err := parseMemoryUsedPercent(strings.NewReader(meminfo))
+	if err != nil || got != 75 {
+		t.Errorf("parseMemoryUsedPercent = %d, %v, want 75, nil", got, err)
+	}
+	if _, err := parseMemoryUsedPercent(strings.NewReader("MemTotal: 8000000 kB\n")); err == nil {
+		t.Error("parseMemoryUsedPercent without MemAvailable = nil error, want error")
+	}
+}
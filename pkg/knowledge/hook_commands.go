@@ -0,0 +1,101 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jpnorenam/rag-snap/pkg/progress"
+)
+
+// hookCommandTimeout bounds each run of a hook command, so a hung one cannot
+// stall an ingest for good.
+const hookCommandTimeout = 10 * time.Minute
+
+// hookEnv is the environment describing the source being ingested that hook
+// commands receive.
+func hookEnv(opts IngestOptions) []string {
+	source := opts.MetadataPath
+	if source == "" {
+		source = opts.FilePath
+	}
+	kb, _ := KnowledgeBaseNameFromIndex(opts.TargetIndex)
+	return []string{
+		"RAG_INGEST_PATH=" + opts.FilePath,
+		"RAG_INGEST_SOURCE=" + source,
+		"RAG_INGEST_SOURCE_ID=" + opts.SourceID,
+		"RAG_INGEST_KNOWLEDGE_BASE=" + kb,
+	}
+}
+
+// runPreHook runs command, the knowledge.ingest.pre-hook command line, with
+// sh -c for the source in opts. It returns the
+// file to ingest in place of the source when the command printed an existing
+// file's path as the last line of its output, e.g. after converting a format
+// extraction cannot read, and "" otherwise. A failing command fails the
+// ingest.
+func runPreHook(ctx context.Context, command string, opts IngestOptions) (string, error) {
+	out, err := runHookCommand(ctx, command, hookEnv(opts))
+	if err != nil {
+		return "", fmt.Errorf("knowledge.ingest.pre-hook failed: %w", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	path := strings.TrimSpace(lines[len(lines)-1])
+	if path == "" {
+		return "", nil
+	}
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		return "", nil
+	}
+	return path, nil
+}
+
+// runPostHook runs command, the knowledge.ingest.post-hook command line, with
+// a summary of the ingest's result. The ingest is done by then, so a failing
+// command is reported as a failed item rather than failing it.
+func runPostHook(command string, opts IngestOptions, result *BulkResult, ingestErr error) {
+	env := hookEnv(opts)
+	status := StatusCompleted
+	if ingestErr != nil {
+		status = StatusFailed
+		env = append(env, "RAG_INGEST_ERROR="+ingestErr.Error())
+	}
+	env = append(env, "RAG_INGEST_STATUS="+status)
+	if result != nil {
+		env = append(env,
+			"RAG_INGEST_CHUNKS="+strconv.Itoa(result.Indexed),
+			"RAG_INGEST_FAILED_CHUNKS="+strconv.Itoa(result.Errors),
+			"RAG_INGEST_DUPLICATE_CHUNKS="+strconv.Itoa(result.Duplicates),
+		)
+	}
+	// The ingest's own context may be done, e.g. when it was interrupted.
+	if _, err := runHookCommand(context.Background(), command, env); err != nil {
+		progress.Failed("knowledge.ingest.post-hook failed for source %q: %v\n", opts.SourceID, err)
+	}
+}
+
+// runHookCommand runs command with sh -c, adding env to the environment, and
+// returns its standard output. Its standard error is included in the error
+// of a failed run.
+func runHookCommand(ctx context.Context, command string, env []string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, hookCommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(os.Environ(), env...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return out, fmt.Errorf("%w: %s", err, msg)
+		}
+		return out, err
+	}
+	return out, nil
+}
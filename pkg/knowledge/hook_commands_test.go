@@ -0,0 +1,93 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jpnorenam/rag-snap/pkg/progress"
+)
+
+func TestRunPreHook(t *testing.T) {
+	dir := t.TempDir()
+	opts := IngestOptions{
+		FilePath:     filepath.Join(dir, "report.xyz"),
+		MetadataPath: "https://example.com/report.xyz",
+		SourceID:     "report",
+		TargetIndex:  FullIndexName("docs"),
+	}
+
+	envFile := filepath.Join(dir, "env")
+	hook := `env | grep '^RAG_INGEST_' | sort > ` + envFile
+	if path, err := runPreHook(context.Background(), hook, opts); err != nil || path != "" {
+		t.Fatalf("runPreHook = %q, %v; want no path", path, err)
+	}
+	env, _ := os.ReadFile(envFile)
+	want := "RAG_INGEST_KNOWLEDGE_BASE=docs\n" +
+		"RAG_INGEST_PATH=" + opts.FilePath + "\n" +
+		"RAG_INGEST_SOURCE=https://example.com/report.xyz\n" +
+		"RAG_INGEST_SOURCE_ID=report\n"
+	if string(env) != want {
+		t.Errorf("hook saw\n%s\nwant\n%s", env, want)
+	}
+
+	// The command may write the converted file where the environment says
+	// and print its path.
+	hook = `out="${RAG_INGEST_PATH%.xyz}-$RAG_INGEST_SOURCE_ID.txt"; echo text > "$out"; echo "$out"`
+	if path, err := runPreHook(context.Background(), hook, opts); err != nil || path != filepath.Join(dir, "report-report.txt") {
+		t.Errorf("runPreHook = %q, %v; want the path the command built", path, err)
+	}
+
+	// A command printing an existing file's path hands it over.
+	converted := filepath.Join(dir, "report.pdf")
+	if err := os.WriteFile(converted, []byte("%PDF"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	hook = "echo converting; echo " + converted
+	if path, err := runPreHook(context.Background(), hook, opts); err != nil || path != converted {
+		t.Errorf("runPreHook = %q, %v; want %q", path, err, converted)
+	}
+
+	// Output that names no file is ignored.
+	hook = "echo done"
+	if path, err := runPreHook(context.Background(), hook, opts); err != nil || path != "" {
+		t.Errorf("runPreHook = %q, %v; want no path", path, err)
+	}
+
+	hook = "echo unsupported format >&2; exit 3"
+	_, err := runPreHook(context.Background(), hook, opts)
+	if err == nil || !strings.Contains(err.Error(), "unsupported format") {
+		t.Errorf("runPreHook error = %v, want the command's stderr", err)
+	}
+}
+
+func TestRunPostHook(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), "env")
+	hook := `echo "$RAG_INGEST_STATUS $RAG_INGEST_CHUNKS $RAG_INGEST_ERROR" > ` + envFile
+	opts := IngestOptions{FilePath: "guide.md", SourceID: "guide", TargetIndex: FullIndexName("docs")}
+
+	runPostHook(hook, opts, &BulkResult{Indexed: 12}, nil)
+	if env, _ := os.ReadFile(envFile); string(env) != "completed 12 \n" {
+		t.Errorf("hook saw %q after a completed ingest", env)
+	}
+
+	runPostHook(hook, opts, nil, errors.New("indexing failed"))
+	if env, _ := os.ReadFile(envFile); string(env) != "failed  indexing failed\n" {
+		t.Errorf("hook saw %q after a failed ingest", env)
+	}
+
+	// A failing command is reported as a failed item.
+	var reported []string
+	progress.SetReporter(func(ok bool, format string, args ...any) {
+		reported = append(reported, fmt.Sprintf("%t "+format, append([]any{ok}, args...)...))
+	})
+	defer progress.SetReporter(func(ok bool, format string, args ...any) {})
+	runPostHook("echo hook broke >&2; exit 1", opts, &BulkResult{Indexed: 12}, nil)
+	if len(reported) != 1 || !strings.HasPrefix(reported[0], "false ") || !strings.Contains(reported[0], "hook broke") {
+		t.Errorf("reported %q, want one failure with the command's stderr", reported)
+	}
+}
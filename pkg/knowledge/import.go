@@ -14,6 +14,9 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/jpnorenam/rag-snap/pkg/processing"
+	"github.com/jpnorenam/rag-snap/pkg/progress"
 )
 
 // ImportOptions configures a knowledge base import.
@@ -23,9 +26,9 @@ type ImportOptions struct {
 }
 
 // resolveInputDir returns the directory to import from. If input is a .tar.gz
-// file it is extracted into a temporary directory; the caller must remove it
-// via the returned cleanup function.
-func resolveInputDir(input string) (dir string, cleanup func(), err error) {
+// file it is extracted into a temporary directory under temp; the caller must
+// remove it via the returned cleanup function.
+func resolveInputDir(temp processing.TempSettings, input string) (dir string, cleanup func(), err error) {
 	info, err := os.Stat(input)
 	if err != nil {
 		return "", nil, fmt.Errorf("accessing input path: %w", err)
@@ -39,13 +42,13 @@ func resolveInputDir(input string) (dir string, cleanup func(), err error) {
 		return "", nil, fmt.Errorf("input %q is not a directory or a .tar.gz archive", input)
 	}
 
-	tmp, err := os.MkdirTemp("", "rag-import-*")
+	tmp, err := temp.MkdirTemp("rag-import-*")
 	if err != nil {
 		return "", nil, fmt.Errorf("creating temporary directory: %w", err)
 	}
 	cleanup = func() { _ = os.RemoveAll(tmp) }
 
-	fmt.Printf("Extracting %s...\n", input)
+	progress.Infof("Extracting %s...\n", input)
 	if err := extractTarGz(input, tmp); err != nil {
 		cleanup()
 		return "", nil, fmt.Errorf("extracting archive: %w", err)
@@ -240,7 +243,7 @@ func synthesizeSourcesFromIndex(ctx context.Context, client *OpenSearchClient, t
 // ImportKnowledgeBase restores a knowledge base from an export directory or
 // a .tar.gz archive produced by ExportKnowledgeBase.
 func ImportKnowledgeBase(ctx context.Context, client *OpenSearchClient, kbName string, opts ImportOptions) error {
-	inputDir, cleanup, err := resolveInputDir(opts.InputDir)
+	inputDir, cleanup, err := resolveInputDir(client.settings.Temp, opts.InputDir)
 	if err != nil {
 		return err
 	}
@@ -266,7 +269,7 @@ func ImportKnowledgeBase(ctx context.Context, client *OpenSearchClient, kbName s
 			return fmt.Errorf("kb-name not provided and manifest does not contain a knowledge base name")
 		}
 		kbName = manifest.KBName
-		fmt.Printf("Using knowledge base name from manifest: %q\n", kbName)
+		progress.Infof("Using knowledge base name from manifest: %q\n", kbName)
 	}
 
 	// Verify all required files are present.
@@ -305,7 +308,7 @@ func ImportKnowledgeBase(ctx context.Context, client *OpenSearchClient, kbName s
 
 	// Import mapping (best-effort; template already provides it).
 	mappingPath := filepath.Join(inputDir, "mapping.json")
-	fmt.Println("Importing mapping...")
+	progress.Infof("Importing mapping...\n")
 	_ = runElasticdump(ctx, bin, nodeDir, []string{
 		"--input=" + mappingPath,
 		"--output=" + outputURL,
@@ -316,7 +319,7 @@ func ImportKnowledgeBase(ctx context.Context, client *OpenSearchClient, kbName s
 	// Import data. --noRefresh speeds up bulk import and pre-computed embeddings
 	// are preserved as-is, so the ingest pipeline must not be applied.
 	dataPath := filepath.Join(inputDir, "data.json")
-	fmt.Println("Importing document data...")
+	progress.Infof("Importing document data...\n")
 	if err := runElasticdump(ctx, bin, nodeDir, []string{
 		"--input=" + dataPath,
 		"--output=" + outputURL,
@@ -330,7 +333,7 @@ func ImportKnowledgeBase(ctx context.Context, client *OpenSearchClient, kbName s
 
 	// Import sources via Go (handles index_name rewrite for rename).
 	sourcesPath := filepath.Join(inputDir, "sources.json")
-	fmt.Println("Importing source metadata...")
+	progress.Infof("Importing source metadata...\n")
 	sourcesImported, err := importSources(ctx, client, sourcesPath, targetIndex)
 	if err != nil {
 		return fmt.Errorf("importing sources: %w", err)
@@ -339,10 +342,10 @@ func ImportKnowledgeBase(ctx context.Context, client *OpenSearchClient, kbName s
 	// When sources.json is empty (e.g. older exports), synthesize metadata from
 	// the chunk index so the sources index is always populated after import.
 	if sourcesImported == 0 {
-		fmt.Println("No source metadata in archive; synthesizing from imported chunks...")
+		progress.Infof("No source metadata in archive; synthesizing from imported chunks...\n")
 		sourcesImported, err = synthesizeSourcesFromIndex(ctx, client, targetIndex)
 		if err != nil {
-			fmt.Printf("  warning: could not synthesize source metadata: %v\n", err)
+			progress.Printf("  warning: could not synthesize source metadata: %v\n", err)
 		}
 	}
 
@@ -353,8 +356,8 @@ func ImportKnowledgeBase(ctx context.Context, client *OpenSearchClient, kbName s
 		chunkCount = manifest.ChunkCount
 	}
 
-	fmt.Printf("\nImport complete.\n")
-	fmt.Printf("  Sources imported: %d\n", sourcesImported)
-	fmt.Printf("  Chunks imported:  %d\n", chunkCount)
+	progress.Printf("\nImport complete.\n")
+	progress.Printf("  Sources imported: %d\n", sourcesImported)
+	progress.Printf("  Chunks imported:  %d\n", chunkCount)
 	return nil
 }
@@ -0,0 +1,286 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	settingIngestPipeline = "index.default_pipeline"
+	settingSearchPipeline = "index.search.default_pipeline"
+
+	// PipelineDefault given to SetIndexPipelines removes the setting, so the
+	// knowledge base goes back to rag-snap's pipeline.
+	PipelineDefault = "default"
+)
+
+// IndexPipelines is the ingest and search pipeline attached to a knowledge
+// base index through its index.default_pipeline and
+// index.search.default_pipeline settings. An empty name means the setting is
+// unset and rag-snap's own pipeline is used.
+type IndexPipelines struct {
+	Index  string
+	Ingest string
+	Search string
+}
+
+// EffectiveIngest returns the ingest pipeline documents written to the index
+// run through.
+func (p IndexPipelines) EffectiveIngest() string {
+	if p.Ingest == "" {
+		return ingestPipelineName
+	}
+	return p.Ingest
+}
+
+// EffectiveSearch returns the search pipeline queries on the index run through.
+func (p IndexPipelines) EffectiveSearch() string {
+	if p.Search == "" {
+		return searchPipelineName
+	}
+	return p.Search
+}
+
+// PipelineCheck is the state of one pipeline a knowledge base runs.
+type PipelineCheck struct {
+	Name string
+	// Custom is set for a pipeline other than rag-snap's.
+	Custom bool
+	// Problem says how the pipeline drifted from what rag-snap expects; empty
+	// when it is as expected.
+	Problem string
+}
+
+// Status renders the check for a listing: "ok", "custom", or the problem.
+func (c PipelineCheck) Status() string {
+	switch {
+	case c.Problem != "":
+		return c.Problem
+	case c.Custom:
+		return "custom"
+	default:
+		return "ok"
+	}
+}
+
+// ListIndexPipelines returns the pipelines attached to every knowledge base
+// index, sorted by index name.
+func (c *OpenSearchClient) ListIndexPipelines(ctx context.Context) ([]IndexPipelines, error) {
+	settings, err := c.pipelineSettings(ctx, indexPatterns)
+	if err != nil {
+		return nil, err
+	}
+	var list []IndexPipelines
+	for _, p := range settings {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Index < list[j].Index })
+	return list, nil
+}
+
+// GetIndexPipelines returns the pipelines attached to a knowledge base index.
+// An index that does not exist yet has none attached.
+func (c *OpenSearchClient) GetIndexPipelines(ctx context.Context, indexName string) (IndexPipelines, error) {
+	settings, err := c.pipelineSettings(ctx, indexName)
+	if err != nil {
+		return IndexPipelines{}, err
+	}
+	if p, ok := settings[indexName]; ok {
+		return p, nil
+	}
+	return IndexPipelines{Index: indexName}, nil
+}
+
+// pipelineSettings reads the pipeline settings of the indexes matching target.
+func (c *OpenSearchClient) pipelineSettings(ctx context.Context, target string) (map[string]IndexPipelines, error) {
+	path := fmt.Sprintf("/%s/_settings/%s,%s?flat_settings=true", target, settingIngestPipeline, settingSearchPipeline)
+	req, err := c.newAuthenticatedRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error getting index settings: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return map[string]IndexPipelines{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("get index settings failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	return parsePipelineSettings(resp.Body)
+}
+
+// parsePipelineSettings reads the pipeline settings of each index out of a
+// flat GET /{index}/_settings response.
+func parsePipelineSettings(r io.Reader) (map[string]IndexPipelines, error) {
+	var settingsResp map[string]struct {
+		Settings map[string]string `json:"settings"`
+	}
+	if err := json.NewDecoder(r).Decode(&settingsResp); err != nil {
+		return nil, fmt.Errorf("error decoding index settings: %w", err)
+	}
+
+	settings := make(map[string]IndexPipelines, len(settingsResp))
+	for index, s := range settingsResp {
+		settings[index] = IndexPipelines{
+			Index:  index,
+			Ingest: s.Settings[settingIngestPipeline],
+			Search: s.Settings[settingSearchPipeline],
+		}
+	}
+	return settings, nil
+}
+
+// SetIndexPipelines attaches the named ingest and search pipelines to a
+// knowledge base index. An empty name leaves that pipeline unchanged and
+// PipelineDefault detaches it, going back to rag-snap's. A pipeline that does
+// not exist is refused, since every write or query would then fail.
+func (c *OpenSearchClient) SetIndexPipelines(ctx context.Context, indexName, ingest, search string) error {
+	settings := map[string]any{}
+	for _, p := range []struct {
+		setting, kind, name string
+	}{
+		{settingIngestPipeline, "_ingest", ingest},
+		{settingSearchPipeline, "_search", search},
+	} {
+		switch p.name {
+		case "":
+			continue
+		case PipelineDefault:
+			settings[p.setting] = nil
+			continue
+		}
+		exists, err := c.pipelineExists(ctx, p.kind, p.name)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("%s pipeline %q does not exist", strings.TrimPrefix(p.kind, "_"), p.name)
+		}
+		settings[p.setting] = p.name
+	}
+	if len(settings) == 0 {
+		return nil
+	}
+
+	bodyBytes, err := json.Marshal(map[string]any{"index": settings})
+	if err != nil {
+		return fmt.Errorf("error marshaling index settings: %w", err)
+	}
+
+	req, err := c.newAuthenticatedRequest(http.MethodPut, fmt.Sprintf("/%s/_settings", indexName), bytes.NewReader(bodyBytes))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("error updating index settings: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("update index settings failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// pipelineExists reports whether the named pipeline of kind ("_ingest" or
+// "_search") exists.
+func (c *OpenSearchClient) pipelineExists(ctx context.Context, kind, name string) (bool, error) {
+	req, err := c.newAuthenticatedRequest(http.MethodGet, fmt.Sprintf("/%s/pipeline/%s", kind, name), nil)
+	if err != nil {
+		return false, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return false, fmt.Errorf("error getting pipeline %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return false, fmt.Errorf("get pipeline request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+}
+
+// CheckIndexPipelines compares the pipelines a knowledge base runs with what
+// rag-snap expects: each must exist, and rag-snap's own must still use the
+// engine's embedding and rerank models. Custom pipelines are only checked for
+// existence. An empty model id skips that model comparison.
+func (c *OpenSearchClient) CheckIndexPipelines(ctx context.Context, p IndexPipelines, embeddingModelID, rerankModelID string) (ingest, search PipelineCheck, err error) {
+	ingest = PipelineCheck{Name: p.EffectiveIngest(), Custom: p.EffectiveIngest() != ingestPipelineName}
+	ingestPipeline, err := c.getIngestPipeline(ctx, ingest.Name)
+	if err != nil {
+		return ingest, search, err
+	}
+	ingest.Problem = ingestPipelineDrift(ingestPipeline, ingest, embeddingModelID)
+
+	search = PipelineCheck{Name: p.EffectiveSearch(), Custom: p.EffectiveSearch() != searchPipelineName}
+	searchPipeline, err := c.getSearchPipeline(ctx, search.Name)
+	if err != nil {
+		return ingest, search, err
+	}
+	search.Problem = searchPipelineDrift(searchPipeline, search, rerankModelID)
+	return ingest, search, nil
+}
+
+// ingestPipelineDrift describes how a fetched ingest pipeline (nil when it
+// does not exist) differs from what rag-snap expects, or returns "".
+func ingestPipelineDrift(pipeline *ingestPipelineResponse, check PipelineCheck, embeddingModelID string) string {
+	if pipeline == nil {
+		return "missing"
+	}
+	if check.Custom || embeddingModelID == "" {
+		return ""
+	}
+	return modelDrift("embedding", pipelineEmbeddingModel(pipeline, check.Name), embeddingModelID)
+}
+
+// searchPipelineDrift is ingestPipelineDrift for a search pipeline and its
+// rerank model.
+func searchPipelineDrift(pipeline *searchPipelineResponse, check PipelineCheck, rerankModelID string) string {
+	if pipeline == nil {
+		return "missing"
+	}
+	if check.Custom || rerankModelID == "" {
+		return ""
+	}
+	var model string
+	for _, proc := range (*pipeline)[check.Name].ResponseProcessors {
+		rerank, _ := proc["rerank"].(map[string]any)
+		if ml, ok := rerank["ml_opensearch"].(map[string]any); ok {
+			model, _ = ml["model_id"].(string)
+		}
+	}
+	return modelDrift("rerank", model, rerankModelID)
+}
+
+func modelDrift(role, got, want string) string {
+	switch got {
+	case want:
+		return ""
+	case "":
+		return fmt.Sprintf("no %s model", role)
+	default:
+		return fmt.Sprintf("%s model %s, expected %s", role, got, want)
+	}
+}
@@ -0,0 +1,56 @@
+package knowledge
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePipelineSettings(t *testing.T) {
+	body := `{
+		"rag-snap-context-docs": {"settings": {}},
+		"rag-snap-context-tickets": {"settings": {
+			"index.default_pipeline": "tickets-ingest",
+			"index.search.default_pipeline": "tickets-search"
+		}}
+	}`
+	settings, err := parsePipelineSettings(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	docs := settings["rag-snap-context-docs"]
+	if docs.EffectiveIngest() != ingestPipelineName || docs.EffectiveSearch() != searchPipelineName {
+		t.Errorf("docs runs %s/%s, want rag-snap's pipelines", docs.EffectiveIngest(), docs.EffectiveSearch())
+	}
+	tickets := settings["rag-snap-context-tickets"]
+	if tickets.EffectiveIngest() != "tickets-ingest" || tickets.EffectiveSearch() != "tickets-search" {
+		t.Errorf("tickets runs %s/%s, want its custom pipelines", tickets.EffectiveIngest(), tickets.EffectiveSearch())
+	}
+}
+
+func TestPipelineDrift(t *testing.T) {
+	ingest := &ingestPipelineResponse{ingestPipelineName: {Processors: buildIngestPipelineBody("embed-1")["processors"].([]map[string]any)}}
+	ours := PipelineCheck{Name: ingestPipelineName}
+
+	if got := ingestPipelineDrift(ingest, ours, "embed-1"); got != "" {
+		t.Errorf("matching ingest pipeline drift = %q, want none", got)
+	}
+	if got := ingestPipelineDrift(ingest, ours, "embed-2"); got != "embedding model embed-1, expected embed-2" {
+		t.Errorf("stale ingest pipeline drift = %q", got)
+	}
+	if got := ingestPipelineDrift(nil, ours, "embed-1"); got != "missing" {
+		t.Errorf("absent ingest pipeline drift = %q, want missing", got)
+	}
+	custom := &ingestPipelineResponse{"mine": {}}
+	if got := ingestPipelineDrift(custom, PipelineCheck{Name: "mine", Custom: true}, "embed-1"); got != "" {
+		t.Errorf("custom ingest pipeline drift = %q, want none", got)
+	}
+
+	search := &searchPipelineResponse{searchPipelineName: {ResponseProcessors: buildSearchPipelineBody("rerank-1")["response_processors"].([]map[string]any)}}
+	if got := searchPipelineDrift(search, PipelineCheck{Name: searchPipelineName}, "rerank-1"); got != "" {
+		t.Errorf("matching search pipeline drift = %q, want none", got)
+	}
+	if got := searchPipelineDrift(search, PipelineCheck{Name: searchPipelineName}, "rerank-2"); got != "rerank model rerank-1, expected rerank-2" {
+		t.Errorf("stale search pipeline drift = %q", got)
+	}
+}
@@ -12,10 +12,13 @@ import (
 	opensearchapi "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
 )
 
+var (
+	indexTemplateName = artifactName("index-template")
+	indexPatterns     = artifactName("context-*")
+	indexAlias        = artifactName("context")
+)
+
 const (
-	indexTemplateName  = "rag-snap-index-template"
-	indexPatterns      = "rag-snap-context-*"
-	indexAlias         = "rag-snap-context"
 	indexDefaultSubfix = "default"
 	embeddingDimension = 768
 	efConstruction     = 256
@@ -188,6 +191,7 @@ func buildIndexTemplateBody() map[string]any {
 				},
 			},
 			"mappings": map[string]any{
+				"dynamic_templates": tagsDynamicTemplates(),
 				"properties": map[string]any{
 					"source_id": map[string]any{
 						"type": "keyword",
@@ -195,6 +199,9 @@ func buildIndexTemplateBody() map[string]any {
 					"label": map[string]any{
 						"type": "keyword",
 					},
+					"tags": map[string]any{
+						"type": "object",
+					},
 					"content": map[string]any{
 						"type": "text",
 					},
@@ -221,6 +228,18 @@ func buildIndexTemplateBody() map[string]any {
 						"type":   "date",
 						"format": "yyyy-MM-dd HH:mm:ss",
 					},
+					"ordinal":      map[string]any{"type": "integer"},
+					"start_offset": map[string]any{"type": "integer"},
+					"end_offset":   map[string]any{"type": "integer"},
+					"content_hash": map[string]any{"type": "keyword"},
+					"page":         map[string]any{"type": "integer"},
+					"title":        map[string]any{"type": "text"},
+					"heading":      map[string]any{"type": "text"},
+					"language":     map[string]any{"type": "keyword"},
+					"symbol":       map[string]any{"type": "keyword"},
+					"archived":     map[string]any{"type": "boolean"},
+
+					"embedding_model_id": map[string]any{"type": "keyword"},
 				},
 			},
 		},
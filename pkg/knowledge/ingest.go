@@ -0,0 +1,399 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/jpnorenam/rag-snap/pkg/processing"
+)
+
+// ErrSourceAlreadyIngested signals that a source with the same identifier is
+// already present and completed, and the caller did not request a forced
+// re-ingest. Callers decide whether to skip (batch) or surface a conflict
+// (single ingest over the API).
+var ErrSourceAlreadyIngested = errors.New("source already ingested")
+
+// IngestOptions carries the resolved inputs for ingesting a single source. It
+// is the one place the ingest mechanics live, shared by the CLI and the daemon
+// so their re-ingest semantics cannot diverge.
+type IngestOptions struct {
+	// FilePath is the local path to the file fed to Tika (a downloaded/crawled
+	// temp file for URLs and repos, or a staged upload).
+	FilePath string
+	// SourceID is the stable identifier used by forget and metadata.
+	SourceID string
+	// MetadataPath is stored as SourceMetadata.FilePath — the original URL for
+	// crawled sources, otherwise the file path.
+	MetadataPath string
+	// TargetIndex is the full index name of the destination knowledge base.
+	TargetIndex string
+	// Label is the explicit label for this source. When empty, the base's
+	// default label applies (stored _meta default, or the naming convention).
+	Label string
+	// Tags are user-defined key/value metadata stored on the source record and
+	// on every chunk, so searches can filter on them.
+	Tags map[string]string
+	// Force replaces an existing source: its chunks are removed before
+	// re-indexing so a re-ingest does not append duplicate chunks.
+	Force bool
+	// Swap, with Force, replaces an existing source without a gap: the new
+	// chunks are indexed alongside the old ones, which are removed only once
+	// all of them are in. On failure the new chunks are removed instead, and
+	// the source keeps its previous chunks and metadata.
+	Swap bool
+	// Format picks the extraction: FormatRFP for a CSV of question/answer
+	// pairs, a structured format (see processing.IsStructuredFormat), or
+	// processing.FormatTika; "" detects it from the file extension.
+	Format string
+	// Title and Author are recorded when extraction finds none, e.g. from
+	// the metadata of a crawled page.
+	Title  string
+	Author string
+	// ETag and LastModified are the cache validators of a crawled page,
+	// recorded for knowledge refresh.
+	ETag         string
+	LastModified string
+	// Trigger is the Trigger* recorded in the source's version history;
+	// "" records TriggerIngest.
+	Trigger string
+	// KeepVersions, with Force, keeps the chunks of the version being
+	// replaced under a versioned source ID (see VersionedSourceID), and the
+	// chunks of at most this many replaced versions in all. 0 deletes the
+	// replaced chunks.
+	KeepVersions int
+	// Extracted is FilePath already extracted and chunked with the target
+	// base's chunking settings, e.g. ahead of indexing by a repository batch
+	// job; nil extracts FilePath during the ingest.
+	Extracted *processing.IngestResult
+}
+
+// FormatRFP is the IngestOptions.Format of a CSV of previous RFP
+// question/answer pairs, ingested one chunk per row.
+const FormatRFP = "rfp"
+
+// abandonTimeout bounds the cleanup AbandonSource performs after the ingest's
+// own context has been cancelled.
+const abandonTimeout = 30 * time.Second
+
+// AbandonSource cleans up after an interrupted ingest: any chunks already
+// indexed for the source are deleted and its metadata record is marked failed,
+// so the base holds no half-indexed source and a re-run starts clean. It uses a
+// fresh context because the ingest's own context is typically already done.
+// Cleanup is best-effort; errors are ignored.
+func (c *OpenSearchClient) AbandonSource(indexName, sourceID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), abandonTimeout)
+	defer cancel()
+	_, _ = c.DeleteChunksBySourceID(ctx, indexName, sourceID)
+	_ = c.UpdateSourceStatus(ctx, sourceID, StatusFailed)
+}
+
+// SourceCompleted reports whether a source with the given id already exists and
+// is in the completed state. Metadata is keyed globally by source id.
+func (c *OpenSearchClient) SourceCompleted(ctx context.Context, sourceID string) bool {
+	existing, err := c.GetSourceMetadata(ctx, sourceID)
+	return err == nil && existing.Status == StatusCompleted
+}
+
+// IngestSource ingests one source through an Ingestor without hooks; see
+// Ingestor.Ingest.
+func (c *OpenSearchClient) IngestSource(ctx context.Context, tika *processing.TikaClient, opts IngestOptions) error {
+	_, err := NewIngestor(c, tika).Ingest(ctx, opts)
+	return err
+}
+
+// IngestHooks are optional callbacks an Ingestor makes as an ingest
+// progresses, for callers that report on it.
+type IngestHooks struct {
+	// Indexed is called once the chunks are bulk-indexed, before the source
+	// is marked completed.
+	Indexed func(sourceID string, result *BulkResult)
+}
+
+// Ingestor runs the ingest flow every path shares — single ingest, batch
+// jobs, and the daemon: extract and chunk the source, write its metadata
+// record as processing, bulk-index the chunks, and mark the record completed
+// or failed.
+type Ingestor struct {
+	client *OpenSearchClient
+	tika   *processing.TikaClient
+	Hooks  IngestHooks
+}
+
+// NewIngestor returns an Ingestor writing through client and extracting with
+// tika.
+func NewIngestor(client *OpenSearchClient, tika *processing.TikaClient) *Ingestor {
+	return &Ingestor{client: client, tika: tika}
+}
+
+// Ingest runs the extraction + chunking pipeline for one source and
+// bulk-indexes the result. When Force is set and the source already exists,
+// its prior chunks are deleted first so the re-ingest replaces rather than
+// appends, or, with Swap, once the new chunks are indexed. It does NOT itself skip already-completed sources — that policy
+// belongs to the caller (see ErrSourceAlreadyIngested). A partial indexing
+// failure marks the source failed and is returned as an error along with the
+// bulk result.
+//
+// The knowledge.ingest.pre-hook command runs first, and may hand over a
+// converted file to ingest instead; the post-hook command runs last, with the
+// result.
+func (in *Ingestor) Ingest(ctx context.Context, opts IngestOptions) (*BulkResult, error) {
+	hooks := in.client.settings
+	if opts.FilePath != "" && hooks.preHook != "" {
+		converted, err := runPreHook(ctx, hooks.preHook, opts)
+		if err != nil {
+			return nil, err
+		}
+		if converted != "" {
+			if opts.MetadataPath == "" {
+				opts.MetadataPath = opts.FilePath
+			}
+			opts.FilePath = converted
+		}
+	}
+	result, err := in.ingest(ctx, opts)
+	if hooks.postHook != "" {
+		runPostHook(hooks.postHook, opts, result, err)
+	}
+	return result, err
+}
+
+func (in *Ingestor) ingest(ctx context.Context, opts IngestOptions) (*BulkResult, error) {
+	c := in.client
+	if opts.FilePath == "" {
+		return nil, fmt.Errorf("no file to ingest for source %q", opts.SourceID)
+	}
+	metadataPath := opts.MetadataPath
+	if metadataPath == "" {
+		metadataPath = opts.FilePath
+	}
+
+	// Resolve the source's label: explicit > base default > naming convention.
+	label := opts.Label
+	if label == "" {
+		var err error
+		if label, _, err = c.GetDefaultLabel(ctx, opts.TargetIndex); err != nil {
+			return nil, fmt.Errorf("resolving base default label: %w", err)
+		}
+	}
+	if err := ValidateLabel(label); err != nil {
+		return nil, err
+	}
+	// Indexes created before labels existed lack the keyword mapping; without
+	// it, dynamic mapping would type the field wrong on first write.
+	if err := c.EnsureLabelMapping(ctx, opts.TargetIndex); err != nil {
+		return nil, fmt.Errorf("ensuring label mapping: %w", err)
+	}
+	if err := c.EnsureProvenanceMapping(ctx, opts.TargetIndex); err != nil {
+		return nil, fmt.Errorf("ensuring chunk provenance mapping: %w", err)
+	}
+	// Fail before extraction, not with a mapper error on every chunk.
+	dims, err := c.CheckEmbeddingDimension(ctx, opts.TargetIndex)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Tags) > 0 {
+		if err := ValidateTags(opts.Tags); err != nil {
+			return nil, err
+		}
+		if err := c.EnsureSourceTagsMapping(ctx, opts.TargetIndex); err != nil {
+			return nil, err
+		}
+	}
+
+	// Forced re-ingest of an existing source replaces its old chunks, so the
+	// base ends up with only the new batch (fixes append-not-replace).
+	// The previous record is read either way, to carry its version history.
+	previous, err := c.GetSourceMetadata(ctx, opts.SourceID)
+	if err != nil {
+		previous = nil
+	}
+	replace := opts.Force && previous != nil
+	swap := replace && opts.Swap
+	keep := 0
+	if replace {
+		keep = opts.KeepVersions
+	}
+
+	settings, err := c.GetBaseSettings(ctx, opts.TargetIndex)
+	if err != nil {
+		return nil, fmt.Errorf("reading base settings: %w", err)
+	}
+	result := opts.Extracted
+	switch {
+	case result != nil:
+	case opts.Format == FormatRFP:
+		result, err = processing.IngestRFP(opts.FilePath, opts.SourceID)
+	default:
+		result, err = processing.IngestChunked(ctx, in.tika, opts.FilePath, opts.SourceID, opts.Format, settings.Chunking)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ingest pipeline failed: %w", err)
+	}
+
+	docs := make([]Document, len(result.Chunks))
+	for i, chunk := range result.Chunks {
+		docs[i] = DocumentFromChunk(chunk, label, opts.Tags)
+		if settings.IndexFilePath {
+			docs[i].FilePath = metadataPath
+		}
+	}
+	// With knowledge.ingest.dedup, chunks other sources already put in the
+	// base are left out.
+	var duplicates int
+	if c.settings.dedup {
+		docs, duplicates = c.DropDuplicateChunks(ctx, opts.TargetIndex, opts.SourceID, docs)
+	}
+	// Unchanged chunks keep their embeddings, if the pipeline still embeds
+	// with the model that computed them; look them up before the old chunks
+	// are deleted.
+	c.ReuseEmbeddings(ctx, opts.TargetIndex, dims.ModelID, dims.IndexDimension, docs)
+	if err := c.CheckDiskSpace(ctx, docs); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC().Format(DateFormat)
+	trigger := opts.Trigger
+	if trigger == "" {
+		trigger = TriggerIngest
+	}
+	versions := planVersions(previous, SourceVersion{
+		Checksum:   result.Checksum,
+		ChunkCount: len(docs),
+		IngestedAt: now,
+		Trigger:    trigger,
+		User:       CurrentUser(),
+	}, keep)
+
+	var swapIDs []string
+	if swap {
+		if swapIDs, err = assignSwapIDs(docs); err != nil {
+			return nil, err
+		}
+	} else if versions.archiveAs != "" {
+		if _, err := c.archiveChunks(ctx, opts.TargetIndex, opts.SourceID, versions.archiveAs, nil); err != nil {
+			return nil, fmt.Errorf("archiving existing chunks: %w", err)
+		}
+	} else if replace {
+		if _, err := c.DeleteChunksBySourceID(ctx, opts.TargetIndex, opts.SourceID); err != nil {
+			return nil, fmt.Errorf("removing existing chunks: %w", err)
+		}
+	}
+
+	meta := SourceMetadata{
+		SourceID:        opts.SourceID,
+		FileName:        filepath.Base(opts.FilePath),
+		FilePath:        metadataPath,
+		Checksum:        result.Checksum,
+		IndexName:       opts.TargetIndex,
+		ChunkCount:      len(docs),
+		DuplicateChunks: duplicates,
+		ChunkSize:       settings.Chunking.Size,
+		ChunkOverlap:    result.ChunkOverlap,
+		ContentLength:   result.ContentLength,
+		Label:           label,
+		Tags:            opts.Tags,
+		Status:          StatusProcessing,
+		IngestedAt:      now,
+		UpdatedAt:       now,
+		ContentType:     result.ContentType,
+		ETag:            opts.ETag,
+		LastModified:    opts.LastModified,
+		Versions:        versions.versions,
+	}
+	if swap && previous.IngestedAt != "" {
+		meta.IngestedAt = previous.IngestedAt
+	}
+	if opts.Format == FormatRFP {
+		meta.ContentType = "text/csv"
+	}
+	if result.TikaMetadata != nil {
+		meta.ContentType = result.TikaMetadata.ContentType
+		meta.Title = result.TikaMetadata.Title
+		meta.Author = result.TikaMetadata.Author
+		meta.Language = result.TikaMetadata.Language
+	}
+	if meta.Title == "" {
+		meta.Title = opts.Title
+	}
+	for i := range docs {
+		docs[i].Title = meta.Title
+	}
+	if meta.Author == "" {
+		meta.Author = opts.Author
+	}
+	if swap {
+		indexResult, err := in.swap(ctx, opts, meta, docs, swapIDs, versions.archiveAs)
+		if err == nil {
+			c.pruneVersions(ctx, opts.TargetIndex, versions.prune)
+		}
+		return indexResult, err
+	}
+	// Write metadata BEFORE bulk indexing, so an interrupted or failed
+	// ingest leaves a record saying so.
+	if err := c.IndexSourceMetadata(ctx, meta); err != nil {
+		return nil, fmt.Errorf("writing source metadata: %w", err)
+	}
+
+	indexResult, err := c.BulkIndex(ctx, opts.TargetIndex, docs)
+	if err != nil {
+		if ctx.Err() != nil {
+			c.AbandonSource(opts.TargetIndex, opts.SourceID)
+			return nil, fmt.Errorf("ingest interrupted: %w", ctx.Err())
+		}
+		_ = c.UpdateSourceStatus(ctx, opts.SourceID, StatusFailed)
+		return nil, fmt.Errorf("indexing failed: %w", err)
+	}
+	indexResult.Duplicates = duplicates
+	if in.Hooks.Indexed != nil {
+		in.Hooks.Indexed(opts.SourceID, indexResult)
+	}
+	if indexResult.Errors > 0 {
+		_ = c.UpdateSourceStatus(ctx, opts.SourceID, StatusFailed)
+		return indexResult, fmt.Errorf("partial indexing failure: %d/%d documents failed: %s", indexResult.Errors, indexResult.Total, indexResult.FirstError)
+	}
+	if err := c.UpdateSourceStatus(ctx, opts.SourceID, StatusCompleted); err != nil {
+		return indexResult, fmt.Errorf("updating source status: %w", err)
+	}
+	c.pruneVersions(ctx, opts.TargetIndex, versions.prune)
+	return indexResult, nil
+}
+
+// swap indexes docs, carrying the _ids in ids, alongside the chunks they
+// replace, then removes those, or archives them under archiveAs when set, and
+// writes meta as completed. The source's previous record stays in place
+// throughout, so a failure at any step only has to remove the new chunks.
+func (in *Ingestor) swap(ctx context.Context, opts IngestOptions, meta SourceMetadata, docs []Document, ids []string, archiveAs string) (*BulkResult, error) {
+	c := in.client
+	indexResult, err := c.BulkIndex(ctx, opts.TargetIndex, docs)
+	if err != nil {
+		c.discardSwap(opts.TargetIndex, ids)
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("ingest interrupted: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("indexing failed: %w", err)
+	}
+	indexResult.Duplicates = meta.DuplicateChunks
+	if in.Hooks.Indexed != nil {
+		in.Hooks.Indexed(opts.SourceID, indexResult)
+	}
+	if indexResult.Errors > 0 {
+		c.discardSwap(opts.TargetIndex, ids)
+		return indexResult, fmt.Errorf("partial indexing failure: %d/%d documents failed: %s", indexResult.Errors, indexResult.Total, indexResult.FirstError)
+	}
+	if archiveAs != "" {
+		if _, err := c.archiveChunks(ctx, opts.TargetIndex, opts.SourceID, archiveAs, ids); err != nil {
+			c.discardSwap(opts.TargetIndex, ids)
+			return indexResult, fmt.Errorf("archiving replaced chunks: %w", err)
+		}
+	} else if _, err := c.deleteStaleChunks(ctx, opts.TargetIndex, opts.SourceID, ids); err != nil {
+		c.discardSwap(opts.TargetIndex, ids)
+		return indexResult, fmt.Errorf("removing replaced chunks: %w", err)
+	}
+	meta.Status = StatusCompleted
+	if err := c.IndexSourceMetadata(ctx, meta); err != nil {
+		return indexResult, fmt.Errorf("writing source metadata: %w", err)
+	}
+	return indexResult, nil
+}
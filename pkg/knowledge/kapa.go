@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"sort"
 	"time"
+
+	"github.com/jpnorenam/rag-snap/pkg/httpclient"
 )
 
 const (
@@ -32,7 +34,7 @@ func NewKapaClient(projectID, apiKey string) *KapaClient {
 	return &KapaClient{
 		projectID:  projectID,
 		apiKey:     apiKey,
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+		httpClient: httpclient.New(30 * time.Second),
 	}
 }
 
@@ -78,13 +78,20 @@ func (c *OpenSearchClient) EnsureLabelMapping(ctx context.Context, indexName str
 }
 
 // SetDefaultLabel stores label as the base's default in the index mapping
-// _meta, so it travels with the mapping on export/import.
+// _meta, so it travels with the mapping on export/import. A mapping update
+// replaces _meta as a whole, so the other entries (the base's preset) are
+// read and written back with it.
 func (c *OpenSearchClient) SetDefaultLabel(ctx context.Context, indexName, label string) error {
 	if err := ValidateLabel(label); err != nil {
 		return err
 	}
+	meta, err := c.getIndexMeta(ctx, indexName)
+	if err != nil {
+		return err
+	}
+	meta["default_label"] = label
 	body := map[string]any{
-		"_meta": map[string]any{"default_label": label},
+		"_meta": meta,
 	}
 	return c.putMapping(ctx, indexName, body)
 }
@@ -105,8 +112,7 @@ func (c *OpenSearchClient) GetDefaultLabel(ctx context.Context, indexName string
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", false, fmt.Errorf("get mapping failed with status %d: %s", resp.StatusCode, string(body))
+		return "", false, statusError("get mapping", resp)
 	}
 
 	// Response shape: {"<index>": {"mappings": {"_meta": {"default_label": ...}, ...}}}
@@ -0,0 +1,195 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	opensearchapi "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+)
+
+// memoryIndexName holds the conversation summaries chat memory keeps. It is
+// outside the knowledge base prefix, so it never shows up as a base.
+var memoryIndexName = artifactName("memory")
+
+// Memory is the summary of one past chat session.
+type Memory struct {
+	Summary string `json:"summary"`
+	Model   string `json:"model,omitempty"`
+	// Owner is the system user whose chat was summarized (see CurrentUser);
+	// only they recall it.
+	Owner     string `json:"owner"`
+	CreatedAt string `json:"created_at"`
+}
+
+// getOrCreateMemoryIndex creates the memory index if it does not exist. An
+// index created before memories had owners gets the owner field's mapping, so
+// recall's exact match on it is not run against analyzed text.
+func (c *OpenSearchClient) getOrCreateMemoryIndex(ctx context.Context) error {
+	resp, err := c.client.Client.Do(ctx, opensearchapi.IndicesExistsReq{Indices: []string{memoryIndexName}}, nil)
+	if err != nil {
+		return fmt.Errorf("error checking if memory index exists: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return c.putMapping(ctx, memoryIndexName, map[string]any{
+			"properties": map[string]any{
+				"owner": map[string]any{"type": "keyword"},
+			},
+		})
+	}
+
+	bodyBytes, err := json.Marshal(buildMemoryIndexBody())
+	if err != nil {
+		return fmt.Errorf("error marshaling memory index body: %w", err)
+	}
+	createResp, err := c.client.Client.Do(
+		ctx,
+		opensearchapi.IndicesCreateReq{
+			Index: memoryIndexName,
+			Body:  bytes.NewReader(bodyBytes),
+		},
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("error creating memory index: %w", err)
+	}
+	defer createResp.Body.Close()
+
+	if createResp.StatusCode != http.StatusOK && createResp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(createResp.Body)
+		return fmt.Errorf("create memory index failed with status %d: %s", createResp.StatusCode, string(body))
+	}
+	return nil
+}
+
+func buildMemoryIndexBody() map[string]any {
+	return map[string]any{
+		"settings": map[string]any{
+			"index": map[string]any{
+				"number_of_shards":   "1",
+				"number_of_replicas": "1",
+			},
+		},
+		"mappings": map[string]any{
+			"properties": map[string]any{
+				"summary": map[string]any{"type": "text", "analyzer": "english"},
+				"model":   map[string]any{"type": "keyword"},
+				"owner":   map[string]any{"type": "keyword"},
+				"created_at": map[string]any{
+					"type":   "date",
+					"format": "yyyy-MM-dd HH:mm:ss",
+				},
+			},
+		},
+	}
+}
+
+// SaveMemory stores the summary of a finished chat session, creating the
+// memory index on first use. The memory must name its owner.
+func (c *OpenSearchClient) SaveMemory(ctx context.Context, memory Memory) error {
+	if memory.Owner == "" {
+		return fmt.Errorf("memory has no owner")
+	}
+	if err := c.getOrCreateMemoryIndex(ctx); err != nil {
+		return fmt.Errorf("ensuring memory index: %w", err)
+	}
+
+	bodyBytes, err := json.Marshal(memory)
+	if err != nil {
+		return fmt.Errorf("error marshaling memory: %w", err)
+	}
+	req, err := c.newAuthenticatedRequest(http.MethodPost, "/"+memoryIndexName+"/_doc", bytes.NewReader(bodyBytes))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("error indexing memory: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("index memory failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// RecallMemories returns up to k of owner's past session summaries matching
+// query, best match first, or the k most recent when query is empty. It
+// returns none before any memory has been saved, and never those of other
+// owners, nor those saved before memories had owners.
+func (c *OpenSearchClient) RecallMemories(ctx context.Context, owner, query string, k int) ([]Memory, error) {
+	if owner == "" {
+		return nil, fmt.Errorf("recalling memories needs an owner")
+	}
+	bodyBytes, err := json.Marshal(buildRecallBody(owner, query, k))
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling recall query: %w", err)
+	}
+	req, err := c.newAuthenticatedRequest(http.MethodPost, "/"+memoryIndexName+"/_search", bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error searching memories: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("recall request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var searchResp struct {
+		Hits struct {
+			Hits []struct {
+				Source Memory `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
+		return nil, fmt.Errorf("error decoding recall response: %w", err)
+	}
+	memories := make([]Memory, 0, len(searchResp.Hits.Hits))
+	for _, hit := range searchResp.Hits.Hits {
+		memories = append(memories, hit.Source)
+	}
+	return memories, nil
+}
+
+// buildRecallBody matches query against owner's summaries, or lists their
+// newest summaries when query is empty.
+func buildRecallBody(owner, query string, k int) map[string]any {
+	ownerFilter := []map[string]any{{"term": map[string]any{"owner": owner}}}
+	if query == "" {
+		return map[string]any{
+			"size":  k,
+			"query": map[string]any{"bool": map[string]any{"filter": ownerFilter}},
+			"sort":  []map[string]any{{"created_at": map[string]any{"order": "desc"}}},
+		}
+	}
+	return map[string]any{
+		"size": k,
+		"query": map[string]any{
+			"bool": map[string]any{
+				"must": []map[string]any{{
+					"match": map[string]any{
+						"summary": map[string]any{"query": query},
+					},
+				}},
+				"filter": ownerFilter,
+			},
+		},
+	}
+}
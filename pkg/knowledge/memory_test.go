@@ -0,0 +1,17 @@
+package knowledge
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildRecallBodyFiltersOwner(t *testing.T) {
+	want := []map[string]any{{"term": map[string]any{"owner": "alice"}}}
+	for _, query := range []string{"", "ceph upgrade"} {
+		body := buildRecallBody("alice", query, 3)
+		filter := body["query"].(map[string]any)["bool"].(map[string]any)["filter"]
+		if !reflect.DeepEqual(filter, want) {
+			t.Errorf("recall %q filter = %v, want %v", query, filter, want)
+		}
+	}
+}
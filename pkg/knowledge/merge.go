@@ -0,0 +1,135 @@
+package knowledge
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Score normalizations applied to each base's hits before a search across
+// several bases merges them, from the knowledge.search.normalization config
+// value.
+const (
+	NormalizationNone   = "none"
+	NormalizationMinMax = "min-max"
+	NormalizationZScore = "z-score"
+)
+
+// parseSearchMerge reads how a search across several knowledge bases merges
+// their hits, from the knowledge.search.weights and
+// knowledge.search.normalization config values: each base's scores are
+// normalized, then scaled by the base's weight. Weights are comma-separated
+// base=weight pairs, e.g. "docs=2,scratch=0.5"; bases left out weigh 1.
+// Normalization is none, min-max, or z-score. Empty values keep the defaults:
+// no weights, no normalization.
+func parseSearchMerge(weights, normalization string) (map[string]float64, string, error) {
+	norm := NormalizationNone
+	switch normalization = strings.TrimSpace(normalization); normalization {
+	case "", NormalizationNone:
+	case NormalizationMinMax, NormalizationZScore:
+		norm = normalization
+	default:
+		return nil, "", fmt.Errorf("invalid knowledge.search.normalization %q: expected none, min-max, or z-score", normalization)
+	}
+
+	parsed := map[string]float64{}
+	if weights = strings.TrimSpace(weights); weights != "" {
+		for _, pair := range strings.Split(weights, ",") {
+			base, weight, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			base = strings.TrimSpace(base)
+			if !ok || base == "" {
+				return nil, "", fmt.Errorf("invalid knowledge.search.weights %q: expected base=weight pairs", weights)
+			}
+			w, err := strconv.ParseFloat(strings.TrimSpace(weight), 64)
+			if err != nil || w < 0 {
+				return nil, "", fmt.Errorf("invalid knowledge.search.weights weight %q for %s: expected a non-negative number", weight, base)
+			}
+			parsed[base] = w
+		}
+	}
+
+	return parsed, norm, nil
+}
+
+// mergeHits merges the hits each index returned into one list by score. When
+// there are several indexes, each one's scores are first normalized and
+// weighed for the merge with normalization and weights (see
+// parseSearchMerge). With perBaseK, the list starts with each index's top
+// perBaseK hits, so every base keeps a place among the first results however
+// the scores compare.
+func mergeHits(perIndex [][]SearchHit, perBaseK int, normalization string, weights map[string]float64) []SearchHit {
+	var reserved, rest []SearchHit
+	for _, hits := range perIndex {
+		if len(perIndex) > 1 {
+			scoreForMerge(hits, normalization, weights)
+		}
+		sortByScore(hits)
+		n := min(max(perBaseK, 0), len(hits))
+		reserved = append(reserved, hits[:n]...)
+		rest = append(rest, hits[n:]...)
+	}
+	sortByScore(reserved)
+	sortByScore(rest)
+	return append(reserved, rest...)
+}
+
+// scoreForMerge rescales the scores of one index's hits in place: normalized
+// by norm, then multiplied by the weight of the index's base. Normalized
+// scores are never negative, so a larger weight always ranks a hit higher.
+func scoreForMerge(hits []SearchHit, norm string, weights map[string]float64) {
+	if len(hits) == 0 {
+		return
+	}
+	switch norm {
+	case NormalizationMinMax:
+		lo, hi := hits[0].Score, hits[0].Score
+		for _, h := range hits {
+			lo, hi = math.Min(lo, h.Score), math.Max(hi, h.Score)
+		}
+		for i := range hits {
+			if hi > lo {
+				hits[i].Score = (hits[i].Score - lo) / (hi - lo)
+			} else {
+				hits[i].Score = 1
+			}
+		}
+	case NormalizationZScore:
+		var mean float64
+		for _, h := range hits {
+			mean += h.Score
+		}
+		mean /= float64(len(hits))
+		var variance float64
+		for _, h := range hits {
+			variance += (h.Score - mean) * (h.Score - mean)
+		}
+		std := math.Sqrt(variance / float64(len(hits)))
+		for i := range hits {
+			var z float64
+			if std > 0 {
+				z = (hits[i].Score - mean) / std
+			}
+			// Map z onto 0–1 through the normal distribution, so that the
+			// below-average hits a weight scales stay below the average ones
+			// rather than falling further as the weight grows.
+			hits[i].Score = 0.5 * (1 + math.Erf(z/math.Sqrt2))
+		}
+	}
+
+	base, _ := KnowledgeBaseNameFromIndex(hits[0].Index)
+	if w, ok := weights[base]; ok {
+		for i := range hits {
+			hits[i].Score *= w
+		}
+	}
+}
+
+// sortByScore sorts hits by score, highest first, keeping the order of equal
+// scores.
+func sortByScore(hits []SearchHit) {
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+}
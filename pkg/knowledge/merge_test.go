@@ -0,0 +1,115 @@
+package knowledge
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseSearchMerge(t *testing.T) {
+	weights, normalization, err := parseSearchMerge("docs=2, scratch=0.5", "min-max")
+	if err != nil {
+		t.Fatalf("parseSearchMerge: %v", err)
+	}
+	if want := map[string]float64{"docs": 2, "scratch": 0.5}; !reflect.DeepEqual(weights, want) {
+		t.Errorf("weights = %v, want %v", weights, want)
+	}
+	if normalization != NormalizationMinMax {
+		t.Errorf("normalization = %q, want %q", normalization, NormalizationMinMax)
+	}
+
+	for _, bad := range [][2]string{{"docs", ""}, {"=2", ""}, {"docs=-1", ""}, {"docs=x", ""}, {"", "rank"}} {
+		if _, _, err := parseSearchMerge(bad[0], bad[1]); err == nil {
+			t.Errorf("parseSearchMerge(%q, %q) succeeded", bad[0], bad[1])
+		}
+	}
+}
+
+func TestScoreForMerge(t *testing.T) {
+	hits := func(scores ...float64) []SearchHit {
+		var out []SearchHit
+		for _, s := range scores {
+			out = append(out, SearchHit{Index: FullIndexName("docs"), Score: s})
+		}
+		return out
+	}
+	scores := func(hits []SearchHit) []float64 {
+		var out []float64
+		for _, h := range hits {
+			out = append(out, math.Round(h.Score*1000)/1000)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name    string
+		in      []SearchHit
+		norm    string
+		weights map[string]float64
+		want    []float64
+	}{
+		{"none", hits(8, 4), NormalizationNone, nil, []float64{8, 4}},
+		{"weight only", hits(8, 4), NormalizationNone, map[string]float64{"docs": 0.5}, []float64{4, 2}},
+		{"min-max", hits(10, 6, 2), NormalizationMinMax, nil, []float64{1, 0.5, 0}},
+		{"min-max weighed", hits(10, 6, 2), NormalizationMinMax, map[string]float64{"docs": 2}, []float64{2, 1, 0}},
+		{"min-max equal scores", hits(3, 3), NormalizationMinMax, nil, []float64{1, 1}},
+		{"z-score", hits(3, 1), NormalizationZScore, nil, []float64{0.841, 0.159}},
+		{"z-score weighed", hits(3, 1), NormalizationZScore, map[string]float64{"docs": 2}, []float64{1.683, 0.317}},
+		{"z-score equal scores", hits(5, 5), NormalizationZScore, nil, []float64{0.5, 0.5}},
+		{"other base's weight", hits(8), NormalizationNone, map[string]float64{"wiki": 3}, []float64{8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scoreForMerge(tt.in, tt.norm, tt.weights)
+			if got := scores(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scores = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeHits(t *testing.T) {
+	base := func(name string, scores ...float64) []SearchHit {
+		var out []SearchHit
+		for i, s := range scores {
+			out = append(out, SearchHit{Index: FullIndexName(name), Content: name + string(rune('a'+i)), Score: s})
+		}
+		return out
+	}
+	ids := func(hits []SearchHit) []string {
+		var out []string
+		for _, h := range hits {
+			out = append(out, h.Content)
+		}
+		return out
+	}
+	// big scores far higher than small, so without normalization it takes
+	// every top spot.
+	perIndex := func() [][]SearchHit {
+		return [][]SearchHit{base("big", 40, 30, 20), base("small", 2, 1.5, 1)}
+	}
+
+	if got, want := ids(mergeHits(perIndex(), 0, NormalizationNone, nil)), []string{"biga", "bigb", "bigc", "smalla", "smallb", "smallc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("raw merge = %v, want %v", got, want)
+	}
+	if got, want := ids(mergeHits(perIndex(), 1, NormalizationNone, nil)), []string{"biga", "smalla", "bigb", "bigc", "smallb", "smallc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("merge with per-base k = %v, want %v", got, want)
+	}
+
+	weights := map[string]float64{"small": 2}
+	if got, want := ids(mergeHits(perIndex(), 0, NormalizationMinMax, weights)), []string{"smalla", "biga", "smallb", "bigb", "bigc", "smallc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("normalized, weighed merge = %v, want %v", got, want)
+	}
+
+	// Under z-score, the weight raises small's below-average hit too: it
+	// ranks above big's, not below as a negative z-score times 2 would.
+	if got, want := ids(mergeHits(perIndex(), 0, NormalizationZScore, weights)), []string{"smalla", "smallb", "biga", "bigb", "smallc", "bigc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("z-score, weighed merge = %v, want %v", got, want)
+	}
+
+	// A single index keeps its raw scores.
+	single := mergeHits([][]SearchHit{base("small", 2, 1)}, 0, NormalizationMinMax, weights)
+	if single[0].Score != 2 || single[1].Score != 1 {
+		t.Errorf("single index scores = %v, %v, want 2, 1", single[0].Score, single[1].Score)
+	}
+}
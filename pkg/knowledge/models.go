@@ -4,10 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"time"
 )
 
 const (
@@ -16,11 +16,12 @@ const (
 
 	DefaultCrossEncoderName    = "huggingface/cross-encoders/ms-marco-MiniLM-L-12-v2"
 	defaultCrossEncoderVersion = "1.0.2"
-
-	modelGroupName = "rag-snap-models"
 )
 
-// getOrCreateModelGroup searches for a model group named "rag-snap-models".
+// modelGroupName is the model group the snap registers its models in.
+var modelGroupName = artifactName("models")
+
+// getOrCreateModelGroup searches for a model group named modelGroupName.
 // If it exists, returns the model_group_id. If not, creates one and returns the new model_group_id.
 func (c *OpenSearchClient) getOrCreateModelGroup(ctx context.Context) (string, error) {
 	modelGroupID, err := c.findModelGroup(ctx, modelGroupName)
@@ -180,8 +181,8 @@ func (c *OpenSearchClient) registerAndDeploySentenceTransformer(
 		if state == "DEPLOYED" {
 			return existingModelID, nil
 		}
-		// Model exists but not deployed, deploy it
-		if state == "REGISTERED" {
+		// Model exists but not deployed (never, or undeployed since), deploy it
+		if state == "REGISTERED" || state == "UNDEPLOYED" {
 			if err := c.deployModel(ctx, existingModelID); err != nil {
 				return "", fmt.Errorf("error deploying existing model: %w", err)
 			}
@@ -192,8 +193,13 @@ func (c *OpenSearchClient) registerAndDeploySentenceTransformer(
 		return existingModelID, nil
 	}
 
-	// Register the model
-	modelID, err := c.registerModel(ctx, modelGroupID, modelName, modelVersion, "TORCH_SCRIPT", "TEXT_EMBEDDING")
+	// Register the model, uploading a bundled artifact when one was given
+	var modelID string
+	if local := c.localModels.Embedding; local != nil {
+		modelID, err = c.uploadModel(ctx, local, modelGroupID, modelName, modelVersion, "TEXT_EMBEDDING", sentenceTransformerConfig)
+	} else {
+		modelID, err = c.registerModel(ctx, modelGroupID, modelName, modelVersion, "TORCH_SCRIPT", "TEXT_EMBEDDING")
+	}
 	if err != nil {
 		return "", fmt.Errorf("error registering sentence transformer model: %w", err)
 	}
@@ -246,8 +252,8 @@ func (c *OpenSearchClient) registerAndDeployCrossEncoder(
 		if state == "DEPLOYED" {
 			return existingModelID, nil
 		}
-		// Model exists but not deployed, deploy it
-		if state == "REGISTERED" {
+		// Model exists but not deployed (never, or undeployed since), deploy it
+		if state == "REGISTERED" || state == "UNDEPLOYED" {
 			if err := c.deployModel(ctx, existingModelID); err != nil {
 				return "", fmt.Errorf("error deploying existing model: %w", err)
 			}
@@ -258,8 +264,13 @@ func (c *OpenSearchClient) registerAndDeployCrossEncoder(
 		return existingModelID, nil
 	}
 
-	// Register the model
-	modelID, err := c.registerModel(ctx, modelGroupID, modelName, modelVersion, "TORCH_SCRIPT", "TEXT_SIMILARITY")
+	// Register the model, uploading a bundled artifact when one was given
+	var modelID string
+	if local := c.localModels.Rerank; local != nil {
+		modelID, err = c.uploadModel(ctx, local, modelGroupID, modelName, modelVersion, "TEXT_SIMILARITY", crossEncoderConfig)
+	} else {
+		modelID, err = c.registerModel(ctx, modelGroupID, modelName, modelVersion, "TORCH_SCRIPT", "TEXT_SIMILARITY")
+	}
 	if err != nil {
 		return "", fmt.Errorf("error registering cross-encoder model: %w", err)
 	}
@@ -415,6 +426,12 @@ func (c *OpenSearchClient) ListDeployedModels(ctx context.Context) ([]DeployedMo
 	return models, nil
 }
 
+// ModelState returns the ML plugin's state for a model, e.g. "DEPLOYED",
+// "PARTIALLY_DEPLOYED", or "DEPLOY_FAILED".
+func (c *OpenSearchClient) ModelState(ctx context.Context, modelID string) (string, error) {
+	return c.getModelState(ctx, modelID)
+}
+
 // getModelState retrieves the current state of a model.
 func (c *OpenSearchClient) getModelState(ctx context.Context, modelID string) (string, error) {
 	req, err := c.newAuthenticatedRequest(http.MethodGet, fmt.Sprintf("/_plugins/_ml/models/%s", modelID), nil)
@@ -519,88 +536,73 @@ func (c *OpenSearchClient) deployModel(ctx context.Context, modelID string) erro
 
 // waitForTaskAndGetModelID polls a task until it completes and returns the model_id.
 func (c *OpenSearchClient) waitForTaskAndGetModelID(ctx context.Context, taskID string) (string, error) {
-	const (
-		pollInterval = 2 * time.Second
-		timeout      = 5 * time.Minute
-	)
-
-	start := time.Now()
-	for {
-		if time.Since(start) > timeout {
-			return "", fmt.Errorf("timeout waiting for task %s to complete", taskID)
-		}
-
+	var modelID string
+	err := c.settings.modelPoller().poll(ctx, func(ctx context.Context) (bool, error) {
 		req, err := c.newAuthenticatedRequest(http.MethodGet, fmt.Sprintf("/_plugins/_ml/tasks/%s", taskID), nil)
 		if err != nil {
-			return "", fmt.Errorf("error creating request: %w", err)
+			return true, fmt.Errorf("error creating request: %w", err)
 		}
 
 		resp, err := c.client.Client.Perform(req.WithContext(ctx))
 		if err != nil {
-			return "", fmt.Errorf("error getting task status: %w", err)
+			return false, fmt.Errorf("error getting task status: %w", err)
 		}
+		defer resp.Body.Close()
 
 		var taskResp taskStatusResponse
 		if err := json.NewDecoder(resp.Body).Decode(&taskResp); err != nil {
-			resp.Body.Close()
-			return "", fmt.Errorf("error decoding task response: %w", err)
+			return true, fmt.Errorf("error decoding task response: %w", err)
 		}
-		resp.Body.Close()
 
 		switch taskResp.State {
 		case "COMPLETED":
-			if taskResp.ModelID != "" {
-				return taskResp.ModelID, nil
+			if taskResp.ModelID == "" {
+				return true, fmt.Errorf("task completed but no model_id returned")
 			}
-			return "", fmt.Errorf("task completed but no model_id returned")
+			modelID = taskResp.ModelID
+			return true, nil
 		case "FAILED":
-			return "", fmt.Errorf("task failed: %s", taskResp.Error)
+			return true, fmt.Errorf("task failed: %s", taskResp.Error)
 		}
-
-		time.Sleep(pollInterval)
+		return false, nil
+	})
+	if errors.Is(err, errPollTimeout) {
+		return "", fmt.Errorf("timeout after %s waiting for task %s to complete (knowledge.model.timeout)", c.settings.modelPoller().timeout, taskID)
 	}
+	return modelID, err
 }
 
 // waitForModelState polls the model status until it reaches the desired state.
 func (c *OpenSearchClient) waitForModelState(ctx context.Context, modelID, desiredState string) error {
-	const (
-		pollInterval = 2 * time.Second
-		timeout      = 5 * time.Minute
-	)
-
-	start := time.Now()
-	for {
-		if time.Since(start) > timeout {
-			return fmt.Errorf("timeout waiting for model %s to reach state %s", modelID, desiredState)
-		}
-
+	err := c.settings.modelPoller().poll(ctx, func(ctx context.Context) (bool, error) {
 		req, err := c.newAuthenticatedRequest(http.MethodGet, fmt.Sprintf("/_plugins/_ml/models/%s", modelID), nil)
 		if err != nil {
-			return fmt.Errorf("error creating request: %w", err)
+			return true, fmt.Errorf("error creating request: %w", err)
 		}
 
 		resp, err := c.client.Client.Perform(req.WithContext(ctx))
 		if err != nil {
-			return fmt.Errorf("error getting model status: %w", err)
+			return false, fmt.Errorf("error getting model status: %w", err)
 		}
+		defer resp.Body.Close()
 
 		var modelResp modelStatusResponse
 		if err := json.NewDecoder(resp.Body).Decode(&modelResp); err != nil {
-			resp.Body.Close()
-			return fmt.Errorf("error decoding model response: %w", err)
+			return true, fmt.Errorf("error decoding model response: %w", err)
 		}
-		resp.Body.Close()
 
-		if modelResp.ModelState == desiredState {
-			return nil
+		switch modelResp.ModelState {
+		case desiredState:
+			return true, nil
+		case "DEPLOY_FAILED", "REGISTER_FAILED":
+			return true, fmt.Errorf("model reached failed state: %s", modelResp.ModelState)
 		}
-
-		if modelResp.ModelState == "DEPLOY_FAILED" || modelResp.ModelState == "REGISTER_FAILED" {
-			return fmt.Errorf("model reached failed state: %s", modelResp.ModelState)
-		}
-
-		time.Sleep(pollInterval)
+		return false, nil
+	})
+	if errors.Is(err, errPollTimeout) {
+		return fmt.Errorf("timeout after %s waiting for model %s to reach state %s (knowledge.model.timeout)", c.settings.modelPoller().timeout, modelID, desiredState)
 	}
+	return err
 }
 
 type modelRegisterResponse struct {
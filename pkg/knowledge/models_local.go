@@ -0,0 +1,181 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// modelChunkSize is the size of each part uploaded through the ML upload_chunk
+// API; OpenSearch caps a chunk at 10 MB.
+const modelChunkSize = 10 << 20
+
+// LocalModel is a model artifact zip on local disk, registered by uploading it
+// to OpenSearch in chunks instead of letting OpenSearch download it from
+// Hugging Face. It must be the TorchScript build of the model it stands in for
+// (DefaultSentenceTransformerName or DefaultCrossEncoderName): the index
+// mapping and pipelines are built around those models.
+type LocalModel struct {
+	Path string
+	// SHA256 is the expected hex digest of the zip. When set, a file whose
+	// digest differs is rejected before anything is uploaded.
+	SHA256 string
+}
+
+// LocalModels selects bundled artifacts for knowledge init. A nil field keeps
+// the Hugging Face download for that model.
+type LocalModels struct {
+	Embedding *LocalModel
+	Rerank    *LocalModel
+}
+
+// UseLocalModels makes the next Init register the given models from local
+// files, for air-gapped clusters that cannot reach Hugging Face.
+func (c *OpenSearchClient) UseLocalModels(models LocalModels) {
+	c.localModels = models
+}
+
+// modelConfig describes a TorchScript artifact to the ML plugin. Hugging Face
+// registrations carry this in the model's own metadata; uploads must supply it.
+type modelConfig struct {
+	ModelType          string `json:"model_type"`
+	EmbeddingDimension int    `json:"embedding_dimension"`
+	FrameworkType      string `json:"framework_type"`
+}
+
+var (
+	sentenceTransformerConfig = modelConfig{
+		ModelType:          "distilbert",
+		EmbeddingDimension: embeddingDimension,
+		FrameworkType:      "sentence_transformers",
+	}
+	crossEncoderConfig = modelConfig{
+		ModelType:          "bert",
+		EmbeddingDimension: 1,
+		FrameworkType:      "huggingface_transformers",
+	}
+)
+
+// verify checks the artifact exists and matches the expected digest, and
+// returns its digest and size.
+func (m *LocalModel) verify() (string, int64, error) {
+	f, err := os.Open(m.Path)
+	if err != nil {
+		return "", 0, fmt.Errorf("opening model file: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, fmt.Errorf("hashing model file: %w", err)
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	if m.SHA256 != "" && !strings.EqualFold(sum, strings.TrimSpace(m.SHA256)) {
+		return "", 0, fmt.Errorf("model file %s has SHA-256 %s, expected %s", m.Path, sum, m.SHA256)
+	}
+	return sum, size, nil
+}
+
+// uploadModel registers a local artifact's metadata and uploads its content in
+// chunks, returning the model ID. Registration completes asynchronously once the
+// last chunk arrives; callers wait for the REGISTERED state as usual.
+func (c *OpenSearchClient) uploadModel(
+	ctx context.Context,
+	model *LocalModel,
+	modelGroupID,
+	modelName,
+	modelVersion,
+	functionName string,
+	config modelConfig,
+) (string, error) {
+	sum, size, err := model.verify()
+	if err != nil {
+		return "", err
+	}
+	totalChunks := int((size + modelChunkSize - 1) / modelChunkSize)
+
+	requestBody := map[string]any{
+		"name":                        modelName,
+		"version":                     modelVersion,
+		"model_group_id":              modelGroupID,
+		"model_format":                "TORCH_SCRIPT",
+		"function_name":               functionName,
+		"model_content_hash_value":    sum,
+		"model_content_size_in_bytes": size,
+		"total_chunks":                totalChunks,
+		"model_config":                config,
+	}
+	bodyBytes, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling request body: %w", err)
+	}
+
+	req, err := c.newAuthenticatedRequest(http.MethodPost, "/_plugins/_ml/models/_register_meta", bytes.NewReader(bodyBytes))
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %w", err)
+	}
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("error executing register meta request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("register meta request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+	var registerResp modelRegisterResponse
+	if err := json.NewDecoder(resp.Body).Decode(&registerResp); err != nil {
+		return "", fmt.Errorf("error decoding register meta response: %w", err)
+	}
+	if registerResp.ModelID == "" {
+		return "", fmt.Errorf("no model_id returned from register meta")
+	}
+
+	f, err := os.Open(model.Path)
+	if err != nil {
+		return "", fmt.Errorf("opening model file: %w", err)
+	}
+	defer f.Close()
+
+	buf := make([]byte, modelChunkSize)
+	for chunk := 0; chunk < totalChunks; chunk++ {
+		n, err := io.ReadFull(f, buf)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return "", fmt.Errorf("reading model file: %w", err)
+		}
+		if err := c.uploadModelChunk(ctx, registerResp.ModelID, chunk, buf[:n]); err != nil {
+			return "", fmt.Errorf("uploading chunk %d/%d: %w", chunk+1, totalChunks, err)
+		}
+	}
+	return registerResp.ModelID, nil
+}
+
+func (c *OpenSearchClient) uploadModelChunk(ctx context.Context, modelID string, chunk int, data []byte) error {
+	path := fmt.Sprintf("/_plugins/_ml/models/%s/upload_chunk/%d", modelID, chunk)
+	req, err := c.newAuthenticatedRequest(http.MethodPost, path, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("upload chunk request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
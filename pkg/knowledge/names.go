@@ -0,0 +1,35 @@
+package knowledge
+
+import (
+	"strings"
+
+	"github.com/canonical/go-snapctl/env"
+)
+
+// defaultNamePrefix starts the name of every OpenSearch artifact the default
+// snap instance creates.
+const defaultNamePrefix = "rag-snap"
+
+// namePrefix starts the name of every index, template, pipeline, and model
+// group the snap creates: defaultNamePrefix for the default instance, and
+// defaultNamePrefix_<key> for a parallel instance installed as <snap>_<key>,
+// so instances sharing a cluster never touch each other's artifacts. The
+// underscore keeps the prefixes apart: every name of the default instance
+// continues "rag-snap-", which no instance prefix starts with.
+var namePrefix = instanceNamePrefix(env.SnapInstanceName())
+
+// instanceNamePrefix returns the artifact name prefix of the snap instance
+// named instanceName, which is empty outside a snap.
+func instanceNamePrefix(instanceName string) string {
+	_, key, ok := strings.Cut(instanceName, "_")
+	if !ok || key == "" {
+		return defaultNamePrefix
+	}
+	return defaultNamePrefix + "_" + strings.ToLower(key)
+}
+
+// artifactName returns the name of the artifact called suffix under
+// namePrefix, e.g. "rag-snap-memory".
+func artifactName(suffix string) string {
+	return namePrefix + "-" + suffix
+}
@@ -0,0 +1,20 @@
+package knowledge
+
+import "testing"
+
+func TestInstanceNamePrefix(t *testing.T) {
+	tests := []struct {
+		instance, want string
+	}{
+		{"", "rag-snap"},
+		{"rag-cli", "rag-snap"},
+		{"rag-cli_dev", "rag-snap_dev"},
+		{"rag-cli_Dev2", "rag-snap_dev2"},
+		{"rag-cli_", "rag-snap"},
+	}
+	for _, tt := range tests {
+		if got := instanceNamePrefix(tt.instance); got != tt.want {
+			t.Errorf("instanceNamePrefix(%q) = %q, want %q", tt.instance, got, tt.want)
+		}
+	}
+}
@@ -9,15 +9,32 @@ import (
 	"net/http"
 )
 
-const (
-	ingestPipelineName = "rag-snap-ingest-pipeline"
-	searchPipelineName = "rag-snap-search-pipeline"
+var (
+	ingestPipelineName = artifactName("ingest-pipeline")
+	searchPipelineName = artifactName("search-pipeline")
 )
 
+// RewirePipelines points the snap's ingest and search pipelines at the given
+// embedding and rerank models, creating a pipeline that is missing. An empty
+// model ID leaves its pipeline as it is.
+func (c *OpenSearchClient) RewirePipelines(ctx context.Context, embeddingModelID, rerankModelID string) error {
+	if embeddingModelID != "" {
+		if err := c.getOrCreateIngestPipeline(ctx, embeddingModelID); err != nil {
+			return err
+		}
+	}
+	if rerankModelID != "" {
+		if err := c.getOrCreateSearchPipeline(ctx, rerankModelID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // getOrCreateIngestPipeline checks if the ingest pipeline exists and creates or updates it.
 // The embeddingModelID parameter specifies the model to use for text embedding.
 func (c *OpenSearchClient) getOrCreateIngestPipeline(ctx context.Context, embeddingModelID string) error {
-	pipeline, err := c.getIngestPipeline(ctx)
+	pipeline, err := c.getIngestPipeline(ctx, ingestPipelineName)
 	if err != nil {
 		return fmt.Errorf("error getting ingest pipeline: %w", err)
 	}
@@ -38,10 +55,10 @@ func (c *OpenSearchClient) getOrCreateIngestPipeline(ctx context.Context, embedd
 	return nil
 }
 
-// getIngestPipeline retrieves the ingest pipeline if it exists.
+// getIngestPipeline retrieves the named ingest pipeline if it exists.
 // Returns nil if the pipeline is not found (404).
-func (c *OpenSearchClient) getIngestPipeline(ctx context.Context) (*ingestPipelineResponse, error) {
-	req, err := c.newAuthenticatedRequest(http.MethodGet, fmt.Sprintf("/_ingest/pipeline/%s", ingestPipelineName), nil)
+func (c *OpenSearchClient) getIngestPipeline(ctx context.Context, name string) (*ingestPipelineResponse, error) {
+	req, err := c.newAuthenticatedRequest(http.MethodGet, fmt.Sprintf("/_ingest/pipeline/%s", name), nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
 	}
@@ -126,7 +143,9 @@ func (c *OpenSearchClient) updateIngestPipeline(ctx context.Context, embeddingMo
 	return nil
 }
 
-// buildIngestPipelineBody constructs the ingest pipeline JSON body.
+// buildIngestPipelineBody constructs the ingest pipeline JSON body. Each chunk
+// records the model that embedded it, so ReuseEmbeddings only copies vectors
+// computed by the model the pipeline runs.
 func buildIngestPipelineBody(embeddingModelID string) map[string]any {
 	return map[string]any{
 		"description": "rag-snap ingest pipeline",
@@ -139,6 +158,12 @@ func buildIngestPipelineBody(embeddingModelID string) map[string]any {
 					},
 				},
 			},
+			{
+				"set": map[string]any{
+					"field": "embedding_model_id",
+					"value": embeddingModelID,
+				},
+			},
 		},
 	}
 }
@@ -146,7 +171,7 @@ func buildIngestPipelineBody(embeddingModelID string) map[string]any {
 // getOrCreateSearchPipeline checks if the search pipeline exists and creates or updates it.
 // The rerankerModelID parameter specifies the cross-encoder model to use for reranking.
 func (c *OpenSearchClient) getOrCreateSearchPipeline(ctx context.Context, rerankerModelID string) error {
-	pipeline, err := c.getSearchPipeline(ctx)
+	pipeline, err := c.getSearchPipeline(ctx, searchPipelineName)
 	if err != nil {
 		return fmt.Errorf("error getting search pipeline: %w", err)
 	}
@@ -167,10 +192,10 @@ func (c *OpenSearchClient) getOrCreateSearchPipeline(ctx context.Context, rerank
 	return nil
 }
 
-// getSearchPipeline retrieves the search pipeline if it exists.
+// getSearchPipeline retrieves the named search pipeline if it exists.
 // Returns nil if the pipeline is not found (404).
-func (c *OpenSearchClient) getSearchPipeline(ctx context.Context) (*searchPipelineResponse, error) {
-	req, err := c.newAuthenticatedRequest(http.MethodGet, fmt.Sprintf("/_search/pipeline/%s", searchPipelineName), nil)
+func (c *OpenSearchClient) getSearchPipeline(ctx context.Context, name string) (*searchPipelineResponse, error) {
+	req, err := c.newAuthenticatedRequest(http.MethodGet, fmt.Sprintf("/_search/pipeline/%s", name), nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
 	}
@@ -0,0 +1,343 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// retentionMetaKey is the index _meta entry holding a base's policy.
+	retentionMetaKey = "retention"
+	// retentionPage is how many expired sources one listing returns.
+	retentionPage = 500
+)
+
+var (
+	// retainPattern accepts a whole number of days, hours, or minutes.
+	retainPattern = regexp.MustCompile(`^[1-9][0-9]*[dhm]$`)
+	// maxSizePattern accepts a whole number of megabytes or gigabytes, with or
+	// without the trailing "b" ("500m", "5g", "5gb").
+	maxSizePattern = regexp.MustCompile(`^([1-9][0-9]*)([mg])b?$`)
+)
+
+// RetentionPolicy bounds how long a knowledge base keeps its sources and how
+// large it may grow. EnforceRetention forgets the sources past either limit,
+// chunks and metadata alike, and keeps the rest. Empty fields are unbounded.
+type RetentionPolicy struct {
+	// Retain is how long a source is kept after it was last ingested or
+	// updated, in days, hours, or minutes, e.g. "90d".
+	Retain string `json:"retain,omitempty"`
+	// MaxSize is the primary store size past which the oldest sources are
+	// forgotten, in megabytes or gigabytes, e.g. "5gb".
+	MaxSize string `json:"max_size,omitempty"`
+}
+
+// IsZero reports whether the policy sets no limit.
+func (p RetentionPolicy) IsZero() bool {
+	return p.Retain == "" && p.MaxSize == ""
+}
+
+// String renders the policy for display.
+func (p RetentionPolicy) String() string {
+	var parts []string
+	if p.Retain != "" {
+		parts = append(parts, "retain "+p.Retain)
+	}
+	if p.MaxSize != "" {
+		parts = append(parts, "max size "+p.MaxSize)
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ParseRetentionPolicy validates the --retain and --max-size flag values and
+// normalizes them to the units the policy is stored in. Either may be empty.
+func ParseRetentionPolicy(retain, maxSize string) (RetentionPolicy, error) {
+	var p RetentionPolicy
+	if retain = strings.ToLower(strings.TrimSpace(retain)); retain != "" {
+		if !retainPattern.MatchString(retain) {
+			return RetentionPolicy{}, fmt.Errorf("invalid retention %q: expected a number of days, hours, or minutes such as 90d", retain)
+		}
+		p.Retain = retain
+	}
+	if maxSize = strings.ToLower(strings.TrimSpace(maxSize)); maxSize != "" {
+		m := maxSizePattern.FindStringSubmatch(maxSize)
+		if m == nil {
+			return RetentionPolicy{}, fmt.Errorf("invalid max size %q: expected a number of megabytes or gigabytes such as 500m or 5g", maxSize)
+		}
+		p.MaxSize = m[1] + m[2] + "b"
+	}
+	return p, nil
+}
+
+// retainDuration returns how long p keeps a source, or 0 when unbounded.
+func (p RetentionPolicy) retainDuration() time.Duration {
+	if p.Retain == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(p.Retain[:len(p.Retain)-1])
+	if err != nil {
+		return 0
+	}
+	unit := map[byte]time.Duration{'d': 24 * time.Hour, 'h': time.Hour, 'm': time.Minute}[p.Retain[len(p.Retain)-1]]
+	return time.Duration(n) * unit
+}
+
+// maxSizeBytes returns p's size limit in bytes, or 0 when unbounded.
+func (p RetentionPolicy) maxSizeBytes() int64 {
+	m := maxSizePattern.FindStringSubmatch(p.MaxSize)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.ParseInt(m[1], 10, 64)
+	if m[2] == "g" {
+		return n << 30
+	}
+	return n << 20
+}
+
+// SetRetentionPolicy replaces the retention policy of a knowledge base index
+// with p, or removes it when p sets no limit. The policy is kept in the index
+// _meta, so it goes with the index.
+func (c *OpenSearchClient) SetRetentionPolicy(ctx context.Context, indexName string, p RetentionPolicy) error {
+	meta, err := c.getIndexMeta(ctx, indexName)
+	if err != nil {
+		return err
+	}
+	if p.IsZero() {
+		delete(meta, retentionMetaKey)
+	} else {
+		meta[retentionMetaKey] = p
+	}
+	if err := c.putMapping(ctx, indexName, map[string]any{"_meta": meta}); err != nil {
+		return fmt.Errorf("saving retention policy: %w", err)
+	}
+	return nil
+}
+
+// GetRetentionPolicy returns a knowledge base's retention policy, or a zero
+// policy when it has none.
+func (c *OpenSearchClient) GetRetentionPolicy(ctx context.Context, indexName string) (RetentionPolicy, error) {
+	meta, err := c.getIndexMeta(ctx, indexName)
+	if err != nil {
+		return RetentionPolicy{}, err
+	}
+	p, _ := retentionFromMeta(meta)
+	return p, nil
+}
+
+// retentionFromMeta reads the policy record out of an index _meta.
+func retentionFromMeta(meta map[string]any) (RetentionPolicy, bool) {
+	raw, ok := meta[retentionMetaKey]
+	if !ok {
+		return RetentionPolicy{}, false
+	}
+	// Round-trip through JSON to decode the generic map into the policy.
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return RetentionPolicy{}, false
+	}
+	var p RetentionPolicy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return RetentionPolicy{}, false
+	}
+	return p, true
+}
+
+// RetentionResult is what one EnforceRetention pass forgot.
+type RetentionResult struct {
+	// Sources are the forgotten source IDs, oldest first.
+	Sources []string
+	// Chunks counts their chunks, archived versions included.
+	Chunks int
+}
+
+// EnforceRetention applies a knowledge base's retention policy at now: it
+// forgets every source not ingested or updated within the policy's retention
+// period, then, while the base's live chunks are estimated to take more than
+// its size limit, the oldest remaining sources. A source is forgotten as
+// knowledge forget does it, chunks, archived versions, and metadata record
+// alike; the rest of the base is untouched.
+func (c *OpenSearchClient) EnforceRetention(ctx context.Context, indexName string, now time.Time) (RetentionResult, error) {
+	var result RetentionResult
+	p, err := c.GetRetentionPolicy(ctx, indexName)
+	if err != nil || p.IsZero() {
+		return result, err
+	}
+
+	if retain := p.retainDuration(); retain > 0 {
+		cutoff := now.Add(-retain).UTC().Format(DateFormat)
+		for {
+			expired, err := c.oldestSources(ctx, indexName, cutoff)
+			if err != nil {
+				return result, err
+			}
+			for _, meta := range expired {
+				if err := c.forgetForRetention(ctx, indexName, meta, &result); err != nil {
+					return result, err
+				}
+			}
+			if len(expired) < retentionPage {
+				break
+			}
+		}
+	}
+
+	if limit := p.maxSizeBytes(); limit > 0 {
+		if err := c.enforceMaxSize(ctx, indexName, limit, &result); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// enforceMaxSize forgets a base's oldest sources until its live chunks are
+// estimated to fit in limit bytes. Forgotten chunks keep their space until
+// OpenSearch merges them away, so the estimate counts the live chunks at the
+// store's bytes per chunk rather than the store size itself.
+func (c *OpenSearchClient) enforceMaxSize(ctx context.Context, indexName string, limit int64, result *RetentionResult) error {
+	stats, err := c.IndexStorageStats(ctx)
+	if err != nil {
+		return err
+	}
+	storage := stats[indexName]
+	chunks := storage.Docs + storage.DeletedDocs
+	if chunks == 0 {
+		return nil
+	}
+	perChunk := float64(storage.PrimaryBytes) / float64(chunks)
+	live := float64(storage.Docs) * perChunk
+
+	for live > float64(limit) {
+		oldest, err := c.oldestSources(ctx, indexName, "")
+		if err != nil {
+			return err
+		}
+		oldest = slices.DeleteFunc(oldest, func(m SourceMetadata) bool { return slices.Contains(result.Sources, m.SourceID) })
+		if len(oldest) == 0 {
+			return nil
+		}
+		for _, meta := range oldest {
+			if live <= float64(limit) {
+				break
+			}
+			before := result.Chunks
+			if err := c.forgetForRetention(ctx, indexName, meta, result); err != nil {
+				return err
+			}
+			live -= float64(result.Chunks-before) * perChunk
+		}
+	}
+	return nil
+}
+
+// oldestSources lists up to retentionPage of a base's sources, least recently
+// ingested or updated first, only those updated before cutoff unless it is
+// empty.
+func (c *OpenSearchClient) oldestSources(ctx context.Context, indexName, cutoff string) ([]SourceMetadata, error) {
+	filters := []any{map[string]any{"term": map[string]any{"index_name": indexName}}}
+	if cutoff != "" {
+		filters = append(filters, map[string]any{"range": map[string]any{"updated_at": map[string]any{"lt": cutoff}}})
+	}
+	body := map[string]any{
+		"query": map[string]any{"bool": map[string]any{"filter": filters}},
+		"sort":  []any{map[string]any{"updated_at": "asc"}, map[string]any{"source_id": "asc"}},
+		"size":  retentionPage,
+	}
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling search query: %w", err)
+	}
+
+	path := fmt.Sprintf("/%s/_search", sourcesIndexName)
+	req, err := c.newAuthenticatedRequest(http.MethodPost, path, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error listing source metadata: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("list source metadata failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var searchResp struct {
+		Hits struct {
+			Hits []struct {
+				Source SourceMetadata `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
+		return nil, fmt.Errorf("error decoding search response: %w", err)
+	}
+	sources := make([]SourceMetadata, 0, len(searchResp.Hits.Hits))
+	for _, hit := range searchResp.Hits.Hits {
+		sources = append(sources, hit.Source)
+	}
+	return sources, nil
+}
+
+// forgetForRetention forgets one source, its chunks, archived versions, and
+// metadata record, and adds it to result. The metadata goes last and with a
+// refresh, so a pass interrupted halfway lists the source again next time.
+func (c *OpenSearchClient) forgetForRetention(ctx context.Context, indexName string, meta SourceMetadata, result *RetentionResult) error {
+	deleted, err := c.deleteChunksBySourceID(ctx, indexName, meta.SourceID)
+	if err != nil {
+		return fmt.Errorf("forgetting %s: %w", meta.SourceID, err)
+	}
+	archived, err := c.DeleteArchivedChunks(ctx, &meta)
+	if err != nil {
+		return fmt.Errorf("forgetting %s: %w", meta.SourceID, err)
+	}
+	if err := c.deleteSourceMetadata(ctx, meta.SourceID); err != nil {
+		return fmt.Errorf("forgetting %s: %w", meta.SourceID, err)
+	}
+	if err := c.refreshIndex(ctx, sourcesIndexName); err != nil {
+		return err
+	}
+	result.Sources = append(result.Sources, meta.SourceID)
+	result.Chunks += deleted + archived
+	return nil
+}
+
+// EnforceRetentionAll runs EnforceRetention over every knowledge base, going
+// on past a base that fails, and returns what each pass forgot by index name
+// with the errors joined.
+func (c *OpenSearchClient) EnforceRetentionAll(ctx context.Context, now time.Time) (map[string]RetentionResult, error) {
+	indexes, err := c.ListIndexes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results := make(map[string]RetentionResult)
+	var errs []error
+	for _, idx := range indexes {
+		result, err := c.EnforceRetention(ctx, idx.Name, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", idx.Name, err))
+		}
+		if len(result.Sources) > 0 {
+			results[idx.Name] = result
+		}
+	}
+	return results, errors.Join(errs...)
+}
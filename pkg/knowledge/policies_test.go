@@ -0,0 +1,63 @@
+package knowledge
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRetentionPolicy(t *testing.T) {
+	cases := []struct {
+		retain, maxSize string
+		want            RetentionPolicy
+	}{
+		{"90d", "5g", RetentionPolicy{Retain: "90d", MaxSize: "5gb"}},
+		{" 12H ", "", RetentionPolicy{Retain: "12h"}},
+		{"", "500MB", RetentionPolicy{MaxSize: "500mb"}},
+		{"", "", RetentionPolicy{}},
+	}
+	for _, tc := range cases {
+		got, err := ParseRetentionPolicy(tc.retain, tc.maxSize)
+		if err != nil || got != tc.want {
+			t.Errorf("ParseRetentionPolicy(%q, %q) = (%+v, %v), want %+v", tc.retain, tc.maxSize, got, err, tc.want)
+		}
+	}
+
+	invalid := [][2]string{{"90", ""}, {"0d", ""}, {"3w", ""}, {"", "5"}, {"", "5k"}, {"", "1.5g"}}
+	for _, in := range invalid {
+		if _, err := ParseRetentionPolicy(in[0], in[1]); err == nil {
+			t.Errorf("ParseRetentionPolicy(%q, %q) = nil error, want error", in[0], in[1])
+		}
+	}
+}
+
+func TestRetentionPolicyLimits(t *testing.T) {
+	p := RetentionPolicy{Retain: "90d", MaxSize: "5gb"}
+	if got, want := p.retainDuration(), 90*24*time.Hour; got != want {
+		t.Errorf("retainDuration = %v, want %v", got, want)
+	}
+	if got, want := p.maxSizeBytes(), int64(5<<30); got != want {
+		t.Errorf("maxSizeBytes = %d, want %d", got, want)
+	}
+
+	p = RetentionPolicy{Retain: "12h", MaxSize: "500mb"}
+	if p.retainDuration() != 12*time.Hour || p.maxSizeBytes() != 500<<20 {
+		t.Errorf("limits of %+v = %v, %d", p, p.retainDuration(), p.maxSizeBytes())
+	}
+	if (RetentionPolicy{}).retainDuration() != 0 || (RetentionPolicy{}).maxSizeBytes() != 0 {
+		t.Error("a zero policy has limits")
+	}
+}
+
+func TestRetentionFromMeta(t *testing.T) {
+	meta := map[string]any{
+		"preset":         "faq",
+		retentionMetaKey: map[string]any{"retain": "30d", "max_size": "1gb"},
+	}
+	p, ok := retentionFromMeta(meta)
+	if want := (RetentionPolicy{Retain: "30d", MaxSize: "1gb"}); !ok || p != want {
+		t.Errorf("retentionFromMeta = %+v, %v; want %+v", p, ok, want)
+	}
+	if _, ok := retentionFromMeta(map[string]any{"preset": "faq"}); ok {
+		t.Error("retentionFromMeta found a policy in a _meta without one")
+	}
+}
@@ -0,0 +1,72 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultModelWaitTimeout bounds each wait on a model registration, deployment,
+// or ML task.
+const DefaultModelWaitTimeout = 5 * time.Minute
+
+// errPollTimeout is returned by poll when its own timeout elapses, as opposed to
+// the caller's context ending.
+var errPollTimeout = errors.New("timed out")
+
+// poller waits for a condition, backing off exponentially between checks so a
+// slow model deployment is not polled every two seconds for minutes.
+type poller struct {
+	initial time.Duration
+	max     time.Duration
+	timeout time.Duration
+}
+
+// parseModelTimeout reads the model wait timeout from a
+// knowledge.model.timeout value such as "10m". An empty value keeps
+// DefaultModelWaitTimeout.
+func parseModelTimeout(timeout string) (time.Duration, error) {
+	if timeout = strings.TrimSpace(timeout); timeout == "" {
+		return DefaultModelWaitTimeout, nil
+	}
+	d, err := time.ParseDuration(timeout)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid knowledge.model.timeout %q: expected a duration such as 10m", timeout)
+	}
+	return d, nil
+}
+
+// poll calls check until it reports done, sleeping between checks from
+// p.initial doubling up to p.max. An error from check ends the poll, except one
+// caused by the wait itself ending: check receives a context bounded by
+// p.timeout, so an in-flight request is cancelled with the wait, and poll then
+// returns ctx's error when ctx ended or errPollTimeout when p.timeout elapsed.
+func (p poller) poll(ctx context.Context, check func(context.Context) (bool, error)) error {
+	pollCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	delay := p.initial
+	for {
+		done, err := check(pollCtx)
+		if done {
+			return err
+		}
+		if err != nil && pollCtx.Err() == nil {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-pollCtx.Done():
+			timer.Stop()
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return errPollTimeout
+		case <-timer.C:
+		}
+		delay = min(delay*2, p.max)
+	}
+}
@@ -0,0 +1,61 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPollBacksOffUntilDone(t *testing.T) {
+	p := poller{initial: time.Millisecond, max: 4 * time.Millisecond, timeout: time.Second}
+	var calls []time.Time
+	err := p.poll(context.Background(), func(context.Context) (bool, error) {
+		calls = append(calls, time.Now())
+		return len(calls) == 5, nil
+	})
+	if err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	if len(calls) != 5 {
+		t.Fatalf("check called %d times, want 5", len(calls))
+	}
+	// Delays run 1, 2, 4, 4 ms: capped, never shorter than the schedule.
+	if total := calls[4].Sub(calls[0]); total < 11*time.Millisecond {
+		t.Errorf("polled for %v, want at least 11ms of backoff", total)
+	}
+}
+
+func TestPollStopsOnCheckError(t *testing.T) {
+	p := poller{initial: time.Millisecond, max: time.Millisecond, timeout: time.Second}
+	want := errors.New("model reached failed state")
+	err := p.poll(context.Background(), func(context.Context) (bool, error) { return false, want })
+	if !errors.Is(err, want) {
+		t.Errorf("poll = %v, want %v", err, want)
+	}
+}
+
+func TestPollTimeout(t *testing.T) {
+	p := poller{initial: time.Millisecond, max: time.Millisecond, timeout: 20 * time.Millisecond}
+	err := p.poll(context.Background(), func(context.Context) (bool, error) { return false, nil })
+	if !errors.Is(err, errPollTimeout) {
+		t.Errorf("poll = %v, want errPollTimeout", err)
+	}
+}
+
+func TestPollHonorsCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := poller{initial: time.Hour, max: time.Hour, timeout: time.Hour}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	err := p.poll(ctx, func(context.Context) (bool, error) { return false, nil })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("poll = %v, want context.Canceled", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("poll did not return promptly on cancellation")
+	}
+}
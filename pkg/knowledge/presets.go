@@ -0,0 +1,253 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jpnorenam/rag-snap/pkg/processing"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+)
+
+const (
+	// codeAnalyzer splits identifiers such as getOrCreateIndex or
+	// http_client.go into their words while keeping the original token, so a
+	// query matches either form.
+	codeAnalyzer = "rag_code"
+	// pathAnalyzer indexes a file path as each of its directory prefixes.
+	pathAnalyzer = "rag_path"
+)
+
+// Preset bundles the settings a knowledge base is created with for one kind
+// of content. The chunking is stored in the index _meta and applied to every
+// later ingest; the analyzer and mapping are fixed when the index is created.
+type Preset struct {
+	Name        string
+	Description string
+	Chunking    processing.ChunkOptions
+	// Analyzer is the content field's analyzer; "" keeps the standard one.
+	Analyzer string
+	// IndexFilePath stores each source's path on its chunks as file_path,
+	// matchable exactly or by directory prefix.
+	IndexFilePath bool
+}
+
+// Presets lists the presets knowledge create --preset accepts.
+var Presets = []Preset{
+	{
+		Name:        "docs",
+		Description: "Prose documentation: Markdown-aware chunks, English stemming",
+		Chunking:    processing.ChunkOptions{Size: 1024, Overlap: 200, Strategy: processing.ChunkStrategyMarkdown},
+		Analyzer:    "english",
+	},
+	{
+		Name:          "code",
+		Description:   "Source code: fenced code kept whole, identifiers split into words, file paths indexed",
+		Chunking:      processing.ChunkOptions{Size: 1536, Overlap: 100, Strategy: processing.ChunkStrategyCode},
+		Analyzer:      codeAnalyzer,
+		IndexFilePath: true,
+	},
+	{
+		Name:        "logs",
+		Description: "Logs and chat transcripts: small chunks of whole lines, no overlap",
+		Chunking:    processing.ChunkOptions{Size: 512, Strategy: processing.ChunkStrategyLines},
+	},
+}
+
+// LookupPreset returns the preset with the given name.
+func LookupPreset(name string) (Preset, error) {
+	var names []string
+	for _, p := range Presets {
+		if p.Name == name {
+			return p, nil
+		}
+		names = append(names, p.Name)
+	}
+	return Preset{}, fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// BaseSettings are the per-base settings ingest applies, from the preset the
+// base was created with; a base without one gets the defaults.
+type BaseSettings struct {
+	// Preset is the preset's name, or "" for none.
+	Preset        string
+	Chunking      processing.ChunkOptions
+	IndexFilePath bool
+}
+
+// presetMeta is the preset record kept in the index mapping _meta.
+type presetMeta struct {
+	Name          string `json:"name"`
+	Strategy      string `json:"chunk_strategy"`
+	ChunkSize     int    `json:"chunk_size"`
+	ChunkOverlap  int    `json:"chunk_overlap"`
+	IndexFilePath bool   `json:"index_file_path,omitempty"`
+}
+
+// buildPresetIndexBody constructs the create index body for a preset. Its
+// mapping and settings are merged over the shared index template's.
+func buildPresetIndexBody(p Preset) map[string]any {
+	properties := map[string]any{}
+	analysis := map[string]any{}
+	analyzers := map[string]any{}
+
+	if p.Analyzer != "" {
+		properties["content"] = map[string]any{"type": "text", "analyzer": p.Analyzer}
+	}
+	if p.Analyzer == codeAnalyzer {
+		analysis["filter"] = map[string]any{
+			"rag_code_split": map[string]any{
+				"type":              "word_delimiter",
+				"preserve_original": true,
+			},
+		}
+		analyzers[codeAnalyzer] = map[string]any{
+			"type":      "custom",
+			"tokenizer": "whitespace",
+			"filter":    []string{"rag_code_split", "lowercase"},
+		}
+	}
+	if p.IndexFilePath {
+		properties["file_path"] = map[string]any{
+			"type": "keyword",
+			"fields": map[string]any{
+				"tree": map[string]any{"type": "text", "analyzer": pathAnalyzer},
+			},
+		}
+		analysis["tokenizer"] = map[string]any{
+			pathAnalyzer: map[string]any{"type": "path_hierarchy"},
+		}
+		analyzers[pathAnalyzer] = map[string]any{"type": "custom", "tokenizer": pathAnalyzer}
+	}
+
+	body := map[string]any{
+		"mappings": map[string]any{
+			"_meta": map[string]any{
+				"preset": presetMeta{
+					Name:          p.Name,
+					Strategy:      p.Chunking.Strategy,
+					ChunkSize:     p.Chunking.Size,
+					ChunkOverlap:  p.Chunking.Overlap,
+					IndexFilePath: p.IndexFilePath,
+				},
+			},
+			"properties": properties,
+		},
+	}
+	if len(analyzers) > 0 {
+		analysis["analyzer"] = analyzers
+		body["settings"] = map[string]any{
+			"index": map[string]any{"analysis": analysis},
+		}
+	}
+	return body
+}
+
+// CreateIndexWithPreset creates indexName configured by preset. Unlike
+// CreateIndex it fails when the index already exists: an existing index's
+// analyzers cannot be changed.
+func (c *OpenSearchClient) CreateIndexWithPreset(ctx context.Context, indexName string, preset Preset) error {
+	existsResp, err := c.client.Client.Do(ctx, opensearchapi.IndicesExistsReq{Indices: []string{indexName}}, nil)
+	if err != nil {
+		return fmt.Errorf("error checking if index exists: %w", err)
+	}
+	existsResp.Body.Close()
+	if existsResp.StatusCode == http.StatusOK {
+		return fmt.Errorf("index %s already exists; a preset can only be applied when the base is created", indexName)
+	}
+
+	bodyBytes, err := json.Marshal(buildPresetIndexBody(preset))
+	if err != nil {
+		return fmt.Errorf("error marshaling create index body: %w", err)
+	}
+	resp, err := c.client.Client.Do(
+		ctx,
+		opensearchapi.IndicesCreateReq{
+			Index: indexName,
+			Body:  bytes.NewReader(bodyBytes),
+		},
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("error creating index: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("create index request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// GetBaseSettings returns the settings ingest applies to the base stored in
+// indexName.
+func (c *OpenSearchClient) GetBaseSettings(ctx context.Context, indexName string) (BaseSettings, error) {
+	meta, err := c.getIndexMeta(ctx, indexName)
+	if err != nil {
+		return BaseSettings{}, err
+	}
+	return baseSettingsFromMeta(meta), nil
+}
+
+// baseSettingsFromMeta reads the preset record out of an index _meta,
+// falling back to the defaults for a base without one.
+func baseSettingsFromMeta(meta map[string]any) BaseSettings {
+	settings := BaseSettings{Chunking: processing.DefaultChunkOptions()}
+	raw, ok := meta["preset"]
+	if !ok {
+		return settings
+	}
+	// Round-trip through JSON to decode the generic map into presetMeta.
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return settings
+	}
+	var pm presetMeta
+	if err := json.Unmarshal(data, &pm); err != nil || pm.ChunkSize <= 0 {
+		return settings
+	}
+	settings.Preset = pm.Name
+	settings.Chunking = processing.ChunkOptions{Size: pm.ChunkSize, Overlap: pm.ChunkOverlap, Strategy: pm.Strategy}
+	settings.IndexFilePath = pm.IndexFilePath
+	return settings
+}
+
+// getIndexMeta returns the index mapping's _meta object, empty when unset.
+func (c *OpenSearchClient) getIndexMeta(ctx context.Context, indexName string) (map[string]any, error) {
+	path := fmt.Sprintf("/%s/_mapping", indexName)
+	req, err := c.newAuthenticatedRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating mapping request: %w", err)
+	}
+
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("getting index mapping: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("get mapping failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var mappingResp map[string]struct {
+		Mappings struct {
+			Meta map[string]any `json:"_meta"`
+		} `json:"mappings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&mappingResp); err != nil {
+		return nil, fmt.Errorf("decoding mapping response: %w", err)
+	}
+	for _, m := range mappingResp {
+		if m.Mappings.Meta != nil {
+			return m.Mappings.Meta, nil
+		}
+	}
+	return map[string]any{}, nil
+}
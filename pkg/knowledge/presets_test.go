@@ -0,0 +1,68 @@
+package knowledge
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jpnorenam/rag-snap/pkg/processing"
+)
+
+func TestLookupPreset(t *testing.T) {
+	for _, name := range []string{"docs", "code", "logs"} {
+		p, err := LookupPreset(name)
+		if err != nil || p.Name != name {
+			t.Errorf("LookupPreset(%q) = (%q, %v)", name, p.Name, err)
+		}
+	}
+	if _, err := LookupPreset("chat"); err == nil {
+		t.Error("LookupPreset(\"chat\") = nil error, want error")
+	}
+}
+
+func TestBuildPresetIndexBody(t *testing.T) {
+	code, _ := LookupPreset("code")
+	body := buildPresetIndexBody(code)
+
+	props := body["mappings"].(map[string]any)["properties"].(map[string]any)
+	if got := props["content"].(map[string]any)["analyzer"]; got != codeAnalyzer {
+		t.Errorf("content analyzer = %v, want %s", got, codeAnalyzer)
+	}
+	if _, ok := props["file_path"]; !ok {
+		t.Error("code preset does not map file_path")
+	}
+	analysis := body["settings"].(map[string]any)["index"].(map[string]any)["analysis"].(map[string]any)
+	analyzers := analysis["analyzer"].(map[string]any)
+	for _, name := range []string{codeAnalyzer, pathAnalyzer} {
+		if _, ok := analyzers[name]; !ok {
+			t.Errorf("analyzer %s not defined", name)
+		}
+	}
+
+	logs, _ := LookupPreset("logs")
+	body = buildPresetIndexBody(logs)
+	if _, ok := body["settings"]; ok {
+		t.Error("logs preset defines analysis settings, want none")
+	}
+}
+
+func TestBaseSettingsFromMeta(t *testing.T) {
+	if got := baseSettingsFromMeta(map[string]any{"default_label": "canonical"}); got.Preset != "" || got.Chunking != processing.DefaultChunkOptions() {
+		t.Errorf("settings without a preset = %+v, want defaults", got)
+	}
+
+	// Decode the stored _meta as it comes back from a mapping request.
+	code, _ := LookupPreset("code")
+	data, err := json.Marshal(buildPresetIndexBody(code)["mappings"].(map[string]any)["_meta"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta map[string]any
+	if err := json.Unmarshal(data, &meta); err != nil {
+		t.Fatal(err)
+	}
+	got := baseSettingsFromMeta(meta)
+	want := BaseSettings{Preset: "code", Chunking: code.Chunking, IndexFilePath: true}
+	if got != want {
+		t.Errorf("baseSettingsFromMeta = %+v, want %+v", got, want)
+	}
+}
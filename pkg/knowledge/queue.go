@@ -0,0 +1,475 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/jpnorenam/rag-snap/pkg/processing"
+	"github.com/jpnorenam/rag-snap/pkg/progress"
+	opensearchapi "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+)
+
+// queueIndexName holds the ingest queue: batch jobs waiting for, or taken by,
+// a knowledge worker. It is outside the knowledge base prefix, so it never
+// shows up as a base.
+var queueIndexName = artifactName("ingest-queue")
+
+// Queued job states. A job moves from queued to running when a worker takes
+// it, and from there to completed, failed, or cancelled. A worker that stops
+// mid-job puts the job back to queued.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// Worker defaults.
+const (
+	// DefaultWorkerInterval is how long an idle worker waits before looking
+	// at the queue again.
+	DefaultWorkerInterval = 10 * time.Second
+	// queueListLimit is the most jobs one queue listing returns.
+	queueListLimit = 1000
+	// jobCancelPoll is how often a worker checks whether its running job has
+	// been cancelled.
+	jobCancelPoll = 5 * time.Second
+)
+
+// errJobChanged reports that a queued job was changed by someone else between
+// reading and writing it.
+var errJobChanged = errors.New("job changed concurrently")
+
+// QueuedJob is one batch job in the ingest queue.
+type QueuedJob struct {
+	ID  string   `json:"-"`
+	Job BatchJob `json:"job"`
+	// Force re-ingests sources that are already completed, as ingest --force.
+	Force      bool   `json:"force,omitempty"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	QueuedAt   string `json:"queued_at"`
+	StartedAt  string `json:"started_at,omitempty"`
+	FinishedAt string `json:"finished_at,omitempty"`
+
+	// seqNo and primaryTerm are the version the job was read at, so a write
+	// based on it fails if another process changed the job meanwhile.
+	seqNo       int64
+	primaryTerm int64
+}
+
+// Finished reports whether the job reached a final state.
+func (j QueuedJob) Finished() bool {
+	return j.Status == JobCompleted || j.Status == JobFailed || j.Status == JobCancelled
+}
+
+// getOrCreateQueueIndex creates the queue index if it does not exist.
+func (c *OpenSearchClient) getOrCreateQueueIndex(ctx context.Context) error {
+	resp, err := c.client.Client.Do(ctx, opensearchapi.IndicesExistsReq{Indices: []string{queueIndexName}}, nil)
+	if err != nil {
+		return fmt.Errorf("error checking if queue index exists: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	bodyBytes, err := json.Marshal(buildQueueIndexBody())
+	if err != nil {
+		return fmt.Errorf("error marshaling queue index body: %w", err)
+	}
+	createResp, err := c.client.Client.Do(
+		ctx,
+		opensearchapi.IndicesCreateReq{
+			Index: queueIndexName,
+			Body:  bytes.NewReader(bodyBytes),
+		},
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("error creating queue index: %w", err)
+	}
+	defer createResp.Body.Close()
+
+	if createResp.StatusCode != http.StatusOK && createResp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(createResp.Body)
+		return fmt.Errorf("create queue index failed with status %d: %s", createResp.StatusCode, string(body))
+	}
+	return nil
+}
+
+func buildQueueIndexBody() map[string]any {
+	date := map[string]any{"type": "date", "format": "yyyy-MM-dd HH:mm:ss"}
+	return map[string]any{
+		"settings": map[string]any{
+			"index": map[string]any{
+				"number_of_shards":   "1",
+				"number_of_replicas": "1",
+			},
+		},
+		"mappings": map[string]any{
+			"properties": map[string]any{
+				// The job is only ever read back whole.
+				"job":         map[string]any{"type": "object", "enabled": false},
+				"force":       map[string]any{"type": "boolean"},
+				"status":      map[string]any{"type": "keyword"},
+				"error":       map[string]any{"type": "text", "index": false},
+				"queued_at":   date,
+				"started_at":  date,
+				"finished_at": date,
+			},
+		},
+	}
+}
+
+// QueueJobs validates jobs as ProcessBatch would and adds them to the ingest
+// queue, for a knowledge worker to ingest in the background. File sources are
+// recorded as absolute paths, since the worker runs elsewhere. With
+// opts.CreateMissing, missing target bases are created now; opts.DryRun is
+// ignored.
+func (c *OpenSearchClient) QueueJobs(ctx context.Context, jobs []BatchJob, opts BatchOptions) ([]QueuedJob, error) {
+	for i, job := range jobs {
+		if job.Type != "file" {
+			continue
+		}
+		path, err := filepath.Abs(job.Source)
+		if err != nil {
+			return nil, fmt.Errorf("resolving path: %w", err)
+		}
+		jobs[i].Source = path
+	}
+
+	missing, err := validateBatch(ctx, c, jobs, opts.CreateMissing)
+	if err != nil {
+		return nil, err
+	}
+	for _, kb := range missing {
+		if err := c.CreateIndex(ctx, FullIndexName(kb)); err != nil {
+			return nil, fmt.Errorf("creating knowledge base '%s': %w", kb, err)
+		}
+		progress.Infof("Created knowledge base '%s'\n", kb)
+	}
+
+	if err := c.getOrCreateQueueIndex(ctx); err != nil {
+		return nil, fmt.Errorf("ensuring queue index: %w", err)
+	}
+	queued := make([]QueuedJob, 0, len(jobs))
+	for _, job := range jobs {
+		id, err := newJobID()
+		if err != nil {
+			return queued, fmt.Errorf("generating job id: %w", err)
+		}
+		qj := QueuedJob{ID: id, Job: job, Force: opts.Force, Status: JobQueued, QueuedAt: now()}
+		if err := c.writeQueuedJob(ctx, &qj, false); err != nil {
+			return queued, err
+		}
+		queued = append(queued, qj)
+	}
+	return queued, nil
+}
+
+// ListQueuedJobs returns the jobs in the ingest queue, oldest first, keeping
+// only those in one of statuses when any are given. It returns none before
+// anything has been queued.
+func (c *OpenSearchClient) ListQueuedJobs(ctx context.Context, statuses ...string) ([]QueuedJob, error) {
+	return c.searchQueuedJobs(ctx, buildQueueListBody(statuses, queueListLimit))
+}
+
+// CancelQueuedJob cancels a job that has not finished. A queued job is never
+// started; a running job's worker stops it within a few seconds and removes
+// what it had indexed of the source in progress.
+func (c *OpenSearchClient) CancelQueuedJob(ctx context.Context, id string) (*QueuedJob, error) {
+	job, err := c.getQueuedJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Finished() {
+		return nil, fmt.Errorf("job %s already %s", id, job.Status)
+	}
+	job.Status = JobCancelled
+	job.FinishedAt = now()
+	if err := c.writeQueuedJob(ctx, job, true); err != nil {
+		if errors.Is(err, errJobChanged) {
+			return nil, fmt.Errorf("job %s changed while cancelling it; try again", id)
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+// WorkerOptions controls RunWorker.
+type WorkerOptions struct {
+	// Interval is how long to wait before looking at an empty queue again;
+	// 0 is DefaultWorkerInterval.
+	Interval time.Duration
+	// Once drains the queue and returns instead of waiting for more jobs.
+	Once bool
+}
+
+// RunWorker takes queued jobs one at a time, oldest first, and ingests each
+// as a batch job, until ctx is done. Several workers may share a queue: a job
+// is taken by writing it as running at the version it was read, which fails
+// for all but one of them. A job interrupted because ctx is done goes back to
+// the queue for the next worker.
+func RunWorker(ctx context.Context, client *OpenSearchClient, tika *processing.TikaClient, opts WorkerOptions) error {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = DefaultWorkerInterval
+	}
+	for {
+		job, err := client.claimQueuedJob(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err != nil && opts.Once:
+			return fmt.Errorf("reading the ingest queue: %w", err)
+		case err != nil:
+			progress.Failed("Reading the ingest queue: %v\n", err)
+		case job != nil:
+			client.runQueuedJob(ctx, tika, job)
+			continue
+		case opts.Once:
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// runQueuedJob ingests a claimed job and records how it ended.
+func (c *OpenSearchClient) runQueuedJob(ctx context.Context, tika *processing.TikaClient, job *QueuedJob) {
+	progress.Infof("[%s] Processing: %s\n", job.ID, job.Job.Source)
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go c.watchCancellation(jobCtx, cancel, job.ID)
+
+	err := processSingleJob(jobCtx, c, tika, job.Job, job.Force)
+
+	// Record the outcome with a fresh context: the job's may be done.
+	recordCtx, stop := context.WithTimeout(context.Background(), abandonTimeout)
+	defer stop()
+	current, getErr := c.getQueuedJob(recordCtx, job.ID)
+	switch {
+	case getErr == nil && current.Status == JobCancelled:
+		progress.Printf("[%s] Cancelled: %s\n", job.ID, job.Job.Source)
+		return
+	case getErr == nil:
+		job = current
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		job.Status, job.StartedAt = JobQueued, ""
+		progress.Printf("[%s] Interrupted, back in the queue: %s\n", job.ID, job.Job.Source)
+	case err != nil:
+		job.Status, job.Error, job.FinishedAt = JobFailed, err.Error(), now()
+		progress.Failed("[%s] Error processing %s: %v\n", job.ID, job.Job.Source, err)
+	default:
+		job.Status, job.FinishedAt = JobCompleted, now()
+		progress.Succeeded("[%s] Success: %s\n", job.ID, job.Job.Source)
+	}
+	if err := c.writeQueuedJob(recordCtx, job, getErr == nil); err != nil {
+		progress.Failed("[%s] Recording the job's outcome: %v\n", job.ID, err)
+	}
+}
+
+// watchCancellation cancels a running job's context once the job is
+// cancelled in the queue, until ctx is done.
+func (c *OpenSearchClient) watchCancellation(ctx context.Context, cancel context.CancelFunc, id string) {
+	ticker := time.NewTicker(jobCancelPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			job, err := c.getQueuedJob(ctx, id)
+			if err == nil && job.Status == JobCancelled {
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// claimQueuedJob takes the oldest queued job by marking it running, skipping
+// jobs another worker took first. It returns nil when nothing is queued.
+func (c *OpenSearchClient) claimQueuedJob(ctx context.Context) (*QueuedJob, error) {
+	jobs, err := c.searchQueuedJobs(ctx, buildQueueListBody([]string{JobQueued}, 10))
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs {
+		job := &jobs[i]
+		job.Status, job.StartedAt = JobRunning, now()
+		err := c.writeQueuedJob(ctx, job, true)
+		if errors.Is(err, errJobChanged) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return job, nil
+	}
+	return nil, nil
+}
+
+// getQueuedJob reads one job, with the version it was read at.
+func (c *OpenSearchClient) getQueuedJob(ctx context.Context, id string) (*QueuedJob, error) {
+	path := fmt.Sprintf("/%s/_doc/%s", queueIndexName, url.PathEscape(id))
+	req, err := c.newAuthenticatedRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error reading queued job: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("no queued job %s", id)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("read queued job failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var doc struct {
+		SeqNo       int64     `json:"_seq_no"`
+		PrimaryTerm int64     `json:"_primary_term"`
+		Source      QueuedJob `json:"_source"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("error decoding queued job: %w", err)
+	}
+	job := doc.Source
+	job.ID, job.seqNo, job.primaryTerm = id, doc.SeqNo, doc.PrimaryTerm
+	return &job, nil
+}
+
+// searchQueuedJobs runs a search of the queue index and returns its hits as
+// jobs, each with the version it was read at.
+func (c *OpenSearchClient) searchQueuedJobs(ctx context.Context, body map[string]any) ([]QueuedJob, error) {
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling queue query: %w", err)
+	}
+	req, err := c.newAuthenticatedRequest(http.MethodPost, "/"+queueIndexName+"/_search", bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error searching the queue: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("queue search failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var searchResp struct {
+		Hits struct {
+			Hits []struct {
+				ID          string    `json:"_id"`
+				SeqNo       int64     `json:"_seq_no"`
+				PrimaryTerm int64     `json:"_primary_term"`
+				Source      QueuedJob `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
+		return nil, fmt.Errorf("error decoding queue response: %w", err)
+	}
+	jobs := make([]QueuedJob, 0, len(searchResp.Hits.Hits))
+	for _, hit := range searchResp.Hits.Hits {
+		job := hit.Source
+		job.ID, job.seqNo, job.primaryTerm = hit.ID, hit.SeqNo, hit.PrimaryTerm
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// buildQueueListBody constructs a search for up to size jobs in one of
+// statuses (any, when none are given), oldest first.
+func buildQueueListBody(statuses []string, size int) map[string]any {
+	query := map[string]any{"match_all": map[string]any{}}
+	if len(statuses) > 0 {
+		query = map[string]any{"terms": map[string]any{"status": statuses}}
+	}
+	return map[string]any{
+		"size":                size,
+		"query":               query,
+		"sort":                []map[string]any{{"queued_at": map[string]any{"order": "asc"}}},
+		"seq_no_primary_term": true,
+	}
+}
+
+// writeQueuedJob stores job under its id, visible to searches on return. With
+// ifUnchanged, the write only succeeds if the job is still at the version it
+// was read at, and errJobChanged is returned otherwise.
+func (c *OpenSearchClient) writeQueuedJob(ctx context.Context, job *QueuedJob, ifUnchanged bool) error {
+	bodyBytes, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("error marshaling queued job: %w", err)
+	}
+	path := fmt.Sprintf("/%s/_doc/%s?refresh=wait_for", queueIndexName, url.PathEscape(job.ID))
+	if ifUnchanged {
+		path += fmt.Sprintf("&if_seq_no=%d&if_primary_term=%d", job.seqNo, job.primaryTerm)
+	}
+	req, err := c.newAuthenticatedRequest(http.MethodPut, path, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("error writing queued job: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		return errJobChanged
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("write queued job failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var written struct {
+		SeqNo       int64 `json:"_seq_no"`
+		PrimaryTerm int64 `json:"_primary_term"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&written); err == nil {
+		job.seqNo, job.primaryTerm = written.SeqNo, written.PrimaryTerm
+	}
+	return nil
+}
+
+// newJobID returns a short random id for a queued job.
+func newJobID() (string, error) {
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
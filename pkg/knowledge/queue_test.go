@@ -0,0 +1,56 @@
+package knowledge
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestBuildQueueListBody(t *testing.T) {
+	body := buildQueueListBody([]string{JobQueued}, 10)
+	want := map[string]any{
+		"size":                10,
+		"query":               map[string]any{"terms": map[string]any{"status": []string{JobQueued}}},
+		"sort":                []map[string]any{{"queued_at": map[string]any{"order": "asc"}}},
+		"seq_no_primary_term": true,
+	}
+	if !reflect.DeepEqual(body, want) {
+		t.Errorf("buildQueueListBody = %v, want %v", body, want)
+	}
+
+	if q := buildQueueListBody(nil, 10)["query"]; !reflect.DeepEqual(q, map[string]any{"match_all": map[string]any{}}) {
+		t.Errorf("query without statuses = %v, want match_all", q)
+	}
+}
+
+func TestQueuedJobJSON(t *testing.T) {
+	job := QueuedJob{
+		ID:       "3f9a1c02",
+		Job:      BatchJob{Name: "install", Type: "file", Source: "/srv/install.pdf", TargetKB: "manuals", Metadata: map[string]string{"team": "ops"}},
+		Status:   JobQueued,
+		QueuedAt: "2026-10-16 09:12:44",
+		seqNo:    3,
+	}
+	b, err := json.Marshal(job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got QueuedJob
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	job.ID, job.seqNo = "", 0 // kept by OpenSearch, not in the document
+	if !reflect.DeepEqual(got, job) {
+		t.Errorf("round trip = %+v, want %+v", got, job)
+	}
+}
+
+func TestQueuedJobFinished(t *testing.T) {
+	for status, want := range map[string]bool{
+		JobQueued: false, JobRunning: false, JobCompleted: true, JobFailed: true, JobCancelled: true,
+	} {
+		if got := (QueuedJob{Status: status}).Finished(); got != want {
+			t.Errorf("Finished() with status %s = %v, want %v", status, got, want)
+		}
+	}
+}
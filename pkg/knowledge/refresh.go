@@ -0,0 +1,126 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jpnorenam/rag-snap/pkg/processing"
+)
+
+// RefreshOutcome is what RefreshSource did with a source.
+type RefreshOutcome string
+
+const (
+	RefreshUnchanged RefreshOutcome = "unchanged"
+	RefreshUpdated   RefreshOutcome = "updated"
+	RefreshFailed    RefreshOutcome = "failed"
+)
+
+// RefreshResult reports the refresh of one URL source.
+type RefreshResult struct {
+	SourceID string
+	URL      string
+	Outcome  RefreshOutcome
+	// Reason says how an unchanged source was found unchanged.
+	Reason string
+	// Chunks is the source's chunk count after an update.
+	Chunks int
+	Err    error
+}
+
+// IsURLSource reports whether the source was ingested from a web page, whose
+// URL it keeps as its FilePath.
+func IsURLSource(meta SourceMetadata) bool {
+	return strings.HasPrefix(meta.FilePath, "http://") || strings.HasPrefix(meta.FilePath, "https://")
+}
+
+// RefreshTargets returns the URL sources of indexName to refresh: all of them,
+// or only sourceID when it is given, which must be a URL source of the base.
+func (c *OpenSearchClient) RefreshTargets(ctx context.Context, indexName, sourceID string) ([]SourceMetadata, error) {
+	if sourceID != "" {
+		meta, err := c.GetSourceMetadata(ctx, sourceID)
+		if err != nil {
+			return nil, err
+		}
+		if meta.IndexName != indexName {
+			return nil, fmt.Errorf("%w: %q is not in this knowledge base", ErrSourceNotFound, sourceID)
+		}
+		if !IsURLSource(*meta) {
+			return nil, fmt.Errorf("source %q was not ingested from a URL", sourceID)
+		}
+		return []SourceMetadata{*meta}, nil
+	}
+
+	sources, err := c.ListSourceMetadata(ctx, indexName)
+	if err != nil {
+		return nil, err
+	}
+	var targets []SourceMetadata
+	for _, meta := range sources {
+		if IsURLSource(meta) {
+			targets = append(targets, meta)
+		}
+	}
+	return targets, nil
+}
+
+// RefreshSource re-fetches the page of a URL source and re-ingests it only if
+// it changed: the server is asked for the page only if it changed since the
+// stored ETag and Last-Modified, and a page it sends anyway is compared with
+// the stored checksum. A changed page replaces the source's chunks in one
+// swap (see IngestOptions.Swap), keeping its label, tags, and ingest time.
+func (c *OpenSearchClient) RefreshSource(ctx context.Context, tika *processing.TikaClient, meta SourceMetadata) RefreshResult {
+	result := RefreshResult{SourceID: meta.SourceID, URL: meta.FilePath}
+	fail := func(err error) RefreshResult {
+		result.Outcome, result.Err = RefreshFailed, err
+		return result
+	}
+
+	path, web, cleanup, err := processing.RecrawlPage(ctx, c.settings.Temp, meta.FilePath, meta.ETag, meta.LastModified)
+	if errors.Is(err, processing.ErrNotModified) {
+		result.Outcome, result.Reason = RefreshUnchanged, "not modified since the last fetch"
+		return result
+	}
+	if err != nil {
+		return fail(err)
+	}
+	defer cleanup()
+
+	checksum, err := processing.FileChecksum(path)
+	if err != nil {
+		return fail(err)
+	}
+	if checksum == meta.Checksum && meta.Status == StatusCompleted {
+		result.Outcome, result.Reason = RefreshUnchanged, "content unchanged"
+		if web.ETag != meta.ETag || web.LastModified != meta.LastModified {
+			// Best-effort: without the new validators the next refresh
+			// downloads the page again, and finds it unchanged again.
+			meta.ETag, meta.LastModified = web.ETag, web.LastModified
+			_ = c.IndexSourceMetadata(ctx, meta)
+		}
+		return result
+	}
+
+	indexed, err := NewIngestor(c, tika).Ingest(ctx, IngestOptions{
+		FilePath:     path,
+		SourceID:     meta.SourceID,
+		MetadataPath: meta.FilePath,
+		TargetIndex:  meta.IndexName,
+		Label:        meta.Label,
+		Tags:         meta.Tags,
+		Force:        true,
+		Swap:         true,
+		Title:        web.Title,
+		Author:       web.Author,
+		ETag:         web.ETag,
+		LastModified: web.LastModified,
+		Trigger:      TriggerRefresh,
+	})
+	if err != nil {
+		return fail(err)
+	}
+	result.Outcome, result.Chunks = RefreshUpdated, indexed.Indexed
+	return result
+}
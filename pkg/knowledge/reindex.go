@@ -0,0 +1,234 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ReindexResult reports a knowledge base rebuilt by Reindex.
+type ReindexResult struct {
+	// FromDimension and ToDimension are the embedding sizes the index was
+	// mapped for before and after.
+	FromDimension int
+	ToDimension   int
+	// ModelID is the model that re-embedded the chunks.
+	ModelID string
+	// Chunks is how many chunks were re-embedded.
+	Chunks int
+}
+
+// reindexPrivateSettings are the index settings OpenSearch sets itself, by
+// prefix; they are left out when an index is recreated from another's.
+var reindexPrivateSettings = []string{
+	"index.uuid",
+	"index.creation_date",
+	"index.provided_name",
+	"index.version.",
+	"index.history.",
+	"index.resize.",
+	"index.blocks.",
+}
+
+// Reindex rebuilds the index of a knowledge base for the embedding dimension
+// of the model its ingest pipeline runs, re-embedding every chunk with it, so
+// a base whose pipeline was pointed at a model of another size can be
+// ingested into again. The chunks are first re-embedded into a temporary
+// index; the base's index is only deleted once that succeeded, then recreated
+// with the same settings, mapping, and _meta, and filled from the temporary
+// one. Should that last step fail, the error names the temporary index, which
+// still holds every chunk.
+func (c *OpenSearchClient) Reindex(ctx context.Context, indexName string) (ReindexResult, error) {
+	dims, err := c.CheckEmbeddingDimension(ctx, indexName)
+	if err != nil && !errors.Is(err, ErrEmbeddingDimensionMismatch) {
+		return ReindexResult{}, err
+	}
+	if dims.ModelDimension == 0 {
+		return ReindexResult{}, fmt.Errorf("cannot tell what embedding dimension the ingest pipeline of %s produces: "+
+			"it has no text_embedding processor, or its model records no dimension", indexName)
+	}
+	result := ReindexResult{FromDimension: dims.IndexDimension, ToDimension: dims.ModelDimension, ModelID: dims.ModelID}
+
+	def, err := c.getIndexDefinition(ctx, indexName)
+	if err != nil {
+		return result, err
+	}
+	body := buildReindexIndexBody(def, dims.ModelDimension)
+
+	kb, _ := KnowledgeBaseNameFromIndex(indexName)
+	temp := artifactName("reindex-" + kb)
+	// The temporary index is named outside the knowledge base pattern, so it
+	// neither takes the index template nor joins the search alias.
+	tempBody := map[string]any{"settings": body["settings"], "mappings": body["mappings"]}
+	if err := c.createIndexWithBody(ctx, temp, tempBody); err != nil {
+		return result, err
+	}
+	if err := withProgress("Re-embedding chunks", func() error {
+		result.Chunks, err = c.reindexInto(ctx, indexName, temp, dims.Pipeline, true)
+		return err
+	}); err != nil {
+		_ = c.DeleteIndex(context.Background(), temp)
+		return result, err
+	}
+
+	kept := fmt.Errorf("the re-embedded chunks are kept in %s", temp)
+	if err := c.DeleteIndex(ctx, indexName); err != nil {
+		_ = c.DeleteIndex(context.Background(), temp)
+		return result, err
+	}
+	if err := c.createIndexWithBody(ctx, indexName, body); err != nil {
+		return result, fmt.Errorf("recreating %s: %w; %w", indexName, err, kept)
+	}
+	if err := withProgress("Restoring the knowledge base", func() error {
+		_, err := c.reindexInto(ctx, temp, indexName, "_none", false)
+		return err
+	}); err != nil {
+		return result, fmt.Errorf("filling %s: %w; %w", indexName, err, kept)
+	}
+	_ = c.DeleteIndex(ctx, temp)
+	return result, nil
+}
+
+// indexDefinition is an index as GET /{index}?flat_settings=true returns it.
+type indexDefinition struct {
+	Aliases  map[string]any    `json:"aliases"`
+	Mappings map[string]any    `json:"mappings"`
+	Settings map[string]string `json:"settings"`
+}
+
+// getIndexDefinition reads the aliases, mapping, and settings of indexName.
+func (c *OpenSearchClient) getIndexDefinition(ctx context.Context, indexName string) (indexDefinition, error) {
+	req, err := c.newAuthenticatedRequest(http.MethodGet, "/"+indexName+"?flat_settings=true", nil)
+	if err != nil {
+		return indexDefinition{}, fmt.Errorf("error creating request: %w", err)
+	}
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return indexDefinition{}, fmt.Errorf("error getting index: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return indexDefinition{}, statusError("get index", resp)
+	}
+	var defs map[string]indexDefinition
+	if err := json.NewDecoder(resp.Body).Decode(&defs); err != nil {
+		return indexDefinition{}, fmt.Errorf("error decoding index: %w", err)
+	}
+	def, ok := defs[indexName]
+	if !ok {
+		return indexDefinition{}, fmt.Errorf("%w: %s", ErrIndexNotFound, indexName)
+	}
+	return def, nil
+}
+
+// buildReindexIndexBody constructs the create index body recreating def with
+// its embedding field mapped for dimension, leaving out the settings
+// OpenSearch sets itself.
+func buildReindexIndexBody(def indexDefinition, dimension int) map[string]any {
+	settings := map[string]string{}
+	for key, value := range def.Settings {
+		private := false
+		for _, prefix := range reindexPrivateSettings {
+			private = private || strings.HasPrefix(key, prefix)
+		}
+		if !private {
+			settings[key] = value
+		}
+	}
+
+	mappings := map[string]any{}
+	for key, value := range def.Mappings {
+		mappings[key] = value
+	}
+	properties := map[string]any{}
+	if props, ok := def.Mappings["properties"].(map[string]any); ok {
+		for key, value := range props {
+			properties[key] = value
+		}
+	}
+	embedding := map[string]any{}
+	if field, ok := properties["embedding"].(map[string]any); ok {
+		for key, value := range field {
+			embedding[key] = value
+		}
+	}
+	embedding["type"] = "knn_vector"
+	embedding["dimension"] = dimension
+	properties["embedding"] = embedding
+	mappings["properties"] = properties
+
+	body := map[string]any{"settings": settings, "mappings": mappings}
+	if len(def.Aliases) > 0 {
+		body["aliases"] = def.Aliases
+	}
+	return body
+}
+
+// createIndexWithBody creates indexName from a create index body.
+func (c *OpenSearchClient) createIndexWithBody(ctx context.Context, indexName string, body map[string]any) error {
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("error marshaling create index body: %w", err)
+	}
+	req, err := c.newAuthenticatedRequest(http.MethodPut, "/"+indexName, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("error creating index: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return statusError("create index "+indexName, resp)
+	}
+	return nil
+}
+
+// reindexInto copies every document of source into dest through pipeline,
+// and returns how many it wrote. With reembed, the stored embeddings are left
+// behind, so that the pipeline computes them again.
+func (c *OpenSearchClient) reindexInto(ctx context.Context, source, dest, pipeline string, reembed bool) (int, error) {
+	src := map[string]any{"index": source}
+	if reembed {
+		src["_source"] = map[string]any{"excludes": []string{"embedding", "embedding_model_id"}}
+	}
+	bodyBytes, err := json.Marshal(map[string]any{
+		"source": src,
+		"dest":   map[string]any{"index": dest, "pipeline": pipeline},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error marshaling reindex body: %w", err)
+	}
+	req, err := c.newAuthenticatedRequest(http.MethodPost, "/_reindex?refresh=true", bytes.NewReader(bodyBytes))
+	if err != nil {
+		return 0, fmt.Errorf("error creating request: %w", err)
+	}
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("error reindexing %s: %w", source, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, statusError("reindex "+source, resp)
+	}
+	var reindexResp struct {
+		Created  int               `json:"created"`
+		Updated  int               `json:"updated"`
+		Failures []json.RawMessage `json:"failures"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reindexResp); err != nil {
+		return 0, fmt.Errorf("error decoding reindex response: %w", err)
+	}
+	if len(reindexResp.Failures) > 0 {
+		return 0, fmt.Errorf("reindexing %s into %s failed for %d document(s), e.g. %s", source, dest, len(reindexResp.Failures), reindexResp.Failures[0])
+	}
+	return reindexResp.Created + reindexResp.Updated, nil
+}
@@ -0,0 +1,92 @@
+package knowledge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildReindexIndexBody(t *testing.T) {
+	def := indexDefinition{
+		Aliases: map[string]any{indexAlias: map[string]any{}},
+		Mappings: map[string]any{
+			"_meta": map[string]any{"retention": map[string]any{"max_age": "720h"}},
+			"properties": map[string]any{
+				"content":   map[string]any{"type": "text"},
+				"embedding": map[string]any{"type": "knn_vector", "dimension": 768.0, "space_type": "l2"},
+			},
+		},
+		Settings: map[string]string{
+			"index.knn":                     "true",
+			"index.default_pipeline":        "tickets-ingest",
+			"index.uuid":                    "Xy1",
+			"index.creation_date":           "1700000000000",
+			"index.provided_name":           FullIndexName("tickets"),
+			"index.version.created":         "136327827",
+			"index.blocks.read_only":        "true",
+			"index.number_of_shards":        "2",
+			"index.search.default_pipeline": "rag-snap-search-pipeline",
+		},
+	}
+	body := buildReindexIndexBody(def, 384)
+
+	wantSettings := map[string]string{
+		"index.knn":                     "true",
+		"index.default_pipeline":        "tickets-ingest",
+		"index.number_of_shards":        "2",
+		"index.search.default_pipeline": "rag-snap-search-pipeline",
+	}
+	if got := body["settings"]; !reflect.DeepEqual(got, wantSettings) {
+		t.Errorf("settings = %v, want %v", got, wantSettings)
+	}
+	mappings := body["mappings"].(map[string]any)
+	if !reflect.DeepEqual(mappings["_meta"], def.Mappings["_meta"]) {
+		t.Errorf("_meta = %v, want it kept", mappings["_meta"])
+	}
+	embedding := mappings["properties"].(map[string]any)["embedding"].(map[string]any)
+	if embedding["dimension"] != 384 || embedding["space_type"] != "l2" {
+		t.Errorf("embedding mapping = %v, want dimension 384 and the rest kept", embedding)
+	}
+	if def.Mappings["properties"].(map[string]any)["embedding"].(map[string]any)["dimension"] != 768.0 {
+		t.Error("buildReindexIndexBody changed the definition it was given")
+	}
+	if !reflect.DeepEqual(body["aliases"], def.Aliases) {
+		t.Errorf("aliases = %v, want %v", body["aliases"], def.Aliases)
+	}
+}
+
+func TestReindexInto(t *testing.T) {
+	var got map[string]any
+	failures := `[]`
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/_reindex" {
+			t.Errorf("request %s %s, want POST /_reindex", r.Method, r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprintf(w, `{"created":3,"updated":1,"failures":%s}`, failures)
+	})
+
+	n, err := c.reindexInto(context.Background(), "from", "to", "tickets-ingest", true)
+	if err != nil || n != 4 {
+		t.Fatalf("reindexInto = %d, %v; want 4 documents", n, err)
+	}
+	want := map[string]any{
+		"source": map[string]any{"index": "from", "_source": map[string]any{"excludes": []any{"embedding", "embedding_model_id"}}},
+		"dest":   map[string]any{"index": "to", "pipeline": "tickets-ingest"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("reindex body = %v, want %v", got, want)
+	}
+
+	failures = `[{"id":"c1","cause":{"type":"mapper_parsing_exception"}}]`
+	if _, err := c.reindexInto(context.Background(), "from", "to", "_none", false); err == nil || !strings.Contains(err.Error(), "1 document(s)") {
+		t.Errorf("reindexInto with a failure = %v, want it reported", err)
+	}
+	if _, ok := got["source"].(map[string]any)["_source"]; ok {
+		t.Error("reindexInto without reembed left the embeddings behind")
+	}
+}
@@ -0,0 +1,173 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Kinds of the OpenSearch artifacts the snap creates, as Artifacts reports
+// them.
+const (
+	ArtifactKnowledgeBase  = "knowledge base"
+	ArtifactSourceMetadata = "source metadata index"
+	ArtifactIndex          = "index"
+	ArtifactIndexTemplate  = "index template"
+	ArtifactSearchPipeline = "search pipeline"
+	ArtifactIngestPipeline = "ingest pipeline"
+	ArtifactModel          = "model"
+	ArtifactModelGroup     = "model group"
+)
+
+// Artifact is one thing the snap created in OpenSearch.
+type Artifact struct {
+	Kind string
+	// Name is the artifact's name: an index, template, pipeline, model, or
+	// model group name.
+	Name string
+	// ID is the ML plugin's ID of a model or model group; empty for the rest.
+	ID string
+}
+
+// String describes the artifact for a listing, e.g. "model group
+// rag-snap-models (Xk1a...)".
+func (a Artifact) String() string {
+	if a.ID != "" {
+		return fmt.Sprintf("%s %s (%s)", a.Kind, a.Name, a.ID)
+	}
+	return fmt.Sprintf("%s %s", a.Kind, a.Name)
+}
+
+// Artifacts lists what the snap instance has created in OpenSearch, in an
+// order RemoveArtifact can remove them in: the indexes before the template and
+// pipelines they were created from, and the models before their model group.
+// Artifacts that do not exist, e.g. before knowledge init ran, are left out.
+func (c *OpenSearchClient) Artifacts(ctx context.Context) ([]Artifact, error) {
+	var artifacts []Artifact
+
+	bases, err := c.catIndexes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, idx := range bases {
+		artifacts = append(artifacts, Artifact{Kind: ArtifactKnowledgeBase, Name: idx.Name})
+	}
+
+	for _, idx := range []struct{ kind, name string }{
+		{ArtifactSourceMetadata, sourcesIndexName},
+		{ArtifactIndex, queueIndexName},
+		{ArtifactIndex, memoryIndexName},
+	} {
+		exists, err := c.IndexExists(ctx, idx.name)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			artifacts = append(artifacts, Artifact{Kind: idx.kind, Name: idx.name})
+		}
+	}
+
+	template, err := c.getIndexTemplate(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting index template: %w", err)
+	}
+	if template != nil {
+		artifacts = append(artifacts, Artifact{Kind: ArtifactIndexTemplate, Name: indexTemplateName})
+	}
+
+	for _, p := range []struct{ kind, pathKind, name string }{
+		{ArtifactSearchPipeline, "_search", searchPipelineName},
+		{ArtifactIngestPipeline, "_ingest", ingestPipelineName},
+	} {
+		exists, err := c.pipelineExists(ctx, p.pathKind, p.name)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			artifacts = append(artifacts, Artifact{Kind: p.kind, Name: p.name})
+		}
+	}
+
+	models, err := c.ListModels(ctx, "", "")
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range models {
+		artifacts = append(artifacts, Artifact{Kind: ArtifactModel, Name: m.Name, ID: m.ID})
+	}
+
+	groupID, err := c.findModelGroup(ctx, modelGroupName)
+	if err != nil {
+		return nil, fmt.Errorf("error searching for model group: %w", err)
+	}
+	if groupID != "" {
+		artifacts = append(artifacts, Artifact{Kind: ArtifactModelGroup, Name: modelGroupName, ID: groupID})
+	}
+
+	return artifacts, nil
+}
+
+// DefaultResetSelection is what a reset removes unless told otherwise: every
+// artifact except the knowledge base indexes, which hold ingested content and
+// are only included when indexes is set, and the source metadata index, which
+// describes that content and goes with them: without it, the bases left behind
+// would hold chunks no source listing, forget, or refresh can find.
+func DefaultResetSelection(artifacts []Artifact, indexes bool) []Artifact {
+	var selected []Artifact
+	for _, a := range artifacts {
+		switch a.Kind {
+		case ArtifactKnowledgeBase, ArtifactSourceMetadata:
+			if !indexes {
+				continue
+			}
+		}
+		selected = append(selected, a)
+	}
+	return selected
+}
+
+// RemoveArtifact deletes an artifact Artifacts listed: a model is undeployed
+// first. An artifact that is already gone is not an error.
+func (c *OpenSearchClient) RemoveArtifact(ctx context.Context, a Artifact) error {
+	switch a.Kind {
+	case ArtifactKnowledgeBase:
+		return c.deleteArtifact(ctx, "/"+url.PathEscape(a.Name), "delete index")
+	case ArtifactSourceMetadata, ArtifactIndex:
+		return c.deleteArtifact(ctx, "/"+url.PathEscape(a.Name), "delete index")
+	case ArtifactIndexTemplate:
+		return c.deleteArtifact(ctx, "/_index_template/"+url.PathEscape(a.Name), "delete index template")
+	case ArtifactSearchPipeline:
+		return c.deleteArtifact(ctx, "/_search/pipeline/"+url.PathEscape(a.Name), "delete search pipeline")
+	case ArtifactIngestPipeline:
+		return c.deleteArtifact(ctx, "/_ingest/pipeline/"+url.PathEscape(a.Name), "delete ingest pipeline")
+	case ArtifactModel:
+		_ = c.UndeployModel(ctx, a.ID) // as in DeleteModel: a model never deployed cannot be undeployed
+		return c.deleteArtifact(ctx, "/_plugins/_ml/models/"+url.PathEscape(a.ID), "delete model")
+	case ArtifactModelGroup:
+		return c.deleteArtifact(ctx, "/_plugins/_ml/model_groups/"+url.PathEscape(a.ID), "delete model group")
+	}
+	return fmt.Errorf("unknown artifact kind %q", a.Kind)
+}
+
+// deleteArtifact sends a DELETE request for path, treating a 404 as success.
+func (c *OpenSearchClient) deleteArtifact(ctx context.Context, path, op string) error {
+	req, err := c.newAuthenticatedRequest(http.MethodDelete, path, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("error executing %s request: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return statusError(op, resp)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package knowledge
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDefaultResetSelection(t *testing.T) {
+	base := Artifact{Kind: ArtifactKnowledgeBase, Name: "rag-snap-context-docs"}
+	sources := Artifact{Kind: ArtifactSourceMetadata, Name: sourcesIndexName}
+	queue := Artifact{Kind: ArtifactIndex, Name: queueIndexName}
+	template := Artifact{Kind: ArtifactIndexTemplate, Name: indexTemplateName}
+	model := Artifact{Kind: ArtifactModel, Name: "embed", ID: "m1"}
+	artifacts := []Artifact{base, sources, queue, template, model}
+
+	// Without --indexes, the bases and the metadata describing them are kept.
+	if got, want := DefaultResetSelection(artifacts, false), []Artifact{queue, template, model}; !reflect.DeepEqual(got, want) {
+		t.Errorf("default selection = %v, want %v", got, want)
+	}
+	if got := DefaultResetSelection(artifacts, true); !reflect.DeepEqual(got, artifacts) {
+		t.Errorf("selection with indexes = %v, want every artifact", got)
+	}
+}
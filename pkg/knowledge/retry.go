@@ -0,0 +1,119 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jpnorenam/rag-snap/pkg/processing"
+)
+
+// RetryResult reports the retry of one failed source.
+type RetryResult struct {
+	SourceID string
+	// Path is where the source was ingested from again: its URL or file path.
+	Path string
+	// Chunks is the source's chunk count after a successful retry.
+	Chunks int
+	Err    error
+}
+
+// RetryTargets returns the failed sources of indexName to retry: all of them,
+// or only sourceID when it is given, which must be a failed source of the base.
+func (c *OpenSearchClient) RetryTargets(ctx context.Context, indexName, sourceID string) ([]SourceMetadata, error) {
+	if sourceID != "" {
+		meta, err := c.GetSourceMetadata(ctx, sourceID)
+		if err != nil {
+			return nil, err
+		}
+		if meta.IndexName != indexName {
+			return nil, fmt.Errorf("%w: %q is not in this knowledge base", ErrSourceNotFound, sourceID)
+		}
+		if meta.Status != StatusFailed {
+			return nil, fmt.Errorf("source %q has not failed (status: %s)", sourceID, meta.Status)
+		}
+		return []SourceMetadata{*meta}, nil
+	}
+
+	sources, err := c.ListSourceMetadata(ctx, indexName)
+	if err != nil {
+		return nil, err
+	}
+	var targets []SourceMetadata
+	for _, meta := range sources {
+		if meta.Status == StatusFailed {
+			targets = append(targets, meta)
+		}
+	}
+	return targets, nil
+}
+
+// CheckRetryable returns why a failed source cannot be ingested again from
+// what its metadata recorded, or nil when it can: a URL source is fetched
+// again, and any other source needs its file still at the absolute path it
+// was ingested from. Repository files and uploads to the daemon record no such
+// path and have to be ingested again the way they were at first.
+func CheckRetryable(meta SourceMetadata) error {
+	if IsURLSource(meta) {
+		return nil
+	}
+	if !filepath.IsAbs(meta.FilePath) {
+		return fmt.Errorf("source %q records no file path to ingest it from again; ingest it again with --force", meta.SourceID)
+	}
+	info, err := os.Stat(meta.FilePath)
+	if err != nil {
+		return fmt.Errorf("source file %s is no longer available: %w", meta.FilePath, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("source path %s is a directory", meta.FilePath)
+	}
+	return nil
+}
+
+// RetrySource ingests a failed source again from its URL or file path. The
+// chunks a failed attempt left in the base are deleted first, and the source
+// keeps its label and tags; the ingest records it as completed, or as failed
+// again.
+func (c *OpenSearchClient) RetrySource(ctx context.Context, tika *processing.TikaClient, meta SourceMetadata) RetryResult {
+	result := RetryResult{SourceID: meta.SourceID, Path: meta.FilePath}
+	fail := func(err error) RetryResult {
+		result.Err = err
+		return result
+	}
+
+	if err := CheckRetryable(meta); err != nil {
+		return fail(err)
+	}
+	if _, err := c.DeleteChunksBySourceID(ctx, meta.IndexName, meta.SourceID); err != nil {
+		return fail(fmt.Errorf("deleting partial chunks: %w", err))
+	}
+
+	opts := IngestOptions{
+		FilePath:     meta.FilePath,
+		SourceID:     meta.SourceID,
+		MetadataPath: meta.FilePath,
+		TargetIndex:  meta.IndexName,
+		Label:        meta.Label,
+		Tags:         meta.Tags,
+		Force:        true,
+		Trigger:      TriggerRetry,
+	}
+	if IsURLSource(meta) {
+		path, web, cleanup, err := processing.CrawlPage(ctx, c.settings.Temp, meta.FilePath)
+		if err != nil {
+			return fail(fmt.Errorf("fetching %s: %w", meta.FilePath, err))
+		}
+		defer cleanup()
+		opts.FilePath = path
+		opts.Title, opts.Author = web.Title, web.Author
+		opts.ETag, opts.LastModified = web.ETag, web.LastModified
+	}
+
+	indexed, err := NewIngestor(c, tika).Ingest(ctx, opts)
+	if err != nil {
+		return fail(err)
+	}
+	result.Chunks = indexed.Indexed
+	return result
+}
@@ -0,0 +1,34 @@
+package knowledge
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckRetryable(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "doc.md")
+	if err := os.WriteFile(file, []byte("# Doc"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, path string
+		ok         bool
+	}{
+		{"url", "https://example.com/docs", true},
+		{"local file", file, true},
+		{"missing file", filepath.Join(dir, "gone.md"), false},
+		{"directory", dir, false},
+		{"repository path", "docs/install.md", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckRetryable(SourceMetadata{SourceID: "src", FilePath: tt.path})
+			if (err == nil) != tt.ok {
+				t.Errorf("CheckRetryable(%q) = %v, want ok %v", tt.path, err, tt.ok)
+			}
+		})
+	}
+}
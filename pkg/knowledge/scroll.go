@@ -0,0 +1,113 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// scrollPageSize is how many hits each scroll round trip fetches.
+	scrollPageSize = 500
+	// scrollKeepAlive is how long OpenSearch keeps the scroll context open
+	// between round trips.
+	scrollKeepAlive = "1m"
+)
+
+// SearchAll streams every chunk in indexes whose content matches lexicalQuery,
+// calling fn once per hit in descending BM25 score order. It is the export
+// counterpart of Search: a scroll over the lexical query alone, since neural
+// KNN and reranking only ever produce a top-k. Returning an error from fn stops
+// the scan.
+func (c *OpenSearchClient) SearchAll(ctx context.Context, indexes []string, lexicalQuery string, opts SearchOptions, fn func(SearchHit) error) error {
+	body := map[string]any{
+		"size": scrollPageSize,
+		"_source": map[string]any{
+			"excludes": []string{"embedding"},
+		},
+		"query": lexicalClause(lexicalQuery, opts, c.settings.searchBoosts()),
+	}
+	path := fmt.Sprintf("/%s/_search?scroll=%s", strings.Join(indexes, ","), scrollKeepAlive)
+
+	page, err := c.scrollRequest(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return err
+	}
+	defer c.clearScroll(page.ScrollID)
+
+	for {
+		hits := page.searchHits()
+		if len(hits) == 0 {
+			return nil
+		}
+		for _, hit := range hits {
+			if err := fn(hit); err != nil {
+				return err
+			}
+		}
+
+		next, err := c.scrollRequest(ctx, http.MethodPost, "/_search/scroll", map[string]any{
+			"scroll":    scrollKeepAlive,
+			"scroll_id": page.ScrollID,
+		})
+		if err != nil {
+			return err
+		}
+		page = next
+	}
+}
+
+// scrollResponse is a search response page that carries a scroll id.
+type scrollResponse struct {
+	neuralSearchResponse
+	ScrollID string `json:"_scroll_id"`
+}
+
+func (c *OpenSearchClient) scrollRequest(ctx context.Context, method, path string, body map[string]any) (*scrollResponse, error) {
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling scroll body: %w", err)
+	}
+
+	req, err := c.newAuthenticatedRequest(method, path, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("executing scroll request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("scroll request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var page scrollResponse
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("decoding scroll response: %w", err)
+	}
+	return &page, nil
+}
+
+// clearScroll releases a scroll context. Failures are ignored: the context
+// expires on its own after scrollKeepAlive.
+func (c *OpenSearchClient) clearScroll(scrollID string) {
+	if scrollID == "" {
+		return
+	}
+	bodyBytes, _ := json.Marshal(map[string]any{"scroll_id": scrollID})
+	req, err := c.newAuthenticatedRequest(http.MethodDelete, "/_search/scroll", bytes.NewReader(bodyBytes))
+	if err != nil {
+		return
+	}
+	if resp, err := c.client.Client.Perform(req); err == nil {
+		resp.Body.Close()
+	}
+}
@@ -0,0 +1,323 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jpnorenam/rag-snap/pkg/progress"
+)
+
+// SearchHit represents a single search result with its relevance score.
+// Label is always resolved (stored chunk label, or the legacy index-name
+// inference for unlabeled chunks) — consumers use it directly and never
+// re-derive provenance.
+type SearchHit struct {
+	Index     string            `json:"index"`
+	Score     float64           `json:"score"`
+	Content   string            `json:"content"`
+	SourceID  string            `json:"source_id"`
+	Label     string            `json:"label"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	CreatedAt string            `json:"created_at"`
+	// Page is the PDF page the chunk starts on; 0 when unknown.
+	Page int `json:"page,omitempty"`
+}
+
+// Citation names the source a hit came from for display, with its page when
+// known: "manual.pdf, p.42".
+func Citation(sourceID string, page int) string {
+	if page <= 0 {
+		return sourceID
+	}
+	return fmt.Sprintf("%s, p.%d", sourceID, page)
+}
+
+// SearchOptions narrows a search beyond the query text. The zero value
+// searches every chunk.
+type SearchOptions struct {
+	// Tags restricts hits to chunks carrying every given key/value tag.
+	Tags map[string]string
+	// Since and Until restrict hits to chunks ingested at or after Since and
+	// before Until. A zero time leaves that end open.
+	Since, Until time.Time
+	// PerBaseK, when searching several indexes, puts each one's top PerBaseK
+	// hits first in the merged results, so no base is crowded out of them.
+	PerBaseK int
+	// Versions also matches the chunks kept for the replaced versions of
+	// sources (see VersionedSourceID), which are left out otherwise.
+	Versions bool
+}
+
+// relativeBound matches an age such as 7d: that long before now.
+var relativeBound = regexp.MustCompile(`^(\d+)([mhdw])$`)
+
+// ParseTimeBound parses a --since or --until value: an age before now (30m,
+// 12h, 7d, 2w), a date (2006-01-02), or a UTC timestamp (2006-01-02 15:04:05
+// or RFC 3339). A date as an end bound covers the whole day, so --until
+// 2024-05-01 includes chunks ingested that day. "" returns the zero time.
+func ParseTimeBound(value string, now time.Time, end bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if m := relativeBound.FindStringSubmatch(strings.ToLower(value)); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		unit := map[string]time.Duration{"m": time.Minute, "h": time.Hour, "d": 24 * time.Hour, "w": 7 * 24 * time.Hour}[m[2]]
+		return now.Add(-time.Duration(n) * unit), nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	for _, layout := range []string{DateFormat, time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: expected an age (7d, 12h), a date (2006-01-02), or a timestamp (2006-01-02 15:04:05)", value)
+}
+
+// filterClauses returns the bool filter clauses opts applies to both arms of
+// the hybrid query: one term per tag, one leaving out archived chunks unless
+// opts.Versions is set, and a created_at range.
+func (opts SearchOptions) filterClauses() []map[string]any {
+	clauses := tagFilterClauses(opts.Tags)
+	if !opts.Versions {
+		clauses = append(clauses, currentVersionClause())
+	}
+	if opts.Since.IsZero() && opts.Until.IsZero() {
+		return clauses
+	}
+	bounds := map[string]any{"format": "yyyy-MM-dd HH:mm:ss"}
+	if !opts.Since.IsZero() {
+		bounds["gte"] = opts.Since.UTC().Format(DateFormat)
+	}
+	if !opts.Until.IsZero() {
+		bounds["lt"] = opts.Until.UTC().Format(DateFormat)
+	}
+	return append(clauses, map[string]any{
+		"range": map[string]any{"created_at": bounds},
+	})
+}
+
+// Search performs a hybrid search (BM25 + neural) with reranking across the
+// given indexes, merges the results, and returns them sorted by score descending;
+// across several indexes, the scores are normalized and weighed for the merge
+// as knowledge.search.normalization and knowledge.search.weights set.
+// Indexes should be full index names (e.g. "rag-snap-context-default").
+// The query parameter is used for neural embedding and reranking.
+// The lexicalQuery parameter is used for BM25 matching and may include
+// additional context (e.g. recent conversation queries) for richer lexical recall.
+func (c *OpenSearchClient) Search(ctx context.Context, indexes []string, query, lexicalQuery, embeddingModelID string, k int) ([]SearchHit, error) {
+	stopProgress := progress.Start("Searching knowledge base")
+	defer stopProgress()
+
+	return c.search(ctx, indexes, query, lexicalQuery, embeddingModelID, k, SearchOptions{})
+}
+
+// SearchWithOptions is Search with additional filtering applied to both the
+// lexical and neural arms of the hybrid query.
+func (c *OpenSearchClient) SearchWithOptions(ctx context.Context, indexes []string, query, lexicalQuery, embeddingModelID string, k int, opts SearchOptions) ([]SearchHit, error) {
+	stopProgress := progress.Start("Searching knowledge base")
+	defer stopProgress()
+
+	return c.search(ctx, indexes, query, lexicalQuery, embeddingModelID, k, opts)
+}
+
+func (c *OpenSearchClient) search(ctx context.Context, indexes []string, query, lexicalQuery, embeddingModelID string, k int, opts SearchOptions) ([]SearchHit, error) {
+	// Search each index individually, then merge their hits.
+	perIndex := make([][]SearchHit, 0, len(indexes))
+	for _, index := range indexes {
+		hits, err := c.hybridSearch(ctx, index, query, lexicalQuery, embeddingModelID, k, opts)
+		if err != nil {
+			return nil, fmt.Errorf("searching index %q: %w", index, err)
+		}
+		perIndex = append(perIndex, hits)
+	}
+	return mergeHits(perIndex, opts.PerBaseK, c.settings.searchNormalization(), c.settings.weights), nil
+}
+
+// PageHits returns the page of merged hits starting at from and holding at most
+// size hits. To page through merged results, search with k = from+size so each
+// index contributes enough candidates to fill the page.
+func PageHits(hits []SearchHit, from, size int) []SearchHit {
+	if from >= len(hits) {
+		return nil
+	}
+	hits = hits[from:]
+	if size < len(hits) {
+		hits = hits[:size]
+	}
+	return hits
+}
+
+// hybridSearch executes a hybrid (BM25 + neural) search with reranking on a
+// single index, through the search pipeline attached to it.
+func (c *OpenSearchClient) hybridSearch(
+	ctx context.Context,
+	indexName, query, lexicalQuery, embeddingModelID string,
+	k int,
+	opts SearchOptions,
+) ([]SearchHit, error) {
+	body := buildSearchBody(query, lexicalQuery, embeddingModelID, k, opts, c.settings.searchBoosts())
+
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling search body: %w", err)
+	}
+
+	pipelines, err := c.GetIndexPipelines(ctx, indexName)
+	if err != nil {
+		return nil, err
+	}
+	return c.searchWithBody(ctx, indexName, pipelines.EffectiveSearch(), bodyBytes)
+}
+
+// searchWithBody runs a prepared search body on one index through pipeline.
+func (c *OpenSearchClient) searchWithBody(ctx context.Context, indexName, pipeline string, body []byte) ([]SearchHit, error) {
+	path := fmt.Sprintf("/%s/_search?search_pipeline=%s", indexName, pipeline)
+	req, err := c.newAuthenticatedRequest(http.MethodGet, path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("executing search request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError("search request", resp)
+	}
+
+	var searchResp neuralSearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
+		return nil, fmt.Errorf("decoding search response: %w", err)
+	}
+	return searchResp.searchHits(), nil
+}
+
+// buildSearchBody constructs a hybrid search request body combining BM25
+// lexical matching with neural KNN, plus reranking context; boosts weigh the
+// fields BM25 matches.
+// The lexicalQuery is used for BM25 matching and may be enriched with
+// conversation history. The query is used for neural embedding and reranking.
+// Tag and date filters from opts are applied inside each hybrid sub-query,
+// since the hybrid query itself cannot be wrapped in a bool filter.
+func buildSearchBody(query, lexicalQuery, embeddingModelID string, k int, opts SearchOptions, boosts map[string]float64) map[string]any {
+	// Over-fetch candidates so the reranker has a larger pool to work with.
+	// The final result count is capped back to k via "size".
+	neuralK := k * 3
+
+	lexical := lexicalClause(lexicalQuery, opts, boosts)
+	neural := map[string]any{
+		"query_text": query,
+		"model_id":   embeddingModelID,
+		"k":          neuralK,
+	}
+	if filters := opts.filterClauses(); len(filters) > 0 {
+		neural["filter"] = map[string]any{
+			"bool": map[string]any{"filter": filters},
+		}
+	}
+
+	return map[string]any{
+		"size": k,
+		"_source": map[string]any{
+			"excludes": []string{"embedding"},
+		},
+		"query": map[string]any{
+			"hybrid": map[string]any{
+				"queries": []map[string]any{
+					lexical,
+					{
+						"neural": map[string]any{
+							"embedding": neural,
+						},
+					},
+				},
+			},
+		},
+		"ext": map[string]any{
+			"rerank": map[string]any{
+				"query_context": map[string]any{
+					"query_text": query,
+				},
+			},
+		},
+	}
+}
+
+// lexicalClause is the BM25 match on chunk content, titles, and headings,
+// weighed by boosts (knowledge.search.boosts), with opts' tag and date
+// filters applied.
+func lexicalClause(lexicalQuery string, opts SearchOptions, boosts map[string]float64) map[string]any {
+	lexical := map[string]any{
+		"multi_match": map[string]any{
+			"query":       lexicalQuery,
+			"fields":      boostedFields(boosts),
+			"type":        "best_fields",
+			"tie_breaker": boostTieBreaker,
+		},
+	}
+	filters := opts.filterClauses()
+	if len(filters) == 0 {
+		return lexical
+	}
+	return map[string]any{
+		"bool": map[string]any{
+			"must":   []map[string]any{lexical},
+			"filter": filters,
+		},
+	}
+}
+
+// neuralSearchResponse represents the OpenSearch response for a neural search query.
+type neuralSearchResponse struct {
+	Hits struct {
+		Total struct {
+			Value int `json:"value"`
+		} `json:"total"`
+		Hits []struct {
+			Index  string  `json:"_index"`
+			ID     string  `json:"_id"`
+			Score  float64 `json:"_score"`
+			Source struct {
+				Content   string            `json:"content"`
+				SourceID  string            `json:"source_id"`
+				Label     string            `json:"label"`
+				Tags      map[string]string `json:"tags"`
+				CreatedAt string            `json:"created_at"`
+				Page      int               `json:"page"`
+			} `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+// searchHits converts the response's hits to SearchHits.
+func (r *neuralSearchResponse) searchHits() []SearchHit {
+	hits := make([]SearchHit, 0, len(r.Hits.Hits))
+	for _, hit := range r.Hits.Hits {
+		hits = append(hits, SearchHit{
+			Index:     hit.Index,
+			Score:     hit.Score,
+			Content:   hit.Source.Content,
+			SourceID:  hit.Source.SourceID,
+			Label:     ResolveLabel(hit.Index, hit.Source.Label),
+			Tags:      hit.Source.Tags,
+			CreatedAt: hit.Source.CreatedAt,
+			Page:      hit.Source.Page,
+		})
+	}
+	return hits
+}
@@ -0,0 +1,106 @@
+package knowledge
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPageHits(t *testing.T) {
+	hits := []SearchHit{{SourceID: "a"}, {SourceID: "b"}, {SourceID: "c"}, {SourceID: "d"}}
+
+	tests := []struct {
+		name       string
+		from, size int
+		want       []string
+	}{
+		{"first page", 0, 2, []string{"a", "b"}},
+		{"middle page", 1, 2, []string{"b", "c"}},
+		{"short last page", 3, 2, []string{"d"}},
+		{"past the end", 4, 2, nil},
+		{"size beyond results", 0, 10, []string{"a", "b", "c", "d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PageHits(hits, tt.from, tt.size)
+			if len(got) != len(tt.want) {
+				t.Fatalf("PageHits(%d, %d) returned %d hits, want %d", tt.from, tt.size, len(got), len(tt.want))
+			}
+			for i, hit := range got {
+				if hit.SourceID != tt.want[i] {
+					t.Errorf("hit %d = %q, want %q", i, hit.SourceID, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		end   bool
+		want  time.Time
+	}{
+		{"", false, time.Time{}},
+		{"7d", false, now.AddDate(0, 0, -7)},
+		{"12h", true, now.Add(-12 * time.Hour)},
+		{"2w", false, now.AddDate(0, 0, -14)},
+		{"2024-05-01", false, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"2024-05-01", true, time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)},
+		{"2024-05-01 14:30:00", true, time.Date(2024, 5, 1, 14, 30, 0, 0, time.UTC)},
+		{"2024-05-01T14:30:00Z", false, time.Date(2024, 5, 1, 14, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseTimeBound(tt.value, now, tt.end)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("ParseTimeBound(%q, end=%v) = (%v, %v), want %v", tt.value, tt.end, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"yesterday", "7y", "2024-13-01"} {
+		if _, err := ParseTimeBound(bad, now, false); err == nil {
+			t.Errorf("ParseTimeBound(%q) = nil error, want error", bad)
+		}
+	}
+}
+
+func TestBuildSearchBodyDateFilter(t *testing.T) {
+	opts := SearchOptions{
+		Since: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Until: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
+	body := buildSearchBody("q", "q", "model", 5, opts, defaultSearchBoosts())
+	queries := body["query"].(map[string]any)["hybrid"].(map[string]any)["queries"].([]map[string]any)
+
+	want := []map[string]any{currentVersionClause(), {"range": map[string]any{"created_at": map[string]any{
+		"format": "yyyy-MM-dd HH:mm:ss",
+		"gte":    "2024-05-01 00:00:00",
+		"lt":     "2024-06-01 00:00:00",
+	}}}}
+	lexical := queries[0]["bool"].(map[string]any)
+	if !reflect.DeepEqual(lexical["filter"], want) {
+		t.Errorf("lexical filter = %v, want %v", lexical["filter"], want)
+	}
+	neural := queries[1]["neural"].(map[string]any)["embedding"].(map[string]any)
+	if got := neural["filter"].(map[string]any)["bool"].(map[string]any)["filter"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("neural filter = %v, want %v", got, want)
+	}
+}
+
+func TestFilterClausesVersions(t *testing.T) {
+	if got := (SearchOptions{}).filterClauses(); !reflect.DeepEqual(got, []map[string]any{currentVersionClause()}) {
+		t.Errorf("default filter = %v, want archived chunks left out", got)
+	}
+	if got := (SearchOptions{Versions: true}).filterClauses(); len(got) != 0 {
+		t.Errorf("filter with versions = %v, want none", got)
+	}
+}
+
+func TestCitation(t *testing.T) {
+	if got := Citation("manual.pdf", 42); got != "manual.pdf, p.42" {
+		t.Errorf("Citation with page = %q", got)
+	}
+	if got := Citation("notes.md", 0); got != "notes.md" {
+		t.Errorf("Citation without page = %q", got)
+	}
+}
@@ -0,0 +1,133 @@
+package knowledge
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jpnorenam/rag-snap/pkg/processing"
+)
+
+// Config keys of the settings an OpenSearchClient indexes, searches, and waits
+// on models with.
+const (
+	ConfBulkBytes   = "knowledge.bulk.bytes"
+	ConfBulkDocs    = "knowledge.bulk.docs"
+	ConfBulkRefresh = "knowledge.bulk.refresh"
+
+	ConfIngestDedup    = "knowledge.ingest.dedup"
+	ConfIngestPreHook  = "knowledge.ingest.pre-hook"
+	ConfIngestPostHook = "knowledge.ingest.post-hook"
+
+	ConfSearchBoosts        = "knowledge.search.boosts"
+	ConfSearchWeights       = "knowledge.search.weights"
+	ConfSearchNormalization = "knowledge.search.normalization"
+
+	ConfGuard       = "knowledge.guard"
+	ConfGuardMemory = "knowledge.guard.memory"
+
+	ConfModelTimeout = "knowledge.model.timeout"
+)
+
+// Settings are the knowledge.* settings of an OpenSearchClient, and the temp.*
+// settings of the managed temp directory it fetches and unpacks sources into.
+// The zero Settings are the defaults.
+type Settings struct {
+	bulk     bulkSettings
+	dedup    bool
+	preHook  string
+	postHook string
+	// boosts weighs each field the lexical search matches; nil is
+	// defaultSearchBoosts.
+	boosts map[string]float64
+	// normalization and weights set how the hits of several bases are
+	// merged (see mergeHits).
+	normalization string
+	weights       map[string]float64
+	guard         guardSettings
+	modelTimeout  time.Duration
+	// Temp is the managed temp directory sources are staged in.
+	Temp processing.TempSettings
+}
+
+// ParseSettings reads the knowledge.* and temp.* values get returns. An empty
+// value keeps the key's default.
+func ParseSettings(get func(key string) string) (Settings, error) {
+	var (
+		s   Settings
+		err error
+	)
+	if s.bulk, err = parseBulkSettings(get(ConfBulkBytes), get(ConfBulkDocs), get(ConfBulkRefresh)); err != nil {
+		return Settings{}, err
+	}
+	if s.dedup, err = parseDedup(get(ConfIngestDedup)); err != nil {
+		return Settings{}, err
+	}
+	s.preHook = strings.TrimSpace(get(ConfIngestPreHook))
+	s.postHook = strings.TrimSpace(get(ConfIngestPostHook))
+	if s.boosts, err = parseSearchBoosts(get(ConfSearchBoosts)); err != nil {
+		return Settings{}, err
+	}
+	if s.weights, s.normalization, err = parseSearchMerge(get(ConfSearchWeights), get(ConfSearchNormalization)); err != nil {
+		return Settings{}, err
+	}
+	if s.guard, err = parseResourceGuard(get(ConfGuard), get(ConfGuardMemory)); err != nil {
+		return Settings{}, err
+	}
+	if s.modelTimeout, err = parseModelTimeout(get(ConfModelTimeout)); err != nil {
+		return Settings{}, err
+	}
+	if s.Temp, err = processing.ParseTempSettings(get); err != nil {
+		return Settings{}, err
+	}
+	return s, nil
+}
+
+// bulkSettings returns the bulk settings, with the defaults for those unset.
+func (s Settings) bulkSettings() bulkSettings {
+	b := s.bulk
+	if b.maxBytes <= 0 {
+		b.maxBytes = DefaultBulkMaxBytes
+	}
+	if b.maxDocs <= 0 {
+		b.maxDocs = DefaultBulkMaxDocs
+	}
+	if b.refresh == "" {
+		b.refresh = RefreshFalse
+	}
+	return b
+}
+
+// searchBoosts returns the field boosts of the lexical search.
+func (s Settings) searchBoosts() map[string]float64 {
+	if s.boosts == nil {
+		return defaultSearchBoosts()
+	}
+	return s.boosts
+}
+
+// searchNormalization returns the score normalization of a merge.
+func (s Settings) searchNormalization() string {
+	if s.normalization == "" {
+		return NormalizationNone
+	}
+	return s.normalization
+}
+
+// guardSettings returns the resource guard settings.
+func (s Settings) guardSettings() guardSettings {
+	g := s.guard
+	if g.memoryPercent <= 0 {
+		g.memoryPercent = DefaultGuardMemoryPercent
+	}
+	return g
+}
+
+// modelPoller returns the poller model registrations, deployments, and ML
+// tasks are waited on with.
+func (s Settings) modelPoller() poller {
+	timeout := s.modelTimeout
+	if timeout <= 0 {
+		timeout = DefaultModelWaitTimeout
+	}
+	return poller{initial: time.Second, max: 15 * time.Second, timeout: timeout}
+}
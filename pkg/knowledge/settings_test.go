@@ -0,0 +1,61 @@
+package knowledge
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSettings(t *testing.T) {
+	values := map[string]string{
+		ConfBulkDocs:      "50",
+		ConfIngestDedup:   "true",
+		ConfIngestPreHook: " convert.sh ",
+		ConfSearchBoosts:  "title=3",
+		ConfGuard:         "true",
+		ConfModelTimeout:  "10m",
+		"temp.quota":      "500M",
+		"chat.rag.top-k":  "not read",
+	}
+	s, err := ParseSettings(func(key string) string { return values[key] })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := s.bulkSettings(); b.maxDocs != 50 || b.maxBytes != DefaultBulkMaxBytes || b.refresh != RefreshFalse {
+		t.Errorf("bulk = %+v, want 50 docs and the defaults", b)
+	}
+	if !s.dedup || s.preHook != "convert.sh" || s.postHook != "" {
+		t.Errorf("ingest settings = dedup %v, hooks %q %q", s.dedup, s.preHook, s.postHook)
+	}
+	if got := boostedFields(s.searchBoosts()); !reflect.DeepEqual(got, []string{"content^1", "title^3", "heading^1.5"}) {
+		t.Errorf("boosts = %v", got)
+	}
+	if g := s.guardSettings(); !g.enabled || g.memoryPercent != DefaultGuardMemoryPercent {
+		t.Errorf("guard = %+v, want on at the default", g)
+	}
+	if p := s.modelPoller(); p.timeout != 10*time.Minute {
+		t.Errorf("model timeout = %s, want 10m", p.timeout)
+	}
+	if s.Temp.Quota != 500<<20 {
+		t.Errorf("temp quota = %d, want 500M", s.Temp.Quota)
+	}
+
+	values = map[string]string{ConfSearchNormalization: "rank"}
+	if _, err := ParseSettings(func(key string) string { return values[key] }); err == nil || !strings.Contains(err.Error(), ConfSearchNormalization) {
+		t.Errorf("ParseSettings with a bad normalization = %v, want an error naming the key", err)
+	}
+}
+
+func TestZeroSettings(t *testing.T) {
+	var s Settings
+	if b := s.bulkSettings(); b != (bulkSettings{maxBytes: DefaultBulkMaxBytes, maxDocs: DefaultBulkMaxDocs, refresh: RefreshFalse}) {
+		t.Errorf("zero bulk settings = %+v, want the defaults", b)
+	}
+	if !reflect.DeepEqual(s.searchBoosts(), defaultSearchBoosts()) || s.searchNormalization() != NormalizationNone {
+		t.Errorf("zero search settings = %v, %q; want the defaults", s.searchBoosts(), s.searchNormalization())
+	}
+	if p := s.modelPoller(); p.timeout != DefaultModelWaitTimeout {
+		t.Errorf("zero model timeout = %s, want %s", p.timeout, DefaultModelWaitTimeout)
+	}
+}
@@ -0,0 +1,185 @@
+package knowledge
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jpnorenam/rag-snap/pkg/processing"
+	"github.com/jpnorenam/rag-snap/pkg/progress"
+)
+
+// SitemapOptions controls how IngestSitemap ingests the pages of a sitemap.
+type SitemapOptions struct {
+	// SourcePrefix names the pages' sources: each page is ingested as
+	// <prefix>/<page path>.
+	SourcePrefix string
+	// TargetIndex is the full index name of the destination knowledge base.
+	TargetIndex string
+	// Label and Tags apply to every page, as in IngestOptions.
+	Label string
+	Tags  map[string]string
+	// Force re-ingests pages that are already ingested instead of skipping them.
+	Force bool
+	// Concurrency is how many pages are fetched at once.
+	Concurrency int
+	// Rate caps how many pages are requested per second across all fetches;
+	// 0 leaves it uncapped.
+	Rate float64
+}
+
+// SitemapResult counts what IngestSitemap did with the pages it was given.
+type SitemapResult struct {
+	Ingested int
+	Skipped  int
+	Failed   int
+}
+
+// fetchedPage is one page a fetch worker has crawled, or failed to.
+type fetchedPage struct {
+	url      string
+	sourceID string
+	path     string
+	meta     *processing.WebMetadata
+	cleanup  func()
+	err      error
+}
+
+// IngestSitemap ingests each page as a separate source. Pages are fetched
+// opts.Concurrency at a time, at most opts.Rate per second, and indexed one
+// at a time as they arrive. A page that fails is reported and the rest carry
+// on; already ingested pages are skipped unless opts.Force is set.
+func IngestSitemap(ctx context.Context, client *OpenSearchClient, tika *processing.TikaClient, pages []string, opts SitemapOptions) (SitemapResult, error) {
+	var result SitemapResult
+
+	var todo []string
+	for _, page := range pages {
+		if !opts.Force && client.SourceCompleted(ctx, sitemapSourceID(opts.SourcePrefix, page)) {
+			result.Skipped++
+			continue
+		}
+		todo = append(todo, page)
+	}
+	if result.Skipped > 0 {
+		progress.Infof("Skipping %d already ingested pages (use --force to re-ingest them)\n", result.Skipped)
+	}
+	if len(todo) == 0 {
+		return result, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	fetched := fetchPages(ctx, client.settings.Temp, todo, opts)
+
+	for i := range todo {
+		page, ok := <-fetched
+		if !ok {
+			// The workers only stop early when ctx is done.
+			return result, ctx.Err()
+		}
+
+		progress.Infof("[%d/%d] %s\n", i+1, len(todo), page.url)
+		err := page.err
+		if err == nil {
+			err = client.IngestSource(ctx, tika, IngestOptions{
+				FilePath:     page.path,
+				SourceID:     page.sourceID,
+				MetadataPath: page.url,
+				TargetIndex:  opts.TargetIndex,
+				Label:        opts.Label,
+				Tags:         opts.Tags,
+				Force:        opts.Force,
+				ETag:         page.meta.ETag,
+				LastModified: page.meta.LastModified,
+				Trigger:      TriggerSitemap,
+			})
+			page.cleanup()
+		}
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		if err != nil {
+			progress.Failed("Error processing %s: %v\n", page.url, err)
+			result.Failed++
+			continue
+		}
+		result.Ingested++
+	}
+	return result, nil
+}
+
+// fetchPages crawls pages into temp with opts.Concurrency workers, paced to
+// opts.Rate requests per second, and delivers each page on the returned
+// channel as it is ready. Workers stop when ctx is done, removing pages not yet delivered.
+func fetchPages(ctx context.Context, temp processing.TempSettings, pages []string, opts SitemapOptions) <-chan fetchedPage {
+	jobs := make(chan string)
+	fetched := make(chan fetchedPage)
+
+	go func() {
+		defer close(jobs)
+		var tick <-chan time.Time
+		if opts.Rate > 0 {
+			ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.Rate))
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for i, page := range pages {
+			if tick != nil && i > 0 {
+				select {
+				case <-tick:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case jobs <- page:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range max(opts.Concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for page := range jobs {
+				path, meta, cleanup, err := processing.CrawlPage(ctx, temp, page)
+				out := fetchedPage{url: page, sourceID: sitemapSourceID(opts.SourcePrefix, page), path: path, meta: meta, cleanup: cleanup, err: err}
+				select {
+				case fetched <- out:
+				case <-ctx.Done():
+					if cleanup != nil {
+						cleanup()
+					}
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(fetched)
+	}()
+	return fetched
+}
+
+// sitemapSourceID names the source a sitemap page is ingested as: the prefix
+// followed by the page's path and query, or "index" for the site root.
+func sitemapSourceID(prefix, page string) string {
+	u, err := url.Parse(page)
+	if err != nil {
+		return prefix + "/" + page
+	}
+	path := strings.Trim(u.Path, "/")
+	if path == "" {
+		path = "index"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return prefix + "/" + path
+}
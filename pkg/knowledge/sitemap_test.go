@@ -0,0 +1,20 @@
+package knowledge
+
+import "testing"
+
+func TestSitemapSourceID(t *testing.T) {
+	tests := []struct {
+		page string
+		want string
+	}{
+		{"https://docs.example.com/", "docs/index"},
+		{"https://docs.example.com", "docs/index"},
+		{"https://docs.example.com/guide/install/", "docs/guide/install"},
+		{"https://docs.example.com/search?q=tls", "docs/search?q=tls"},
+	}
+	for _, tt := range tests {
+		if got := sitemapSourceID("docs", tt.page); got != tt.want {
+			t.Errorf("sitemapSourceID(docs, %q) = %q, want %q", tt.page, got, tt.want)
+		}
+	}
+}
@@ -13,9 +13,10 @@ import (
 	opensearchapi "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
 )
 
-const (
-	sourcesIndexName = "rag-snap-metadata"
+// sourcesIndexName holds the metadata of every ingested source.
+var sourcesIndexName = artifactName("metadata")
 
+const (
 	StatusProcessing = "processing"
 	StatusCompleted  = "completed"
 	StatusFailed     = "failed"
@@ -37,12 +38,24 @@ type SourceMetadata struct {
 	ChunkOverlap  int    `json:"chunk_overlap"`
 	ContentLength int64  `json:"content_length"`
 	Label         string `json:"label,omitempty"`
-	Status        string `json:"status"`
-	IngestedAt    string `json:"ingested_at"`
-	UpdatedAt     string `json:"updated_at"`
-	Title         string `json:"title,omitempty"`
-	Author        string `json:"author,omitempty"`
-	Language      string `json:"language,omitempty"`
+	// DuplicateChunks counts the chunks left out of ChunkCount because
+	// another source already put them in the base (knowledge.ingest.dedup).
+	DuplicateChunks int `json:"duplicate_chunks,omitempty"`
+	// Tags are user-defined key/value metadata (ingest --metadata), mirrored
+	// onto every chunk so searches can filter on them.
+	Tags       map[string]string `json:"tags,omitempty"`
+	Status     string            `json:"status"`
+	IngestedAt string            `json:"ingested_at"`
+	UpdatedAt  string            `json:"updated_at"`
+	Title      string            `json:"title,omitempty"`
+	Author     string            `json:"author,omitempty"`
+	Language   string            `json:"language,omitempty"`
+	// ETag and LastModified are the cache validators a crawled page was
+	// served with, sent back by knowledge refresh to skip unchanged pages.
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	// Versions is the source's ingest history, oldest first (see History).
+	Versions []SourceVersion `json:"versions,omitempty"`
 }
 
 // CreateSourcesIndex creates the sources metadata index if it does not exist.
@@ -104,6 +117,7 @@ func buildSourcesIndexBody() map[string]any {
 			},
 		},
 		"mappings": map[string]any{
+			"dynamic_templates": tagsDynamicTemplates(),
 			"properties": map[string]any{
 				"source_id":      map[string]any{"type": "keyword"},
 				"file_name":      map[string]any{"type": "keyword"},
@@ -116,6 +130,7 @@ func buildSourcesIndexBody() map[string]any {
 				"chunk_overlap":  map[string]any{"type": "integer"},
 				"content_length": map[string]any{"type": "long"},
 				"label":          map[string]any{"type": "keyword"},
+				"tags":           map[string]any{"type": "object"},
 				"status":         map[string]any{"type": "keyword"},
 				"ingested_at": map[string]any{
 					"type":   "date",
@@ -125,9 +140,13 @@ func buildSourcesIndexBody() map[string]any {
 					"type":   "date",
 					"format": "yyyy-MM-dd HH:mm:ss",
 				},
-				"title":    map[string]any{"type": "text"},
-				"author":   map[string]any{"type": "keyword"},
-				"language": map[string]any{"type": "keyword"},
+				"title":            map[string]any{"type": "text"},
+				"author":           map[string]any{"type": "keyword"},
+				"language":         map[string]any{"type": "keyword"},
+				"duplicate_chunks": map[string]any{"type": "integer"},
+				"etag":             map[string]any{"type": "keyword"},
+				"last_modified":    map[string]any{"type": "keyword"},
+				"versions":         map[string]any{"type": "object", "enabled": false},
 			},
 		},
 	}
@@ -225,7 +244,7 @@ func (c *OpenSearchClient) getSourceMetadata(ctx context.Context, sourceID strin
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("source '%s' not found", sourceID)
+		return nil, fmt.Errorf("%w: '%s'", ErrSourceNotFound, sourceID)
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -245,27 +264,19 @@ func (c *OpenSearchClient) getSourceMetadata(ctx context.Context, sourceID strin
 
 // ListSourceMetadata lists all source metadata documents, optionally filtered by index name.
 func (c *OpenSearchClient) ListSourceMetadata(ctx context.Context, indexName string) ([]SourceMetadata, error) {
-	return c.listSourceMetadata(ctx, indexName)
+	return c.listSourceMetadata(ctx, SourceQuery{IndexName: indexName})
 }
 
-func (c *OpenSearchClient) listSourceMetadata(ctx context.Context, indexName string) ([]SourceMetadata, error) {
-	var query map[string]any
-	if indexName != "" {
-		query = map[string]any{
-			"query": map[string]any{
-				"term": map[string]any{
-					"index_name": indexName,
-				},
-			},
-			"size": 1000,
-		}
-	} else {
-		query = map[string]any{
-			"query": map[string]any{
-				"match_all": map[string]any{},
-			},
-			"size": 1000,
-		}
+// QuerySourceMetadata lists the source metadata documents q selects, in its
+// order.
+func (c *OpenSearchClient) QuerySourceMetadata(ctx context.Context, q SourceQuery) ([]SourceMetadata, error) {
+	return c.listSourceMetadata(ctx, q)
+}
+
+func (c *OpenSearchClient) listSourceMetadata(ctx context.Context, q SourceQuery) ([]SourceMetadata, error) {
+	query, err := q.body()
+	if err != nil {
+		return nil, err
 	}
 
 	bodyBytes, err := json.Marshal(query)
@@ -407,19 +418,26 @@ func (c *OpenSearchClient) DeleteChunksBySourceID(ctx context.Context, indexName
 
 func (c *OpenSearchClient) deleteChunksBySourceID(ctx context.Context, indexName string, sourceID string) (int, error) {
 	query := map[string]any{
-		"query": map[string]any{
-			"term": map[string]any{
-				"source_id": sourceID,
-			},
+		"term": map[string]any{
+			"source_id": sourceID,
 		},
 	}
+	return c.deleteChunksByQuery(ctx, indexName, query, false)
+}
 
-	bodyBytes, err := json.Marshal(query)
+// deleteChunksByQuery deletes the chunks of a KNN index matching query,
+// refreshing the index afterwards when refresh is set. Returns the number of
+// deleted documents.
+func (c *OpenSearchClient) deleteChunksByQuery(ctx context.Context, indexName string, query map[string]any, refresh bool) (int, error) {
+	bodyBytes, err := json.Marshal(map[string]any{"query": query})
 	if err != nil {
 		return 0, fmt.Errorf("error marshaling delete query: %w", err)
 	}
 
 	path := fmt.Sprintf("/%s/_delete_by_query", indexName)
+	if refresh {
+		path += "?refresh=true"
+	}
 	req, err := c.newAuthenticatedRequest(http.MethodPost, path, bytes.NewReader(bodyBytes))
 	if err != nil {
 		return 0, fmt.Errorf("error creating request: %w", err)
@@ -432,8 +450,7 @@ func (c *OpenSearchClient) deleteChunksBySourceID(ctx context.Context, indexName
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("delete chunks failed with status %d: %s", resp.StatusCode, string(body))
+		return 0, statusError("delete chunks", resp)
 	}
 
 	var deleteResp struct {
@@ -0,0 +1,118 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultFindLimit caps how many sources FindSources returns by default.
+const DefaultFindLimit = 50
+
+// FindSources searches source metadata — title, author, file name and path,
+// source ID, and tag values — for text, rather than chunk content, and returns
+// the matching sources best match first. indexNames restricts the search to
+// those knowledge bases; empty searches all of them.
+func (c *OpenSearchClient) FindSources(ctx context.Context, text string, indexNames []string, limit int) ([]SourceMetadata, error) {
+	bodyBytes, err := json.Marshal(buildFindSourcesBody(text, indexNames, limit))
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling search query: %w", err)
+	}
+
+	path := fmt.Sprintf("/%s/_search", sourcesIndexName)
+	req, err := c.newAuthenticatedRequest(http.MethodPost, path, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error searching source metadata: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("find sources failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var searchResp struct {
+		Hits struct {
+			Hits []struct {
+				Source SourceMetadata `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
+		return nil, fmt.Errorf("error decoding search response: %w", err)
+	}
+
+	sources := make([]SourceMetadata, 0, len(searchResp.Hits.Hits))
+	for _, hit := range searchResp.Hits.Hits {
+		sources = append(sources, hit.Source)
+	}
+	return sources, nil
+}
+
+// buildFindSourcesBody matches text against the analyzed title, as a
+// case-insensitive substring of the keyword fields, and as a substring of any
+// tag value. Tag keys are user-defined, so tags are reached through a
+// query_string over tags.*, which — unlike wildcard — accepts a field pattern.
+func buildFindSourcesBody(text string, indexNames []string, limit int) map[string]any {
+	text = strings.TrimSpace(text)
+	pattern := "*" + escapeWildcard(text) + "*"
+
+	should := []map[string]any{
+		{"match": map[string]any{"title": map[string]any{"query": text, "fuzziness": "AUTO", "boost": 3}}},
+	}
+	for _, field := range []string{"source_id", "file_name", "file_path", "author"} {
+		should = append(should, map[string]any{
+			"wildcard": map[string]any{field: map[string]any{"value": pattern, "case_insensitive": true}},
+		})
+	}
+	should = append(should, map[string]any{
+		"query_string": map[string]any{
+			"query":  "*" + escapeQueryString(text) + "*",
+			"fields": []string{"tags.*"},
+		},
+	})
+
+	boolQuery := map[string]any{
+		"should":               should,
+		"minimum_should_match": 1,
+	}
+	if len(indexNames) > 0 {
+		boolQuery["filter"] = []map[string]any{
+			{"terms": map[string]any{"index_name": indexNames}},
+		}
+	}
+	return map[string]any{
+		"query": map[string]any{"bool": boolQuery},
+		"size":  limit,
+	}
+}
+
+// escapeWildcard escapes the wildcard query's special characters.
+func escapeWildcard(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`).Replace(s)
+}
+
+// escapeQueryString escapes the query_string syntax's reserved characters, and
+// whitespace, so text is matched as one literal term.
+func escapeQueryString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`+-=&|><!(){}[]^"~*?:\/ `, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
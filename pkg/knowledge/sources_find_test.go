@@ -0,0 +1,46 @@
+package knowledge
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEscapeQueryString(t *testing.T) {
+	tests := map[string]string{
+		"runbook":      "runbook",
+		"v2.1 (draft)": `v2.1\ \(draft\)`,
+		"a:b/c*":       `a\:b\/c\*`,
+	}
+	for in, want := range tests {
+		if got := escapeQueryString(in); got != want {
+			t.Errorf("escapeQueryString(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestBuildFindSourcesBody(t *testing.T) {
+	body := buildFindSourcesBody("  Run*book ", []string{"rag-snap-context-docs"}, 10)
+
+	raw, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(raw)
+	for _, want := range []string{
+		`"query":"Run*book"`,
+		`"value":"*Run\\*book*"`,
+		`"case_insensitive":true`,
+		`"fields":["tags.*"]`,
+		`"terms":{"index_name":["rag-snap-context-docs"]}`,
+		`"size":10`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("body missing %s:\n%s", want, got)
+		}
+	}
+
+	if _, ok := buildFindSourcesBody("x", nil, 10)["query"].(map[string]any)["bool"].(map[string]any)["filter"]; ok {
+		t.Error("body without bases has an index filter")
+	}
+}
@@ -0,0 +1,135 @@
+package knowledge
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Orders QuerySourceMetadata lists sources in.
+const (
+	// SourceSortIngestedAt lists the most recently ingested sources first.
+	SourceSortIngestedAt = "ingested_at"
+	// SourceSortChunks lists the sources with the most chunks first.
+	SourceSortChunks = "chunks"
+	// SourceSortName lists sources by source ID.
+	SourceSortName = "name"
+)
+
+// maxSourceListing is how many sources a listing returns without a limit.
+const maxSourceListing = 1000
+
+// SourceQuery selects and orders the sources QuerySourceMetadata lists. The
+// zero value lists every source, in no particular order.
+type SourceQuery struct {
+	// IndexName limits the listing to one knowledge base's index.
+	IndexName string
+	// Statuses limits the listing to sources in these states.
+	Statuses []string
+	// Since limits the listing to sources ingested at or after it.
+	Since time.Time
+	// Sort is a SourceSort* order, or "" for none.
+	Sort string
+	// Limit caps how many sources are listed; 0 lists up to 1000.
+	Limit int
+}
+
+// Validate reports the first setting of q that QuerySourceMetadata would
+// reject.
+func (q SourceQuery) Validate() error {
+	for _, status := range q.Statuses {
+		switch status {
+		case StatusProcessing, StatusCompleted, StatusFailed:
+		default:
+			return fmt.Errorf("invalid status %q: expected processing, completed, or failed", status)
+		}
+	}
+	switch q.Sort {
+	case "", SourceSortIngestedAt, SourceSortChunks, SourceSortName:
+	default:
+		return fmt.Errorf("invalid sort %q: expected ingested_at, chunks, or name", q.Sort)
+	}
+	if q.Limit < 0 {
+		return fmt.Errorf("invalid limit %d: expected a positive number", q.Limit)
+	}
+	return nil
+}
+
+// body builds the search request for q against the sources index.
+func (q SourceQuery) body() (map[string]any, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	var filters []any
+	if q.IndexName != "" {
+		filters = append(filters, map[string]any{"term": map[string]any{"index_name": q.IndexName}})
+	}
+	if len(q.Statuses) > 0 {
+		filters = append(filters, map[string]any{"terms": map[string]any{"status": q.Statuses}})
+	}
+	if !q.Since.IsZero() {
+		filters = append(filters, map[string]any{"range": map[string]any{
+			"ingested_at": map[string]any{"gte": q.Since.UTC().Format(DateFormat)},
+		}})
+	}
+
+	query := map[string]any{"match_all": map[string]any{}}
+	if len(filters) > 0 {
+		query = map[string]any{"bool": map[string]any{"filter": filters}}
+	}
+	size := maxSourceListing
+	if q.Limit > 0 {
+		size = q.Limit
+	}
+	body := map[string]any{"query": query, "size": size}
+
+	switch q.Sort {
+	case SourceSortIngestedAt:
+		body["sort"] = []any{map[string]any{"ingested_at": "desc"}, map[string]any{"source_id": "asc"}}
+	case SourceSortChunks:
+		body["sort"] = []any{map[string]any{"chunk_count": "desc"}, map[string]any{"source_id": "asc"}}
+	case SourceSortName:
+		body["sort"] = []any{map[string]any{"source_id": "asc"}}
+	}
+	return body, nil
+}
+
+// Matches reports whether a source with the given status and ingest time is
+// one q selects, for listings filtered after the fact, e.g. through the
+// daemon.
+func (q SourceQuery) Matches(status, ingestedAt string) bool {
+	if len(q.Statuses) > 0 && !slices.Contains(q.Statuses, status) {
+		return false
+	}
+	if !q.Since.IsZero() {
+		at, err := time.Parse(DateFormat, ingestedAt)
+		if err != nil || at.Before(q.Since.UTC().Truncate(time.Second)) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseSince parses a --since value relative to now: a duration such as
+// "36h" or a number of days such as "7d", a date ("2006-01-02"), or a time
+// as sources record it ("2006-01-02 15:04:05"), both in UTC.
+func ParseSince(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.DateOnly, DateFormat} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: expected a duration (36h, 7d), a date (2006-01-02), or a time (2006-01-02 15:04:05)", value)
+}
@@ -0,0 +1,92 @@
+package knowledge
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSourceQueryBody(t *testing.T) {
+	since := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	body, err := SourceQuery{
+		IndexName: "rag-snap-context-docs",
+		Statuses:  []string{StatusFailed},
+		Since:     since,
+		Sort:      SourceSortChunks,
+		Limit:     5,
+	}.body()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(body)
+	got := string(data)
+	for _, want := range []string{
+		`{"term":{"index_name":"rag-snap-context-docs"}}`,
+		`{"terms":{"status":["failed"]}}`,
+		`{"range":{"ingested_at":{"gte":"2026-03-01 12:00:00"}}}`,
+		`"size":5`,
+		`"sort":[{"chunk_count":"desc"},{"source_id":"asc"}]`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("body %s lacks %s", got, want)
+		}
+	}
+
+	data, _ = json.Marshal(mustBody(SourceQuery{}.body()))
+	if got := string(data); got != `{"query":{"match_all":{}},"size":1000}` {
+		t.Errorf("zero query body = %s", got)
+	}
+
+	for _, q := range []SourceQuery{{Statuses: []string{"done"}}, {Sort: "size"}, {Limit: -1}} {
+		if _, err := q.body(); err == nil {
+			t.Errorf("body(%+v) succeeded, want an error", q)
+		}
+	}
+}
+
+func mustBody(body map[string]any, err error) map[string]any {
+	if err != nil {
+		panic(err)
+	}
+	return body
+}
+
+func TestSourceQueryMatches(t *testing.T) {
+	q := SourceQuery{Statuses: []string{StatusCompleted}, Since: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)}
+	tests := []struct {
+		status, at string
+		want       bool
+	}{
+		{StatusCompleted, "2026-03-01 00:00:00", true},
+		{StatusCompleted, "2026-02-28 23:59:59", false},
+		{StatusFailed, "2026-03-02 00:00:00", false},
+		{StatusCompleted, "", false},
+	}
+	for _, tt := range tests {
+		if got := q.Matches(tt.status, tt.at); got != tt.want {
+			t.Errorf("Matches(%q, %q) = %v, want %v", tt.status, tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		"36h":                 now.Add(-36 * time.Hour),
+		"7d":                  now.AddDate(0, 0, -7),
+		"2026-03-01":          time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		"2026-03-01 08:30:00": time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC),
+	}
+	for in, want := range tests {
+		got, err := ParseSince(in, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("ParseSince(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "yesterday", "-3d"} {
+		if _, err := ParseSince(in, now); err == nil {
+			t.Errorf("ParseSince(%q) succeeded, want an error", in)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+)
+
+// SourceMetadataUpdate is a partial edit of a source's descriptive metadata.
+// Nil fields are left unchanged; a pointer to "" clears the field. Tag edits
+// are applied on top of the source's existing tags.
+type SourceMetadataUpdate struct {
+	Title  *string
+	Author *string
+	// SetTags adds or replaces tags.
+	SetTags map[string]string
+	// RemoveTags drops tags by key; removing an absent key is not an error.
+	RemoveTags []string
+}
+
+// IsZero reports whether the update changes nothing.
+func (u SourceMetadataUpdate) IsZero() bool {
+	return u.Title == nil && u.Author == nil && len(u.SetTags) == 0 && len(u.RemoveTags) == 0
+}
+
+// editTags returns existing with u's tag edits applied, and whether the tags
+// changed. existing is not modified.
+func (u SourceMetadataUpdate) editTags(existing map[string]string) (map[string]string, bool) {
+	tags := maps.Clone(existing)
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	for _, key := range u.RemoveTags {
+		delete(tags, key)
+	}
+	maps.Copy(tags, u.SetTags)
+	return tags, !maps.Equal(tags, existing)
+}
+
+// UpdateSourceMetadata applies u to a source's metadata record and returns the
+// updated record. Tag changes are also written to every chunk of the source,
+// since searches filter and report tags from the chunks themselves. Title and
+// author live only on the metadata record.
+func (c *OpenSearchClient) UpdateSourceMetadata(ctx context.Context, sourceID string, u SourceMetadataUpdate) (*SourceMetadata, error) {
+	if err := ValidateTags(u.SetTags); err != nil {
+		return nil, err
+	}
+
+	meta, err := c.getSourceMetadata(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, tagsChanged := u.editTags(meta.Tags)
+	if tagsChanged {
+		if err := c.EnsureSourceTagsMapping(ctx, meta.IndexName); err != nil {
+			return nil, err
+		}
+		if _, err := c.setChunkTags(ctx, meta.IndexName, sourceID, tags); err != nil {
+			return nil, fmt.Errorf("updating chunk tags: %w", err)
+		}
+		meta.Tags = tags
+	}
+	if u.Title != nil {
+		meta.Title = *u.Title
+	}
+	if u.Author != nil {
+		meta.Author = *u.Author
+	}
+	meta.UpdatedAt = now()
+
+	// A scripted update rather than a partial doc: a partial doc merges object
+	// fields, so a removed tag would survive in the stored tags object.
+	fields := map[string]any{
+		"title":      meta.Title,
+		"author":     meta.Author,
+		"tags":       meta.Tags,
+		"updated_at": meta.UpdatedAt,
+	}
+	body := map[string]any{
+		"script": map[string]any{
+			"source": "for (entry in params.fields.entrySet()) { ctx._source[entry.getKey()] = entry.getValue() }",
+			"lang":   "painless",
+			"params": map[string]any{"fields": fields},
+		},
+	}
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling update body: %w", err)
+	}
+
+	path := fmt.Sprintf("/%s/_update/%s?refresh=true", sourcesIndexName, url.PathEscape(sourceID))
+	req, err := c.newAuthenticatedRequest(http.MethodPost, path, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("updating source metadata: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("update source metadata failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return meta, nil
+}
+
+// setChunkTags replaces the tags on every chunk of a source.
+func (c *OpenSearchClient) setChunkTags(ctx context.Context, indexName, sourceID string, tags map[string]string) (int, error) {
+	body := map[string]any{
+		"script": map[string]any{
+			"source": "ctx._source.tags = params.tags",
+			"lang":   "painless",
+			"params": map[string]any{"tags": tags},
+		},
+		"query": map[string]any{
+			"term": map[string]any{"source_id": sourceID},
+		},
+	}
+
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return 0, fmt.Errorf("marshaling update query: %w", err)
+	}
+
+	path := fmt.Sprintf("/%s/_update_by_query?conflicts=proceed&refresh=true", indexName)
+	req, err := c.newAuthenticatedRequest(http.MethodPost, path, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return 0, fmt.Errorf("creating update request: %w", err)
+	}
+
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("update by query failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var updateResp struct {
+		Updated int `json:"updated"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&updateResp); err != nil {
+		return 0, fmt.Errorf("decoding update response: %w", err)
+	}
+	return updateResp.Updated, nil
+}
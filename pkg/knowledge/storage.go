@@ -0,0 +1,158 @@
+package knowledge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jpnorenam/rag-snap/pkg/utils"
+)
+
+// StorageBudget is the knowledge.storage.warn-at setting: the store size past
+// which knowledge list flags a knowledge base. Zero sizes set no budget.
+type StorageBudget struct {
+	// Default applies to every base without an override.
+	Default uint64
+	// Bases holds per-base overrides, by knowledge base name.
+	Bases map[string]uint64
+}
+
+// ParseStorageBudget parses a knowledge.storage.warn-at value: a size with
+// optional M or G suffix for every base, and comma-separated <base>=<size>
+// overrides, e.g. "5G,archive=20G". Either part may be left out; an empty
+// value sets no budget, and a base set to 0 is exempt.
+func ParseStorageBudget(value string) (StorageBudget, error) {
+	var b StorageBudget
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return b, nil
+	}
+	invalid := fmt.Errorf("invalid knowledge.storage.warn-at %q: expected a size with optional M or G suffix, "+
+		"and comma-separated <base>=<size> overrides, e.g. 5G,archive=20G", value)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		base, size, isOverride := strings.Cut(part, "=")
+		if !isOverride {
+			size = part
+		}
+		n, err := utils.StringToBytes(strings.TrimSpace(size))
+		if err != nil {
+			return StorageBudget{}, invalid
+		}
+		if !isOverride {
+			b.Default = n
+			continue
+		}
+		if base = strings.TrimSpace(base); base == "" {
+			return StorageBudget{}, invalid
+		}
+		if b.Bases == nil {
+			b.Bases = make(map[string]uint64)
+		}
+		b.Bases[base] = n
+	}
+	return b, nil
+}
+
+// IsZero reports whether the budget flags no base.
+func (b StorageBudget) IsZero() bool {
+	if b.Default > 0 {
+		return false
+	}
+	for _, n := range b.Bases {
+		if n > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// For returns the budget of the named base, or 0 when it has none.
+func (b StorageBudget) For(base string) uint64 {
+	if n, ok := b.Bases[base]; ok {
+		return n
+	}
+	return b.Default
+}
+
+// Over reports whether a base storing size bytes is past its budget.
+func (b StorageBudget) Over(base string, size uint64) bool {
+	limit := b.For(base)
+	return limit > 0 && size > limit
+}
+
+// IndexStorage is the disk one index takes, from the _stats API.
+type IndexStorage struct {
+	// StoreBytes counts every copy of the index, as _cat/indices store.size.
+	StoreBytes uint64
+	// PrimaryBytes counts the primary shards alone, which retention's
+	// max size is measured against.
+	PrimaryBytes uint64
+	// Docs counts the live documents of the primary shards.
+	Docs int64
+	// DeletedDocs are documents forgotten or replaced but not yet merged
+	// away; their space is reclaimed as segments merge.
+	DeletedDocs int64
+}
+
+// IndexStorageStats returns the disk each knowledge base index takes, keyed by
+// index name. _cat/indices only renders sizes for display, so the exact byte
+// counts come from the _stats API.
+func (c *OpenSearchClient) IndexStorageStats(ctx context.Context) (map[string]IndexStorage, error) {
+	req, err := c.newAuthenticatedRequest(http.MethodGet, "/"+indexPatterns+"/_stats/store,docs", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error getting index stats: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return map[string]IndexStorage{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("index stats request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	return parseIndexStorage(resp.Body)
+}
+
+// parseIndexStorage reads the per-index store sizes and deleted document
+// counts out of an _stats/store,docs response.
+func parseIndexStorage(r io.Reader) (map[string]IndexStorage, error) {
+	type section struct {
+		Docs struct {
+			Count   int64 `json:"count"`
+			Deleted int64 `json:"deleted"`
+		} `json:"docs"`
+		Store struct {
+			SizeInBytes uint64 `json:"size_in_bytes"`
+		} `json:"store"`
+	}
+	var stats struct {
+		Indices map[string]struct {
+			Primaries section `json:"primaries"`
+			Total     section `json:"total"`
+		} `json:"indices"`
+	}
+	if err := json.NewDecoder(r).Decode(&stats); err != nil {
+		return nil, fmt.Errorf("error decoding index stats: %w", err)
+	}
+
+	storage := make(map[string]IndexStorage, len(stats.Indices))
+	for name, s := range stats.Indices {
+		storage[name] = IndexStorage{
+			StoreBytes:   s.Total.Store.SizeInBytes,
+			PrimaryBytes: s.Primaries.Store.SizeInBytes,
+			Docs:         s.Primaries.Docs.Count,
+			DeletedDocs:  s.Primaries.Docs.Deleted,
+		}
+	}
+	return storage, nil
+}
@@ -0,0 +1,56 @@
+package knowledge
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseStorageBudget(t *testing.T) {
+	b, err := ParseStorageBudget(" 5G, archive=20G ,scratch=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const gib = 1 << 30
+	if b.Default != 5*gib || b.For("docs") != 5*gib || b.For("archive") != 20*gib || b.For("scratch") != 0 {
+		t.Errorf("ParseStorageBudget = %+v", b)
+	}
+	if !b.Over("docs", 6*gib) || b.Over("docs", 5*gib) || b.Over("archive", 6*gib) || b.Over("scratch", 100*gib) {
+		t.Errorf("Over disagrees with budget %+v", b)
+	}
+
+	b, err = ParseStorageBudget("docs=500M")
+	if err != nil || b.Default != 0 || b.For("docs") != 500<<20 || b.Over("other", 100*gib) {
+		t.Errorf("ParseStorageBudget(docs=500M) = %+v, %v", b, err)
+	}
+
+	for _, zero := range []string{"", "  ", "0", "docs=0"} {
+		if b, err := ParseStorageBudget(zero); err != nil || !b.IsZero() {
+			t.Errorf("ParseStorageBudget(%q) = %+v, %v; want no budget", zero, b, err)
+		}
+	}
+	for _, bad := range []string{"5GB", "lots", "=5G", "docs=", "5G,,docs=1G"} {
+		if _, err := ParseStorageBudget(bad); err == nil || !strings.Contains(err.Error(), "knowledge.storage.warn-at") {
+			t.Errorf("ParseStorageBudget(%q) = %v, want a knowledge.storage.warn-at error", bad, err)
+		}
+	}
+}
+
+func TestParseIndexStorage(t *testing.T) {
+	body := `{"_all":{},"indices":{
+		"rag-snap-context-docs":{"primaries":{"docs":{"count":10,"deleted":3},"store":{"size_in_bytes":1000}},
+			"total":{"docs":{"count":20,"deleted":6},"store":{"size_in_bytes":2000}}},
+		"rag-snap-context-empty":{"primaries":{},"total":{}}}}`
+	got, err := parseIndexStorage(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (IndexStorage{StoreBytes: 2000, PrimaryBytes: 1000, Docs: 10, DeletedDocs: 3}); got["rag-snap-context-docs"] != want {
+		t.Errorf("docs storage = %+v, want %+v", got["rag-snap-context-docs"], want)
+	}
+	if s, ok := got["rag-snap-context-empty"]; !ok || s != (IndexStorage{}) {
+		t.Errorf("empty storage = %+v, %v; want zero", s, ok)
+	}
+	if _, err := parseIndexStorage(strings.NewReader("not json")); err == nil {
+		t.Error("parseIndexStorage accepted a malformed body")
+	}
+}
@@ -0,0 +1,52 @@
+package knowledge
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// assignSwapIDs gives docs _ids of a fresh generation, so a swapping ingest
+// can tell the chunks it wrote from the ones they replace. It returns the ids
+// in document order.
+func assignSwapIDs(docs []Document) ([]string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("generating chunk ids: %w", err)
+	}
+	generation := hex.EncodeToString(b)
+	ids := make([]string, len(docs))
+	for i := range docs {
+		ids[i] = fmt.Sprintf("%s-%d", generation, i)
+		docs[i].ID = ids[i]
+	}
+	return ids, nil
+}
+
+// deleteStaleChunks completes a swap: it deletes the chunks of sourceID other
+// than keep and refreshes the index in the same request, so searches go from
+// the old chunk set to the new one without seeing both or neither.
+func (c *OpenSearchClient) deleteStaleChunks(ctx context.Context, indexName, sourceID string, keep []string) (int, error) {
+	query := map[string]any{
+		"bool": map[string]any{
+			"filter":   []any{map[string]any{"term": map[string]any{"source_id": sourceID}}},
+			"must_not": []any{map[string]any{"ids": map[string]any{"values": keep}}},
+		},
+	}
+	return c.deleteChunksByQuery(ctx, indexName, query, true)
+}
+
+// discardSwap rolls back a failed swap: the new chunks written so far are
+// deleted, leaving the source's previous chunks and metadata as they were. Like
+// AbandonSource it uses a fresh context and is best-effort.
+func (c *OpenSearchClient) discardSwap(indexName string, ids []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), abandonTimeout)
+	defer cancel()
+	// Delete by query only finds searchable documents.
+	if err := c.refreshIndex(ctx, indexName); err != nil {
+		return
+	}
+	query := map[string]any{"ids": map[string]any{"values": ids}}
+	_, _ = c.deleteChunksByQuery(ctx, indexName, query, true)
+}
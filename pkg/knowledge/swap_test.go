@@ -0,0 +1,56 @@
+package knowledge
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAssignSwapIDs(t *testing.T) {
+	docs := make([]Document, 3)
+	ids, err := assignSwapIDs(docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != len(docs) {
+		t.Fatalf("got %d ids for %d documents", len(ids), len(docs))
+	}
+	seen := map[string]bool{}
+	for i, id := range ids {
+		if docs[i].ID != id {
+			t.Errorf("docs[%d].ID = %q, want %q", i, docs[i].ID, id)
+		}
+		if seen[id] {
+			t.Errorf("id %q assigned twice", id)
+		}
+		seen[id] = true
+	}
+
+	again, err := assignSwapIDs(make([]Document, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seen[again[0]] {
+		t.Errorf("a second swap reused id %q", again[0])
+	}
+
+	lines, err := bulkLines("rag-snap-context-docs", docs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(lines), `"_id":"`+ids[0]+`"`) {
+		t.Errorf("bulk action does not carry the document id: %s", lines)
+	}
+}
+
+func TestIsURLSource(t *testing.T) {
+	for path, want := range map[string]bool{
+		"https://example.com/docs":  true,
+		"http://example.com":        true,
+		"/home/user/docs/guide.md":  false,
+		"github.com/canonical/repo": false,
+	} {
+		if got := IsURLSource(SourceMetadata{FilePath: path}); got != want {
+			t.Errorf("IsURLSource(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
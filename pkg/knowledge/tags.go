@@ -0,0 +1,133 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// tagKeyPattern constrains user metadata keys. Dots are excluded because
+// OpenSearch would expand "a.b" into a nested object under tags.
+var tagKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+// maxTagValueLength caps a tag value; tags are keyword-mapped, so values are
+// exact-match identifiers rather than free text.
+const maxTagValueLength = 256
+
+// ParseTags parses repeated key=value flag values into a tag map. Keys must be
+// letters, digits, hyphens, and underscores; values must be non-empty and on a
+// single line. A key given twice is rejected rather than silently overwritten.
+func ParseTags(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	tags := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid metadata %q: expected key=value", pair)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if _, dup := tags[key]; dup {
+			return nil, fmt.Errorf("duplicate metadata key %q", key)
+		}
+		tags[key] = value
+	}
+	if err := ValidateTags(tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// ValidateTags rejects tag maps with malformed keys or values.
+func ValidateTags(tags map[string]string) error {
+	for key, value := range tags {
+		if !tagKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid metadata key %q: keys must be letters, digits, hyphens, and underscores, and at most 64 characters", key)
+		}
+		if value == "" {
+			return fmt.Errorf("metadata key %q has an empty value", key)
+		}
+		if len(value) > maxTagValueLength || strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("invalid value for metadata key %q: values must be a single line of at most %d characters", key, maxTagValueLength)
+		}
+	}
+	return nil
+}
+
+// FormatTags renders tags as a stable, comma-separated key=value list for
+// listings and metadata output.
+func FormatTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + tags[k]
+	}
+	return strings.Join(pairs, ",")
+}
+
+// tagFilterClauses returns one term clause per tag, for use as a bool filter.
+// Clauses are ordered by key so request bodies are deterministic.
+func tagFilterClauses(tags map[string]string) []map[string]any {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	clauses := make([]map[string]any, len(keys))
+	for i, k := range keys {
+		clauses[i] = map[string]any{
+			"term": map[string]any{"tags." + k: tags[k]},
+		}
+	}
+	return clauses
+}
+
+// tagsDynamicTemplates maps every string under tags.* to a keyword, so
+// arbitrary user keys are filterable by exact match without declaring them.
+func tagsDynamicTemplates() []map[string]any {
+	return []map[string]any{
+		{
+			"tags_as_keywords": map[string]any{
+				"path_match":         "tags.*",
+				"match_mapping_type": "string",
+				"mapping":            map[string]any{"type": "keyword"},
+			},
+		},
+	}
+}
+
+// EnsureTagsMapping adds the tags object and its keyword dynamic template to an
+// existing index's mapping. Indexes created before tags existed need this
+// before tagged documents are written; otherwise dynamic mapping would type
+// tag values as analyzed text and exact-match filters would miss.
+func (c *OpenSearchClient) EnsureTagsMapping(ctx context.Context, indexName string) error {
+	body := map[string]any{
+		"dynamic_templates": tagsDynamicTemplates(),
+		"properties": map[string]any{
+			"tags": map[string]any{"type": "object"},
+		},
+	}
+	return c.putMapping(ctx, indexName, body)
+}
+
+// EnsureSourceTagsMapping ensures both the knowledge base index and the sources
+// metadata index can store tags, ahead of a tagged ingest.
+func (c *OpenSearchClient) EnsureSourceTagsMapping(ctx context.Context, indexName string) error {
+	if err := c.getOrCreateSourcesIndex(ctx); err != nil {
+		return fmt.Errorf("ensuring sources index: %w", err)
+	}
+	for _, idx := range []string{indexName, sourcesIndexName} {
+		if err := c.EnsureTagsMapping(ctx, idx); err != nil {
+			return fmt.Errorf("ensuring tags mapping on %q: %w", idx, err)
+		}
+	}
+	return nil
+}
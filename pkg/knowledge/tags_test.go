@@ -0,0 +1,97 @@
+package knowledge
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	got, err := ParseTags([]string{"team=platform", " version = 2.1 ", "url=https://x/y?a=b"})
+	if err != nil {
+		t.Fatalf("ParseTags returned error: %v", err)
+	}
+	want := map[string]string{"team": "platform", "version": "2.1", "url": "https://x/y?a=b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseTags = %v, want %v", got, want)
+	}
+
+	if got, err := ParseTags(nil); err != nil || got != nil {
+		t.Errorf("ParseTags(nil) = (%v, %v), want (nil, nil)", got, err)
+	}
+
+	invalid := [][]string{
+		{"team"},
+		{"=platform"},
+		{"team="},
+		{"team.name=platform"},
+		{"has space=x"},
+		{"team=a", "team=b"},
+	}
+	for _, pairs := range invalid {
+		if _, err := ParseTags(pairs); err == nil {
+			t.Errorf("ParseTags(%q) = nil error, want error", pairs)
+		}
+	}
+}
+
+func TestFormatTags(t *testing.T) {
+	got := FormatTags(map[string]string{"version": "2.1", "team": "platform"})
+	if want := "team=platform,version=2.1"; got != want {
+		t.Errorf("FormatTags = %q, want %q", got, want)
+	}
+	if got := FormatTags(nil); got != "" {
+		t.Errorf("FormatTags(nil) = %q, want empty", got)
+	}
+}
+
+func TestBuildSearchBodyTagFilter(t *testing.T) {
+	body := buildSearchBody("q", "q", "model", 5, SearchOptions{Tags: map[string]string{"team": "platform"}}, defaultSearchBoosts())
+	queries := body["query"].(map[string]any)["hybrid"].(map[string]any)["queries"].([]map[string]any)
+
+	lexical, ok := queries[0]["bool"].(map[string]any)
+	if !ok {
+		t.Fatalf("lexical arm is not wrapped in a bool filter: %v", queries[0])
+	}
+	wantFilter := []map[string]any{{"term": map[string]any{"tags.team": "platform"}}, currentVersionClause()}
+	if !reflect.DeepEqual(lexical["filter"], wantFilter) {
+		t.Errorf("lexical filter = %v, want %v", lexical["filter"], wantFilter)
+	}
+
+	neural := queries[1]["neural"].(map[string]any)["embedding"].(map[string]any)
+	if _, ok := neural["filter"]; !ok {
+		t.Errorf("neural arm has no filter: %v", neural)
+	}
+
+	unfiltered := buildSearchBody("q", "q", "model", 5, SearchOptions{Versions: true}, defaultSearchBoosts())
+	plain := unfiltered["query"].(map[string]any)["hybrid"].(map[string]any)["queries"].([]map[string]any)
+	if _, ok := plain[0]["multi_match"]; !ok {
+		t.Errorf("unfiltered lexical arm = %v, want a bare multi_match", plain[0])
+	}
+}
+
+func TestSourceMetadataUpdateEditTags(t *testing.T) {
+	existing := map[string]string{"team": "platform", "year": "2024"}
+	u := SourceMetadataUpdate{
+		SetTags:    map[string]string{"year": "2025", "status": "draft"},
+		RemoveTags: []string{"team", "absent"},
+	}
+
+	tags, changed := u.editTags(existing)
+	if !changed {
+		t.Fatal("editTags reported no change")
+	}
+	want := map[string]string{"year": "2025", "status": "draft"}
+	if FormatTags(tags) != FormatTags(want) {
+		t.Errorf("editTags = %s, want %s", FormatTags(tags), FormatTags(want))
+	}
+	if existing["team"] != "platform" || existing["year"] != "2024" {
+		t.Errorf("editTags modified its input: %v", existing)
+	}
+
+	if _, changed := (SourceMetadataUpdate{SetTags: map[string]string{"team": "platform"}}).editTags(existing); changed {
+		t.Error("re-setting an existing tag reported a change")
+	}
+	if _, changed := (SourceMetadataUpdate{}).editTags(nil); changed {
+		t.Error("an empty edit of no tags reported a change")
+	}
+}
@@ -0,0 +1,377 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jpnorenam/rag-snap/pkg/processing"
+	"github.com/jpnorenam/rag-snap/pkg/progress"
+	"gopkg.in/yaml.v3"
+)
+
+// Tuning defaults for knowledge tune.
+var (
+	DefaultTuneSizes    = []int{512, 1024, 1536}
+	DefaultTuneOverlaps = []int{0, 100, 200}
+)
+
+const (
+	// DefaultTuneSample is how many sources besides the expected ones are
+	// re-chunked, so the queries compete with unrelated content.
+	DefaultTuneSample = 20
+	// DefaultTuneK is the k recall is measured at.
+	DefaultTuneK = 5
+)
+
+// TuneQuery is one query of a tuning set and the sources a good retrieval
+// returns for it.
+type TuneQuery struct {
+	Query   string   `yaml:"query"`
+	Sources []string `yaml:"sources"`
+}
+
+// ParseTuneQueries reads a tuning set:
+//
+//	queries:
+//	  - query: How do I rotate the TLS certificates?
+//	    sources: [ops/tls.md]
+func ParseTuneQueries(r io.Reader) ([]TuneQuery, error) {
+	var file struct {
+		Queries []TuneQuery `yaml:"queries"`
+	}
+	if err := yaml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("decoding queries: %w", err)
+	}
+	if len(file.Queries) == 0 {
+		return nil, fmt.Errorf("no queries found")
+	}
+	for i, q := range file.Queries {
+		if strings.TrimSpace(q.Query) == "" {
+			return nil, fmt.Errorf("query %d is empty", i+1)
+		}
+		if len(q.Sources) == 0 {
+			return nil, fmt.Errorf("query %d (%q) lists no expected sources", i+1, q.Query)
+		}
+	}
+	return file.Queries, nil
+}
+
+// TuneOptions shapes a tuning run.
+type TuneOptions struct {
+	// Sizes and Overlaps are crossed into the candidate chunk settings;
+	// pairs whose overlap is not below the size are left out.
+	Sizes    []int
+	Overlaps []int
+	// Sample is how many sources besides the expected ones are re-chunked.
+	Sample int
+	// K is the number of hits recall is measured at.
+	K                int
+	EmbeddingModelID string
+}
+
+// TuneResult is how one candidate chunk setting scored.
+type TuneResult struct {
+	Chunking processing.ChunkOptions
+	// Recall is the mean recall@k over the queries: the share of each
+	// query's expected sources found in its top k hits.
+	Recall float64
+	// Chunks is how many chunks the sampled sources made.
+	Chunks int
+	// Current is set for the base's own chunk setting.
+	Current bool
+}
+
+// Tune re-chunks a sample of the base's sources at each candidate chunk
+// setting into a temporary index, measures recall@k of queries on it, and
+// returns the results best first. The base itself, and its source records,
+// are not touched; the temporary indexes are deleted as each candidate is
+// done.
+func (c *OpenSearchClient) Tune(ctx context.Context, tika *processing.TikaClient, indexName string, queries []TuneQuery, opts TuneOptions) ([]TuneResult, error) {
+	settings, err := c.GetBaseSettings(ctx, indexName)
+	if err != nil {
+		return nil, fmt.Errorf("reading base settings: %w", err)
+	}
+	sources, err := c.ListSourceMetadata(ctx, indexName)
+	if err != nil {
+		return nil, err
+	}
+	sample, err := tuneSample(sources, queries, opts.Sample)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := expectedSources(queries)
+	var files []tuneFile
+	defer func() {
+		for _, f := range files {
+			f.cleanup()
+		}
+	}()
+	for _, meta := range sample {
+		f, err := fetchTuneFile(ctx, c.settings.Temp, meta)
+		if err != nil {
+			if expected[meta.SourceID] {
+				return nil, fmt.Errorf("re-reading expected source %s: %w", meta.SourceID, err)
+			}
+			progress.Printf("Skipping %s: %v\n", meta.SourceID, err)
+			continue
+		}
+		files = append(files, f)
+	}
+
+	var preset *Preset
+	if settings.Preset != "" {
+		if p, err := LookupPreset(settings.Preset); err == nil {
+			preset = &p
+		}
+	}
+
+	var results []TuneResult
+	for _, chunking := range tuneCandidates(settings.Chunking, opts.Sizes, opts.Overlaps) {
+		progress.Infof("Evaluating chunk size %d, overlap %d on %d sources\n", chunking.Size, chunking.Overlap, len(files))
+		result, err := c.tuneCandidate(ctx, tika, indexName, chunking, preset, files, queries, opts)
+		if err != nil {
+			return nil, err
+		}
+		result.Current = chunking == settings.Chunking
+		results = append(results, result)
+	}
+	rankTuneResults(results)
+	return results, nil
+}
+
+// tuneCandidate scores one chunk setting in a temporary index of its own.
+func (c *OpenSearchClient) tuneCandidate(ctx context.Context, tika *processing.TikaClient, indexName string, chunking processing.ChunkOptions, preset *Preset, files []tuneFile, queries []TuneQuery, opts TuneOptions) (TuneResult, error) {
+	result := TuneResult{Chunking: chunking}
+	tmpIndex := fmt.Sprintf("%s-tune-%d-%d", indexName, chunking.Size, chunking.Overlap)
+
+	var err error
+	if preset != nil {
+		err = c.CreateIndexWithPreset(ctx, tmpIndex, *preset)
+	} else {
+		err = c.CreateIndex(ctx, tmpIndex)
+	}
+	if err != nil {
+		return result, fmt.Errorf("creating temporary index: %w", err)
+	}
+	defer func() {
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), abandonTimeout)
+		defer cancel()
+		_ = c.DeleteIndex(cleanupCtx, tmpIndex)
+	}()
+	if err := c.EnsureLabelMapping(ctx, tmpIndex); err != nil {
+		return result, fmt.Errorf("ensuring label mapping: %w", err)
+	}
+
+	for _, f := range files {
+		ingested, err := processing.IngestChunked(ctx, tika, f.path, f.sourceID, "", chunking)
+		if err != nil {
+			return result, fmt.Errorf("re-chunking %s: %w", f.sourceID, err)
+		}
+		docs := make([]Document, len(ingested.Chunks))
+		for i, chunk := range ingested.Chunks {
+			docs[i] = DocumentFromChunk(chunk, "", nil)
+		}
+		bulk, err := c.BulkIndex(ctx, tmpIndex, docs)
+		if err != nil {
+			return result, fmt.Errorf("indexing %s: %w", f.sourceID, err)
+		}
+		if bulk.Errors > 0 {
+			return result, fmt.Errorf("indexing %s: %d/%d documents failed: %s", f.sourceID, bulk.Errors, bulk.Total, bulk.FirstError)
+		}
+		result.Chunks += len(docs)
+	}
+	if err := c.refreshIndex(ctx, tmpIndex); err != nil {
+		return result, err
+	}
+
+	var total float64
+	for _, q := range queries {
+		hits, err := c.Search(ctx, []string{tmpIndex}, q.Query, q.Query, opts.EmbeddingModelID, opts.K)
+		if err != nil {
+			return result, fmt.Errorf("searching %q: %w", q.Query, err)
+		}
+		total += recallAtK(hits, q.Sources, opts.K)
+	}
+	result.Recall = total / float64(len(queries))
+	return result, nil
+}
+
+// SetBaseChunking changes the chunking later ingests into the base apply,
+// keeping the rest of its preset record. Chunks already indexed keep the
+// setting they were made with until their source is re-ingested.
+func (c *OpenSearchClient) SetBaseChunking(ctx context.Context, indexName string, chunking processing.ChunkOptions) error {
+	meta, err := c.getIndexMeta(ctx, indexName)
+	if err != nil {
+		return err
+	}
+	settings := baseSettingsFromMeta(meta)
+	meta["preset"] = presetMeta{
+		Name:          settings.Preset,
+		Strategy:      chunking.Strategy,
+		ChunkSize:     chunking.Size,
+		ChunkOverlap:  chunking.Overlap,
+		IndexFilePath: settings.IndexFilePath,
+	}
+	return c.putMapping(ctx, indexName, map[string]any{"_meta": meta})
+}
+
+// refreshIndex makes everything written to the index searchable.
+func (c *OpenSearchClient) refreshIndex(ctx context.Context, indexName string) error {
+	req, err := c.newAuthenticatedRequest(http.MethodPost, fmt.Sprintf("/%s/_refresh", indexName), nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("error refreshing index: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("refresh index request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// tuneFile is a sampled source's content, re-read for re-chunking.
+type tuneFile struct {
+	sourceID string
+	path     string
+	cleanup  func()
+}
+
+// fetchTuneFile re-reads a source from where it was ingested from: its page
+// for a crawled URL, fetched into temp, otherwise its file, which must still
+// be there.
+func fetchTuneFile(ctx context.Context, temp processing.TempSettings, meta SourceMetadata) (tuneFile, error) {
+	f := tuneFile{sourceID: meta.SourceID, path: meta.FilePath, cleanup: func() {}}
+	if IsURLSource(meta) {
+		path, _, cleanup, err := processing.CrawlPage(ctx, temp, meta.FilePath)
+		if err != nil {
+			return f, err
+		}
+		f.path, f.cleanup = path, cleanup
+		return f, nil
+	}
+	if _, err := os.Stat(meta.FilePath); err != nil {
+		return f, fmt.Errorf("source file no longer readable: %w", err)
+	}
+	return f, nil
+}
+
+// expectedSources is the set of sources the queries expect.
+func expectedSources(queries []TuneQuery) map[string]bool {
+	expected := map[string]bool{}
+	for _, q := range queries {
+		for _, s := range q.Sources {
+			expected[s] = true
+		}
+	}
+	return expected
+}
+
+// tuneSample picks the sources to re-chunk: every source the queries expect,
+// and up to n others spread evenly over the rest by source id, so reruns
+// compare the same sample. An expected source missing from the base is an
+// error, since no setting could find it.
+func tuneSample(sources []SourceMetadata, queries []TuneQuery, n int) ([]SourceMetadata, error) {
+	expected := expectedSources(queries)
+	byID := make(map[string]SourceMetadata, len(sources))
+	for _, s := range sources {
+		byID[s.SourceID] = s
+	}
+
+	var sample, others []SourceMetadata
+	var missing []string
+	for id := range expected {
+		s, ok := byID[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		sample = append(sample, s)
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("expected sources not in the knowledge base: %s", strings.Join(missing, ", "))
+	}
+	for _, s := range sources {
+		if !expected[s.SourceID] {
+			others = append(others, s)
+		}
+	}
+
+	sort.Slice(sample, func(i, j int) bool { return sample[i].SourceID < sample[j].SourceID })
+	sort.Slice(others, func(i, j int) bool { return others[i].SourceID < others[j].SourceID })
+	if n >= len(others) {
+		return append(sample, others...), nil
+	}
+	for i := 0; i < n; i++ {
+		sample = append(sample, others[i*len(others)/n])
+	}
+	return sample, nil
+}
+
+// tuneCandidates crosses sizes and overlaps into chunk settings with the
+// base's strategy, always including the base's current setting so the
+// results show whether a change is worth it.
+func tuneCandidates(current processing.ChunkOptions, sizes, overlaps []int) []processing.ChunkOptions {
+	candidates := []processing.ChunkOptions{current}
+	seen := map[processing.ChunkOptions]bool{current: true}
+	for _, size := range sizes {
+		for _, overlap := range overlaps {
+			opts := processing.ChunkOptions{Size: size, Overlap: overlap, Strategy: current.Strategy}
+			if size <= 0 || overlap < 0 || overlap >= size || seen[opts] {
+				continue
+			}
+			seen[opts] = true
+			candidates = append(candidates, opts)
+		}
+	}
+	return candidates
+}
+
+// recallAtK returns the share of expected sources among the sources of the
+// top k hits.
+func recallAtK(hits []SearchHit, expected []string, k int) float64 {
+	if len(expected) == 0 {
+		return 0
+	}
+	if len(hits) > k {
+		hits = hits[:k]
+	}
+	found := map[string]bool{}
+	for _, h := range hits {
+		found[h.SourceID] = true
+	}
+	n := 0
+	for _, s := range expected {
+		if found[s] {
+			n++
+		}
+	}
+	return float64(n) / float64(len(expected))
+}
+
+// rankTuneResults orders results best first: by recall, then the current
+// setting, since a tie is not worth a change, then by fewer chunks.
+func rankTuneResults(results []TuneResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Recall != b.Recall {
+			return a.Recall > b.Recall
+		}
+		if a.Current != b.Current {
+			return a.Current
+		}
+		return a.Chunks < b.Chunks
+	})
+}
@@ -0,0 +1,128 @@
+package knowledge
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jpnorenam/rag-snap/pkg/processing"
+)
+
+func TestParseTuneQueries(t *testing.T) {
+	queries, err := ParseTuneQueries(strings.NewReader(`
+queries:
+  - query: How do I rotate the TLS certificates?
+    sources: [ops/tls.md]
+  - query: Which ports does the cluster use?
+    sources: [ops/network.md, ops/firewall.md]
+`))
+	if err != nil {
+		t.Fatalf("ParseTuneQueries: %v", err)
+	}
+	if len(queries) != 2 || len(queries[1].Sources) != 2 || queries[0].Sources[0] != "ops/tls.md" {
+		t.Errorf("queries = %+v", queries)
+	}
+
+	for name, input := range map[string]string{
+		"no queries":   "queries: []\n",
+		"empty query":  "queries:\n  - query: ''\n    sources: [a]\n",
+		"no sources":   "queries:\n  - query: what\n",
+		"invalid yaml": "queries: [\n",
+	} {
+		if _, err := ParseTuneQueries(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: ParseTuneQueries = nil error, want error", name)
+		}
+	}
+}
+
+func TestTuneCandidates(t *testing.T) {
+	current := processing.ChunkOptions{Size: 1024, Overlap: 200, Strategy: processing.ChunkStrategyMarkdown}
+	got := tuneCandidates(current, []int{512, 1024}, []int{0, 200, 600})
+
+	want := []processing.ChunkOptions{
+		current,
+		{Size: 512, Overlap: 0, Strategy: processing.ChunkStrategyMarkdown},
+		{Size: 512, Overlap: 200, Strategy: processing.ChunkStrategyMarkdown},
+		{Size: 1024, Overlap: 0, Strategy: processing.ChunkStrategyMarkdown},
+		{Size: 1024, Overlap: 600, Strategy: processing.ChunkStrategyMarkdown},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("tuneCandidates = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("candidate %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestTuneSample(t *testing.T) {
+	var sources []SourceMetadata
+	for _, id := range []string{"a", "b", "c", "d", "e", "f", "want"} {
+		sources = append(sources, SourceMetadata{SourceID: id})
+	}
+	queries := []TuneQuery{{Query: "q", Sources: []string{"want"}}}
+
+	sample, err := tuneSample(sources, queries, 3)
+	if err != nil {
+		t.Fatalf("tuneSample: %v", err)
+	}
+	var ids []string
+	for _, s := range sample {
+		ids = append(ids, s.SourceID)
+	}
+	if got := strings.Join(ids, ","); got != "want,a,c,e" {
+		t.Errorf("sample = %s, want want,a,c,e", got)
+	}
+
+	if sample, _ := tuneSample(sources, queries, 10); len(sample) != len(sources) {
+		t.Errorf("sample larger than the base = %d sources, want %d", len(sample), len(sources))
+	}
+
+	queries = append(queries, TuneQuery{Query: "q2", Sources: []string{"gone"}})
+	if _, err := tuneSample(sources, queries, 3); err == nil || !strings.Contains(err.Error(), "gone") {
+		t.Errorf("tuneSample with a missing source = %v, want an error naming it", err)
+	}
+}
+
+func TestRecallAtK(t *testing.T) {
+	hits := []SearchHit{{SourceID: "a"}, {SourceID: "a"}, {SourceID: "b"}, {SourceID: "c"}}
+
+	tests := []struct {
+		expected []string
+		k        int
+		want     float64
+	}{
+		{[]string{"a"}, 1, 1},
+		{[]string{"b"}, 2, 0},
+		{[]string{"a", "b"}, 3, 1},
+		{[]string{"a", "c"}, 3, 0.5},
+		{[]string{"a", "c"}, 10, 1},
+		{nil, 3, 0},
+	}
+	for _, tt := range tests {
+		if got := recallAtK(hits, tt.expected, tt.k); got != tt.want {
+			t.Errorf("recallAtK(%v, k=%d) = %v, want %v", tt.expected, tt.k, got, tt.want)
+		}
+	}
+}
+
+func TestRankTuneResults(t *testing.T) {
+	results := []TuneResult{
+		{Chunking: processing.ChunkOptions{Size: 512}, Recall: 0.8, Chunks: 40},
+		{Chunking: processing.ChunkOptions{Size: 1536}, Recall: 0.9, Chunks: 10},
+		{Chunking: processing.ChunkOptions{Size: 1024}, Recall: 0.9, Chunks: 20, Current: true},
+		{Chunking: processing.ChunkOptions{Size: 2048}, Recall: 0.8, Chunks: 8},
+	}
+	rankTuneResults(results)
+
+	var sizes []int
+	for _, r := range results {
+		sizes = append(sizes, r.Chunking.Size)
+	}
+	want := []int{1024, 1536, 2048, 512}
+	for i := range want {
+		if sizes[i] != want[i] {
+			t.Fatalf("ranked sizes = %v, want %v", sizes, want)
+		}
+	}
+}
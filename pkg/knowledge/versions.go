@@ -0,0 +1,225 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/user"
+)
+
+// What triggered an ingest, as recorded in a source's version history.
+const (
+	TriggerIngest  = "ingest"
+	TriggerBatch   = "batch"
+	TriggerSitemap = "sitemap"
+	TriggerRefresh = "refresh"
+	TriggerRetry   = "retry"
+	TriggerDaemon  = "daemon"
+)
+
+// maxSourceVersions is how many versions a source's history keeps; older ones
+// are dropped from it, along with any chunks archived for them.
+const maxSourceVersions = 50
+
+// SourceVersion records one ingest of a source.
+type SourceVersion struct {
+	// Version numbers a source's ingests from 1.
+	Version    int    `json:"version"`
+	Checksum   string `json:"checksum"`
+	ChunkCount int    `json:"chunk_count"`
+	IngestedAt string `json:"ingested_at"`
+	// Trigger is the Trigger* that ran the ingest, and User the system user
+	// it ran as; both are empty for versions recorded before history was.
+	Trigger string `json:"trigger,omitempty"`
+	User    string `json:"user,omitempty"`
+	// ArchivedAs is the versioned source ID the version's chunks were kept
+	// under when it was replaced with --keep-versions (see VersionedSourceID).
+	ArchivedAs string `json:"archived_as,omitempty"`
+}
+
+// VersionedSourceID is the source ID the chunks of version n of sourceID are
+// archived under when a re-ingest keeps them.
+func VersionedSourceID(sourceID string, n int) string {
+	return fmt.Sprintf("%s@v%d", sourceID, n)
+}
+
+// versionedSourcePrefix prefixes the source IDs of every archived version of
+// sourceID.
+func versionedSourcePrefix(sourceID string) string {
+	return sourceID + "@v"
+}
+
+// History returns meta's versions, oldest first. A source ingested before
+// versions were recorded gets one, rebuilt from its current record.
+func (meta *SourceMetadata) History() []SourceVersion {
+	if len(meta.Versions) > 0 {
+		return meta.Versions
+	}
+	at := meta.UpdatedAt
+	if at == "" {
+		at = meta.IngestedAt
+	}
+	return []SourceVersion{{Version: 1, Checksum: meta.Checksum, ChunkCount: meta.ChunkCount, IngestedAt: at}}
+}
+
+// versionPlan is how a re-ingest updates a source's history.
+type versionPlan struct {
+	// versions is the new history, ending with the version being ingested.
+	versions []SourceVersion
+	// archiveAs is the versioned source ID to keep the replaced chunks under,
+	// or "" to delete them.
+	archiveAs string
+	// prune lists archived source IDs whose chunks are no longer kept.
+	prune []string
+}
+
+// planVersions extends the history of previous, the record of the source
+// being replaced (nil for a first ingest), with current. With keep above 0 the
+// replaced version's chunks are archived, and only the keep newest archives
+// are retained; with keep at 0 existing archives are left as they are.
+func planVersions(previous *SourceMetadata, current SourceVersion, keep int) versionPlan {
+	var plan versionPlan
+	if previous == nil {
+		current.Version = 1
+		plan.versions = []SourceVersion{current}
+		return plan
+	}
+
+	history := append([]SourceVersion(nil), previous.History()...)
+	last := &history[len(history)-1]
+	current.Version = last.Version + 1
+	if keep > 0 {
+		plan.archiveAs = VersionedSourceID(previous.SourceID, last.Version)
+		last.ArchivedAs = plan.archiveAs
+		kept := 0
+		for i := len(history) - 1; i >= 0; i-- {
+			if history[i].ArchivedAs == "" {
+				continue
+			}
+			if kept++; kept > keep {
+				plan.prune = append(plan.prune, history[i].ArchivedAs)
+				history[i].ArchivedAs = ""
+			}
+		}
+	}
+	history = append(history, current)
+	if drop := len(history) - maxSourceVersions; drop > 0 {
+		for _, v := range history[:drop] {
+			if v.ArchivedAs != "" {
+				plan.prune = append(plan.prune, v.ArchivedAs)
+			}
+		}
+		history = history[drop:]
+	}
+	plan.versions = history
+	return plan
+}
+
+// ArchivedSourceIDs returns the versioned source IDs meta's archived chunks
+// are kept under.
+func (meta *SourceMetadata) ArchivedSourceIDs() []string {
+	var ids []string
+	for _, v := range meta.Versions {
+		if v.ArchivedAs != "" {
+			ids = append(ids, v.ArchivedAs)
+		}
+	}
+	return ids
+}
+
+// DeleteArchivedChunks deletes the chunks kept for meta's archived versions,
+// e.g. when the source is forgotten. Returns the number of deleted documents.
+func (c *OpenSearchClient) DeleteArchivedChunks(ctx context.Context, meta *SourceMetadata) (int, error) {
+	ids := meta.ArchivedSourceIDs()
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	query := map[string]any{"terms": map[string]any{"source_id": ids}}
+	return c.deleteChunksByQuery(ctx, meta.IndexName, query, false)
+}
+
+// pruneVersions deletes the chunks archived under ids once a re-ingest no
+// longer keeps them. It is best-effort: the ingest itself has succeeded.
+func (c *OpenSearchClient) pruneVersions(ctx context.Context, indexName string, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	query := map[string]any{"terms": map[string]any{"source_id": ids}}
+	_, _ = c.deleteChunksByQuery(ctx, indexName, query, false)
+}
+
+// currentVersionClause is the filter leaving out the chunks archiveChunks
+// kept, which searches only return when asked to (SearchOptions.Versions).
+func currentVersionClause() map[string]any {
+	return map[string]any{
+		"bool": map[string]any{
+			"must_not": []any{map[string]any{"term": map[string]any{"archived": true}}},
+		},
+	}
+}
+
+// archiveChunks moves the chunks of sourceID, other than keep, to archivedID,
+// refreshing the index in the same request; like deleteStaleChunks, but the
+// replaced chunks stay in the index under their versioned source ID, marked
+// archived so searches leave them out.
+func (c *OpenSearchClient) archiveChunks(ctx context.Context, indexName, sourceID, archivedID string, keep []string) (int, error) {
+	query := map[string]any{"term": map[string]any{"source_id": sourceID}}
+	if len(keep) > 0 {
+		query = map[string]any{
+			"bool": map[string]any{
+				"filter":   []any{query},
+				"must_not": []any{map[string]any{"ids": map[string]any{"values": keep}}},
+			},
+		}
+	}
+	body := map[string]any{
+		"script": map[string]any{
+			"source": "ctx._source.source_id = params.id; ctx._source.archived = true",
+			"lang":   "painless",
+			"params": map[string]any{"id": archivedID},
+		},
+		"query": query,
+	}
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return 0, fmt.Errorf("marshaling archive query: %w", err)
+	}
+
+	path := fmt.Sprintf("/%s/_update_by_query?conflicts=proceed&refresh=true", indexName)
+	req, err := c.newAuthenticatedRequest(http.MethodPost, path, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return 0, fmt.Errorf("creating archive request: %w", err)
+	}
+
+	resp, err := c.client.Client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("archiving chunks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("archive chunks failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var updateResp struct {
+		Updated int `json:"updated"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&updateResp); err != nil {
+		return 0, fmt.Errorf("decoding archive response: %w", err)
+	}
+	return updateResp.Updated, nil
+}
+
+// CurrentUser names the system user the process runs as, as recorded in a
+// source's version history and on the chat memories it saves.
+func CurrentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
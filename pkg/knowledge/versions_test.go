@@ -0,0 +1,71 @@
+package knowledge
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlanVersionsFirstIngest(t *testing.T) {
+	plan := planVersions(nil, SourceVersion{Checksum: "aa", ChunkCount: 3}, 2)
+	if len(plan.versions) != 1 || plan.versions[0].Version != 1 || plan.archiveAs != "" || plan.prune != nil {
+		t.Errorf("first ingest plan = %+v", plan)
+	}
+}
+
+func TestPlanVersionsLegacyRecord(t *testing.T) {
+	previous := &SourceMetadata{SourceID: "guide.md", Checksum: "aa", ChunkCount: 3, IngestedAt: "2026-01-01 00:00:00", UpdatedAt: "2026-02-01 00:00:00"}
+	plan := planVersions(previous, SourceVersion{Checksum: "bb", ChunkCount: 4}, 0)
+	want := []SourceVersion{
+		{Version: 1, Checksum: "aa", ChunkCount: 3, IngestedAt: "2026-02-01 00:00:00"},
+		{Version: 2, Checksum: "bb", ChunkCount: 4},
+	}
+	if !reflect.DeepEqual(plan.versions, want) || plan.archiveAs != "" {
+		t.Errorf("plan = %+v, want versions %+v and nothing archived", plan, want)
+	}
+}
+
+func TestPlanVersionsKeep(t *testing.T) {
+	previous := &SourceMetadata{SourceID: "guide.md", Versions: []SourceVersion{
+		{Version: 1, ArchivedAs: "guide.md@v1"},
+		{Version: 2, ArchivedAs: "guide.md@v2"},
+		{Version: 3},
+	}}
+	plan := planVersions(previous, SourceVersion{Checksum: "dd"}, 2)
+	if plan.archiveAs != "guide.md@v3" {
+		t.Errorf("archiveAs = %q, want guide.md@v3", plan.archiveAs)
+	}
+	if !reflect.DeepEqual(plan.prune, []string{"guide.md@v1"}) {
+		t.Errorf("prune = %v, want [guide.md@v1]", plan.prune)
+	}
+	var kept []string
+	for _, v := range plan.versions {
+		kept = append(kept, v.ArchivedAs)
+	}
+	if want := []string{"", "guide.md@v2", "guide.md@v3", ""}; !reflect.DeepEqual(kept, want) {
+		t.Errorf("archived = %q, want %q", kept, want)
+	}
+	if previous.Versions[2].ArchivedAs != "" {
+		t.Error("planVersions modified the previous record")
+	}
+
+	// Without --keep-versions, existing archives stay.
+	plan = planVersions(previous, SourceVersion{}, 0)
+	if plan.archiveAs != "" || plan.prune != nil || plan.versions[1].ArchivedAs != "guide.md@v2" {
+		t.Errorf("keep 0 plan = %+v", plan)
+	}
+}
+
+func TestPlanVersionsCapsHistory(t *testing.T) {
+	previous := &SourceMetadata{SourceID: "s"}
+	for i := 1; i <= maxSourceVersions; i++ {
+		previous.Versions = append(previous.Versions, SourceVersion{Version: i})
+	}
+	previous.Versions[0].ArchivedAs = "s@v1"
+	plan := planVersions(previous, SourceVersion{}, 0)
+	if len(plan.versions) != maxSourceVersions || plan.versions[0].Version != 2 {
+		t.Errorf("history has %d versions starting at %d, want %d starting at 2", len(plan.versions), plan.versions[0].Version, maxSourceVersions)
+	}
+	if !reflect.DeepEqual(plan.prune, []string{"s@v1"}) {
+		t.Errorf("prune = %v, want the dropped version's archive", plan.prune)
+	}
+}
@@ -0,0 +1,690 @@
+package processing
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	DefaultChunkSize    = 1024
+	DefaultChunkOverlap = 200
+
+	// dateFormat matches the OpenSearch index mapping format: "yyyy-MM-dd HH:mm:ss"
+	dateFormat = "2006-01-02 15:04:05"
+)
+
+// Chunk represents a text segment ready for indexing into OpenSearch.
+// Fields match the KNN index mapping defined in knowledge/indexes.go.
+type Chunk struct {
+	Content   string `json:"content"`
+	SourceID  string `json:"source_id"`
+	CreatedAt string `json:"created_at"`
+	// Ordinal is the chunk's 0-based position within its source.
+	Ordinal int `json:"ordinal"`
+	// StartOffset and EndOffset are the byte span of the extracted Markdown (of
+	// the file itself for source code) the chunk was cut from, excluding any overlap copied from the previous chunk.
+	// Both are -1 for chunks that are not a span of extracted text (structured
+	// and RFP formats) or whose span could not be located.
+	StartOffset int `json:"start_offset"`
+	EndOffset   int `json:"end_offset"`
+	// ContentHash is the SHA-256 hex digest of Content.
+	ContentHash string `json:"content_hash"`
+	// Page is the 1-based page of a paged document (a PDF) the chunk's own
+	// text starts on; 0 when the document has no pages or the span is unknown.
+	Page int `json:"page,omitempty"`
+	// Heading is the trail of Markdown headings of the section the chunk's own
+	// text starts in, e.g. "Install > Prerequisites"; empty before the first
+	// heading and for strategies that do not follow document structure.
+	Heading string `json:"heading,omitempty"`
+	// Language is the programming language of a chunk of source code, e.g.
+	// "go", and Symbol the function, class, or type it declares, e.g.
+	// "Client.Do" for a Go method; both are empty for other chunks
+	// (ChunkSource).
+	Language string `json:"language,omitempty"`
+	Symbol   string `json:"symbol,omitempty"`
+}
+
+// Chunk strategies select how extracted text is split; see ChunkOptions.
+const (
+	// ChunkStrategyMarkdown splits along Markdown structure, keeping tables
+	// whole (ChunkMarkdown).
+	ChunkStrategyMarkdown = "markdown"
+	// ChunkStrategyCode is ChunkStrategyMarkdown that also keeps fenced code
+	// blocks whole (ChunkCode).
+	ChunkStrategyCode = "code"
+	// ChunkStrategyLines packs whole lines, for logs (ChunkLines).
+	ChunkStrategyLines = "lines"
+)
+
+// ChunkOptions configures the text chunking behavior.
+type ChunkOptions struct {
+	// Size is the most bytes a chunk holds, overlap included.
+	Size int
+	// Overlap is how many bytes of the previous chunk's tail a chunk starts
+	// with. It is capped at half of Size.
+	Overlap int
+	// Strategy is one of the ChunkStrategy constants; "" is
+	// ChunkStrategyMarkdown.
+	Strategy string
+	// MaxTokens is the most tokens a chunk holds by EstimateTokens, overlap
+	// included; 0 is DefaultChunkMaxTokens.
+	MaxTokens int
+}
+
+// chunkBudget is how much text one chunk may hold: at most chars bytes and,
+// when tokens is positive, at most tokens estimated tokens.
+type chunkBudget struct {
+	chars  int
+	tokens int
+}
+
+// fits reports whether s is within the budget.
+func (b chunkBudget) fits(s string) bool {
+	if len(s) > b.chars {
+		return false
+	}
+	return b.tokens <= 0 || EstimateTokens(s) <= b.tokens
+}
+
+// budgets splits opts' chunk budget between a chunk's own text and the
+// overlap carried into it from the previous chunk, so that the two joined by
+// a space still fit the whole budget. Without overlap, own is the whole
+// budget. Text that never carries overlap (tables, code) uses opts.budget.
+// A budget too small to share gets no overlap.
+func (opts ChunkOptions) budgets() (own, overlap chunkBudget) {
+	whole := opts.budget()
+	overlapChars := min(opts.Overlap, opts.Size/2)
+	if overlapChars <= 0 || opts.Size-overlapChars-1 < 1 || whole.tokens < 2 {
+		return whole, chunkBudget{}
+	}
+	overlap = chunkBudget{chars: overlapChars, tokens: max(1, whole.tokens*overlapChars/opts.Size)}
+	own = chunkBudget{chars: opts.Size - overlapChars - 1, tokens: whole.tokens - overlap.tokens}
+	return own, overlap
+}
+
+// budget is the whole budget of one chunk.
+func (opts ChunkOptions) budget() chunkBudget {
+	tokens := opts.MaxTokens
+	if tokens <= 0 {
+		tokens = DefaultChunkMaxTokens
+	}
+	return chunkBudget{chars: opts.Size, tokens: tokens}
+}
+
+// DefaultChunkOptions returns the options used for bases without a preset.
+func DefaultChunkOptions() ChunkOptions {
+	return ChunkOptions{Size: DefaultChunkSize, Overlap: DefaultChunkOverlap, Strategy: ChunkStrategyMarkdown}
+}
+
+// chunkContent splits extracted Markdown with the strategy opts selects.
+func chunkContent(text, sourceID string, opts ChunkOptions) []Chunk {
+	switch opts.Strategy {
+	case ChunkStrategyCode:
+		return ChunkCode(text, sourceID, opts)
+	case ChunkStrategyLines:
+		return ChunkLines(text, sourceID, opts)
+	default:
+		return ChunkMarkdown(text, sourceID, opts)
+	}
+}
+
+// ChunkText splits text into overlapping chunks with metadata.
+// It tries to split at natural boundaries (paragraphs, lines, sentences, words)
+// and adds overlap between consecutive chunks for context continuity. The
+// overlap counts against opts.Size and opts.MaxTokens: no chunk exceeds them.
+func ChunkText(text, sourceID string, opts ChunkOptions) []Chunk {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+
+	now := time.Now().UTC().Format(dateFormat)
+	own, overlap := opts.budgets()
+	segments := splitToBudget(strings.TrimSpace(text), own)
+
+	var chunks []Chunk
+	cursor := 0
+	for i, seg := range segments {
+		content := strings.TrimSpace(seg)
+		if content == "" {
+			continue
+		}
+		start, end := locateSpan(text, content, cursor)
+		if end >= 0 {
+			cursor = end
+		}
+
+		// Prepend overlap from the tail of the previous segment
+		if i > 0 {
+			if tail := overlapTail(segments[i-1], overlap); tail != "" {
+				content = tail + " " + content
+			}
+		}
+
+		chunks = append(chunks, Chunk{
+			Content:     content,
+			SourceID:    sourceID,
+			CreatedAt:   now,
+			StartOffset: start,
+			EndOffset:   end,
+		})
+	}
+
+	return numberChunks(chunks)
+}
+
+// separators defines the hierarchy of split points tried in order:
+// paragraph break → line break → sentence end → word boundary.
+var separators = []string{"\n\n", "\n", ". ", " "}
+
+// recursiveSplit splits text into segments no larger than maxSize bytes,
+// trying natural boundary separators before falling back to hard splits.
+func recursiveSplit(text string, maxSize int) []string {
+	return splitToBudget(text, chunkBudget{chars: maxSize})
+}
+
+// splitToBudget splits text into segments that each fit b, trying natural
+// boundary separators before falling back to hard splits.
+func splitToBudget(text string, b chunkBudget) []string {
+	if b.fits(text) {
+		return []string{text}
+	}
+
+	for _, sep := range separators {
+		parts := strings.SplitAfter(text, sep)
+		if len(parts) > 1 && len(parts[0]) < len(text) {
+			return mergeParts(parts, b)
+		}
+	}
+
+	// Last resort: hard split at the longest prefix that fits
+	var result []string
+	for text != "" {
+		n := fittingPrefix(text, b)
+		result = append(result, text[:n])
+		text = text[n:]
+	}
+	return result
+}
+
+// fittingPrefix returns the length of the longest prefix of text that fits b
+// and ends on a rune boundary, and at least one rune's length so a split
+// always makes progress. Both a prefix's length and its token estimate grow
+// with it, so the longest fitting prefix is found by bisection.
+func fittingPrefix(text string, b chunkBudget) int {
+	lo, hi := 0, min(len(text), b.chars)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if b.fits(text[:mid]) {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	for lo > 0 && lo < len(text) && !utf8.RuneStart(text[lo]) {
+		lo--
+	}
+	if lo == 0 {
+		_, lo = utf8.DecodeRuneInString(text)
+	}
+	return lo
+}
+
+// mergeParts combines small text parts into segments that fit b.
+// Parts that do not fit on their own are recursively split further.
+func mergeParts(parts []string, b chunkBudget) []string {
+	var result []string
+	var current strings.Builder
+
+	for _, part := range parts {
+		if current.Len() > 0 && !b.fits(current.String()+part) {
+			result = append(result, current.String())
+			current.Reset()
+		}
+
+		if !b.fits(part) {
+			if current.Len() > 0 {
+				result = append(result, current.String())
+				current.Reset()
+			}
+			sub := splitToBudget(part, b)
+			result = append(result, sub...)
+			continue
+		}
+
+		current.WriteString(part)
+	}
+
+	if current.Len() > 0 {
+		result = append(result, current.String())
+	}
+
+	return result
+}
+
+// blockKind identifies whether a parsed block is prose or a table.
+type blockKind int
+
+const (
+	blockText  blockKind = iota
+	blockTable           // lines starting with |
+	blockCode            // a fenced code block (ChunkCode only)
+)
+
+// block represents a structural segment of Markdown content.
+type block struct {
+	kind    blockKind
+	content string
+	heading string // nearest preceding heading (for context on table chunks)
+}
+
+// ChunkMarkdown splits Markdown text into chunks with structure awareness.
+// Tables are kept atomic when they fit in a single chunk; oversized tables
+// are split with header repetition. Overlap is applied between consecutive
+// prose chunks but skipped across table/prose boundaries. Each chunk records
+// the span of text it was cut from.
+func ChunkMarkdown(text, sourceID string, opts ChunkOptions) []Chunk {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	return chunkParsed(text, sourceID, parseBlocks(strings.TrimSpace(text)), opts)
+}
+
+// ChunkCode is ChunkMarkdown for source code and technical docs: a fenced code
+// block is also kept in one chunk when it fits, even across blank lines, and no
+// overlap is carried into or out of it.
+func ChunkCode(text, sourceID string, opts ChunkOptions) []Chunk {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	return chunkParsed(text, sourceID, groupCodeFences(parseBlocks(strings.TrimSpace(text))), opts)
+}
+
+// ChunkLines splits text into chunks of whole lines, for logs and other
+// line-oriented text where a record must not be cut in two. Only a line that
+// does not fit a chunk on its own is split. Chunks never overlap: each line
+// belongs to exactly one chunk.
+func ChunkLines(text, sourceID string, opts ChunkOptions) []Chunk {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+
+	now := time.Now().UTC().Format(dateFormat)
+	segments := mergeParts(strings.SplitAfter(strings.TrimSpace(text), "\n"), opts.budget())
+
+	var chunks []Chunk
+	cursor := 0
+	for _, seg := range segments {
+		content := strings.TrimSpace(seg)
+		if content == "" {
+			continue
+		}
+		start, end := locateSpan(text, content, cursor)
+		if end >= 0 {
+			cursor = end
+		}
+		chunks = append(chunks, Chunk{
+			Content:     content,
+			SourceID:    sourceID,
+			CreatedAt:   now,
+			StartOffset: start,
+			EndOffset:   end,
+		})
+	}
+
+	return numberChunks(chunks)
+}
+
+// chunkParsed turns parsed blocks into chunks, recording each one's span of text.
+func chunkParsed(text, sourceID string, blocks []block, opts ChunkOptions) []Chunk {
+	now := time.Now().UTC().Format(dateFormat)
+	segments := chunkBlocks(blocks, opts)
+
+	var chunks []Chunk
+	cursor := 0
+	for _, seg := range segments {
+		content := strings.TrimSpace(seg.content)
+		if content == "" {
+			continue
+		}
+		start, end := locateSpan(text, seg.own, cursor)
+		if end >= 0 {
+			cursor = end
+		}
+		chunks = append(chunks, Chunk{
+			Content:     content,
+			SourceID:    sourceID,
+			CreatedAt:   now,
+			StartOffset: start,
+			EndOffset:   end,
+		})
+	}
+
+	assignHeadings(chunks, text)
+	return numberChunks(chunks)
+}
+
+// parseBlocks splits Markdown text on double-newlines and classifies each
+// segment as either a table block or a text block. It tracks the most recent
+// heading to attach as context to table blocks.
+func parseBlocks(text string) []block {
+	paragraphs := strings.Split(text, "\n\n")
+	var blocks []block
+	var currentHeading string
+
+	for _, para := range paragraphs {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+
+		// Check if this paragraph is a heading
+		if strings.HasPrefix(para, "#") {
+			// Extract the heading line (first line if multi-line)
+			lines := strings.SplitN(para, "\n", 2)
+			currentHeading = strings.TrimSpace(lines[0])
+			blocks = append(blocks, block{
+				kind:    blockText,
+				content: para,
+			})
+			continue
+		}
+
+		// Check if this is a table (lines starting with |)
+		lines := strings.Split(para, "\n")
+		isTable := true
+		for _, line := range lines {
+			trimmed := strings.TrimSpace(line)
+			if trimmed != "" && !strings.HasPrefix(trimmed, "|") {
+				isTable = false
+				break
+			}
+		}
+
+		if isTable {
+			blocks = append(blocks, block{
+				kind:    blockTable,
+				content: para,
+				heading: currentHeading,
+			})
+		} else {
+			blocks = append(blocks, block{
+				kind:    blockText,
+				content: para,
+			})
+		}
+	}
+
+	return blocks
+}
+
+// groupCodeFences merges the blocks of each ``` fenced code block, which
+// parseBlocks splits at blank lines, into a single blockCode block. An
+// unterminated fence runs to the end of the text.
+func groupCodeFences(blocks []block) []block {
+	var result []block
+	var code *block
+	for _, b := range blocks {
+		if code != nil {
+			code.content += "\n\n" + b.content
+			if countFences(b.content)%2 == 1 {
+				result = append(result, *code)
+				code = nil
+			}
+			continue
+		}
+		if b.kind == blockText && strings.HasPrefix(b.content, "```") {
+			if countFences(b.content)%2 == 1 {
+				code = &block{kind: blockCode, content: b.content}
+				continue
+			}
+			b.kind = blockCode
+		}
+		result = append(result, b)
+	}
+	if code != nil {
+		result = append(result, *code)
+	}
+	return result
+}
+
+// countFences counts the lines of s that open or close a ``` fence.
+func countFences(s string) int {
+	n := 0
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			n++
+		}
+	}
+	return n
+}
+
+// segment is a chunk's content together with its own text: the content
+// without the overlap prepended from the previous chunk.
+type segment struct {
+	content string
+	own     string
+}
+
+// chunkBlocks processes blocks into segments respecting structure.
+// Text blocks are accumulated and flushed when they exceed the budget left
+// beside the overlap. Table blocks are emitted atomically or split with header
+// repetition.
+func chunkBlocks(blocks []block, opts ChunkOptions) []segment {
+	var result []segment
+	var proseBuf strings.Builder
+	var lastProseSegment string
+	whole := opts.budget()
+	own, overlap := opts.budgets()
+
+	flushProse := func() {
+		if proseBuf.Len() == 0 {
+			return
+		}
+		text := proseBuf.String()
+		proseBuf.Reset()
+
+		// Split oversized prose using the existing recursive splitter
+		segments := splitToBudget(text, own)
+		for i, seg := range segments {
+			own := strings.TrimSpace(seg)
+			if own == "" {
+				continue
+			}
+			content := own
+			// Apply overlap from previous prose segment
+			if i > 0 || lastProseSegment != "" {
+				var prev string
+				if i > 0 {
+					prev = segments[i-1]
+				} else {
+					prev = lastProseSegment
+				}
+				if tail := overlapTail(prev, overlap); tail != "" {
+					content = tail + " " + content
+				}
+			}
+			result = append(result, segment{content: content, own: own})
+		}
+		lastProseSegment = segments[len(segments)-1]
+	}
+
+	for _, b := range blocks {
+		switch b.kind {
+		case blockText:
+			// Check if adding this block would exceed the chunk size
+			addition := b.content
+			if proseBuf.Len() > 0 {
+				addition = "\n\n" + addition
+			}
+			if proseBuf.Len() > 0 && !own.fits(proseBuf.String()+addition) {
+				flushProse()
+			}
+			if proseBuf.Len() > 0 {
+				proseBuf.WriteString("\n\n")
+			}
+			proseBuf.WriteString(b.content)
+
+		case blockCode:
+			// Code is emitted whole when it fits, with no overlap across it.
+			flushProse()
+			lastProseSegment = ""
+			if whole.fits(b.content) {
+				result = append(result, segment{content: b.content, own: b.content})
+			} else {
+				for _, part := range splitToBudget(b.content, whole) {
+					result = append(result, segment{content: part, own: part})
+				}
+			}
+
+		case blockTable:
+			// If the prose buffer contains only the heading that the table
+			// will carry as context, discard it to avoid duplication.
+			if b.heading != "" && strings.TrimSpace(proseBuf.String()) == b.heading {
+				proseBuf.Reset()
+			} else {
+				flushProse()
+			}
+			// No overlap between prose and table
+			lastProseSegment = ""
+
+			prefix := ""
+			if b.heading != "" {
+				prefix = b.heading + "\n\n"
+			}
+			tableContent := prefix + b.content
+
+			if whole.fits(tableContent) {
+				result = append(result, segment{content: tableContent, own: tableContent})
+			} else {
+				for _, part := range splitTable(b.content, b.heading, whole) {
+					result = append(result, segment{content: part, own: part})
+				}
+			}
+		}
+	}
+
+	// Flush remaining prose
+	flushProse()
+
+	return result
+}
+
+// splitTable splits a Markdown table into multiple chunks that fit b,
+// repeating the header row and separator in each chunk for context. A single
+// row too large for a chunk is split like prose (splitRow).
+func splitTable(tableText, heading string, b chunkBudget) []string {
+	lines := strings.Split(tableText, "\n")
+	if len(lines) < 2 {
+		// Not enough lines to be a proper table
+		content := tableText
+		if heading != "" {
+			content = heading + "\n\n" + content
+		}
+		return splitToBudget(content, b)
+	}
+
+	// Extract header (first row) and separator (second row)
+	headerLine := lines[0]
+	sepLine := lines[1]
+	header := headerLine + "\n" + sepLine + "\n"
+
+	prefix := ""
+	if heading != "" {
+		prefix = heading + "\n\n"
+	}
+
+	var result []string
+	if heading != "" && !leavesRoom(prefix+header, b) {
+		// Too little room to repeat the heading: it gets chunks of its own
+		result = splitToBudget(heading, b)
+		prefix = ""
+	}
+
+	headerWithPrefix := prefix + header
+	dataLines := lines[2:]
+
+	var batch strings.Builder
+	batch.WriteString(headerWithPrefix)
+
+	for _, line := range dataLines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		lineWithNewline := line + "\n"
+		if batch.Len() > len(headerWithPrefix) && !b.fits(batch.String()+lineWithNewline) {
+			// Flush current batch
+			result = append(result, strings.TrimRight(batch.String(), "\n"))
+			batch.Reset()
+			batch.WriteString(headerWithPrefix)
+		}
+		if !b.fits(headerWithPrefix + line) {
+			result = append(result, splitRow(line, headerWithPrefix, b)...)
+			continue
+		}
+		batch.WriteString(lineWithNewline)
+	}
+
+	if batch.Len() > len(headerWithPrefix) {
+		result = append(result, strings.TrimRight(batch.String(), "\n"))
+	}
+
+	return result
+}
+
+// splitRow splits a table row too large for one chunk into pieces, each
+// after the header when it leavesRoom, or bare otherwise.
+func splitRow(line, header string, b chunkBudget) []string {
+	if !leavesRoom(header, b) {
+		return splitToBudget(line, b)
+	}
+	rest := chunkBudget{chars: b.chars - len(header), tokens: b.tokens - EstimateTokens(header)}
+	pieces := splitToBudget(line, rest)
+	for i, piece := range pieces {
+		pieces[i] = header + piece
+	}
+	return pieces
+}
+
+// leavesRoom reports whether header takes at most half of b, leaving the
+// rest for the rows repeated under it.
+func leavesRoom(header string, b chunkBudget) bool {
+	return len(header) <= b.chars/2 && (b.tokens <= 0 || EstimateTokens(header) <= b.tokens/2)
+}
+
+// overlapTail returns the tail of prev carried into the next chunk: at most
+// b.chars bytes, starting at a word boundary, and cut down word by word to
+// b.tokens estimated tokens. It is empty when b allows no overlap.
+func overlapTail(prev string, b chunkBudget) string {
+	if b.chars <= 0 {
+		return ""
+	}
+	tail := strings.TrimSpace(tailChars(prev, b.chars))
+	for tail != "" && !b.fits(tail) {
+		i := strings.IndexFunc(tail, unicode.IsSpace)
+		if i < 0 {
+			return ""
+		}
+		tail = strings.TrimSpace(tail[i:])
+	}
+	return tail
+}
+
+// tailChars returns the last n characters of s, breaking at a word boundary.
+func tailChars(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	sub := s[len(s)-n:]
+	if idx := strings.Index(sub, " "); idx >= 0 {
+		return sub[idx+1:]
+	}
+	// No word boundary: at least start on a whole character
+	for len(sub) > 0 && !utf8.RuneStart(sub[0]) {
+		sub = sub[1:]
+	}
+	return sub
+}
@@ -0,0 +1,170 @@
+package processing
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"  \n\t", 0},
+		{"cat", 1},
+		{"tokenizer", 3},
+		{"Hello, world!", 6},
+		{"v1.2.3", 5},
+		{"日本語", 3},
+		{"see 日本 here", 4},
+	}
+	for _, tt := range tests {
+		if got := EstimateTokens(tt.text); got != tt.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestChunkTextOverlapWithinSize(t *testing.T) {
+	text := strings.Repeat("alpha beta gamma delta epsilon. ", 40)
+	opts := ChunkOptions{Size: 100, Overlap: 30}
+	chunks := ChunkText(text, "src", opts)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want several", len(chunks))
+	}
+	for _, c := range chunks {
+		if len(c.Content) > opts.Size {
+			t.Errorf("chunk %d is %d bytes, over the size of %d: %q", c.Ordinal, len(c.Content), opts.Size, c.Content)
+		}
+	}
+	if !strings.HasPrefix(chunks[1].Content, "epsilon.") && !strings.Contains(chunks[1].Content, "epsilon. ") {
+		t.Errorf("second chunk %q carries no overlap", chunks[1].Content)
+	}
+}
+
+// words and fragments the random documents are built from.
+var (
+	chunkWords = []string{
+		"the", "index", "snap", "cluster", "OpenSearch", "embedding", "a", "of",
+		"configuration", "retrieval", "v1.2.3", "e.g.", "x86_64", "naïve", "café",
+		"日本語", "検索", "Привет", "1024", "(see", "below)", "--flag", "key=value",
+	}
+	chunkPunct = []string{".", ",", ";", ":", "!", "?"}
+)
+
+// randomDocument builds Markdown-like text with paragraphs, headings,
+// tables, code fences, and words longer than any chunk.
+func randomDocument(r *rand.Rand) string {
+	var b strings.Builder
+	for range 1 + r.IntN(12) {
+		switch r.IntN(6) {
+		case 0:
+			b.WriteString("## " + randomSentence(r, 1+r.IntN(4)) + "\n\n")
+		case 1:
+			b.WriteString("| a | b |\n|---|---|\n")
+			for range 1 + r.IntN(20) {
+				b.WriteString("| " + randomSentence(r, 1+r.IntN(8)) + " | " + randomSentence(r, 1+r.IntN(30)) + " |\n")
+			}
+			b.WriteString("\n")
+		case 2:
+			b.WriteString("```\n")
+			for range 1 + r.IntN(15) {
+				b.WriteString(randomSentence(r, r.IntN(10)) + "\n")
+			}
+			b.WriteString("```\n\n")
+		case 3:
+			b.WriteString(strings.Repeat(string(rune('a'+r.IntN(26))), 50+r.IntN(2000)) + "\n\n")
+		default:
+			for range 1 + r.IntN(8) {
+				b.WriteString(randomSentence(r, 1+r.IntN(40)) + " ")
+			}
+			b.WriteString("\n\n")
+		}
+	}
+	return b.String()
+}
+
+func randomSentence(r *rand.Rand, n int) string {
+	words := make([]string, n)
+	for i := range words {
+		words[i] = chunkWords[r.IntN(len(chunkWords))]
+		if r.IntN(8) == 0 {
+			words[i] += chunkPunct[r.IntN(len(chunkPunct))]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// TestChunkBudgetProperties checks, over random documents and options, that
+// every strategy keeps each chunk, overlap included, within the size and the
+// token budget, and loses no word short enough to never need a hard split.
+func TestChunkBudgetProperties(t *testing.T) {
+	r := rand.New(rand.NewPCG(391, 1))
+	strategies := []string{ChunkStrategyMarkdown, ChunkStrategyCode, ChunkStrategyLines, "text"}
+
+	for i := range 500 {
+		text := randomDocument(r)
+		opts := ChunkOptions{
+			Size:     32 + r.IntN(1500),
+			Strategy: strategies[r.IntN(len(strategies))],
+		}
+		opts.Overlap = r.IntN(opts.Size)
+		if r.IntN(2) == 0 {
+			opts.MaxTokens = 12 + r.IntN(300)
+		}
+		maxTokens := opts.budget().tokens
+
+		var chunks []Chunk
+		if opts.Strategy == "text" {
+			chunks = ChunkText(text, "src", opts)
+		} else {
+			chunks = chunkContent(text, "src", opts)
+		}
+		if len(chunks) == 0 {
+			t.Fatalf("case %d: no chunks for %d bytes of text", i, len(text))
+		}
+
+		var all strings.Builder
+		for _, c := range chunks {
+			if len(c.Content) > opts.Size {
+				t.Fatalf("case %d (%+v): chunk %d is %d bytes: %q", i, opts, c.Ordinal, len(c.Content), c.Content)
+			}
+			if n := EstimateTokens(c.Content); n > maxTokens {
+				t.Fatalf("case %d (%+v): chunk %d is %d tokens, over %d: %q", i, opts, c.Ordinal, n, maxTokens, c.Content)
+			}
+			all.WriteString(c.Content + "\n")
+		}
+		for _, w := range strings.Fields(text) {
+			if strings.ContainsAny(w[:1], "#|`-") || len(w) > 16 {
+				continue // Markdown markup, or a run long enough to be hard split
+			}
+			if !strings.Contains(all.String(), w) {
+				t.Fatalf("case %d (%+v): word %q is in no chunk", i, opts, w)
+			}
+		}
+	}
+}
+
+func TestChunkMarkdownHeadings(t *testing.T) {
+	text := "Preamble.\n\n# Install\n\nGet it.\n\n## Prerequisites\n\nA host.\n\n```\n# not a heading\n```\n\n# Usage\n\nRun it."
+	chunks := ChunkMarkdown(text, "src", ChunkOptions{Size: 20})
+
+	want := map[string]string{
+		"Preamble.": "",
+		"Get it.":   "Install",
+		"A host.":   "Install > Prerequisites",
+		"Run it.":   "Usage",
+	}
+	for _, c := range chunks {
+		for body, heading := range want {
+			if strings.HasSuffix(c.Content, body) && c.Heading != heading {
+				t.Errorf("chunk %q has heading %q, want %q", c.Content, c.Heading, heading)
+			}
+		}
+		if strings.Contains(c.Content, "not a heading") && c.Heading != "Install > Prerequisites" {
+			t.Errorf("code chunk %q has heading %q", c.Content, c.Heading)
+		}
+	}
+}
@@ -0,0 +1,533 @@
+package processing
+
+import (
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jpnorenam/rag-snap/pkg/progress"
+)
+
+// codeFamily is how a language marks where a block of code ends, which is
+// what tells ChunkSource where one top-level unit stops and the next starts.
+type codeFamily int
+
+const (
+	familyBraces codeFamily = iota // { } blocks: C, Go, Java, JavaScript, Rust, ...
+	familyIndent                   // indented blocks: Python
+	familyEnd                      // blocks closed by "end": Ruby, Lua
+)
+
+// codeLanguage describes a programming language to ChunkSource.
+type codeLanguage struct {
+	family      codeFamily
+	contentType string
+	// lineComment starts a comment running to the end of the line.
+	lineComment string
+	// blockComments is set for languages with /* */ comments, tripleQuotes
+	// for ''' and """ strings, and backticks for `...` strings; all three may
+	// span lines.
+	blockComments, tripleQuotes, backticks bool
+	// symbols match the first line of a unit that declares something. The
+	// captured groups, joined by ".", name it, e.g. "Client.Do" for a Go
+	// method.
+	symbols []*regexp.Regexp
+}
+
+// Symbol patterns shared by the C-like languages.
+var (
+	symbolTypeDecl = regexp.MustCompile(`\b(?:class|interface|struct|enum|trait|record|object|namespace|module|union)\s+(\w+)`)
+	symbolImpl     = regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?impl(?:<[^>]*>)?\s+(?:[\w:<>, ]+\s+for\s+)?(\w+)`)
+	symbolFuncKw   = regexp.MustCompile(`\b(?:function|fn|func|fun|def)\s*\*?\s*(\w+)`)
+	symbolJSArrow  = regexp.MustCompile(`^(?:export\s+)?(?:const|let|var)\s+(\w+)\s*=\s*(?:async\s*)?(?:function\b|\([^)]*\)\s*=>|\w+\s*=>)`)
+	symbolCFunc    = regexp.MustCompile(`^[\w\s*&:<>,~]*?\b(\w+)\s*\([^;]*$`)
+)
+
+// braceSymbols are the symbol patterns of the C-like languages, most specific
+// first: a C function signature matches nearly any line ending in an open
+// parenthesis, so it comes last.
+var braceSymbols = []*regexp.Regexp{symbolImpl, symbolTypeDecl, symbolFuncKw, symbolJSArrow, symbolCFunc}
+
+func braceLanguage(contentType string) codeLanguage {
+	return codeLanguage{
+		family:        familyBraces,
+		contentType:   contentType,
+		lineComment:   "//",
+		blockComments: true,
+		symbols:       braceSymbols,
+	}
+}
+
+// codeLanguages are the languages ChunkSource splits along their syntax, by
+// the name recorded in each chunk's Language.
+var codeLanguages = map[string]codeLanguage{
+	"go": {
+		family:        familyBraces,
+		contentType:   "text/x-go",
+		lineComment:   "//",
+		blockComments: true,
+		backticks:     true,
+		symbols: []*regexp.Regexp{
+			regexp.MustCompile(`^func\s+\(\s*(?:\w+\s+)?\*?(\w+)[^)]*\)\s*(\w+)`),
+			regexp.MustCompile(`^func\s+(\w+)`),
+			regexp.MustCompile(`^type\s+(\w+)`),
+		},
+	},
+	"c":          braceLanguage("text/x-c"),
+	"cpp":        braceLanguage("text/x-c++src"),
+	"csharp":     braceLanguage("text/x-csharp"),
+	"java":       braceLanguage("text/x-java-source"),
+	"javascript": withBackticks(braceLanguage("text/javascript")),
+	"typescript": withBackticks(braceLanguage("application/typescript")),
+	"kotlin":     braceLanguage("text/x-kotlin"),
+	"scala":      braceLanguage("text/x-scala"),
+	"swift":      braceLanguage("text/x-swift"),
+	"rust":       braceLanguage("text/x-rust"),
+	"php":        braceLanguage("application/x-php"),
+	"python": {
+		family:       familyIndent,
+		contentType:  "text/x-python",
+		lineComment:  "#",
+		tripleQuotes: true,
+		symbols: []*regexp.Regexp{
+			regexp.MustCompile(`^(?:async\s+)?def\s+(\w+)`),
+			regexp.MustCompile(`^class\s+(\w+)`),
+		},
+	},
+	"ruby": {
+		family:      familyEnd,
+		contentType: "text/x-ruby",
+		lineComment: "#",
+		symbols: []*regexp.Regexp{
+			regexp.MustCompile(`^(?:def|class|module)\s+(?:self\.)?([\w:.?!]+)`),
+		},
+	},
+	"lua": {
+		family:      familyEnd,
+		contentType: "text/x-lua",
+		lineComment: "--",
+		symbols: []*regexp.Regexp{
+			regexp.MustCompile(`^(?:local\s+)?function\s+([\w.:]+)`),
+		},
+	},
+}
+
+func withBackticks(l codeLanguage) codeLanguage {
+	l.backticks = true
+	return l
+}
+
+// codeExtensions maps source file extensions to their language.
+var codeExtensions = map[string]string{
+	".go":    "go",
+	".c":     "c",
+	".h":     "c",
+	".cc":    "cpp",
+	".cpp":   "cpp",
+	".cxx":   "cpp",
+	".hpp":   "cpp",
+	".hh":    "cpp",
+	".cs":    "csharp",
+	".java":  "java",
+	".js":    "javascript",
+	".mjs":   "javascript",
+	".cjs":   "javascript",
+	".jsx":   "javascript",
+	".ts":    "typescript",
+	".tsx":   "typescript",
+	".kt":    "kotlin",
+	".kts":   "kotlin",
+	".scala": "scala",
+	".swift": "swift",
+	".rs":    "rust",
+	".php":   "php",
+	".py":    "python",
+	".pyi":   "python",
+	".rb":    "ruby",
+	".lua":   "lua",
+}
+
+// codeContentTypes maps the content types Tika reports for source code to
+// their language, beyond each language's own contentType.
+var codeContentTypes = map[string]string{
+	"text/x-csrc":             "c",
+	"text/x-chdr":             "c",
+	"text/x-c++hdr":           "cpp",
+	"text/x-java":             "java",
+	"application/javascript":  "javascript",
+	"text/x-rustsrc":          "rust",
+	"text/x-php":              "php",
+	"application/x-httpd-php": "php",
+	"application/x-ruby":      "ruby",
+}
+
+// DetectCodeLanguage returns the language of a source file from its
+// extension, or "" when it is not source code ChunkSource knows.
+func DetectCodeLanguage(filePath string) string {
+	return codeExtensions[strings.ToLower(filepath.Ext(filePath))]
+}
+
+// CodeLanguageForContentType returns the language a content type, e.g. as
+// Tika detected it, indicates, or "" when it is not source code ChunkSource
+// knows.
+func CodeLanguageForContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	if lang, ok := codeContentTypes[mediaType]; ok {
+		return lang
+	}
+	for name, lang := range codeLanguages {
+		if lang.contentType == mediaType {
+			return name
+		}
+	}
+	return ""
+}
+
+// codeUnit is a top-level piece of a source file: a declaration with the
+// comments and annotations leading it, or a run of other top-level code
+// (imports, constants). start and end are its byte span in the file.
+type codeUnit struct {
+	content    string
+	symbol     string
+	start, end int
+}
+
+// ChunkSource splits source code in language (see DetectCodeLanguage) into
+// chunks along its top-level functions, classes, and types, found by brace,
+// indentation, or "end" heuristics for the language's family. Each
+// declaration is kept whole in one chunk when it fits, with its leading
+// comments, and its chunks record its symbol name; runs of other top-level
+// code are packed together. A declaration too large for one chunk is split at
+// blank lines first, its first line repeated at the top of each further piece.
+// Chunks never overlap. Text in a language ChunkSource does not know is chunked
+// by ChunkLines.
+func ChunkSource(text, sourceID, language string, opts ChunkOptions) []Chunk {
+	lang, ok := codeLanguages[language]
+	if !ok {
+		return ChunkLines(text, sourceID, opts)
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+
+	now := time.Now().UTC().Format(dateFormat)
+	b := opts.budget()
+
+	var chunks []Chunk
+	emit := func(content, symbol string, start, end int) {
+		if strings.TrimSpace(content) == "" {
+			return
+		}
+		chunks = append(chunks, Chunk{
+			Content:     strings.TrimRight(content, " \t\r\n"),
+			SourceID:    sourceID,
+			CreatedAt:   now,
+			StartOffset: start,
+			EndOffset:   end,
+			Language:    language,
+			Symbol:      symbol,
+		})
+	}
+
+	// Runs of code that declare nothing are packed together while they fit;
+	// only a single unit too large on its own is split.
+	var pack []codeUnit
+	flush := func() {
+		if len(pack) > 0 {
+			emitSplit(joinUnits(pack), b, emit)
+		}
+		pack = nil
+	}
+
+	for _, u := range codeUnits(text, lang) {
+		if u.symbol == "" {
+			if len(pack) > 0 && !b.fits(joinUnits(append(pack, u)).trimmed()) {
+				flush()
+			}
+			pack = append(pack, u)
+			continue
+		}
+		flush()
+		emitSplit(u, b, emit)
+	}
+	flush()
+
+	return numberChunks(chunks)
+}
+
+// trimmed is the unit's content without the blank lines trailing it.
+func (u codeUnit) trimmed() string {
+	return strings.TrimRight(u.content, " \t\r\n")
+}
+
+// joinUnits joins consecutive units back into the span of the file they
+// cover.
+func joinUnits(units []codeUnit) codeUnit {
+	var b strings.Builder
+	for _, u := range units {
+		b.WriteString(u.content)
+	}
+	return codeUnit{content: b.String(), start: units[0].start, end: units[len(units)-1].end}
+}
+
+// emitSplit emits u whole when it fits b, and otherwise in pieces split at
+// blank lines, then lines, each after the first led by u's signature line
+// when it leavesRoom.
+func emitSplit(u codeUnit, b chunkBudget, emit func(content, symbol string, start, end int)) {
+	content := u.trimmed()
+	if b.fits(content) {
+		emit(content, u.symbol, u.start, u.start+len(content))
+		return
+	}
+
+	header := signatureLine(content) + "\n"
+	repeat := leavesRoom(header, b)
+	rest := b
+	if repeat {
+		rest = chunkBudget{chars: b.chars - len(header), tokens: b.tokens - EstimateTokens(header)}
+	}
+
+	// The pieces are consecutive spans of content, cut after separators.
+	start := u.start
+	for i, piece := range splitToBudget(content, rest) {
+		end := start + len(strings.TrimRight(piece, " \t\r\n"))
+		if i > 0 && repeat {
+			emit(header+piece, u.symbol, start, end)
+		} else {
+			emit(piece, u.symbol, start, end)
+		}
+		start += len(piece)
+	}
+}
+
+// signatureLine returns the first line of a unit's code, past the comments and
+// annotations leading it.
+func signatureLine(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "//") &&
+			!strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, "/*") && !strings.HasPrefix(trimmed, "*") &&
+			!strings.HasPrefix(trimmed, "@") && !strings.HasPrefix(trimmed, "--") {
+			return strings.TrimRight(line, " \t\r")
+		}
+	}
+	return strings.TrimSpace(strings.SplitN(content, "\n", 2)[0])
+}
+
+// codeUnits splits text into its top-level units. A unit starts at a line
+// with no indentation, outside any bracket, comment, or multi-line string,
+// that does not close a block; comments and annotations just before a
+// declaration belong to it.
+func codeUnits(text string, lang codeLanguage) []codeUnit {
+	var (
+		units   []codeUnit
+		current strings.Builder
+		start   int
+		// prelude is set while the current unit holds only comments,
+		// annotations, and blank lines, which lead the next declaration.
+		prelude = true
+		scan    codeScanner
+	)
+	finish := func(end int) {
+		if current.Len() > 0 {
+			units = append(units, codeUnit{content: current.String(), start: start, end: end})
+		}
+		current.Reset()
+		start = end
+		prelude = true
+	}
+
+	offset := 0
+	for _, line := range strings.SplitAfter(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		topLevel := scan.depth == 0 && !scan.inMultiline() && trimmed != "" &&
+			line[0] != ' ' && line[0] != '\t' && !closesBlock(trimmed, lang)
+		if topLevel && !prelude {
+			finish(offset)
+		}
+		if trimmed != "" && !isPreludeLine(trimmed, lang) {
+			prelude = false
+		}
+		current.WriteString(line)
+		scan.scan(line, lang)
+		offset += len(line)
+	}
+	finish(offset)
+
+	for i := range units {
+		units[i].symbol = unitSymbol(units[i].content, lang)
+	}
+	return units
+}
+
+// closesBlock reports whether a top-level line continues the unit before it
+// rather than starting one: a closing bracket, an opening brace on a line of
+// its own, or Ruby and Lua's "end".
+func closesBlock(trimmed string, lang codeLanguage) bool {
+	switch trimmed[0] {
+	case '}', ')', ']', '{':
+		return true
+	}
+	if lang.family == familyEnd {
+		word, _, _ := strings.Cut(trimmed, " ")
+		return strings.TrimRight(word, ";,.)") == "end"
+	}
+	return false
+}
+
+// isPreludeLine reports whether a line only leads a declaration: a comment or
+// an annotation, decorator, or attribute.
+func isPreludeLine(trimmed string, lang codeLanguage) bool {
+	switch {
+	case strings.HasPrefix(trimmed, lang.lineComment):
+		return true
+	case lang.blockComments && (strings.HasPrefix(trimmed, "/*") || strings.HasPrefix(trimmed, "*")):
+		return true
+	case strings.HasPrefix(trimmed, "@"), strings.HasPrefix(trimmed, "#["):
+		return true
+	}
+	return false
+}
+
+// unitSymbol names what a unit declares from its first line of code, or
+// returns "" when it declares nothing lang's patterns know.
+func unitSymbol(content string, lang codeLanguage) string {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || isPreludeLine(trimmed, lang) {
+			continue
+		}
+		for _, re := range lang.symbols {
+			if m := re.FindStringSubmatch(trimmed); m != nil {
+				var parts []string
+				for _, g := range m[1:] {
+					if g != "" {
+						parts = append(parts, g)
+					}
+				}
+				if symbol := strings.Join(parts, "."); !notSymbols[symbol] {
+					return symbol
+				}
+				return ""
+			}
+		}
+		return ""
+	}
+	return ""
+}
+
+// notSymbols are keywords the C function pattern takes for a name in
+// top-level statements such as JavaScript's "if (...) {".
+var notSymbols = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true,
+	"return": true, "function": true, "typeof": true, "sizeof": true,
+}
+
+// codeScanner follows the bracket depth of source code line by line, skipping
+// brackets in comments and strings, so codeUnits can tell top-level lines
+// from the inside of a block.
+type codeScanner struct {
+	depth        int
+	blockComment bool
+	// rawString is the delimiter of a multi-line string being scanned.
+	rawString string
+}
+
+func (s *codeScanner) inMultiline() bool {
+	return s.blockComment || s.rawString != ""
+}
+
+func (s *codeScanner) scan(line string, lang codeLanguage) {
+	for i := 0; i < len(line); i++ {
+		rest := line[i:]
+		switch {
+		case s.blockComment:
+			if strings.HasPrefix(rest, "*/") {
+				s.blockComment = false
+				i++
+			}
+		case s.rawString != "":
+			if strings.HasPrefix(rest, s.rawString) {
+				i += len(s.rawString) - 1
+				s.rawString = ""
+			}
+		case lang.lineComment != "" && strings.HasPrefix(rest, lang.lineComment):
+			return
+		case lang.blockComments && strings.HasPrefix(rest, "/*"):
+			s.blockComment = true
+			i++
+		case lang.tripleQuotes && (strings.HasPrefix(rest, `"""`) || strings.HasPrefix(rest, "'''")):
+			s.rawString = rest[:3]
+			i += 2
+		case lang.backticks && line[i] == '`':
+			s.rawString = "`"
+		case line[i] == '"':
+			i += quotedLength(rest, '"', len(rest)) - 1
+		case line[i] == '\'':
+			// A character literal is short; a lone quote (a Rust lifetime)
+			// is not a string.
+			i += quotedLength(rest, '\'', 5) - 1
+		case strings.ContainsRune("{([", rune(line[i])):
+			s.depth++
+		case strings.ContainsRune("})]", rune(line[i])):
+			if s.depth > 0 {
+				s.depth--
+			}
+		}
+	}
+}
+
+// quotedLength returns the length of the quoted string rest starts with, or
+// 1 when it does not close within limit bytes.
+func quotedLength(rest string, quote byte, limit int) int {
+	for j := 1; j < len(rest) && j < limit; j++ {
+		switch rest[j] {
+		case '\\':
+			j++
+		case quote:
+			return j + 1
+		}
+	}
+	return 1
+}
+
+// ingestCode reads a source file as it is and chunks it with ChunkSource:
+// Tika's extraction would add nothing to plain text but lose its layout.
+func ingestCode(filePath, sourceID, language string, opts ChunkOptions) (*IngestResult, error) {
+	checksum, fileSize, err := checksumAndSize(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("computing file checksum: %w", err)
+	}
+	if err := ValidateFileSize(fileSize); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filepath.Base(filePath), err)
+	}
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("%s is not UTF-8 text", filepath.Base(filePath))
+	}
+
+	stopProgress := progress.Start("Chunking source code")
+	chunks := ChunkSource(string(data), sourceID, language, opts)
+	stopProgress()
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("no content found in %s", filepath.Base(filePath))
+	}
+
+	return &IngestResult{
+		Chunks:        chunks,
+		Checksum:      checksum,
+		ContentLength: fileSize,
+		ContentType:   codeLanguages[language].contentType,
+	}, nil
+}
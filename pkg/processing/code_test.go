@@ -0,0 +1,105 @@
+package processing
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChunkSourceSymbols(t *testing.T) {
+	tests := []struct {
+		name, language, text string
+		want                 []string // symbol of each chunk, in order
+	}{
+		{
+			name:     "go",
+			language: "go",
+			text: "package demo\n\nimport (\n\t\"fmt\"\n)\n\n" +
+				"// Client talks to the server.\ntype Client struct {\n\tURL string\n}\n\n" +
+				"// Do sends a request.\nfunc (c *Client) Do() error {\n\tif c.URL == \"\" {\n\t\treturn fmt.Errorf(\"}\")\n\t}\n\treturn nil\n}\n\n" +
+				"func helper(\n\ta int,\n) int {\n\treturn a\n}\n",
+			want: []string{"", "Client", "Client.Do", "helper"},
+		},
+		{
+			name:     "python",
+			language: "python",
+			text: "import os\n\n\n@decorator\ndef first(x):\n    \"\"\"Docs.\n\ndef not_a_unit():\n\"\"\"\n    return x\n\n\n" +
+				"class Second:\n    def method(self):\n        pass\n",
+			want: []string{"", "first", "Second"},
+		},
+		{
+			name:     "ruby",
+			language: "ruby",
+			text:     "require 'json'\n\ndef first\n  1\nend\n\nclass Second\n  def m; end\nend\n",
+			want:     []string{"", "first", "Second"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := ChunkSource(tt.text, "src", tt.language, ChunkOptions{Size: 1024})
+			var got []string
+			for _, c := range chunks {
+				got = append(got, c.Symbol)
+				if c.Language != tt.language {
+					t.Errorf("chunk %q has language %q, want %q", c.Content, c.Language, tt.language)
+				}
+				if c.StartOffset < 0 || tt.text[c.StartOffset:c.EndOffset] != c.Content {
+					t.Errorf("chunk %q has span [%d, %d) that does not hold it", c.Content, c.StartOffset, c.EndOffset)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("symbols = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChunkSourceKeepsCommentsWithDeclaration(t *testing.T) {
+	text := "package demo\n\n// Run runs.\n// It never fails.\nfunc Run() {}\n"
+	chunks := ChunkSource(text, "src", "go", ChunkOptions{Size: 1024})
+	for _, c := range chunks {
+		if c.Symbol == "Run" && !strings.HasPrefix(c.Content, "// Run runs.") {
+			t.Errorf("Run chunk %q lost its doc comment", c.Content)
+		}
+	}
+}
+
+func TestChunkSourceSplitsLargeUnit(t *testing.T) {
+	var body strings.Builder
+	body.WriteString("func Big() {\n")
+	for range 40 {
+		body.WriteString("\tx := compute(1, 2, 3)\n\n")
+	}
+	body.WriteString("}\n")
+
+	opts := ChunkOptions{Size: 200}
+	chunks := ChunkSource(body.String(), "src", "go", opts)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want the function split", len(chunks))
+	}
+	for i, c := range chunks {
+		if len(c.Content) > opts.Size {
+			t.Errorf("chunk %d is %d bytes, over %d", i, len(c.Content), opts.Size)
+		}
+		if c.Symbol != "Big" {
+			t.Errorf("chunk %d has symbol %q, want Big", i, c.Symbol)
+		}
+		if !strings.HasPrefix(c.Content, "func Big() {") {
+			t.Errorf("chunk %d does not start with the signature: %q", i, c.Content)
+		}
+	}
+}
+
+func TestCodeLanguageDetection(t *testing.T) {
+	if got := DetectCodeLanguage("/tmp/main.GO"); got != "go" {
+		t.Errorf("DetectCodeLanguage(main.GO) = %q, want go", got)
+	}
+	if got := DetectCodeLanguage("notes.md"); got != "" {
+		t.Errorf("DetectCodeLanguage(notes.md) = %q, want none", got)
+	}
+	if got := CodeLanguageForContentType("text/x-python; charset=UTF-8"); got != "python" {
+		t.Errorf("CodeLanguageForContentType(text/x-python) = %q, want python", got)
+	}
+	if got := CodeLanguageForContentType("application/pdf"); got != "" {
+		t.Errorf("CodeLanguageForContentType(application/pdf) = %q, want none", got)
+	}
+}
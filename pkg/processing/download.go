@@ -0,0 +1,209 @@
+package processing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jpnorenam/rag-snap/pkg/httpclient"
+	"github.com/jpnorenam/rag-snap/pkg/progress"
+	trafilatura "github.com/markusmobius/go-trafilatura"
+	"golang.org/x/net/html"
+)
+
+// WebMetadata holds page-level metadata extracted from a web page.
+type WebMetadata struct {
+	Title       string
+	Author      string
+	Description string
+	PublishDate string
+	// ETag and LastModified are the page's cache validators, from the
+	// response headers; empty when the server sent none.
+	ETag         string
+	LastModified string
+}
+
+// ErrNotModified is returned by RecrawlPage when the server reports the page
+// unchanged since the validators given.
+var ErrNotModified = errors.New("page not modified")
+
+// validators are the cache validators of an earlier fetch of a page, sent to
+// have the server answer 304 Not Modified when the page has not changed.
+type validators struct {
+	etag         string
+	lastModified string
+}
+
+// urlCheckTimeout bounds CheckURL, so one dead host cannot stall a preflight.
+const urlCheckTimeout = 15 * time.Second
+
+// CheckURL reports whether url answers with a success status, without
+// downloading the page. Servers that refuse HEAD are retried with a GET whose
+// body is not read.
+func CheckURL(ctx context.Context, url string) error {
+	client := httpclient.New(urlCheckTimeout)
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, url, nil)
+		if err != nil {
+			return fmt.Errorf("invalid URL %s: %w", url, err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("fetching %s: %w", url, err)
+		}
+		resp.Body.Close()
+		if method == http.MethodHead && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+			continue
+		}
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("fetching %s: HTTP %s", url, resp.Status)
+		}
+		return nil
+	}
+	return nil
+}
+
+// CrawlURL fetches url, extracts its main content via go-trafilatura, writes the
+// resulting HTML to a temp file staged under temp, and returns the path, extracted metadata, a
+// cleanup function, and any error. Size limits from MaxIngestFileSize still apply.
+func CrawlURL(temp TempSettings, url string) (filePath string, meta *WebMetadata, cleanup func(), err error) {
+	return crawlURL(context.Background(), temp, url, validators{}, progress.Start)
+}
+
+// CrawlPage is CrawlURL without progress spinners, for fetching several pages
+// at once, and bound to ctx.
+func CrawlPage(ctx context.Context, temp TempSettings, url string) (filePath string, meta *WebMetadata, cleanup func(), err error) {
+	return crawlURL(ctx, temp, url, validators{}, noSpinner)
+}
+
+// RecrawlPage is CrawlPage for a page fetched before with the given ETag and
+// Last-Modified validators (either may be empty). It returns ErrNotModified,
+// without downloading the page, when the server reports it unchanged.
+func RecrawlPage(ctx context.Context, temp TempSettings, url, etag, lastModified string) (filePath string, meta *WebMetadata, cleanup func(), err error) {
+	return crawlURL(ctx, temp, url, validators{etag: etag, lastModified: lastModified}, noSpinner)
+}
+
+func noSpinner(string) func() { return func() {} }
+
+func crawlURL(ctx context.Context, temp TempSettings, url string, since validators, startSpinner func(prefix string) (stop func())) (filePath string, meta *WebMetadata, cleanup func(), err error) {
+	stopProgress := startSpinner("Fetching page")
+
+	req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if reqErr != nil {
+		stopProgress()
+		return "", nil, nil, fmt.Errorf("invalid URL %s: %w", url, reqErr)
+	}
+	if since.etag != "" {
+		req.Header.Set("If-None-Match", since.etag)
+	}
+	if since.lastModified != "" {
+		req.Header.Set("If-Modified-Since", since.lastModified)
+	}
+	resp, httpErr := httpclient.New(0).Do(req) //nolint:gosec // URL comes from authenticated CLI input
+	if httpErr != nil {
+		stopProgress()
+		return "", nil, nil, fmt.Errorf("fetching %s: %w", url, httpErr)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && (since.etag != "" || since.lastModified != "") {
+		stopProgress()
+		return "", nil, nil, ErrNotModified
+	}
+	if resp.StatusCode != http.StatusOK {
+		stopProgress()
+		return "", nil, nil, fmt.Errorf("fetching %s: HTTP %d %s", url, resp.StatusCode, resp.Status)
+	}
+
+	// Pre-check Content-Length when available.
+	if cl := resp.Header.Get("Content-Length"); cl != "" {
+		if size, parseErr := strconv.ParseInt(cl, 10, 64); parseErr == nil {
+			if sizeErr := ValidateFileSize(size); sizeErr != nil {
+				stopProgress()
+				return "", nil, nil, fmt.Errorf("remote page too large: %w", sizeErr)
+			}
+		}
+	}
+
+	// Read body with size guard (MaxIngestFileSize+1 so we can detect oversize).
+	bodyBytes, readErr := io.ReadAll(io.LimitReader(resp.Body, MaxIngestFileSize+1))
+	stopProgress()
+	if readErr != nil {
+		return "", nil, nil, fmt.Errorf("reading response body: %w", readErr)
+	}
+	if sizeErr := ValidateFileSize(int64(len(bodyBytes))); sizeErr != nil {
+		return "", nil, nil, fmt.Errorf("remote page too large: %w", sizeErr)
+	}
+
+	const minExtractedChars = 100
+
+	stopProgress = startSpinner("Extracting content")
+	result, extractErr := trafilatura.Extract(bytes.NewReader(bodyBytes), trafilatura.Options{
+		Focus:           trafilatura.FavorRecall,
+		EnableFallback:  true,
+		ExcludeComments: true,
+	})
+	stopProgress()
+
+	if extractErr != nil {
+		return "", nil, nil, fmt.Errorf("extracting content from %s: %w", url, extractErr)
+	}
+	if result == nil || result.ContentNode == nil {
+		return "", nil, nil, fmt.Errorf("no readable content found at %s", url)
+	}
+
+	contentText := strings.TrimSpace(result.ContentText)
+	if len(contentText) < minExtractedChars {
+		return "", nil, nil, fmt.Errorf(
+			"extracted only %d characters of text from %s (raw HTML was %d bytes)\n"+
+				"The page likely requires JavaScript to render its content.\n"+
+				"Try rendering it in a browser, saving the page as HTML, then using --file instead.",
+			len(contentText), url, len(bodyBytes),
+		)
+	}
+
+	// Render the extracted content node back to HTML for the downstream Tika pipeline.
+	var buf strings.Builder
+	if renderErr := html.Render(&buf, result.ContentNode); renderErr != nil {
+		return "", nil, nil, fmt.Errorf("rendering extracted HTML: %w", renderErr)
+	}
+	if strings.TrimSpace(buf.String()) == "" {
+		return "", nil, nil, fmt.Errorf("no readable content found at %s", url)
+	}
+
+	tmpFile, tmpErr := temp.CreateTemp("rag-crawl-*.html")
+	if tmpErr != nil {
+		return "", nil, nil, fmt.Errorf("creating temp file: %w", tmpErr)
+	}
+	cleanupFn := func() { os.Remove(tmpFile.Name()) }
+
+	if _, writeErr := io.WriteString(tmpFile, buf.String()); writeErr != nil {
+		tmpFile.Close()
+		cleanupFn()
+		return "", nil, nil, fmt.Errorf("writing extracted content: %w", writeErr)
+	}
+	if closeErr := tmpFile.Close(); closeErr != nil {
+		cleanupFn()
+		return "", nil, nil, fmt.Errorf("closing temp file: %w", closeErr)
+	}
+
+	webMeta := &WebMetadata{
+		Title:        result.Metadata.Title,
+		Author:       result.Metadata.Author,
+		Description:  result.Metadata.Description,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	if !result.Metadata.Date.IsZero() {
+		webMeta.PublishDate = result.Metadata.Date.Format("2006-01-02")
+	}
+
+	return tmpFile.Name(), webMeta, cleanupFn, nil
+}
@@ -0,0 +1,41 @@
+package processing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecrawlPage(t *testing.T) {
+	const etag = `"v1"`
+	page := "<html><head><title>Guide</title></head><body><article><p>" +
+		strings.Repeat("MicroCloud deploys a small cluster of machines. ", 10) +
+		"</p></article></body></html>"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2026 03:04:05 GMT")
+		fmt.Fprint(w, page)
+	}))
+	defer srv.Close()
+
+	_, meta, cleanup, err := RecrawlPage(context.Background(), DefaultTempSettings(), srv.URL, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanup()
+	if meta.ETag != etag || meta.LastModified != "Mon, 02 Jan 2026 03:04:05 GMT" {
+		t.Errorf("validators = %q, %q; want the response headers", meta.ETag, meta.LastModified)
+	}
+
+	if _, _, _, err := RecrawlPage(context.Background(), DefaultTempSettings(), srv.URL, etag, ""); !errors.Is(err, ErrNotModified) {
+		t.Errorf("RecrawlPage with the current ETag = %v, want ErrNotModified", err)
+	}
+}
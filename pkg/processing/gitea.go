@@ -8,6 +8,9 @@ import (
 	"net/url"
 	"path/filepath"
 	"strings"
+
+	"github.com/jpnorenam/rag-snap/pkg/httpclient"
+	"github.com/jpnorenam/rag-snap/pkg/progress"
 )
 
 // ParseGiteaSource parses a full Gitea URL into baseURL, owner, and repo.
@@ -60,7 +63,7 @@ func ListGiteaRepoFiles(baseURL, owner, repo, branch, pathFilter string, extensi
 		return nil, fmt.Errorf("parsing tree response: %w", err)
 	}
 	if treeResp.Truncated {
-		fmt.Println("Warning: repository tree is truncated; some files may be skipped")
+		progress.Printf("Warning: repository tree is truncated; some files may be skipped\n")
 	}
 
 	extSet := make(map[string]struct{}, len(extensions))
@@ -144,7 +147,7 @@ func giteaGET(rawURL, token string) (io.ReadCloser, error) {
 	}
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.New(0).Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
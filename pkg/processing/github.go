@@ -8,6 +8,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/jpnorenam/rag-snap/pkg/httpclient"
+	"github.com/jpnorenam/rag-snap/pkg/progress"
 )
 
 const gitHubAPIBase = "https://api.github.com"
@@ -62,7 +65,7 @@ func ListGitHubRepoFiles(owner, repo, branch, pathFilter string, extensions []st
 		return nil, fmt.Errorf("parsing tree response: %w", err)
 	}
 	if treeResp.Truncated {
-		fmt.Println("Warning: repository tree is truncated (>100k files); some files may be skipped")
+		progress.Printf("Warning: repository tree is truncated (>100k files); some files may be skipped\n")
 	}
 
 	extSet := make(map[string]struct{}, len(extensions))
@@ -89,9 +92,10 @@ func ListGitHubRepoFiles(owner, repo, branch, pathFilter string, extensions []st
 	return entries, nil
 }
 
-// FetchRepoFile downloads a raw file from a remote repository and writes it to a temp file.
+// FetchRepoFile downloads a raw file from a remote repository and writes it to a temp file
+// staged under temp.
 // The caller must call the returned cleanup function when done.
-func FetchRepoFile(rawURL, filePath, token string) (tempPath string, cleanup func(), err error) {
+func FetchRepoFile(temp TempSettings, rawURL, filePath, token string) (tempPath string, cleanup func(), err error) {
 	body, err := githubGET(rawURL, token)
 	if err != nil {
 		return "", nil, fmt.Errorf("fetching file: %w", err)
@@ -102,7 +106,7 @@ func FetchRepoFile(rawURL, filePath, token string) (tempPath string, cleanup fun
 	if ext == "" {
 		ext = ".txt"
 	}
-	tmpFile, err := os.CreateTemp("", "rag-github-*"+ext)
+	tmpFile, err := temp.CreateTemp("rag-github-*" + ext)
 	if err != nil {
 		return "", nil, fmt.Errorf("creating temp file: %w", err)
 	}
@@ -157,7 +161,7 @@ func githubGET(url, token string) (io.ReadCloser, error) {
 	}
 	req.Header.Set("Accept", "application/vnd.github+json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.New(0).Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -3,22 +3,61 @@ package processing
 import (
 	"fmt"
 	"strings"
+	"unicode"
 
 	"golang.org/x/net/html"
 )
 
+// pageMarker is written where one of Tika's page divs opens, and removed
+// again once the page offsets are recorded. Parsed text never contains NUL.
+const pageMarker = "\x00page\x00"
+
 // HTMLToMarkdown converts Tika's XHTML output to Markdown, preserving
 // table structure, headings, paragraphs, and lists.
 func HTMLToMarkdown(rawHTML string) (string, error) {
+	content, _, err := HTMLToMarkdownPages(rawHTML)
+	return content, err
+}
+
+// HTMLToMarkdownPages is HTMLToMarkdown that also returns where each page of a
+// paged document starts: Tika renders every PDF page as a <div class="page">,
+// and pageStarts[i] is the byte offset of page i+1 in the Markdown. It is empty
+// for documents without pages.
+func HTMLToMarkdownPages(rawHTML string) (content string, pageStarts []int, err error) {
 	doc, err := html.Parse(strings.NewReader(rawHTML))
 	if err != nil {
-		return "", fmt.Errorf("parsing HTML: %w", err)
+		return "", nil, fmt.Errorf("parsing HTML: %w", err)
 	}
 
 	var buf strings.Builder
 	walkNode(&buf, doc)
 
-	return strings.TrimSpace(buf.String()), nil
+	content, pageStarts = extractPageMarkers(buf.String())
+	return content, pageStarts, nil
+}
+
+// extractPageMarkers removes the page markers from rendered Markdown and trims
+// it, returning the offset each marker had in the trimmed text.
+func extractPageMarkers(rendered string) (string, []int) {
+	parts := strings.Split(rendered, pageMarker)
+	var (
+		b      strings.Builder
+		starts []int
+	)
+	for i, part := range parts {
+		if i > 0 {
+			starts = append(starts, b.Len())
+		}
+		b.WriteString(part)
+	}
+
+	text := b.String()
+	content := strings.TrimSpace(text)
+	lead := len(text) - len(strings.TrimLeftFunc(text, unicode.IsSpace))
+	for i, start := range starts {
+		starts[i] = min(max(start-lead, 0), len(content))
+	}
+	return content, starts
 }
 
 // walkNode recursively traverses the HTML tree and writes Markdown to buf.
@@ -71,7 +110,14 @@ func walkNode(buf *strings.Builder, n *html.Node) {
 			walkChildren(buf, n)
 			return
 
-		case "thead", "tbody", "tfoot", "div", "span", "body", "html", "head":
+		case "div":
+			if hasClass(n, "page") {
+				buf.WriteString(pageMarker)
+			}
+			walkChildren(buf, n)
+			return
+
+		case "thead", "tbody", "tfoot", "span", "body", "html", "head":
 			// Transparent wrappers — just process children
 			walkChildren(buf, n)
 			return
@@ -93,6 +139,20 @@ func walkChildren(buf *strings.Builder, n *html.Node) {
 	}
 }
 
+// hasClass reports whether element n carries class in its class attribute.
+func hasClass(n *html.Node, class string) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == "class" {
+			for _, c := range strings.Fields(attr.Val) {
+				if c == class {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 // renderTable converts a <table> element into a Markdown pipe table.
 func renderTable(buf *strings.Builder, tableNode *html.Node) {
 	rows := collectRows(tableNode)
@@ -0,0 +1,202 @@
+package processing
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jpnorenam/rag-snap/pkg/progress"
+)
+
+// MaxIngestFileSize is the maximum allowed file size for ingestion (50 MB).
+const MaxIngestFileSize = 50 * 1024 * 1024
+
+// ValidateFileSize returns an error if the given size exceeds MaxIngestFileSize.
+func ValidateFileSize(size int64) error {
+	if size > MaxIngestFileSize {
+		return fmt.Errorf("file size %d bytes exceeds maximum allowed %d bytes (50 MB)", size, int64(MaxIngestFileSize))
+	}
+	return nil
+}
+
+// IngestResult holds the output of the Ingest pipeline.
+type IngestResult struct {
+	Chunks        []Chunk
+	Checksum      string        // SHA-256 hex digest of the original file
+	ContentLength int64         // file size in bytes
+	ChunkOverlap  int           // characters shared by adjacent chunks; 0 for per-record formats
+	TikaMetadata  *TikaMetadata // may be nil if metadata extraction fails
+	ContentType   string        // set when there is no Tika metadata, e.g. for structured formats
+}
+
+// Ingest extracts content from a file using Tika and splits it into chunks
+// ready for indexing.
+func Ingest(tika *TikaClient, filePath, sourceID string) (*IngestResult, error) {
+	return IngestContext(context.Background(), tika, filePath, sourceID)
+}
+
+// IngestContext is Ingest with cancellation: the Tika extraction request is
+// aborted when ctx is done, and no further stages run after that. CSV, JSON,
+// and YAML files are detected by extension and chunked along their structure.
+func IngestContext(ctx context.Context, tika *TikaClient, filePath, sourceID string) (*IngestResult, error) {
+	return IngestFormat(ctx, tika, filePath, sourceID, "")
+}
+
+// IngestFormat is IngestContext with an explicit input format: FormatTika
+// forces Tika extraction, a structured format (FormatCSV, FormatJSON,
+// FormatYAML, FormatOpenAPI) selects its handler, and "" detects the format
+// from the file extension.
+func IngestFormat(ctx context.Context, tika *TikaClient, filePath, sourceID, format string) (*IngestResult, error) {
+	return IngestChunked(ctx, tika, filePath, sourceID, format, DefaultChunkOptions())
+}
+
+// IngestChunked is IngestFormat with explicit chunking options for extracted
+// text, as a base's preset sets them. Structured formats keep their per-record
+// chunking, and source code detected by its extension or content type is
+// split along its syntax (ChunkSource) rather than by the preset's strategy.
+func IngestChunked(ctx context.Context, tika *TikaClient, filePath, sourceID, format string, opts ChunkOptions) (*IngestResult, error) {
+	detect := format == ""
+	if detect {
+		format = DetectStructuredFormat(filePath)
+	}
+	if IsStructuredFormat(format) {
+		return IngestStructured(filePath, sourceID, format)
+	}
+	if format != "" && format != FormatTika {
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+	if language := DetectCodeLanguage(filePath); detect && language != "" {
+		return ingestCode(filePath, sourceID, language, opts)
+	}
+	return ingestTika(ctx, tika, filePath, sourceID, detect, opts)
+}
+
+// ingestTika extracts content via Tika, converts it to Markdown, and chunks it.
+// With detectCode, a file Tika finds to be source code is chunked as it is by
+// ChunkSource instead.
+func ingestTika(ctx context.Context, tika *TikaClient, filePath, sourceID string, detectCode bool, opts ChunkOptions) (*IngestResult, error) {
+	// 1. Compute file checksum and size
+	checksum, fileSize, err := checksumAndSize(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("computing file checksum: %w", err)
+	}
+
+	if err := ValidateFileSize(fileSize); err != nil {
+		return nil, err
+	}
+
+	// 2. Extract content via Tika, once it is accepting requests
+	if tika == nil {
+		return nil, fmt.Errorf("tika service is not configured — cannot extract %s", filepath.Base(filePath))
+	}
+	if err := tika.WaitReady(ctx); err != nil {
+		return nil, err
+	}
+
+	// With tika.rmeta, the metadata comes back with the content.
+	var (
+		rawHTML  string
+		tikaMeta *TikaMetadata
+	)
+	stopProgress := progress.Start("Extracting content")
+	if tika.settings.Rmeta {
+		rawHTML, tikaMeta, err = tika.ExtractRmeta(ctx, filePath)
+	} else {
+		rawHTML, err = tika.ExtractHTMLContext(ctx, filePath)
+	}
+	stopProgress()
+	if err != nil {
+		return nil, fmt.Errorf("content extraction failed: %w", err)
+	}
+
+	rawHTML = strings.TrimSpace(rawHTML)
+	if rawHTML == "" {
+		return nil, fmt.Errorf("no content extracted from %s", filepath.Base(filePath))
+	}
+
+	// 3. Convert HTML to Markdown (preserves table structure)
+	stopProgress = progress.Start("Converting to Markdown")
+	content, pageStarts, err := HTMLToMarkdownPages(rawHTML)
+	stopProgress()
+	if err != nil {
+		return nil, fmt.Errorf("HTML to Markdown conversion failed: %w", err)
+	}
+
+	if content == "" {
+		return nil, fmt.Errorf("no content extracted from %s", filepath.Base(filePath))
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// 4. Extract metadata (non-fatal on error)
+	if !tika.settings.Rmeta {
+		tikaMeta, _ = tika.ExtractMetadata(filePath)
+	}
+	if tikaMeta != nil && detectCode {
+		if language := CodeLanguageForContentType(tikaMeta.ContentType); language != "" {
+			result, err := ingestCode(filePath, sourceID, language, opts)
+			if err != nil {
+				return nil, err
+			}
+			result.TikaMetadata = tikaMeta
+			return result, nil
+		}
+	}
+
+	// 5. Chunk the Markdown content (structure-aware)
+	stopProgress = progress.Start("Chunking content")
+	chunks := chunkContent(content, sourceID, opts)
+	assignPages(chunks, pageStarts)
+	stopProgress()
+	overlap := opts.Overlap
+	if opts.Strategy == ChunkStrategyLines {
+		overlap = 0
+	}
+
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("no chunks generated from content")
+	}
+
+	return &IngestResult{
+		Chunks:        chunks,
+		Checksum:      checksum,
+		ContentLength: fileSize,
+		ChunkOverlap:  overlap,
+		TikaMetadata:  tikaMeta,
+	}, nil
+}
+
+// FileChecksum returns the SHA-256 hex digest of the file at filePath, as
+// IngestResult.Checksum records it.
+func FileChecksum(filePath string) (string, error) {
+	checksum, _, err := checksumAndSize(filePath)
+	return checksum, err
+}
+
+// checksumAndSize computes the SHA-256 hex digest and file size.
+func checksumAndSize(filePath string) (string, int64, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", 0, err
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", 0, err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), info.Size(), nil
+}
@@ -0,0 +1,136 @@
+package processing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+)
+
+// ContentHash returns the SHA-256 hex digest of a chunk's content. Chunks with
+// equal hashes carry identical text, whichever source or ingest produced them.
+func ContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// numberChunks sets each chunk's ordinal and content hash from its final
+// position and content.
+func numberChunks(chunks []Chunk) []Chunk {
+	for i := range chunks {
+		chunks[i].Ordinal = i
+		chunks[i].ContentHash = ContentHash(chunks[i].Content)
+	}
+	return chunks
+}
+
+// assignPages sets each chunk's page from pageStarts, the ascending offsets at
+// which the pages of the chunked text begin, by where the chunk's own text
+// starts. Chunks whose span is unknown keep page 0.
+func assignPages(chunks []Chunk, pageStarts []int) {
+	if len(pageStarts) == 0 {
+		return
+	}
+	for i := range chunks {
+		if chunks[i].StartOffset < 0 {
+			continue
+		}
+		// The number of pages starting at or before the chunk's first byte.
+		chunks[i].Page = sort.SearchInts(pageStarts, chunks[i].StartOffset+1)
+	}
+}
+
+// locateSpan returns the byte span of text that segment was cut from, searching
+// forward from cursor. Chunking trims whitespace and rejoins paragraphs, so the
+// segment is matched by its first and last lines rather than as a whole; a
+// first line found only before cursor (a table heading repeated on each part of
+// a split table) is matched there. It returns -1, -1 when the segment cannot be
+// found.
+func locateSpan(text, segment string, cursor int) (int, int) {
+	segment = strings.TrimSpace(segment)
+	if segment == "" || cursor > len(text) {
+		return -1, -1
+	}
+	first, last := segment, ""
+	if i := strings.IndexByte(segment, '\n'); i >= 0 {
+		first = strings.TrimSpace(segment[:i])
+		last = strings.TrimSpace(segment[strings.LastIndexByte(segment, '\n')+1:])
+	}
+
+	start := strings.Index(text[cursor:], first)
+	if start >= 0 {
+		start += cursor
+	} else if start = strings.LastIndex(text[:cursor], first); start < 0 {
+		return -1, -1
+	}
+	end := start + len(first)
+	if last != "" {
+		i := strings.Index(text[end:], last)
+		if i < 0 {
+			return -1, -1
+		}
+		end += i + len(last)
+	}
+	return start, end
+}
+
+// headingMark is a Markdown heading and the offset of the text it starts.
+type headingMark struct {
+	offset int
+	trail  string
+}
+
+// headingMarks returns the ATX headings of text ("# Title" through
+// "###### Title") outside code fences, in order, each with its trail: the
+// titles of the enclosing headings down to it, joined with " > ".
+func headingMarks(text string) []headingMark {
+	var marks []headingMark
+	var titles [6]string
+	inFence := false
+	offset := 0
+	for _, line := range strings.SplitAfter(text, "\n") {
+		start := offset
+		offset += len(line)
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+			continue
+		}
+		level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+		if inFence || level == 0 || level > 6 || !strings.HasPrefix(trimmed[level:], " ") {
+			continue
+		}
+		titles[level-1] = strings.TrimSpace(strings.TrimRight(trimmed[level:], "#"))
+		for i := level; i < len(titles); i++ {
+			titles[i] = ""
+		}
+		var trail []string
+		for _, t := range titles[:level] {
+			if t != "" {
+				trail = append(trail, t)
+			}
+		}
+		marks = append(marks, headingMark{offset: start, trail: strings.Join(trail, " > ")})
+	}
+	return marks
+}
+
+// assignHeadings sets each chunk's heading to the trail of the last heading of
+// text at or before where the chunk's own text starts. Chunks whose span is
+// unknown, or that start before the first heading, keep no heading.
+func assignHeadings(chunks []Chunk, text string) {
+	marks := headingMarks(text)
+	if len(marks) == 0 {
+		return
+	}
+	for i := range chunks {
+		if chunks[i].StartOffset < 0 {
+			continue
+		}
+		// The number of headings starting at or before the chunk's first byte.
+		n := sort.Search(len(marks), func(j int) bool { return marks[j].offset > chunks[i].StartOffset })
+		if n > 0 {
+			chunks[i].Heading = marks[n-1].trail
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package processing
+
+import "testing"
+
+func TestLocateSpan(t *testing.T) {
+	tests := []struct {
+		name          string
+		text, segment string
+		cursor        int
+		start, end    int
+	}{
+		{
+			name:    "span crossing a page boundary",
+			text:    "Page one ends here.\n\nPage two starts here.\n\nMore.",
+			segment: "Page one ends here.\n\nPage two starts here.",
+			start:   0, end: 42,
+		},
+		{
+			name:    "repeated text is located after the cursor",
+			text:    "Note: check.\n\nBody A.\n\nNote: check.\n\nBody B.",
+			segment: "Note: check.\n\nBody B.",
+			cursor:  21,
+			start:   23, end: 44,
+		},
+		{
+			name:    "repeated table heading found only before the cursor",
+			text:    "| a | b |\n|---|---|\n| 1 | 2 |\n| 3 | 4 |",
+			segment: "| a | b |\n|---|---|\n| 3 | 4 |",
+			cursor:  30,
+			start:   0, end: 39,
+		},
+		{
+			name:    "whitespace trimmed and paragraphs rejoined",
+			text:    "  Alpha line.   \n\n\n   Omega line.  ",
+			segment: "\nAlpha line.\n\nOmega line.\n",
+			start:   2, end: 33,
+		},
+		{
+			name:    "hyphenation kept as extracted",
+			text:    "The infor-\nmation is here.",
+			segment: "infor-\nmation is here.",
+			start:   4, end: 26,
+		},
+		{
+			name:    "hyphenation rejoined is not found",
+			text:    "The infor-\nmation is here.",
+			segment: "information is here.",
+			start:   -1, end: -1,
+		},
+		{
+			name:    "last line missing",
+			text:    "First line.\n\nSecond line.",
+			segment: "First line.\n\nThird line.",
+			start:   -1, end: -1,
+		},
+		{
+			name:    "segment not in the text",
+			text:    "Some text.",
+			segment: "Other text.",
+			start:   -1, end: -1,
+		},
+		{
+			name:    "blank segment",
+			text:    "Some text.",
+			segment: " \n ",
+			start:   -1, end: -1,
+		},
+		{
+			name:    "cursor past the end",
+			text:    "Some text.",
+			segment: "Some text.",
+			cursor:  11,
+			start:   -1, end: -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := locateSpan(tt.text, tt.segment, tt.cursor)
+			if start != tt.start || end != tt.end {
+				t.Errorf("locateSpan = %d, %d, want %d, %d", start, end, tt.start, tt.end)
+			}
+		})
+	}
+}
+
+func TestAssignPagesSpanCrossingPages(t *testing.T) {
+	chunks := []Chunk{{StartOffset: 0, EndOffset: 42}, {StartOffset: 21, EndOffset: 49}, {StartOffset: -1, EndOffset: -1}}
+	assignPages(chunks, []int{0, 21})
+	for i, want := range []int{1, 2, 0} {
+		if chunks[i].Page != want {
+			t.Errorf("chunk %d page = %d, want %d", i, chunks[i].Page, want)
+		}
+	}
+}
@@ -67,7 +67,7 @@ func IngestRFP(filePath, sourceID string) (*IngestResult, error) {
 	}
 
 	return &IngestResult{
-		Chunks:        chunks,
+		Chunks:        numberChunks(chunks),
 		Checksum:      checksum,
 		ContentLength: fileSize,
 	}, nil
@@ -94,7 +94,7 @@ func rfpRowChunks(question, answer, source, sourceID, createdAt string) []Chunk
 
 	full := prefix + answer + suffix
 	if len(full) <= DefaultChunkSize {
-		return []Chunk{{Content: full, SourceID: sourceID, CreatedAt: createdAt}}
+		return []Chunk{{Content: full, SourceID: sourceID, CreatedAt: createdAt, StartOffset: -1, EndOffset: -1}}
 	}
 
 	maxAnswerLen := DefaultChunkSize - len(prefix) - len(suffix)
@@ -110,9 +110,11 @@ func rfpRowChunks(question, answer, source, sourceID, createdAt string) []Chunk
 			continue
 		}
 		chunks = append(chunks, Chunk{
-			Content:   prefix + content + suffix,
-			SourceID:  sourceID,
-			CreatedAt: createdAt,
+			Content:     prefix + content + suffix,
+			SourceID:    sourceID,
+			CreatedAt:   createdAt,
+			StartOffset: -1,
+			EndOffset:   -1,
 		})
 	}
 	return chunks
@@ -0,0 +1,160 @@
+package processing
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jpnorenam/rag-snap/pkg/httpclient"
+)
+
+const (
+	// maxSitemapBytes is the sitemap protocol's limit on one uncompressed
+	// sitemap file.
+	maxSitemapBytes = 50 << 20
+	// maxSitemapPages caps the pages collected from a sitemap and the
+	// sitemaps it links to, so a runaway index cannot queue a whole site.
+	maxSitemapPages = 50000
+	// maxSitemapDepth is how many levels of sitemap index are followed below
+	// the sitemap given.
+	maxSitemapDepth = 2
+	// sitemapTimeout bounds fetching one sitemap file.
+	sitemapTimeout = 60 * time.Second
+)
+
+// IsSitemapURL reports whether rawURL names a sitemap: an XML file, optionally
+// gzipped, such as https://example.com/sitemap.xml.
+func IsSitemapURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	path := strings.ToLower(u.Path)
+	return strings.HasSuffix(path, ".xml") || strings.HasSuffix(path, ".xml.gz")
+}
+
+// sitemapDoc covers both sitemap file kinds: a urlset listing pages and a
+// sitemapindex listing further sitemaps.
+type sitemapDoc struct {
+	XMLName  xml.Name
+	URLs     []sitemapLoc `xml:"url"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+type sitemapLoc struct {
+	Loc string `xml:"loc"`
+}
+
+// FetchSitemap returns the page URLs the sitemap at rawURL lists, in order
+// and without duplicates, following sitemap indexes up to maxSitemapDepth
+// levels down.
+func FetchSitemap(ctx context.Context, rawURL string) ([]string, error) {
+	var pages []string
+	seen := map[string]bool{}
+	if err := collectSitemap(ctx, rawURL, 0, seen, &pages); err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+func collectSitemap(ctx context.Context, rawURL string, depth int, seen map[string]bool, pages *[]string) error {
+	doc, err := fetchSitemapDoc(ctx, rawURL)
+	if err != nil {
+		return err
+	}
+
+	switch doc.XMLName.Local {
+	case "urlset":
+		for _, u := range doc.URLs {
+			loc := strings.TrimSpace(u.Loc)
+			if loc == "" || seen[loc] {
+				continue
+			}
+			if len(*pages) == maxSitemapPages {
+				return fmt.Errorf("sitemap %s lists more than %d pages; point --url at one of its smaller sitemaps", rawURL, maxSitemapPages)
+			}
+			seen[loc] = true
+			*pages = append(*pages, loc)
+		}
+		return nil
+	case "sitemapindex":
+		if depth == maxSitemapDepth {
+			return fmt.Errorf("sitemap index %s nests more than %d levels deep", rawURL, maxSitemapDepth)
+		}
+		for _, s := range doc.Sitemaps {
+			if loc := strings.TrimSpace(s.Loc); loc != "" {
+				if err := collectSitemap(ctx, loc, depth+1, seen, pages); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s is not a sitemap: root element is <%s>, not <urlset> or <sitemapindex>", rawURL, doc.XMLName.Local)
+	}
+}
+
+// fetchSitemapDoc downloads and parses one sitemap file, gunzipping it when it
+// is served compressed.
+func fetchSitemapDoc(ctx context.Context, rawURL string) (*sitemapDoc, error) {
+	ctx, cancel := context.WithTimeout(ctx, sitemapTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sitemap URL %s: %w", rawURL, err)
+	}
+	resp, err := httpclient.New(0).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching sitemap %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching sitemap %s: HTTP %s", rawURL, resp.Status)
+	}
+
+	body := bufio.NewReader(resp.Body)
+	var r io.Reader = body
+	// A .xml.gz sitemap is gzip data whatever the server labels it; a
+	// transparently decompressed response is plain XML.
+	if magic, _ := body.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("decompressing sitemap %s: %w", rawURL, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var doc sitemapDoc
+	if err := xml.NewDecoder(io.LimitReader(r, maxSitemapBytes)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parsing sitemap %s: %w", rawURL, err)
+	}
+	return &doc, nil
+}
+
+// FilterURLs keeps the URLs matching include, when set, and not matching
+// exclude, when set.
+func FilterURLs(urls []string, include, exclude *regexp.Regexp) []string {
+	var kept []string
+	for _, u := range urls {
+		if include != nil && !include.MatchString(u) {
+			continue
+		}
+		if exclude != nil && exclude.MatchString(u) {
+			continue
+		}
+		kept = append(kept, u)
+	}
+	return kept
+}
@@ -0,0 +1,381 @@
+package processing
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jpnorenam/rag-snap/pkg/progress"
+	"gopkg.in/yaml.v3"
+)
+
+// Ingest formats selectable with `knowledge ingest --format`. The structured
+// formats bypass Tika, which flattens them into undifferentiated text.
+const (
+	// FormatTika forces Tika extraction even for a structured file.
+	FormatTika = "tika"
+	// FormatCSV chunks groups of rows, each chunk led by the column names.
+	FormatCSV = "csv"
+	// FormatJSON chunks along top-level keys (or array items).
+	FormatJSON = "json"
+	// FormatYAML chunks along top-level keys (or sequence items).
+	FormatYAML = "yaml"
+	// FormatOpenAPI chunks an OpenAPI/Swagger spec per endpoint and schema.
+	FormatOpenAPI = "openapi"
+)
+
+// structuredContentTypes is recorded as the source's content type, since no
+// Tika metadata is extracted for structured formats.
+var structuredContentTypes = map[string]string{
+	FormatCSV:     "text/csv",
+	FormatJSON:    "application/json",
+	FormatYAML:    "application/yaml",
+	FormatOpenAPI: "application/vnd.oai.openapi",
+}
+
+// openAPIMethods are the operation keys of an OpenAPI path item, in the order
+// endpoints are emitted.
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// DetectStructuredFormat returns the structured format for filePath based on
+// its extension, or "" when the file should go through Tika. JSON and YAML
+// files holding an OpenAPI spec are recognized when they are parsed.
+func DetectStructuredFormat(filePath string) string {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".csv":
+		return FormatCSV
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	default:
+		return ""
+	}
+}
+
+// IsStructuredFormat reports whether format names a structured handler.
+func IsStructuredFormat(format string) bool {
+	_, ok := structuredContentTypes[format]
+	return ok
+}
+
+// IngestStructured parses a CSV, JSON, YAML, or OpenAPI file and chunks it
+// along its structure, so each chunk is a self-describing unit (a group of rows
+// with their column names, a top-level key, an endpoint) rather than an
+// arbitrary slice of flattened text. A JSON or YAML file that turns out to be an
+// OpenAPI spec is chunked as one.
+func IngestStructured(filePath, sourceID, format string) (*IngestResult, error) {
+	checksum, fileSize, err := checksumAndSize(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("computing file checksum: %w", err)
+	}
+	if err := ValidateFileSize(fileSize); err != nil {
+		return nil, err
+	}
+
+	stopProgress := progress.Start("Chunking structured content")
+	defer stopProgress()
+
+	var (
+		units  []structuredUnit
+		prefix string
+	)
+	switch format {
+	case FormatCSV:
+		units, prefix, err = csvUnits(filePath)
+	case FormatJSON, FormatYAML, FormatOpenAPI:
+		var doc *yaml.Node
+		doc, err = parseYAMLFile(filePath)
+		if err != nil {
+			break
+		}
+		if isOpenAPI(doc) {
+			format = FormatOpenAPI
+			units = openAPIUnits(doc)
+		} else if format == FormatOpenAPI {
+			err = fmt.Errorf("%s has no top-level openapi or swagger key", filepath.Base(filePath))
+		} else {
+			units = treeUnits(doc)
+		}
+	default:
+		err = fmt.Errorf("unsupported structured format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := packUnits(units, prefix, sourceID, DefaultChunkSize)
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("no content found in %s", filepath.Base(filePath))
+	}
+
+	return &IngestResult{
+		Chunks:        chunks,
+		Checksum:      checksum,
+		ContentLength: fileSize,
+		ContentType:   structuredContentTypes[format],
+	}, nil
+}
+
+// structuredUnit is one piece of a structured document. When a unit is too large
+// for one chunk, title is repeated at the top of every piece, so a split unit
+// keeps its context. A unit may be title-only (e.g. "key: value").
+type structuredUnit struct {
+	title string
+	body  string
+}
+
+// packUnits packs consecutive units into chunks of at most size bytes, each
+// led by prefix. A unit never shares a chunk partially: it goes whole into the
+// current chunk, starts a new one, or — when too large even alone — is split
+// with its title leading each piece.
+func packUnits(units []structuredUnit, prefix, sourceID string, size int) []Chunk {
+	now := time.Now().UTC().Format(dateFormat)
+	size -= len(prefix)
+	if size < 1 {
+		prefix, size = "", size+len(prefix)
+	}
+
+	var chunks []Chunk
+	var current strings.Builder
+	emit := func(content string) {
+		chunks = append(chunks, Chunk{Content: prefix + content, SourceID: sourceID, CreatedAt: now, StartOffset: -1, EndOffset: -1})
+	}
+	flush := func() {
+		if content := strings.TrimSpace(current.String()); content != "" {
+			emit(content)
+		}
+		current.Reset()
+	}
+
+	for _, u := range units {
+		body := strings.TrimSpace(u.body)
+		text := u.title
+		if body != "" {
+			text += "\n" + body
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		switch {
+		case current.Len() > 0 && current.Len()+2+len(text) <= size:
+			current.WriteString("\n\n")
+			current.WriteString(text)
+		case len(text) <= size:
+			flush()
+			current.WriteString(text)
+		default:
+			flush()
+			room := size - len(u.title) - 1
+			if room < 1 {
+				room = size
+			}
+			for _, piece := range recursiveSplit(body, room) {
+				if piece = strings.TrimSpace(piece); piece != "" {
+					emit(u.title + "\n" + piece)
+				}
+			}
+		}
+	}
+	flush()
+	return numberChunks(chunks)
+}
+
+// csvUnits renders each data row as "column: value" lines titled with its row
+// number, and returns the column list as the prefix for every chunk, so any
+// group of rows explains its own fields.
+func csvUnits(filePath string) ([]structuredUnit, string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, "", fmt.Errorf("opening file: %w", err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, "", fmt.Errorf("file %s is empty", filePath)
+		}
+		return nil, "", fmt.Errorf("reading header row: %w", err)
+	}
+	for i, h := range header {
+		if header[i] = strings.TrimSpace(h); header[i] == "" {
+			header[i] = fmt.Sprintf("column %d", i+1)
+		}
+	}
+
+	var units []structuredUnit
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("reading row %d: %w", row, err)
+		}
+
+		var b strings.Builder
+		for i, value := range record {
+			if value = strings.TrimSpace(value); value == "" {
+				continue
+			}
+			name := fmt.Sprintf("column %d", i+1)
+			if i < len(header) {
+				name = header[i]
+			}
+			fmt.Fprintf(&b, "%s: %s\n", name, value)
+		}
+		units = append(units, structuredUnit{title: fmt.Sprintf("Row %d:", row), body: b.String()})
+	}
+	return units, "Columns: " + strings.Join(header, ", ") + "\n\n", nil
+}
+
+// parseYAMLFile parses a JSON or YAML file; YAML is a superset of JSON, so one
+// parser serves both and keeps the document's key order.
+func parseYAMLFile(filePath string) (*yaml.Node, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("reading file: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", filepath.Base(filePath), err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("file %s is empty", filePath)
+	}
+	return doc.Content[0], nil
+}
+
+// treeUnits splits a document along its top level: one unit per mapping key or
+// sequence item, or a single unit for a scalar document.
+func treeUnits(root *yaml.Node) []structuredUnit {
+	switch root.Kind {
+	case yaml.MappingNode:
+		units := make([]structuredUnit, 0, len(root.Content)/2)
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			units = append(units, keyUnit(root.Content[i].Value, root.Content[i+1]))
+		}
+		return units
+	case yaml.SequenceNode:
+		units := make([]structuredUnit, 0, len(root.Content))
+		for i, item := range root.Content {
+			units = append(units, keyUnit(fmt.Sprintf("Item %d", i+1), item))
+		}
+		return units
+	default:
+		return []structuredUnit{keyUnit("Value", root)}
+	}
+}
+
+// keyUnit renders one top-level entry: inline ("key: value") for a scalar,
+// otherwise the key as title over the rendered value.
+func keyUnit(key string, value *yaml.Node) structuredUnit {
+	if value.Kind == yaml.ScalarNode {
+		return structuredUnit{title: key + ": " + value.Value}
+	}
+	return structuredUnit{title: key + ":", body: renderNode(value)}
+}
+
+// isOpenAPI reports whether root is an OpenAPI 3 or Swagger 2 document.
+func isOpenAPI(root *yaml.Node) bool {
+	return mappingValue(root, "openapi") != nil || mappingValue(root, "swagger") != nil
+}
+
+// openAPIUnits emits the API's info, one unit per operation (titled with its
+// method, path, and summary), and one per schema definition.
+func openAPIUnits(root *yaml.Node) []structuredUnit {
+	var units []structuredUnit
+
+	if info := mappingValue(root, "info"); info != nil {
+		title := "API"
+		if t := mappingValue(info, "title"); t != nil {
+			title = "API: " + t.Value
+		}
+		if v := mappingValue(info, "version"); v != nil {
+			title += " (version " + v.Value + ")"
+		}
+		units = append(units, structuredUnit{title: title, body: renderNode(info)})
+	}
+
+	if paths := mappingValue(root, "paths"); paths != nil && paths.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(paths.Content); i += 2 {
+			path, item := paths.Content[i].Value, paths.Content[i+1]
+			shared := mappingValue(item, "parameters")
+			for _, method := range openAPIMethods {
+				op := mappingValue(item, method)
+				if op == nil {
+					continue
+				}
+				title := strings.ToUpper(method) + " " + path
+				if s := mappingValue(op, "summary"); s != nil && s.Value != "" {
+					title += " — " + s.Value
+				}
+				body := renderNode(op)
+				if shared != nil {
+					body += "path parameters:\n" + renderNode(shared)
+				}
+				units = append(units, structuredUnit{title: title, body: body})
+			}
+		}
+	}
+
+	schemas := mappingValue(root, "definitions") // Swagger 2
+	if components := mappingValue(root, "components"); components != nil {
+		schemas = mappingValue(components, "schemas")
+	}
+	if schemas != nil && schemas.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(schemas.Content); i += 2 {
+			units = append(units, structuredUnit{
+				title: "Schema: " + schemas.Content[i].Value,
+				body:  renderNode(schemas.Content[i+1]),
+			})
+		}
+	}
+	return units
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// renderNode renders a node as YAML, the most compact readable form for both
+// JSON and YAML input. Source styles (JSON's flow collections and quoted
+// strings) are reset so the encoder picks plain block style wherever it can.
+func renderNode(node *yaml.Node) string {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value + "\n"
+	}
+	resetStyle(node)
+	var b strings.Builder
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return ""
+	}
+	_ = enc.Close()
+	return b.String()
+}
+
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
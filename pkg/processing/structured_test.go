@@ -0,0 +1,192 @@
+package processing
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeStructured(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestIngestStructured(t *testing.T) {
+	tests := []struct {
+		name, file, format, content string
+		want                        []string // chunk contents, in order
+		contentType                 string
+	}{
+		{
+			name: "csv rows led by the columns", file: "team.csv", format: FormatCSV,
+			content:     "name,team\nada,eng\nbob,\n",
+			want:        []string{"Columns: name, team\n\nRow 2:\nname: ada\nteam: eng\n\nRow 3:\nname: bob"},
+			contentType: "text/csv",
+		},
+		{
+			name: "json top-level keys", file: "guide.json", format: FormatJSON,
+			content:     `{"title":"Guide","steps":[1,2]}`,
+			want:        []string{"title: Guide\n\nsteps:\n- 1\n- 2"},
+			contentType: "application/json",
+		},
+		{
+			name: "yaml sequence items", file: "people.yaml", format: FormatYAML,
+			content:     "- name: ada\n  team: eng\n- plain\n",
+			want:        []string{"Item 1:\nname: ada\nteam: eng\n\nItem 2: plain"},
+			contentType: "application/yaml",
+		},
+		{
+			name: "openapi recognized in json", file: "pets.json", format: FormatJSON,
+			content: `{"openapi":"3.0.0","info":{"title":"Pets","version":"1"},` +
+				`"paths":{"/pets":{"get":{"summary":"List pets"},"post":{"summary":"Add"}}},` +
+				`"components":{"schemas":{"Pet":{"type":"object"}}}}`,
+			want: []string{"API: Pets (version 1)\ntitle: Pets\nversion: \"1\"\n\n" +
+				"GET /pets — List pets\nsummary: List pets\n\n" +
+				"POST /pets — Add\nsummary: Add\n\n" +
+				"Schema: Pet\ntype: object"},
+			contentType: "application/vnd.oai.openapi",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := IngestStructured(writeStructured(t, tt.file, tt.content), "src", tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, c := range result.Chunks {
+				got = append(got, c.Content)
+			}
+			if strings.Join(got, "\n---\n") != strings.Join(tt.want, "\n---\n") {
+				t.Errorf("chunks = %q, want %q", got, tt.want)
+			}
+			if result.ContentType != tt.contentType {
+				t.Errorf("content type = %q, want %q", result.ContentType, tt.contentType)
+			}
+		})
+	}
+}
+
+// TestIngestStructuredBoundaries checks that a file too large for one chunk
+// is cut between its units, never inside one, with a CSV's columns leading
+// every chunk.
+func TestIngestStructuredBoundaries(t *testing.T) {
+	var csvRows, jsonKeys, yamlItems, paths strings.Builder
+	csvRows.WriteString("id,description\n")
+	for i := range 60 {
+		fmt.Fprintf(&csvRows, "%d,%s\n", i, strings.Repeat("x", 40))
+		if i > 0 {
+			jsonKeys.WriteString(",")
+			paths.WriteString(",")
+		}
+		fmt.Fprintf(&jsonKeys, `"key%d":{"text":"%s"}`, i, strings.Repeat("y", 40))
+		fmt.Fprintf(&yamlItems, "- text: %s\n", strings.Repeat("z", 40))
+		fmt.Fprintf(&paths, `"/things/%d":{"get":{"summary":"Thing %d","description":"%s"}}`, i, i, strings.Repeat("w", 40))
+	}
+
+	tests := []struct {
+		name, file, format, content string
+		unit                        func(i int) string // title of the i-th unit
+		prefix                      string
+	}{
+		{"csv", "rows.csv", FormatCSV, csvRows.String(), func(i int) string { return fmt.Sprintf("Row %d:\n", i+2) }, "Columns: id, description\n\n"},
+		{"json", "keys.json", FormatJSON, "{" + jsonKeys.String() + "}", func(i int) string { return fmt.Sprintf("key%d:\n", i) }, ""},
+		{"yaml", "items.yaml", FormatYAML, yamlItems.String(), func(i int) string { return fmt.Sprintf("Item %d:\n", i+1) }, ""},
+		{"openapi", "api.json", FormatOpenAPI, `{"swagger":"2.0","paths":{` + paths.String() + `}}`, func(i int) string { return fmt.Sprintf("GET /things/%d — Thing %d\n", i, i) }, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := IngestStructured(writeStructured(t, tt.file, tt.content), "src", tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Chunks) < 2 {
+				t.Fatalf("got %d chunk(s), want the file split", len(result.Chunks))
+			}
+			for i, c := range result.Chunks {
+				if len(c.Content) > DefaultChunkSize {
+					t.Errorf("chunk %d is %d bytes, over %d", i, len(c.Content), DefaultChunkSize)
+				}
+				if !strings.HasPrefix(c.Content, tt.prefix) {
+					t.Errorf("chunk %d = %q, want it led by %q", i, c.Content, tt.prefix)
+				}
+				if c.Ordinal != i {
+					t.Errorf("chunk %d has ordinal %d", i, c.Ordinal)
+				}
+			}
+			for u := range 60 {
+				title := tt.unit(u)
+				var in []int
+				for i, c := range result.Chunks {
+					if strings.Contains(c.Content+"\n", title) {
+						in = append(in, i)
+					}
+				}
+				if len(in) != 1 {
+					t.Errorf("unit %q is in chunks %v, want exactly one", title, in)
+				}
+			}
+		})
+	}
+}
+
+func TestPackUnitsSplitsOversizedUnit(t *testing.T) {
+	units := []structuredUnit{
+		{title: "A:", body: "aaaa"},
+		{title: "B:", body: "bbbb"},
+		{title: "C:", body: "cccc"},
+		{title: "D:", body: "one two three four five six"},
+	}
+	var got []string
+	for _, c := range packUnits(units, "P\n", "src", 20) {
+		got = append(got, c.Content)
+	}
+	want := []string{"P\nA:\naaaa\n\nB:\nbbbb", "P\nC:\ncccc", "P\nD:\none two three", "P\nD:\nfour five six"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("packUnits = %q, want %q", got, want)
+	}
+}
+
+func TestIngestStructuredMalformed(t *testing.T) {
+	tests := []struct {
+		name, file, format, content, wantErr string
+	}{
+		{"empty csv", "empty.csv", FormatCSV, "", "is empty"},
+		{"csv header only", "header.csv", FormatCSV, "name,team\n", "no content found"},
+		{"csv bad quoting", "quotes.csv", FormatCSV, "name,team\nada,\"eng\n", "reading row 2"},
+		{"truncated json", "cut.json", FormatJSON, `{"title":`, "parsing cut.json"},
+		{"invalid yaml", "tabs.yaml", FormatYAML, "a:\n\t- b\n", "parsing tabs.yaml"},
+		{"empty yaml", "empty.yaml", FormatYAML, "", "is empty"},
+		{"openapi without its key", "plain.yaml", FormatOpenAPI, "title: Guide\n", "no top-level openapi or swagger key"},
+		{"unknown format", "data.txt", "xml", "<a/>", "unsupported structured format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := IngestStructured(writeStructured(t, tt.file, tt.content), "src", tt.format)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("IngestStructured error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDetectStructuredFormat(t *testing.T) {
+	for path, want := range map[string]string{
+		"rows.CSV":  FormatCSV,
+		"spec.json": FormatJSON,
+		"conf.yml":  FormatYAML,
+		"conf.yaml": FormatYAML,
+		"guide.pdf": "",
+		"README":    "",
+	} {
+		if got := DetectStructuredFormat(path); got != want {
+			t.Errorf("DetectStructuredFormat(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package processing
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jpnorenam/rag-snap/pkg/utils"
+)
+
+const (
+	// DefaultTempQuota caps the managed temp directory at 2 GiB.
+	DefaultTempQuota = 2 << 30
+	// DefaultTempMaxAge is how old a leftover temp file must be before startup
+	// cleanup removes it. Files in use by a running ingest are far younger.
+	DefaultTempMaxAge = 24 * time.Hour
+)
+
+// Config keys of the managed temp directory's settings.
+const (
+	ConfTempQuota  = "temp.quota"
+	ConfTempMaxAge = "temp.max-age"
+)
+
+// TempSettings are the temp.* settings of the managed temp directory.
+type TempSettings struct {
+	// Quota is the size past which CreateTemp and MkdirTemp fail.
+	Quota int64
+	// MaxAge is how old a leftover entry must be before CleanStale
+	// removes it.
+	MaxAge time.Duration
+}
+
+// DefaultTempSettings returns the settings used when the temp.* keys are unset.
+func DefaultTempSettings() TempSettings {
+	return TempSettings{Quota: DefaultTempQuota, MaxAge: DefaultTempMaxAge}
+}
+
+// ParseTempSettings reads the temp.quota value, such as "500M", and the
+// temp.max-age value, such as "6h", get returns; an empty value keeps the
+// default.
+func ParseTempSettings(get func(key string) string) (TempSettings, error) {
+	s := DefaultTempSettings()
+	if quota := strings.TrimSpace(get(ConfTempQuota)); quota != "" {
+		n, err := utils.StringToBytes(quota)
+		if err != nil || n == 0 {
+			return TempSettings{}, fmt.Errorf("invalid %s %q: expected a byte count with optional M or G suffix", ConfTempQuota, quota)
+		}
+		s.Quota = int64(n)
+	}
+	if maxAge := strings.TrimSpace(get(ConfTempMaxAge)); maxAge != "" {
+		d, err := time.ParseDuration(maxAge)
+		if err != nil || d <= 0 {
+			return TempSettings{}, fmt.Errorf("invalid %s %q: expected a duration such as 24h", ConfTempMaxAge, maxAge)
+		}
+		s.MaxAge = d
+	}
+	return s, nil
+}
+
+// TempDir returns the managed directory that crawled pages, downloaded
+// repository files, and extracted archives are staged in: $SNAP_USER_COMMON/tmp
+// inside the snap, otherwise a per-user directory under the system temp dir.
+// Keeping them apart from the system temp dir lets leftovers from a crashed run
+// be found and removed.
+func TempDir() string {
+	if common := os.Getenv("SNAP_USER_COMMON"); common != "" {
+		return filepath.Join(common, "tmp")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("rag-cli-%d", os.Getuid()))
+}
+
+// CreateTemp creates a new temp file in TempDir, as os.CreateTemp does. It fails
+// when the directory already holds its quota.
+func (s TempSettings) CreateTemp(pattern string) (*os.File, error) {
+	dir, err := s.reserve()
+	if err != nil {
+		return nil, err
+	}
+	return os.CreateTemp(dir, pattern)
+}
+
+// MkdirTemp creates a new temp directory in TempDir, as os.MkdirTemp does. It
+// fails when the directory already holds its quota.
+func (s TempSettings) MkdirTemp(pattern string) (string, error) {
+	dir, err := s.reserve()
+	if err != nil {
+		return "", err
+	}
+	return os.MkdirTemp(dir, pattern)
+}
+
+// reserve creates TempDir if needed and checks it is under quota; a zero
+// quota is DefaultTempQuota.
+func (s TempSettings) reserve() (string, error) {
+	quota := s.Quota
+	if quota <= 0 {
+		quota = DefaultTempQuota
+	}
+	dir := TempDir()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("creating temp directory: %w", err)
+	}
+	used, err := TempUsage()
+	if err != nil {
+		return "", err
+	}
+	if used >= quota {
+		return "", fmt.Errorf("temp directory %s holds %s, over its %s quota (temp.quota); run `debug clean-temp` to clear it",
+			dir, utils.FmtBytes(uint64(used)), utils.FmtBytes(uint64(quota)))
+	}
+	return dir, nil
+}
+
+// TempUsage returns the total size of the files in TempDir.
+func TempUsage() (int64, error) {
+	var total int64
+	err := filepath.WalkDir(TempDir(), func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Entries can vanish under a concurrent cleanup; skip them.
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("measuring temp directory: %w", err)
+	}
+	return total, nil
+}
+
+// CleanResult reports what CleanTemp removed.
+type CleanResult struct {
+	Removed int
+	Freed   int64
+}
+
+// CleanTemp removes the entries of TempDir last modified more than olderThan
+// ago; olderThan <= 0 removes everything. A directory's age is that of the
+// newest entry in its tree, so a run still writing into a directory it
+// created long ago keeps it. A missing directory is not an error.
+func CleanTemp(olderThan time.Duration) (CleanResult, error) {
+	var result CleanResult
+	entries, err := os.ReadDir(TempDir())
+	if errors.Is(err, fs.ErrNotExist) {
+		return result, nil
+	}
+	if err != nil {
+		return result, fmt.Errorf("reading temp directory: %w", err)
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(TempDir(), entry.Name())
+		size, modTime := info.Size(), info.ModTime()
+		if entry.IsDir() {
+			size, modTime = treeStat(path, modTime)
+		}
+		if olderThan > 0 && modTime.After(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return result, fmt.Errorf("removing %s: %w", path, err)
+		}
+		result.Removed++
+		result.Freed += size
+	}
+	return result, nil
+}
+
+// CleanStale removes temp entries older than MaxAge, the leftovers of runs
+// that crashed before their cleanup ran; a zero MaxAge is DefaultTempMaxAge.
+// It is run at CLI startup.
+func (s TempSettings) CleanStale() (CleanResult, error) {
+	maxAge := s.MaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultTempMaxAge
+	}
+	return CleanTemp(maxAge)
+}
+
+// treeStat returns the total size of the files under dir, and the newest
+// modification time in its tree, starting from modTime, dir's own.
+func treeStat(dir string, modTime time.Time) (int64, time.Time) {
+	var total int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+		if d.Type().IsRegular() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, modTime
+}
@@ -0,0 +1,51 @@
+package processing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCleanTempKeepsDirectoriesInUse(t *testing.T) {
+	t.Setenv("SNAP_USER_COMMON", t.TempDir())
+	old := time.Now().Add(-48 * time.Hour)
+
+	// mkTree creates a directory of TempDir, last modified two days ago,
+	// holding a nested file last modified at fileTime.
+	mkTree := func(name string, fileTime time.Time) string {
+		dir := filepath.Join(TempDir(), name)
+		file := filepath.Join(dir, "sub", "page.html")
+		if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte("page"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range []string{filepath.Dir(file), dir} {
+			if err := os.Chtimes(p, old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.Chtimes(file, fileTime, fileTime); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+	stale := mkTree("crawl-stale", old)
+	inUse := mkTree("crawl-in-use", time.Now())
+
+	result, err := CleanTemp(24 * time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Removed != 1 || result.Freed != int64(len("page")) {
+		t.Errorf("CleanTemp = %+v, want one entry of 4 bytes removed", result)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale directory still exists (%v)", err)
+	}
+	if _, err := os.Stat(inUse); err != nil {
+		t.Errorf("directory with a fresh file was removed: %v", err)
+	}
+}
@@ -0,0 +1,442 @@
+package processing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jpnorenam/rag-snap/pkg/httpclient"
+	"github.com/jpnorenam/rag-snap/pkg/progress"
+	"github.com/jpnorenam/rag-snap/pkg/suggest"
+)
+
+// DefaultTikaReadyTimeout is how long WaitReady waits for a starting Tika
+// server when tika.ready.timeout is unset. The JVM typically needs 10–30s.
+const DefaultTikaReadyTimeout = 60 * time.Second
+
+// DefaultTikaConcurrency is how many extractions run against Tika at once when
+// tika.concurrency is unset.
+const DefaultTikaConcurrency = 4
+
+// Config keys of the settings a TikaClient extracts with.
+const (
+	ConfTikaReadyTimeout = "tika.ready.timeout"
+	ConfTikaConcurrency  = "tika.concurrency"
+	ConfTikaRmeta        = "tika.rmeta"
+)
+
+// tikaRetryInterval is how long WaitReady and the extraction requests wait
+// before asking a starting Tika server again.
+var tikaRetryInterval = 3 * time.Second
+
+var (
+	// tikaReady records base URLs that already passed WaitReady, so a batch
+	// ingest handshakes once rather than before every file.
+	tikaReady sync.Map
+	// tikaClients holds one TikaClient per base URL and settings, so every
+	// extraction of a batch goes through the same client and reuses its
+	// connections.
+	tikaClients sync.Map
+)
+
+// TikaSettings are the tika.* settings a TikaClient extracts with.
+type TikaSettings struct {
+	// ReadyTimeout is how long WaitReady waits for a starting server.
+	ReadyTimeout time.Duration
+	// Concurrency is how many extractions may run at once, which is also how
+	// far a repository batch job extracts ahead of indexing.
+	Concurrency int
+	// Rmeta extracts content and metadata with one /rmeta request instead of
+	// a /tika and a /meta request.
+	Rmeta bool
+}
+
+// DefaultTikaSettings returns the settings used when the tika.* keys are unset.
+func DefaultTikaSettings() TikaSettings {
+	return TikaSettings{ReadyTimeout: DefaultTikaReadyTimeout, Concurrency: DefaultTikaConcurrency}
+}
+
+// ParseTikaSettings reads the tika.ready.timeout, tika.concurrency and
+// tika.rmeta values get returns; an empty value keeps the default.
+func ParseTikaSettings(get func(key string) string) (TikaSettings, error) {
+	s := DefaultTikaSettings()
+	if value := strings.TrimSpace(get(ConfTikaReadyTimeout)); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return TikaSettings{}, fmt.Errorf("invalid %s %q: expected a duration such as 90s", ConfTikaReadyTimeout, value)
+		}
+		s.ReadyTimeout = d
+	}
+	if value := strings.TrimSpace(get(ConfTikaConcurrency)); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return TikaSettings{}, fmt.Errorf("invalid %s %q: expected a positive number of extractions", ConfTikaConcurrency, value)
+		}
+		s.Concurrency = n
+	}
+	if value := strings.TrimSpace(get(ConfTikaRmeta)); value != "" {
+		on, err := strconv.ParseBool(value)
+		if err != nil {
+			return TikaSettings{}, fmt.Errorf("invalid %s %q: expected true or false", ConfTikaRmeta, value)
+		}
+		s.Rmeta = on
+	}
+	return s, nil
+}
+
+// withDefaults fills in the zero fields of s, as left by a caller building
+// TikaSettings by hand.
+func (s TikaSettings) withDefaults() TikaSettings {
+	if s.ReadyTimeout <= 0 {
+		s.ReadyTimeout = DefaultTikaReadyTimeout
+	}
+	if s.Concurrency <= 0 {
+		s.Concurrency = DefaultTikaConcurrency
+	}
+	return s
+}
+
+// TikaMetadata holds metadata fields extracted by the Tika /meta endpoint.
+type TikaMetadata struct {
+	ContentType string
+	Title       string
+	Author      string
+	Language    string
+}
+
+// TikaClient sends files to a Tika server for content extraction.
+type TikaClient struct {
+	baseURL  string
+	client   *http.Client
+	settings TikaSettings
+	// slots caps the requests in flight at settings.Concurrency; each
+	// request holds a slot while it runs.
+	slots chan struct{}
+}
+
+// tikaClientKey identifies the shared client of a server and settings.
+type tikaClientKey struct {
+	base     string
+	settings TikaSettings
+}
+
+// NewTikaClient returns the TikaClient for a Tika URL, shared by every caller
+// of the same server with the same settings. It strips any path component,
+// keeping only scheme://host:port.
+func NewTikaClient(tikaURL string, settings TikaSettings) (*TikaClient, error) {
+	u, err := url.Parse(tikaURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tika URL: %w", err)
+	}
+	base := fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+	settings = settings.withDefaults()
+	client, _ := tikaClients.LoadOrStore(tikaClientKey{base, settings}, &TikaClient{
+		baseURL:  base,
+		client:   httpclient.New(0),
+		settings: settings,
+		slots:    make(chan struct{}, settings.Concurrency),
+	})
+	return client.(*TikaClient), nil
+}
+
+// Concurrency returns how many extractions may run against t at once.
+func (t *TikaClient) Concurrency() int {
+	return cap(t.slots)
+}
+
+// acquire waits for a free extraction slot and returns the func that frees
+// it.
+func (t *TikaClient) acquire(ctx context.Context) (release func(), err error) {
+	select {
+	case t.slots <- struct{}{}:
+		return func() { <-t.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// WaitReady blocks until the Tika server answers its status endpoint, retrying
+// while it refuses connections or returns 503 (still starting), up to the
+// configured ready timeout. Any other status is reported immediately.
+func (t *TikaClient) WaitReady(ctx context.Context) error {
+	if _, ok := tikaReady.Load(t.baseURL); ok {
+		return nil
+	}
+
+	stopProgress := progress.Start("Waiting for Tika to be ready")
+	defer stopProgress()
+
+	deadline := time.Now().Add(t.settings.ReadyTimeout)
+	for {
+		status, err := t.ping(ctx)
+		if err == nil && status == http.StatusOK {
+			tikaReady.Store(t.baseURL, struct{}{})
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == nil && status != http.StatusServiceUnavailable {
+			return fmt.Errorf("tika readiness check returned status %d", status)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("tika not available after %s\n\n%s\n%s",
+				t.settings.ReadyTimeout,
+				suggest.ServerStartup(),
+				suggest.TikaLogs())
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(tikaRetryInterval):
+		}
+	}
+}
+
+// Ready checks once, without retrying, whether the Tika server is accepting
+// requests.
+func (t *TikaClient) Ready(ctx context.Context) error {
+	status, err := t.ping(ctx)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("tika readiness check returned status %d", status)
+	}
+	return nil
+}
+
+// ping issues a GET to Tika's status endpoint and returns the HTTP status.
+func (t *TikaClient) ping(ctx context.Context) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.baseURL+"/tika", nil)
+	if err != nil {
+		return 0, fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := httpclient.New(5 * time.Second).Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+// Extract sends a file to the Tika server and returns the extracted plain text.
+func (t *TikaClient) Extract(filePath string) (string, error) {
+	ctx := context.Background()
+	release, err := t.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	resp, err := t.send(ctx, "/tika", "text/plain", filePath)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("tika returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	content, err := httpclient.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading response: %w", err)
+	}
+
+	return string(content), nil
+}
+
+// ExtractHTML sends a file to the Tika server and returns the extracted content as HTML.
+// Tika returns XHTML with <table>, <h1>–<h6>, <p> tags that preserve document structure.
+func (t *TikaClient) ExtractHTML(filePath string) (string, error) {
+	return t.ExtractHTMLContext(context.Background(), filePath)
+}
+
+// ExtractHTMLContext is ExtractHTML bound to ctx, so an interrupted ingest does
+// not wait for Tika to finish a large document.
+func (t *TikaClient) ExtractHTMLContext(ctx context.Context, filePath string) (string, error) {
+	release, err := t.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	resp, err := t.send(ctx, "/tika", "text/html", filePath)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("tika returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	content, err := httpclient.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading response: %w", err)
+	}
+
+	return string(content), nil
+}
+
+// ExtractMetadata sends a file to the Tika /meta endpoint and returns parsed metadata.
+func (t *TikaClient) ExtractMetadata(filePath string) (*TikaMetadata, error) {
+	ctx := context.Background()
+	release, err := t.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	resp, err := t.send(ctx, "/meta", "application/json", filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("tika /meta returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var raw map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("decoding metadata response: %w", err)
+	}
+	// Read to the end, so the connection can be reused.
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return tikaMetadata(raw), nil
+}
+
+// ExtractRmeta sends a file to the Tika /rmeta endpoint and returns its content
+// as HTML, as ExtractHTMLContext does, with its metadata, as ExtractMetadata
+// does, from one request. Tika reports the content of each embedded document
+// (an attachment, an image in a document) separately; it is appended to the
+// container's, in order.
+func (t *TikaClient) ExtractRmeta(ctx context.Context, filePath string) (string, *TikaMetadata, error) {
+	release, err := t.acquire(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	defer release()
+	resp, err := t.send(ctx, "/rmeta/html", "application/json", filePath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", nil, fmt.Errorf("tika /rmeta returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	body, err := httpclient.ReadAll(resp.Body)
+	if err != nil {
+		return "", nil, fmt.Errorf("reading response: %w", err)
+	}
+	return parseRmeta(body)
+}
+
+// send PUTs the file at filePath to a Tika endpoint and returns the response,
+// which the caller closes. While Tika refuses connections or answers 503, as
+// it does while starting or restarting, the request is retried up to the
+// ready timeout; the last response or error is returned after that.
+func (t *TikaClient) send(ctx context.Context, endpoint, accept, filePath string) (*http.Response, error) {
+	deadline := time.Now().Add(t.settings.ReadyTimeout)
+	for {
+		resp, err := t.sendOnce(ctx, endpoint, accept, filePath)
+		starting := errors.Is(err, syscall.ECONNREFUSED) ||
+			(err == nil && resp.StatusCode == http.StatusServiceUnavailable)
+		if !starting || time.Now().After(deadline) {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(tikaRetryInterval):
+		}
+	}
+}
+
+// sendOnce makes one attempt of send, reopening the file so that every
+// attempt uploads it whole.
+func (t *TikaClient) sendOnce(ctx context.Context, endpoint, accept, filePath string) (*http.Response, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+	defer file.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, t.baseURL+endpoint, file)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", accept)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("tika request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// parseRmeta reads the content and the container's metadata out of an /rmeta
+// response: a JSON array with one object per document, the container first.
+func parseRmeta(body []byte) (string, *TikaMetadata, error) {
+	var docs []map[string]any
+	if err := json.Unmarshal(body, &docs); err != nil {
+		return "", nil, fmt.Errorf("decoding rmeta response: %w", err)
+	}
+	if len(docs) == 0 {
+		return "", nil, nil
+	}
+	var content strings.Builder
+	for _, doc := range docs {
+		if c := metaString(doc, "X-TIKA:content"); c != "" {
+			if content.Len() > 0 {
+				content.WriteString("\n")
+			}
+			content.WriteString(c)
+		}
+	}
+	return content.String(), tikaMetadata(docs[0]), nil
+}
+
+// tikaMetadata picks the fields TikaMetadata holds out of a document's raw
+// Tika metadata.
+func tikaMetadata(raw map[string]any) *TikaMetadata {
+	return &TikaMetadata{
+		ContentType: metaString(raw, "Content-Type"),
+		Title:       metaString(raw, "dc:title"),
+		Author:      metaString(raw, "dc:creator"),
+		Language:    metaString(raw, "language"),
+	}
+}
+
+// metaString defensively extracts a string value from a metadata map.
+func metaString(m map[string]any, key string) string {
+	v, ok := m[key]
+	if !ok {
+		return ""
+	}
+	s, ok := v.(string)
+	if !ok {
+		return ""
+	}
+	return s
+}
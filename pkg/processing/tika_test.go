@@ -0,0 +1,231 @@
+package processing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseTikaSettings(t *testing.T) {
+	values := map[string]string{ConfTikaConcurrency: "2", ConfTikaReadyTimeout: "90s", ConfTikaRmeta: "true"}
+	s, err := ParseTikaSettings(func(key string) string { return values[key] })
+	if want := (TikaSettings{ReadyTimeout: 90 * time.Second, Concurrency: 2, Rmeta: true}); err != nil || s != want {
+		t.Errorf("ParseTikaSettings = %+v, %v; want %+v", s, err, want)
+	}
+	for _, bad := range []string{"0", "-1", "many"} {
+		get := func(key string) string {
+			if key == ConfTikaConcurrency {
+				return bad
+			}
+			return ""
+		}
+		if _, err := ParseTikaSettings(get); err == nil {
+			t.Errorf("ParseTikaSettings with tika.concurrency %q succeeded, want an error", bad)
+		}
+	}
+	if s, err := ParseTikaSettings(func(string) string { return "" }); err != nil || s != DefaultTikaSettings() {
+		t.Errorf("ParseTikaSettings of unset keys = %+v, %v; want the defaults", s, err)
+	}
+}
+
+func TestParseRmeta(t *testing.T) {
+	body := `[
+		{"Content-Type":"application/pdf","dc:title":"Guide","dc:creator":"Ops","X-TIKA:content":"<p>Main</p>"},
+		{"Content-Type":"image/png","X-TIKA:content":"<p>Embedded</p>"}
+	]`
+	content, meta, err := parseRmeta([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != "<p>Main</p>\n<p>Embedded</p>" {
+		t.Errorf("content = %q, want the container's then the embedded document's", content)
+	}
+	if meta.ContentType != "application/pdf" || meta.Title != "Guide" || meta.Author != "Ops" {
+		t.Errorf("metadata = %+v, want the container's", meta)
+	}
+
+	if content, meta, err := parseRmeta([]byte(`[]`)); err != nil || content != "" || meta != nil {
+		t.Errorf("parseRmeta([]) = %q, %+v, %v; want nothing", content, meta, err)
+	}
+}
+
+// TestTikaConcurrencyCap checks that extractions beyond tika.concurrency wait
+// for a free slot, and that clients of one server are shared.
+func TestTikaConcurrencyCap(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprint(w, "<p>text</p>")
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "doc.txt")
+	if err := os.WriteFile(path, []byte("text"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	settings := TikaSettings{Concurrency: 2}
+	tika, err := NewTikaClient(srv.URL+"/tika", settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := NewTikaClient(srv.URL, settings); again != tika {
+		t.Error("NewTikaClient returned a second client for the same server")
+	}
+	if tika.Concurrency() != 2 {
+		t.Errorf("Concurrency() = %d, want 2", tika.Concurrency())
+	}
+
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := tika.ExtractHTMLContext(context.Background(), path); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := peak.Load(); got > 2 {
+		t.Errorf("%d extractions ran at once, want at most 2", got)
+	}
+}
+
+// startingTika serves 503 to the first starting requests, as a Tika server
+// still loading does, and then answers with body. It counts every request
+// and records the uploads it accepted.
+func startingTika(t *testing.T, starting int32, body string) (*httptest.Server, *atomic.Int32, *[]string) {
+	t.Helper()
+	var calls atomic.Int32
+	var mu sync.Mutex
+	var uploads []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= starting {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		upload, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		uploads = append(uploads, string(upload))
+		mu.Unlock()
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls, &uploads
+}
+
+func shortTikaRetries(t *testing.T) {
+	t.Helper()
+	interval := tikaRetryInterval
+	tikaRetryInterval = time.Millisecond
+	t.Cleanup(func() { tikaRetryInterval = interval })
+}
+
+func TestTikaWaitReady(t *testing.T) {
+	shortTikaRetries(t)
+
+	srv, calls, _ := startingTika(t, 2, "ok")
+	tika, err := NewTikaClient(srv.URL, TikaSettings{ReadyTimeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tika.WaitReady(context.Background()); err != nil {
+		t.Fatalf("WaitReady = %v, want the server ready after two 503s", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("WaitReady made %d requests, want 3", got)
+	}
+	if err := tika.WaitReady(context.Background()); err != nil || calls.Load() != 3 {
+		t.Errorf("second WaitReady = %v after %d requests, want nil without asking again", err, calls.Load())
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	tika, err = NewTikaClient(failing.URL, TikaSettings{ReadyTimeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tika.WaitReady(context.Background()); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("WaitReady against a 500 = %v, want the status reported", err)
+	}
+}
+
+func TestTikaExtractRetriesWhileStarting(t *testing.T) {
+	shortTikaRetries(t)
+
+	path := filepath.Join(t.TempDir(), "doc.txt")
+	if err := os.WriteFile(path, []byte("document body"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	srv, calls, uploads := startingTika(t, 2, "<p>text</p>")
+	tika, err := NewTikaClient(srv.URL, TikaSettings{ReadyTimeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := tika.ExtractHTMLContext(context.Background(), path)
+	if err != nil || content != "<p>text</p>" {
+		t.Fatalf("ExtractHTMLContext = %q, %v; want the text after two 503s", content, err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("ExtractHTMLContext made %d requests, want 3", got)
+	}
+	if len(*uploads) != 1 || (*uploads)[0] != "document body" {
+		t.Errorf("uploads = %q, want the whole file once", *uploads)
+	}
+
+	// A server that stays unavailable fails the extraction at the ready
+	// timeout.
+	srv, _, _ = startingTika(t, 1<<30, "")
+	tika, err = NewTikaClient(srv.URL, TikaSettings{ReadyTimeout: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tika.Extract(path); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("Extract against a server that stays unavailable = %v, want the 503 reported", err)
+	}
+
+	// Connection refused is retried until a server listens.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	tika, err = NewTikaClient("http://"+addr, TikaSettings{ReadyTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return
+		}
+		srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"Content-Type":"text/plain"}`)
+		})}
+		t.Cleanup(func() { srv.Close() })
+		_ = srv.Serve(l)
+	}()
+	meta, err := tika.ExtractMetadata(path)
+	if err != nil || meta.ContentType != "text/plain" {
+		t.Errorf("ExtractMetadata = %+v, %v; want the metadata once the server listens", meta, err)
+	}
+}
@@ -0,0 +1,45 @@
+package processing
+
+import "unicode"
+
+// DefaultChunkMaxTokens caps the estimated tokens of a chunk when its options
+// set no cap: the embedding model (msmarco-distilbert-base-tas-b) reads at
+// most 512 word pieces, and truncates the rest of a chunk unseen. The cap
+// leaves room for the model's special tokens and the estimate's error.
+const DefaultChunkMaxTokens = 500
+
+// EstimateTokens approximates how many word pieces a subword tokenizer splits
+// s into, erring high: each run of letters and digits counts one token per
+// four characters, and every other visible character — punctuation, symbols,
+// and CJK ideographs, which tokenizers split one per piece — counts one.
+// Whitespace counts nothing, so the estimate of two texts joined by a space is
+// the sum of their estimates.
+func EstimateTokens(s string) int {
+	tokens, run := 0, 0
+	endRun := func() {
+		tokens += (run + 3) / 4
+		run = 0
+	}
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			endRun()
+		case isIdeograph(r):
+			endRun()
+			tokens++
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			run++
+		default:
+			endRun()
+			tokens++
+		}
+	}
+	endRun()
+	return tokens
+}
+
+// isIdeograph reports whether r is from a script written without spaces,
+// which tokenizers split one character at a time.
+func isIdeograph(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul, unicode.Thai)
+}
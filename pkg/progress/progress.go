@@ -0,0 +1,81 @@
+// Package progress lets the library packages report a long-running step
+// without depending on how, or whether, the program shows it. Nothing is shown
+// until a front end installs a spinner with SetSpinner; the CLI and ragd do so
+// through cmd/cli/common. Output printed while steps may be in progress goes
+// through Printf, so the front end can keep it clear of the spinners.
+package progress
+
+import "fmt"
+
+// StartFunc starts showing prefix as a step in progress and returns the
+// function that stops it.
+type StartFunc func(prefix string) (stop func())
+
+var start StartFunc = func(string) func() { return func() {} }
+
+// SetSpinner installs how steps in progress are shown.
+func SetSpinner(fn StartFunc) {
+	start = fn
+}
+
+// Start shows prefix as a step in progress until the returned stop is called.
+func Start(prefix string) (stop func()) {
+	return start(prefix)
+}
+
+// PrintFunc prints formatted output.
+type PrintFunc func(format string, args ...any)
+
+var printf PrintFunc = func(format string, args ...any) { fmt.Printf(format, args...) }
+
+// SetPrinter installs how output is printed while steps may be in progress.
+func SetPrinter(fn PrintFunc) {
+	printf = fn
+}
+
+// Printf prints output that may appear while steps are in progress, such as
+// a line per file of a batch whose next files are extracted concurrently.
+func Printf(format string, args ...any) {
+	printf(format, args...)
+}
+
+var infof PrintFunc = func(format string, args ...any) { printf(format, args...) }
+
+// SetNotifier installs how informational notices are printed.
+func SetNotifier(fn PrintFunc) {
+	infof = fn
+}
+
+// Infof prints an informational notice — the step a long operation is on, a
+// knowledge base it created — which the front end may leave out, as the CLI
+// does under --quiet.
+func Infof(format string, args ...any) {
+	infof(format, args...)
+}
+
+// ReportFunc prints the outcome of one item of a batch: ok is whether it
+// succeeded.
+type ReportFunc func(ok bool, format string, args ...any)
+
+var report ReportFunc = func(ok bool, format string, args ...any) {
+	mark := "✅ "
+	if !ok {
+		mark = "❌ "
+	}
+	printf(mark+format, args...)
+}
+
+// SetReporter installs how the outcomes of batch items are printed.
+func SetReporter(fn ReportFunc) {
+	report = fn
+}
+
+// Succeeded reports that an item of a batch succeeded.
+func Succeeded(format string, args ...any) {
+	report(true, format, args...)
+}
+
+// Failed reports that an item of a batch failed.
+func Failed(format string, args ...any) {
+	report(false, format, args...)
+}
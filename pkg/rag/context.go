@@ -0,0 +1,130 @@
+// Package rag turns knowledge base search hits into grounded prompts: it
+// retrieves hits for a question, renders them as the context block injected
+// into the prompt, and words the system prompts that tell the model how to
+// weigh the knowledge labels on each chunk. The chat REPL, ask, and answer
+// batch are built on it; other Go programs can embed the same retrieval.
+package rag
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+)
+
+// FormatContext renders a slice of search hits into a single text block
+// suitable for injection into a RAG prompt. Each chunk is prefixed with its
+// resolved knowledge label so the LLM can apply the priority rules the active
+// system prompt defines for those labels. `knowledge search --output context`
+// prints the same block for use outside the snap.
+func FormatContext(hits []knowledge.SearchHit) string {
+	var b strings.Builder
+	for i, hit := range hits {
+		if i > 0 {
+			b.WriteString("\n---\n")
+		}
+		fmt.Fprintf(&b, "%s\n", knowledge.LabelTag(hit.Label))
+		b.WriteString(hit.Content)
+		fmt.Fprintf(&b, "\n(source: %s, score: %.4f)", knowledge.Citation(hit.SourceID, hit.Page), hit.Score)
+	}
+	return b.String()
+}
+
+// FormatDelimitedContext renders hits as FormatContext does, but wraps each
+// chunk in <document> and </document> so the model can tell retrieved text
+// from instructions. Pass hits through SanitizeHits first, so no chunk can
+// close its delimiter early.
+func FormatDelimitedContext(hits []knowledge.SearchHit) string {
+	var b strings.Builder
+	for i, hit := range hits {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("<document>\n")
+		b.WriteString(FormatContext([]knowledge.SearchHit{hit}))
+		b.WriteString("\n</document>")
+	}
+	return b.String()
+}
+
+// BuildPrompt wraps the user's original prompt with the retrieved
+// context so the LLM can ground its answer.
+func BuildPrompt(ragContext, prompt string) string {
+	return fmt.Sprintf("Context:\n%s\n\nQuestion: %s", ragContext, prompt)
+}
+
+// BuildGuardedPrompt is BuildPrompt for context from FormatDelimitedContext:
+// ContextGuard follows the context, so the last word before the question
+// is that the documents are data to answer from, not instructions to obey.
+func BuildGuardedPrompt(ragContext, prompt string) string {
+	return fmt.Sprintf("Context:\n%s\n\n%s\n\nQuestion: %s", ragContext, ContextGuard, prompt)
+}
+
+// Sources returns the distinct source ids of hits in retrieval order, for
+// recording which sources grounded a reply. A source with paged hits lists the
+// pages they start on: "manual.pdf (p.3, p.42)".
+func Sources(hits []knowledge.SearchHit) []string {
+	var order []string
+	pages := make(map[string][]int, len(hits))
+	for _, hit := range hits {
+		if hit.SourceID == "" {
+			continue
+		}
+		known, seen := pages[hit.SourceID]
+		if !seen {
+			order = append(order, hit.SourceID)
+		}
+		if hit.Page > 0 && !slices.Contains(known, hit.Page) {
+			known = append(known, hit.Page)
+		}
+		pages[hit.SourceID] = known
+	}
+
+	sources := make([]string, len(order))
+	for i, id := range order {
+		sources[i] = id
+		if p := pages[id]; len(p) > 0 {
+			slices.Sort(p)
+			cited := make([]string, len(p))
+			for j, page := range p {
+				cited[j] = "p." + strconv.Itoa(page)
+			}
+			sources[i] += " (" + strings.Join(cited, ", ") + ")"
+		}
+	}
+	return sources
+}
+
+// DropWeakHits removes the hits scoring below minScore, keeping their order.
+// A zero minScore keeps every hit.
+func DropWeakHits(hits []knowledge.SearchHit, minScore float64) []knowledge.SearchHit {
+	if minScore <= 0 {
+		return hits
+	}
+	kept := hits[:0:0]
+	for _, hit := range hits {
+		if hit.Score >= minScore {
+			kept = append(kept, hit)
+		}
+	}
+	return kept
+}
+
+// StripThinkTags removes <think>...</think> reasoning blocks that
+// reasoning models (e.g. DeepSeek R1) emit before their actual response.
+func StripThinkTags(s string) string {
+	for {
+		start := strings.Index(s, "<think>")
+		if start == -1 {
+			return s
+		}
+		end := strings.Index(s, "</think>")
+		if end == -1 {
+			// Unclosed <think> — drop everything from the tag onward.
+			return s[:start]
+		}
+		s = s[:start] + s[end+len("</think>"):]
+	}
+}
@@ -0,0 +1,73 @@
+package rag
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+)
+
+func TestFormatContext(t *testing.T) {
+	hits := []knowledge.SearchHit{
+		{SourceID: "manual.pdf", Label: "canonical", Score: 0.9, Content: "first", Page: 3},
+		{SourceID: "notes.md", Label: "upstream", Score: 0.5, Content: "second"},
+	}
+	want := "[CANONICAL]\nfirst\n(source: manual.pdf, p.3, score: 0.9000)\n---\n" +
+		"[UPSTREAM]\nsecond\n(source: notes.md, score: 0.5000)"
+	if got := FormatContext(hits); got != want {
+		t.Errorf("FormatContext = %q, want %q", got, want)
+	}
+	if got := FormatContext(nil); got != "" {
+		t.Errorf("FormatContext(nil) = %q, want empty", got)
+	}
+}
+
+func TestSources(t *testing.T) {
+	hits := []knowledge.SearchHit{
+		{SourceID: "manual.pdf", Page: 42},
+		{SourceID: "notes.md"},
+		{SourceID: "manual.pdf", Page: 3},
+		{SourceID: "manual.pdf", Page: 42},
+		{SourceID: ""},
+	}
+	want := []string{"manual.pdf (p.3, p.42)", "notes.md"}
+	if got := Sources(hits); !reflect.DeepEqual(got, want) {
+		t.Errorf("Sources = %q, want %q", got, want)
+	}
+}
+
+func TestDropWeakHits(t *testing.T) {
+	hits := []knowledge.SearchHit{
+		{SourceID: "a", Score: 0.5, Content: strings.Repeat("a", 100)},
+		{SourceID: "b", Score: 0.9, Content: strings.Repeat("b", 100)},
+		{SourceID: "c", Score: 0.1, Content: strings.Repeat("c", 100)},
+	}
+	if got := DropWeakHits(hits, 0); len(got) != len(hits) {
+		t.Errorf("DropWeakHits with no floor kept %d of %d hits", len(got), len(hits))
+	}
+	got := DropWeakHits(hits, 0.5)
+	if len(got) != 2 || got[0].SourceID != "a" || got[1].SourceID != "b" {
+		t.Errorf("DropWeakHits(0.5) = %+v, want a and b in order", got)
+	}
+	if hits[2].SourceID != "c" {
+		t.Error("DropWeakHits modified its input")
+	}
+	if got := DropWeakHits([]knowledge.SearchHit{{Score: 0.1}}, 0.2); len(got) != 0 {
+		t.Errorf("DropWeakHits kept %d hits below the floor", len(got))
+	}
+}
+
+func TestStripThinkTags(t *testing.T) {
+	tests := map[string]string{
+		"answer":                              "answer",
+		"<think>hmm</think>answer":            "answer",
+		"a<think>x</think>b<think>y</think>c": "abc",
+		"answer<think>unclosed":               "answer",
+	}
+	for in, want := range tests {
+		if got := StripThinkTags(in); got != want {
+			t.Errorf("StripThinkTags(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
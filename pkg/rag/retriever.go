@@ -0,0 +1,41 @@
+package rag
+
+import (
+	"context"
+
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+)
+
+// DefaultTopK is the number of hits a Retriever fetches when TopK is unset.
+const DefaultTopK = 15
+
+// Retriever searches a set of knowledge bases for the context of a question.
+type Retriever struct {
+	Client *knowledge.OpenSearchClient
+	// Indexes are the full index names of the knowledge bases searched.
+	Indexes []string
+	// EmbeddingModelID is the model 'knowledge init' deployed for semantic
+	// search.
+	EmbeddingModelID string
+	// TopK is the number of hits fetched; 0 is DefaultTopK.
+	TopK int
+	// MinScore drops hits scoring below it; 0 keeps every hit.
+	MinScore float64
+	// Filter narrows the search by tags and ingest time.
+	Filter knowledge.SearchOptions
+}
+
+// Retrieve returns the hits for query, best first. lexicalQuery is matched by
+// keywords alongside the semantic search; pass query again when there is no
+// separate keyword form.
+func (r Retriever) Retrieve(ctx context.Context, query, lexicalQuery string) ([]knowledge.SearchHit, error) {
+	k := r.TopK
+	if k <= 0 {
+		k = DefaultTopK
+	}
+	hits, err := r.Client.SearchWithOptions(ctx, r.Indexes, query, lexicalQuery, r.EmbeddingModelID, k, r.Filter)
+	if err != nil {
+		return nil, err
+	}
+	return DropWeakHits(hits, r.MinScore), nil
+}
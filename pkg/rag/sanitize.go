@@ -0,0 +1,65 @@
+package rag
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/jpnorenam/rag-snap/pkg/knowledge"
+)
+
+// directivePatterns match lines of retrieved content that address the model
+// rather than the reader: attempts to cancel its instructions, reassign its
+// role, or extract its prompt, and the chat template markers used to fake a
+// new turn. They are kept narrow, so documentation about prompts survives.
+var directivePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override|bypass)\b.{0,40}\b(previous|prior|above|earlier|preceding|all|any|your|the)\b.{0,40}\b(instructions?|prompts?|rules|guidelines|directions|directives)\b`),
+	regexp.MustCompile(`(?i)\b(new|updated|revised|real)\s+(system\s+)?(instructions?|prompt|directives?)\s*:`),
+	regexp.MustCompile(`(?i)\byou\s+are\s+(now|no\s+longer)\b`),
+	regexp.MustCompile(`(?i)\b(reveal|print|repeat|output|show|leak)\b.{0,30}\b(system\s+prompt|your\s+(instructions|prompt|rules))\b`),
+	regexp.MustCompile(`(?i)^\s*(system|assistant)\s*:`),
+	regexp.MustCompile(`(?i)<\|?(im_start|im_end|system|endoftext)\|?>|\[/?INST\]|<</?SYS>>`),
+}
+
+// documentTag matches the delimiters FormatDelimitedContext wraps chunks in, so
+// a chunk cannot close its own delimiter and pose as instructions outside it.
+var documentTag = regexp.MustCompile(`(?i)</?\s*document\s*>`)
+
+// SanitizedLine is a line SanitizeHits removed from a hit.
+type SanitizedLine struct {
+	SourceID string
+	Line     string
+}
+
+// SanitizeHits returns copies of hits with directive-looking lines removed
+// and document delimiters escaped, along with the lines it removed. Ingested
+// web pages can carry text written to hijack the model ("ignore previous
+// instructions"); it is of no use to an answer, so it never reaches the
+// prompt. hits is not modified.
+func SanitizeHits(hits []knowledge.SearchHit) ([]knowledge.SearchHit, []SanitizedLine) {
+	var removed []SanitizedLine
+	clean := make([]knowledge.SearchHit, len(hits))
+	for i, hit := range hits {
+		lines := strings.Split(hit.Content, "\n")
+		kept := lines[:0:0]
+		for _, line := range lines {
+			if isDirective(line) {
+				removed = append(removed, SanitizedLine{SourceID: hit.SourceID, Line: strings.TrimSpace(line)})
+				continue
+			}
+			kept = append(kept, line)
+		}
+		hit.Content = documentTag.ReplaceAllString(strings.Join(kept, "\n"), "[document]")
+		clean[i] = hit
+	}
+	return clean, removed
+}
+
+// isDirective reports whether line matches one of directivePatterns.
+func isDirective(line string) bool {
+	for _, p := range directivePatterns {
+		if p.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
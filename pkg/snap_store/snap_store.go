@@ -8,6 +8,8 @@ import (
 	"os"
 	"strconv"
 	"strings"
+
+	"github.com/jpnorenam/rag-snap/pkg/httpclient"
 )
 
 func ComponentSizes() (map[string]int64, error) {
@@ -70,7 +72,7 @@ func snapInfo(snapName string) (*snapInfoResponse, error) {
 	req.Header.Add("Snap-Device-Series", "16")
 	req.Header.Add("Accept", "application/json")
 
-	client := &http.Client{}
+	client := httpclient.New(0)
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error making HTTP request: %v", err)
@@ -151,7 +153,7 @@ func snapRefresh(snapId string, revision int, snapArch string) (*snapRefreshResp
 	req.Header.Add("Accept", "application/json")
 	req.Header.Add("Content-Type", "application/json")
 
-	client := &http.Client{}
+	client := httpclient.New(0)
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error making HTTP request: %v", err)
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Services whose readiness is recorded. The backend names match the keys of
+// the server API endpoints.
+const (
+	ServiceOpenSearch = "opensearch"
+	ServiceOpenAI     = "openai"
+	ServiceTika       = "tika"
+	ServiceRagd       = "ragd"
+)
+
+// readinessKeyPrefix is the snapctl key readiness is recorded under, apart
+// from the config layers so it is never mistaken for configuration.
+const readinessKeyPrefix = "readiness"
+
+// Readiness is the registry of when each of the snap's services became ready.
+// Services record themselves, or a process that has just seen one answer
+// records it on its behalf; the CLI, the hooks, and the run wrapper consult it
+// instead of each waiting on the services with a timeout of its own. Recording
+// needs snapctl set, which only root may run, so callers outside the services
+// treat it as best-effort.
+type Readiness struct {
+	storage storage
+}
+
+func NewReadiness() *Readiness {
+	return &Readiness{
+		storage: NewSnapctlStorage(), // hardcoded since that's the only supported backend
+	}
+}
+
+// MarkReady records that service became ready at at.
+func (r *Readiness) MarkReady(service string, at time.Time) error {
+	return r.storage.Set(readinessKey(service), at.UTC().Format(time.RFC3339))
+}
+
+// MarkNotReady removes service's record, after it stopped or was seen down.
+func (r *Readiness) MarkNotReady(service string) error {
+	return r.storage.Unset(readinessKey(service))
+}
+
+// All returns when each service recorded as ready became so. A record that
+// is not a timestamp is left out.
+func (r *Readiness) All() (map[string]time.Time, error) {
+	values, err := r.storage.Get(readinessKeyPrefix)
+	if errors.Is(err, ErrorNotFound) {
+		return map[string]time.Time{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ready := make(map[string]time.Time, len(values))
+	for service, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			continue
+		}
+		ready[service] = at
+	}
+	return ready, nil
+}
+
+// NotReady returns the services, out of the given ones, that have no
+// readiness record, sorted.
+func (r *Readiness) NotReady(services []string) ([]string, error) {
+	ready, err := r.All()
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for _, service := range services {
+		if _, ok := ready[service]; !ok {
+			missing = append(missing, service)
+		}
+	}
+	sort.Strings(missing)
+	return missing, nil
+}
+
+func readinessKey(service string) string {
+	return fmt.Sprintf("%s.%s", readinessKeyPrefix, service)
+}
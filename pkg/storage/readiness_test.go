@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memStorage is an in-memory storage backend for keys one level under a
+// prefix, answering a read of the prefix with the entries nested under it.
+// Documents are kept decoded, as snapctl get returns them.
+type memStorage struct {
+	values map[string]any
+}
+
+func (s *memStorage) Set(key, value string) error {
+	s.values[key] = value
+	return nil
+}
+
+func (s *memStorage) SetDocument(key string, value any) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	var decoded any
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return err
+	}
+	s.values[key] = decoded
+	return nil
+}
+
+func (s *memStorage) Unset(key string) error {
+	delete(s.values, key)
+	return nil
+}
+
+func (s *memStorage) Get(key string) (map[string]any, error) {
+	nested := map[string]any{}
+	for k, v := range s.values {
+		if name, ok := strings.CutPrefix(k, key+"."); ok {
+			nested[name] = v
+		}
+	}
+	if len(nested) == 0 {
+		return nil, ErrorNotFound
+	}
+	return nested, nil
+}
+
+func TestReadiness(t *testing.T) {
+	r := &Readiness{storage: &memStorage{values: map[string]any{}}}
+
+	ready, err := r.All()
+	if err != nil || len(ready) != 0 {
+		t.Fatalf("All on an empty registry = %v, %v; want empty", ready, err)
+	}
+
+	at := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := r.MarkReady(ServiceTika, at); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.MarkReady(ServiceOpenSearch, at.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	ready, err = r.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ready[ServiceTika].Equal(at) || !ready[ServiceOpenSearch].Equal(at.Add(time.Minute)) {
+		t.Errorf("All = %v", ready)
+	}
+
+	missing, err := r.NotReady([]string{ServiceTika, ServiceRagd, ServiceOpenAI})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{ServiceOpenAI, ServiceRagd}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("NotReady = %v, want %v", missing, want)
+	}
+
+	if err := r.MarkNotReady(ServiceTika); err != nil {
+		t.Fatal(err)
+	}
+	if missing, _ := r.NotReady([]string{ServiceTika}); len(missing) != 1 {
+		t.Errorf("NotReady after MarkNotReady = %v, want [tika]", missing)
+	}
+}
+
+func TestReadinessSkipsMalformedRecords(t *testing.T) {
+	r := &Readiness{storage: &memStorage{values: map[string]any{
+		"readiness.tika":       "yesterday",
+		"readiness.opensearch": "2026-05-01T12:00:00Z",
+	}}}
+	ready, err := r.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ready[ServiceTika]; ok || len(ready) != 1 {
+		t.Errorf("All = %v, want only opensearch", ready)
+	}
+}
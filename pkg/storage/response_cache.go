@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// responseCacheExt is the extension of the files ResponseCache stores
+// entries in, one per key.
+const responseCacheExt = ".json"
+
+// CachedResponse is an answer stored in a ResponseCache.
+type CachedResponse struct {
+	Answer  string    `json:"answer"`
+	Sources []string  `json:"sources,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+// ResponseCache stores chat answers by key for a limited time, so a prompt
+// repeated word for word is answered without the inference server. Unlike
+// the snapctl backed stores it is written on every answer, by whichever user
+// chats, so it keeps one owner-only file per entry in a directory of theirs.
+type ResponseCache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+// NewResponseCache returns a cache keeping entries in dir for ttl. The
+// directory is created on the first Put.
+func NewResponseCache(dir string, ttl time.Duration) *ResponseCache {
+	return &ResponseCache{dir: dir, ttl: ttl, now: time.Now}
+}
+
+// ResponseCacheKey derives a cache key from the parts an answer depends on.
+// Parts are length-prefixed, so no two different lists share a key.
+func ResponseCacheKey(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		fmt.Fprintf(h, "%d:%s", len(p), p)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns the entry stored under key, if there is one younger than the
+// cache's TTL. An expired entry is removed.
+func (c *ResponseCache) Get(key string) (CachedResponse, bool) {
+	path := c.path(key)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return CachedResponse{}, false
+	}
+	var resp CachedResponse
+	if err := json.Unmarshal(b, &resp); err != nil {
+		os.Remove(path)
+		return CachedResponse{}, false
+	}
+	if c.expired(resp) {
+		os.Remove(path)
+		return CachedResponse{}, false
+	}
+	return resp, true
+}
+
+// Put stores resp under key, stamped with the current time, and removes the
+// entries that have expired since.
+func (c *ResponseCache) Put(key string, resp CachedResponse) error {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return fmt.Errorf("creating response cache directory: %w", err)
+	}
+	resp.Created = c.now()
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("encoding cached response: %w", err)
+	}
+
+	// Write to a temporary file and rename it into place, so a concurrent Get
+	// never reads half an entry.
+	tmp, err := os.CreateTemp(c.dir, key+"-*")
+	if err != nil {
+		return fmt.Errorf("creating cached response: %w", err)
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing cached response: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing cached response: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("storing cached response: %w", err)
+	}
+
+	c.prune()
+	return nil
+}
+
+// Clear removes every entry.
+func (c *ResponseCache) Clear() error {
+	entries, err := os.ReadDir(c.dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading response cache directory: %w", err)
+	}
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), responseCacheExt) {
+			if err := os.Remove(filepath.Join(c.dir, e.Name())); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("removing cached response: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// prune removes the entries whose files were last written more than a TTL
+// ago. Failures are ignored: an entry left behind is still expired on Get.
+func (c *ResponseCache) prune() {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), responseCacheExt) {
+			continue
+		}
+		if info, err := e.Info(); err == nil && c.now().Sub(info.ModTime()) > c.ttl {
+			os.Remove(filepath.Join(c.dir, e.Name()))
+		}
+	}
+}
+
+func (c *ResponseCache) expired(resp CachedResponse) bool {
+	return c.now().Sub(resp.Created) > c.ttl
+}
+
+func (c *ResponseCache) path(key string) string {
+	return filepath.Join(c.dir, key+responseCacheExt)
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestResponseCache(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	c := NewResponseCache(t.TempDir(), time.Hour)
+	c.now = func() time.Time { return now }
+
+	key := ResponseCacheKey("model", "What is MicroCloud?", "docs", "ctx")
+	if _, ok := c.Get(key); ok {
+		t.Fatal("Get on an empty cache found an entry")
+	}
+
+	want := CachedResponse{Answer: "A small cloud.", Sources: []string{"guide.md"}}
+	if err := c.Put(key, want); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := c.Get(key)
+	want.Created = now
+	if !ok || !reflect.DeepEqual(got, want) {
+		t.Errorf("Get = %+v, %v; want %+v, true", got, ok, want)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, ok := c.Get(key); ok {
+		t.Error("Get returned an entry older than the TTL")
+	}
+
+	if err := c.Put(key, want); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get(key); ok {
+		t.Error("Get found an entry after Clear")
+	}
+}
+
+func TestResponseCacheKey(t *testing.T) {
+	if ResponseCacheKey("ab", "c") == ResponseCacheKey("a", "bc") {
+		t.Error("keys of different parts collide")
+	}
+	if ResponseCacheKey("a", "b") != ResponseCacheKey("a", "b") {
+		t.Error("keys of the same parts differ")
+	}
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// savedSearchKeyPrefix is the snapctl key saved searches are stored under,
+// apart from the config layers like the readiness registry.
+const savedSearchKeyPrefix = "saved-search"
+
+// savedSearchNameRe matches the names a saved search may have: they become a
+// snapctl key segment, which only allows lowercase letters, digits, and
+// single dashes between them.
+var savedSearchNameRe = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// SavedSearch is a knowledge base query stored under a name to be replayed.
+// Filters are key=value metadata tags, as given to knowledge search --filter.
+type SavedSearch struct {
+	Query   string   `json:"query"`
+	Bases   []string `json:"bases,omitempty"`
+	Filters []string `json:"filters,omitempty"`
+	TopK    int      `json:"top-k,omitempty"`
+}
+
+// SavedSearches stores named knowledge base searches. Storing needs snapctl
+// set, which only root may run; any user may list and run them.
+type SavedSearches struct {
+	storage storage
+}
+
+func NewSavedSearches() *SavedSearches {
+	return &SavedSearches{
+		storage: NewSnapctlStorage(), // hardcoded since that's the only supported backend
+	}
+}
+
+// ValidateSavedSearchName reports why name cannot name a saved search, if it
+// cannot.
+func ValidateSavedSearchName(name string) error {
+	if !savedSearchNameRe.MatchString(name) {
+		return fmt.Errorf("invalid saved search name %q: use lowercase letters, digits, and dashes", name)
+	}
+	return nil
+}
+
+// Save stores s under name, replacing any saved search of that name.
+func (s *SavedSearches) Save(name string, search SavedSearch) error {
+	if err := ValidateSavedSearchName(name); err != nil {
+		return err
+	}
+	return s.storage.SetDocument(savedSearchKey(name), search)
+}
+
+// Get returns the saved search stored under name, or ErrorNotFound.
+func (s *SavedSearches) Get(name string) (SavedSearch, error) {
+	if err := ValidateSavedSearchName(name); err != nil {
+		return SavedSearch{}, err
+	}
+	all, err := s.List()
+	if err != nil {
+		return SavedSearch{}, err
+	}
+	search, ok := all[name]
+	if !ok {
+		return SavedSearch{}, ErrorNotFound
+	}
+	return search, nil
+}
+
+// List returns every saved search by name. A record that does not decode as
+// a saved search is left out.
+func (s *SavedSearches) List() (map[string]SavedSearch, error) {
+	values, err := s.storage.Get(savedSearchKeyPrefix)
+	if errors.Is(err, ErrorNotFound) {
+		return map[string]SavedSearch{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	searches := make(map[string]SavedSearch, len(values))
+	for name, v := range values {
+		// The record comes back as decoded JSON; round-trip it into the struct.
+		b, err := json.Marshal(v)
+		if err != nil {
+			continue
+		}
+		var search SavedSearch
+		if err := json.Unmarshal(b, &search); err != nil || search.Query == "" {
+			continue
+		}
+		searches[name] = search
+	}
+	return searches, nil
+}
+
+// Delete removes the saved search stored under name.
+func (s *SavedSearches) Delete(name string) error {
+	if _, err := s.Get(name); err != nil {
+		return err
+	}
+	return s.storage.Unset(savedSearchKey(name))
+}
+
+func savedSearchKey(name string) string {
+	return fmt.Sprintf("%s.%s", savedSearchKeyPrefix, name)
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSavedSearches(t *testing.T) {
+	s := &SavedSearches{storage: &memStorage{values: map[string]any{}}}
+
+	if all, err := s.List(); err != nil || len(all) != 0 {
+		t.Fatalf("List on an empty store = %v, %v; want empty", all, err)
+	}
+
+	want := SavedSearch{
+		Query:   "open CVEs",
+		Bases:   []string{"advisories"},
+		Filters: []string{"status=open"},
+		TopK:    20,
+	}
+	if err := s.Save("open-cves", want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Get("open-cves")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Get = %+v, want %+v", got, want)
+	}
+
+	if _, err := s.Get("missing"); !errors.Is(err, ErrorNotFound) {
+		t.Errorf("Get of a missing search: err = %v, want ErrorNotFound", err)
+	}
+
+	if err := s.Delete("open-cves"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete("open-cves"); !errors.Is(err, ErrorNotFound) {
+		t.Errorf("second Delete: err = %v, want ErrorNotFound", err)
+	}
+}
+
+func TestValidateSavedSearchName(t *testing.T) {
+	for name, valid := range map[string]bool{
+		"open-cves": true,
+		"q3":        true,
+		"Open-CVEs": false,
+		"open_cves": false,
+		"-open":     false,
+		"open--cve": false,
+		"":          false,
+	} {
+		if err := ValidateSavedSearchName(name); (err == nil) != valid {
+			t.Errorf("ValidateSavedSearchName(%q) = %v, want valid %v", name, err, valid)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+// Package suggest words the next steps shown with errors about the snap's
+// services, naming the services by the installed snap instance.
+package suggest
+
+import (
+	"fmt"
+
+	"github.com/canonical/go-snapctl/env"
+)
+
+// instanceName returns the snap instance name, or a placeholder outside a snap.
+func instanceName() string {
+	if name := env.SnapInstanceName(); name != "" {
+		return name
+	}
+	return "<snap-instance-name>"
+}
+
+// DaemonServiceName returns the snap service name of the ragd daemon
+// (e.g. "rag-cli.ragd").
+func DaemonServiceName() string {
+	return instanceName() + ".ragd"
+}
+
+func ServerStartup() string {
+	return "Try again when the server is ready."
+}
+
+func ServerLogs() string {
+	return fmt.Sprintf("Run \"snap logs %s\" to see the server logs.", DaemonServiceName())
+}
+
+// TikaLogs points at the tika-server service logs, for when extraction fails
+// because Tika never became ready.
+func TikaLogs() string {
+	name := instanceName()
+	return fmt.Sprintf("Run \"snap logs %s.tika-server\" to see the Tika logs, or \"sudo snap start %s.tika-server\" if it is not running.", name, name)
+}